@@ -0,0 +1,88 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: accessible.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the accessible mode interface, a linear alternative to the TUI
+//    intended for use with terminal screen readers.
+//
+// =============================================================================
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/library"
+)
+
+// local unexported constants for the accessible mode interface.
+const (
+	announcePrefix = "status: " // labels every announcement
+)
+
+// the announcer used by all units in accessible mode (nil otherwise).
+var announcer *Announcer = nil
+
+// type Announcer prints a single, clearly labeled line for each change in the
+// program's state. it is the accessible mode replacement for the visual status
+// indicators (spinners, counters, etc.) of the TUI.
+type Announcer struct {
+	busy *library.BusyState // busy state to monitor (may be nil)
+	lock sync.Mutex         // serializes announcements
+	last string             // most recent announcement
+}
+
+// function newAnnouncer() creates a new Announcer that will report on changes
+// to the given busy state once listen() is called.
+func newAnnouncer(busy *library.BusyState) *Announcer {
+	return &Announcer{busy: busy, last: ""}
+}
+
+// function announce() prints the given printf-style message. consecutive
+// duplicate announcements are suppressed so that a screen reader doesn't
+// repeat itself.
+func (a *Announcer) announce(format string, v ...interface{}) {
+
+	msg := fmt.Sprintf(format, v...)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if msg != a.last {
+		a.last = msg
+		console.Raw.Log(announcePrefix + msg)
+	}
+}
+
+// function listen() announces each transition between the busy and idle state,
+// along with the tasks keeping the program busy. their progress isn't
+// announced, which a screen reader would be reading constantly (see -progress). this should be called in its
+// own goroutine, it returns only once unsubscribed from the busy state.
+func (a *Announcer) listen() {
+
+	if nil == a.busy {
+		return
+	}
+
+	for change := range a.busy.Subscribe() {
+		switch change.Count {
+		case 0:
+			a.announce("ready")
+		case 1:
+			a.announce("working: %s", change.Tasks[0].Label)
+		default:
+			label := make([]string, len(change.Tasks))
+			for i, t := range change.Tasks {
+				label[i] = t.Label
+			}
+			a.announce("working on %d tasks: %s", change.Count, strings.Join(label, ", "))
+		}
+	}
+}
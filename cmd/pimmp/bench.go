@@ -0,0 +1,224 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: bench.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    runs the scan passes of the "bench" subcommand, each with different numbers
+//    of workers and sizes of database buffers, reporting how fast each examined
+//    the files of a library and how much it allocated, and suggesting the
+//    fastest settings.
+//
+// =============================================================================
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/library"
+	"ardnew.com/pimmp/pkg/pimmp"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/report"
+	"ardnew.com/pimmp/pkg/storage"
+)
+
+// constant benchTolerance is the fraction of the fastest rate within which the
+// scan passes of a benchmark are considered equally fast, the one allocating
+// the least of them then being suggested.
+const benchTolerance = 0.05
+
+// type benchSettings lists the settings compared by a benchmark, each scan pass
+// run with one combination of them.
+type benchSettings struct {
+	probers []int // numbers of ffprobe processes run at once
+	disk    []int // sizes (bytes) of the database's disk buffers
+	hash    []int // sizes (bytes) of the database's hash table growth (empty = disk/4)
+	passes  int   // number of passes run with each combination, the fastest kept
+	warmup  bool  // run an unmeasured pass first, so every pass finds the files cached
+}
+
+// function parseBenchSettings() parses the comma-separated lists of probers and
+// buffer sizes given to the "bench" subcommand. the buffer sizes are numbers
+// optionally followed by a unit (see library.ParseSize()). empty lists of disk
+// buffer sizes compare half, once, and twice the default size.
+func parseBenchSettings(probers, disk, hash string, passes uint, warmup bool) (*benchSettings, *rc.ReturnCode) {
+
+	s := &benchSettings{passes: int(passes), warmup: warmup}
+	if s.passes < 1 {
+		s.passes = 1
+	}
+	for _, p := range splitList(probers) {
+		n, err := strconv.Atoi(p)
+		if nil != err || n < 0 {
+			return nil, rc.InvalidArgs.Specf("invalid number of probers: %q", p)
+		}
+		s.probers = append(s.probers, n)
+	}
+	if 0 == len(s.probers) {
+		s.probers = []int{0}
+	}
+	sizes := func(list string) ([]int, *rc.ReturnCode) {
+		size := []int{}
+		for _, e := range splitList(list) {
+			n, ret := library.ParseSize(e)
+			if nil != ret {
+				return nil, ret
+			}
+			if n <= 0 {
+				return nil, rc.InvalidArgs.Specf("invalid buffer size: %q", e)
+			}
+			size = append(size, int(n))
+		}
+		return size, nil
+	}
+	var ret *rc.ReturnCode
+	if s.disk, ret = sizes(disk); nil != ret {
+		return nil, ret
+	}
+	if 0 == len(s.disk) {
+		s.disk = []int{storage.DefaultDiskBufferSize / 2,
+			storage.DefaultDiskBufferSize, storage.DefaultDiskBufferSize * 2}
+	}
+	if s.hash, ret = sizes(hash); nil != ret {
+		return nil, ret
+	}
+	return s, nil
+}
+
+// function benchScan() scans the library at the given path once with each
+// combination of the given settings, each time into a new, temporary database
+// so that every file is found as new, and reports the rate at which each pass
+// examined files and the memory it allocated. the settings of the fastest pass
+// (or of the one allocating the least of those nearly as fast) are suggested.
+// the report is written in the named format. any profiling requested by the
+// global options (e.g. -cpuprofile) spans every pass.
+func benchScan(options *Options, path string, settings *benchSettings, formatName string) *rc.ReturnCode {
+
+	format, ret := report.ParseFormat(formatName)
+	if nil != ret {
+		return ret
+	}
+	interruptOnSignal(options)
+	probeVideo := scanProbe(options)
+
+	// run a single scan pass with the given settings.
+	pass := func(probers, disk, hash int) (report.BenchRun, *rc.ReturnCode) {
+
+		run := report.BenchRun{Probers: probers, DiskBufferSize: disk, HashBufferSize: hash}
+		dir, err := ioutil.TempDir("", "pimmp-bench-")
+		if nil != err {
+			return run, rc.InvalidPath.Specf("benchScan(%q): ioutil.TempDir(): %s", path, err)
+		}
+		defer os.RemoveAll(dir)
+
+		cfg := pimmp.NewConfig(dir)
+		cfg.Database = &storage.Config{
+			Engine:         options.DBEngine.string,
+			DiskBufferSize: disk,
+			HashBufferSize: hash,
+			Provided:       []string{storage.DiskBufferSizeOption, storage.HashBufferSizeOption},
+		}
+		cfg.MaxDepth = options.maxDepth
+		cfg.Limits = library.Limits{Probes: probers}
+		cfg.MinSize = options.MinSize.string
+		cfg.Exclude = splitList(options.Exclude.string)
+		cfg.FollowLinks = options.FollowLinks.bool
+		cfg.ReadMetadata = !options.NoMetadata.bool
+		eng, ret := pimmp.New(cfg, path)
+		if nil != ret {
+			return run, ret
+		}
+		defer eng.Close()
+		for _, l := range eng.Libraries() {
+			l.SetProbe(probeVideo)
+		}
+		// the bus delivers events synchronously, from the scanner's goroutine.
+		eng.Bus().Subscribe(func(library.Event) { run.Files++ },
+			library.MediaDiscovered, library.SupportDiscovered, library.OtherDiscovered)
+
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		run.Media, ret = eng.Scan(options.ctx)
+		run.Elapsed = time.Since(start)
+		runtime.ReadMemStats(&after)
+		run.Bytes = after.TotalAlloc - before.TotalAlloc
+		run.Allocs = after.Mallocs - before.Mallocs
+		return run, ret
+	}
+
+	if settings.warmup {
+		console.Info.Logf("warming up: %q", path)
+		if _, ret := pass(settings.probers[0], settings.disk[0], settings.disk[0]/4); nil != ret {
+			return ret
+		}
+	}
+
+	runs := []report.BenchRun{}
+	for _, probers := range settings.probers {
+		for _, disk := range settings.disk {
+			hash := settings.hash
+			if 0 == len(hash) {
+				hash = []int{disk / 4}
+			}
+			for _, h := range hash {
+				var best report.BenchRun
+				for i := 0; i < settings.passes; i++ {
+					console.Info.Logf("scanning: probers=%d %s=%d %s=%d (pass %d of %d)",
+						probers, storage.DiskBufferSizeOption, disk, storage.HashBufferSizeOption, h,
+						i+1, settings.passes)
+					run, ret := pass(probers, disk, h)
+					if nil != ret {
+						return ret
+					}
+					if 0 == i || run.FilesPerSec() > best.FilesPerSec() {
+						best = run
+					}
+				}
+				runs = append(runs, best)
+			}
+		}
+	}
+
+	w, _, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+
+	if ret := report.Bench(runs).Write(w, format); nil != ret {
+		return ret
+	}
+
+	// suggest the settings of the pass allocating the least of those nearly as
+	// fast as the fastest.
+	var fastest float64
+	for _, r := range runs {
+		if r.FilesPerSec() > fastest {
+			fastest = r.FilesPerSec()
+		}
+	}
+	var suggest *report.BenchRun
+	for i := range runs {
+		if runs[i].FilesPerSec() >= fastest*(1-benchTolerance) &&
+			(nil == suggest || runs[i].Bytes < suggest.Bytes) {
+			suggest = &runs[i]
+		}
+	}
+	if nil != suggest {
+		console.Info.Logf("suggested settings: -%s=%d -%s=%d -%s=%d (%.1f files/s)",
+			options.Probers.name, suggest.Probers,
+			storage.DiskBufferSizeOption, suggest.DiskBufferSize,
+			storage.HashBufferSizeOption, suggest.HashBufferSize, suggest.FilesPerSec())
+	}
+	return nil
+}
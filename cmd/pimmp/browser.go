@@ -20,6 +20,10 @@ import (
 
 	"github.com/gdamore/tcell"
 	"github.com/rivo/tview"
+
+	"ardnew.com/pimmp/pkg/collection"
+	"ardnew.com/pimmp/pkg/library"
+	"ardnew.com/pimmp/pkg/media"
 )
 
 // local unexported constants for the Browser primitive.
@@ -29,12 +33,12 @@ const (
 
 // mediaItem represents one Media object in a Browser.
 type mediaItem struct {
-	*Media                 // the corresponding Media item represented by this object.
-	SourceLibrary *Library // the Library collection in which this item was found.
-	Owner         *Browser // the Browser list in which this item is a member.
-	MainText      string   // The main text of the list item.
-	SecondaryText string   // A secondary text to be shown underneath the main text.
-	Selected      func()   // The optional function which is called when the item is selected.
+	*media.Media                   // the corresponding Media item represented by this object.
+	SourceLibrary *library.Library // the Library collection in which this item was found.
+	Owner         *Browser         // the Browser list in which this item is a member.
+	MainText      string           // The main text of the list item.
+	SecondaryText string           // A secondary text to be shown underneath the main text.
+	Selected      func()           // The optional function which is called when the item is selected.
 }
 
 // function isValidIndex() checks if a given index is valid (in-range) for the
@@ -186,7 +190,7 @@ func newBrowser() *Browser {
 // then only the items which are members of that library will be displayed. if
 // a nil value is provided (the default), then all data items from all libraries
 // are displayed.
-func (l *Browser) showLibrary(library *Library) {
+func (l *Browser) showLibrary(lib *library.Library) {
 
 	// create a single slice containing -all- items for simpler traversal of all
 	// candidates.
@@ -195,7 +199,7 @@ func (l *Browser) showLibrary(library *Library) {
 	allItems = append(allItems, l.visibleItem...)
 
 	// check if we are intending to filter the items
-	if nil == library {
+	if nil == lib {
 		// a nil library means no filtering, display all data items from all
 		// libraries.
 		for _, m := range allItems {
@@ -227,7 +231,7 @@ func (l *Browser) showLibrary(library *Library) {
 		//
 		for i := len(allItems) - 1; i >= 0; i-- {
 			m := allItems[i]
-			if m.SourceLibrary != library {
+			if m.SourceLibrary != lib {
 				m.hideItem()
 			} else {
 				m.showItem()
@@ -236,6 +240,49 @@ func (l *Browser) showLibrary(library *Library) {
 	}
 }
 
+// function showCollection() filters the list of data items shown in the Browser
+// to only those which are members of the given Collection, from any library.
+// see showLibrary() for why the items are traversed in reverse.
+func (l *Browser) showCollection(col *collection.Collection) {
+	l.showMatching(func(m *mediaItem) bool { return col.Contains(m.Media) })
+}
+
+// function showMatching() filters the items displayed to only those accepted
+// by the given function, which may be in any library.
+func (l *Browser) showMatching(accept func(*mediaItem) bool) {
+
+	allItems := []*mediaItem{}
+	allItems = append(allItems, l.hiddenItem...)
+	allItems = append(allItems, l.visibleItem...)
+
+	for i := len(allItems) - 1; i >= 0; i-- {
+		m := allItems[i]
+		if accept(m) {
+			m.showItem()
+		} else {
+			m.hideItem()
+		}
+	}
+}
+
+// function countVisible() returns the number of visible items of each kind of
+// media.
+func (l *Browser) countVisible() (numVideo, numAudio, numImage, numDocument uint) {
+	for _, m := range l.visibleItem {
+		switch m.Kind {
+		case media.KindVideo:
+			numVideo++
+		case media.KindAudio:
+			numAudio++
+		case media.KindImage:
+			numImage++
+		case media.KindDocument:
+			numDocument++
+		}
+	}
+	return numVideo, numAudio, numImage, numDocument
+}
+
 // setCurrentItem sets the currently selected item by its index. This triggers
 // a "changed" event.
 func (l *Browser) setCurrentItem(index int) *Browser {
@@ -349,7 +396,7 @@ func (l *Browser) removeItem(index int) *Browser {
 		} else if l.currentItem >= length {
 			l.currentItem = length - 1
 		}
-		if nil != l.changed {
+		if nil != l.changed && length > 0 {
 			item := l.visibleItem[l.currentItem]
 			l.changed(l.currentItem, item.MainText, item.SecondaryText)
 		}
@@ -358,12 +405,28 @@ func (l *Browser) removeItem(index int) *Browser {
 	return l
 }
 
+// function removeMediaPath() removes the item of the media at the given path,
+// found in the given library, whether the item is visible or hidden.
+func (l *Browser) removeMediaPath(lib *library.Library, absPath string) {
+
+	for i := len(l.hiddenItem) - 1; i >= 0; i-- {
+		if m := l.hiddenItem[i]; m.SourceLibrary == lib && m.AbsPath == absPath {
+			l.hiddenItem = append(l.hiddenItem[:i], l.hiddenItem[i+1:]...)
+		}
+	}
+	for i := len(l.visibleItem) - 1; i >= 0; i-- {
+		if m := l.visibleItem[i]; m.SourceLibrary == lib && m.AbsPath == absPath {
+			l.removeItem(i)
+		}
+	}
+}
+
 // function positionForMediaItem() iterates over the visible items in the media
 // item browser to decide which position the provided media item name and path
 // should be inserted and formats the text to be displayed in both primary and
 // secondary text strings. this method effectively provides the sorting order of
 // the media item library.
-func (l *Browser) positionForMediaItem(media *Media) (int, string, string) {
+func (l *Browser) positionForMediaItem(item *media.Media) (int, string, string) {
 
 	// determines WHEN the discovered item (discoName, discoPath) should be
 	// inserted based on the current item (currName, currPath) iteration.
@@ -377,11 +440,11 @@ func (l *Browser) positionForMediaItem(media *Media) (int, string, string) {
 	}
 
 	// the formatting/appearance to use for the item's displayed text.
-	fmtPrimary := func(m *Media) string { return m.AbsName }
-	fmtSecondary := func(m *Media) string { return m.AbsPath }
+	fmtPrimary := func(m *media.Media) string { return m.AbsName }
+	fmtSecondary := func(m *media.Media) string { return m.AbsPath }
 
-	primary := fmtPrimary(media)
-	secondary := fmtSecondary(media)
+	primary := fmtPrimary(item)
+	secondary := fmtSecondary(item)
 
 	// append by default, because we did not find an item that already exists in
 	// our list which should appear after our new item we are trying to insert
@@ -415,11 +478,11 @@ func (l *Browser) positionForMediaItem(media *Media) (int, string, string) {
 // The "selected" callback will be invoked when the user selects the item. You
 // may provide nil if no such item is needed or if all events are handled
 // through the selected callback set with setSelectedFunc().
-func (l *Browser) addMediaItem(library *Library, media *Media, mainText, secondaryText string, selected func()) *Browser {
+func (l *Browser) addMediaItem(lib *library.Library, item *media.Media, mainText, secondaryText string, selected func()) *Browser {
 
 	l.visibleItem = append(l.visibleItem, &mediaItem{
-		Media:         media,
-		SourceLibrary: library,
+		Media:         item,
+		SourceLibrary: lib,
 		Owner:         l,
 		MainText:      mainText,
 		SecondaryText: secondaryText,
@@ -436,7 +499,7 @@ func (l *Browser) addMediaItem(library *Library, media *Media, mainText, seconda
 // item, creates it, and then inserts it into the list at the correct position
 // among -all- data items. this is effectively an insertion sort implemented
 // with linear traversal -- so not the fastest, but simple and effective.
-func (l *Browser) insertMediaItem(library *Library, media *Media, index int, mainText, secondaryText string, selected func()) *Browser {
+func (l *Browser) insertMediaItem(lib *library.Library, item *media.Media, index int, mainText, secondaryText string, selected func()) *Browser {
 
 	// several different ways to interpret index < 0. one convenient way would
 	// be to insert starting from the end of the list. the safest option, which
@@ -449,12 +512,12 @@ func (l *Browser) insertMediaItem(library *Library, media *Media, index int, mai
 	// if the index provided is greater than the number of elements in the list,
 	// then treat this like an ordinary append using the exported addMediaItem()
 	if index >= len(l.visibleItem) {
-		return l.addMediaItem(library, media, mainText, secondaryText, selected)
+		return l.addMediaItem(lib, item, mainText, secondaryText, selected)
 	}
 
 	newItem := &mediaItem{
-		Media:         media,
-		SourceLibrary: library,
+		Media:         item,
+		SourceLibrary: lib,
 		Owner:         l,
 		MainText:      mainText,
 		SecondaryText: secondaryText,
@@ -491,6 +554,15 @@ func (l *Browser) getItemText(index int) (main, secondary string) {
 	return l.visibleItem[index].MainText, l.visibleItem[index].SecondaryText
 }
 
+// itemAt returns the visible item at the given index, or nil if the index is
+// out of range.
+func (l *Browser) itemAt(index int) *mediaItem {
+	if !isValidIndex(l.visibleItem, index) {
+		return nil
+	}
+	return l.visibleItem[index]
+}
+
 // setItemText sets an item's main and secondary text. Panics if the index is
 // out of range.
 func (l *Browser) setItemText(index int, main, secondary string) *Browser {
@@ -0,0 +1,2734 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: command.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the subcommands, e.g. "pimmp list -kind video path", each of which
+//    parses its own options following its name. the global options precede
+//    the subcommand and are shared by all of them.
+//
+// =============================================================================
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/export"
+	"ardnew.com/pimmp/pkg/library"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/migrate"
+	"ardnew.com/pimmp/pkg/player"
+	"ardnew.com/pimmp/pkg/profile"
+	"ardnew.com/pimmp/pkg/provider"
+	"ardnew.com/pimmp/pkg/query"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/registry"
+	"ardnew.com/pimmp/pkg/report"
+	"ardnew.com/pimmp/pkg/storage"
+	"ardnew.com/pimmp/pkg/trakt"
+	"ardnew.com/pimmp/pkg/trash"
+	"ardnew.com/pimmp/pkg/versions"
+	"ardnew.com/pimmp/pkg/web"
+)
+
+// constant cmdHelp is the subcommand showing the usage of another.
+const cmdHelp = "help"
+
+// constant resumeSaveInterval is how far playback must progress before its
+// position is recorded again while playing.
+const resumeSaveInterval = 15 * time.Second
+
+// constant defaultUsageLimit is the number of the largest directories listed
+// by the disk usage reports, unless given otherwise with "du -limit".
+const defaultUsageLimit = 10
+
+// the kinds of report written by the "report" subcommands (see writeReport()).
+const (
+	reportContents = "contents"
+	reportRecent   = "recent"
+	reportDupes    = "dupes"
+	reportFailed   = "failed"
+)
+
+// type Subcommand is a command selected by the leading positional arguments,
+// which parses its own options from the arguments following it. any remaining
+// arguments, after those the subcommand takes itself, are library paths.
+type Subcommand struct {
+	name   string        // words selecting the subcommand
+	args   string        // synopsis of the positional arguments
+	usage  string        // description shown by its help
+	nargs  int           // number of positional arguments that aren't library paths
+	noLibs bool          // the subcommand operates on no libraries
+	stdout bool          // the subcommand writes its output to standard output
+	files  bool          // the subcommand moves or deletes the files of the libraries
+	dryRun bool          // the subcommand only shows what it would change with -dryrun
+	output string        // file to which the output is written, given with -o (standard output if empty)
+	flags  *flag.FlagSet // options specific to the subcommand
+
+	// performs the subcommand with its positional arguments on the libraries.
+	run func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode
+}
+
+// function newSubcommands() defines all of the subcommands, binding any of
+// their options that affect the global initialization to the given Options.
+func newSubcommands(options *Options) []*Subcommand {
+
+	scan := &Subcommand{
+		name:  "scan",
+		args:  "path [path ...]",
+		usage: "scans the libraries for new media, updating their databases, and exits once finished",
+	}
+	scan.flags = scan.newFlagSet()
+	scan.flags.UintVar(&options.maxDepth, "depth", library.DepthUnlimited,
+		"max number of directories below the library root scanned (0 = unlimited)")
+	scan.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return scanLibrary(options, libs)
+	}
+
+	list := &Subcommand{
+		name:   "list",
+		args:   "path [path ...]",
+		usage:  "lists the ID, kind, and path of each media in the libraries matching the global -match option",
+		stdout: true,
+	}
+	list.flags = list.newFlagSet()
+	kind := list.flags.String("kind", "all", "kind of media listed: audio, video, image, document, or all")
+	long := list.flags.Bool("long", false, "also list the size, date added, and title of each media")
+	by := map[string]*string{
+		"Tags":  list.flags.String("tag", "", "list only the media with the given tag"),
+		"Title": list.flags.String("title", "", "list only the media with exactly the given title"),
+		"Ext":   list.flags.String("ext", "", "list only the media with the given file name extension, e.g. \"mkv\""),
+	}
+	filter := listFilter{
+		contains:  list.flags.String("contains", "", "list only the media whose title contains the given text, ignoring case"),
+		since:     list.flags.String("since", "", "list only the media added on or after the given date (YYYY-MM-DD)"),
+		until:     list.flags.String("until", "", "list only the media added on or before the given date (YYYY-MM-DD)"),
+		recent:    list.flags.Uint("recent", 0, "list only the media added in the last given number of days (0 = any)"),
+		onThisDay: list.flags.Bool("onthisday", false, "list only the media added on this day in previous years"),
+		rule: list.flags.String("q", "",
+			"list only the media matching the given query, e.g. 'kind=video and released>2015 and not tag:kids' (see \"playlist smart\")"),
+	}
+	format := list.flags.String("format", listFormatPlain,
+		"format of the list: plain (tab-separated), table (aligned columns), json, or csv")
+	list.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return listMedia(options, libs, *kind, *long, by, filter, *format)
+	}
+
+	play := &Subcommand{
+		name:  "play",
+		args:  "id path [path ...]",
+		usage: "plays the media with the given ID (or unique prefix of one, see \"list\") found in the libraries",
+		nargs: 1,
+	}
+	play.flags = play.newFlagSet()
+	command := play.flags.String("player", "",
+		"command line of the player, in the same form as -playvideo (default: -playvideo or -playaudio, by kind of media)")
+	play.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return playMedia(options, libs, args[0], *command)
+	}
+
+	tag := &Subcommand{
+		name:  "tag",
+		args:  "id [+|-]tag[,...] path [path ...]",
+		usage: "adds (+tag, or just tag) or removes (-tag) the comma-separated tags of the media with the given ID found in the libraries",
+		nargs: 2,
+	}
+	tag.flags = tag.newFlagSet()
+	tag.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return tagMedia(options, libs, args[0], args[1])
+	}
+
+	rate := &Subcommand{
+		name:  "rate",
+		args:  fmt.Sprintf("id 0-%d path [path ...]", media.MaxRating),
+		usage: "rates the media with the given ID found in the libraries (0 = unrated)",
+		nargs: 2,
+	}
+	rate.flags = rate.newFlagSet()
+	rate.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return rateMedia(options, libs, args[0], args[1])
+	}
+
+	plList := &Subcommand{
+		name:   "playlist list",
+		args:   "path [path ...]",
+		usage:  "lists the name, number of media, and source file (if imported) or rule (if smart) of each playlist in the libraries",
+		stdout: true,
+	}
+	plList.flags = plList.newFlagSet()
+	plList.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return listPlaylists(options, libs)
+	}
+
+	plShow := &Subcommand{
+		name:   "playlist show",
+		args:   "name path [path ...]",
+		usage:  "lists the ID, kind, and path of each media in the playlist with the given name, in order",
+		nargs:  1,
+		stdout: true,
+	}
+	plShow.flags = plShow.newFlagSet()
+	plShow.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return showPlaylist(options, libs, args[0])
+	}
+
+	plAdd := &Subcommand{
+		name:  "playlist add",
+		args:  "name id path [path ...]",
+		usage: "appends the media with the given ID to the playlist with the given name in the media's library, creating the playlist if needed",
+		nargs: 2,
+	}
+	plAdd.flags = plAdd.newFlagSet()
+	plAdd.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return addToPlaylist(options, libs, args[0], args[1])
+	}
+
+	plRemove := &Subcommand{
+		name:  "playlist remove",
+		args:  "name id path [path ...]",
+		usage: "removes every occurrence of the media with the given ID from the playlist with the given name",
+		nargs: 2,
+	}
+	plRemove.flags = plRemove.newFlagSet()
+	plRemove.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return removeFromPlaylist(options, libs, args[0], args[1])
+	}
+
+	plSmart := &Subcommand{
+		name: "playlist smart",
+		args: "name rule path",
+		usage: "adds a smart playlist to the library, selecting the media matching the given rule whenever it is opened, " +
+			"e.g. \"kind=audio AND tag=jazz AND rating>=7\" (fields: kind, tag, genre, name, title, path, ext, " +
+			"rating, playcount, size, added, played, released, watched; operators: = : != < <= > >= ~ !~; AND OR NOT ( ); dates YYYY-MM-DD, YYYY-MM, or YYYY)",
+		nargs: 2,
+	}
+	plSmart.flags = plSmart.newFlagSet()
+	plSmart.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return createSmartPlaylist(options, libs, args[0], args[1])
+	}
+
+	plDelete := &Subcommand{
+		name:  "playlist delete",
+		args:  "name path [path ...]",
+		usage: "deletes the playlist with the given name (but neither its media nor the file it was imported from)",
+		nargs: 1,
+	}
+	plDelete.flags = plDelete.newFlagSet()
+	plDelete.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return deletePlaylist(options, libs, args[0])
+	}
+
+	plImport := &Subcommand{
+		name:  "playlist import",
+		args:  "file path",
+		usage: "adds a playlist to the library with the media listed by the given .m3u, .m3u8, or .pls file (which need not be in the library)",
+		nargs: 1,
+	}
+	plImport.flags = plImport.newFlagSet()
+	plName := plImport.flags.String("name", "", "name of the new playlist (default: the file's name, without extension)")
+	plImport.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return importPlaylist(options, libs, args[0], *plName)
+	}
+
+	plExport := &Subcommand{
+		name:   "playlist export",
+		args:   "name path [path ...]",
+		usage:  "writes the playlist with the given name to standard output (or the file given with -o), e.g. for another player",
+		nargs:  1,
+		stdout: true,
+	}
+	plExport.flags = plExport.newFlagSet()
+	plFormat := plExport.flags.String("format", "",
+		"format of the playlist written: m3u8 or pls (default: by the -o file's extension, or else m3u8)")
+	plRelative := plExport.relativeFlag()
+	plExport.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return exportPlaylist(options, libs, args[0], *plFormat, *plRelative)
+	}
+
+	series := &Subcommand{
+		name:   "series",
+		args:   "path [path ...]",
+		usage:  "lists the TV series in the libraries, each followed by its seasons and their episodes (the ID and path of each), as named by their files",
+		stdout: true,
+	}
+	series.flags = series.newFlagSet()
+	show := series.flags.String("show", "", "list only the series with the given name (ignoring case)")
+	series.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return listSeries(options, libs, *show)
+	}
+
+	config := &Subcommand{
+		name:   "config",
+		args:   "",
+		usage:  "shows the value of every option and where it came from (command line, environment, config file, or default)",
+		noLibs: true,
+		stdout: true,
+	}
+	config.flags = config.newFlagSet()
+	initConfig := config.flags.Bool("init", false,
+		"instead, write a new config file (see -config) defining every option with its default value (replaces an existing file with -force)")
+	configForce := config.flags.Bool("force", false, "with -init, replace an existing config file")
+	config.run = func(options *Options, _ []string, _ []*library.Library) *rc.ReturnCode {
+		return showConfig(options, *initConfig, *configForce)
+	}
+
+	backup := &Subcommand{
+		name:  "db backup",
+		args:  "path [path ...]",
+		usage: "copies the libraries' databases into a new directory, from which each can be restored by replacing its database directory",
+	}
+	backup.flags = backup.newFlagSet()
+	dest := backup.flags.String("to", "",
+		"directory in which the backup directory is created (default: the -libdata directory)")
+	backup.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return backupLibrary(options, libs, *dest)
+	}
+
+	dbExport := &Subcommand{
+		name:  "db export",
+		args:  "file path",
+		usage: "writes every record of the library's database to the given file as a single JSON document, e.g. for inspection or to move the library to another machine (see \"db import\")",
+		nargs: 1,
+	}
+	dbExport.flags = dbExport.newFlagSet()
+	dbForce := dbExport.flags.Bool("force", false, "replace the file if it exists")
+	dbExport.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return exportDatabase(options, libs, args[0], *dbForce)
+	}
+
+	dbImport := &Subcommand{
+		name:  "db import",
+		args:  "file path",
+		usage: "inserts the records of the JSON document written by \"db export\" into the library's database, which must be empty, changing the paths of its files if the library was exported from another path",
+		nargs: 1,
+	}
+	dbImport.flags = dbImport.newFlagSet()
+	replace := dbImport.flags.Bool("replace", false,
+		"delete every record of the library's database first, rather than requiring it to be empty")
+	dbImport.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return importDatabase(options, libs, args[0], *replace)
+	}
+
+	fetch := &Subcommand{
+		name:  "fetch",
+		args:  "path [path ...]",
+		usage: "fills in the title, synopsis, release date, genres, and artwork of the videos in the libraries (selected with -match, etc.) from an online database, identifying each movie or episode by its file name, and the artist, album, track, and release date of the audio from MusicBrainz, identifying each track by its tags (or fingerprint, see -acoustidkey)",
+	}
+	fetch.flags = fetch.newFlagSet()
+	source := fetch.flags.String("provider", "tmdb",
+		"online database queried for videos: \"tmdb\" (requires -tmdbkey) or \"tvdb\" (requires -tvdbkey)")
+	offline := fetch.flags.Bool("offline", false,
+		"use only the responses cached by earlier fetches, making no requests")
+	refetch := fetch.flags.Bool("all", false,
+		"fetch the metadata of all media, even videos already having a synopsis and audio already having an artist and album")
+	fetch.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return fetchMetadata(options, libs, *source, *offline, *refetch)
+	}
+
+	relink := &Subcommand{
+		name:  "subs relink",
+		args:  "path [path ...]",
+		usage: "associates the subtitles unassociated with any video with those matching best, using the current -subthreshold and -subdirweight, without rescanning",
+	}
+	relink.flags = relink.newFlagSet()
+	relinkAll := relink.flags.Bool("force", false,
+		"discard every association first, relinking all subtitles (selections of subtitles no longer associated with their video are cleared)")
+	relink.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return relinkSubtitles(options, libs, *relinkAll)
+	}
+
+	dupes := &Subcommand{
+		name:   "dupes",
+		args:   "path [path ...]",
+		usage:  "reports each group of identical media files in all of the libraries, by the content hashes computed when scanned (see -hashsize), with their sizes and paths",
+		stdout: true,
+	}
+	dupes.flags = dupes.newFlagSet()
+	dupesFormat := dupes.formatFlag(report.FormatName[report.FormatCSV])
+	dupes.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return reportIdentical(options, libs, *dupesFormat)
+	}
+
+	resolve := &Subcommand{
+		name:   "dupes resolve",
+		args:   "path [path ...]",
+		usage:  "finds the videos that are versions of the same movie or episode (e.g. in 1080p and 720p), ranked by resolution and bitrate (see -probe), and asks which version of each to keep and whether to delete or hide the others, recording the decision so it isn't asked again (with -dryrun, only shows what would change)",
+		files:  true,
+		dryRun: true,
+	}
+	resolve.flags = resolve.newFlagSet()
+	resolveAction := resolve.flags.String("action", "",
+		"decide every title without asking, keeping its best version and taking the given action on the others: "+strings.Join(versions.Actions, ", ")+" (keep = keep them all)")
+	resolveAll := resolve.flags.Bool("all", false, "also decide again the titles already decided")
+	resolveTrash := resolve.trashDirFlag()
+	resolve.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return resolveVersions(options, libs, *resolveAction, *resolveAll, *resolveTrash)
+	}
+
+	problems := &Subcommand{
+		name:   "report",
+		args:   "path [path ...]",
+		usage:  "reports the problems (files skipped, records quarantined, etc.) encountered by the most recent load and scan of each library, with the path and return code of each",
+		stdout: true,
+	}
+	problems.flags = problems.newFlagSet()
+	problemsFormat := problems.formatFlag(report.FormatName[report.FormatCSV])
+	problems.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return reportProblems(options, libs, *problemsFormat)
+	}
+
+	junkList := &Subcommand{
+		name:   "junk list",
+		args:   "path [path ...]",
+		usage:  "reports the files found by the most recent scan of each library that are neither media nor support files (e.g. release notes, thumbnails, and partial downloads), with the extension and size of each",
+		stdout: true,
+	}
+	junkList.flags = junkList.newFlagSet()
+	listPattern := junkList.flags.String("pattern", "",
+		"comma-separated list of glob patterns of the files listed, matching the file name (or, if it contains a \"/\", the path relative to the library), ignoring case (default: all)")
+	byExt := junkList.flags.Bool("byext", false, "summarize the space consumed by the files of each extension instead of listing them")
+	junkFormat := junkList.formatFlag(report.FormatName[report.FormatCSV])
+	junkList.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return listJunk(options, libs, splitList(*listPattern), *byExt, *junkFormat)
+	}
+
+	junkClean := &Subcommand{
+		name:   "junk clean",
+		args:   "path [path ...]",
+		usage:  "moves the files reported by \"junk list\" matching -pattern to the trash, from which \"undo batch\" or \"trash restore\" restores them (with -dryrun, only lists them)",
+		files:  true,
+		dryRun: true,
+	}
+	junkClean.flags = junkClean.newFlagSet()
+	cleanPattern := junkClean.flags.String("pattern", "",
+		"comma-separated list of glob patterns of the files cleaned, as with \"junk list\", e.g. \"*.url,*.part,Thumbs.db\" (required)")
+	cleanTrash := junkClean.trashDirFlag()
+	junkClean.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return cleanJunk(options, libs, splitList(*cleanPattern), *cleanTrash)
+	}
+
+	serve := &Subcommand{
+		name:  "serve",
+		args:  "path [path ...]",
+		usage: "serves a web interface for browsing, streaming, and playing the media of the libraries matching the global -match option, until interrupted",
+	}
+	serve.flags = serve.newFlagSet()
+	addr := serve.flags.String("addr", web.DefaultAddr,
+		"address on which the web interface is served (NOTE: without -webusers or -webtokens it has no authentication, so use e.g. \":8642\" only on trusted networks)")
+	noPlay := serve.flags.Bool("noplay", false, "never play media on the host, only stream them to the browser")
+	serve.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return serveWeb(options, libs, *addr, !*noPlay)
+	}
+
+	bench := &Subcommand{
+		name:   "bench",
+		args:   "path",
+		usage:  "scans the library at the given path once with each combination of the settings compared, each time into a new, temporary database (the library's own is untouched), then reports the files examined per second and the memory allocated by each pass, and suggests the settings of the fastest (global options such as -cpuprofile span every pass)",
+		nargs:  1,
+		noLibs: true,
+		stdout: true,
+	}
+	bench.flags = bench.newFlagSet()
+	bench.flags.UintVar(&options.maxDepth, "depth", library.DepthUnlimited,
+		"max number of directories below the library root scanned (0 = unlimited)")
+	benchProbers := bench.flags.String("probers", "1,2,4,8",
+		"comma-separated list of the numbers of ffprobe processes run at once compared (0 = any, see the global -probe)")
+	benchDisk := bench.flags.String("diskbuffers", "",
+		"comma-separated list of the database disk buffer sizes compared, e.g. \"64KiB,256KiB,1MiB\" (default: half, once, and twice -"+storage.DiskBufferSizeOption+"'s default)")
+	benchHash := bench.flags.String("hashbuffers", "",
+		"comma-separated list of the database hash buffer sizes compared (default: a quarter of each disk buffer size)")
+	benchPasses := bench.flags.Uint("passes", 1, "number of passes run with each combination of settings, the fastest of which is reported")
+	benchWarmup := bench.flags.Bool("warmup", true, "run an unmeasured pass first, so that every pass measured reads the files from the same (warm) cache")
+	benchFormat := bench.formatFlag(report.FormatName[report.FormatCSV])
+	bench.run = func(options *Options, args []string, _ []*library.Library) *rc.ReturnCode {
+		settings, ret := parseBenchSettings(*benchProbers, *benchDisk, *benchHash, *benchPasses, *benchWarmup)
+		if nil != ret {
+			return ret
+		}
+		return benchScan(options, args[0], settings, *benchFormat)
+	}
+
+	cachePrune := &Subcommand{
+		name:   "cache prune",
+		args:   "",
+		usage:  "removes the least recently used artwork and thumbnails from the file cache in the library data directory until the rest fit within -cachesize (with -dryrun, only lists them)",
+		noLibs: true,
+	}
+	cachePrune.flags = cachePrune.newFlagSet()
+	pruneSize := cachePrune.flags.String("maxsize", "",
+		"size to which the cache is reduced instead of -cachesize, e.g. \"100MiB\" (0 = remove everything)")
+	pruneAll := cachePrune.flags.Bool("all", false, "remove everything from the cache")
+	cachePrune.run = func(options *Options, _ []string, _ []*library.Library) *rc.ReturnCode {
+		return pruneCache(options, *pruneSize, *pruneAll)
+	}
+
+	traktLogin := &Subcommand{
+		name:   "trakt login",
+		args:   "",
+		usage:  "authorizes pimmp to access your Trakt account through the API application given by -traktid and -traktsecret: visit the URL shown and enter the code shown with it",
+		noLibs: true,
+	}
+	traktLogin.flags = traktLogin.newFlagSet()
+	traktLogin.run = func(options *Options, _ []string, _ []*library.Library) *rc.ReturnCode {
+		return loginTrakt(options)
+	}
+
+	traktSync := &Subcommand{
+		name:  "trakt sync",
+		args:  "path [path ...]",
+		usage: "syncs the watch state and ratings of the videos in the libraries (selected with -match, etc.) with the Trakt account authorized by \"trakt login\", the most recent change on either side winning (with -dryrun, only shows how many would change; see -traktsync to keep syncing in the background)",
+	}
+	traktSync.flags = traktSync.newFlagSet()
+	traktSync.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		client, ret := newTraktClient(options)
+		if nil != ret {
+			return ret
+		}
+		selected, ret := selectMedia(options)
+		if nil != ret {
+			return ret
+		}
+		return syncTrakt(options, client, libs, selected, options.DryRun.bool)
+	}
+
+	libAdd := &Subcommand{
+		name:   "lib add",
+		args:   "path",
+		usage:  "registers the library rooted at the given path, which is then opened, along with every other library registered, whenever no library path is given",
+		nargs:  1,
+		noLibs: true,
+	}
+	libAdd.flags = libAdd.newFlagSet()
+	libName := libAdd.flags.String("name", "", "name of the library, by which it may be given in place of its path (default: base name of the path)")
+	libDepth := libAdd.flags.Uint("depth", library.DepthUnlimited,
+		"max number of directories below the library root scanned (0 = unlimited, or the global -depth)")
+	libExclude := libAdd.flags.String("exclude", "",
+		"comma-separated list of glob patterns of the files never scanned in this library, in addition to the global -exclude")
+	libKinds := libAdd.flags.String("kinds", "",
+		"comma-separated list of the kinds of media scans add to this library: audio, video, image, document (default: all)")
+	libType := libAdd.flags.String("type", "",
+		"type of the library, to whose kind of media its scans are restricted: "+strings.Join(library.TypeNames(), ", ")+" (default: unchanged, or mixed if new)")
+	libProbe := libAdd.flags.String("probe", "",
+		"whether scans of this library describe the streams of video files using ffprobe: true or false (default: the global -probe)")
+	libAdd.run = func(options *Options, args []string, _ []*library.Library) *rc.ReturnCode {
+		return addRegistry(options, args[0], *libName, *libDepth, splitList(*libExclude), splitList(*libKinds), *libType, *libProbe)
+	}
+
+	libRemove := &Subcommand{
+		name:   "lib remove",
+		args:   "name",
+		usage:  "unregisters the named library, leaving its files and database untouched",
+		nargs:  1,
+		noLibs: true,
+	}
+	libRemove.flags = libRemove.newFlagSet()
+	libRemove.run = func(options *Options, args []string, _ []*library.Library) *rc.ReturnCode {
+		return removeRegistry(options, args[0])
+	}
+
+	libRename := &Subcommand{
+		name:   "lib rename",
+		args:   "name newname",
+		usage:  "renames the named library",
+		nargs:  2,
+		noLibs: true,
+	}
+	libRename.flags = libRename.newFlagSet()
+	libRename.run = func(options *Options, args []string, _ []*library.Library) *rc.ReturnCode {
+		return renameRegistry(options, args[0], args[1])
+	}
+
+	libList := &Subcommand{
+		name:   "lib list",
+		args:   "",
+		usage:  "lists the name, path, and settings of each library registered",
+		noLibs: true,
+		stdout: true,
+	}
+	libList.flags = libList.newFlagSet()
+	libList.run = func(options *Options, _ []string, _ []*library.Library) *rc.ReturnCode {
+		list, ret := loadRegistry(options)
+		if nil != ret {
+			return ret
+		}
+		for _, r := range list {
+			console.Raw.Log(r)
+		}
+		return nil
+	}
+
+	repair := &Subcommand{
+		name:  "db repair",
+		args:  "path [path ...]",
+		usage: "quarantines every corrupt record found in the libraries' databases and then rebuilds what it can of them from the files on disk",
+	}
+	repair.flags = repair.newFlagSet()
+	repair.run = func(_ *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		repairLibrary(libs)
+		return nil
+	}
+
+	kodi := &Subcommand{
+		name:  "export kodi",
+		args:  "path [path ...]",
+		usage: "writes a Kodi .nfo file alongside each video in the libraries, describing it with the metadata of its record",
+	}
+	kodi.flags = kodi.newFlagSet()
+	kodiForce := kodi.flags.Bool("force", false, "overwrite the existing .nfo files rather than skipping them")
+	kodi.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		exportKodi(options, libs, *kodiForce)
+		return nil
+	}
+
+	m3u8 := &Subcommand{
+		name:   "export m3u8",
+		args:   "path [path ...]",
+		usage:  "writes a playlist of the media in the libraries matching the global -match option, sorted by path",
+		stdout: true,
+	}
+	m3u8.flags = m3u8.newFlagSet()
+	m3u8Relative := m3u8.relativeFlag()
+	m3u8.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return exportM3U8(options, libs, *m3u8Relative)
+	}
+
+	plex := &Subcommand{
+		name:  "import plex",
+		args:  "file path [path ...]",
+		usage: "seeds the records of the libraries with the metadata and watch state of the Plex library export (XML) in the given file, matching its items to the media by path (so scan the libraries first)",
+		nargs: 1,
+	}
+	plex.flags = plex.newFlagSet()
+	plexPathMap := plex.pathMapFlag()
+	plex.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return importLibrary(options, libs, "Plex", args[0], *plexPathMap, migrate.ReadPlex)
+	}
+
+	jellyfin := &Subcommand{
+		name:  "import jellyfin",
+		args:  "file path [path ...]",
+		usage: "seeds the records of the libraries with the metadata and watch state of the Jellyfin library export (JSON) in the given file, matching its items to the media by path (so scan the libraries first)",
+		nargs: 1,
+	}
+	jellyfin.flags = jellyfin.newFlagSet()
+	jellyfinPathMap := jellyfin.pathMapFlag()
+	jellyfin.run = func(options *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return importLibrary(options, libs, "Jellyfin", args[0], *jellyfinPathMap, migrate.ReadJellyfin)
+	}
+
+	rptContents := newReportSubcommand(reportContents,
+		"reports the media in the libraries matching the global -match option")
+	rptRecent := newReportSubcommand(reportRecent,
+		"reports the media in the libraries matching the global -match option that were recently added (see -recent and -sessions)")
+	rptDupes := newReportSubcommand(reportDupes,
+		"reports the media in the libraries matching the global -match option having the same kind, extension, and size as another")
+	rptFailed := newReportSubcommand(reportFailed,
+		"reports the media in the libraries matching the global -match option whose latest verification failed (see \"verify\")")
+
+	du := &Subcommand{
+		name:   "du",
+		args:   "path [path ...]",
+		usage:  "reports the space consumed by the media in each library matching the global -match option by kind, extension, directory, and quality tier",
+		stdout: true,
+	}
+	du.flags = du.newFlagSet()
+	duFormat := du.formatFlag(report.FormatName[report.FormatText])
+	duLimit := du.flags.Int("limit", defaultUsageLimit, "max number of the largest directories listed (0 = unlimited)")
+	du.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return diskUsage(options, libs, *duFormat, *duLimit)
+	}
+
+	undo := &Subcommand{
+		name:  "undo",
+		args:  "path [path ...]",
+		usage: "reverts the most recent edit of each media in the libraries matching the global -match or -collection option (one is required, or -force)",
+		files: true,
+	}
+	undo.flags = undo.newFlagSet()
+	undoForce := undo.flags.Bool("force", false, "revert the most recent edit of every media in the libraries")
+	undo.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return undoEdits(options, libs, *undoForce)
+	}
+
+	undoBatch := &Subcommand{
+		name:  "undo batch",
+		args:  "path [path ...]",
+		usage: "reverts the most recent batch of changes made to the files of the libraries by \"organize\", \"delete\", \"junk clean\", or \"dupes resolve\", moving the files back and restoring their records, and lists the changes that could no longer be reverted",
+		files: true,
+	}
+	undoBatch.flags = undoBatch.newFlagSet()
+	undoBatch.run = func(_ *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return undoJournal(libs)
+	}
+
+	remove := &Subcommand{
+		name:  "delete",
+		args:  "path [path ...]",
+		usage: "moves the files of the media in the libraries matching the global -match or -collection option (one is required) to the trash, and removes their records (see \"undo batch\" and \"trash restore\")",
+		files: true,
+	}
+	remove.flags = remove.newFlagSet()
+	removeTrash := remove.trashDirFlag()
+	remove.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return deleteMedia(options, libs, *removeTrash)
+	}
+
+	trashList := &Subcommand{
+		name:  "trash list",
+		args:  "path [path ...]",
+		usage: "lists the files deleted from the libraries that are still in the trash and whose path contains the text of the global -match option",
+	}
+	trashList.flags = trashList.newFlagSet()
+	listTrash := trashList.trashDirFlag()
+	trashList.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return trashItems(options, libs, *listTrash, false, false)
+	}
+
+	trashRestore := &Subcommand{
+		name:  "trash restore",
+		args:  "path [path ...]",
+		usage: "moves the files listed by \"trash list\" back to where they were deleted from (rescan the libraries to add them back)",
+		files: true,
+	}
+	trashRestore.flags = trashRestore.newFlagSet()
+	restoreTrash := trashRestore.trashDirFlag()
+	restoreForce := trashRestore.flags.Bool("force", false, "restore every file in the trash if the global -match option isn't given")
+	trashRestore.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return trashItems(options, libs, *restoreTrash, true, *restoreForce)
+	}
+
+	organizer := &Subcommand{
+		name:   "organize",
+		args:   "path [path ...]",
+		usage:  "moves the media files of the libraries matching the global -match option into the layout described by -template, updating their records to match (with -dryrun, only shows the moves)",
+		files:  true,
+		dryRun: true,
+	}
+	organizer.flags = organizer.newFlagSet()
+	organizeTemplate := organizer.flags.String("template", "",
+		"path template, relative to the library, into which the media files are moved, e.g. \"{show}/Season {s}/{show} - S{s:2}E{e:2} - {title}.{ext}\" (default: the global -template)")
+	organizer.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		tmpl := *organizeTemplate
+		if "" == tmpl {
+			tmpl = options.Template.string
+		}
+		return organizeLibrary(options, libs, tmpl)
+	}
+
+	dedup := &Subcommand{
+		name:   "dedupe",
+		args:   "path [path ...]",
+		usage:  "replaces the media files of the libraries matching the global -match option that are byte-identical copies of another on the same file system with hard links to it, once confirmed (with -dryrun, only lists the copies)",
+		files:  true,
+		dryRun: true,
+	}
+	dedup.flags = dedup.newFlagSet()
+	dedupForce := dedup.flags.Bool("force", false, "replace the copies without asking for confirmation")
+	dedup.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return dedupeLibrary(options, libs, *dedupForce)
+	}
+
+	verifier := &Subcommand{
+		name:  "verify",
+		args:  "path [path ...]",
+		usage: "verifies the integrity of the media in the libraries matching the global -match option (the share of them due today with -verify, otherwise all), listing the failures",
+	}
+	verifier.flags = verifier.newFlagSet()
+	verifier.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return verifyLibrary(options, libs)
+	}
+
+	colList := &Subcommand{
+		name:   "collection list",
+		args:   "",
+		usage:  "lists the name, tags, and text of each collection",
+		noLibs: true,
+	}
+	colList.flags = colList.newFlagSet()
+	colList.run = func(options *Options, _ []string, _ []*library.Library) *rc.ReturnCode {
+		return listCollections(options)
+	}
+
+	colAdd := &Subcommand{
+		name:   "collection add",
+		args:   "name",
+		usage:  "defines the named collection of the media having every given tag and matching the given text, replacing any collection of the same name",
+		nargs:  1,
+		noLibs: true,
+	}
+	colAdd.flags = colAdd.newFlagSet()
+	colTags := colAdd.flags.String("tags", "", "comma-separated list of tags that every media in the collection must have")
+	colMatch := colAdd.flags.String("match", "", "text that the title, name, or path of every media in the collection must contain (case-insensitive)")
+	colAdd.run = func(options *Options, args []string, _ []*library.Library) *rc.ReturnCode {
+		return addCollection(options, args[0], splitList(*colTags), *colMatch)
+	}
+
+	colRemove := &Subcommand{
+		name:   "collection remove",
+		args:   "name",
+		usage:  "removes the named collection (but none of its media)",
+		nargs:  1,
+		noLibs: true,
+	}
+	colRemove.flags = colRemove.newFlagSet()
+	colRemove.run = func(options *Options, args []string, _ []*library.Library) *rc.ReturnCode {
+		return removeCollection(options, args[0])
+	}
+
+	profList := &Subcommand{
+		name:   "profile list",
+		args:   "",
+		usage:  "lists the name and the tags, content ratings, and paths hidden by each viewing profile, marking the active one with \"*\"",
+		noLibs: true,
+	}
+	profList.flags = profList.newFlagSet()
+	profList.run = func(options *Options, _ []string, _ []*library.Library) *rc.ReturnCode {
+		return listProfiles(options)
+	}
+
+	profAdd := &Subcommand{
+		name:   "profile add",
+		args:   "name",
+		usage:  "defines the named viewing profile, hiding the media having any of the given tags or content ratings, or residing in any of the given paths, replacing any profile of the same name",
+		nargs:  1,
+		noLibs: true,
+	}
+	profAdd.flags = profAdd.newFlagSet()
+	hideTags := profAdd.flags.String("hidetags", "", "comma-separated list of tags whose media are hidden")
+	hideRatings := profAdd.flags.String("hideratings", "", "comma-separated list of content ratings whose media are hidden, e.g. \"R,NC-17,TV-MA\"")
+	hidePaths := profAdd.flags.String("hidepaths", "", "comma-separated list of paths (or glob patterns) whose media are hidden")
+	addPIN := profAdd.pinFlag()
+	profAdd.run = func(options *Options, args []string, _ []*library.Library) *rc.ReturnCode {
+		return addProfile(options, &profile.Profile{
+			Name:        strings.TrimSpace(args[0]),
+			HideTags:    splitList(*hideTags),
+			HideRatings: splitList(*hideRatings),
+			HidePaths:   splitList(*hidePaths),
+		}, *addPIN)
+	}
+
+	profRemove := &Subcommand{
+		name:   "profile remove",
+		args:   "name",
+		usage:  "removes the named viewing profile, which must not be active",
+		nargs:  1,
+		noLibs: true,
+	}
+	profRemove.flags = profRemove.newFlagSet()
+	removePIN := profRemove.pinFlag()
+	profRemove.run = func(options *Options, args []string, _ []*library.Library) *rc.ReturnCode {
+		return removeProfile(options, strings.TrimSpace(args[0]), *removePIN)
+	}
+
+	profUse := &Subcommand{
+		name:   "profile use",
+		args:   "name",
+		usage:  "makes the named viewing profile active until switched again, hiding its media from the TUI and from every subcommand (an empty name, \"\", makes none active)",
+		nargs:  1,
+		noLibs: true,
+	}
+	profUse.flags = profUse.newFlagSet()
+	usePIN := profUse.pinFlag()
+	profUse.run = func(options *Options, args []string, _ []*library.Library) *rc.ReturnCode {
+		return useProfile(options, strings.TrimSpace(args[0]), *usePIN)
+	}
+
+	profPIN := &Subcommand{
+		name:   "profile pin",
+		args:   "",
+		usage:  "sets the PIN, read from standard input, then required to switch, add, or remove viewing profiles (an empty PIN removes it)",
+		noLibs: true,
+	}
+	profPIN.flags = profPIN.newFlagSet()
+	oldPIN := profPIN.pinFlag()
+	profPIN.run = func(options *Options, _ []string, _ []*library.Library) *rc.ReturnCode {
+		return setProfilePIN(options, *oldPIN)
+	}
+
+	snapTake := &Subcommand{
+		name:  "snapshot take",
+		args:  "path [path ...]",
+		usage: "records the state of every record of each library in a snapshot saved with its database",
+	}
+	snapTake.flags = snapTake.newFlagSet()
+	snapName := snapTake.flags.String("name", "", "name of the snapshot (default: the current time)")
+	snapTake.run = func(_ *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return takeSnapshots(libs, strings.TrimSpace(*snapName))
+	}
+
+	snapList := &Subcommand{
+		name:  "snapshot list",
+		args:  "path [path ...]",
+		usage: "lists the name, time taken, and number of records of each snapshot of the libraries",
+	}
+	snapList.flags = snapList.newFlagSet()
+	snapList.run = func(_ *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return listSnapshots(libs)
+	}
+
+	snapDiff := &Subcommand{
+		name:   "snapshot diff",
+		args:   "path [path ...]",
+		usage:  "lists the records of each library added (+), removed (-), and changed (~, with the old and new value of each field) between two of its snapshots",
+		stdout: true,
+	}
+	snapDiff.flags = snapDiff.newFlagSet()
+	snapOld := snapDiff.flags.String("old", "", "name of the older snapshot compared (default: the latest snapshot)")
+	snapNew := snapDiff.flags.String("new", "", "name of the newer snapshot compared (default: the current records)")
+	snapDiff.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return diffLibrarySnapshots(options, libs, strings.TrimSpace(*snapOld), strings.TrimSpace(*snapNew))
+	}
+
+	snapRemove := &Subcommand{
+		name:  "snapshot remove",
+		args:  "name path [path ...]",
+		usage: "removes the snapshot with the given name of each library",
+		nargs: 1,
+	}
+	snapRemove.flags = snapRemove.newFlagSet()
+	snapRemove.run = func(_ *Options, args []string, libs []*library.Library) *rc.ReturnCode {
+		return removeSnapshots(libs, strings.TrimSpace(args[0]))
+	}
+
+	return []*Subcommand{scan, list, play, tag, rate,
+		plList, plShow, plAdd, plRemove, plSmart, plDelete, plImport, plExport, series, config,
+		backup, dbExport, dbImport, repair, fetch, relink, resolve, dupes, problems, junkList, junkClean, serve, bench, cachePrune, traktLogin, traktSync,
+		libAdd, libRemove, libRename, libList,
+		kodi, m3u8, plex, jellyfin, rptContents, rptRecent, rptDupes, rptFailed, du, undo, undoBatch, remove, trashList, trashRestore,
+		organizer, dedup, verifier, colList, colAdd, colRemove, profList, profAdd, profRemove, profUse, profPIN,
+		snapTake, snapList, snapDiff, snapRemove}
+}
+
+// function newFlagSet() creates the Subcommand's option parser. errors are
+// returned rather than handled, so that they are reported like all others.
+// subcommands writing to standard output may write to a file instead (-o).
+func (s *Subcommand) newFlagSet() *flag.FlagSet {
+	f := flag.NewFlagSet(s.name, flag.ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.Usage = func() {}
+	if s.stdout {
+		f.StringVar(&s.output, "o", "", "path of the file to which the output is written (default: standard output)")
+	}
+	return f
+}
+
+// function formatFlag() defines the Subcommand's option selecting the file
+// format of the reports it writes, with the given default.
+func (s *Subcommand) formatFlag(def string) *string {
+	return s.flags.String("format", def,
+		"file format of the report: "+strings.Join(report.FormatName[:], ", "))
+}
+
+// function relativeFlag() defines the Subcommand's option writing the paths
+// of the files it lists relative to the file written (-o).
+func (s *Subcommand) relativeFlag() *bool {
+	return s.flags.Bool("relative", false,
+		"write paths relative to the directory of the -o file instead of absolute paths")
+}
+
+// function trashDirFlag() defines the Subcommand's option selecting the trash
+// to which files are deleted.
+func (s *Subcommand) trashDirFlag() *string {
+	return s.flags.String("trashdir", "",
+		"directory to which deleted files are moved (default: the OS trash if supported, otherwise \""+trash.DefaultDirName+"\" in the library)")
+}
+
+// function pinFlag() defines the Subcommand's option giving the PIN which
+// protects the viewing profiles.
+func (s *Subcommand) pinFlag() *string {
+	return s.flags.String("pin", "", "PIN protecting the viewing profiles, once one is set (see \"profile pin\")")
+}
+
+// function pathMapFlag() defines the Subcommand's option translating the paths
+// of the files of another media server to our own.
+func (s *Subcommand) pathMapFlag() *string {
+	return s.flags.String("pathmap", "",
+		"comma-separated list of from=to path prefixes translating the server's file paths to the local file paths")
+}
+
+// function newReportSubcommand() defines the subcommand writing the given kind
+// of report (see writeReport()), described by the given usage.
+func newReportSubcommand(kind, usage string) *Subcommand {
+	s := &Subcommand{
+		name:   "report " + kind,
+		args:   "path [path ...]",
+		usage:  usage,
+		stdout: true,
+	}
+	s.flags = s.newFlagSet()
+	format := s.formatFlag(report.FormatName[report.FormatCSV])
+	s.run = func(options *Options, _ []string, libs []*library.Library) *rc.ReturnCode {
+		return writeReport(options, libs, kind, *format)
+	}
+	return s
+}
+
+// function Usage() shows the Subcommand's synopsis, description, and options.
+func (s *Subcommand) Usage() {
+	console.Raw.Logf("usage: %s [global options] %s [options] %s", identity, s.name, s.args)
+	console.Raw.Log()
+	console.Raw.Logf("  %s", s.usage)
+	console.Raw.Log()
+	s.flags.SetOutput(os.Stdout)
+	s.flags.PrintDefaults()
+	s.flags.SetOutput(ioutil.Discard)
+	console.Raw.Log()
+}
+
+// function parse() parses the Subcommand's options from the given arguments
+// following its name, returning the positional arguments it takes and the
+// library paths following them.
+func (s *Subcommand) parse(args []string) ([]string, []string, *rc.ReturnCode) {
+
+	if err := s.flags.Parse(args); nil != err {
+		if flag.ErrHelp == err {
+			s.Usage()
+			return nil, nil, rc.Usage
+		}
+		return nil, nil, rc.InvalidArgs.Specf("%s: %s (see \"%s %s %s\")",
+			s.name, err, identity, cmdHelp, s.name)
+	}
+	rest := s.flags.Args()
+	if len(rest) < s.nargs {
+		return nil, nil, rc.InvalidArgs.Specf("%s: missing arguments: %s (see \"%s %s %s\")",
+			s.name, s.args, identity, cmdHelp, s.name)
+	}
+	return rest[:s.nargs], rest[s.nargs:], nil
+}
+
+// function findSubcommand() returns the subcommand named by the leading words
+// of the given arguments, and the arguments following them. the subcommand
+// with the most words matching is found, e.g. "dupes resolve" over "dupes".
+// returns nil if none is named.
+func findSubcommand(list []*Subcommand, args []string) (*Subcommand, []string) {
+
+	var found *Subcommand
+	var numWords int
+	for _, s := range list {
+		word := strings.Fields(s.name)
+		if len(word) <= numWords || len(args) < len(word) {
+			continue
+		}
+		match := true
+		for i, w := range word {
+			if w != args[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			found, numWords = s, len(word)
+		}
+	}
+	if nil == found {
+		return nil, args
+	}
+	return found, args[numWords:]
+}
+
+// function showHelp() shows the usage of the subcommand named by the given
+// arguments following "help", or the global usage if none is named.
+func showHelp(options *Options, args []string) {
+	if s, _ := findSubcommand(options.subcommands, args); nil != s {
+		s.Usage()
+		return
+	}
+	options.Usage()
+}
+
+// function scanLibrary() scans the given libraries, waiting until each of them
+// is finished.
+func scanLibrary(options *Options, libs []*library.Library) *rc.ReturnCode {
+
+	start := time.Now()
+	summarize := subscribeSummary(options)
+	populateLibrary(options, libs)
+
+	var numFound uint = 0
+	for _, l := range libs {
+		numFound += <-l.ScanComplete()
+	}
+	elapsed := time.Since(start)
+	status := "complete"
+	if nil != options.ctx.Err() {
+		status = "interrupted"
+	}
+	console.Info.Logf("scan %s (%d ~things~ found in %d libraries in %s)",
+		status, numFound, len(libs), elapsed.Round(time.Millisecond))
+	reportChanges(options, libs)
+	return summarize(libs, numFound, elapsed)
+}
+
+// the formats in which listMedia() writes the media listed.
+const (
+	listFormatPlain = "plain" // tab-separated fields, one media per line
+	listFormatTable = "table" // aligned columns under a header
+	listFormatJSON  = "json"  // array of objects, one per media
+	listFormatCSV   = "csv"   // comma-separated fields, under a header
+)
+
+// type listFilter holds the options of the list subcommand selecting media by
+// fields that aren't indexed, so every record is read.
+type listFilter struct {
+	contains *string // text the title contains (ignoring case), or empty
+	since    *string // first date added (YYYY-MM-DD), or empty
+	until    *string // last date added (YYYY-MM-DD), or empty
+	rule     *string // query the media match (see package query), or empty
+
+	// the media added in the last days, or on this day in previous years,
+	// are found using the index of the dates they were added.
+	recent    *uint // number of days
+	onThisDay *bool
+}
+
+// function dates() returns the dates the media selected by the listFilter were
+// added (see DateAdded of Media), or nil if any.
+func (f listFilter) dates(now time.Time) []string {
+	if *f.onThisDay {
+		return library.OnThisDay(now)
+	}
+	if *f.recent > 0 {
+		return library.RecentDates(now, int(*f.recent))
+	}
+	return nil
+}
+
+// function parseRule() returns the parsed query of the listFilter, or nil if
+// none was given.
+func (f listFilter) parseRule() (*query.Query, *rc.ReturnCode) {
+	if "" == strings.TrimSpace(*f.rule) {
+		return nil, nil
+	}
+	return query.Parse(*f.rule)
+}
+
+// function accept() returns a function accepting the media selected by the
+// listFilter.
+func (f listFilter) accept() (func(*media.Media) bool, *rc.ReturnCode) {
+
+	date := func(name, value string) (time.Time, *rc.ReturnCode) {
+		if "" == value {
+			return time.Time{}, nil
+		}
+		t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if nil != err {
+			return t, rc.InvalidArgs.Wrapf(err, "invalid date: -%s %q (expected YYYY-MM-DD)", name, value)
+		}
+		return t, nil
+	}
+	contains := strings.ToLower(*f.contains)
+	since, ret := date("since", *f.since)
+	if nil != ret {
+		return nil, ret
+	}
+	until, ret := date("until", *f.until)
+	if nil != ret {
+		return nil, ret
+	}
+	q, ret := f.parseRule()
+	if nil != ret {
+		return nil, ret
+	}
+	if *f.onThisDay && *f.recent > 0 {
+		return nil, rc.InvalidArgs.Specf("only one of -recent or -onthisday may be given (see \"%s %s list\")", identity, cmdHelp)
+	}
+	added := map[string]bool{}
+	for _, d := range f.dates(time.Now()) {
+		added[d] = true
+	}
+	if !until.IsZero() {
+		until = until.AddDate(0, 0, 1) // the whole day
+	}
+	return func(m *media.Media) bool {
+		if "" != contains && !strings.Contains(strings.ToLower(m.Title), contains) {
+			return false
+		}
+		if !since.IsZero() && m.TimeAdded.Before(since) {
+			return false
+		}
+		if !until.IsZero() && !m.TimeAdded.Before(until) {
+			return false
+		}
+		if len(added) > 0 && !added[media.DateOf(m.TimeAdded)] {
+			return false
+		}
+		return nil == q || q.Match(m)
+	}, nil
+}
+
+// type listEntry is a media listed by listMedia() in JSON.
+type listEntry struct {
+	ID    string    `json:"id"`
+	Kind  string    `json:"kind"`
+	Path  string    `json:"path"`
+	Size  int64     `json:"size"`
+	Added time.Time `json:"added"`
+	Title string    `json:"title"`
+	Tags  []string  `json:"tags"`
+}
+
+// function listMedia() lists the media of the given kind ("all" for any) in the
+// given libraries matching the -match option and the given filter, one per
+// line (or JSON object) in the given format.
+func listMedia(options *Options, libs []*library.Library, kind string, long bool, by map[string]*string, filter listFilter, format string) *rc.ReturnCode {
+
+	want := media.KindUnknown
+	switch strings.ToLower(kind) {
+	case "audio":
+		want = media.KindAudio
+	case "video":
+		want = media.KindVideo
+	case "image":
+		want = media.KindImage
+	case "document":
+		want = media.KindDocument
+	case "all", "":
+	default:
+		return rc.InvalidArgs.Specf("invalid kind of media: %q (see \"%s %s list\")", kind, identity, cmdHelp)
+	}
+	format = strings.ToLower(format)
+	switch format {
+	case listFormatPlain, listFormatTable, listFormatJSON, listFormatCSV:
+	default:
+		return rc.InvalidArgs.Specf("invalid list format: %q (see \"%s %s list\")", format, identity, cmdHelp)
+	}
+
+	// the media with the given field values are found using the indexes of
+	// the databases, rather than by reading every record, as are those of a
+	// query comparing an indexed field.
+	field, value := "", []string{}
+	for f, v := range by {
+		if "" == *v {
+			continue
+		}
+		if "" != field {
+			return rc.InvalidArgs.Specf("only one of -tag, -title, or -ext may be given (see \"%s %s list\")", identity, cmdHelp)
+		}
+		field, value = f, []string{*v}
+		if "Ext" == f && !strings.HasPrefix(*v, ".") {
+			value = []string{"." + *v}
+		}
+	}
+	if date := filter.dates(time.Now()); "" == field && nil != date {
+		field, value = "DateAdded", date
+	}
+	q, ret := filter.parseRule()
+	if nil != ret {
+		return ret
+	}
+	if "" == field && nil != q {
+		field, value, _ = q.Index()
+	}
+
+	selected, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+	filtered, ret := filter.accept()
+	if nil != ret {
+		return ret
+	}
+	accept := func(m *media.Media) bool {
+		return (media.KindUnknown == want || want == m.Kind) && selected(m) && filtered(m)
+	}
+	var list []*media.Media
+	if "" != field {
+		list = loadMediaBy(libs, field, value, accept)
+	} else {
+		list = loadMedia(libs, accept)
+	}
+
+	w, _, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+
+	if listFormatJSON == format {
+		entry := []listEntry{}
+		for _, m := range list {
+			entry = append(entry, listEntry{ID: m.ID(), Kind: kindName(m.Kind), Path: m.AbsPath,
+				Size: m.Size, Added: m.TimeAdded, Title: m.Title, Tags: m.Tags})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entry); nil != err {
+			return rc.ExportError.Specf("listMedia(): %s", err)
+		}
+		console.Info.Verbosef("listed %d media", len(list))
+		return nil
+	}
+
+	column := []string{"ID", "Kind", "Path"}
+	if long {
+		column = append(column, "Size", "Added", "Title")
+	}
+	row := [][]string{}
+	for _, m := range list {
+		r := []string{m.ID(), kindName(m.Kind), m.AbsPath}
+		if long {
+			r = append(r, report.HumanSize(m.Size), m.TimeAdded.Local().Format("2006-01-02"), m.Title)
+		}
+		row = append(row, r)
+	}
+
+	var err error
+	switch format {
+	case listFormatPlain:
+		for _, r := range row {
+			if _, err = fmt.Fprintln(w, strings.Join(r, "\t")); nil != err {
+				break
+			}
+		}
+	case listFormatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(column, "\t")))
+		for _, r := range row {
+			fmt.Fprintln(tw, strings.Join(r, "\t"))
+		}
+		err = tw.Flush()
+	case listFormatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(column)
+		cw.WriteAll(row)
+		err = cw.Error()
+	}
+	if nil != err {
+		return rc.ExportError.Specf("listMedia(): %s", err)
+	}
+	console.Info.Verbosef("listed %d media", len(list))
+	return nil
+}
+
+// function reportIdentical() writes a report of the media in the given
+// libraries matching the -match option whose files have identical content
+// (see report.Identical()). media are compared by the content hashes recorded
+// when scanned, without reading any files; those scanned before hashes were
+// computed are hashed by the next scan.
+func reportIdentical(options *Options, libs []*library.Library, formatName string) *rc.ReturnCode {
+
+	format, ret := report.ParseFormat(formatName)
+	if nil != ret {
+		return ret
+	}
+
+	selected, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+	list := loadMedia(libs, selected)
+	rep := report.Identical(list)
+
+	w, _, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+
+	if ret := rep.Write(w, format); nil != ret {
+		return ret
+	}
+	var unhashed uint
+	for _, m := range list {
+		if "" == m.Hash && !m.IsTrack() && !m.IsDisc() {
+			unhashed++
+		}
+	}
+	if unhashed > 0 {
+		console.Warn.Logf("%d media not yet hashed, so never reported (rescan the libraries to hash them)", unhashed)
+	}
+	console.Info.Verbosef("wrote report: %s (%d rows)", rep.Title, len(rep.Rows))
+	return nil
+}
+
+// function resolveVersions() finds the videos in the given libraries that are
+// versions of the same movie or episode (see package versions), and decides
+// which version of each title to keep and what to do with the others: move
+// them to the trash, hide them, or keep them all. the decision is the given
+// action, keeping the best version, or else asked of the user for each title.
+// each decision is recorded, and the titles already decided aren't decided
+// again unless all is true (or a version was found since). the versions deleted
+// are moved to the trash in the given directory (see trashFile()). with
+// -dryrun, the changes are only shown.
+func resolveVersions(options *Options, libs []*library.Library, action string, all bool, trashDir string) *rc.ReturnCode {
+
+	if "" != action && !versions.ValidAction(action) {
+		return rc.InvalidArgs.Specf("invalid action: %q (expected one of: %s)",
+			action, strings.Join(versions.Actions, ", "))
+	}
+	path := filepath.Join(options.configDir(), versions.DefaultFileName)
+	decided, ret := versions.Load(path)
+	if nil != ret {
+		return ret
+	}
+	selected, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+
+	videos := []*media.VideoMedia{}
+	owner := map[string]*library.Library{}
+	for _, l := range libs {
+		for _, ent := range loadEntities([]*library.Library{l}, selected) {
+			if v, ok := ent.(*media.VideoMedia); ok {
+				videos = append(videos, v)
+				owner[v.AbsPath] = l
+			}
+		}
+	}
+	if !options.DryRun.bool {
+		beginJournal(libs)
+	}
+
+	var numDecided, numSkipped, numFailed uint
+	for _, g := range versions.Find(videos) {
+		if !all && decided[g.Key].Decides(g) {
+			continue
+		}
+		keep, act := g.Versions[0], action
+		if "" == act {
+			var quit bool
+			if keep, act, quit = askVersion(g); quit {
+				break
+			}
+			if "" == act {
+				numSkipped++
+				continue
+			}
+		}
+
+		d := &versions.Decision{Key: g.Key, Action: act, Time: time.Now()}
+		if versions.ActionKeep != act {
+			d.Keep = keep.AbsPath
+		}
+		// only the versions actually handled are recorded, so that a title
+		// with any version that couldn't be is decided again next time.
+		failed := false
+		for _, v := range g.Versions {
+			if versions.ActionKeep == act || v == keep {
+				d.Paths = append(d.Paths, v.AbsPath)
+				continue
+			}
+			if options.DryRun.bool {
+				console.Info.Logf("dry run: would %s: %q (keeping %q)", act, v.AbsPath, keep.AbsPath)
+				continue
+			}
+			l := owner[v.AbsPath]
+			switch act {
+			case versions.ActionDelete:
+				item, ret := trashFile(trashDir, l, v.AbsPath)
+				if nil != ret {
+					console.Warn.Log(ret)
+					failed = true
+					continue
+				}
+				if _, ret := l.TrashMedia(options.bus, v.AbsPath, item); nil != ret {
+					console.Warn.Log(ret)
+					failed = true
+					continue
+				}
+				console.Info.Logf("moved to trash: %s", item)
+			case versions.ActionHide:
+				if _, ret := l.UpdateMedia(v.AbsPath, func(u *media.Media) bool {
+					return u.AddTag(versions.HiddenTag)
+				}); nil != ret {
+					console.Warn.Log(ret)
+					failed = true
+					continue
+				}
+				console.Info.Logf("hidden: %q", v.AbsPath)
+			}
+			d.Paths = append(d.Paths, v.AbsPath)
+		}
+		if failed {
+			numFailed++
+		} else {
+			numDecided++
+		}
+		if options.DryRun.bool {
+			continue
+		}
+		sort.Strings(d.Paths)
+		// each decision is saved right away, so that none are lost if the
+		// user quits early.
+		decided[g.Key] = d
+		if ret := versions.Save(path, decided); nil != ret {
+			return ret
+		}
+	}
+	console.Info.Logf("finished resolving versions (%d titles decided, %d skipped, %d failed)",
+		numDecided, numSkipped, numFailed)
+	return nil
+}
+
+// function askVersion() shows the versions of the given Group, best first, and
+// asks the user which to keep and what to do with the others. returns the
+// version kept and the action taken (empty if skipped), or true if the user
+// quit.
+func askVersion(g *versions.Group) (*media.VideoMedia, string, bool) {
+
+	console.Raw.Logf("%s: %d versions", g.Title, len(g.Versions))
+	for i, v := range g.Versions {
+		console.Raw.Logf("  %d) %s, %s: %q", i+1, versions.Quality(v), report.HumanSize(v.Size), v.AbsPath)
+	}
+	for {
+		answer := strings.ToLower(readLine(fmt.Sprintf(
+			"keep which version? [1-%d, enter = 1, a = keep all, s = skip, q = quit]", len(g.Versions))))
+		switch answer {
+		case "q":
+			return nil, "", true
+		case "s":
+			return nil, "", false
+		case "a":
+			return g.Versions[0], versions.ActionKeep, false
+		case "":
+			answer = "1"
+		}
+		n, err := strconv.Atoi(answer)
+		if nil != err || n < 1 || n > len(g.Versions) {
+			continue
+		}
+		for {
+			switch strings.ToLower(readLine("delete or hide the others? [d = delete, h = hide (default), s = skip]")) {
+			case "d":
+				return g.Versions[n-1], versions.ActionDelete, false
+			case "h", "":
+				return g.Versions[n-1], versions.ActionHide, false
+			case "s":
+				return nil, "", false
+			}
+		}
+	}
+}
+
+// function reportProblems() writes the report of the problems recorded by the
+// most recent load and scan of each of the given libraries to the -o file (or
+// standard output) in the named format.
+func reportProblems(options *Options, libs []*library.Library, formatName string) *rc.ReturnCode {
+
+	format, ret := report.ParseFormat(formatName)
+	if nil != ret {
+		return ret
+	}
+
+	list := map[string]*storage.ScanReport{}
+	for _, l := range libs {
+		r, ret := l.ScanReport()
+		if nil != ret {
+			return ret
+		}
+		list[l.AbsPath()] = r
+	}
+	rep := report.Problems(list)
+
+	w, _, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+
+	if ret := rep.Write(w, format); nil != ret {
+		return ret
+	}
+	console.Info.Verbosef("wrote report: %s (%d rows)", rep.Title, len(rep.Rows))
+	return nil
+}
+
+// function junkMatcher() returns a function accepting the junk files (see
+// "junk list") matching any of the given glob patterns: those with a "/" match
+// the path relative to the library, the others match the file name, ignoring
+// case. every file is accepted if there are no patterns.
+func junkMatcher(pattern []string) (func(storage.JunkEntry) bool, *rc.ReturnCode) {
+
+	for i, p := range pattern {
+		pattern[i] = strings.ToLower(strings.TrimPrefix(filepath.ToSlash(p), "/"))
+		if _, err := path.Match(pattern[i], ""); nil != err {
+			return nil, rc.InvalidArgs.Specf("invalid pattern: %q: %s", p, err)
+		}
+	}
+	return func(e storage.JunkEntry) bool {
+		if 0 == len(pattern) {
+			return true
+		}
+		rel := strings.ToLower(filepath.ToSlash(e.Path))
+		for _, p := range pattern {
+			name := path.Base(rel)
+			if strings.Contains(p, "/") {
+				name = rel
+			}
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// function listJunk() writes the report of the files found by the most recent
+// scan of each of the given libraries that are neither media nor support files
+// and match any of the given patterns (see junkMatcher()), or of the space
+// consumed by those of each extension if byExt is true, to the -o file (or
+// standard output) in the named format.
+func listJunk(options *Options, libs []*library.Library, pattern []string, byExt bool, formatName string) *rc.ReturnCode {
+
+	format, ret := report.ParseFormat(formatName)
+	if nil != ret {
+		return ret
+	}
+	accept, ret := junkMatcher(pattern)
+	if nil != ret {
+		return ret
+	}
+
+	list := map[string]*storage.JunkReport{}
+	for _, l := range libs {
+		r, ret := l.JunkReport()
+		if nil != ret {
+			return ret
+		}
+		entries := []storage.JunkEntry{}
+		for _, e := range r.Entries {
+			if accept(e) {
+				entries = append(entries, e)
+			}
+		}
+		r.Entries = entries
+		list[l.AbsPath()] = r
+	}
+	rep := report.Junk(list)
+	if byExt {
+		rep = report.JunkByExt(list)
+	}
+
+	w, _, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+
+	if ret := rep.Write(w, format); nil != ret {
+		return ret
+	}
+	console.Info.Verbosef("wrote report: %s (%d rows)", rep.Title, len(rep.Rows))
+	return nil
+}
+
+// function cleanJunk() moves the files found by the most recent scan of each of
+// the given libraries that are neither media nor support files and match any of
+// the given patterns (see junkMatcher()) to the trash in the given directory
+// (see trashFile()), as a single batch of changes that can be reverted (see
+// undoJournal()). with -dryrun, the files are only listed.
+func cleanJunk(options *Options, libs []*library.Library, pattern []string, trashDir string) *rc.ReturnCode {
+
+	if 0 == len(pattern) {
+		return rc.InvalidArgs.Spec("refusing to clean every junk file: select files with -pattern (e.g. \"*\" for all)")
+	}
+	accept, ret := junkMatcher(pattern)
+	if nil != ret {
+		return ret
+	}
+
+	var numFiles uint
+	var numBytes int64
+	if !options.DryRun.bool {
+		beginJournal(libs)
+	}
+	for _, l := range libs {
+		if options.DryRun.bool {
+			r, ret := l.JunkReport()
+			if nil != ret {
+				return ret
+			}
+			for _, e := range r.Entries {
+				if accept(e) {
+					console.Info.Logf("would move to trash: %q (%s)",
+						filepath.Join(l.AbsPath(), e.Path), report.HumanSize(e.Size))
+					numFiles++
+					numBytes += e.Size
+				}
+			}
+			continue
+		}
+		cleaned, ret := l.CleanJunk(accept, func(absPath string) (*trash.Item, *rc.ReturnCode) {
+			return trashFile(trashDir, l, absPath)
+		})
+		for _, e := range cleaned {
+			console.Info.Verbosef("moved to trash: %q (%s)",
+				filepath.Join(l.AbsPath(), e.Path), report.HumanSize(e.Size))
+			numFiles++
+			numBytes += e.Size
+		}
+		if nil != ret {
+			console.Warn.Log(ret)
+		}
+	}
+	if options.DryRun.bool {
+		console.Info.Logf("finished cleaning (dry run: %d file(s), %s, would be moved to trash)",
+			numFiles, report.HumanSize(numBytes))
+	} else {
+		console.Info.Logf("finished cleaning (%d file(s), %s, moved to trash)",
+			numFiles, report.HumanSize(numBytes))
+	}
+	return nil
+}
+
+// function kindName() returns the lower case name of the given kind of media.
+func kindName(kind media.MediaKind) string {
+	if kind < 0 || kind >= media.KindCOUNT {
+		return "unknown"
+	}
+	return strings.ToLower(media.MediaColName[kind])
+}
+
+// function playMedia() plays the media in the given libraries with the given ID
+// (or unique prefix of one) using the given player command line (see
+// playerFor()), and records the play in its history.
+func playMedia(options *Options, libs []*library.Library, id, command string) *rc.ReturnCode {
+
+	found, owner, ret := findMedia(libs, id)
+	if nil != ret {
+		return ret
+	}
+	if ret := playItem(options, owner, found, command); nil != ret {
+		return ret
+	}
+	return nil
+}
+
+// function serveWeb() serves the web interface to the media of the given
+// libraries selected by the -match and -collection options on the given
+// address, until interrupted. if play is true, the page may also play media
+// on the host with the player configured for their kind.
+func serveWeb(options *Options, libs []*library.Library, addr string, play bool) *rc.ReturnCode {
+
+	var fn web.PlayFunc
+	if play {
+		fn = func(l *library.Library, m *media.Media) *rc.ReturnCode {
+			return playItem(options, l, m, "")
+		}
+	}
+	selected, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+	srv := web.New(libs, selected, fn)
+	srv.SetThumbnails(thumbnailCache(options))
+	srv.SetArtworkCache(fileCache(options))
+	if ret := secureWeb(options, srv, addr); nil != ret {
+		return ret
+	}
+	interruptOnSignal(options)
+	reloadWeb(options, srv)
+	reloadOnSignal(options, libs)
+	if ret := srv.ListenAndServe(options.ctx, addr); nil != ret {
+		return ret
+	}
+	return nil
+}
+
+// function reloadWeb() reloads the media served by the given web Server each
+// time a rescan of the libraries finishes, so that the page lists the files
+// found. the media are reloaded in the background, never on the goroutine of
+// the scan, and the scans finished while reloading are all covered by a single
+// reload afterward, rather than one each.
+func reloadWeb(options *Options, srv *web.Server) {
+
+	pending := make(chan struct{}, 1)
+	options.bus.Subscribe(func(library.Event) {
+		select {
+		case pending <- struct{}{}:
+		default: // a reload is already pending
+		}
+	}, library.ScanFinished)
+	go func() {
+		for {
+			select {
+			case <-options.ctx.Done():
+				return
+			case <-pending:
+			}
+			if ret := srv.Reload(options.ctx); nil != ret {
+				console.Warn.Log(ret)
+			}
+		}
+	}()
+}
+
+// function secureWeb() configures the given web Server, to listen on the given
+// address, with the credentials and TLS certificate given by the -webusers,
+// -webtokens, -webtls, -webcert, and -webkey options. -webcert implies -webtls;
+// without it, a self-signed certificate is generated in the configuration
+// directory.
+func secureWeb(options *Options, srv *web.Server, addr string) *rc.ReturnCode {
+
+	creds, ret := web.ParseCredentials(splitList(options.WebUsers.string), splitList(options.WebTokens.string))
+	if nil != ret {
+		return ret
+	}
+	srv.SetCredentials(creds)
+	if !options.WebTLS.bool && "" == options.WebCert.string {
+		if nil != creds {
+			console.Warn.Logf("serving without TLS, credentials are sent in the clear (see -%s)", options.WebTLS.name)
+		}
+		return nil
+	}
+
+	cert, key := options.WebCert.string, options.WebKey.string
+	if "" == cert {
+		cert = filepath.Join(options.configDir(), web.CertFileName)
+		key = filepath.Join(options.configDir(), web.KeyFileName)
+		// the certificate names the host it is served from, as well as the
+		// address listened on.
+		hosts := []string{}
+		if host, _, err := net.SplitHostPort(addr); nil == err && "" != host {
+			hosts = append(hosts, host)
+		}
+		if name, err := os.Hostname(); nil == err {
+			hosts = append(hosts, name)
+		}
+		if ret := web.SelfSignedCert(cert, key, hosts); nil != ret {
+			return ret
+		}
+		console.Info.Verbosef("serving with self-signed certificate: %q", cert)
+	} else if "" == key {
+		return rc.InvalidArgs.Specf("-%s requires -%s", options.WebCert.name, options.WebKey.name)
+	}
+	srv.SetTLS(cert, key)
+	return nil
+}
+
+// function findMedia() returns the media in the given libraries with the given
+// ID (or unique prefix of one), and the library in which it was found.
+func findMedia(libs []*library.Library, id string) (*media.Media, *library.Library, *rc.ReturnCode) {
+
+	id = strings.ToLower(strings.TrimSpace(id))
+	if "" == id {
+		return nil, nil, rc.InvalidArgs.Spec("missing media ID")
+	}
+	var found *media.Media
+	var owner *library.Library
+	for _, l := range libs {
+		for _, m := range loadMedia([]*library.Library{l}, func(m *media.Media) bool {
+			return strings.HasPrefix(m.ID(), id)
+		}) {
+			if nil != found {
+				return nil, nil, rc.InvalidArgs.Specf("ambiguous media ID %q: %q and %q", id, found.AbsPath, m.AbsPath)
+			}
+			found, owner = m, l
+		}
+	}
+	if nil == found {
+		return nil, nil, rc.InvalidArgs.Specf("no media with ID %q", id)
+	}
+	return found, owner, nil
+}
+
+// function tagMedia() adds and removes the tags of the media in the given
+// libraries with the given ID (or unique prefix of one), as listed by the
+// given comma-separated changes: each is a tag to add, optionally prefixed
+// with '+', or a tag to remove prefixed with '-'.
+func tagMedia(options *Options, libs []*library.Library, id, changes string) *rc.ReturnCode {
+
+	add, remove := []string{}, []string{}
+	for _, c := range splitList(changes) {
+		switch {
+		case strings.HasPrefix(c, "-"):
+			remove = append(remove, strings.TrimSpace(c[1:]))
+		case strings.HasPrefix(c, "+"):
+			add = append(add, strings.TrimSpace(c[1:]))
+		default:
+			add = append(add, c)
+		}
+	}
+	if 0 == len(add)+len(remove) {
+		return rc.InvalidArgs.Specf("no tags given: %q", changes)
+	}
+
+	found, owner, ret := findMedia(libs, id)
+	if nil != ret {
+		return ret
+	}
+	changed, ret := owner.UpdateMedia(found.AbsPath, func(u *media.Media) bool {
+		modified := false
+		for _, t := range remove {
+			modified = u.RemoveTag(t) || modified
+		}
+		for _, t := range add {
+			modified = u.AddTag(t) || modified
+		}
+		return modified
+	})
+	if nil != ret {
+		return ret
+	}
+	if !changed {
+		console.Info.Logf("tags unchanged: %q", found.AbsPath)
+		return nil
+	}
+	console.Info.Logf("tagged: %q", found.AbsPath)
+	return nil
+}
+
+// function rateMedia() changes the rating of the media in the given libraries
+// with the given ID (or unique prefix of one) to the given rating, from 0
+// (unrated) to media.MaxRating.
+func rateMedia(options *Options, libs []*library.Library, id, rating string) *rc.ReturnCode {
+
+	r, err := strconv.ParseInt(strings.TrimSpace(rating), 10, 64)
+	if nil != err || r < 0 || r > media.MaxRating {
+		return rc.InvalidArgs.Specf("invalid rating: %q (must be 0-%d)", rating, media.MaxRating)
+	}
+
+	found, owner, ret := findMedia(libs, id)
+	if nil != ret {
+		return ret
+	}
+	changed, ret := owner.UpdateMedia(found.AbsPath, func(u *media.Media) bool {
+		return u.SetRating(r)
+	})
+	if nil != ret {
+		return ret
+	}
+	if !changed {
+		console.Info.Logf("rating unchanged: %q", found.AbsPath)
+		return nil
+	}
+	console.Info.Logf("rated %d/%d: %q", r, media.MaxRating, found.AbsPath)
+	return nil
+}
+
+// function playerFor() returns the Player of the given kind of media, running
+// the given command line unless empty, or else the one configured for the kind
+// (see -playvideo, -playaudio, and -reader).
+func playerFor(options *Options, kind media.MediaKind, command string) (*player.Player, *rc.ReturnCode) {
+
+	if "" == strings.TrimSpace(command) {
+		switch kind {
+		case media.KindAudio:
+			command = options.PlayAudio.string
+		case media.KindDocument:
+			command = options.Reader.string
+		default:
+			command = options.PlayVideo.string
+		}
+	}
+	t, ret := player.ParseTemplate(command)
+	if nil != ret {
+		return nil, ret
+	}
+	return player.NewTemplate(t), nil
+}
+
+// function playItem() plays the given media of the given library with the given
+// command line (see playerFor()), and then records the play in the library's
+// database. the given media is updated to match its record.
+func playItem(options *Options, owner *library.Library, m *media.Media, command string) *rc.ReturnCode {
+
+	p, ret := playerFor(options, m.Kind, command)
+	if nil != ret {
+		return ret
+	}
+	p.SetPlugins(owner.Plugins())
+	if nil != options.mpris {
+		p.SetController(options.mpris)
+	}
+
+	// record the position as playback progresses (if the player reports it),
+	// so that it is resumed from there even if the player doesn't exit
+	// cleanly. the final position is recorded below.
+	var saved time.Duration
+	report := func(progress player.Progress) {
+		moved := progress.Position - saved
+		if progress.Finished || (moved < resumeSaveInterval && moved > -resumeSaveInterval) {
+			return
+		}
+		saved = progress.Position
+		if _, ret := owner.SetResumePosition(m.AbsPath, progress.Position); nil != ret {
+			console.Warn.Verbose(ret)
+		}
+	}
+
+	progress, ret := p.PlayMedia(m, owner.Subtitles(m.AbsPath), report)
+	if nil != ret {
+		return ret
+	}
+	// media played until finished begins again from the start next time, all
+	// others resume where they were stopped.
+	played := time.Now()
+	var record *media.Media
+	if _, ret := owner.UpdateMedia(m.AbsPath, func(u *media.Media) bool {
+		if progress.Finished {
+			u.PlayCount++
+			u.ResumePosition = 0
+			u.Watched = true
+		} else {
+			u.ResumePosition = progress.Position
+		}
+		u.LastPlayed = played
+		record = u
+		return true
+	}); nil != ret {
+		// the media was played all the same.
+		console.Warn.Log(ret)
+	}
+	if nil != record {
+		*m = *record
+	}
+	return nil
+}
+
+// function newTraktClient() returns the client of the Trakt API application
+// given by the -traktid and -traktsecret options, whose account file is kept in
+// the -libdata directory.
+func newTraktClient(options *Options) (*trakt.Client, *rc.ReturnCode) {
+	if "" == options.TraktID.string || "" == options.TraktSecret.string {
+		return nil, rc.InvalidConfig.Specf("no Trakt API application given (see options -%s and -%s)",
+			options.TraktID.name, options.TraktSecret.name)
+	}
+	return trakt.New(options.TraktID.string, options.TraktSecret.string,
+		filepath.Join(options.LibData.string, trakt.AccountFileName))
+}
+
+// function loginTrakt() authorizes access to a Trakt account, waiting until the
+// user has entered the code shown (or the program is interrupted).
+func loginTrakt(options *Options) *rc.ReturnCode {
+
+	client, ret := newTraktClient(options)
+	if nil != ret {
+		return ret
+	}
+	interruptOnSignal(options)
+	if ret := client.Login(options.ctx, func(url, code string) {
+		console.Raw.Logf("to authorize pimmp, visit %s and enter the code: %s", url, code)
+	}); nil != ret {
+		return ret
+	}
+	console.Info.Log("authorized Trakt account")
+	return nil
+}
+
+// function syncTrakt() syncs the watch state and ratings of the videos in the
+// given libraries accepted by the given filter with the Trakt account, updating
+// their records with the changes pulled from Trakt. if dryRun is true, nothing
+// is changed on either side, the changes are only counted.
+func syncTrakt(options *Options, client *trakt.Client, libs []*library.Library, accept func(*media.Media) bool, dryRun bool) *rc.ReturnCode {
+
+	type location struct {
+		lib     *library.Library
+		absPath string
+	}
+	videos, where := []trakt.Video{}, []location{}
+	for _, l := range libs {
+		for _, ent := range loadEntities([]*library.Library{l}, accept) {
+			v, ok := ent.(*media.VideoMedia)
+			if !ok {
+				continue
+			}
+			if vid, ok := trakt.NewVideo(v); ok {
+				videos = append(videos, vid)
+				where = append(where, location{lib: l, absPath: v.AbsPath})
+			}
+		}
+	}
+
+	updates, numPushed, ret := client.Sync(videos, dryRun)
+	if nil != ret {
+		return ret
+	}
+	if dryRun {
+		console.Info.Logf("finished syncing with Trakt (dry run: %d changes would be made on Trakt, up to %d videos updated)",
+			numPushed, len(updates))
+		return nil
+	}
+
+	var numUpdated uint
+	for i := range updates {
+		at := where[updates[i].Index]
+		changed, ret := at.lib.UpdateMedia(at.absPath, updates[i].Apply)
+		if nil != ret {
+			console.Warn.Log(ret)
+			continue
+		}
+		if changed {
+			console.Info.Tracef("synced from Trakt: %s (%q)", videos[updates[i].Index].Query, at.absPath)
+			numUpdated++
+		}
+	}
+	if ret := client.Synced(time.Now()); nil != ret {
+		return ret
+	}
+	console.Info.Logf("finished syncing with Trakt (%d changes made on Trakt, %d videos updated)",
+		numPushed, numUpdated)
+	return nil
+}
+
+// function findPlaylist() returns the playlist with the given name in the given
+// libraries, and the library in which it was found. the name must identify a
+// single playlist among all of the libraries.
+func findPlaylist(libs []*library.Library, name string) (*media.Playlist, *library.Library, *rc.ReturnCode) {
+
+	var found *media.Playlist
+	var owner *library.Library
+	for _, l := range libs {
+		p, ret := l.FindPlaylist(name)
+		if nil != ret {
+			return nil, nil, ret
+		}
+		if nil == p {
+			continue
+		}
+		if nil != found {
+			return nil, nil, rc.InvalidArgs.Specf("ambiguous playlist %q: found in libraries %q and %q",
+				name, owner.Name(), l.Name())
+		}
+		found, owner = p, l
+	}
+	if nil == found {
+		return nil, nil, rc.InvalidArgs.Specf("no playlist named %q", name)
+	}
+	return found, owner, nil
+}
+
+// function listPlaylists() lists the playlists of the given libraries, one per
+// line.
+func listPlaylists(options *Options, libs []*library.Library) *rc.ReturnCode {
+
+	w, _, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+
+	count := 0
+	for _, l := range libs {
+		list, ret := l.Playlists()
+		if nil != ret {
+			return ret
+		}
+		for _, p := range list {
+			source, size := "-", len(p.Items)
+			switch {
+			case p.IsSmart():
+				source, size = p.Rule, len(l.PlaylistMedia(p))
+			case p.IsImported():
+				source = p.AbsPath
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", l.Name(), p.Name, size, source)
+		}
+		count += len(list)
+	}
+	console.Info.Verbosef("listed %d playlists", count)
+	return nil
+}
+
+// function showPlaylist() lists the media of the playlist with the given name,
+// in order, in the same form as listMedia().
+func showPlaylist(options *Options, libs []*library.Library, name string) *rc.ReturnCode {
+
+	p, owner, ret := findPlaylist(libs, name)
+	if nil != ret {
+		return ret
+	}
+
+	w, _, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+
+	list := owner.PlaylistMedia(p)
+	for _, m := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", m.ID(), kindName(m.Kind), m.AbsPath)
+	}
+	console.Info.Verbosef("listed %d of %d media in playlist %q", len(list), len(p.Items), p.Name)
+	return nil
+}
+
+// function listSeries() lists the TV series of the given libraries (or only
+// those with the given name, if not empty) as a hierarchy: each series is
+// followed by its seasons, each indented once, and each season by its
+// episodes, indented twice, in the same form as listMedia().
+func listSeries(options *Options, libs []*library.Library, name string) *rc.ReturnCode {
+
+	w, _, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+
+	count := 0
+	for _, l := range libs {
+		list, ret := l.Series()
+		if nil != ret {
+			return ret
+		}
+		for _, s := range list {
+			if "" != name && !strings.EqualFold(name, s.Name) {
+				continue
+			}
+			year := ""
+			if s.Year > 0 {
+				year = fmt.Sprintf(" (%d)", s.Year)
+			}
+			fmt.Fprintf(w, "%s\t%s%s\n", l.Name(), s.Name, year)
+			seasons, ret := l.Seasons(s.Key)
+			if nil != ret {
+				return ret
+			}
+			for _, n := range seasons {
+				fmt.Fprintf(w, "\tSeason %d\n", n.Number)
+				episodes, ret := l.Episodes(n.Key)
+				if nil != ret {
+					return ret
+				}
+				for _, e := range episodes {
+					fmt.Fprintf(w, "\t\t%s\t%s\t%s\n", e.ID(), episodeNumber(e), e.AbsPath)
+				}
+			}
+			count++
+		}
+	}
+	console.Info.Verbosef("listed %d series", count)
+	return nil
+}
+
+// function relinkSubtitles() re-runs the association of subtitles with videos
+// in each of the given libraries (see RelinkSubtitles()), reporting how many
+// were relinked and how many remain unassociated.
+func relinkSubtitles(options *Options, libs []*library.Library, force bool) *rc.ReturnCode {
+
+	for _, l := range libs {
+		relinked, remain, ret := l.RelinkSubtitles(force)
+		if nil != ret {
+			return ret
+		}
+		console.Info.Logf("relinked %d subtitles in library %q (%d unassociated with any video)",
+			relinked-remain, l.Name(), remain)
+	}
+	return nil
+}
+
+// function episodeNumber() returns the season and episode numbers of the given
+// episode, e.g. "S02E05", or "S02E05-E06" if the file has several episodes.
+func episodeNumber(v *media.VideoMedia) string {
+	if v.LastEpisode > v.Episode {
+		return fmt.Sprintf("S%02dE%02d-E%02d", v.Season, v.Episode, v.LastEpisode)
+	}
+	return fmt.Sprintf("S%02dE%02d", v.Season, v.Episode)
+}
+
+// function addToPlaylist() appends the media with the given ID (or unique
+// prefix of one) to the playlist with the given name in the media's library.
+func addToPlaylist(options *Options, libs []*library.Library, name, id string) *rc.ReturnCode {
+
+	found, owner, ret := findMedia(libs, id)
+	if nil != ret {
+		return ret
+	}
+	if ret := owner.AddToPlaylist(name, found.AbsPath); nil != ret {
+		return ret
+	}
+	console.Info.Logf("added to playlist %q: %q", name, found.AbsPath)
+	return nil
+}
+
+// function removeFromPlaylist() removes every occurrence of the media with the
+// given ID (or unique prefix of one) from the playlist with the given name.
+func removeFromPlaylist(options *Options, libs []*library.Library, name, id string) *rc.ReturnCode {
+
+	p, owner, ret := findPlaylist(libs, name)
+	if nil != ret {
+		return ret
+	}
+	found, _, ret := findMedia([]*library.Library{owner}, id)
+	if nil != ret {
+		return ret
+	}
+	removed := 0
+	if _, ret := owner.UpdatePlaylist(p.Name, func(u *media.Playlist) bool {
+		for i := u.IndexOf(found.AbsPath); i >= 0; i = u.IndexOf(found.AbsPath) {
+			u.Remove(i)
+			removed++
+		}
+		return removed > 0
+	}); nil != ret {
+		return ret
+	}
+	if 0 == removed {
+		console.Info.Logf("not in playlist %q: %q", p.Name, found.AbsPath)
+		return nil
+	}
+	console.Info.Logf("removed from playlist %q: %q (%d times)", p.Name, found.AbsPath, removed)
+	return nil
+}
+
+// function createSmartPlaylist() adds a smart playlist to the given library
+// (only one may be given) with the given name, selecting the media matching
+// the given rule.
+func createSmartPlaylist(options *Options, libs []*library.Library, name, rule string) *rc.ReturnCode {
+
+	if 1 != len(libs) {
+		return rc.InvalidArgs.Specf("playlist smart: exactly one library required (%d given)", len(libs))
+	}
+	p, ret := libs[0].CreateSmartPlaylist(name, rule)
+	if nil != ret {
+		return ret
+	}
+	console.Info.Logf("created smart playlist %q in library %q (%d media currently match)",
+		p.Name, libs[0].Name(), len(libs[0].PlaylistMedia(p)))
+	return nil
+}
+
+// function deletePlaylist() deletes the playlist with the given name.
+func deletePlaylist(options *Options, libs []*library.Library, name string) *rc.ReturnCode {
+
+	p, owner, ret := findPlaylist(libs, name)
+	if nil != ret {
+		return ret
+	}
+	if _, ret := owner.DeletePlaylist(p.Name); nil != ret {
+		return ret
+	}
+	console.Info.Logf("deleted playlist %q from library %q", p.Name, owner.Name())
+	return nil
+}
+
+// function importPlaylist() adds a playlist to the given library (only one may
+// be given) with the media listed by the playlist file at the given path,
+// named after the file unless name is non-empty.
+func importPlaylist(options *Options, libs []*library.Library, file, name string) *rc.ReturnCode {
+
+	if 1 != len(libs) {
+		return rc.InvalidArgs.Specf("playlist import: exactly one library required (%d given)", len(libs))
+	}
+	absFile, err := filepath.Abs(file)
+	if nil != err {
+		return rc.InvalidPath.Specf("invalid playlist path: %q: %s", file, err)
+	}
+	p, ret := libs[0].ImportPlaylist(absFile, name)
+	if nil != ret {
+		return ret
+	}
+	console.Info.Logf("imported playlist %q into library %q (%d media)", p.Name, libs[0].Name(), len(p.Items))
+	return nil
+}
+
+// function exportPlaylist() writes the playlist with the given name in the
+// given format ("m3u8" or "pls"; by the -o file's extension when empty), with
+// paths relative to the -o file if relative is true.
+func exportPlaylist(options *Options, libs []*library.Library, name, format string, relative bool) *rc.ReturnCode {
+
+	p, owner, ret := findPlaylist(libs, name)
+	if nil != ret {
+		return ret
+	}
+	list := owner.PlaylistMedia(p)
+
+	if "" == format {
+		format = "m3u8"
+		if strings.EqualFold(".pls", filepath.Ext(options.subcommand.output)) {
+			format = "pls"
+		}
+	}
+	format = strings.ToLower(format)
+	switch format {
+	case "m3u8", "m3u", "pls":
+	default:
+		return rc.InvalidArgs.Specf("invalid playlist format: %q (see \"%s %s playlist export\")",
+			format, identity, cmdHelp)
+	}
+
+	w, base, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+	if !relative {
+		base = ""
+	}
+	if "pls" == format {
+		ret = export.NewPLS(base).Write(w, list)
+	} else {
+		ret = export.NewM3U(base).Write(w, list)
+	}
+	if nil != ret {
+		return ret
+	}
+	console.Info.Verbosef("exported %d media of playlist %q", len(list), p.Name)
+	return nil
+}
+
+// function registryPath() returns the path to the file in which the libraries
+// registered are saved.
+func registryPath(options *Options) string {
+	return filepath.Join(options.configDir(), registry.DefaultFileName)
+}
+
+// function loadRegistry() returns the libraries registered. problems reading
+// them are fatal, since the wrong libraries would be opened otherwise.
+func loadRegistry(options *Options) ([]*registry.Library, *rc.ReturnCode) {
+	return registry.Load(registryPath(options))
+}
+
+// function addRegistry() registers the library rooted at the given path with
+// the given name and settings. neither its name nor its path may already be
+// registered. the library's scans probe video files as selected by -probe
+// unless probe is "true" or "false".
+func addRegistry(options *Options, path, name string, depth uint, exclude, kinds []string, libType, probe string) *rc.ReturnCode {
+
+	var probeVideo *bool
+	if probe = strings.TrimSpace(probe); "" != probe {
+		b, err := strconv.ParseBool(probe)
+		if nil != err {
+			return rc.InvalidArgs.Specf("invalid value for -probe: %q (must be true or false)", probe)
+		}
+		probeVideo = &b
+	}
+	r, ret := registry.New(name, path, depth, exclude, kinds, libType, probeVideo)
+	if nil != ret {
+		return ret
+	}
+	list, ret := loadRegistry(options)
+	if nil != ret {
+		return ret
+	}
+	if other := registry.Find(list, r.Name); nil != other {
+		return rc.InvalidArgs.Specf("library already registered: %q (see \"lib rename\")", other.Name)
+	}
+	if other := registry.FindPath(list, r.Path); nil != other {
+		return rc.InvalidArgs.Specf("library already registered as %q: %q", other.Name, r.Path)
+	}
+	if err := os.MkdirAll(options.configDir(), os.ModePerm); nil != err {
+		return rc.InvalidConfig.Specf("cannot create configuration directory: %s", err)
+	}
+	if ret := registry.Save(registryPath(options), append(list, r)); nil != ret {
+		return ret
+	}
+	console.Info.Logf("registered library: %s", r)
+	return nil
+}
+
+// function removeRegistry() unregisters the named library.
+func removeRegistry(options *Options, name string) *rc.ReturnCode {
+
+	list, ret := loadRegistry(options)
+	if nil != ret {
+		return ret
+	}
+	r := registry.Find(list, name)
+	if nil == r {
+		return rc.InvalidArgs.Specf("no such library: %q (see \"lib list\")", name)
+	}
+	keep := []*registry.Library{}
+	for _, l := range list {
+		if l != r {
+			keep = append(keep, l)
+		}
+	}
+	if ret := registry.Save(registryPath(options), keep); nil != ret {
+		return ret
+	}
+	console.Info.Logf("unregistered library: %q (its database remains in %q)", r.Name, options.LibData.string)
+	return nil
+}
+
+// function renameRegistry() renames the named library.
+func renameRegistry(options *Options, name, newName string) *rc.ReturnCode {
+
+	list, ret := loadRegistry(options)
+	if nil != ret {
+		return ret
+	}
+	r := registry.Find(list, name)
+	if nil == r {
+		return rc.InvalidArgs.Specf("no such library: %q (see \"lib list\")", name)
+	}
+	if newName = strings.TrimSpace(newName); "" == newName {
+		return rc.InvalidArgs.Spec("library name must not be empty")
+	}
+	if other := registry.Find(list, newName); nil != other && other != r {
+		return rc.InvalidArgs.Specf("library already registered: %q", other.Name)
+	}
+	old := r.Name
+	r.Name = newName
+	if ret := registry.Save(registryPath(options), list); nil != ret {
+		return ret
+	}
+	console.Info.Logf("renamed library: %q to %q", old, newName)
+	return nil
+}
+
+// function pruneCache() removes the least recently used files from the file
+// cache until the rest total no more than the given size (e.g. "100MiB"), or
+// else -cachesize, or removes them all if all is true. with -dryrun, the files
+// are only listed.
+func pruneCache(options *Options, maxSize string, all bool) *rc.ReturnCode {
+
+	files := fileCache(options)
+	limit := files.MaxSize()
+	if "" != strings.TrimSpace(maxSize) {
+		var ret *rc.ReturnCode
+		if limit, ret = library.ParseSize(maxSize); nil != ret {
+			return ret
+		}
+	} else if limit <= 0 {
+		console.Info.Logf("the cache is unlimited, nothing to prune (see -%s)", options.CacheSize.name)
+		return nil
+	}
+	if all {
+		limit = 0
+	}
+	_, total, ret := files.Entries()
+	if nil != ret {
+		return ret
+	}
+	removed, ret := files.Prune(limit, options.DryRun.bool)
+	var size int64
+	for _, e := range removed {
+		size += e.Size
+		if options.DryRun.bool {
+			console.Info.Logf("dry run: would remove: %q (%s, last used %s)",
+				e.Key, report.HumanSize(e.Size), e.Used.Local().Format("2006-01-02"))
+		} else {
+			console.Info.Verbosef("removed: %q (%s)", e.Key, report.HumanSize(e.Size))
+		}
+	}
+	if nil != ret {
+		return ret
+	}
+	verb := "removed"
+	if options.DryRun.bool {
+		verb = "would remove"
+	}
+	console.Info.Logf("%s %d files (%s) from the cache, %s remain: %q",
+		verb, len(removed), report.HumanSize(size), report.HumanSize(total-size), files.Dir())
+	return nil
+}
+
+// function showConfig() writes the value of every option and where it came
+// from. if initialize is true, a new config file is written instead, which
+// replaces an existing one only if force is true.
+func showConfig(options *Options, initialize, force bool) *rc.ReturnCode {
+
+	if initialize {
+		path := options.Config.string
+		if _, err := os.Stat(path); nil == err {
+			if !force {
+				return rc.InvalidConfig.Specf("config file exists: %q (see \"config -force\")", path)
+			}
+			if err := os.Remove(path); nil != err {
+				return rc.InvalidConfig.Specf("cannot replace config file: %q: %s", path, err)
+			}
+		}
+		if ret := writeConfig(options, path); nil != ret {
+			return ret
+		}
+		console.Info.Logf("created configuration: %q", path)
+		return nil
+	}
+
+	w, _, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+
+	options.VisitAll(func(f *flag.Flag) {
+		source := SourceDefault
+		if o, ok := options.Provided[f.Name]; ok {
+			source = o.source
+		}
+		fmt.Fprintf(w, "%s = %s\t(%s)\n", f.Name, f.Value, source)
+	})
+	return nil
+}
+
+// function backupLibrary() copies the databases of the given libraries into a
+// new directory, named after the current time, in the given directory (or the
+// -libdata directory, if empty).
+func backupLibrary(options *Options, libs []*library.Library, dir string) *rc.ReturnCode {
+
+	if "" == dir {
+		dir = options.LibData.string
+	}
+	dest := filepath.Join(dir, "backup-"+time.Now().Format("2006-01-02_150405"))
+	for _, l := range libs {
+		path, ret := l.DB().Backup(dest)
+		if nil != ret {
+			return ret
+		}
+		console.Info.Logf("backed up library %q: %q", l.Name(), path)
+	}
+	return nil
+}
+
+// function exportDatabase() writes every record of the given library's
+// database to the file at the given path, which is replaced only if force is
+// true.
+func exportDatabase(options *Options, libs []*library.Library, file string, force bool) *rc.ReturnCode {
+
+	if 1 != len(libs) {
+		return rc.InvalidArgs.Specf("db export: exactly one library required (%d given)", len(libs))
+	}
+	absFile, err := filepath.Abs(file)
+	if nil != err {
+		return rc.InvalidPath.Specf("invalid export path: %q: %s", file, err)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(absFile, flags, 0644)
+	if nil != err {
+		if os.IsExist(err) {
+			return rc.ExportError.Specf("export file exists: %q (see \"db export -force\")", absFile)
+		}
+		return rc.ExportError.Specf("cannot create export file: %q: %s", absFile, err)
+	}
+	ret := libs[0].DB().Export(f)
+	if err := f.Close(); nil == ret && nil != err {
+		ret = rc.ExportError.Specf("cannot write export file: %q: %s", absFile, err)
+	}
+	if nil != ret {
+		return ret
+	}
+	console.Info.Logf("exported database of library %q: %q", libs[0].Name(), absFile)
+	return nil
+}
+
+// function importDatabase() inserts the records of the file at the given path,
+// written by exportDatabase(), into the given library's database, deleting its
+// records first if replace is true.
+func importDatabase(options *Options, libs []*library.Library, file string, replace bool) *rc.ReturnCode {
+
+	if 1 != len(libs) {
+		return rc.InvalidArgs.Specf("db import: exactly one library required (%d given)", len(libs))
+	}
+	f, err := os.Open(file)
+	if nil != err {
+		return rc.ImportError.Specf("cannot open import file: %q: %s", file, err)
+	}
+	defer f.Close()
+	count, ret := libs[0].DB().Import(f, replace)
+	if nil != ret {
+		return ret
+	}
+	console.Info.Logf("imported %d records into library %q", count, libs[0].Name())
+	return nil
+}
+
+// function fetchMetadata() fills in the metadata of the selected media in the
+// given libraries from online databases: videos from the named provider, and
+// audio from MusicBrainz. media already described (videos having a synopsis,
+// audio having an artist and album) are skipped unless all is true. each
+// response is cached in the -libdata directory, so only the cache is consulted
+// if offline is true.
+func fetchMetadata(options *Options, libs []*library.Library, source string, offline, all bool) *rc.ReturnCode {
+
+	cache := provider.NewCache(filepath.Join(options.LibData.string, provider.CacheDirName))
+	cache.SetOffline(offline)
+
+	apiKey, keyOption := options.TMDBKey.string, options.TMDBKey.name
+	if strings.EqualFold("tvdb", strings.TrimSpace(source)) {
+		apiKey, keyOption = options.TVDBKey.string, options.TVDBKey.name
+	}
+	if offline {
+		apiKey = "offline" // never used, since no requests are made
+	}
+	var video provider.Provider
+	if "" == strings.TrimSpace(apiKey) {
+		console.Warn.Logf("no API key given for provider %q, skipping videos (see option -%s)",
+			source, keyOption)
+	} else {
+		p, ret := provider.New(source, apiKey, cache)
+		if nil != ret {
+			return ret
+		}
+		video = p
+	}
+
+	acoustIDKey := options.AcoustIDKey.string
+	if "" != acoustIDKey && !provider.CanFingerprint() {
+		console.Warn.Verbosef("fpcalc not found, identifying only tagged audio (see option -%s)",
+			options.AcoustIDKey.name)
+		acoustIDKey = ""
+	}
+	audio := provider.NewMusicBrainz(acoustIDKey, cache)
+
+	accept, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+	var numUpdated, numUnchanged, numMissing uint
+	for _, l := range libs {
+		for _, ent := range loadEntities([]*library.Library{l}, accept) {
+			var (
+				desc    fmt.Stringer
+				found   bool
+				updated bool
+				ret     *rc.ReturnCode
+				absPath string
+			)
+			switch m := ent.(type) {
+			case *media.VideoMedia:
+				q, ok := provider.NewQuery(m)
+				if nil == video || !ok || (!all && "" != m.Description && "--" != m.Description) {
+					continue
+				}
+				var item *migrate.Item
+				if item, ret = video.Lookup(q); nil != item {
+					found = true
+					updated, ret = l.UpdateMedia(m.AbsPath, item.Apply)
+				}
+				desc, absPath = q, m.AbsPath
+			case *media.AudioMedia:
+				q, ok := provider.NewTrackQuery(m)
+				if !ok || (!all && "" != m.Artist && "" != m.Album) {
+					continue
+				}
+				var track *provider.Track
+				if track, ret = audio.LookupTrack(q); nil != track {
+					found = true
+					updated, ret = l.UpdateAudio(m.AbsPath, track.Apply)
+				}
+				desc, absPath = q, m.AbsPath
+			default:
+				continue
+			}
+			switch {
+			case nil != ret:
+				console.Warn.Log(ret)
+				numMissing++
+			case !found:
+				console.Warn.Verbosef("not found: %s (%q)", desc, absPath)
+				numMissing++
+			case updated:
+				console.Info.Tracef("fetched %s: %q", desc, absPath)
+				numUpdated++
+			default:
+				numUnchanged++
+			}
+		}
+	}
+	console.Info.Logf("finished fetching (%d updated, %d unchanged, %d not found)",
+		numUpdated, numUnchanged, numMissing)
+	return nil
+}
@@ -0,0 +1,314 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: config.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the configuration file, in TOML format, and the environment
+//    variables, supplying the value of any option not given on the command
+//    line.
+//
+// =============================================================================
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/library"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant envPrefix is prepended to the (upper case) name of an option to
+// form the name of the environment variable setting it, e.g. PIMMP_LIBDATA.
+const envPrefix = "PIMMP_"
+
+// the options never read from or written to the configuration file, because
+// they are meaningless there.
+var configExclude = map[string]bool{
+	"config": true,
+	"help":   true,
+	"daemon": true, // every invocation would start a daemon
+}
+
+// the options formerly global, which have since become options of the
+// subcommands using them. a configuration file still defining any of them is
+// loaded all the same, without them.
+var configRetired = map[string]bool{
+	"force": true, "exportfile": true, "exportrelative": true, "reportformat": true,
+	"dulimit": true, "trashdir": true, "tags": true, "profile": true,
+	"hidetags": true, "hideratings": true, "hidepaths": true, "pin": true,
+	"snapshot": true, "importfile": true, "importpathmap": true,
+}
+
+// function loadConfig() reads the TOML configuration file at the given path,
+// setting each option it defines that wasn't already provided on the command
+// line. options whose value is changed by the file are then also provided, as
+// far as Options.Provided is concerned. a file that doesn't exist defines no
+// options. returns the names of the options set by the file, sorted.
+func loadConfig(options *Options, known NamedOption, path string) ([]string, *rc.ReturnCode) {
+
+	value := map[string]interface{}{}
+	if _, err := toml.DecodeFile(path, &value); nil != err {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, rc.InvalidConfig.Specf("loadConfig(%q): %s", path, err)
+	}
+
+	key := make([]string, 0, len(value))
+	for k := range value {
+		key = append(key, k)
+	}
+	sort.Strings(key)
+
+	set := []string{}
+	for _, k := range key {
+		if configRetired[k] {
+			console.Warn.Logf("loadConfig(%q): ignoring option %q, now given to the subcommands using it (see \"%s %s subcommand\")",
+				path, k, identity, cmdHelp)
+			continue
+		}
+		f := options.Lookup(k)
+		if _, ok := known[k]; !ok || nil == f || configExclude[k] {
+			return nil, rc.InvalidConfig.Specf("loadConfig(%q): unknown option: %q", path, k)
+		}
+		if _, ok := options.Provided[k]; ok {
+			continue // the command line always wins
+		}
+		var str string
+		switch v := value[k].(type) {
+		case bool, int64, float64, string:
+			str = fmt.Sprint(v)
+		default:
+			return nil, rc.InvalidConfig.Specf("loadConfig(%q): option %q: unsupported value: %v", path, k, v)
+		}
+		was := f.Value.String()
+		if err := options.Set(k, str); nil != err {
+			return nil, rc.InvalidConfig.Specf("loadConfig(%q): option %q: %s", path, k, err)
+		}
+		// a value identical to the default is as good as not provided.
+		if f.Value.String() != was {
+			options.Provided[k] = known[k]
+			options.Provided[k].source = SourceConfig
+			set = append(set, k)
+		}
+	}
+	return set, nil
+}
+
+// function loadEnv() sets each option that wasn't already provided on the
+// command line from its environment variable (see envPrefix), if defined and
+// not empty. options set this way are then also provided, as far as
+// Options.Provided is concerned. returns the names of the options set by the
+// environment, sorted.
+func loadEnv(options *Options, known NamedOption) ([]string, *rc.ReturnCode) {
+
+	set := []string{}
+	var ret *rc.ReturnCode = nil
+	options.VisitAll(func(f *flag.Flag) {
+		if _, ok := options.Provided[f.Name]; ok || nil != ret {
+			return // the command line always wins
+		}
+		if _, ok := known[f.Name]; !ok || "help" == f.Name {
+			return
+		}
+		name := envPrefix + strings.ToUpper(f.Name)
+		value, ok := os.LookupEnv(name)
+		if !ok || "" == value {
+			return
+		}
+		if err := options.Set(f.Name, value); nil != err {
+			ret = rc.InvalidConfig.Specf("loadEnv(): %s: %s", name, err)
+			return
+		}
+		options.Provided[f.Name] = known[f.Name]
+		options.Provided[f.Name].source = SourceEnv
+		set = append(set, f.Name)
+	})
+	if nil != ret {
+		return nil, ret
+	}
+	return set, nil
+}
+
+// function reloadConfig() reads the configuration file again, applying the
+// options that can safely change while running: the log levels (-loglevel)
+// take effect immediately, and the patterns of the files never scanned
+// (-exclude) with the next scan of the given libraries. each is reset to its
+// default value if the file no longer defines it, but those given on the
+// command line or in the environment keep taking precedence. nothing changes
+// unless all of them are valid. returns the names of the options changed.
+func reloadConfig(options *Options, libs []*library.Library) ([]string, *rc.ReturnCode) {
+
+	path := options.Config.string
+	value := map[string]interface{}{}
+	if _, err := toml.DecodeFile(path, &value); nil != err && !os.IsNotExist(err) {
+		return nil, rc.InvalidConfig.Specf("reloadConfig(%q): %s", path, err)
+	}
+
+	// both options hold strings, which are replaced outright rather than Set(),
+	// since setting -exclude appends to it.
+	next := map[*Option]string{}
+	for _, o := range []*Option{options.LogLevel, options.Exclude} {
+		if o.source > SourceConfig {
+			continue // the command line always wins
+		}
+		str := options.Lookup(o.name).DefValue
+		if v, ok := value[o.name]; ok {
+			if str, ok = v.(string); !ok {
+				return nil, rc.InvalidConfig.Specf("reloadConfig(%q): option %q: unsupported value: %v", path, o.name, v)
+			}
+		}
+		if str != o.string {
+			next[o] = str
+		}
+	}
+	if str, ok := next[options.LogLevel]; ok {
+		if _, _, ret := console.ParseLevels(str); nil != ret {
+			return nil, ret
+		}
+	}
+	if str, ok := next[options.Exclude]; ok {
+		for _, l := range libs {
+			if ret := l.SetExclude(splitList(str)); nil != ret {
+				return nil, ret
+			}
+		}
+	}
+
+	set := []string{}
+	for o, str := range next {
+		o.string = str
+		if str == options.Lookup(o.name).DefValue {
+			delete(options.Provided, o.name)
+			o.source = SourceDefault
+		} else {
+			options.Provided[o.name] = o
+			o.source = SourceConfig
+		}
+		set = append(set, o.name)
+	}
+	sort.Strings(set)
+	level, filter, ret := options.logLevels()
+	if nil != ret {
+		return nil, ret
+	}
+	console.SetLevel(level, filter)
+	return set, nil
+}
+
+// function writeConfig() writes a TOML configuration file to the given path,
+// defining every option with its default value and described by its usage.
+// an existing file is never replaced.
+func writeConfig(options *Options, path string) *rc.ReturnCode {
+
+	if _, err := os.Stat(path); nil == err {
+		return rc.InvalidConfig.Specf("writeConfig(%q): file exists", path)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s configuration (TOML)\n", identity)
+	fmt.Fprintf(&sb, "#\n# options given on the command line take precedence over those defined\n")
+	fmt.Fprintf(&sb, "# here. run \"%s -help\" for a summary of all options.\n", identity)
+
+	var ret *rc.ReturnCode = nil
+	options.VisitAll(func(f *flag.Flag) {
+		if configExclude[f.Name] || nil != ret {
+			return
+		}
+		value, r := configValue(f)
+		if nil != r {
+			ret = r
+			return
+		}
+		usage := strings.Replace(f.Usage, "\n", "\n# ", -1)
+		fmt.Fprintf(&sb, "\n# %s\n%s = %s\n", usage, f.Name, value)
+	})
+	if nil != ret {
+		return ret
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); nil != err {
+		return rc.InvalidConfig.Specf("writeConfig(%q): os.MkdirAll(): %s", path, err)
+	}
+	if err := ioutil.WriteFile(path, []byte(sb.String()), 0644); nil != err {
+		return rc.InvalidConfig.Specf("writeConfig(%q): %s", path, err)
+	}
+	return nil
+}
+
+// function configValue() returns the default value of the given option encoded
+// as a TOML value of the option's type. all others, including durations, are
+// written as strings, e.g. "1h30m0s", since TOML has no such type.
+func configValue(f *flag.Flag) (string, *rc.ReturnCode) {
+
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return tomlString(f.DefValue), nil
+	}
+	var err error
+	switch getter.Get().(type) {
+	case bool:
+		_, err = strconv.ParseBool(f.DefValue)
+	case int, int64, uint, uint64:
+		_, err = strconv.ParseInt(f.DefValue, 10, 64)
+	case float64:
+		var v float64
+		if v, err = strconv.ParseFloat(f.DefValue, 64); nil == err {
+			// TOML floats always have a fractional part or exponent.
+			s := strconv.FormatFloat(v, 'f', -1, 64)
+			if !strings.ContainsAny(s, ".eE") {
+				s += ".0"
+			}
+			return s, nil
+		}
+	default:
+		return tomlString(f.DefValue), nil
+	}
+	if nil != err {
+		return "", rc.InvalidConfig.Specf("configValue(%q): invalid default %q: %s", f.Name, f.DefValue, err)
+	}
+	return f.DefValue, nil
+}
+
+// function tomlString() encodes the given string as a TOML basic string.
+func tomlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			if unicode.IsControl(r) {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
@@ -0,0 +1,98 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: daemon.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the daemon started by option -daemon: another instance of pimmp,
+//    detached from the terminal, which keeps watching (or serving) the
+//    libraries in the background.
+//
+// =============================================================================
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"ardnew.com/pimmp/pkg/pidfile"
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constants identifying the daemon and the files it writes in the
+// configuration directory.
+const (
+	daemonEnv         = "PIMMP_DETACHED" // defined in the environment of the daemon by the process starting it
+	daemonPIDFileName = "pimmp.pid"
+	daemonLogFileName = "pimmp.log"
+)
+
+// function checkDaemon() verifies that the daemon has something to keep doing
+// in the background: watching the libraries, or serving them ("serve").
+func checkDaemon(options *Options) *rc.ReturnCode {
+	if nil != options.subcommand && "serve" != options.subcommand.name {
+		return rc.InvalidArgs.Specf(
+			"-%s only watches the libraries, or serves them with \"serve\"", options.Daemon.name)
+	}
+	return nil
+}
+
+// function daemonPIDPath() returns the path of the file containing the process
+// ID of the daemon, while it runs.
+func daemonPIDPath(options *Options) string {
+	return filepath.Join(options.configDir(), daemonPIDFileName)
+}
+
+// function startDaemon() starts another instance of this program with the same
+// arguments, detached from the terminal, returning its process ID and the path
+// of the file to which it logs: the -log file, if given, or else the daemon log
+// file in the configuration directory, to which all of its output is appended.
+func startDaemon(options *Options) (int, string, *rc.ReturnCode) {
+
+	if pid, alive := pidfile.Owner(daemonPIDPath(options)); alive {
+		return 0, "", rc.Locked.Specf("startDaemon(): already running (PID %d)", pid)
+	}
+	exe, err := os.Executable()
+	if nil != err {
+		return 0, "", rc.InvalidPath.Specf("startDaemon(): os.Executable(): %s", err)
+	}
+
+	configDir := options.configDir()
+	if err := os.MkdirAll(configDir, os.ModePerm); nil != err {
+		return 0, "", rc.InvalidConfig.Specf("startDaemon(): os.MkdirAll(%q): %s", configDir, err)
+	}
+	logPath := filepath.Join(configDir, daemonLogFileName)
+	out, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if nil != err {
+		return 0, "", rc.InvalidPath.Specf("startDaemon(): os.OpenFile(%q): %s", logPath, err)
+	}
+	defer out.Close()
+	// the daemon creates the -log file itself, like any other instance, in
+	// which case the daemon log file only receives the output of a panic.
+	if p, ok := options.Provided[options.LogPath.name]; ok {
+		logPath = p.string
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), daemonEnv+"=1")
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.SysProcAttr = platform.DetachedProcess()
+	if err := cmd.Start(); nil != err {
+		return 0, "", rc.InvalidArgs.Specf("startDaemon(): %q: %s", exe, err)
+	}
+	pid := cmd.Process.Pid
+	cmd.Process.Release()
+	return pid, logPath, nil
+}
+
+// function lockDaemon() writes the process ID of the daemon to its PID file,
+// failing if another daemon is already running.
+func lockDaemon(options *Options) (*pidfile.File, *rc.ReturnCode) {
+	return pidfile.Acquire(daemonPIDPath(options))
+}
@@ -0,0 +1,2653 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 29 Nov 2018
+//  FILE: layout.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines overall layout and management of UI widgets, keypress event
+//    handlers, and other high-level user interactions.
+//
+// =============================================================================
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/gdamore/tcell"
+	"github.com/rivo/tview"
+
+	"ardnew.com/pimmp/pkg/collection"
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/fuzzy"
+	"ardnew.com/pimmp/pkg/library"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/query"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/report"
+	"ardnew.com/pimmp/pkg/storage"
+	"ardnew.com/pimmp/pkg/thumbnail"
+)
+
+const (
+	sideColumnWidth = 32
+	logRowsHeight   = 6 // number of visible log lines + 1
+
+	browseBusyTask      = "updating media browser" // labels the BusyState while the browser is repopulated
+	statusProgressWidth = 20                       // width of the progress bar in the status bar
+)
+
+// the various refresh rates for the UI intended to lighten the CPU load when
+// idle or not actively in use, while remaining highly responsive when active.
+var (
+	idleUpdateFreq time.Duration = 30 * time.Second
+	busyUpdateFreq time.Duration = 100 * time.Millisecond
+)
+
+var (
+	// the term "interactive" is used to mean an item has a dedicated, keyboard-
+	// driven key combo, so that it behaves much like a button.
+	colorScheme = struct {
+		backgroundPrimary   tcell.Color // main background color
+		backgroundSecondary tcell.Color // background color of modal windows
+		backgroundTertiary  tcell.Color // background of dropdown menus, etc.
+		inactiveText        tcell.Color // non-interactive info, secondary or unfocused
+		activeText          tcell.Color // non-interactive info, primary or focused
+		inactiveMenuText    tcell.Color // unselected interactive text
+		activeMenuText      tcell.Color // selected interactive text
+		activeBorder        tcell.Color // border of active/modal views
+		highlightPrimary    tcell.Color // active selections and prominent indicators
+		highlightSecondary  tcell.Color // dynamic persistent status info
+		highlightTertiary   tcell.Color // dynamic temporary status info
+	}{
+		backgroundPrimary:   tcell.ColorBlack,
+		backgroundSecondary: tcell.ColorDarkSlateGray,
+		backgroundTertiary:  tcell.ColorSkyblue,
+		inactiveText:        tcell.ColorDarkSlateGray,
+		activeText:          tcell.ColorWhiteSmoke,
+		inactiveMenuText:    tcell.ColorSkyblue,
+		activeMenuText:      tcell.ColorDodgerBlue,
+		activeBorder:        tcell.ColorSkyblue,
+		highlightPrimary:    tcell.ColorDarkOrange,
+		highlightSecondary:  tcell.ColorDodgerBlue,
+		highlightTertiary:   tcell.ColorGreenYellow,
+	}
+)
+
+// function init() offers an early opportunity to override some of the constants
+// defined in external libs like tview.
+func init() {
+	// color overrides for the primitives initialized by tview.
+	tview.Styles.ContrastBackgroundColor = colorScheme.backgroundSecondary
+	tview.Styles.MoreContrastBackgroundColor = colorScheme.backgroundTertiary
+	tview.Styles.BorderColor = colorScheme.activeText
+	tview.Styles.PrimaryTextColor = colorScheme.activeText
+}
+
+func busyMessage(intent string) string {
+	return fmt.Sprintf("(not ready) please wait until the current operation completes to %s.", intent)
+}
+
+// type FocusDelegator defines the methods that must exist for any widget or
+// visual entity that can be focused for interaction by the user.
+type FocusDelegator interface {
+	desc() string // identity for debugging!
+	setDelegates(*Layout, FocusDelegator, FocusDelegator)
+	page() string
+	next() FocusDelegator
+	prev() FocusDelegator
+	focus() // you must NOT write to the (*Layout).focusQueue from either of
+	blur()  // these methods, or you -will- cause deadlock!!
+}
+
+// type Layout holds the high level components of the terminal user interface
+// as well as the main tview runtime API object tview.Application.
+type Layout struct {
+	ui     *tview.Application
+	option *Options
+	lib    []*library.Library
+	busy   *library.BusyState
+
+	pages     *tview.Pages
+	pagesRoot string
+
+	root *tview.Grid
+
+	quitModal  *QuitDialog
+	helpInfo   *HelpInfoView
+	libSelect  *LibSelectView
+	libTree    *LibTreeView
+	browseView *BrowseView
+	detailView *DetailView
+	logView    *LogView
+	usageView  *DiskUsageView
+	statsView  *DashboardView
+	issueView  *ProblemsView
+	searchView *SearchView
+
+	focusQueue chan FocusDelegator
+	focusLock  sync.Mutex
+	focusBase  FocusDelegator
+	focused    FocusDelegator
+
+	eventQueue chan func()
+
+	// NOTE: this vars below won't get set until one of the draw routines which
+	// uses a tcell.Screen is called, so be careful when accessing them -- make
+	// sure they're actually available.
+	screen *tcell.Screen
+}
+
+// function show() starts drawing the user interface.
+func (l *Layout) show() *rc.ReturnCode {
+
+	// zeroized Time is some time in the distant past.
+	lastUpdate := time.Time{}
+
+	// queues a screen refresh on the tview event queue and updates the time
+	// marker representing the last moment in time we updated.
+	redraw := func(f func()) {
+		l.ui.QueueUpdateDraw(f)
+		lastUpdate = time.Now()
+	}
+
+	// timer forcing the app to redraw any areas that may have changed. this
+	// update frequency is dynamic -- more frequent while the "Busy" indicator
+	// is active, less frequent while it isn't.
+	go func(l *Layout) {
+
+		// use the CPU-intensive frequency by default to err on the side of
+		// caution.
+		updateFreq := busyUpdateFreq
+
+		// observe the busy state for the lifetime of the UI.
+		busyChanged := l.busy.Subscribe()
+
+		// updates the currently selected refresh rate only if the requested
+		// rate is different from the current.
+		setFreq := func(curr, freq *time.Duration) bool {
+			if *curr != *freq {
+				*curr = *freq
+				return true
+			}
+			return false
+		}
+
+		// let's see if i can describe this. lots of nested loops, lots of break
+		// statements, not particularly intuitive. most of these loops are
+		// infinite and unconditional and serve primarily to poll channels at
+		// regular intervals. i'll describe each loop at the level it appears.
+
+		// this outer-most loop simply provides a mechanism to reset the ticker,
+		// so that the refresh rate can be changed dynamically:
+		//   1. create a ticker with the currently selected frequency
+		//   2. start infinite loop, breaking out -only-if- frequency changed.
+		//   3. dispose of ticker, return to 1 (we can only reach here if the
+		//      frequency changed, breaking out of the outer loop).
+		for {
+			tick := time.NewTicker(updateFreq)
+		REFRESH:
+			// this is the actual main draw cycle, iterating only as frequently
+			// as our currently selected tick duration -- which should be longer
+			// if we are idle, shorter if we are busy and constantly redrawing.
+			for {
+				select {
+
+				case <-tick.C:
+				DRAIN:
+					// at each fired tick event, check if we've added any events
+					// to our private internal event queue. if so, immediately
+					// pull out -all- events, process them, and then perform a
+					// single screen draw refresh. this prevents flicker and
+					// ensures all updates get handled without starving out all
+					// other goroutines.
+					for eventsHandled := 0; ; {
+						select {
+						case event := <-l.eventQueue:
+							// we have an event in the queue, so inc the counter
+							// to indicate we will need to redraw the screen.
+							if event != nil {
+								eventsHandled++
+								event()
+							}
+						default:
+							// if nothing in the queue, then this for-loop will
+							// have its body evaluated only a single time each
+							// draw cycle to then break out and check again.
+
+							// we need to redraw the screen because a change was
+							// made to one of the primitives.
+							shouldDrawForEvent := eventsHandled > 0
+							// we need to redraw the screen because
+							shouldDrawForTimeout := time.Since(lastUpdate) > idleUpdateFreq
+							if shouldDrawForEvent || shouldDrawForTimeout {
+								redraw(func() {})
+							}
+							break DRAIN
+						}
+					}
+
+				case change := <-busyChanged:
+					// if the frequency changed, perform one last screen refresh
+					// before updating the draw cycle duration. the duration is
+					// selected based on the number of goroutines which have
+					// incremented the BusyState semaphore. if non-zero, then we
+					// have active goroutines and need to frequently redraw the
+					// screen. otherwise, we are basically idle and only need to
+					// redraw occassionally.
+					redraw(func() {})
+					// use setFreq() so that we kill the Ticker and alloc a new
+					// one if and only if the duration actually changed.
+					switch change.Count {
+					case 0:
+						if setFreq(&updateFreq, &idleUpdateFreq) {
+							break REFRESH
+						}
+					default:
+						if setFreq(&updateFreq, &busyUpdateFreq) {
+							break REFRESH
+						}
+					}
+
+				// signal monitor for refocus requests. when an event occurs
+				// that requires a new widget to be focused, this routine will
+				// call the interface-compliant widgets' event handlers to
+				// blur() and focus() the old and new widgets, respectively.
+				case delegate := <-l.focusQueue:
+					if delegate != nil {
+						l.focusLock.Lock()
+						if delegate != l.focused {
+							if l.focused != nil {
+								l.focused.blur()
+							}
+							delegate.focus()
+							// update afterwards so that the focus() method can
+							// make decisions based on which was previously
+							// focused.
+							l.focused = delegate
+						}
+						l.focusLock.Unlock()
+						redraw(func() {})
+					}
+				}
+			}
+			tick.Stop()
+		}
+	}(l)
+
+	// the default view to focus when no other view is explicitly requested
+	l.focusBase = l.browseView
+	l.focusQueue <- l.focusBase
+
+	l.logView.ScrollToEnd()
+
+	if err := l.ui.Run(); err != nil {
+		return rc.TUIError.Specf("show(): ui.Run(): %s", err)
+	}
+	return nil
+}
+
+func stop(ui *tview.Application) {
+	if nil != ui {
+		ui.Stop()
+	}
+}
+
+// function newLayout() creates the initial layout of the user interface and
+// populates it with the primary widgets. each Library passed in as argument
+// has its Layout field initialized with this instance.
+func newLayout(opt *Options, busy *library.BusyState, lib ...*library.Library) *Layout {
+
+	var layout Layout
+
+	ui := tview.NewApplication()
+
+	header := tview.NewBox().
+		SetBorder(false)
+
+	collections := loadCollections(opt)
+
+	libTree := newLibTreeView(ui, "root", lib, collections)
+	browseView := newBrowseView(ui, "root", lib)
+	detailView := newDetailView(ui, lib)
+	logView := newLogView(ui, "root", lib)
+
+	footer := tview.NewBox().
+		SetBorder(false)
+
+	root := tview.NewGrid().
+		// these are actual sizes, in terms of addressable terminal locations,
+		// i.e. characters and lines. the literal width and height values in the
+		// arguments to AddItem() are the logical sizes, in terms of rows and
+		// columns that are laid out by the arguments to SetRows()/SetColumns().
+		SetRows(1, 0, logRowsHeight, 1).
+		SetColumns(sideColumnWidth, 0, sideColumnWidth).
+		// fixed components that are always visible
+		AddItem(header /******/, 0, 0, 1, 3, 0, 0, false).
+		AddItem(libTree /*****/, 1, 0, 1, 1, 0, 0, false).
+		AddItem(browseView /**/, 1, 1, 1, 1, 0, 0, false).
+		AddItem(detailView /**/, 1, 2, 1, 1, 0, 0, false).
+		AddItem(logView /*****/, 2, 0, 1, 3, 0, 0, false).
+		AddItem(footer /******/, 3, 0, 1, 3, 0, 0, false)
+
+	root. // other options for the primary layout grid
+		SetBorders(true)
+
+	quitModal := newQuitDialog(ui, "quitModal", lib)
+	libSelect := newLibSelectView(ui, "libSelect", lib, collections)
+	helpInfo := newHelpInfoView(ui, "helpInfo", lib)
+	usageView := newDiskUsageView(ui, "usageView", lib)
+	statsView := newDashboardView(ui, "statsView", lib)
+	issueView := newProblemsView(ui, "issueView", lib)
+	searchView := newSearchView(ui, "searchView", lib)
+
+	pages := tview.NewPages().
+		AddPage("root", root, true, true).
+		AddPage(quitModal.page(), quitModal, false, true).
+		AddPage(libSelect.page(), libSelect, false, true).
+		AddPage(helpInfo.page(), helpInfo, false, true).
+		AddPage(usageView.page(), usageView, false, true).
+		AddPage(statsView.page(), statsView, false, true).
+		AddPage(issueView.page(), issueView, false, true).
+		AddPage(searchView.page(), searchView, false, true)
+
+	header. // register the header bar screen drawing callback
+		SetDrawFunc(layout.drawMenuBar)
+
+	footer. // register the status bar screen drawing callback
+		SetDrawFunc(layout.drawStatusBar)
+
+	// define the higher-order tab cycle among the panes of the root page:
+	// library tree -> media list -> log view -> library tree ...
+	libTree.setDelegates(&layout, logView, browseView)
+	browseView.setDelegates(&layout, libTree, logView)
+	logView.setDelegates(&layout, browseView, libTree)
+	detailView.layout = &layout
+	quitModal.setDelegates(&layout, nil, nil)
+	libSelect.setDelegates(&layout, nil, nil)
+	helpInfo.setDelegates(&layout, nil, nil)
+	usageView.setDelegates(&layout, nil, nil)
+	statsView.setDelegates(&layout, nil, nil)
+	issueView.setDelegates(&layout, nil, nil)
+	searchView.setDelegates(&layout, nil, nil)
+
+	// and finally initialize our actual Layout object to be returned
+	layout = Layout{
+		ui:     ui,
+		option: opt,
+		lib:    lib,
+		busy:   busy,
+
+		pages:     pages,
+		pagesRoot: "root",
+
+		root: root,
+
+		quitModal:  quitModal,
+		helpInfo:   helpInfo,
+		libSelect:  libSelect,
+		libTree:    libTree,
+		browseView: browseView,
+		detailView: detailView,
+		logView:    logView,
+		usageView:  usageView,
+		statsView:  statsView,
+		issueView:  issueView,
+		searchView: searchView,
+
+		focusQueue: make(chan FocusDelegator),
+		focusLock:  sync.Mutex{},
+		focusBase:  nil,
+		focused:    nil,
+
+		eventQueue: make(chan func()),
+
+		screen: nil,
+	}
+
+	// the detail pane always describes the media selected in the media list.
+	browseView.
+		setChangedFunc(func(index int, mainText, secondaryText string) {
+			detailView.update(browseView.itemAt(index))
+		})
+
+	// set the initial page displayed when application begins
+	pages.SwitchToPage(layout.pagesRoot)
+
+	ui. // global tview application configuration
+		SetRoot(pages, true).
+		SetInputCapture(layout.inputEvent)
+
+	// manually initiate the event handler for selecting the "(All)"-libraries
+	// dropdown to update the meta info in the LibSelectView
+	libSelect.
+		selectedLibDropDown(selectedLibraryAllOption, selectedLibraryAll)
+
+	return &layout
+}
+
+func (l *Layout) shouldDelegateInputEvent(busy bool, event *tcell.EventKey) bool {
+
+	l.focusLock.Lock()
+	focused := l.focused
+	l.focusLock.Unlock()
+
+	switch focused.(type) {
+	case *LogView:
+		switch event.Key() {
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'h', 'H', 'j', 'J', 'k', 'K', 'l', 'L':
+				// do NOT support the vi-style navigation keys in the log view
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// function inputEvent() is the application-level keyboard input event handler.
+// this function provides an opportunity to override or reject input keys before
+// ever forwarding them onto the focused view. it also defines the global key
+// event handlers such as for cycling focus among the available views.
+func (l *Layout) inputEvent(event *tcell.EventKey) *tcell.EventKey {
+
+	focusWidget := map[rune]FocusDelegator{
+		'L': l.libSelect,
+		'H': l.helpInfo,
+		'V': l.logView,
+		'U': l.usageView,
+		'S': l.statsView,
+		'P': l.issueView,
+		'/': l.searchView,
+	}
+
+	fwdEvent := event
+	isBusy := l.busy.Count() > 0
+
+	l.focusLock.Lock()
+	focused := l.focused
+	l.focusLock.Unlock()
+
+	evTime := event.When()
+	evMod := event.Modifiers()
+	evKey := event.Key()
+	evRune := event.Rune()
+
+	// catch some global, application-level events before evaluating them in the
+	// context of whatever view is currently focused.
+	switch {
+	case tcell.KeyCtrlC == evKey && isBusy && nil == l.option.ctx.Err():
+		// Ctrl+C interrupts the library scanners while they're working, each
+		// keeping whatever it has found so far.
+		fwdEvent = nil
+		console.Warn.Logf("interrupting the library scanners ...")
+		l.option.cancel()
+	case tcell.KeyCtrlC == evKey:
+		// don't exit on Ctrl+C, it feels unsanitary. instead, notify the
+		// user we can exit cleanly by simply pressing 'q'.
+		fwdEvent = nil
+		console.Warn.Logf("(ignored) please use '%c' key to terminate the "+
+			"application. ctrl keys are swallowed to prevent choking.", 'q')
+	}
+
+	navigationEvent := func(lo *Layout, busy bool, ek tcell.Key, er rune, em tcell.ModMask, et time.Time) bool {
+		switch ek {
+		case tcell.KeyTab, tcell.KeyBacktab:
+			// cycle among the panes of the root page, if one is focused.
+			var cycle FocusDelegator
+			if nil != focused {
+				if tcell.KeyTab == ek {
+					cycle = focused.next()
+				} else {
+					cycle = focused.prev()
+				}
+			}
+			if nil != cycle {
+				lo.focusQueue <- cycle
+				fwdEvent = nil
+				return true
+			}
+		case tcell.KeyRune:
+			if widget, ok := focusWidget[unicode.ToUpper(er)]; ok {
+				// do not process any navigation events (opening windows, dialogs, etc.)
+				// if our BusyState indicates we are preoccupied handling other events,
+				// unless the view we are wanting to access is the HelpView.
+				if busy && (widget != l.helpInfo) {
+					console.Warn.Logf(busyMessage("navigate or open a submenu"))
+					return false
+				}
+				lo.focusQueue <- widget
+				return true
+			}
+			// TODO: remove me, exists only for eval of color palettes
+			if fn, ok := logColors[er]; ok {
+				fn()
+				return true
+			}
+		}
+		return false
+	}
+
+	exitEvent := func(lo *Layout, ek tcell.Key, er rune, em tcell.ModMask, et time.Time) bool {
+		switch ek {
+		case tcell.KeyRune:
+			switch er {
+			case 'q', 'Q':
+				return true
+			}
+		}
+		return false
+	}
+
+	switch focused.(type) {
+
+	case *HelpInfoView:
+		if !navigationEvent(l, isBusy, evKey, evRune, evMod, evTime) {
+			switch evKey {
+			case tcell.KeyEsc, tcell.KeyRune:
+				l.focusQueue <- l.focusBase
+			}
+			if exitEvent(l, evKey, evRune, evMod, evTime) {
+				l.focusQueue <- l.quitModal
+			}
+		}
+
+	case *LibSelectView:
+		switch evKey {
+		case tcell.KeyEsc:
+			l.focusQueue <- l.focusBase
+		}
+
+	case *SearchView:
+		// every key typed is part of the search, none navigate or exit.
+		switch evKey {
+		case tcell.KeyEsc:
+			l.focusQueue <- l.focusBase
+		}
+
+	case *LibTreeView:
+		if !navigationEvent(l, isBusy, evKey, evRune, evMod, evTime) {
+			switch evKey {
+			case tcell.KeyEsc:
+				l.focusQueue <- l.focusBase
+			}
+			if exitEvent(l, evKey, evRune, evMod, evTime) {
+				l.focusQueue <- l.quitModal
+			}
+		}
+
+	case *BrowseView:
+		if !navigationEvent(l, isBusy, evKey, evRune, evMod, evTime) {
+			switch evKey {
+			case tcell.KeyEsc:
+				l.focusQueue <- l.focusBase
+			case tcell.KeyRune:
+				switch evRune {
+				case 'z', 'Z':
+					if isBusy {
+						console.Warn.Logf(busyMessage("undo changes"))
+					} else {
+						l.browseView.undoItem()
+					}
+				case 'c', 'C':
+					if isBusy {
+						console.Warn.Logf(busyMessage("select subtitles"))
+					} else {
+						l.browseView.cycleSubtitles()
+					}
+				case 't', 'T':
+					if isBusy {
+						console.Warn.Logf(busyMessage("show thumbnail"))
+					} else {
+						l.browseView.showThumbnail()
+					}
+				case '+', '=', '-', '_':
+					if isBusy {
+						console.Warn.Logf(busyMessage("rate media"))
+					} else if '+' == evRune || '=' == evRune {
+						l.browseView.rateItem(+1)
+					} else {
+						l.browseView.rateItem(-1)
+					}
+				}
+			}
+			if exitEvent(l, evKey, evRune, evMod, evTime) {
+				l.focusQueue <- l.quitModal
+			}
+		}
+
+	case *DiskUsageView, *DashboardView, *ProblemsView:
+		if !navigationEvent(l, isBusy, evKey, evRune, evMod, evTime) {
+			switch evKey {
+			case tcell.KeyEsc:
+				l.focusQueue <- l.focusBase
+			}
+			if exitEvent(l, evKey, evRune, evMod, evTime) {
+				l.focusQueue <- l.quitModal
+			}
+		}
+
+	case *LogView:
+		if !navigationEvent(l, isBusy, evKey, evRune, evMod, evTime) {
+			switch evKey {
+			case tcell.KeyEsc:
+				l.focusQueue <- l.focusBase
+			}
+			if exitEvent(l, evKey, evRune, evMod, evTime) {
+				l.focusQueue <- l.quitModal
+			}
+		}
+	}
+
+	if !l.shouldDelegateInputEvent(isBusy, event) {
+		fwdEvent = nil
+	}
+
+	return fwdEvent
+}
+
+// function drawMenuBar() is the callback handler associated with the top-most
+// header box. this routine is not called on-demand, but is usually invoked
+// implicitly by other re-draw events.
+func (l *Layout) drawMenuBar(screen tcell.Screen, x int, y int, width int, height int) (int, int, int, int) {
+
+	const (
+		libDimWidth   = 40 // library selection window width
+		libDimHeight  = 20 // ^----------------------- height
+		helpDimWidth  = 40 // help info window width
+		helpDimHeight = 10 // ^--------------- height
+		findDimWidth  = 64 // search window width
+		findDimHeight = 24 // ^------------ height
+	)
+
+	// update the layout's associated screen field. note that you must be very
+	// careful and not access this field until this status line has been drawn
+	// at least one time.
+	if nil == l.screen {
+		l.screen = &screen
+	}
+
+	l.searchView.
+		SetRect((width-findDimWidth)/2, 1, findDimWidth, findDimHeight)
+
+	l.libSelect.
+		SetRect(2, 1, libDimWidth, libDimHeight)
+
+	l.helpInfo.
+		SetRect(width-helpDimWidth, 1, helpDimWidth, helpDimHeight)
+
+	libName := l.libSelect.selectedName
+	library := fmt.Sprintf("[::bu]%s[::-]%s: [#%06x]%s", "L", "ibrary", colorScheme.highlightPrimary.Hex(), libName)
+	help := fmt.Sprintf("[::bu]%s[::-]%s", "H", "elp")
+	search := fmt.Sprintf("[::bu]%s[::-]%s", "/", " Search")
+
+	tview.Print(screen, library, x+3, y, width, tview.AlignLeft, colorScheme.inactiveMenuText)
+	tview.Print(screen, search, x, y, width, tview.AlignCenter, colorScheme.inactiveMenuText)
+	tview.Print(screen, help, x, y, width-3, tview.AlignRight, colorScheme.inactiveMenuText)
+
+	// Coordinate space for subsequent draws.
+	return 0, 0, 0, 0
+}
+
+// function drawStatusBar() is the callback handler associated with the bottom-
+// most footer box. this routine is regularly called so that the datetime clock
+// remains accurate along with any status information currently available.
+// this function is the primary driver of the BusyState's UI cycle counter.
+func (l *Layout) drawStatusBar(screen tcell.Screen, x int, y int, width int, height int) (int, int, int, int) {
+
+	// the number of periods to draw incrementally during the "working"
+	// animation is equal to: ellipses - 1
+	const ellipses = 4
+
+	// update the layout's associated screen field. note that you must be very
+	// careful and not access this field until this status line has been drawn
+	// at least one time.
+	if nil == l.screen {
+		l.screen = &screen
+	}
+
+	//dateTime := time.Now().Format("[15:04:05] Monday, January 02, 2006")
+	dateTime := time.Now().Format("2006/01/02 03:04 PM")
+
+	// Write some text along the horizontal line.
+	tview.Print(screen, dateTime, x+3, y, width, tview.AlignLeft, colorScheme.highlightSecondary)
+
+	// badge the media damaged since they were added, see "report failed".
+	if failed := atomic.LoadInt64(&numFailedVerify); failed > 0 {
+		badge := fmt.Sprintf("✗ %d failed verification", failed)
+		tview.Print(screen, badge, x, y, width, tview.AlignCenter, colorScheme.highlightTertiary)
+	}
+
+	// update the busy indicator if we have any active worker threads
+	count := l.busy.Count()
+	if count > 0 {
+		// increment the screen refresh counter
+		cycle := l.busy.Next()
+
+		// draw the "working..." indicator. note the +2 is to make room for the
+		// moon rune following this indicator.
+		working := fmt.Sprintf("working%-*s", ellipses, bytes.Repeat([]byte{'.'}, cycle%ellipses))
+		tview.Print(screen, working, x-ellipses+1, y, width, tview.AlignRight, colorScheme.highlightTertiary)
+
+		// draw the cyclic moon rotation
+		moon := fmt.Sprintf("%c ", console.MoonPhase[cycle%console.MoonPhaseLength])
+		tview.Print(screen, moon, x, y, width, tview.AlignRight, colorScheme.highlightPrimary)
+
+		// draw the progress of the most recent task reporting any, to the left
+		// of the "working..." indicator.
+		tasks := l.busy.Tasks()
+		for i := len(tasks) - 1; i >= 0; i-- {
+			if bar := progressBar(tasks[i], statusProgressWidth); "" != bar {
+				tview.Print(screen, bar, x, y, width-len("working")-2*ellipses, tview.AlignRight, colorScheme.highlightTertiary)
+				break
+			}
+		}
+	}
+
+	// Coordinate space for subsequent draws.
+	return 0, 0, 0, 0
+}
+
+// function addDiscovery() inserts the media discovered, if any, into the media
+// browser at its sorted position. support files are currently unused.
+func (l *Layout) addDiscovery(disco *library.Discovery) *rc.ReturnCode {
+
+	if item := disco.Media(); nil != item {
+		l.eventQueue <- func() {
+			position, primary, secondary := l.browseView.positionForMediaItem(item)
+			l.browseView.insertMediaItem(disco.Library, item, position, primary, secondary, nil)
+		}
+	}
+
+	return nil
+}
+
+// function removeDiscovery() removes the media at the given path, found in the
+// given library, from the media browser once its file has been removed.
+func (l *Layout) removeDiscovery(lib *library.Library, absPath string) {
+	l.eventQueue <- func() {
+		l.browseView.removeMediaPath(lib, absPath)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type QuitDialog struct {
+	*tview.Modal
+	layout    *Layout
+	focusPage string
+	focusNext FocusDelegator
+	focusPrev FocusDelegator
+}
+
+// function newQuitDialog() allocates and initializes the tview.Modal widget
+// that prompts the user to confirm before quitting the application.
+func newQuitDialog(ui *tview.Application, page string, lib []*library.Library) *QuitDialog {
+
+	prompt := "Oh, so you're a quitter, huh?"
+	button := []string{"Y-yeah...", " Fuck NO "}
+
+	view := tview.NewModal().
+		SetText(prompt).
+		AddButtons(button)
+
+	v := QuitDialog{view, nil, page, nil, nil}
+
+	v.SetDoneFunc(
+		func(buttonIndex int, buttonLabel string) {
+			switch {
+			case button[0] == buttonLabel:
+				stop(ui)
+			case button[1] == buttonLabel:
+				v.layout.focusQueue <- v.layout.focusBase
+			}
+		})
+
+	return &v
+}
+
+func (v *QuitDialog) desc() string { return "" }
+func (v *QuitDialog) setDelegates(layout *Layout, prev, next FocusDelegator) {
+	v.layout = layout
+	v.focusPrev = prev
+	v.focusNext = next
+}
+func (v *QuitDialog) page() string         { return v.focusPage }
+func (v *QuitDialog) next() FocusDelegator { return v.focusNext }
+func (v *QuitDialog) prev() FocusDelegator { return v.focusPrev }
+func (v *QuitDialog) focus() {
+	page := v.page()
+	v.layout.pages.ShowPage(page)
+}
+func (v *QuitDialog) blur() {
+	page := v.page()
+	v.layout.pages.HidePage(page)
+}
+
+//------------------------------------------------------------------------------
+
+type HelpInfoView struct {
+	*tview.Box
+	layout    *Layout
+	focusPage string
+	focusNext FocusDelegator
+	focusPrev FocusDelegator
+}
+
+// function newHelpInfoView() allocates and initializes the tview.Form widget
+// where the user selects which library to browse and any other filtering
+// options.
+func newHelpInfoView(ui *tview.Application, page string, lib []*library.Library) *HelpInfoView {
+
+	v := HelpInfoView{nil, nil, page, nil, nil}
+
+	help := tview.NewBox()
+
+	help.
+		SetBorder(true).
+		SetBorderColor(colorScheme.activeBorder).
+		SetTitle(" Help ").
+		SetTitleColor(colorScheme.activeMenuText).
+		SetTitleAlign(tview.AlignRight)
+
+	help.
+		SetDrawFunc(v.drawHelpInfoView)
+
+	v.Box = help
+
+	return &v
+}
+
+func (v *HelpInfoView) desc() string { return "" }
+func (v *HelpInfoView) setDelegates(layout *Layout, prev, next FocusDelegator) {
+	v.layout = layout
+	v.focusPrev = prev
+	v.focusNext = next
+}
+func (v *HelpInfoView) page() string         { return v.focusPage }
+func (v *HelpInfoView) next() FocusDelegator { return v.focusNext }
+func (v *HelpInfoView) prev() FocusDelegator { return v.focusPrev }
+func (v *HelpInfoView) focus() {
+	page := v.page()
+	v.layout.pages.ShowPage(page)
+}
+func (v *HelpInfoView) blur() {
+	page := v.page()
+	v.layout.pages.HidePage(page)
+}
+func (v *HelpInfoView) drawHelpInfoView(screen tcell.Screen, x int, y int, width int, height int) (int, int, int, int) {
+
+	swvers := fmt.Sprintf(" %s v%s (%s) ", identity, version, revision)
+
+	tview.Print(screen, swvers, x+1, y, width-2, tview.AlignLeft, colorScheme.highlightPrimary)
+
+	// Coordinate space for subsequent draws.
+	return 0, 0, 0, 0
+}
+
+//------------------------------------------------------------------------------
+
+type LibSelectViewFormItem int
+
+const (
+	lsiLibrary LibSelectViewFormItem = iota
+	lsiCOUNT
+)
+
+// the index used to indicate -all- libraries selected for viewing.
+const selectedLibraryAll = 0
+const selectedLibraryAllOption = "(All)"
+
+// the format of the dropdown option of each collection, distinguishing them
+// from the libraries.
+const collectionOptionFormat = "{%s}"
+
+// the dropdown option following the collections, showing the media recently
+// added to any library.
+const selectedRecentOption = "(Recently added)"
+
+// the dropdown option following the recently added, showing the media whose
+// playback was stopped before it finished.
+const selectedInProgressOption = "(Continue watching)"
+
+// the dropdown option following those in progress, showing the media added on
+// this day in previous years.
+const selectedOnThisDayOption = "(On this day)"
+
+type LibSelectView struct {
+	*tview.Form
+	libDropDown *tview.DropDown
+	layout      *Layout
+	focusPage   string
+	focusNext   FocusDelegator
+	focusPrev   FocusDelegator
+
+	library         []*library.Library
+	selectedLibrary int
+	selectedName    string
+	numTotal        uint
+	numVideo        uint
+	numAudio        uint
+	numImage        uint
+	numDocument     uint
+
+	// collections are listed in the dropdown following the libraries.
+	collection []*collection.Collection
+}
+
+// function makeUniqueLibraryNames() creates unambiguous library names for all
+// given libraries. this is achieved by starting with the right-most component
+// of each path and iteratively adding its parent directory until the paths
+// can be uniquely identified.
+func makeUniqueLibraryNames(libs []*library.Library) []string {
+
+	reverse := func(a []string) []string {
+		for i, j := 0, len(a)-1; i < j; i, j = i+1, j-1 {
+			a[i], a[j] = a[j], a[i]
+		}
+		return a
+	}
+
+	type indexedSlice struct {
+		index  int
+		slice  []string
+		joined string
+	}
+
+	name := make([]indexedSlice, len(libs))
+	maxLength := 0
+	for i := range name {
+		component := strings.Split(strings.TrimRight(libs[i].AbsPath(), platform.PathSep), platform.PathSep)
+		name[i] = indexedSlice{1, reverse(component), ""}
+		if length := len(component); length > maxLength {
+			maxLength = length
+		}
+	}
+
+	for n := 0; n < maxLength; n++ {
+		d := map[string]int{}
+		for i := range name {
+
+			index := name[i].index
+			slice := name[i].slice[:index]
+			name[i].joined = strings.Join(slice, platform.PathSep)
+			d[name[i].joined]++
+		}
+		for i := range name {
+			if d[name[i].joined] > 1 {
+				name[i].index++
+			}
+		}
+		if len(d) == len(name) {
+			result := []string{}
+			for _, n := range name {
+				joined := reverse(strings.Split(n.joined, platform.PathSep))
+				result = append(result, strings.Join(joined, platform.PathSep))
+			}
+			return result
+		}
+	}
+
+	// there was an error in the algorithm if we reached here.
+	return []string{}
+}
+
+// function newLibSelectView() allocates and initializes the tview.Form widget
+// where the user selects which library (or collection) to browse and any other
+// filtering options.
+func newLibSelectView(ui *tview.Application, page string, lib []*library.Library, col []*collection.Collection) *LibSelectView {
+
+	unique := makeUniqueLibraryNames(lib)
+	for _, c := range col {
+		unique = append(unique, fmt.Sprintf(collectionOptionFormat, c.Name))
+	}
+	unique = append(unique, selectedRecentOption, selectedInProgressOption, selectedOnThisDayOption)
+	libName := []string{selectedLibraryAllOption}
+	dropDownWidth := len(selectedLibraryAllOption)
+	for _, u := range unique {
+		if n := len(u); n > dropDownWidth {
+			dropDownWidth = n
+		}
+		libName = append(libName, u)
+	}
+
+	for i, name := range libName { // +3 strictly for formatting/appearance
+		libName[i] = fmt.Sprintf("%-*s", dropDownWidth+3, name)
+	}
+
+	// offset library by 1 so that the "all" item is at index 0.
+	xref := make([]*library.Library, len(lib)+1)
+	copy(xref[1:], lib)
+
+	v :=
+		LibSelectView{
+			Form:            nil,
+			libDropDown:     nil,
+			layout:          nil,
+			focusPage:       page,
+			focusNext:       nil,
+			focusPrev:       nil,
+			library:         xref,
+			selectedLibrary: selectedLibraryAll,
+			selectedName:    selectedLibraryAllOption,
+			numTotal:        0,
+			numVideo:        0,
+			numAudio:        0,
+			numImage:        0,
+			numDocument:     0,
+			collection:      col,
+		}
+
+	form := tview.NewForm().
+		AddDropDown("   Show:", libName, 0, v.selectedLibDropDown).
+		SetLabelColor(colorScheme.inactiveMenuText).
+		SetFieldTextColor(colorScheme.inactiveMenuText).
+		SetFieldBackgroundColor(colorScheme.backgroundSecondary)
+
+	form.
+		SetBorder(true).
+		SetBorderColor(colorScheme.activeBorder).
+		SetTitleColor(colorScheme.activeMenuText).
+		SetTitleAlign(tview.AlignLeft).
+		SetDrawFunc(v.drawLibSelectView)
+
+	v.Form = form
+	v.libDropDown = form.GetFormItem(int(lsiLibrary)).(*tview.DropDown)
+
+	for i := 0; i < int(lsiCOUNT); i++ {
+		f := v.GetFormItem(i)
+		if nil == f {
+			break
+		}
+		switch f.(type) {
+		case *tview.InputField:
+			f.(*tview.InputField).SetInputCapture(v.inputFieldInput)
+		case *tview.DropDown:
+			f.(*tview.DropDown).SetInputCapture(v.dropDownInput)
+		}
+	}
+
+	return &v
+}
+
+func (v *LibSelectView) desc() string { return "" }
+func (v *LibSelectView) setDelegates(layout *Layout, prev, next FocusDelegator) {
+	v.layout = layout
+	v.focusPrev = prev
+	v.focusNext = next
+}
+func (v *LibSelectView) page() string         { return v.focusPage }
+func (v *LibSelectView) next() FocusDelegator { return v.focusNext }
+func (v *LibSelectView) prev() FocusDelegator { return v.focusPrev }
+func (v *LibSelectView) focus() {
+	// first update the library media counters upon focus of this view.
+	if v.selectedLibrary >= len(v.library) {
+		// the counts of a collection are those of the items it shows.
+		v.updateCollectionCount()
+	} else {
+		switch selected := v.library[v.selectedLibrary]; v.selectedLibrary {
+		case selectedLibraryAll:
+			v.updateMediaCount(v.library...)
+		default:
+			if nil != selected {
+				v.updateMediaCount(selected)
+			}
+		}
+	}
+	page := v.page()
+	v.layout.pages.ShowPage(page)
+}
+func (v *LibSelectView) blur() {
+	page := v.page()
+	v.layout.pages.HidePage(page)
+}
+
+// function updateMediaCount() iterates over the given libraries and counts the
+// number of each kind of media discovered. the LibSelectView object's counts
+// are immediately updated for reading.
+func (v *LibSelectView) updateMediaCount(libs ...*library.Library) {
+
+	v.numVideo = 0
+	v.numAudio = 0
+	v.numImage = 0
+	v.numDocument = 0
+
+	for _, l := range libs {
+		if nil != l {
+			v.numVideo +=
+				l.DB().NumRecordsLoad[media.ClassMedia][media.KindVideo] +
+					l.DB().NumRecordsScan[media.ClassMedia][media.KindVideo]
+
+			v.numAudio +=
+				l.DB().NumRecordsLoad[media.ClassMedia][media.KindAudio] +
+					l.DB().NumRecordsScan[media.ClassMedia][media.KindAudio]
+
+			v.numImage +=
+				l.DB().NumRecordsLoad[media.ClassMedia][media.KindImage] +
+					l.DB().NumRecordsScan[media.ClassMedia][media.KindImage]
+
+			v.numDocument +=
+				l.DB().NumRecordsLoad[media.ClassMedia][media.KindDocument] +
+					l.DB().NumRecordsScan[media.ClassMedia][media.KindDocument]
+		}
+	}
+
+	v.numTotal = v.numVideo + v.numAudio + v.numImage + v.numDocument
+}
+
+// function updateCollectionCount() counts the number of each kind of media
+// shown by the media browser, i.e. the media in the selected collection (or
+// those recently added).
+func (v *LibSelectView) updateCollectionCount() {
+	v.numVideo, v.numAudio, v.numImage, v.numDocument = v.layout.browseView.countVisible()
+	v.numTotal = v.numVideo + v.numAudio + v.numImage + v.numDocument
+}
+func (v *LibSelectView) drawLibSelectView(screen tcell.Screen, x int, y int, width int, height int) (int, int, int, int) {
+
+	const (
+		libDimWidth   = 40 // library selection window width
+		libDimHeight  = 20 // ^----------------------- height
+		helpDimWidth  = 40 // help info window width
+		helpDimHeight = 10 // ^--------------- height
+	)
+
+	// update the layout's associated screen field. note that you must be very
+	// careful and not access this field until this status line has been drawn
+	// at least one time.
+	if nil == v.layout.screen {
+		v.layout.screen = &screen
+	}
+
+	v.SetTitle(fmt.Sprintf(" Library: [#%06x]%s ", colorScheme.highlightPrimary.Hex(), v.selectedName))
+
+	// any existing library scan times must have occurred before right now.
+	lastScan := time.Now()
+	var selectedLibrary *library.Library
+	if v.selectedLibrary < len(v.library) {
+		selectedLibrary = v.library[v.selectedLibrary]
+	}
+	if nil != selectedLibrary {
+		lastScan = selectedLibrary.LastScan()
+	} else {
+		// if showing all libraries, display the -oldest- scan time as it is the
+		// most conservative choice.
+		for _, l := range v.library {
+			if nil != l {
+				if l.LastScan().Before(lastScan) {
+					lastScan = l.LastScan()
+				}
+			}
+		}
+	}
+
+	ddX, ddY, _, _ := v.libDropDown.GetRect()
+
+	fmtInfoRow := func(label, value string) string {
+		return fmt.Sprintf("[#%06x]%10s: [#%06x]%s",
+			colorScheme.inactiveMenuText.Hex(), label,
+			colorScheme.highlightPrimary.Hex(), value)
+	}
+
+	for i, s := range []string{
+		fmtInfoRow("Video", strconv.FormatUint(uint64(v.numVideo), 10)),
+		fmtInfoRow("Audio", strconv.FormatUint(uint64(v.numAudio), 10)),
+		fmtInfoRow("Image", strconv.FormatUint(uint64(v.numImage), 10)),
+		fmtInfoRow("Document", strconv.FormatUint(uint64(v.numDocument), 10)),
+		fmtInfoRow("Last scan", lastScan.Format("2006/01/02 15:04:05")),
+	} {
+		tview.Print(screen, s, ddX+3, ddY+2+i, width, tview.AlignLeft, colorScheme.inactiveMenuText)
+	}
+
+	return v.Form.GetInnerRect()
+}
+func (v *LibSelectView) selectedLibDropDown(option string, optionIndex int) {
+
+	// do not handle any dropdown selection if we are preoccupied handling some
+	// other event or request.
+	if isBusy := v.layout.busy.Count() > 0; isBusy {
+		return
+	}
+
+	// immediately and unconditionally set the class-visible variable which
+	// holds the user-selected library index.
+	v.selectedLibrary = optionIndex
+
+	// the options following the libraries select a collection, which may
+	// contain media from any library.
+	if c := optionIndex - len(v.library); c >= 0 {
+		switch {
+		case c < len(v.collection):
+			selected := v.collection[c]
+			v.selectedName = strings.TrimSpace(option)
+			go func() {
+				v.layout.busy.Inc(browseBusyTask)
+				v.layout.browseView.showCollection(selected)
+				v.updateCollectionCount()
+				v.layout.busy.Dec(browseBusyTask)
+			}()
+		case c == len(v.collection):
+			// what is recently added depends on each library's scans.
+			since := map[*library.Library]time.Time{}
+			for _, l := range v.library {
+				if nil != l {
+					since[l] = addedSince(v.layout.option, l)
+				}
+			}
+			v.selectedName = strings.TrimSpace(option)
+			go func() {
+				v.layout.busy.Inc(browseBusyTask)
+				v.layout.browseView.showMatching(func(m *mediaItem) bool {
+					return !m.TimeAdded.Before(since[m.SourceLibrary])
+				})
+				v.updateCollectionCount()
+				v.layout.busy.Dec(browseBusyTask)
+			}()
+		case c == len(v.collection)+1:
+			v.selectedName = strings.TrimSpace(option)
+			go func() {
+				v.layout.busy.Inc(browseBusyTask)
+				v.layout.browseView.showMatching(func(m *mediaItem) bool {
+					return m.InProgress()
+				})
+				v.updateCollectionCount()
+				v.layout.busy.Dec(browseBusyTask)
+			}()
+		case c == len(v.collection)+2:
+			v.selectedName = strings.TrimSpace(option)
+			now := time.Now()
+			go func() {
+				v.layout.busy.Inc(browseBusyTask)
+				v.layout.browseView.showMatching(func(m *mediaItem) bool {
+					return library.IsOnThisDay(m.Media, now)
+				})
+				v.updateCollectionCount()
+				v.layout.busy.Dec(browseBusyTask)
+			}()
+		}
+		return
+	}
+
+	// include all libraries by default, and then filter the list down based on
+	// user selections.
+	includedLib := v.library
+
+	// if optionIndex is selectedLibraryAll(0), then the value of "selected"
+	// should be nil, which showLibrary() interprets as "all libraries".
+	selected := includedLib[optionIndex]
+	if optionIndex != selectedLibraryAll {
+		if nil == selected {
+			// this is an error; we should always have a Library at the selected
+			// option index unless it is index 0 (i.e. selectedLibraryAll).
+			return
+		}
+		// user selected an option that -isn't- the "(All)"-libraries selection,
+		// so only show that one selected Library.
+		includedLib = []*library.Library{selected}
+	}
+
+	// update the selected library data and content counters based on the list
+	// of selected libraries.
+	v.updateMediaCount(includedLib...)
+	v.selectedName = strings.TrimSpace(option)
+	go func() {
+		// protect the libraries from being modified while we are updating the
+		// media browser and library selection.
+		v.layout.busy.Inc(browseBusyTask)
+		v.layout.browseView.showLibrary(selected)
+		v.layout.busy.Dec(browseBusyTask)
+	}()
+}
+
+// function selectEpisodes() shows the media at the given paths, i.e. the
+// episodes of a TV series or season selected from the LibTreeView, which has
+// no equivalent option in the dropdown. its counts are those of the items it
+// shows, like those of a collection.
+func (v *LibSelectView) selectEpisodes(name string, episodes map[string]bool) {
+
+	if isBusy := v.layout.busy.Count() > 0; isBusy {
+		return
+	}
+	// any index following the dropdown options.
+	v.selectedLibrary = len(v.library) + len(v.collection) + 3
+	v.selectedName = name
+	go func() {
+		v.layout.busy.Inc(browseBusyTask)
+		v.layout.browseView.showMatching(func(m *mediaItem) bool {
+			return episodes[m.AbsPath]
+		})
+		v.updateCollectionCount()
+		v.layout.busy.Dec(browseBusyTask)
+	}()
+}
+
+func (v *LibSelectView) inputFieldInput(event *tcell.EventKey) *tcell.EventKey {
+	isBusy := v.layout.busy.Count() > 0
+	switch key := event.Key(); key {
+	case tcell.KeyDown:
+		// treat the down arrow as a tab key for simpler navigation through the
+		// form items.
+		return tcell.NewEventKey(tcell.KeyTab, 0, event.Modifiers())
+	case tcell.KeyUp:
+		// treat the up arrow as a backtab key for simpler navigation through
+		// the form items.
+		return tcell.NewEventKey(tcell.KeyBacktab, 0, event.Modifiers())
+	default:
+		// do not allow the user to edit any input fields until we have finished
+		// processing whatever has flagged out BusyState indicator.
+		if isBusy {
+			event = nil
+		}
+	}
+	return event
+}
+func (v *LibSelectView) dropDownInput(event *tcell.EventKey) *tcell.EventKey {
+	isBusy := v.layout.busy.Count() > 0
+	switch key := event.Key(); key {
+	case tcell.KeyRune:
+		// just ignore any character keys pressed, do not perform the default
+		// (annoying) prefix-processing of the DropDown.
+		event = nil
+	case tcell.KeyEnter:
+		// do not allow the user to select a new library until we have finished
+		// processing whatever has flagged our BusyState indicator.
+		if isBusy {
+			console.Warn.Logf(busyMessage("select a new library"))
+			event = nil
+		}
+	case tcell.KeyDown, tcell.KeyUp:
+		// handle the up/down keys with the same event handler as the input
+		// fields so that they behave like tab/backtab. the user must press the
+		// Enter key to actually access the DropDown items.
+		return v.inputFieldInput(tcell.NewEventKey(key, 0, event.Modifiers()))
+	}
+	return event
+}
+
+//------------------------------------------------------------------------------
+
+// type libTreeOption is the reference of each selectable LibTreeView node,
+// identifying the equivalent option of the LibSelectView dropdown.
+type libTreeOption struct {
+	index int    // index of the dropdown option
+	name  string // text of the dropdown option
+}
+
+// type libTreeSeries is the reference of each TV series or season node of a
+// LibTreeView, selecting the episodes of the seasons it groups. the seasons
+// are given by their keys in each library, since a series may be spread
+// across several.
+type libTreeSeries struct {
+	name   string                        // name of the series or season
+	season map[*library.Library][]string // keys of the seasons in each library
+}
+
+type LibTreeView struct {
+	*tview.TreeView
+	layout    *Layout
+	focusPage string
+	focusNext FocusDelegator
+	focusPrev FocusDelegator
+}
+
+// function newLibTreeView() allocates and initializes the tview.TreeView widget
+// listing the libraries and collections whose media can be browsed. selecting
+// a node shows its media in the media browser, exactly like selecting the
+// same option from the LibSelectView dropdown.
+func newLibTreeView(ui *tview.Application, page string, lib []*library.Library, col []*collection.Collection) *LibTreeView {
+
+	v := LibTreeView{nil, nil, page, nil, nil}
+
+	// the options are numbered in the same order as the dropdown: all of the
+	// libraries, each library, each collection, the recently added, those in
+	// progress, and then those added on this day.
+	index := selectedLibraryAll
+	option := func(text, name string) *tview.TreeNode {
+		node := tview.NewTreeNode(text).
+			SetReference(libTreeOption{index, name}).
+			SetColor(colorScheme.activeText)
+		index++
+		return node
+	}
+
+	root := option(selectedLibraryAllOption, selectedLibraryAllOption)
+	for _, name := range makeUniqueLibraryNames(lib) {
+		root.AddChild(option(name, name))
+	}
+	if len(col) > 0 {
+		group := tview.NewTreeNode("Collections").
+			SetColor(colorScheme.inactiveMenuText)
+		for _, c := range col {
+			group.AddChild(option(c.Name, fmt.Sprintf(collectionOptionFormat, c.Name)))
+		}
+		root.AddChild(group)
+	}
+	if group := seriesTreeNode(lib); nil != group {
+		root.AddChild(group)
+	}
+	root.AddChild(option(selectedRecentOption, selectedRecentOption))
+	root.AddChild(option(selectedInProgressOption, selectedInProgressOption))
+	root.AddChild(option(selectedOnThisDayOption, selectedOnThisDayOption))
+
+	tree := tview.NewTreeView().
+		SetRoot(root).
+		SetCurrentNode(root).
+		SetGraphicsColor(colorScheme.inactiveText).
+		SetSelectedFunc(v.selectNode)
+
+	tree.
+		SetBorder(false)
+
+	v.TreeView = tree
+
+	return &v
+}
+
+// function seriesTreeNode() returns the node grouping the TV series of the
+// given libraries, each with a child node for each of its seasons, or nil if
+// there are none. the series are merged by name and year across libraries.
+// the nodes aren't options of the LibSelectView dropdown, so they don't
+// affect the numbering of the others.
+func seriesTreeNode(lib []*library.Library) *tview.TreeNode {
+
+	group := tview.NewTreeNode("TV Shows").
+		SetColor(colorScheme.inactiveMenuText)
+
+	seriesNode := map[string]*tview.TreeNode{}
+	seasonNode := map[string]*tview.TreeNode{}
+	addSeason := func(node *tview.TreeNode, l *library.Library, key string) {
+		ref := node.GetReference().(libTreeSeries)
+		ref.season[l] = append(ref.season[l], key)
+	}
+	for _, l := range lib {
+		if nil == l {
+			continue
+		}
+		list, ret := l.Series()
+		if nil != ret {
+			console.Warn.Log(ret)
+			continue
+		}
+		for _, s := range list {
+			node, ok := seriesNode[s.Key]
+			if !ok {
+				name := s.Name
+				if s.Year > 0 {
+					name = fmt.Sprintf("%s (%d)", s.Name, s.Year)
+				}
+				node = tview.NewTreeNode(name).
+					SetReference(libTreeSeries{name, map[*library.Library][]string{}}).
+					SetColor(colorScheme.activeText).
+					SetExpanded(false)
+				seriesNode[s.Key] = node
+				group.AddChild(node)
+			}
+			seasons, ret := l.Seasons(s.Key)
+			if nil != ret {
+				console.Warn.Log(ret)
+				continue
+			}
+			for _, n := range seasons {
+				child, ok := seasonNode[n.Key]
+				if !ok {
+					name := fmt.Sprintf("Season %d", n.Number)
+					child = tview.NewTreeNode(name).
+						SetReference(libTreeSeries{
+							fmt.Sprintf("%s %s", node.GetText(), name), map[*library.Library][]string{}}).
+						SetColor(colorScheme.activeText)
+					seasonNode[n.Key] = child
+					node.AddChild(child)
+				}
+				addSeason(node, l, n.Key)
+				addSeason(child, l, n.Key)
+			}
+		}
+	}
+	if 0 == len(seriesNode) {
+		return nil
+	}
+	return group
+}
+
+func (v *LibTreeView) desc() string { return "" }
+func (v *LibTreeView) setDelegates(layout *Layout, prev, next FocusDelegator) {
+	v.layout = layout
+	v.focusPrev = prev
+	v.focusNext = next
+}
+func (v *LibTreeView) page() string         { return v.focusPage }
+func (v *LibTreeView) next() FocusDelegator { return v.focusNext }
+func (v *LibTreeView) prev() FocusDelegator { return v.focusPrev }
+func (v *LibTreeView) focus() {
+	page := v.page()
+	v.layout.pages.ShowPage(page)
+	v.layout.ui.SetFocus(v.TreeView)
+}
+func (v *LibTreeView) blur() {}
+
+// function selectNode() is the event handler called when the user presses the
+// Enter key on a node of the tree. the nodes grouping other nodes are expanded
+// or collapsed instead.
+func (v *LibTreeView) selectNode(node *tview.TreeNode) {
+
+	if series, ok := node.GetReference().(libTreeSeries); ok {
+		// a series both shows its episodes and expands to its seasons.
+		node.SetExpanded(!node.IsExpanded())
+		if isBusy := v.layout.busy.Count() > 0; isBusy {
+			console.Warn.Logf(busyMessage("select a new series"))
+			return
+		}
+		episodes := map[string]bool{}
+		for l, keys := range series.season {
+			for _, key := range keys {
+				list, ret := l.Episodes(key)
+				if nil != ret {
+					console.Warn.Log(ret)
+				}
+				for _, e := range list {
+					episodes[e.AbsPath] = true
+				}
+			}
+		}
+		v.layout.libSelect.selectEpisodes(series.name, episodes)
+		return
+	}
+	selected, ok := node.GetReference().(libTreeOption)
+	if !ok {
+		node.SetExpanded(!node.IsExpanded())
+		return
+	}
+	if isBusy := v.layout.busy.Count() > 0; isBusy {
+		console.Warn.Logf(busyMessage("select a new library"))
+		return
+	}
+	v.layout.libSelect.selectedLibDropDown(selected.name, selected.index)
+}
+
+//------------------------------------------------------------------------------
+
+type DetailView struct {
+	*tview.TextView
+	layout *Layout
+}
+
+// function newDetailView() allocates and initializes the tview.TextView widget
+// describing the media item currently selected in the media browser. it is
+// never focused, it only follows the selection of the media browser.
+func newDetailView(ui *tview.Application, lib []*library.Library) *DetailView {
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(false).
+		SetTextAlign(tview.AlignLeft).
+		SetTextColor(colorScheme.activeText).
+		SetWordWrap(true).
+		SetWrap(true)
+
+	view.
+		SetBorder(false)
+
+	v := DetailView{view, nil}
+
+	return &v
+}
+
+// function update() replaces the content of the DetailView with the details
+// of the given media item, or clears it if the item is nil.
+func (v *DetailView) update(item *mediaItem) {
+
+	if nil == item {
+		v.TextView.SetText("")
+		return
+	}
+
+	// the field labels are printed on their own line above each value, since
+	// the side columns are too narrow for both.
+	var buf bytes.Buffer
+	field := func(label, value string) {
+		if "" != value {
+			fmt.Fprintf(&buf, "[#%06x]%s:[-]%s %s%s",
+				colorScheme.inactiveMenuText.Hex(), label, platform.NewLine,
+				tview.Escape(value), platform.NewLine)
+		}
+	}
+	date := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006/01/02 15:04:05")
+	}
+
+	kind := ""
+	if item.Kind > media.KindUnknown && item.Kind < media.KindCOUNT {
+		kind = media.MediaColName[item.Kind]
+	}
+	name := item.Title
+	if "" == name {
+		name = item.Name
+	}
+
+	field("Name", name)
+	field("Kind", fmt.Sprintf("%s (%s)", kind, item.ExtName))
+	field("Library", item.SourceLibrary.Name())
+	field("Path", item.AbsPath)
+	field("Links to", item.LinkTarget)
+	field("Size", report.HumanSize(item.Size))
+	if item.Duration > 0 {
+		field("Length", item.Duration.Round(time.Second).String())
+	}
+	if media.KindVideo == item.Kind {
+		if video, _ := item.SourceLibrary.VideoMedia(item.AbsPath); nil != video {
+			tracks := func(list []media.Track) string {
+				desc := make([]string, len(list))
+				for i, t := range list {
+					desc[i] = t.String()
+				}
+				return strings.Join(desc, ", ")
+			}
+			field("Resolution", video.Resolution())
+			field("Container", video.Container)
+			codecs := video.VideoCodec
+			if "" != video.AudioCodec {
+				codecs = strings.TrimPrefix(codecs+" / "+video.AudioCodec, " / ")
+			}
+			field("Codecs", codecs)
+			field("Audio tracks", tracks(video.AudioTracks))
+			field("Subtitle tracks", tracks(video.SubtitleTracks))
+			subs := []string{}
+			for i, u := range item.SourceLibrary.SubtitlesOf(item.AbsPath) {
+				desc := u.Language
+				if "" == desc {
+					desc = "?"
+				}
+				desc = fmt.Sprintf("%s (%s)", desc, u.AbsName)
+				if 0 == i {
+					desc = "▶ " + desc // played first (see Subtitles() of Library)
+				}
+				subs = append(subs, desc)
+			}
+			field("Subtitles", strings.Join(subs, ", "))
+		}
+	}
+	if media.KindImage == item.Kind {
+		if image, _ := item.SourceLibrary.ImageMedia(item.AbsPath); nil != image {
+			field("Dimensions", image.Dimensions())
+			field("Camera", image.Camera)
+			field("Taken", date(image.Taken))
+		}
+	}
+	if media.KindDocument == item.Kind {
+		if doc, _ := item.SourceLibrary.DocumentMedia(item.AbsPath); nil != doc {
+			field("Author", doc.Author)
+			field("Series", doc.SeriesPosition())
+			if doc.Pages > 0 {
+				field("Pages", strconv.FormatInt(doc.Pages, 10))
+			}
+		}
+	}
+	if media.KindAudio == item.Kind {
+		if lyr, _ := item.SourceLibrary.LyricsOf(item.AbsPath); nil != lyr {
+			desc := fmt.Sprintf("%d lines", len(lyr.Lines))
+			if lyr.Synced {
+				desc += ", synchronized"
+			}
+			field("Lyrics", desc)
+		}
+	}
+	if item.IsTrack() {
+		field("Image", fmt.Sprintf("%s (from %s)", item.ImageFile, item.Start.Round(time.Second)))
+	}
+	field("Artwork", item.SourceLibrary.ArtworkOf(item.AbsPath))
+	field("Modified", date(item.TimeModified))
+	field("Added", date(item.TimeAdded))
+	field("Released", date(item.ReleaseDate))
+	field("Genres", strings.Join(item.Genres, ", "))
+	field("Tags", strings.Join(item.Tags, ", "))
+	if item.Rating > 0 {
+		field("Rating", fmt.Sprintf("%d/%d", item.Rating, media.MaxRating))
+	}
+	if item.Watched {
+		field("Watched", "✓")
+	}
+	if item.PlayCount > 0 {
+		field("Played", fmt.Sprintf("%d time(s), last %s", item.PlayCount, date(item.LastPlayed)))
+	}
+	if item.ResumePosition > 0 {
+		field("Resume at", item.ResumePosition.Round(time.Second).String())
+	}
+	switch {
+	case "" != item.VerifyError:
+		field("Verified", "✗ "+item.VerifyError)
+	case !item.Verified.IsZero():
+		field("Verified", date(item.Verified))
+	}
+	field("Description", item.Description)
+
+	v.TextView.SetText(buf.String())
+	v.TextView.ScrollToBeginning()
+}
+
+//------------------------------------------------------------------------------
+
+type BrowseView struct {
+	*Browser
+	layout    *Layout
+	focusPage string
+	focusNext FocusDelegator
+	focusPrev FocusDelegator
+}
+
+// function newBrowseView() allocates and initializes the tview.List widget
+// where all of the currently available media can be browsed.
+func newBrowseView(ui *tview.Application, page string, lib []*library.Library) *BrowseView {
+
+	list := newBrowser()
+	v := BrowseView{list, nil, page, nil, nil}
+	v.setSelectedFunc(v.selectItem)
+
+	return &v
+}
+
+func (v *BrowseView) desc() string { return "" }
+func (v *BrowseView) setDelegates(layout *Layout, prev, next FocusDelegator) {
+	v.layout = layout
+	v.focusPrev = prev
+	v.focusNext = next
+}
+func (v *BrowseView) page() string         { return v.focusPage }
+func (v *BrowseView) next() FocusDelegator { return v.focusNext }
+func (v *BrowseView) prev() FocusDelegator { return v.focusPrev }
+func (v *BrowseView) focus() {
+	page := v.page()
+	v.layout.pages.ShowPage(page)
+	v.layout.ui.SetFocus(v.Browser)
+}
+func (v *BrowseView) blur() {}
+
+// function selectItem() plays the media item selected with the Enter key with
+// the player configured for its kind (see -playvideo and -playaudio), giving
+// the player the terminal until it exits.
+func (v *BrowseView) selectItem(index int, mainText, secondaryText string) {
+
+	item := v.itemAt(index)
+	if nil == item {
+		return
+	}
+	if isBusy := v.layout.busy.Count() > 0; isBusy {
+		console.Warn.Logf(busyMessage("play media"))
+		return
+	}
+	v.layout.ui.Suspend(func() {
+		if ret := playItem(v.layout.option, item.SourceLibrary, item.Media, ""); nil != ret {
+			console.Warn.Log(ret)
+		}
+	})
+	v.layout.detailView.update(item)
+}
+
+// function undoItem() reverts the most recent change made to the currently
+// selected media item.
+func (v *BrowseView) undoItem() {
+
+	if !isValidIndex(v.visibleItem, v.currentItem) {
+		return
+	}
+	item := v.visibleItem[v.currentItem]
+
+	edit, err := item.SourceLibrary.UndoMedia(item.AbsPath)
+	if nil != err {
+		console.Warn.Log(err)
+		return
+	}
+	if 0 == len(edit) {
+		console.Info.Logf("nothing to undo: %q", item.Name)
+		return
+	}
+	for _, e := range edit {
+		console.Info.Logf("undo: %q: %s: %v -> %v", item.Name, e.Field, e.New, e.Old)
+	}
+	// our copy of the media is now stale, so revert the same edits on it.
+	item.PopHistory()
+	data, jsonErr := json.Marshal(item.Media)
+	rec := media.EntityRecord{}
+	if nil == jsonErr {
+		jsonErr = json.Unmarshal(data, &rec)
+	}
+	if nil != jsonErr {
+		console.Warn.Logf("cannot refresh undone item: %q: %s", item.Name, jsonErr)
+		return
+	}
+	if data, err = media.RevertRecord(&rec, edit); nil != err {
+		console.Warn.Log(err)
+		return
+	}
+	reverted := &media.Media{}
+	if jsonErr = json.Unmarshal(data, reverted); nil != jsonErr {
+		console.Warn.Logf("cannot refresh undone item: %q: %s", item.Name, jsonErr)
+		return
+	}
+	*item.Media = *reverted
+}
+
+// function rateItem() raises (or, if negative, lowers) the rating of the
+// currently selected item by the given amount, updating its library's
+// database.
+func (v *BrowseView) rateItem(delta int64) {
+
+	if !isValidIndex(v.visibleItem, v.currentItem) {
+		return
+	}
+	item := v.visibleItem[v.currentItem]
+
+	var record *media.Media
+	changed, err := item.SourceLibrary.UpdateMedia(item.AbsPath, func(u *media.Media) bool {
+		record = u
+		return u.SetRating(u.Rating + delta)
+	})
+	if nil != err {
+		console.Warn.Log(err)
+		return
+	}
+	if !changed {
+		return
+	}
+	*item.Media = *record
+	console.Info.Logf("rated %d/%d: %q", item.Rating, media.MaxRating, item.Name)
+	v.layout.detailView.update(item)
+}
+
+// function cycleSubtitles() selects the subtitles following those currently
+// played first with the video selected in the media browser (see Subtitles()
+// of Library), cycling back to the first.
+func (v *BrowseView) cycleSubtitles() {
+
+	if !isValidIndex(v.visibleItem, v.currentItem) {
+		return
+	}
+	item := v.visibleItem[v.currentItem]
+	if media.KindVideo != item.Kind {
+		return
+	}
+	list := item.SourceLibrary.SubtitlesOf(item.AbsPath)
+	if len(list) < 2 {
+		console.Info.Logf("no other subtitles: %q", item.Name)
+		return
+	}
+	// the list is ordered by preference, which changes with the selection, so
+	// the cycle follows the order of their paths instead.
+	current := list[0].AbsPath
+	sort.Slice(list, func(a, b int) bool { return list[a].AbsPath < list[b].AbsPath })
+	next := list[0]
+	for i, u := range list {
+		if current == u.AbsPath {
+			next = list[(i+1)%len(list)]
+			break
+		}
+	}
+	if _, err := item.SourceLibrary.SelectSubtitles(item.AbsPath, next.AbsPath); nil != err {
+		console.Warn.Log(err)
+		return
+	}
+	lang := next.Language
+	if "" == lang {
+		lang = "unknown language"
+	}
+	console.Info.Logf("selected subtitles (%s): %q", lang, next.AbsName)
+	v.layout.detailView.update(item)
+}
+
+// function showThumbnail() shows the thumbnail of the currently selected media
+// item (a frame of a video, or the waveform of audio) using the terminal's
+// graphics protocol (see -graphics), giving it the terminal until Enter is
+// pressed. the thumbnail is generated first if it isn't cached.
+func (v *BrowseView) showThumbnail() {
+
+	if !isValidIndex(v.visibleItem, v.currentItem) {
+		return
+	}
+	item := v.visibleItem[v.currentItem]
+	if !thumbnail.Supports(item.Media) {
+		console.Info.Logf("no thumbnail: %q", item.Name)
+		return
+	}
+	protocol := thumbnail.DetectProtocol(v.layout.option.Graphics.string)
+	if thumbnail.ProtocolNone == protocol {
+		console.Warn.Logf("cannot show thumbnails in this terminal (see option -%s)", v.layout.option.Graphics.name)
+		return
+	}
+	cache := thumbnailCache(v.layout.option)
+	if nil == cache {
+		console.Warn.Logf("cannot generate thumbnails: ffmpeg not found")
+		return
+	}
+	v.layout.ui.Suspend(func() {
+		// the log is drawn by the UI, which is suspended, so the terminal is
+		// written directly.
+		fmt.Printf("%s%s", item.Name, platform.NewLine)
+		path, ret := item.SourceLibrary.Thumbnail(cache, item.AbsPath)
+		if nil == ret {
+			ret = thumbnail.Display(os.Stdout, path, protocol)
+		}
+		if nil != ret {
+			console.Warn.Log(ret)
+			fmt.Printf("%s%s", ret, platform.NewLine)
+		}
+		fmt.Printf("press Enter to return")
+		bufio.NewReader(os.Stdin).ReadString('\n')
+	})
+}
+
+//------------------------------------------------------------------------------
+
+type LogView struct {
+	*tview.TextView
+	layout    *Layout
+	focusPage string
+	focusNext FocusDelegator
+	focusPrev FocusDelegator
+}
+
+// function newLogView() allocates and initializes the tview.TextView widget
+// where all runtime log data is navigated by and displayed to the user.
+func newLogView(ui *tview.Application, page string, lib []*library.Library) *LogView {
+
+	logChanged := func() {}
+	logDone := func(key tcell.Key) {}
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetRegions(true).
+		SetScrollable(true).
+		SetTextAlign(tview.AlignLeft).
+		SetTextColor(colorScheme.inactiveText).
+		SetWordWrap(true).
+		SetWrap(false)
+
+	view. // update the TextView event handlers
+		SetChangedFunc(logChanged).
+		SetDoneFunc(logDone).
+		SetBorder(false)
+
+	v := LogView{view, nil, page, nil, nil}
+
+	return &v
+}
+
+func (v *LogView) desc() string { return "" }
+func (v *LogView) setDelegates(layout *Layout, prev, next FocusDelegator) {
+	v.layout = layout
+	v.focusPrev = prev
+	v.focusNext = next
+}
+func (v *LogView) page() string         { return v.focusPage }
+func (v *LogView) next() FocusDelegator { return v.focusNext }
+func (v *LogView) prev() FocusDelegator { return v.focusPrev }
+func (v *LogView) focus() {
+	page := v.page()
+	v.layout.pages.ShowPage(page)
+	v.layout.ui.SetFocus(v.TextView)
+	v.TextView.SetTextColor(colorScheme.activeText)
+}
+func (v *LogView) blur() {
+	v.TextView.SetTextColor(colorScheme.inactiveText)
+}
+
+//------------------------------------------------------------------------------
+
+type DiskUsageView struct {
+	*tview.TextView
+	layout    *Layout
+	focusPage string
+	focusNext FocusDelegator
+	focusPrev FocusDelegator
+}
+
+// function newDiskUsageView() allocates and initializes the tview.TextView
+// widget showing the space consumed by the media in all libraries, grouped by
+// kind, extension, directory, and quality tier.
+func newDiskUsageView(ui *tview.Application, page string, lib []*library.Library) *DiskUsageView {
+
+	view := tview.NewTextView().
+		SetDynamicColors(false).
+		SetScrollable(true).
+		SetTextAlign(tview.AlignLeft).
+		SetTextColor(colorScheme.activeText).
+		SetWrap(false)
+
+	view.
+		SetBorder(true).
+		SetBorderColor(colorScheme.activeBorder).
+		SetTitle(" Disk Usage ").
+		SetTitleColor(colorScheme.activeMenuText).
+		SetTitleAlign(tview.AlignRight)
+
+	v := DiskUsageView{view, nil, page, nil, nil}
+
+	return &v
+}
+
+func (v *DiskUsageView) desc() string { return "" }
+func (v *DiskUsageView) setDelegates(layout *Layout, prev, next FocusDelegator) {
+	v.layout = layout
+	v.focusPrev = prev
+	v.focusNext = next
+}
+func (v *DiskUsageView) page() string         { return v.focusPage }
+func (v *DiskUsageView) next() FocusDelegator { return v.focusNext }
+func (v *DiskUsageView) prev() FocusDelegator { return v.focusPrev }
+func (v *DiskUsageView) focus() {
+	v.update()
+	page := v.page()
+	v.layout.pages.ShowPage(page)
+	v.layout.ui.SetFocus(v.TextView)
+}
+func (v *DiskUsageView) blur() {
+	page := v.page()
+	v.layout.pages.HidePage(page)
+}
+
+// function update() recomputes the disk usage reports from the libraries'
+// databases. the view can only be focused while the libraries aren't busy, so
+// the databases are safe to load.
+func (v *DiskUsageView) update() {
+
+	var buf bytes.Buffer
+	list := loadMedia(v.layout.lib, visibleMedia(v.layout.option))
+	for by := report.UsageBy(0); by < report.UsageByCOUNT; by++ {
+		limit := 0
+		if report.UsageByDir == by {
+			limit = defaultUsageLimit
+		}
+		if err := report.Usage(list, by, limit).WriteText(&buf); nil != err {
+			console.Warn.Log(err)
+		}
+		buf.WriteString(platform.NewLine)
+	}
+	v.TextView.SetText(buf.String())
+	v.TextView.ScrollToBeginning()
+}
+
+//------------------------------------------------------------------------------
+
+// constants defining the content of the DashboardView.
+const (
+	dashboardWeeks  = 12 // number of weeks of history shown
+	dashboardGenres = 10 // number of most common genres shown
+)
+
+type DashboardView struct {
+	*tview.TextView
+	layout    *Layout
+	focusPage string
+	focusNext FocusDelegator
+	focusPrev FocusDelegator
+}
+
+// function newDashboardView() allocates and initializes the tview.TextView
+// widget showing statistics of the media in all libraries: their growth over
+// time, how often they are played, their storage by kind, and their genres.
+func newDashboardView(ui *tview.Application, page string, lib []*library.Library) *DashboardView {
+
+	view := tview.NewTextView().
+		SetDynamicColors(false).
+		SetScrollable(true).
+		SetTextAlign(tview.AlignLeft).
+		SetTextColor(colorScheme.activeText).
+		SetWrap(false)
+
+	view.
+		SetBorder(true).
+		SetBorderColor(colorScheme.activeBorder).
+		SetTitle(" Statistics ").
+		SetTitleColor(colorScheme.activeMenuText).
+		SetTitleAlign(tview.AlignRight)
+
+	v := DashboardView{view, nil, page, nil, nil}
+
+	return &v
+}
+
+func (v *DashboardView) desc() string { return "" }
+func (v *DashboardView) setDelegates(layout *Layout, prev, next FocusDelegator) {
+	v.layout = layout
+	v.focusPrev = prev
+	v.focusNext = next
+}
+func (v *DashboardView) page() string         { return v.focusPage }
+func (v *DashboardView) next() FocusDelegator { return v.focusNext }
+func (v *DashboardView) prev() FocusDelegator { return v.focusPrev }
+func (v *DashboardView) focus() {
+	v.update()
+	page := v.page()
+	v.layout.pages.ShowPage(page)
+	v.layout.ui.SetFocus(v.TextView)
+}
+func (v *DashboardView) blur() {
+	page := v.page()
+	v.layout.pages.HidePage(page)
+}
+
+// function update() recomputes the statistics from the libraries' databases.
+// like the DiskUsageView, the view can only be focused while the libraries
+// aren't busy, so the databases are safe to load.
+func (v *DashboardView) update() {
+
+	now := time.Now()
+	list := loadMedia(v.layout.lib, visibleMedia(v.layout.option))
+	added := report.WeeklyAdded(list, now, dashboardWeeks)
+	plays := report.WeeklyPlays(list, now, dashboardWeeks)
+
+	var buf bytes.Buffer
+	growth := added.Cumulative()
+	fmt.Fprintf(&buf, "Library growth (%d weeks):  %s  %d media%s",
+		dashboardWeeks, report.Sparkline(growth), growth[len(growth)-1], platform.NewLine)
+	numPlays := 0
+	for _, n := range plays.Count {
+		numPlays += n
+	}
+	fmt.Fprintf(&buf, "Plays per week (%d weeks):  %s  %d plays%s%s",
+		dashboardWeeks, report.Sparkline(plays.Count), numPlays, platform.NewLine, platform.NewLine)
+
+	for _, r := range []*report.Report{
+		report.Usage(list, report.UsageByKind, 0),
+		report.Genres(list, dashboardGenres),
+		report.Weekly("Media added", "Added", added),
+		report.Weekly("Media played", "Plays", plays),
+	} {
+		if err := r.WriteText(&buf); nil != err {
+			console.Warn.Log(err)
+		}
+		buf.WriteString(platform.NewLine)
+	}
+	v.TextView.SetText(buf.String())
+	v.TextView.ScrollToBeginning()
+}
+
+//------------------------------------------------------------------------------
+
+type ProblemsView struct {
+	*tview.TextView
+	layout    *Layout
+	focusPage string
+	focusNext FocusDelegator
+	focusPrev FocusDelegator
+}
+
+// function newProblemsView() allocates and initializes the tview.TextView
+// widget showing the problems encountered by the most recent load and scan of
+// each library, i.e. the same report as the "report" subcommand.
+func newProblemsView(ui *tview.Application, page string, lib []*library.Library) *ProblemsView {
+
+	view := tview.NewTextView().
+		SetDynamicColors(false).
+		SetScrollable(true).
+		SetTextAlign(tview.AlignLeft).
+		SetTextColor(colorScheme.activeText).
+		SetWrap(false)
+
+	view.
+		SetBorder(true).
+		SetBorderColor(colorScheme.activeBorder).
+		SetTitle(" Scan Problems ").
+		SetTitleColor(colorScheme.activeMenuText).
+		SetTitleAlign(tview.AlignRight)
+
+	v := ProblemsView{view, nil, page, nil, nil}
+
+	return &v
+}
+
+func (v *ProblemsView) desc() string { return "" }
+func (v *ProblemsView) setDelegates(layout *Layout, prev, next FocusDelegator) {
+	v.layout = layout
+	v.focusPrev = prev
+	v.focusNext = next
+}
+func (v *ProblemsView) page() string         { return v.focusPage }
+func (v *ProblemsView) next() FocusDelegator { return v.focusNext }
+func (v *ProblemsView) prev() FocusDelegator { return v.focusPrev }
+func (v *ProblemsView) focus() {
+	v.update()
+	page := v.page()
+	v.layout.pages.ShowPage(page)
+	v.layout.ui.SetFocus(v.TextView)
+}
+func (v *ProblemsView) blur() {
+	page := v.page()
+	v.layout.pages.HidePage(page)
+}
+
+// function update() reads the scan reports recorded in the libraries'
+// databases, which are only written while the libraries are busy, i.e. never
+// while the view can be focused.
+func (v *ProblemsView) update() {
+
+	list := map[string]*storage.ScanReport{}
+	for _, l := range v.layout.lib {
+		r, err := l.ScanReport()
+		if nil != err {
+			console.Warn.Log(err)
+			continue
+		}
+		list[l.AbsPath()] = r
+	}
+
+	var buf bytes.Buffer
+	rep := report.Problems(list)
+	if 0 == len(rep.Rows) {
+		buf.WriteString("No problems encountered by the last load and scan of the libraries." + platform.NewLine)
+	} else if err := rep.WriteText(&buf); nil != err {
+		console.Warn.Log(err)
+	}
+	v.TextView.SetText(buf.String())
+	v.TextView.ScrollToBeginning()
+}
+
+//------------------------------------------------------------------------------
+
+// the maximum number of media listed by the SearchView.
+const searchResults = 100
+
+type SearchView struct {
+	*tview.Flex
+	input     *tview.InputField
+	results   *tview.List
+	layout    *Layout
+	focusPage string
+	focusNext FocusDelegator
+	focusPrev FocusDelegator
+
+	// every media item of the media browser when the view was focused, and
+	// those of them currently listed, best match first.
+	item  []*mediaItem
+	match []*mediaItem
+}
+
+// function newSearchView() allocates and initializes the tview.Flex widget in
+// which the user searches for media in all libraries by typing any part of its
+// name, title, or path. the media listed are updated with each key pressed,
+// and selecting one selects it in the media browser.
+func newSearchView(ui *tview.Application, page string, lib []*library.Library) *SearchView {
+
+	v := SearchView{
+		Flex:      nil,
+		input:     nil,
+		results:   nil,
+		layout:    nil,
+		focusPage: page,
+		focusNext: nil,
+		focusPrev: nil,
+		item:      []*mediaItem{},
+		match:     []*mediaItem{},
+	}
+
+	input := tview.NewInputField().
+		SetLabel(" Find: ").
+		SetLabelColor(colorScheme.inactiveMenuText).
+		SetFieldTextColor(colorScheme.activeText).
+		SetFieldBackgroundColor(colorScheme.backgroundSecondary).
+		SetChangedFunc(v.search)
+
+	input.
+		SetInputCapture(v.inputFieldInput)
+
+	results := tview.NewList().
+		ShowSecondaryText(true).
+		SetMainTextColor(colorScheme.activeText).
+		SetSecondaryTextColor(colorScheme.inactiveText).
+		SetSelectedTextColor(colorScheme.backgroundPrimary).
+		SetSelectedBackgroundColor(colorScheme.highlightPrimary).
+		SetSelectedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
+			v.selectItem(index)
+		})
+
+	results.
+		SetInputCapture(v.resultsInput)
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(results, 0, 1, false)
+
+	flex.
+		SetBorder(true).
+		SetBorderColor(colorScheme.activeBorder).
+		SetTitle(" Search ").
+		SetTitleColor(colorScheme.activeMenuText).
+		SetTitleAlign(tview.AlignLeft)
+
+	v.Flex = flex
+	v.input = input
+	v.results = results
+
+	return &v
+}
+
+func (v *SearchView) desc() string { return "" }
+func (v *SearchView) setDelegates(layout *Layout, prev, next FocusDelegator) {
+	v.layout = layout
+	v.focusPrev = prev
+	v.focusNext = next
+}
+func (v *SearchView) page() string         { return v.focusPage }
+func (v *SearchView) next() FocusDelegator { return v.focusNext }
+func (v *SearchView) prev() FocusDelegator { return v.focusPrev }
+func (v *SearchView) focus() {
+	// the media browser holds every media loaded from all libraries, whether
+	// or not the selected library shows them. it is only modified by the draw
+	// cycle calling this method, so it is safe to copy here.
+	browser := v.layout.browseView.Browser
+	v.item = append(append([]*mediaItem{}, browser.visibleItem...), browser.hiddenItem...)
+	v.search(v.input.GetText())
+	page := v.page()
+	v.layout.pages.ShowPage(page)
+	v.layout.ui.SetFocus(v.input)
+}
+func (v *SearchView) blur() {
+	page := v.page()
+	v.layout.pages.HidePage(page)
+}
+
+// function search() lists the media best matching the given text, which is
+// the entire content of the input field. text beginning with "?" is instead a
+// query (see package query) listing the media matching it, in order, e.g.
+// "?kind=video and released>2015"; an incomplete query lists nothing.
+func (v *SearchView) search(text string) {
+
+	v.results.Clear()
+	v.match = v.match[:0]
+	trimmed := strings.TrimSpace(text)
+	if "" == trimmed {
+		return
+	}
+	found := []*mediaItem{}
+	if strings.HasPrefix(trimmed, "?") {
+		q, ret := query.Parse(trimmed[1:])
+		if nil != ret {
+			return
+		}
+		for _, m := range v.item {
+			if q.Match(m.Media) {
+				if found = append(found, m); len(found) == searchResults {
+					break
+				}
+			}
+		}
+	} else {
+		rank := fuzzy.Rank(text, len(v.item), func(i int) []string {
+			return []string{v.item[i].Name, v.item[i].Title, v.item[i].AbsPath}
+		}, searchResults)
+		for _, r := range rank {
+			found = append(found, v.item[r.Index])
+		}
+	}
+	for _, m := range found {
+		name := m.Title
+		if "" == name {
+			name = m.Name
+		}
+		v.match = append(v.match, m)
+		v.results.AddItem(tview.Escape(name), tview.Escape(m.AbsPath), 0, nil)
+	}
+}
+
+// function selectItem() selects the listed media at the given index in the
+// media browser, and then focuses the browser.
+func (v *SearchView) selectItem(index int) {
+
+	if index < 0 || index >= len(v.match) {
+		return
+	}
+	item := v.match[index]
+
+	// wait for the draw cycle in a new goroutine, this is called by the
+	// application's input handler which the draw cycle may be waiting on.
+	go func(l *Layout) {
+		l.eventQueue <- func() {
+			browser := l.browseView.Browser
+			if _, isHidden := item.findItem(browser.hiddenItem); isHidden {
+				// the library (or collection) selected doesn't show the item,
+				// so show every library instead.
+				l.libSelect.selectedLibrary = selectedLibraryAll
+				l.libSelect.selectedName = selectedLibraryAllOption
+				l.libSelect.updateMediaCount(l.libSelect.library...)
+				browser.showLibrary(nil)
+			}
+			if visibleIndex, isVisible := item.findItem(browser.visibleItem); isVisible {
+				browser.setCurrentItem(visibleIndex)
+			} else {
+				// removed from the library since the search began.
+				console.Warn.Logf("no longer in library: %q", item.AbsPath)
+			}
+		}
+		l.focusQueue <- l.browseView
+	}(v.layout)
+}
+
+func (v *SearchView) inputFieldInput(event *tcell.EventKey) *tcell.EventKey {
+	switch key := event.Key(); key {
+	case tcell.KeyEnter:
+		// select the best match, if any, without having to move to it.
+		v.selectItem(v.results.GetCurrentItem())
+		return nil
+	case tcell.KeyDown, tcell.KeyTab:
+		if v.results.GetItemCount() > 0 {
+			v.layout.ui.SetFocus(v.results)
+		}
+		return nil
+	}
+	return event
+}
+func (v *SearchView) resultsInput(event *tcell.EventKey) *tcell.EventKey {
+	switch key := event.Key(); key {
+	case tcell.KeyUp:
+		// return to the input field from the first item.
+		if 0 == v.results.GetCurrentItem() {
+			v.layout.ui.SetFocus(v.input)
+			return nil
+		}
+	case tcell.KeyBacktab:
+		v.layout.ui.SetFocus(v.input)
+		return nil
+	case tcell.KeyRune, tcell.KeyBackspace, tcell.KeyBackspace2:
+		// keep typing the search text without having to return to it first.
+		v.layout.ui.SetFocus(v.input)
+		if handler := v.input.InputHandler(); nil != handler {
+			handler(event, func(p tview.Primitive) {})
+		}
+		return nil
+	}
+	return event
+}
+
+// -----------------------------------------------------------------------------
+//  TBD: temporary code below while evaluating color palettes
+// -----------------------------------------------------------------------------
+
+var logColors = map[rune]func(){
+	'1': func() {
+		// blue|navy
+		console.Info.Log("[#000080]ColorNavy")
+		console.Info.Log("[#00008b]ColorDarkBlue")
+		console.Info.Log("[#0000cd]ColorMediumBlue")
+		console.Info.Log("[#0000ff]ColorBlue")
+		console.Info.Log("[#00bfff]ColorDeepSkyBlue")
+		console.Info.Log("[#191970]ColorMidnightBlue")
+		console.Info.Log("[#1e90ff]ColorDodgerBlue")
+		console.Info.Log("[#4169e1]ColorRoyalBlue")
+		console.Info.Log("[#4682b4]ColorSteelBlue")
+		console.Info.Log("[#483d8b]ColorDarkSlateBlue")
+		console.Info.Log("[#5f9ea0]ColorCadetBlue")
+		console.Info.Log("[#6495ed]ColorCornflowerBlue")
+		console.Info.Log("[#6a5acd]ColorSlateBlue")
+		console.Info.Log("[#7b68ee]ColorMediumSlateBlue")
+		console.Info.Log("[#87ceeb]ColorSkyblue")
+		console.Info.Log("[#87cefa]ColorLightSkyBlue")
+		console.Info.Log("[#8a2be2]ColorBlueViolet")
+		console.Info.Log("[#add8e6]ColorLightBlue")
+		console.Info.Log("[#b0c4de]ColorLightSteelBlue")
+		console.Info.Log("[#b0e0e6]ColorPowderBlue")
+		console.Info.Log("[#f0f8ff]ColorAliceBlue")
+	},
+
+	'2': func() {
+		// red|pink|magenta|fire|crimson|tomato|salmon|coral|maroon|rose|seashell
+		console.Info.Log("[#800000]ColorMaroon")
+		console.Info.Log("[#8b0000]ColorDarkRed")
+		console.Info.Log("[#8b008b]ColorDarkMagenta")
+		console.Info.Log("[#b22222]ColorFireBrick")
+		console.Info.Log("[#c71585]ColorMediumVioletRed")
+		console.Info.Log("[#cd5c5c]ColorIndianRed")
+		console.Info.Log("[#db7093]ColorPaleVioletRed")
+		console.Info.Log("[#dc143c]ColorCrimson")
+		console.Info.Log("[#e9967a]ColorDarkSalmon")
+		console.Info.Log("[#f08080]ColorLightCoral")
+		console.Info.Log("[#fa8072]ColorSalmon")
+		console.Info.Log("[#ff0000]ColorRed")
+		console.Info.Log("[#ff1493]ColorDeepPink")
+		console.Info.Log("[#ff4500]ColorOrangeRed")
+		console.Info.Log("[#ff6347]ColorTomato")
+		console.Info.Log("[#ff69b4]ColorHotPink")
+		console.Info.Log("[#ff7f50]ColorCoral")
+		console.Info.Log("[#ffa07a]ColorLightSalmon")
+		console.Info.Log("[#ffb6c1]ColorLightPink")
+		console.Info.Log("[#ffc0cb]ColorPink")
+		console.Info.Log("[#ffe4e1]ColorMistyRose")
+		console.Info.Log("[#fff5ee]ColorSeashell")
+	},
+
+	'3': func() {
+		// black|white|gray|grey|smoke|silver|gainsboro|linen|oldlace|snow|ivory
+		console.Info.Log("[#000000]ColorBlack")
+		console.Info.Log("[#2f4f4f]ColorDarkSlateGray")
+		console.Info.Log("[#696969]ColorDimGray")
+		console.Info.Log("[#708090]ColorSlateGray")
+		console.Info.Log("[#778899]ColorLightSlateGray")
+		console.Info.Log("[#808080]ColorGray")
+		console.Info.Log("[#a9a9a9]ColorDarkGray")
+		console.Info.Log("[#c0c0c0]ColorSilver")
+		console.Info.Log("[#d3d3d3]ColorLightGray")
+		console.Info.Log("[#dcdcdc]ColorGainsboro")
+		console.Info.Log("[#f5f5f5]ColorWhiteSmoke")
+		console.Info.Log("[#f8f8ff]ColorGhostWhite")
+		console.Info.Log("[#faebd7]ColorAntiqueWhite")
+		console.Info.Log("[#faf0e6]ColorLinen")
+		console.Info.Log("[#fdf5e6]ColorOldLace")
+		console.Info.Log("[#ffdead]ColorNavajoWhite")
+		console.Info.Log("[#fffaf0]ColorFloralWhite")
+		console.Info.Log("[#fffafa]ColorSnow")
+		console.Info.Log("[#fffff0]ColorIvory")
+		console.Info.Log("[#ffffff]ColorWhite")
+	},
+
+	'4': func() {
+		// green|lime|olive|chartreuse|mint
+		console.Info.Log("[#006400]ColorDarkGreen")
+		console.Info.Log("[#008000]ColorGreen")
+		console.Info.Log("[#00fa9a]ColorMediumSpringGreen")
+		console.Info.Log("[#00ff00]ColorLime")
+		console.Info.Log("[#00ff7f]ColorSpringGreen")
+		console.Info.Log("[#20b2aa]ColorLightSeaGreen")
+		console.Info.Log("[#228b22]ColorForestGreen")
+		console.Info.Log("[#2e8b57]ColorSeaGreen")
+		console.Info.Log("[#32cd32]ColorLimeGreen")
+		console.Info.Log("[#3cb371]ColorMediumSeaGreen")
+		console.Info.Log("[#556b2f]ColorDarkOliveGreen")
+		console.Info.Log("[#6b8e23]ColorOliveDrab")
+		console.Info.Log("[#7cfc00]ColorLawnGreen")
+		console.Info.Log("[#7fff00]ColorChartreuse")
+		console.Info.Log("[#808000]ColorOlive")
+		console.Info.Log("[#8fbc8f]ColorDarkSeaGreen")
+		console.Info.Log("[#90ee90]ColorLightGreen")
+		console.Info.Log("[#98fb98]ColorPaleGreen")
+		console.Info.Log("[#9acd32]ColorYellowGreen")
+		console.Info.Log("[#adff2f]ColorGreenYellow")
+		console.Info.Log("[#f5fffa]ColorMintCream")
+	},
+
+	'5': func() {
+		// turquoise|teal|cyan|aqua|azure
+		console.Info.Log("[#008080]ColorTeal")
+		console.Info.Log("[#008b8b]ColorDarkCyan")
+		console.Info.Log("[#00ced1]ColorDarkTurquoise")
+		console.Info.Log("[#00ffff]ColorAqua")
+		console.Info.Log("[#40e0d0]ColorTurquoise")
+		console.Info.Log("[#48d1cc]ColorMediumTurquoise")
+		console.Info.Log("[#66cdaa]ColorMediumAquamarine")
+		console.Info.Log("[#7fffd4]ColorAquaMarine")
+		console.Info.Log("[#afeeee]ColorPaleTurquoise")
+		console.Info.Log("[#e0ffff]ColorLightCyan")
+		console.Info.Log("[#f0ffff]ColorAzure")
+	},
+
+	'6': func() {
+		// purple|indigo|violet|lavender|fuchsia|orchid|thistle|plum
+		console.Info.Log("[#4b0082]ColorIndigo")
+		console.Info.Log("[#663399]ColorRebeccaPurple")
+		console.Info.Log("[#800080]ColorPurple")
+		console.Info.Log("[#9370db]ColorMediumPurple")
+		console.Info.Log("[#9400d3]ColorDarkViolet")
+		console.Info.Log("[#9932cc]ColorDarkOrchid")
+		console.Info.Log("[#ba55d3]ColorMediumOrchid")
+		console.Info.Log("[#d8bfd8]ColorThistle")
+		console.Info.Log("[#da70d6]ColorOrchid")
+		console.Info.Log("[#dda0dd]ColorPlum")
+		console.Info.Log("[#e6e6fa]ColorLavender")
+		console.Info.Log("[#ee82ee]ColorViolet")
+		console.Info.Log("[#ff00ff]ColorFuchsia")
+		console.Info.Log("[#fff0f5]ColorLavenderBlush")
+	},
+
+	'7': func() {
+		// yellow|gold|corn|lemon|papaya|orange|peach|honeydew
+		console.Info.Log("[#b8860b]ColorDarkGoldenrod")
+		console.Info.Log("[#daa520]ColorGoldenrod")
+		console.Info.Log("[#eee8aa]ColorPaleGoldenrod")
+		console.Info.Log("[#f0fff0]ColorHoneydew")
+		console.Info.Log("[#fafad2]ColorLightGoldenrodYellow")
+		console.Info.Log("[#ff8c00]ColorDarkOrange")
+		console.Info.Log("[#ffa500]ColorOrange")
+		console.Info.Log("[#ffd700]ColorGold")
+		console.Info.Log("[#ffdab9]ColorPeachPuff")
+		console.Info.Log("[#ffefd5]ColorPapayaWhip")
+		console.Info.Log("[#fff8dc]ColorCornsilk")
+		console.Info.Log("[#fffacd]ColorLemonChiffon")
+		console.Info.Log("[#ffff00]ColorYellow")
+		console.Info.Log("[#ffffe0]ColorLightYellow")
+	},
+
+	'8': func() {
+		// brown|wheat|tan|sienna|peru|moccasin|bisque
+		console.Info.Log("[#8b4513]ColorSaddleBrown")
+		console.Info.Log("[#a0522d]ColorSienna")
+		console.Info.Log("[#a52a2a]ColorBrown")
+		console.Info.Log("[#bc8f8f]ColorRosyBrown")
+		console.Info.Log("[#bdb76b]ColorDarkKhaki")
+		console.Info.Log("[#cd853f]ColorPeru")
+		console.Info.Log("[#d2691e]ColorChocolate")
+		console.Info.Log("[#d2b48c]ColorTan")
+		console.Info.Log("[#deb887]ColorBurlyWood")
+		console.Info.Log("[#f0e68c]ColorKhaki")
+		console.Info.Log("[#f4a460]ColorSandyBrown")
+		console.Info.Log("[#f5deb3]ColorWheat")
+		console.Info.Log("[#f5f5dc]ColorBeige")
+		console.Info.Log("[#ffe4b5]ColorMoccasin")
+		console.Info.Log("[#ffe4c4]ColorBisque")
+		console.Info.Log("[#ffebcd]ColorBlanchedAlmond")
+	},
+}
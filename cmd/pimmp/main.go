@@ -0,0 +1,3354 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 26 Sept 2018
+//  FILE: main.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    program entry-point and primary controller.
+//
+// =============================================================================
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"ardnew.com/goutil"
+
+	"ardnew.com/pimmp/pkg/collection"
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/contenthash"
+	"ardnew.com/pimmp/pkg/dedupe"
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/export"
+	"ardnew.com/pimmp/pkg/filecache"
+	"ardnew.com/pimmp/pkg/incoming"
+	"ardnew.com/pimmp/pkg/library"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/migrate"
+	"ardnew.com/pimmp/pkg/mpris"
+	"ardnew.com/pimmp/pkg/organize"
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/player"
+	"ardnew.com/pimmp/pkg/plugin"
+	"ardnew.com/pimmp/pkg/probe"
+	"ardnew.com/pimmp/pkg/profile"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/registry"
+	"ardnew.com/pimmp/pkg/report"
+	"ardnew.com/pimmp/pkg/storage"
+	"ardnew.com/pimmp/pkg/thumbnail"
+	"ardnew.com/pimmp/pkg/trash"
+	"ardnew.com/pimmp/pkg/verify"
+	"ardnew.com/pimmp/pkg/versions"
+)
+
+// unexported local constants.
+const (
+	defaultCPUProfileName = "cpu.prof"
+	defaultMEMProfileName = "mem.prof"
+	defaultTraceName      = "trace.out"
+	defaultBlockName      = "block.prof"
+	defaultMutexName      = "mutex.prof"
+	defaultConfigName     = "config.toml"
+	defaultLibDataName    = "library.db"
+)
+
+// versioning information defined by compiler switches in Makefile.
+var (
+	identity  string
+	version   string
+	branch    string
+	revision  string
+	buildtime string
+)
+
+// adjectives used to construct the dynamic quit message in function greeting().
+var (
+	adjGood = [...]string{
+		"an acceptable", "an excellent", "an exceptional", "a favorable",
+		"a great", "a marvelous", "a positive", "a satisfactory",
+		"a satisfying", "a superb", "a valuable", "a wonderful", "an ace",
+		"a boss", "a bully", "a capital", "a choice", "a crack", "a nice",
+		"a pleasing", "a prime", "a rad", "a sound", "a spanking", "a sterling",
+		"a super", "a superior", "a welcome", "a worthy", "an admirable",
+		"an agreeable", "a commendable", "a congenial", "a deluxe",
+		"a first-class", "a first-rate", "a gnarly", "a gratifying",
+		"a honorable", "a neat", "a precious", "a recherché", "a reputable",
+		"a select", "a shipshape", "a splendid", "a stupendous",
+		"a super-eminent", "a super-excellent", "a tip-top", "an up to snuff",
+	}
+	adjBad = [...]string{
+		"an atrocious", "a bad", "an awful", "a cheap", "a crummy",
+		"a dreadful", "a lousy", "a poor", "a rough", "a sad",
+		"an unacceptable", "a blah", "a bummer", "a diddly", "a downer",
+		"a garbage", "a gross", "an imperfect", "an inferior", "a junky",
+		"a synthetic", "an abominable", "an amiss", "a bad news", "a beastly",
+		"a bottom out", "a careless", "a cheesy", "a crappy", "a cruddy",
+		"a defective", "a deficient", "a dissatisfactory", "an erroneous",
+		"a fallacious", "a faulty", "a godawful", "a grody", "a grungy",
+		"an icky", "an inadequate", "an incorrect", "a not good", "an off",
+		"a raunchy", "a slipshod", "a stinking", "a substandard",
+		"an unsatisfactory",
+	}
+)
+
+// various globals available to all units.
+var (
+	isCLIMode    bool = false
+	isAccessible bool = false
+
+	// number of media whose latest integrity verification failed, updated
+	// by the verification scheduler. must be accessed atomically.
+	numFailedVerify int64 = 0
+)
+
+// type Option struct can contain any possible individual option configuration
+// including its command line flag identifier and usage info..
+type Option struct {
+	name   string
+	usage  string
+	source OptionSource // where the value came from, if Provided
+	bool
+	int
+	uint
+	float64
+	string
+	time.Duration
+}
+
+// type OptionSource identifies where the value of an Option was provided, in
+// increasing order of precedence.
+type OptionSource int
+
+// constant values for the enumerated type OptionSource.
+const (
+	SourceDefault OptionSource = iota // not provided, the default value
+	SourceConfig                      // the configuration file
+	SourceEnv                         // a PIMMP_* environment variable
+	SourceFlag                        // the command line
+)
+
+// function String() returns a description of the OptionSource for logs.
+func (s OptionSource) String() string {
+	switch s {
+	case SourceConfig:
+		return "config"
+	case SourceEnv:
+		return "environment"
+	case SourceFlag:
+		return "command line"
+	}
+	return "default"
+}
+
+// type listValue is the flag.Value of an Option holding a comma-separated list,
+// to which each occurrence of the option appends, e.g. "-exclude a -exclude b"
+// is the same as "-exclude a,b".
+type listValue struct{ *Option }
+
+// function String() returns the comma-separated list.
+func (v listValue) String() string {
+	if nil == v.Option {
+		return "" // the zero value, see flag.isZeroValue()
+	}
+	return v.string
+}
+
+// function Set() appends the given comma-separated list to the list.
+func (v listValue) Set(s string) error {
+	if "" != v.string && "" != s {
+		v.string += ","
+	}
+	v.string += s
+	return nil
+}
+
+// function Get() returns the comma-separated list, see flag.Getter.
+func (v listValue) Get() interface{} { return v.string }
+
+// type NamedOption is intended to map the name of an option to the actual
+// *Option struct associated with it.
+type NamedOption map[string]*Option
+
+// type Options struct defines the collection of all command line options.
+type Options struct {
+	*flag.FlagSet // the builtin command-line parser
+
+	Provided NamedOption // which options were provided by the user at runtime (see Option.source)
+
+	CPUProfile     *Option // flag indicating CPU profiling should be performed
+	CPUProfileName *Option // name of file to store pprof data of CPU profiler
+	MEMProfile     *Option // flag indicating MEM profiling should be performed
+	MEMProfileName *Option // name of file to store pprof data of MEM profiler
+	TraceProfile   *Option // flag indicating an execution trace should be recorded
+	TraceName      *Option // name of file to store the execution trace
+	BlockProfile   *Option // flag indicating blocking profiling should be performed
+	BlockName      *Option // name of file to store pprof data of blocking profiler
+	MutexProfile   *Option // flag indicating mutex contention profiling should be performed
+	MutexName      *Option // name of file to store pprof data of mutex profiler
+	PprofAddr      *Option // address on which the pprof HTTP endpoints are served (empty = none)
+
+	UsageHelp *Option // shows usage synopsis
+	Verbose   *Option // prints additional status information
+	Trace     *Option // prints very detailed status information
+	LogLevel  *Option // level of the messages logged, and of those of each component
+	Config    *Option // defines path to config file
+	LibData   *Option // defines data directory path (where to store databases)
+	CLIMode   *Option // defines the type of UI to use: CLI or TUI
+	LogPath   *Option // file path where to write all log data
+	Plugins   *Option // comma-separated list of plugin executables
+
+	Accessible *Option // linear, screen reader friendly output (implies CLI)
+
+	Match        *Option // case-insensitive text filtering the media listed by commands
+	Summary      *Option // format of the summary printed once the libraries are scanned (text, json)
+	RecentPeriod *Option // how long media is considered recently added
+	RecentScans  *Option // number of latest scans whose discoveries are recently added
+	CacheSize    *Option // size to which the cached artwork and thumbnails are limited
+
+	Template *Option // path template into which media files are organized
+	DryRun   *Option // only show what commands would change, changing nothing
+	ReadOnly *Option // never write to the library databases, discarding their changes
+
+	Collection *Option // name of the collection to which commands are restricted
+
+	Incoming     *Option // folder watched for new files moved into the libraries
+	IncomingPoll *Option // how often the incoming folder is checked for new files
+
+	Verify *Option // percentage of the libraries verified per day in the background
+	Decode *Option // also decode media files in full when verifying them
+
+	Prune *Option // delete the records of missing files rather than orphaning them
+
+	FollowLinks *Option // follow symbolic links to files and directories when scanning
+
+	Exclude *Option // comma-separated list of glob patterns of the files never scanned
+	Ext     *Option // comma-separated list of changes to the file name extensions of each kind of file
+
+	MinSize    *Option // size below which audio and video files are skipped
+	Sample     *Option // comma-separated list of glob patterns of the names of samples and trailers
+	SampleSize *Option // size up to which audio and video files matching -sample are skipped
+
+	Watch  *Option // keep watching the libraries for changes after the initial scan (CLI mode)
+	Daemon *Option // detach from the terminal, watching the libraries (or serving them) in the background
+
+	Progress *Option // how often the progress of each load and scan is printed (CLI mode)
+
+	PlayVideo *Option // command line template playing video
+	PlayAudio *Option // command line template playing audio
+	Reader    *Option // command line template opening documents
+	Graphics  *Option // terminal graphics protocol showing thumbnails in the TUI (auto, kitty, sixel, none)
+
+	NoMetadata *Option // skip reading the tags embedded in audio files
+
+	Probe *Option // describe the streams of video files using ffprobe when scanning
+	Sniff *Option // identify files of unknown extension by their content when scanning
+
+	Loaders      *Option // number of libraries loaded at once (0 = all)
+	Scanners     *Option // number of libraries scanned at once (0 = all)
+	DiskScanners *Option // number of libraries on the same device scanned at once (0 = all)
+	Probers      *Option // number of ffprobe processes run at once (0 = any)
+	ScanRate     *Option // rate at which scans examine and read files, e.g. "20MB/s,500files/s"
+
+	NoMPRIS *Option // don't expose playback on the D-Bus session bus by MPRIS
+
+	HashSize *Option // MiB hashed at each end of large media files (0 = whole files, < 0 = none)
+
+	TMDBKey *Option // API key of The Movie Database, used by the fetch command
+	TVDBKey *Option // API key of TheTVDB, used by the fetch command
+
+	AcoustIDKey *Option // API key of AcoustID, used by the fetch command to identify untagged audio
+
+	TraktID     *Option // client ID of the Trakt API application synced with
+	TraktSecret *Option // client secret of the Trakt API application synced with
+	TraktSync   *Option // how often the libraries are synced with Trakt in the background
+
+	WebUsers  *Option // comma-separated list of the users of the web interface (name:password[:role])
+	WebTokens *Option // comma-separated list of the bearer tokens of the web interface (token[:role])
+	WebTLS    *Option // serve the web interface over TLS
+	WebCert   *Option // path of the web interface's TLS certificate (default: self-signed)
+	WebKey    *Option // path of the private key of the web interface's TLS certificate
+
+	SubLang *Option // preferred languages of subtitles, comma-separated, most preferred first
+
+	SubThreshold *Option // lowest score (0 to 1) of a video associated with subtitles
+	SubDirWeight *Option // weight (0 to 1) of directory proximity in the scores of videos for subtitles
+
+	OnScanComplete  *Option // shell command run when a library scan finishes
+	OnNewMedia      *Option // shell command run when new media is discovered
+	OnMediaRemoved  *Option // shell command run when the record of media is removed
+	OnPlaybackStart *Option // shell command run when playback starts
+	OnPlaybackDone  *Option // shell command run when playback finishes
+
+	DBEngine       *Option // database engine of newly created library databases
+	DiskBufferSize *Option // size (bytes) of each collection's pre-allocated buffers on disk. num buffers = num CPU cores
+	HashBufferSize *Option // size (bytes) by which each hash table will grow once individual capacity is exceeded.
+
+	libArgs []string // positional args identifying the library paths
+
+	subcommands []*Subcommand // all subcommands, which parse their own options
+	subcommand  *Subcommand   // subcommand to perform instead of normal operation (nil if none)
+	subArgs     []string      // positional args taken by the subcommand itself
+	maxDepth    uint          // max traversal depth of the library scanners (unlimited: 0)
+	cacheSize   int64         // size to which the file cache is limited (unlimited: 0)
+
+	ctx    context.Context    // done once the library scanners and loaders are interrupted
+	cancel context.CancelFunc // interrupts the library scanners and loaders
+
+	bus *library.Bus // on which the library loaders, scanners, and watchers publish what they find
+
+	profile *profile.Profile // the active viewing profile, or nil if none
+	mpris   *mpris.Server    // controls playback from the desktop, or nil if unavailable
+}
+
+// type TimeInterval struct contains a start and end time (together with a
+// contains() function) as well as a description string.
+type TimeInterval struct {
+	start time.Time
+	stop  time.Time
+	desc  string
+}
+
+// function contains() verifies the given time is in the TimeInterval half-open
+// range, i.e. time is in interval [start, end).
+func (i *TimeInterval) contains(t time.Time) bool {
+	return (t.After(i.start) || t.Equal(i.start)) && t.Before(i.stop)
+}
+
+// function greeting() generates a random adjective (synonym of "good" or "bad")
+// followed by a nominal time of day using the actual current system time.
+// e.g. "a crummy evening", or "a splendid morning"
+func greeting() string {
+
+	// first retrieve the current system time and convert it to a Date object.
+	n := time.Now()
+	d := time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, n.Location())
+
+	// next, randomly select a "good" or "bad" adjective using the low bit of
+	// the current second of the current time; and then, randomly select one of
+	// the elements of the respective adjective array.
+	rand.Seed(n.UnixNano())
+	var s, t string
+	if (n.Second() & 1) == 1 {
+		s = adjGood[rand.Intn(len(adjGood))]
+	} else {
+		s = adjBad[rand.Intn(len(adjBad))]
+	}
+
+	// lastly, check which time interval our current time is in to decide which
+	// general time of day to describe.
+	for _, ti := range []*TimeInterval{
+		&TimeInterval{d.Add(time.Hour * 00), d.Add(time.Hour * 05), "night"},     // 12AM-04:59:59AM
+		&TimeInterval{d.Add(time.Hour * 05), d.Add(time.Hour * 12), "morning"},   // 05AM-11:59:59AM
+		&TimeInterval{d.Add(time.Hour * 12), d.Add(time.Hour * 17), "afternoon"}, // 12PM-04:59:59PM
+		&TimeInterval{d.Add(time.Hour * 17), d.Add(time.Hour * 22), "evening"},   // 05PM-09:59:59PM
+		&TimeInterval{d.Add(time.Hour * 22), d.Add(time.Hour * 24), "night"},     // 10PM-11:59:59PM
+	} {
+		if ti.contains(n) {
+			t = ti.desc
+			break
+		}
+	}
+
+	// concatenate the result, ???, PROFIT
+	return fmt.Sprintf("quitting, have %s %s!", s, t)
+}
+
+// function main() is the program entry point, obviously :)
+func main() {
+
+	// the program's exit status is derived from the ReturnCode of run(), which
+	// has released everything it acquired by the time it returns.
+	c := run()
+	switch {
+	// non-errors, normal cleanup and exit
+	case errors.Is(c, rc.OK), errors.Is(c, rc.Usage):
+		console.Info.Die(c, false)
+	// common errors, not unusual enough reason for stack trace
+	case errors.Is(c, rc.InvalidConfig):
+		console.Error.Die(c, false)
+	// all other errors not specifically handled above
+	default:
+		console.Error.Die(c, true)
+	}
+}
+
+// function run() performs whatever the command line asks for, returning the
+// ReturnCode with which the program exits: OK once finished normally, Usage if
+// only the usage was shown, or whichever error stopped it.
+func run() (ret *rc.ReturnCode) {
+
+	var busyState *library.BusyState = library.NewBusyState()
+	var initComplete chan bool = make(chan bool)
+
+	// first things first, parse options and command line arguments which can
+	// influence the operating modes of the program from a very high level.
+	options, err := initOptions()
+	if nil != err {
+		// immediately terminate if we don't understand the runtime options.
+		return err
+	}
+
+	// with -daemon, this process only starts the daemon, which then does
+	// everything else in the background.
+	if options.Daemon.bool {
+		if ret := checkDaemon(options); nil != ret {
+			return ret
+		}
+		if "" == os.Getenv(daemonEnv) {
+			pid, logPath, ret := startDaemon(options)
+			if nil != ret {
+				return ret
+			}
+			console.Info.Logf("started daemon: PID %d (logging to %q)", pid, logPath)
+			return rc.OK.Spec(greeting())
+		}
+		// the commands run by the daemon (hooks, players) aren't daemons.
+		os.Unsetenv(daemonEnv)
+		// it has no terminal for the TUI, and keeps watching the libraries.
+		isCLIMode = true
+		options.Watch.bool = true
+	}
+
+	// if the user provided a log file, redirect all output to that file instead
+	// of the default of STDOUT (or our LogView when running in TUI mode).
+	logPath, isLogPathProvided := options.Provided[options.LogPath.name]
+	if isLogPathProvided {
+		of, err := os.Create(logPath.string)
+		if err != nil {
+			return rc.InvalidPath.Specf("could not create log file: %s", err)
+		}
+		defer func() {
+			if err := of.Close(); nil != err && errors.Is(ret, rc.OK) {
+				ret = rc.InvalidPath.Specf("could not close log file: %s", err)
+			}
+		}()
+		ow := bufio.NewWriter(of)
+		console.SetWriterAll(ow)
+	}
+
+	// create the CPU profiler output if requested.
+	if options.CPUProfile.bool && "" != options.CPUProfileName.string {
+		console.Info.Verbosef("writing CPU profile: %q", options.CPUProfileName.string)
+		f, err := os.Create(options.CPUProfileName.string)
+		if err != nil {
+			return rc.InvalidFile.Specf("could not create CPU profile: %s", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return rc.InvalidFile.Specf("could not start CPU profile: %s", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	// record the execution trace, enable the blocking and mutex profilers, and
+	// serve the pprof endpoints if requested.
+	stopProfiling, ret := startProfiling(options)
+	if nil != ret {
+		return ret
+	}
+	defer stopProfiling()
+
+	// if no options were provided and no config file exists, then we are
+	// totally lost and confused. display usage and bail out.
+	config := options.Config.string
+	configExists, _ := goutil.PathExists(config)
+	if !configExists && len(os.Args) <= 1 {
+		options.Usage()
+		return rc.Usage
+	}
+
+	// create the directory hierarchy that will store our configuration data
+	// permanently on disk.
+	configDir := options.configDir()
+	if !configExists {
+		if dirExists, _ := goutil.PathExists(configDir); !dirExists {
+			if err := os.MkdirAll(configDir, os.ModePerm); nil != err {
+				return rc.InvalidConfig.Specf(
+					"cannot create configuration directory: %q: %s", configDir, err)
+			}
+			console.Info.Tracef("created configuration directory: %q", configDir)
+		}
+
+		// the configuration file documents every option, so that it can be
+		// edited without first consulting the usage.
+		if ret := writeConfig(options, config); nil != ret {
+			console.Warn.Log(ret)
+		} else {
+			console.Info.Verbosef("created configuration: %q", config)
+		}
+	}
+
+	// create the directory hierarchy that will store our libraries' backing
+	// data stores permanently on disk.
+	libData := options.LibData.string
+	if exists, _ := goutil.PathExists(libData); !exists {
+		if err := os.MkdirAll(libData, os.ModePerm); nil != err {
+			return rc.InvalidConfig.Specf(
+				"cannot create shared data directory: %q: %s", libData, err)
+		}
+		console.Info.Tracef("created shared data directory: %q", libData)
+	} else {
+		console.Info.Tracef("(TBD) -- loading shared data directory: %q", libData)
+	}
+
+	// only one daemon runs at a time, which can be found by its PID file.
+	if options.Daemon.bool {
+		pid, ret := lockDaemon(options)
+		if nil != ret {
+			return ret
+		}
+		defer pid.Release()
+	}
+
+	// the subcommands managing e.g. collections only change the configuration,
+	// they need no libraries.
+	if nil != options.subcommand && options.subcommand.noLibs {
+		return finish(options.subcommand.run(options, options.subArgs, nil))
+	}
+	if ret := checkReadOnly(options); nil != ret {
+		return ret
+	}
+
+	// the active viewing profile hides its media from everything that follows:
+	// the TUI, the CLI, and all subcommands.
+	options.profile = activeProfile(options)
+
+	// start any external plugins before the libraries, so that they can be
+	// consulted during the initial scan.
+	plugins := initPlugins(options)
+	defer plugins.Close()
+
+	options.mpris = initMPRIS(options)
+	defer options.mpris.Close()
+
+	// runtime environment defined, begin preparing the libs and databases.
+	console.Info.Log("initializing library databases ...")
+
+	// the libraries begin loading and scanning as soon as they are verified.
+	if isCLIMode && options.Progress.Duration > 0 {
+		go reportProgress(options.ctx, busyState, options.Progress.Duration)
+	}
+
+	// remaining arguments are considered paths to libraries; verify the paths
+	// before assuming valid ones exist for traversal.
+	libs, ret := initLibrary(options, busyState)
+	if nil != ret {
+		return ret
+	}
+	probeVideo := scanProbe(options)
+	scheduler, ret := library.NewScheduler(library.Limits{
+		Loads:     options.Loaders.int,
+		Scans:     options.Scanners.int,
+		DiskScans: options.DiskScanners.int,
+		Probes:    options.Probers.int,
+	})
+	if nil != ret {
+		return ret
+	}
+	scanRate, ret := library.ParseScanRate(options.ScanRate.string)
+	if nil != ret {
+		return ret
+	}
+	filter, ret := library.NewFilter(options.MinSize.string,
+		splitList(options.Sample.string), options.SampleSize.string)
+	if nil != ret {
+		return ret
+	}
+	for _, l := range libs {
+		l.SetPlugins(plugins)
+		l.SetScheduler(scheduler)
+		l.SetScanRate(scanRate)
+		l.SetFilter(filter)
+		l.SetPrune(options.Prune.bool)
+		l.SetFollowLinks(options.FollowLinks.bool)
+		l.SetReadMetadata(!options.NoMetadata.bool)
+		l.SetProbe(probeVideo)
+		l.SetSniff(options.Sniff.bool)
+		l.SetHashPartial(int64(options.HashSize.int) << 20)
+		if ret := l.SetSubtitleLanguages(splitList(options.SubLang.string)); nil != ret {
+			return ret
+		}
+		if ret := l.SetSubtitleMatching(options.SubThreshold.float64, options.SubDirWeight.float64); nil != ret {
+			return ret
+		}
+		if ret := l.SetExclude(splitList(options.Exclude.string)); nil != ret {
+			return ret
+		}
+		if nil != options.profile {
+			l.SetHidden(options.profile.Hides)
+		}
+	}
+	if 0 == len(libs) {
+		return rc.InvalidConfig.Spec("no valid libraries provided (see \"lib add\")")
+	}
+	// the plugins and shell hooks are told of the changes to the libraries.
+	library.SubscribePlugins(options.bus)
+
+	// in accessible mode, each change in status is announced as it happens
+	// since there are no visual indicators to convey it.
+	if isAccessible {
+		announcer = newAnnouncer(busyState)
+		go announcer.listen()
+	}
+
+	// the subcommands operate on the libraries' databases, each handling its
+	// own output.
+	if nil != options.subcommand {
+		return finish(options.subcommand.run(options, options.subArgs, libs))
+	}
+
+	// verify the incoming folder before scanning, so that a mistake is reported
+	// right away instead of after a possibly lengthy scan.
+	watcher, template, ret := initIncoming(options)
+	if nil != ret {
+		return ret
+	}
+
+	// dispatch a goroutine that will listen for the database and file system
+	// media discovery goroutines to finish (scanComplete will only be written
+	// to once both the load and scan operations have completed).
+	scanStart := time.Now()
+
+	// the TUI is created before any media are found so that none are missed by
+	// its media browser.
+	var layout *Layout
+	if !isCLIMode {
+		layout = newLayout(options, busyState, libs...)
+		subscribeLayout(options.bus, layout)
+		// associate the loggers with the navigable log viewer.
+		if !isLogPathProvided {
+			console.SetWriterAll(layout.logView)
+		}
+	}
+
+	subscribeLog(options.bus)
+	summarize := subscribeSummary(options)
+
+	go func(lib []*library.Library, start time.Time) {
+
+		var numFound uint = 0
+		for _, l := range lib {
+			// block this goroutine until each library has written to their
+			// respective channel. the order in which we receive this channel
+			// data is irrelevant because they -all- must complete.
+			numFound += <-l.ScanComplete()
+		}
+		scanElapsed := time.Since(start)
+		console.Info.Logf("initialization complete (%d ~things~ found in %s)",
+			numFound, scanElapsed.Round(time.Millisecond))
+		if isCLIMode {
+			reportChanges(options, lib)
+			if ret := summarize(lib, numFound, scanElapsed); nil != ret {
+				console.Error.Log(ret)
+			}
+		}
+
+		// new files are only moved into the libraries once they have been
+		// scanned, so that they are indexed where they belong.
+		if nil != watcher {
+			go watchIncoming(options, watcher, template, lib)
+		}
+		// likewise, verification waits for the media to be indexed.
+		if options.Verify.float64 > 0 {
+			go scheduleVerify(options, lib)
+		}
+		// and so does syncing with Trakt.
+		if options.TraktSync.Duration > 0 {
+			go scheduleTrakt(options, lib)
+		}
+		// and the libraries are only watched for changes once their files
+		// are all known.
+		if !isCLIMode || options.Watch.bool {
+			for _, l := range lib {
+				go watchLibrary(options, l)
+			}
+		}
+
+		// the only purpose of this channel is to safely handle the transition
+		// from the initial CLI mode to the ncurses TUI mode by displaying
+		// status information to the appropriate interface. if this channel is
+		// still empty by the time we have our ncurses interface shown, then all
+		// prior log/status messages have been obscured by the UI. so we want to
+		// give an update to convince the user we are still working on it. this
+		// determination is made by the select-true block below with a default
+		// case that is selected when initComplete is empty.
+		initComplete <- true
+
+	}(libs, scanStart)
+
+	// libraries ready, spool up the library scanners.
+	populateLibrary(options, libs)
+
+	// a long-lived process reloads its configuration and rescans the
+	// libraries when asked to, e.g. by "kill -HUP".
+	longLived := nil != watcher || options.Verify.float64 > 0 || options.TraktSync.Duration > 0 || options.Watch.bool
+	if !isCLIMode || longLived {
+		reloadOnSignal(options, libs)
+	}
+
+	// we don't wait for the scanning to finish. go ahead and launch the UI for
+	// progress indicators and anything else the user can get away with while
+	// the scanners/loaders work.
+	if !isCLIMode {
+		select {
+		case <-initComplete:
+			// if there exists something in this channel, then we have already
+			// printed "initialization complete ..." above. in which case, just
+			// carry on and display the UI with no other status to the user.
+			// we will hit this case block which is a NOOP.
+		default:
+			// otherwise, nothing exists in that channel and we are still
+			// scanning. don't sit around like a deadbeat -- tell the user we're
+			// working on it.
+			console.Info.Logf("still initializing library databases ...")
+		}
+		if errCode := layout.show(); nil != errCode {
+			return errCode
+		}
+		// stop the scanners and watchers still working once the UI is gone.
+		options.cancel()
+	} else {
+		<-initComplete
+		// the incoming folder and libraries are watched, and the libraries
+		// verified and synced, until the program is interrupted.
+		if longLived {
+			<-options.ctx.Done()
+		}
+	}
+
+	// create the memory profiler output if requested
+	if options.MEMProfile.bool && "" != options.MEMProfileName.string {
+		console.Info.Verbosef("writing memory profile: %q", options.MEMProfileName.string)
+		f, err := os.Create(options.MEMProfileName.string)
+		if err != nil {
+			return rc.InvalidFile.Specf("could not create memory profile: %s", err)
+		}
+		runtime.GC() // get up-to-date statistics
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return rc.InvalidFile.Specf("could not write memory profile: %s", err)
+		}
+		f.Close()
+	}
+
+	// create the blocking and mutex profiler outputs if requested.
+	if ret := writeProfiles(options); nil != ret {
+		return ret
+	}
+
+	// exit cleanly but explicitly so that we have some control on exit codes
+	// and resource cleanup.
+	return rc.OK.Spec(greeting())
+}
+
+// function finish() returns the ReturnCode with which the program exits once
+// a command returning the given ReturnCode is performed: OK unless it failed.
+func finish(ret *rc.ReturnCode) *rc.ReturnCode {
+	if nil != ret {
+		return ret
+	}
+	return rc.OK.Spec(greeting())
+}
+
+// function checkReadOnly() returns an error if the libraries' databases are
+// read-only (see options -readonly and -dryrun) and the subcommand given moves
+// or deletes their files, which the records of a read-only database couldn't
+// follow. those only showing what they would change with -dryrun are allowed
+// with it.
+func checkReadOnly(options *Options) *rc.ReturnCode {
+
+	s := options.subcommand
+	if (!options.ReadOnly.bool && !options.DryRun.bool) || nil == s || !s.files {
+		return nil
+	}
+	if s.dryRun && options.DryRun.bool {
+		return nil
+	}
+	flag := options.ReadOnly.name
+	if !options.ReadOnly.bool {
+		flag = options.DryRun.name
+	}
+	return rc.InvalidArgs.Specf("cannot %q with -%s: it changes files, whose records a read-only database can't update",
+		s.name, flag)
+}
+
+// function reportChanges() logs the changes a scan would have written to the
+// database of each of the given libraries, if read-only (see option -dryrun),
+// by collection.
+func reportChanges(options *Options, libs []*library.Library) {
+
+	if !options.DryRun.bool {
+		return
+	}
+	for _, l := range libs {
+		changes := l.DB().Changes()
+		if 0 == len(changes) {
+			console.Info.Logf("dry run: %q: no changes", l.Name())
+			continue
+		}
+		name := make([]string, 0, len(changes))
+		for n := range changes {
+			name = append(name, n)
+		}
+		sort.Strings(name)
+		for _, n := range name {
+			c := changes[n]
+			console.Info.Logf("dry run: %q: %s: %d record(s) would be inserted, %d updated, %d deleted",
+				l.Name(), n, c.Inserted, c.Updated, c.Deleted)
+		}
+	}
+}
+
+// function interruptOnSignal() interrupts the library scanners and loaders the
+// first time the program receives an interrupt signal (e.g. Ctrl+C) or SIGTERM,
+// so that each flushes what it has found so far to its database before
+// returning. the program exits immediately on the second signal.
+func interruptOnSignal(options *Options) {
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		console.Warn.Logf("interrupted, stopping the library scanners (interrupt again to exit immediately) ...")
+		options.cancel()
+		<-sig
+		console.Warn.Die(rc.Canceled.Spec("interrupted"), false)
+	}()
+}
+
+// function reloadOnSignal() reloads the configuration file (see reloadConfig())
+// each time the program receives SIGHUP until it exits, and then rescans the
+// given libraries, finding the files added (or no longer excluded) since. the
+// files found are published on the event bus.
+func reloadOnSignal(options *Options, libs []*library.Library) {
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(sig)
+		for {
+			select {
+			case <-options.ctx.Done():
+				return
+			case <-sig:
+			}
+			console.Info.Logf("reloading configuration: %q", options.Config.string)
+			if set, ret := reloadConfig(options, libs); nil != ret {
+				console.Warn.Log(ret)
+			} else if len(set) > 0 {
+				console.Info.Logf("changed options: %s", strings.Join(set, ", "))
+			}
+			for _, l := range libs {
+				numFound, ret := l.Scan(options.ctx, options.bus)
+				if nil != ret {
+					// e.g. the library is still busy with its initial scan.
+					console.Warn.Log(ret)
+					continue
+				}
+				console.Info.Logf("rescanned %q (%d ~things~ found)", l.Name(), numFound)
+			}
+		}
+	}()
+}
+
+// function logLevels() returns the level of the messages logged, and of those
+// of each component, given by the -loglevel option. -verbose and -trace raise
+// the level of the messages of components without a level of their own to at
+// least debug and trace, respectively.
+func (o *Options) logLevels() (console.Level, map[string]console.Level, *rc.ReturnCode) {
+
+	level, filter, ret := console.ParseLevels(o.LogLevel.string)
+	if nil != ret {
+		return level, nil, ret
+	}
+	least := console.LevelError
+	switch {
+	case o.Trace.bool:
+		least = console.LevelTrace
+	case o.Verbose.bool:
+		least = console.LevelDebug
+	}
+	if level < least {
+		level = least
+	}
+	return level, filter, nil
+}
+
+// function configDir() constructs the full path to the directory containing all
+// of the program's supporting configuration data. if the user has defined a
+// specific config file (via -config arg), then use the _logical_ parent
+// directory of that file path; otherwise, use the platform's conventional path
+// (see platform.ConfigDir()), unless the directory of earlier versions exists
+// (see legacyDir()).
+func (o *Options) configDir() string {
+	if nil == o {
+		if legacy := legacyDir(); "" != legacy {
+			return legacy
+		}
+		return platform.ConfigDir(identity)
+	} else {
+		return filepath.Dir(o.Config.string)
+	}
+}
+
+// function dataDir() constructs the full path to the directory containing the
+// library data by default, which is the platform's conventional path (see
+// platform.DataDir()), unless the directory of earlier versions exists (see
+// legacyDir()).
+func dataDir() string {
+	if legacy := legacyDir(); "" != legacy {
+		return legacy
+	}
+	return platform.DataDir(identity)
+}
+
+// function legacyDir() returns the path "~/.<identity>", which contained both
+// the configuration and the library data of earlier versions, if it exists,
+// so that upgrading doesn't lose them. otherwise, returns the empty string.
+func legacyDir() string {
+	dir := filepath.Join(platform.HomeDir(), fmt.Sprintf(".%s", identity))
+	if info, err := os.Stat(dir); nil == err && info.IsDir() {
+		return dir
+	}
+	return ""
+}
+
+// function providedDBConfig() checks the "Provided" hash of the Options struct
+// for any of the options related to initial database configuration. this is
+// necessary to decide how to initialize the database. furthermore, a []string
+// will be returned containing the name of each option the user provided.
+func (o *Options) providedDBConfig() (bool, []string) {
+
+	list := []string{}
+	provided := false
+
+	if d, ok := o.Provided[o.DiskBufferSize.name]; ok {
+		list = append(list, d.name)
+		provided = true
+	}
+	if d, ok := o.Provided[o.HashBufferSize.name]; ok {
+		list = append(list, d.name)
+		provided = true
+	}
+
+	return provided, list
+}
+
+// function dbConfig() constructs the database engine configuration from the
+// current Options struct.
+func (o *Options) dbConfig() *storage.Config {
+
+	_, provided := o.providedDBConfig()
+
+	return &storage.Config{
+		Engine:         o.DBEngine.string,
+		DiskBufferSize: o.DiskBufferSize.int,
+		HashBufferSize: o.HashBufferSize.int,
+		Provided:       provided,
+		ReadOnly:       o.ReadOnly.bool || o.DryRun.bool,
+	}
+}
+
+// function initOptions() parses all command line arguments and prepares the
+// environment.
+func initOptions() (options *Options, err *rc.ReturnCode) {
+
+	defer func() {
+		// without options parsed, we cannot know where to print any status or
+		// other info, so we always print everything to the console until they
+		// are. this flag controls that state change.
+		level, filter := console.LevelInfo, map[string]console.Level(nil)
+		if nil != options {
+			if l, f, ret := options.logLevels(); nil == ret {
+				level, filter = l, f
+			}
+		}
+		console.SetLevel(level, filter)
+	}()
+
+	// by default,
+	configPath := filepath.Join(options.configDir(), defaultConfigName)
+	libDataPath := filepath.Join(dataDir(), defaultLibDataName)
+
+	// define the option properties that the command line parser recognizes.
+	options = &Options{
+		// ContinueOnError returns the error of Parse() below, which is
+		// flag.ErrHelp if the usage was requested, so that we can report it
+		// with our own error logger.
+		FlagSet:  flag.NewFlagSet(identity, flag.ContinueOnError),
+		Provided: NamedOption{},
+
+		CPUProfile: &Option{
+			name:  "cpuprofile",
+			usage: "flag indicating CPU profiling should be performed",
+			bool:  false,
+		},
+		CPUProfileName: &Option{
+			name:   "cpuprofilename",
+			usage:  "path to file to store pprof data of CPU profiler",
+			string: filepath.Join(os.Getenv("PWD"), defaultCPUProfileName),
+		},
+		MEMProfile: &Option{
+			name:  "memprofile",
+			usage: "flag indicating MEM profiling should be performed",
+			bool:  false,
+		},
+		MEMProfileName: &Option{
+			name:   "memprofilename",
+			usage:  "path to file to store pprof data of MEM profiler",
+			string: filepath.Join(os.Getenv("PWD"), defaultMEMProfileName),
+		},
+		TraceProfile: &Option{
+			name:  "traceprofile",
+			usage: "flag indicating an execution trace should be recorded (see \"go tool trace\")",
+			bool:  false,
+		},
+		TraceName: &Option{
+			name:   "traceprofilename",
+			usage:  "path to file to store the execution trace",
+			string: filepath.Join(os.Getenv("PWD"), defaultTraceName),
+		},
+		BlockProfile: &Option{
+			name:  "blockprofile",
+			usage: "flag indicating profiling of goroutines blocked on synchronization should be performed",
+			bool:  false,
+		},
+		BlockName: &Option{
+			name:   "blockprofilename",
+			usage:  "path to file to store pprof data of blocking profiler",
+			string: filepath.Join(os.Getenv("PWD"), defaultBlockName),
+		},
+		MutexProfile: &Option{
+			name:  "mutexprofile",
+			usage: "flag indicating profiling of mutex contention should be performed",
+			bool:  false,
+		},
+		MutexName: &Option{
+			name:   "mutexprofilename",
+			usage:  "path to file to store pprof data of mutex profiler",
+			string: filepath.Join(os.Getenv("PWD"), defaultMutexName),
+		},
+		PprofAddr: &Option{
+			name:   "pprofaddr",
+			usage:  "address on which the pprof HTTP endpoints (/debug/pprof/) are served while running, e.g. \"localhost:6060\" (empty = none)",
+			string: "",
+		},
+		UsageHelp: &Option{
+			name:  "help",
+			usage: "display this helpful usage synopsis!",
+			bool:  false,
+		},
+		Verbose: &Option{
+			name:  "verbose",
+			usage: "display additional status information",
+			bool:  false,
+		},
+		Trace: &Option{
+			name:  "trace",
+			usage: "display additional status information (maximum verbosity)",
+			bool:  false,
+		},
+		LogLevel: &Option{
+			name:   "loglevel",
+			usage:  "level of the messages logged: error, warn, info, debug (= -verbose), or trace (= -trace), optionally followed by the levels of individual components, e.g. \"warn,scan=trace,db=error\" (components: " + strings.Join(console.Components(), ", ") + ")",
+			string: console.LevelInfo.String(),
+		},
+		CLIMode: &Option{
+			name:  "cli",
+			usage: "disables the curses-style textual user interface, falling back to basic terminal I/O. useful when deugging.",
+			bool:  false,
+		},
+		Accessible: &Option{
+			name:  "accessible",
+			usage: "screen reader friendly mode: linear, clearly labeled output with announcements of each change in status (implies -cli)",
+			bool:  false,
+		},
+		Match: &Option{
+			name:   "match",
+			usage:  "only include media whose title, name, or path contains this text (case-insensitive) in the output of commands",
+			string: "",
+		},
+		Summary: &Option{
+			name:   "summary",
+			usage:  "format of the summary printed once the libraries are scanned by the CLI or \"scan\": text (logged) or json (a single document written to standard output, in place of the usual status messages)",
+			string: summaryText,
+		},
+		RecentPeriod: &Option{
+			name:     "recent",
+			usage:    "how long media is considered recently added",
+			Duration: 7 * 24 * time.Hour,
+		},
+		RecentScans: &Option{
+			name:  "sessions",
+			usage: "consider media recently added if discovered by this many of the latest scans of its library, e.g. 1 for the media new since the last run (0 = use -recent instead)",
+			int:   0,
+		},
+		CacheSize: &Option{
+			name:   "cachesize",
+			usage:  "size to which the artwork downloaded and the thumbnails generated are limited, in directory \"" + filecache.DirName + "\" of the library data directory, the least recently used being removed first, e.g. \"1GiB\" (units B, KB, MB, GB, KiB, MiB, GiB; 0 = unlimited)",
+			string: "512MiB",
+		},
+		Template: &Option{
+			name:   "template",
+			usage:  "path template, relative to the library, into which the -incoming folder (and the \"organize\" subcommand, unless given its own -template) moves media files, e.g. \"{show}/Season {s}/{show} - S{s:2}E{e:2} - {title}.{ext}\"",
+			string: "",
+		},
+		DryRun: &Option{
+			name:  "dryrun",
+			usage: "only show what subcommands (e.g. \"organize\", \"dedupe\") and scans would change, without changing anything (implies -readonly; scans report the records they would insert, update, and delete)",
+			bool:  false,
+		},
+		ReadOnly: &Option{
+			name:  "readonly",
+			usage: "never write to the library databases: the changes made by scans and commands are kept in memory and discarded on exit (the databases must already exist, and commands moving or deleting files are refused)",
+			bool:  false,
+		},
+		Collection: &Option{
+			name:   "collection",
+			usage:  "only include media in the named collection (see \"collection add\") in the output of subcommands",
+			string: "",
+		},
+		Incoming: &Option{
+			name:   "incoming",
+			usage:  "folder watched for new files, which are moved into the library holding the most media of their kind (renamed by the -template, if given) and indexed",
+			string: "",
+		},
+		IncomingPoll: &Option{
+			name:     "incomingpoll",
+			usage:    "how often the -incoming folder is checked for new files",
+			Duration: 10 * time.Second,
+		},
+		Verify: &Option{
+			name:    "verify",
+			usage:   "percentage of the media in the libraries whose integrity is verified per day in the background, least recently verified first, e.g. 5 to verify everything every 20 days (0 = only by the \"verify\" subcommand)",
+			float64: 0,
+		},
+		Decode: &Option{
+			name:  "decode",
+			usage: "also decode the entire media file when verifying its integrity, if ffmpeg is installed (slow, but finds damage present since it was added)",
+			bool:  true,
+		},
+		Prune: &Option{
+			name:  "prune",
+			usage: "delete the database records of media files found missing while loading, rather than moving them to each library's orphaned collection (from which they could be restored)",
+			bool:  false,
+		},
+		FollowLinks: &Option{
+			name:  "followsymlinks",
+			usage: "follow symbolic links to files and directories when scanning the libraries, rather than skipping them (links leading back to a directory already scanned are skipped)",
+			bool:  false,
+		},
+		Exclude: &Option{
+			name:   "exclude",
+			usage:  "glob pattern of the files and directories never scanned, in addition to those listed in each library's " + library.IgnoreFileName + " file (may be repeated, or given as a comma-separated list)",
+			string: "",
+		},
+		Ext: &Option{
+			name:   "ext",
+			usage:  "file name extension added to (\"kind:.ext\", or \"kind:.ext=name\" naming its file type) or removed from (\"-kind:.ext\") those identifying a kind of media or support file: audio, video, image, document, subtitles, metadata, lyrics, cuesheet, or artwork, e.g. \"audio:.dsf\" or \"-video:.ogg\" (may be repeated, or given as a comma-separated list)",
+			string: "",
+		},
+		MinSize: &Option{
+			name:   "minsize",
+			usage:  "size below which audio and video files are skipped when scanning, e.g. \"500KB\" (units B, KB, MB, GB, KiB, MiB, GiB; empty = none)",
+			string: "",
+		},
+		Sample: &Option{
+			name:   "sample",
+			usage:  "glob pattern of the names of the audio and video files (or of their directories) skipped when scanning as samples or trailers no larger than -samplesize, e.g. \"*sample*\" (may be repeated, or given as a comma-separated list)",
+			string: "",
+		},
+		SampleSize: &Option{
+			name:   "samplesize",
+			usage:  "size up to which audio and video files matching -sample are skipped (empty = any size)",
+			string: "50MB",
+		},
+		Watch: &Option{
+			name:  "watch",
+			usage: "in CLI mode, keep watching the libraries for files added, changed, or removed after the initial scan, updating their databases until interrupted (the TUI always watches them while open)",
+			bool:  false,
+		},
+		Daemon: &Option{
+			name:  "daemon",
+			usage: "detach from the terminal and keep running in the background, watching the libraries (as with -cli -watch) or, with the \"serve\" subcommand, serving them, until sent SIGTERM; the process ID is written to " + daemonPIDFileName + " in the configuration directory, and messages to -log (default: " + daemonLogFileName + " there)",
+			bool:  false,
+		},
+		Progress: &Option{
+			name:     "progress",
+			usage:    "in CLI mode, how often the progress of each library's load and scan is printed, i.e. the number of records or files processed, of those expected from the last scan, the bytes processed per second, and the time left (0 = never)",
+			Duration: 0,
+		},
+		PlayVideo: &Option{
+			name:   "playvideo",
+			usage:  "command line playing video, in which {path}, {title}, {subs}, and {sub} are replaced by the media's file path, title, subtitle files, and preferred subtitle file (the path is appended if {path} is omitted)",
+			string: player.DefaultCommand,
+		},
+		PlayAudio: &Option{
+			name:   "playaudio",
+			usage:  "command line playing audio, see -playvideo",
+			string: player.DefaultCommand,
+		},
+		Reader: &Option{
+			name:   "reader",
+			usage:  "command line opening ebooks, comics, and other documents, see -playvideo",
+			string: platform.Opener,
+		},
+		Graphics: &Option{
+			name:   "graphics",
+			usage:  "terminal graphics protocol by which the TUI shows the thumbnail of the selected media (a frame of a video, or the waveform of audio, generated by ffmpeg): auto (detected from the environment), kitty, sixel, or none",
+			string: thumbnail.ProtocolAuto,
+		},
+		NoMetadata: &Option{
+			name:  "nometadata",
+			usage: "skip reading the tags embedded in audio files (artist, album, track, year, genre, and length), the EXIF data of images, and the metadata of documents when scanning, leaving only what can be derived from the file names",
+			bool:  false,
+		},
+		Probe: &Option{
+			name:  "probe",
+			usage: "describe the streams of video files using ffprobe when scanning (length, resolution, container, codecs, and embedded audio and subtitle tracks), which is considerably slower",
+			bool:  false,
+		},
+		Sniff: &Option{
+			name:  "sniff",
+			usage: "identify the media files whose name extension is missing or unknown by the signature at the start of their content when scanning (e.g. MP3, FLAC, Matroska, MP4, JPEG, PDF), which reads every such file",
+			bool:  false,
+		},
+		Loaders: &Option{
+			name:  "loaders",
+			usage: "number of libraries whose databases are loaded at once, the others waiting their turn (0 = all)",
+			int:   0,
+		},
+		Scanners: &Option{
+			name:  "scanners",
+			usage: "number of libraries scanned at once, the others waiting their turn (0 = all)",
+			int:   0,
+		},
+		DiskScanners: &Option{
+			name:  "diskscanners",
+			usage: "number of libraries on the same device (disk, partition, or network share) scanned at once, e.g. 1 to spare a spinning disk from seeking between them (0 = all)",
+			int:   0,
+		},
+		Probers: &Option{
+			name:  "probers",
+			usage: "number of ffprobe processes run at once by the scans of all libraries, see -probe (0 = any)",
+			int:   0,
+		},
+		ScanRate: &Option{
+			name:   "scanrate",
+			usage:  "rate at which each library's scan reads files (to hash them) and examines files and directories, e.g. \"20MB/s\", \"500files/s\", or both comma-separated, so that a background rescan doesn't starve playback or other users of the disk (empty = unlimited)",
+			string: "",
+		},
+		NoMPRIS: &Option{
+			name:  "nompris",
+			usage: "don't expose the media playing on the D-Bus session bus by the MPRIS interface, through which desktop environments, media keys, and tools like playerctl control playback",
+			bool:  false,
+		},
+		HashSize: &Option{
+			name:  "hashsize",
+			usage: "MiB hashed at the start and at the end of each media file larger than twice that when scanning, identifying identical copies (see \"dupes\"); 0 hashes entire files, and a negative size none",
+			int:   contenthash.DefaultPartial >> 20,
+		},
+		TMDBKey: &Option{
+			name:   "tmdbkey",
+			usage:  "API key (v3) of The Movie Database, from which the \"fetch\" command reads the metadata of movies and episodes (best kept in the config file)",
+			string: "",
+		},
+		TVDBKey: &Option{
+			name:   "tvdbkey",
+			usage:  "API key (v4) of TheTVDB, from which the \"fetch\" command reads the metadata of movies and episodes (best kept in the config file)",
+			string: "",
+		},
+		AcoustIDKey: &Option{
+			name:   "acoustidkey",
+			usage:  "API key of AcoustID, with which the \"fetch\" command identifies untagged audio by its acoustic fingerprint (requires Chromaprint's fpcalc)",
+			string: "",
+		},
+		TraktID: &Option{
+			name:   "traktid",
+			usage:  "client ID of the Trakt API application (created at https://trakt.tv/oauth/applications) through which the \"trakt\" commands access your account (best kept in the config file)",
+			string: "",
+		},
+		TraktSecret: &Option{
+			name:   "traktsecret",
+			usage:  "client secret of the Trakt API application, see -traktid (best kept in the config file)",
+			string: "",
+		},
+		WebUsers: &Option{
+			name:   "webusers",
+			usage:  "comma-separated list of the users permitted to use the web interface (see \"serve\"), each \"name:password[:role]\", the role being \"read\" (browse and stream, the default) or \"admin\" (also play on the host and reload); the name can't contain \":\" but the password can, so \"me:admin\" is user me with password admin, e.g. \"kids:secret,me:hunter2:admin\" (best kept in the config file; empty = no authentication)",
+			string: "",
+		},
+		WebTokens: &Option{
+			name:   "webtokens",
+			usage:  "comma-separated list of the bearer tokens (sent as \"Authorization: Bearer token\") permitted to use the web interface, e.g. by scripts, each \"token[:role]\", the role being \"read\" (the default) or \"admin\", see -webusers",
+			string: "",
+		},
+		WebTLS: &Option{
+			name:  "webtls",
+			usage: "serve the web interface over HTTPS, with the certificate given by -webcert and -webkey, or else a self-signed certificate generated in the configuration directory (and reused until it nearly expires)",
+			bool:  false,
+		},
+		WebCert: &Option{
+			name:   "webcert",
+			usage:  "path of the PEM file of the TLS certificate of the web interface (implies -webtls)",
+			string: "",
+		},
+		WebKey: &Option{
+			name:   "webkey",
+			usage:  "path of the PEM file of the private key of the TLS certificate given by -webcert",
+			string: "",
+		},
+		TraktSync: &Option{
+			name:     "traktsync",
+			usage:    "how often the watch state and ratings of the videos are synced with the Trakt account in the background, once the libraries are scanned (0 = only by the \"trakt sync\" command)",
+			Duration: 0,
+		},
+		SubLang: &Option{
+			name:   "sublang",
+			usage:  "preferred languages of the subtitles played with videos, comma-separated, most preferred first (ISO 639 codes or names, e.g. \"en,es\")",
+			string: "",
+		},
+		SubThreshold: &Option{
+			name:    "subthreshold",
+			usage:   "lowest score (0 to 1) of a video whose name and directory match that of subtitles for them to be associated, e.g. 1 to require identical names in the same directory",
+			float64: library.DefaultSubThreshold,
+		},
+		SubDirWeight: &Option{
+			name:    "subdirweight",
+			usage:   "weight (0 to 1) of the proximity of a video's directory to that of subtitles in its score, the rest being the similarity of their names (0 = names only)",
+			float64: library.DefaultSubDirWeight,
+		},
+		LogPath: &Option{
+			name:   "log",
+			usage:  "file path to where all normal and verbose log messages will be redirected",
+			string: "",
+		},
+		Plugins: &Option{
+			name:   "plugins",
+			usage:  "comma-separated list of plugin executables to run (see package plugin for the protocol)",
+			string: "",
+		},
+		OnScanComplete: &Option{
+			name:   "onscancomplete",
+			usage:  "shell command to run each time a library scan finishes (with environment variables PIMMP_EVENT and PIMMP_RECORD_<FIELD> for each field of the record, e.g. PIMMP_RECORD_ABSPATH)",
+			string: "",
+		},
+		OnNewMedia: &Option{
+			name:   "onnewmedia",
+			usage:  "shell command to run each time new media is discovered (with environment variables PIMMP_EVENT and PIMMP_RECORD_<FIELD> for each field of the record, e.g. PIMMP_RECORD_ABSPATH)",
+			string: "",
+		},
+		OnMediaRemoved: &Option{
+			name:   "onmediaremoved",
+			usage:  "shell command to run each time the record of media is removed, e.g. its file deleted (with environment variables PIMMP_EVENT and PIMMP_RECORD_<FIELD> for each field of the record, e.g. PIMMP_RECORD_ABSPATH)",
+			string: "",
+		},
+		OnPlaybackStart: &Option{
+			name:   "onplaybackstarted",
+			usage:  "shell command to run each time playback of media starts (with environment variables PIMMP_EVENT and PIMMP_RECORD_<FIELD> for each field of the record, e.g. PIMMP_RECORD_ABSPATH)",
+			string: "",
+		},
+		OnPlaybackDone: &Option{
+			name:   "onplaybackfinished",
+			usage:  "shell command to run each time playback of media finishes (with environment variables PIMMP_EVENT and PIMMP_RECORD_<FIELD> for each field of the record, e.g. PIMMP_RECORD_ABSPATH)",
+			string: "",
+		},
+		Config: &Option{
+			name:   "config",
+			usage:  "path to the TOML config file supplying the options not given on the command line (created with every option's default on first run)",
+			string: configPath,
+		},
+		LibData: &Option{
+			name:   "libdata",
+			usage:  "path to library data directory (database storage location)",
+			string: libDataPath,
+		},
+		DBEngine: &Option{
+			name:   storage.EngineOption,
+			usage:  "database engine of each new library's database: \"tiedot\", or \"sqlite\" whose memory use doesn't grow with the library\n  (NOTE: an existing database keeps its engine; see \"db export\" and \"db import\" to convert it)",
+			string: engine.Default,
+		},
+		DiskBufferSize: &Option{
+			name:  storage.DiskBufferSizeOption,
+			usage: "size (in bytes) of each library's preallocated on-disk buffers (number of buffers = number of CPU cores),\n  and of the batches in which new records are inserted while scanning\n  (NOTE: this may not be changed after the corresponding library's database has been created)",
+			int:   storage.DefaultDiskBufferSize,
+		},
+		HashBufferSize: &Option{
+			name:  storage.HashBufferSizeOption,
+			usage: "size (in bytes) by which each hash table will grow to make room once it reaches capacity\n  (NOTE: this may not be changed after the corresponding library's database has been created)",
+			int:   storage.DefaultHashBufferSize,
+		},
+	}
+	knownOptions := NamedOption{
+		"cpuprofile":       options.CPUProfile,
+		"cpuprofilename":   options.CPUProfileName,
+		"memprofile":       options.MEMProfile,
+		"memprofilename":   options.MEMProfileName,
+		"traceprofile":     options.TraceProfile,
+		"traceprofilename": options.TraceName,
+		"blockprofile":     options.BlockProfile,
+		"blockprofilename": options.BlockName,
+		"mutexprofile":     options.MutexProfile,
+		"mutexprofilename": options.MutexName,
+		"pprofaddr":        options.PprofAddr,
+		"help":             options.UsageHelp,
+		"verbose":          options.Verbose,
+		"trace":            options.Trace,
+		"loglevel":         options.LogLevel,
+		"cli":              options.CLIMode,
+		"log":              options.LogPath,
+		"plugins":          options.Plugins,
+		"config":           options.Config,
+		"libdata":          options.LibData,
+		"dbengine":         options.DBEngine,
+		"diskbuffersize":   options.DiskBufferSize,
+		"hashbuffersize":   options.HashBufferSize,
+
+		"onscancomplete":     options.OnScanComplete,
+		"onnewmedia":         options.OnNewMedia,
+		"onmediaremoved":     options.OnMediaRemoved,
+		"onplaybackstarted":  options.OnPlaybackStart,
+		"onplaybackfinished": options.OnPlaybackDone,
+		"accessible":         options.Accessible,
+		"match":              options.Match,
+		"summary":            options.Summary,
+		"recent":             options.RecentPeriod,
+		"sessions":           options.RecentScans,
+		"cachesize":          options.CacheSize,
+		"template":           options.Template,
+		"dryrun":             options.DryRun,
+		"readonly":           options.ReadOnly,
+		"collection":         options.Collection,
+		"incoming":           options.Incoming,
+		"incomingpoll":       options.IncomingPoll,
+		"verify":             options.Verify,
+		"decode":             options.Decode,
+		"prune":              options.Prune,
+		"followsymlinks":     options.FollowLinks,
+		"exclude":            options.Exclude,
+		"ext":                options.Ext,
+		"minsize":            options.MinSize,
+		"sample":             options.Sample,
+		"samplesize":         options.SampleSize,
+		"watch":              options.Watch,
+		"daemon":             options.Daemon,
+		"progress":           options.Progress,
+		"playvideo":          options.PlayVideo,
+		"playaudio":          options.PlayAudio,
+		"reader":             options.Reader,
+		"graphics":           options.Graphics,
+		"nometadata":         options.NoMetadata,
+		"probe":              options.Probe,
+		"sniff":              options.Sniff,
+		"loaders":            options.Loaders,
+		"scanners":           options.Scanners,
+		"diskscanners":       options.DiskScanners,
+		"probers":            options.Probers,
+		"scanrate":           options.ScanRate,
+		"nompris":            options.NoMPRIS,
+		"hashsize":           options.HashSize,
+		"tmdbkey":            options.TMDBKey,
+		"tvdbkey":            options.TVDBKey,
+		"acoustidkey":        options.AcoustIDKey,
+		"traktid":            options.TraktID,
+		"traktsecret":        options.TraktSecret,
+		"traktsync":          options.TraktSync,
+		"webusers":           options.WebUsers,
+		"webtokens":          options.WebTokens,
+		"webtls":             options.WebTLS,
+		"webcert":            options.WebCert,
+		"webkey":             options.WebKey,
+		"sublang":            options.SubLang,
+		"subthreshold":       options.SubThreshold,
+		"subdirweight":       options.SubDirWeight,
+	}
+
+	// register the command line options we want to handle.
+	options.BoolVar(&options.CPUProfile.bool, options.CPUProfile.name, options.CPUProfile.bool, options.CPUProfile.usage)
+	options.StringVar(&options.CPUProfileName.string, options.CPUProfileName.name, options.CPUProfileName.string, options.CPUProfileName.usage)
+	options.BoolVar(&options.MEMProfile.bool, options.MEMProfile.name, options.MEMProfile.bool, options.MEMProfile.usage)
+	options.StringVar(&options.MEMProfileName.string, options.MEMProfileName.name, options.MEMProfileName.string, options.MEMProfileName.usage)
+	options.BoolVar(&options.TraceProfile.bool, options.TraceProfile.name, options.TraceProfile.bool, options.TraceProfile.usage)
+	options.StringVar(&options.TraceName.string, options.TraceName.name, options.TraceName.string, options.TraceName.usage)
+	options.BoolVar(&options.BlockProfile.bool, options.BlockProfile.name, options.BlockProfile.bool, options.BlockProfile.usage)
+	options.StringVar(&options.BlockName.string, options.BlockName.name, options.BlockName.string, options.BlockName.usage)
+	options.BoolVar(&options.MutexProfile.bool, options.MutexProfile.name, options.MutexProfile.bool, options.MutexProfile.usage)
+	options.StringVar(&options.MutexName.string, options.MutexName.name, options.MutexName.string, options.MutexName.usage)
+	options.StringVar(&options.PprofAddr.string, options.PprofAddr.name, options.PprofAddr.string, options.PprofAddr.usage)
+	options.BoolVar(&options.UsageHelp.bool, options.UsageHelp.name, options.UsageHelp.bool, options.UsageHelp.usage)
+	options.BoolVar(&options.Verbose.bool, options.Verbose.name, options.Verbose.bool, options.Verbose.usage)
+	options.BoolVar(&options.Trace.bool, options.Trace.name, options.Trace.bool, options.Trace.usage)
+	options.StringVar(&options.LogLevel.string, options.LogLevel.name, options.LogLevel.string, options.LogLevel.usage)
+	options.BoolVar(&options.CLIMode.bool, options.CLIMode.name, options.CLIMode.bool, options.CLIMode.usage)
+	options.BoolVar(&options.Accessible.bool, options.Accessible.name, options.Accessible.bool, options.Accessible.usage)
+	options.StringVar(&options.Match.string, options.Match.name, options.Match.string, options.Match.usage)
+	options.StringVar(&options.Summary.string, options.Summary.name, options.Summary.string, options.Summary.usage)
+	options.DurationVar(&options.RecentPeriod.Duration, options.RecentPeriod.name, options.RecentPeriod.Duration, options.RecentPeriod.usage)
+	options.IntVar(&options.RecentScans.int, options.RecentScans.name, options.RecentScans.int, options.RecentScans.usage)
+	options.StringVar(&options.CacheSize.string, options.CacheSize.name, options.CacheSize.string, options.CacheSize.usage)
+	options.StringVar(&options.Template.string, options.Template.name, options.Template.string, options.Template.usage)
+	options.BoolVar(&options.DryRun.bool, options.DryRun.name, options.DryRun.bool, options.DryRun.usage)
+	options.BoolVar(&options.ReadOnly.bool, options.ReadOnly.name, options.ReadOnly.bool, options.ReadOnly.usage)
+	options.StringVar(&options.Collection.string, options.Collection.name, options.Collection.string, options.Collection.usage)
+	options.StringVar(&options.Incoming.string, options.Incoming.name, options.Incoming.string, options.Incoming.usage)
+	options.DurationVar(&options.IncomingPoll.Duration, options.IncomingPoll.name, options.IncomingPoll.Duration, options.IncomingPoll.usage)
+	options.Float64Var(&options.Verify.float64, options.Verify.name, options.Verify.float64, options.Verify.usage)
+	options.BoolVar(&options.Decode.bool, options.Decode.name, options.Decode.bool, options.Decode.usage)
+	options.BoolVar(&options.Prune.bool, options.Prune.name, options.Prune.bool, options.Prune.usage)
+	options.BoolVar(&options.FollowLinks.bool, options.FollowLinks.name, options.FollowLinks.bool, options.FollowLinks.usage)
+	options.Var(listValue{options.Exclude}, options.Exclude.name, options.Exclude.usage)
+	options.Var(listValue{options.Ext}, options.Ext.name, options.Ext.usage)
+	options.StringVar(&options.MinSize.string, options.MinSize.name, options.MinSize.string, options.MinSize.usage)
+	options.Var(listValue{options.Sample}, options.Sample.name, options.Sample.usage)
+	options.StringVar(&options.SampleSize.string, options.SampleSize.name, options.SampleSize.string, options.SampleSize.usage)
+	options.BoolVar(&options.Watch.bool, options.Watch.name, options.Watch.bool, options.Watch.usage)
+	options.BoolVar(&options.Daemon.bool, options.Daemon.name, options.Daemon.bool, options.Daemon.usage)
+	options.DurationVar(&options.Progress.Duration, options.Progress.name, options.Progress.Duration, options.Progress.usage)
+	options.StringVar(&options.PlayVideo.string, options.PlayVideo.name, options.PlayVideo.string, options.PlayVideo.usage)
+	options.StringVar(&options.PlayAudio.string, options.PlayAudio.name, options.PlayAudio.string, options.PlayAudio.usage)
+	options.StringVar(&options.Reader.string, options.Reader.name, options.Reader.string, options.Reader.usage)
+	options.StringVar(&options.Graphics.string, options.Graphics.name, options.Graphics.string, options.Graphics.usage)
+	options.BoolVar(&options.NoMetadata.bool, options.NoMetadata.name, options.NoMetadata.bool, options.NoMetadata.usage)
+	options.BoolVar(&options.Probe.bool, options.Probe.name, options.Probe.bool, options.Probe.usage)
+	options.BoolVar(&options.Sniff.bool, options.Sniff.name, options.Sniff.bool, options.Sniff.usage)
+	options.IntVar(&options.Loaders.int, options.Loaders.name, options.Loaders.int, options.Loaders.usage)
+	options.IntVar(&options.Scanners.int, options.Scanners.name, options.Scanners.int, options.Scanners.usage)
+	options.IntVar(&options.DiskScanners.int, options.DiskScanners.name, options.DiskScanners.int, options.DiskScanners.usage)
+	options.IntVar(&options.Probers.int, options.Probers.name, options.Probers.int, options.Probers.usage)
+	options.StringVar(&options.ScanRate.string, options.ScanRate.name, options.ScanRate.string, options.ScanRate.usage)
+	options.BoolVar(&options.NoMPRIS.bool, options.NoMPRIS.name, options.NoMPRIS.bool, options.NoMPRIS.usage)
+	options.IntVar(&options.HashSize.int, options.HashSize.name, options.HashSize.int, options.HashSize.usage)
+	options.StringVar(&options.TMDBKey.string, options.TMDBKey.name, options.TMDBKey.string, options.TMDBKey.usage)
+	options.StringVar(&options.TVDBKey.string, options.TVDBKey.name, options.TVDBKey.string, options.TVDBKey.usage)
+	options.StringVar(&options.AcoustIDKey.string, options.AcoustIDKey.name, options.AcoustIDKey.string, options.AcoustIDKey.usage)
+	options.StringVar(&options.TraktID.string, options.TraktID.name, options.TraktID.string, options.TraktID.usage)
+	options.StringVar(&options.TraktSecret.string, options.TraktSecret.name, options.TraktSecret.string, options.TraktSecret.usage)
+	options.DurationVar(&options.TraktSync.Duration, options.TraktSync.name, options.TraktSync.Duration, options.TraktSync.usage)
+	options.Var(listValue{options.WebUsers}, options.WebUsers.name, options.WebUsers.usage)
+	options.Var(listValue{options.WebTokens}, options.WebTokens.name, options.WebTokens.usage)
+	options.BoolVar(&options.WebTLS.bool, options.WebTLS.name, options.WebTLS.bool, options.WebTLS.usage)
+	options.StringVar(&options.WebCert.string, options.WebCert.name, options.WebCert.string, options.WebCert.usage)
+	options.StringVar(&options.WebKey.string, options.WebKey.name, options.WebKey.string, options.WebKey.usage)
+	options.StringVar(&options.SubLang.string, options.SubLang.name, options.SubLang.string, options.SubLang.usage)
+	options.Float64Var(&options.SubThreshold.float64, options.SubThreshold.name, options.SubThreshold.float64, options.SubThreshold.usage)
+	options.Float64Var(&options.SubDirWeight.float64, options.SubDirWeight.name, options.SubDirWeight.float64, options.SubDirWeight.usage)
+	options.StringVar(&options.LogPath.string, options.LogPath.name, options.LogPath.string, options.LogPath.usage)
+	options.StringVar(&options.Plugins.string, options.Plugins.name, options.Plugins.string, options.Plugins.usage)
+	options.StringVar(&options.OnScanComplete.string, options.OnScanComplete.name, options.OnScanComplete.string, options.OnScanComplete.usage)
+	options.StringVar(&options.OnNewMedia.string, options.OnNewMedia.name, options.OnNewMedia.string, options.OnNewMedia.usage)
+	options.StringVar(&options.OnMediaRemoved.string, options.OnMediaRemoved.name, options.OnMediaRemoved.string, options.OnMediaRemoved.usage)
+	options.StringVar(&options.OnPlaybackStart.string, options.OnPlaybackStart.name, options.OnPlaybackStart.string, options.OnPlaybackStart.usage)
+	options.StringVar(&options.OnPlaybackDone.string, options.OnPlaybackDone.name, options.OnPlaybackDone.string, options.OnPlaybackDone.usage)
+	options.StringVar(&options.Config.string, options.Config.name, options.Config.string, options.Config.usage)
+	options.StringVar(&options.LibData.string, options.LibData.name, options.LibData.string, options.LibData.usage)
+	options.StringVar(&options.DBEngine.string, options.DBEngine.name, options.DBEngine.string, options.DBEngine.usage)
+	options.IntVar(&options.DiskBufferSize.int, options.DiskBufferSize.name, options.DiskBufferSize.int, options.DiskBufferSize.usage)
+	options.IntVar(&options.HashBufferSize.int, options.HashBufferSize.name, options.HashBufferSize.int, options.HashBufferSize.usage)
+
+	// hide the flag.flagSet's default output error message, because we will
+	// display our own.
+	options.SetOutput(ioutil.Discard)
+
+	// the output provided with -help or when a option parse error occurred.
+	options.Usage = func() {
+		console.Raw.Logf("%s v%s (%s@%s) [%s]", identity, version, branch, revision, buildtime)
+		console.Raw.Log()
+		console.Raw.Logf("usage: %s [options] path [path ...]", identity)
+		console.Raw.Logf("       %s [options] subcommand [subcommand options] [args ...]", identity)
+		console.Raw.Log()
+		sub := make([]string, len(options.subcommands))
+		for i, s := range options.subcommands {
+			sub[i] = s.name
+		}
+		console.Raw.Logf("subcommands: %s (see \"%s %s subcommand\")", strings.Join(sub, ", "), identity, cmdHelp)
+		console.Raw.Log()
+		options.SetOutput(os.Stdout)
+		options.PrintDefaults()
+		console.Raw.Log()
+	}
+
+	// each subcommand has its own options, parsed after the global options.
+	options.subcommands = newSubcommands(options)
+
+	// the scanners and loaders of every library are interrupted together.
+	options.ctx, options.cancel = context.WithCancel(context.Background())
+
+	// the interfaces subscribe to what the libraries find independently.
+	options.bus = library.NewBus()
+
+	// yeaaaaaaah, now we do it!
+	if err := options.Parse(os.Args[1:]); nil != err {
+		if flag.ErrHelp == err {
+			// hide the flag.flagSet's default output status message,
+			// because we will print our own.
+			return nil, rc.Usage
+		}
+		return nil, rc.InvalidArgs.Wrapf(err, "%s", err)
+	}
+	options.Visit(
+		func(f *flag.Flag) {
+			options.Provided[f.Name] = knownOptions[f.Name]
+			options.Provided[f.Name].source = SourceFlag
+		})
+
+	// the environment supplies the options not given on the command line, and
+	// the configuration file those given in neither; i.e. the command line
+	// always takes precedence.
+	set, ret := loadEnv(options, knownOptions)
+	if nil != ret {
+		return nil, ret
+	}
+	if len(set) > 0 {
+		console.Info.Tracef("loaded environment: %s", strings.Join(set, ", "))
+	}
+	set, ret = loadConfig(options, knownOptions, options.Config.string)
+	if nil != ret {
+		return nil, ret
+	}
+	if len(set) > 0 {
+		console.Info.Tracef("loaded configuration: %q (%s)", options.Config.string, strings.Join(set, ", "))
+	}
+
+	// the leading positional args may select a subcommand rather than a library
+	// path, which parses its own options from the args following it.
+	options.libArgs = options.Args()
+	var parseError *rc.ReturnCode = nil
+	if len(options.libArgs) > 0 {
+		if cmdHelp == options.libArgs[0] {
+			showHelp(options, options.libArgs[1:])
+			return options, rc.Usage
+		}
+		var rest []string
+		if options.subcommand, rest = findSubcommand(options.subcommands, options.libArgs); nil != options.subcommand {
+			options.subArgs, options.libArgs, parseError = options.subcommand.parse(rest)
+			if nil != parseError {
+				return options, parseError
+			}
+		}
+	}
+
+	// subcommands writing their output to standard output need it kept free
+	// of the usual status messages.
+	if nil != options.subcommand && options.subcommand.stdout && "" == options.subcommand.output {
+		console.Raw.SetWriter(os.Stderr)
+		console.Info.SetWriter(os.Stderr)
+	}
+	// as does the summary, if written as JSON.
+	switch strings.ToLower(options.Summary.string) {
+	case summaryText:
+	case summaryJSON:
+		console.Raw.SetWriter(os.Stderr)
+		console.Info.SetWriter(os.Stderr)
+	default:
+		return options, rc.InvalidArgs.Specf("invalid -%s: %q (expected %s or %s)",
+			options.Summary.name, options.Summary.string, summaryText, summaryJSON)
+	}
+	if options.cacheSize, ret = library.ParseSize(options.CacheSize.string); nil != ret {
+		return options, rc.InvalidArgs.Specf("invalid -%s: %q", options.CacheSize.name, options.CacheSize.string)
+	}
+	if !thumbnail.ValidProtocol(options.Graphics.string) {
+		return options, rc.InvalidArgs.Specf("invalid -%s: %q (expected one of: %s)",
+			options.Graphics.name, options.Graphics.string, strings.Join(thumbnail.Protocols, ", "))
+	}
+
+	// update the loggers' verbosity settings.
+	level, filter, ret := options.logLevels()
+	if nil != ret {
+		return nil, ret
+	}
+	console.SetLevel(level, filter)
+	isCLIMode = options.CLIMode.bool
+
+	// accessible mode never uses the curses-style layout, which is meaningless
+	// to a screen reader. the loggers drop their timestamps and label each
+	// message with its severity in words instead.
+	isAccessible = options.Accessible.bool
+	if isAccessible {
+		isCLIMode = true
+		console.SetAccessible(true)
+	}
+
+	// the file name extensions identifying each kind of file are customized
+	// before any file is identified.
+	if ret := media.CustomizeExt(splitList(options.Ext.string)); nil != ret {
+		return nil, ret
+	}
+
+	// update program state for global optons.
+	if options.UsageHelp.bool {
+		options.Usage()
+		parseError = rc.Usage
+	}
+
+	return options, parseError
+}
+
+// function initIncoming() verifies the folder given with the -incoming option
+// and the -template (if any) by which the new files found there are renamed.
+// returns a nil Watcher if no folder was given.
+func initIncoming(options *Options) (*incoming.Watcher, *organize.Template, *rc.ReturnCode) {
+
+	if "" == options.Incoming.string {
+		return nil, nil, nil
+	}
+	if options.IncomingPoll.Duration <= 0 {
+		return nil, nil, rc.InvalidArgs.Specf("invalid poll interval (see option -%s): %s",
+			options.IncomingPoll.name, options.IncomingPoll.Duration)
+	}
+	w, ret := incoming.NewWatcher(options.Incoming.string)
+	if nil != ret {
+		return nil, nil, ret
+	}
+	var t *organize.Template
+	if "" != options.Template.string {
+		if t, ret = organize.ParseTemplate(options.Template.string); nil != ret {
+			return nil, nil, ret
+		}
+	}
+	return w, t, nil
+}
+
+// function watchIncoming() polls the given Watcher's folder for new files until
+// the program exits, importing each as it is found.
+func watchIncoming(options *Options, w *incoming.Watcher, t *organize.Template, libs []*library.Library) {
+
+	console.Info.Logf("watching for new files: %q (every %s)",
+		w.Dir(), options.IncomingPoll.Duration)
+	for {
+		time.Sleep(options.IncomingPoll.Duration)
+		found, problems := w.Poll()
+		for _, p := range problems {
+			console.Warn.Verbose(p)
+		}
+		for _, absPath := range found {
+			if ret := importIncoming(w, t, libs, absPath); nil != ret {
+				// leave the file where it is until it changes, e.g. until the
+				// user renames it, rather than retrying it every poll.
+				console.Warn.Log(ret)
+				w.Ignore(absPath)
+			}
+		}
+	}
+}
+
+// function watchLibrary() watches the given library for changes to its files,
+// updating its database and publishing them on the event bus, until the
+// program is interrupted.
+func watchLibrary(options *Options, l *library.Library) {
+
+	ret := l.Watch(options.ctx, options.bus)
+	if nil != ret && !errors.Is(ret, rc.Canceled) {
+		console.Warn.Log(ret)
+	}
+}
+
+// function importIncoming() moves the given file from the incoming folder into
+// the library holding the most media of its kind (supporting files, such as
+// subtitles, go with the video), and then indexes it there. media files are
+// renamed by the given Template, if not nil; all others keep their path
+// relative to the incoming folder.
+func importIncoming(w *incoming.Watcher, t *organize.Template, libs []*library.Library, absPath string) *rc.ReturnCode {
+
+	relPath, err := filepath.Rel(w.Dir(), absPath)
+	if nil != err {
+		return rc.InvalidPath.Specf("importIncoming(%q): filepath.Rel(): %s", absPath, err)
+	}
+	ext := filepath.Ext(absPath)
+	kind, extName := media.MediaKindOfFile(absPath)
+	libKind := kind
+	if media.KindUnknown == kind {
+		if sk, _ := media.SupportKindOfFile(absPath); media.SupportUnknown == sk {
+			return rc.InvalidFile.Specf("not a media file, ignoring: %q", absPath)
+		}
+		libKind = media.KindVideo
+	}
+
+	var lib *library.Library
+	for _, l := range libs {
+		if nil == lib || l.NumMedia(libKind) > lib.NumMedia(libKind) {
+			lib = l
+		}
+	}
+
+	newPath := relPath
+	if nil != t && media.KindUnknown != kind {
+		info, err := os.Stat(absPath)
+		if nil != err {
+			return rc.InvalidStat.Specf("importIncoming(%q): os.Stat(): %s", absPath, err)
+		}
+		ent := media.NewStorableEntity(media.ClassMedia, int(kind), absPath, relPath, ext, extName, info)
+		rendered, ret := t.Render(organize.FieldsOf(ent))
+		if nil != ret {
+			console.Warn.Logf("keeping name of new file: %q: %s", relPath, ret)
+		} else {
+			newPath = rendered
+		}
+	}
+
+	to := filepath.Join(lib.AbsPath(), newPath)
+	if ret := incoming.Move(absPath, to); nil != ret {
+		return ret
+	}
+	organize.PruneDirs(filepath.Dir(absPath), w.Dir())
+	console.Info.Logf("imported: %q -> %q", relPath, to)
+
+	if ret := lib.ScanFile(nil, to); nil != ret {
+		// the file will be indexed by the next scan anyway.
+		console.Warn.Log(ret)
+	}
+	return nil
+}
+
+// function repairLibrary() quarantines every corrupt record found in each of
+// the given libraries' databases and then rebuilds what it can of them from
+// the files on disk.
+func repairLibrary(libs []*library.Library) {
+
+	for _, l := range libs {
+		console.Info.Logf("repairing library database: %q", l.Name())
+		numFixed, numFailed, err := l.Repair()
+		if nil != err {
+			console.Error.Log(err)
+			continue
+		}
+		if numFailed > 0 {
+			console.Warn.Logf("finished repairing: %q (%d repaired, %d left in quarantine)",
+				l.Name(), numFixed, numFailed)
+		} else {
+			console.Info.Logf("finished repairing: %q (%d repaired)", l.Name(), numFixed)
+		}
+	}
+}
+
+// function exportKodi() writes a Kodi-compatible .nfo file alongside each of
+// the videos in the given libraries' databases, replacing the existing ones
+// only if force is true.
+func exportKodi(options *Options, libs []*library.Library, force bool) {
+
+	kodi := export.NewKodi(force)
+	for _, l := range libs {
+		console.Info.Logf("exporting Kodi metadata: %q", l.Name())
+		var numWritten, numSkipped, numFailed uint
+		bus := library.NewBus()
+		bus.Subscribe(func(e library.Event) {
+			video, ok := e.Discovery.Entity.(*media.VideoMedia)
+			if !ok {
+				return // Kodi's .nfo files are only written for videos
+			}
+			wrote, err := kodi.ExportVideo(video)
+			switch {
+			case nil != err:
+				console.Warn.Log(err)
+				numFailed++
+			case wrote:
+				numWritten++
+			default:
+				numSkipped++
+			}
+		}, library.MediaDiscovered)
+		_, err := l.Load(options.ctx, bus)
+		if nil != err {
+			console.Error.Log(err)
+			continue
+		}
+		console.Info.Logf("finished exporting: %q (%d written, %d skipped, %d failed)",
+			l.Name(), numWritten, numSkipped, numFailed)
+		if numSkipped > 0 {
+			console.Info.Log("existing .nfo files were skipped, use \"export kodi -force\" to overwrite them")
+		}
+	}
+}
+
+// function exportM3U8() writes a playlist of all media in the given libraries'
+// databases matching the -match option, sorted by path, with paths relative to
+// the -o file if relative is true.
+func exportM3U8(options *Options, libs []*library.Library, relative bool) *rc.ReturnCode {
+
+	selected, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+	list := loadMedia(libs, selected)
+
+	w, base, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+	if !relative {
+		base = ""
+	}
+
+	if ret := export.NewM3U(base).Write(w, list); nil != ret {
+		return ret
+	}
+	console.Info.Verbosef("exported %d media to playlist", len(list))
+	return nil
+}
+
+// function writeReport() writes a report of the given kind (reportContents,
+// etc.) in the named format, composed from all media in the given libraries'
+// databases matching the -match option.
+func writeReport(options *Options, libs []*library.Library, kind, formatName string) *rc.ReturnCode {
+
+	format, ret := report.ParseFormat(formatName)
+	if nil != ret {
+		return ret
+	}
+
+	selected, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+	list := loadMedia(libs, selected)
+
+	var rep *report.Report
+	switch kind {
+	case reportContents:
+		rep = report.Contents(list)
+	case reportRecent:
+		if options.RecentScans.int > 0 {
+			// each library has its own scan sessions, so the media are
+			// selected from each separately.
+			recent := []*media.Media{}
+			for _, l := range libs {
+				since := addedSince(options, l)
+				recent = append(recent, loadMedia([]*library.Library{l},
+					func(m *media.Media) bool {
+						return !m.TimeAdded.Before(since) && selected(m)
+					})...)
+			}
+			rep = report.Recent(recent, time.Time{})
+			rep.Title = fmt.Sprintf("Recently added by the last %d scan(s)", options.RecentScans.int)
+		} else {
+			rep = report.Recent(list, addedSince(options, nil))
+		}
+	case reportDupes:
+		rep = report.Duplicates(list)
+	case reportFailed:
+		rep = report.Failed(list)
+	}
+
+	w, _, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+
+	if ret := rep.Write(w, format); nil != ret {
+		return ret
+	}
+	console.Info.Verbosef("wrote report: %s (%d rows)", rep.Title, len(rep.Rows))
+	return nil
+}
+
+// function verifyLibrary() verifies the integrity of the media in the given
+// libraries matching the -match option: the share of them due each day per the
+// -verify option, or all of them if not given. the failures are listed.
+func verifyLibrary(options *Options, libs []*library.Library) *rc.ReturnCode {
+
+	selected, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+	decode := verifyDecode(options)
+	numVerified, numFailed := 0, 0
+	for _, l := range libs {
+		list := loadMedia([]*library.Library{l}, selected)
+		n := len(list)
+		if options.Verify.float64 > 0 {
+			n = verify.BatchSize(len(list), options.Verify.float64, 24*time.Hour)
+		}
+		due := verify.Due(list, n)
+		console.Info.Logf("verifying %d of %d media in library %q ...", len(due), len(list), l.Name())
+		for _, m := range due {
+			reason, ret := l.VerifyMedia(m.AbsPath, decode)
+			if nil != ret {
+				console.Warn.Log(ret)
+				continue
+			}
+			numVerified++
+			if "" != reason {
+				numFailed++
+				console.Raw.Logf("failed: %q: %s", m.AbsPath, reason)
+			}
+		}
+	}
+	console.Info.Logf("finished verifying (%d media verified, %d failed)", numVerified, numFailed)
+	return nil
+}
+
+// function scheduleVerify() verifies the integrity of the media in the given
+// libraries in the background until the program exits, a small batch every
+// hour such that the -verify percentage of them is verified each day. the
+// number of failures is kept in numFailedVerify for the TUI.
+func scheduleVerify(options *Options, libs []*library.Library) {
+
+	const interval = time.Hour
+
+	decode := verifyDecode(options)
+	console.Info.Logf("verifying %g%% of media per day in the background", options.Verify.float64)
+	for {
+		var numFailed int64
+		for _, l := range libs {
+			list := loadMedia([]*library.Library{l}, visibleMedia(options))
+			for _, m := range verify.Due(list, verify.BatchSize(len(list), options.Verify.float64, interval)) {
+				reason, ret := l.VerifyMedia(m.AbsPath, decode)
+				if nil != ret {
+					console.Warn.Verbose(ret)
+				} else if "" != reason {
+					console.Warn.Logf("verification failed: %q: %s", m.AbsPath, reason)
+				}
+			}
+			numFailed += int64(len(verify.Failed(loadMedia([]*library.Library{l}, visibleMedia(options)))))
+		}
+		atomic.StoreInt64(&numFailedVerify, numFailed)
+		time.Sleep(interval)
+	}
+}
+
+// function scheduleTrakt() syncs the videos in the given libraries with the
+// Trakt account in the background until the program exits, once per the
+// -traktsync interval. failures are only logged, the next sync may succeed.
+func scheduleTrakt(options *Options, libs []*library.Library) {
+
+	client, ret := newTraktClient(options)
+	if nil == ret && !client.LoggedIn() {
+		ret = rc.SyncError.Spec("no Trakt account authorized (see \"trakt login\")")
+	}
+	if nil != ret {
+		console.Warn.Log(ret)
+		return
+	}
+	console.Info.Logf("syncing with Trakt every %s in the background", options.TraktSync.Duration)
+	for {
+		if ret := syncTrakt(options, client, libs, visibleMedia(options), false); nil != ret {
+			console.Warn.Log(ret)
+		}
+		select {
+		case <-options.ctx.Done():
+			return
+		case <-time.After(options.TraktSync.Duration):
+		}
+	}
+}
+
+// function verifyDecode() returns true if media files should be decoded when
+// verified, i.e. if requested by the -decode option and the decoder is
+// installed.
+func verifyDecode(options *Options) bool {
+	if !options.Decode.bool {
+		return false
+	}
+	if !verify.CanDecode() {
+		console.Warn.Verbosef("ffmpeg not found, verifying checksums only (see option -%s)", options.Decode.name)
+		return false
+	}
+	return true
+}
+
+// function fileCache() returns the cache of the artwork downloaded and the
+// thumbnails generated, in the library data directory, limited by -cachesize.
+func fileCache(options *Options) *filecache.Cache {
+	return filecache.New(filepath.Join(options.LibData.string, filecache.DirName), options.cacheSize)
+}
+
+// function thumbnailCache() returns the Cache of the thumbnails of the media,
+// kept in the file cache, or nil if ffmpeg isn't installed to generate them.
+func thumbnailCache(options *Options) *thumbnail.Cache {
+	if !thumbnail.Available() {
+		console.Info.Verbosef("ffmpeg not found, thumbnails are unavailable")
+		return nil
+	}
+	return thumbnail.NewCache(fileCache(options))
+}
+
+// function scanProbe() returns true if video files should be probed when
+// scanned, i.e. if requested by the -probe option and ffprobe is installed.
+func scanProbe(options *Options) bool {
+	if !options.Probe.bool {
+		return false
+	}
+	if !probe.Available() {
+		console.Warn.Logf("ffprobe not found, scanning without it (see option -%s)", options.Probe.name)
+		return false
+	}
+	return true
+}
+
+// function takeSnapshots() takes a snapshot of each of the given libraries with
+// the given name, or named after the current time if empty.
+func takeSnapshots(libs []*library.Library, name string) *rc.ReturnCode {
+
+	if "" == name {
+		name = time.Now().Format("2006-01-02_150405")
+	}
+	for _, l := range libs {
+		snap, ret := l.Snapshot(name)
+		if nil == ret {
+			ret = l.DB().SaveSnapshot(snap)
+		}
+		if nil != ret {
+			return ret
+		}
+		console.Info.Logf("took snapshot %q of library %q (%d records)", name, l.Name(), len(snap.Records))
+	}
+	return nil
+}
+
+// function listSnapshots() lists the snapshots of each of the given libraries.
+func listSnapshots(libs []*library.Library) *rc.ReturnCode {
+
+	for _, l := range libs {
+		list, ret := l.DB().Snapshots()
+		if nil != ret {
+			return ret
+		}
+		for _, s := range list {
+			console.Raw.Logf("%s\t%s\t%s\t%d records", l.Name(), s.Name,
+				s.Taken.Local().Format("2006-01-02 15:04"), len(s.Records))
+		}
+		console.Info.Logf("%d snapshots of library %q", len(list), l.Name())
+	}
+	return nil
+}
+
+// function diffLibrarySnapshots() writes to the -o file (or standard output)
+// the differences between the given pair of snapshots of each of the given
+// libraries (see diffSnapshots()).
+func diffLibrarySnapshots(options *Options, libs []*library.Library, older, newer string) *rc.ReturnCode {
+
+	w, _, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+
+	for _, l := range libs {
+		if ret := diffSnapshots(w, l, older, newer); nil != ret {
+			return ret
+		}
+	}
+	return nil
+}
+
+// function removeSnapshots() removes the snapshot with the given name of each
+// of the given libraries.
+func removeSnapshots(libs []*library.Library, name string) *rc.ReturnCode {
+
+	for _, l := range libs {
+		if ret := l.DB().RemoveSnapshot(name); nil != ret {
+			return rc.InvalidArgs.Specf("%s (see \"snapshot list\")", ret)
+		}
+		console.Info.Logf("removed snapshot %q of library %q", name, l.Name())
+	}
+	return nil
+}
+
+// function diffSnapshots() writes to w the records added, removed, and changed
+// between the snapshots of the given library with the given names. if newer
+// is empty, older is compared to the current records, and if older is empty,
+// the latest snapshot is.
+func diffSnapshots(w io.Writer, l *library.Library, older, newer string) *rc.ReturnCode {
+
+	if "" == older {
+		list, ret := l.DB().Snapshots()
+		if nil != ret {
+			return ret
+		}
+		if 0 == len(list) {
+			return rc.InvalidArgs.Specf("no snapshots of library %q (see \"snapshot take\")",
+				l.Name())
+		}
+		older = list[len(list)-1].Name
+	}
+	oldSnap, ret := l.DB().Snapshot(older)
+	if nil != ret {
+		return ret
+	}
+	var newSnap *storage.Snapshot
+	if "" == newer {
+		newSnap, ret = l.Snapshot("(current)")
+	} else {
+		newSnap, ret = l.DB().Snapshot(newer)
+	}
+	if nil != ret {
+		return ret
+	}
+
+	diff := oldSnap.Diff(newSnap)
+	fmt.Fprintf(w, "# %s: %q (%s) -> %q (%s)\n", l.Name(),
+		oldSnap.Name, oldSnap.Taken.Local().Format("2006-01-02 15:04"),
+		newSnap.Name, newSnap.Taken.Local().Format("2006-01-02 15:04"))
+	for _, p := range diff.Added {
+		fmt.Fprintf(w, "+ %s\n", p)
+	}
+	for _, p := range diff.Removed {
+		fmt.Fprintf(w, "- %s\n", p)
+	}
+	for _, p := range diff.ChangedPaths() {
+		fmt.Fprintf(w, "~ %s\n", p)
+		for _, e := range diff.Changed[p] {
+			fmt.Fprintf(w, "    %s: %s -> %s\n", e.Field, snapshotValue(e.Old), snapshotValue(e.New))
+		}
+	}
+	console.Info.Logf("library %q: %d added, %d removed, %d changed",
+		l.Name(), len(diff.Added), len(diff.Removed), len(diff.Changed))
+	return nil
+}
+
+// function snapshotValue() returns the given record field value as it appears
+// in the record's JSON encoding.
+func snapshotValue(v interface{}) string {
+	if nil == v {
+		return "(none)"
+	}
+	data, err := json.Marshal(v)
+	if nil != err {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// function addedSince() returns the time since which the media of the given
+// library are considered recently added: the start of the -sessions latest
+// scans of the library, or else the -recent period ago (for any library).
+func addedSince(options *Options, l *library.Library) time.Time {
+	if options.RecentScans.int > 0 && nil != l {
+		return l.SessionStart(options.RecentScans.int)
+	}
+	return time.Now().Add(-options.RecentPeriod.Duration)
+}
+
+// function diskUsage() writes the disk usage reports of each of the given
+// libraries, showing the space consumed by kind, extension, directory, and
+// quality tier, in the named format. the report by directory lists only the
+// given number of the largest directories (0 = all).
+func diskUsage(options *Options, libs []*library.Library, formatName string, limit int) *rc.ReturnCode {
+
+	format, ret := report.ParseFormat(formatName)
+	if nil != ret {
+		return ret
+	}
+
+	w, _, ret := createExportFile(options)
+	if nil != ret {
+		return ret
+	}
+	defer closeExportFile(w)
+
+	selected, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+	for _, l := range libs {
+		list := loadMedia([]*library.Library{l}, selected)
+		for by := report.UsageBy(0); by < report.UsageByCOUNT; by++ {
+			n := 0
+			if report.UsageByDir == by {
+				n = limit
+			}
+			rep := report.Usage(list, by, n)
+			rep.Title = fmt.Sprintf("%s: %s", l.Name(), rep.Title)
+			if ret := rep.Write(w, format); nil != ret {
+				return ret
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+// function undoEdits() reverts the most recent change made to each media in
+// the given libraries matching the -match option. since this could revert a
+// great deal of work, force is required to undo the changes of every media.
+// the changes made to the files of the libraries are reverted by undoJournal().
+func undoEdits(options *Options, libs []*library.Library, force bool) *rc.ReturnCode {
+
+	if "" == options.Match.string && "" == options.Collection.string && !force {
+		return rc.InvalidArgs.Specf(
+			"refusing to undo the most recent change of every media: select media with -%s or -%s, or use \"undo -force\" (see \"undo batch\" to revert changes to files)",
+			options.Match.name, options.Collection.name)
+	}
+
+	selected, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+	var numUndone uint
+	for _, l := range libs {
+		for _, m := range loadMedia([]*library.Library{l}, selected) {
+			if 0 == len(m.History) {
+				continue
+			}
+			edit, ret := l.UndoMedia(m.AbsPath)
+			if nil != ret {
+				console.Warn.Log(ret)
+				continue
+			}
+			for _, e := range edit {
+				console.Info.Logf("undo: %q: %s: %v -> %v", m.AbsPath, e.Field, e.New, e.Old)
+			}
+			if len(edit) > 0 {
+				numUndone++
+			}
+		}
+	}
+	console.Info.Logf("finished undoing (%d media reverted)", numUndone)
+	return nil
+}
+
+// function undoJournal() reverts the most recent batch of changes made to the
+// files of the given libraries by the "organize", "delete", "junk clean", or
+// "dupes resolve" subcommands, as recorded in their journals, restoring both
+// the files and their records. the changes that couldn't be reverted, and
+// were dropped from the journals, are listed as well.
+func undoJournal(libs []*library.Library) *rc.ReturnCode {
+
+	var batch time.Time
+	for _, l := range libs {
+		if last := l.LastJournal(); last.After(batch) {
+			batch = last
+		}
+	}
+	if batch.IsZero() {
+		console.Info.Log("nothing to undo (see \"undo\" to revert the most recent edit of media)")
+		return nil
+	}
+
+	var numUndone, numFailed uint
+	for _, l := range libs {
+		undone, failed, ret := l.UndoJournal(batch)
+		for _, jr := range undone {
+			switch jr.Op {
+			case storage.JournalMove:
+				console.Info.Logf("undo: moved back: %q -> %q", jr.NewPath, jr.AbsPath)
+				organize.PruneDirs(filepath.Dir(jr.NewPath), l.AbsPath())
+			case storage.JournalDelete:
+				console.Info.Logf("undo: restored from trash: %q", jr.AbsPath)
+			}
+			numUndone++
+		}
+		for _, jr := range failed {
+			switch jr.Op {
+			case storage.JournalMove:
+				console.Warn.Logf("undo: could not move back: %q -> %q", jr.NewPath, jr.AbsPath)
+			case storage.JournalDelete:
+				console.Warn.Logf("undo: could not restore from trash: %q", jr.AbsPath)
+			}
+			numFailed++
+		}
+		if nil != ret {
+			console.Warn.Log(ret)
+		}
+	}
+	if numFailed > 0 {
+		console.Warn.Logf("finished undoing changes of %s (%d file(s) reverted, %d could not be and were dropped from the journal)",
+			batch.Local().Format("2006-01-02 15:04:05"), numUndone, numFailed)
+	} else {
+		console.Info.Logf("finished undoing changes of %s (%d file(s) reverted)",
+			batch.Local().Format("2006-01-02 15:04:05"), numUndone)
+	}
+	return nil
+}
+
+// function beginJournal() begins a new batch of changes to the files of the
+// given libraries, recorded in their journals so that they can be reverted
+// together (see undoJournal()).
+func beginJournal(libs []*library.Library) {
+
+	batch := time.Now()
+	for _, l := range libs {
+		if ret := l.BeginJournal(batch); nil != ret {
+			console.Warn.Log(ret)
+		}
+	}
+}
+
+// function deleteMedia() moves each media file in the given libraries matching
+// the -match option to the trash, and removes its record from the database.
+// files are never deleted permanently; see trashItems() to restore them, or
+// undoJournal() to restore them along with their records. the files are moved
+// to the trash in the given directory (see trashFile()).
+func deleteMedia(options *Options, libs []*library.Library, trashDir string) *rc.ReturnCode {
+
+	if "" == options.Match.string && "" == options.Collection.string {
+		return rc.InvalidArgs.Specf("refusing to delete every media: select media with -%s or -%s",
+			options.Match.name, options.Collection.name)
+	}
+
+	selected, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+	beginJournal(libs)
+	var numDeleted uint
+	for _, l := range libs {
+		for _, m := range loadMedia([]*library.Library{l}, selected) {
+			if m.IsTrack() {
+				// the file is the whole album, not just the track.
+				console.Warn.Logf("not deleting track of cue sheet (delete its image instead): %q", m.AbsPath)
+				continue
+			}
+			item, ret := trashFile(trashDir, l, m.AbsPath)
+			if nil != ret {
+				console.Warn.Log(ret)
+				continue
+			}
+			if _, ret := l.TrashMedia(options.bus, m.AbsPath, item); nil != ret {
+				console.Warn.Log(ret)
+			}
+			console.Info.Logf("moved to trash: %s", item)
+			numDeleted++
+		}
+	}
+	console.Info.Logf("finished deleting (%d media moved to trash)", numDeleted)
+	return nil
+}
+
+// function trashFile() moves the file at the given absolute path, in the given
+// library, to the trash: the given directory if not empty (see -trashdir), or
+// else the OS trash, falling back on the library's own trash.
+func trashFile(dir string, l *library.Library, absPath string) (*trash.Item, *rc.ReturnCode) {
+
+	bin := trash.For(absPath, dir, l.AbsPath())
+	item, ret := bin.Put(absPath)
+	if nil != ret && "" == dir {
+		// the OS trash may not be reachable from every file system, so fall
+		// back on the library's own trash.
+		console.Warn.Verbose(ret)
+		bin = trash.New(filepath.Join(l.AbsPath(), trash.DefaultDirName))
+		item, ret = bin.Put(absPath)
+	}
+	return item, ret
+}
+
+// function trashItems() lists the files deleted from the given libraries that
+// are still in the trash (the given directory, if not empty, or else the OS
+// trash and the libraries' own) and match the -match option. if restore is
+// true, the files are moved back to where they were deleted from instead, all
+// of them only if force is true. restored files are added back to the
+// library's database on its next scan.
+func trashItems(options *Options, libs []*library.Library, dir string, restore, force bool) *rc.ReturnCode {
+
+	if restore && "" == options.Match.string && !force {
+		return rc.InvalidArgs.Specf(
+			"refusing to restore every file in the trash: select files with -%s, or use \"trash restore -force\"",
+			options.Match.name)
+	}
+
+	root := []string{}
+	for _, l := range libs {
+		root = append(root, l.AbsPath())
+	}
+	match := strings.ToLower(options.Match.string)
+
+	var numItems uint
+	for _, bin := range trash.All(dir, root...) {
+		items, ret := bin.List()
+		if nil != ret {
+			console.Warn.Log(ret)
+			continue
+		}
+		for _, item := range items {
+			// the OS trash is shared with every other program, so only the
+			// files that were in one of our libraries are considered.
+			inLibrary := false
+			for _, r := range root {
+				if rel, err := filepath.Rel(r, item.Path); nil == err && !strings.HasPrefix(rel, "..") {
+					inLibrary = true
+					break
+				}
+			}
+			if !inLibrary || !strings.Contains(strings.ToLower(item.Path), match) {
+				continue
+			}
+			if !restore {
+				console.Raw.Log(item)
+				numItems++
+				continue
+			}
+			if ret := item.Restore(); nil != ret {
+				console.Warn.Log(ret)
+				continue
+			}
+			console.Info.Logf("restored: %q", item.Path)
+			numItems++
+		}
+	}
+
+	if restore {
+		console.Info.Logf("finished restoring (%d file(s) restored, rescan to add them back to the library)", numItems)
+	} else {
+		console.Info.Logf("%d file(s) in trash", numItems)
+	}
+	return nil
+}
+
+// function organizeLibrary() moves the media files of the given libraries that
+// match the -match option into the layout described by the given path template,
+// updating their records to match. with -dryrun, the moves are only shown;
+// otherwise they're journaled, so that they can be reverted (see
+// undoJournal()).
+func organizeLibrary(options *Options, libs []*library.Library, template string) *rc.ReturnCode {
+
+	tmpl, ret := organize.ParseTemplate(template)
+	if nil != ret {
+		return rc.InvalidArgs.Specf("invalid path template (see \"organize -template\"): %s", ret)
+	}
+
+	if !options.DryRun.bool {
+		beginJournal(libs)
+	}
+	selected, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+	var numMoved, numFailed uint
+	for _, l := range libs {
+		moves, problems := tmpl.Plan(l.AbsPath(),
+			loadEntities([]*library.Library{l}, selected))
+		for _, p := range problems {
+			console.Warn.Logf("cannot organize: %s", p)
+		}
+		numFailed += uint(len(problems))
+		for _, mv := range moves {
+			if options.DryRun.bool {
+				console.Info.Logf("would move: %s", mv)
+				numMoved++
+				continue
+			}
+			if moved, ret := l.MoveMedia(mv.From, mv.To); nil != ret {
+				console.Warn.Log(ret)
+				numFailed++
+				continue
+			} else if !moved {
+				continue
+			}
+			console.Info.Verbosef("moved: %s", mv)
+			organize.PruneDirs(filepath.Dir(mv.From), l.AbsPath())
+			numMoved++
+		}
+	}
+	if options.DryRun.bool {
+		console.Info.Logf("finished organizing (dry run: %d media would be moved, %d cannot be)",
+			numMoved, numFailed)
+	} else {
+		console.Info.Logf("finished organizing (%d media moved, %d failed)", numMoved, numFailed)
+	}
+	return nil
+}
+
+// function dedupeLibrary() replaces the media files of the given libraries that
+// match the -match option and are byte-identical copies of another on the same
+// file system with hard links to that file, updating their records to match.
+// the copies found are listed first, and unless force is true, the user must
+// confirm before any are replaced. with -dryrun, the copies are only listed.
+func dedupeLibrary(options *Options, libs []*library.Library, force bool) *rc.ReturnCode {
+
+	selected, ret := selectMedia(options)
+	if nil != ret {
+		return ret
+	}
+	owner := map[string]*library.Library{}
+	files := []dedupe.File{}
+	for _, l := range libs {
+		for _, m := range loadMedia([]*library.Library{l}, selected) {
+			owner[m.AbsPath] = l
+			files = append(files, dedupe.File{Path: m.AbsPath, Size: m.Size})
+		}
+	}
+
+	console.Info.Logf("comparing content of %d media files ...", len(files))
+	groups, problems := dedupe.Find(files)
+	for _, p := range problems {
+		console.Warn.Log(p)
+	}
+
+	var numCopies uint
+	var reclaimable int64
+	for _, g := range groups {
+		console.Raw.Logf("keep: %q (%s)", g.Keep, report.HumanSize(g.Size))
+		for _, c := range g.Copies {
+			console.Raw.Logf("  link: %q", c)
+		}
+		numCopies += uint(len(g.Copies))
+		reclaimable += g.Reclaimed()
+	}
+	if 0 == numCopies {
+		console.Info.Log("no duplicate media found")
+		return nil
+	}
+	summary := fmt.Sprintf("%d copies of %d media (%s)",
+		numCopies, len(groups), report.HumanSize(reclaimable))
+	if options.DryRun.bool {
+		console.Info.Logf("finished deduplicating (dry run: %s would be replaced with hard links)", summary)
+		return nil
+	}
+	if !force && !confirm(fmt.Sprintf("replace %s with hard links?", summary)) {
+		console.Info.Log("deduplication canceled")
+		return nil
+	}
+
+	var numLinked uint
+	var reclaimed int64
+	for _, g := range groups {
+		for _, c := range g.Copies {
+			if ret := dedupe.Link(g.Keep, c); nil != ret {
+				console.Warn.Log(ret)
+				continue
+			}
+			if _, ret := owner[c].RestatMedia(c); nil != ret {
+				console.Warn.Log(ret)
+			}
+			console.Info.Verbosef("linked: %q -> %q", c, g.Keep)
+			numLinked++
+			reclaimed += g.Size
+		}
+	}
+	console.Info.Logf("finished deduplicating (%d copies replaced with hard links, %s reclaimed)",
+		numLinked, report.HumanSize(reclaimed))
+	return nil
+}
+
+// function confirm() asks the user the given yes-or-no question on standard
+// input, returning true only if the answer is yes.
+func confirm(question string) bool {
+	switch strings.ToLower(readLine(question + " [y/N]")) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// function readLine() prints the given prompt and returns the line read from
+// standard input, without surrounding spaces.
+func readLine(prompt string) string {
+	console.Raw.Log(prompt)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// function createExportFile() creates the file given with the -o option of the
+// subcommand, returning it along with the absolute path of the directory
+// containing it. if no file was given, standard output and the working
+// directory are returned -- the latter being our best guess as to wherever the
+// user redirects standard output.
+func createExportFile(options *Options) (*os.File, string, *rc.ReturnCode) {
+
+	path := ""
+	if nil != options.subcommand {
+		path = options.subcommand.output
+	}
+	if "" == path {
+		dir, err := filepath.Abs(platform.CurrDir)
+		if nil != err {
+			return nil, "", rc.InvalidPath.Wrapf(err, "cannot determine working directory: %s", err)
+		}
+		return os.Stdout, dir, nil
+	}
+
+	absFile, err := filepath.Abs(path)
+	if nil != err {
+		return nil, "", rc.InvalidPath.Wrapf(err, "invalid export path: %q: %s", path, err)
+	}
+	f, err := os.Create(absFile)
+	if nil != err {
+		return nil, "", rc.ExportError.Wrapf(err, "cannot create export file: %q: %s", absFile, err)
+	}
+	return f, filepath.Dir(absFile), nil
+}
+
+// function closeExportFile() closes a file returned by createExportFile().
+func closeExportFile(f *os.File) {
+	if os.Stdout != f {
+		f.Close()
+	}
+}
+
+// function matchMedia() returns a filter accepting the media whose title, name,
+// or path contains the given text, ignoring case. an empty text accepts all.
+func matchMedia(text string) func(*media.Media) bool {
+	return func(m *media.Media) bool { return m.Matches(text) }
+}
+
+// function visibleMedia() returns a filter accepting the media not hidden by
+// the active viewing profile, nor hidden as a lesser version of a title (see
+// subcommand "dupes resolve").
+func visibleMedia(options *Options) func(*media.Media) bool {
+	return func(m *media.Media) bool {
+		return !options.profile.Hides(m) && !m.HasTags(versions.HiddenTag)
+	}
+}
+
+// function selectMedia() returns a filter accepting the media selected by the
+// -match and -collection options, i.e. media matching the -match text and in
+// the named collection, if given. media hidden by the active viewing profile
+// are never selected.
+func selectMedia(options *Options) (func(*media.Media) bool, *rc.ReturnCode) {
+
+	text, visible := matchMedia(options.Match.string), visibleMedia(options)
+	match := func(m *media.Media) bool { return text(m) && visible(m) }
+	if "" == options.Collection.string {
+		return match, nil
+	}
+	c := collection.Find(loadCollections(options), options.Collection.string)
+	if nil == c {
+		return nil, rc.InvalidArgs.Specf("no such collection: %q (see \"collection list\")",
+			options.Collection.string)
+	}
+	return func(m *media.Media) bool { return match(m) && c.Contains(m) }, nil
+}
+
+// function collectionsPath() returns the path to the file in which the
+// collections are saved.
+func collectionsPath(options *Options) string {
+	return filepath.Join(options.configDir(), collection.DefaultFileName)
+}
+
+// function loadCollections() returns the saved collections. problems reading
+// them are logged, and no collections returned.
+func loadCollections(options *Options) []*collection.Collection {
+	list, ret := collection.Load(collectionsPath(options))
+	if nil != ret {
+		console.Warn.Log(ret)
+		return []*collection.Collection{}
+	}
+	return list
+}
+
+// function listCollections() lists the collections defined.
+func listCollections(options *Options) *rc.ReturnCode {
+
+	list := loadCollections(options)
+	for _, c := range list {
+		console.Raw.Log(c)
+	}
+	console.Info.Logf("%d collections defined", len(list))
+	return nil
+}
+
+// function addCollection() defines the collection with the given name of the
+// media having all of the given tags and matching the given text. adding a
+// collection with the name of another replaces it.
+func addCollection(options *Options, name string, tags []string, text string) *rc.ReturnCode {
+
+	c, ret := collection.New(name, tags, text)
+	if nil != ret {
+		return rc.InvalidArgs.Specf("%s (see \"%s %s collection add\")", ret, identity, cmdHelp)
+	}
+	list, replaced := collection.Put(loadCollections(options), c)
+	if replaced {
+		console.Info.Logf("replaced collection: %s", c)
+	} else {
+		console.Info.Logf("added collection: %s", c)
+	}
+	return collection.Save(collectionsPath(options), list)
+}
+
+// function removeCollection() removes the collection with the given name.
+func removeCollection(options *Options, name string) *rc.ReturnCode {
+
+	list, removed := collection.Remove(loadCollections(options), name)
+	if !removed {
+		return rc.InvalidArgs.Specf("no such collection: %q (see \"collection list\")", name)
+	}
+	console.Info.Logf("removed collection: %q", name)
+	return collection.Save(collectionsPath(options), list)
+}
+
+// function profilesPath() returns the path to the file in which the viewing
+// profiles are saved.
+func profilesPath(options *Options) string {
+	return filepath.Join(options.configDir(), profile.DefaultFileName)
+}
+
+// function activeProfile() returns the active viewing profile, or nil if none
+// is active. a profiles file that can't be read hides nothing, but is logged.
+func activeProfile(options *Options) *profile.Profile {
+	store, ret := profile.Load(profilesPath(options))
+	if nil != ret {
+		console.Warn.Log(ret)
+		return nil
+	}
+	cur := store.Current()
+	if nil != cur {
+		console.Info.Verbosef("using viewing profile: %q", cur.Name)
+	}
+	return cur
+}
+
+// function listProfiles() lists the viewing profiles, marking the active one.
+func listProfiles(options *Options) *rc.ReturnCode {
+
+	store, ret := profile.Load(profilesPath(options))
+	if nil != ret {
+		return ret
+	}
+	for _, p := range store.Profiles {
+		mark := " "
+		if p == store.Current() {
+			mark = "*"
+		}
+		console.Raw.Logf("%s %s (tags: %s; ratings: %s; paths: %s)", mark, p.Name,
+			strings.Join(p.HideTags, ", "), strings.Join(p.HideRatings, ", "),
+			strings.Join(p.HidePaths, ", "))
+	}
+	console.Info.Logf("%d profiles defined", len(store.Profiles))
+	return nil
+}
+
+// function changeProfiles() makes the given change to the viewing profiles,
+// saving them if it succeeds. once a PIN protects the profiles, the given PIN
+// must match it.
+func changeProfiles(options *Options, pin string, change func(*profile.Store) *rc.ReturnCode) *rc.ReturnCode {
+
+	store, ret := profile.Load(profilesPath(options))
+	if nil != ret {
+		return ret
+	}
+	if !store.CheckPIN(pin) {
+		return rc.InvalidArgs.Spec("incorrect PIN (see option -pin)")
+	}
+	if ret := change(store); nil != ret {
+		return ret
+	}
+	return store.Save(profilesPath(options))
+}
+
+// function addProfile() defines the given viewing profile, replacing any of the
+// same name.
+func addProfile(options *Options, p *profile.Profile, pin string) *rc.ReturnCode {
+
+	if "" == p.Name {
+		return rc.InvalidArgs.Spec("profile name must not be empty")
+	}
+	if !p.Restricted() {
+		console.Warn.Logf("profile %q hides nothing (see options -hidetags, -hideratings, -hidepaths)", p.Name)
+	}
+	return changeProfiles(options, pin, func(store *profile.Store) *rc.ReturnCode {
+		if store.Put(p) {
+			console.Info.Logf("replaced profile: %q", p.Name)
+		} else {
+			console.Info.Logf("added profile: %q", p.Name)
+		}
+		return nil
+	})
+}
+
+// function removeProfile() removes the viewing profile with the given name,
+// which must not be active.
+func removeProfile(options *Options, name, pin string) *rc.ReturnCode {
+
+	return changeProfiles(options, pin, func(store *profile.Store) *rc.ReturnCode {
+		if !store.Remove(name) {
+			return rc.InvalidArgs.Specf("no such inactive profile: %q (see \"profile list\")", name)
+		}
+		console.Info.Logf("removed profile: %q", name)
+		return nil
+	})
+}
+
+// function useProfile() makes the viewing profile with the given name active,
+// or none if empty.
+func useProfile(options *Options, name, pin string) *rc.ReturnCode {
+
+	return changeProfiles(options, pin, func(store *profile.Store) *rc.ReturnCode {
+		if ret := store.Switch(name); nil != ret {
+			return ret
+		}
+		if cur := store.Current(); nil != cur {
+			console.Info.Logf("switched to profile: %q", cur.Name)
+		} else {
+			console.Info.Log("no profile active, nothing is hidden")
+		}
+		if !store.Protected() {
+			console.Warn.Log("profiles are not protected by a PIN (see \"profile pin\")")
+		}
+		return nil
+	})
+}
+
+// function setProfilePIN() sets the PIN protecting the viewing profiles, or
+// removes it, replacing the given PIN.
+func setProfilePIN(options *Options, pin string) *rc.ReturnCode {
+
+	return changeProfiles(options, pin, func(store *profile.Store) *rc.ReturnCode {
+		// the new PIN is read from standard input, so that it doesn't linger
+		// in the shell's history.
+		next := readLine("new PIN (empty to remove):")
+		if ret := store.SetPIN(next); nil != ret {
+			return ret
+		}
+		if store.Protected() {
+			console.Info.Log("PIN set")
+		} else {
+			console.Info.Log("PIN removed")
+		}
+		return nil
+	})
+}
+
+// function splitList() splits the given comma-separated list, omitting empty
+// elements and the spaces surrounding each.
+func splitList(list string) []string {
+	elem := []string{}
+	for _, e := range strings.Split(list, ",") {
+		if e = strings.TrimSpace(e); "" != e {
+			elem = append(elem, e)
+		}
+	}
+	return elem
+}
+
+// function loadMedia() loads all of the media from the given libraries'
+// databases accepted by the given filter, sorted by path.
+func loadMedia(libs []*library.Library, accept func(*media.Media) bool) []*media.Media {
+
+	list := []*media.Media{}
+	for _, ent := range loadEntities(libs, accept) {
+		switch item := ent.(type) {
+		case *media.AudioMedia:
+			list = append(list, item.Media)
+		case *media.VideoMedia:
+			list = append(list, item.Media)
+		case *media.ImageMedia:
+			list = append(list, item.Media)
+		case *media.DocumentMedia:
+			list = append(list, item.Media)
+		}
+	}
+	return list
+}
+
+// function loadMediaBy() is like loadMedia(), but returns only the media
+// having any of the given values in the given indexed field (see LoadBy() of
+// Library), reading just their records.
+func loadMediaBy(libs []*library.Library, field string, value []string, accept func(*media.Media) bool) []*media.Media {
+
+	list := []*media.Media{}
+	bus := library.NewBus()
+	bus.Subscribe(func(e library.Event) {
+		if m := e.Discovery.Media(); nil != m && accept(m) {
+			list = append(list, m)
+		}
+	}, library.MediaDiscovered)
+	for _, l := range libs {
+		_, err := l.LoadBy(bus, field, value...)
+		if nil != err {
+			console.Error.Log(err)
+		}
+	}
+
+	sort.Slice(list, func(a, b int) bool { return list[a].AbsPath < list[b].AbsPath })
+	return list
+}
+
+// function loadEntities() is like loadMedia(), but returns each media as its
+// concrete type (*AudioMedia, *VideoMedia, *ImageMedia, or *DocumentMedia).
+func loadEntities(libs []*library.Library, accept func(*media.Media) bool) []media.StorableEntity {
+
+	list := []media.StorableEntity{}
+	path := map[media.StorableEntity]string{}
+	bus := library.NewBus()
+	bus.Subscribe(func(e library.Event) {
+		if m := e.Discovery.Media(); nil != m && accept(m) {
+			list = append(list, e.Discovery.Entity)
+			path[e.Discovery.Entity] = m.AbsPath
+		}
+	}, library.MediaDiscovered)
+	for _, l := range libs {
+		_, err := l.Load(context.Background(), bus)
+		if nil != err {
+			console.Error.Log(err)
+		}
+	}
+
+	sort.Slice(list, func(a, b int) bool { return path[list[a]] < path[list[b]] })
+	return list
+}
+
+// function importLibrary() seeds the records of the given libraries with the
+// metadata and watch state read from another media server's library export.
+// the server's items are matched to our records by file path, so the libraries
+// should be scanned beforehand. the server's paths are translated by the given
+// list of prefix substitutions (see migrate.NewPathMap()).
+func importLibrary(options *Options, libs []*library.Library, server, file, pathMapList string,
+	read func(io.Reader) ([]*migrate.Item, *rc.ReturnCode)) *rc.ReturnCode {
+
+	pathMap, ret := migrate.NewPathMap(pathMapList)
+	if nil != ret {
+		return ret
+	}
+
+	f, err := os.Open(file)
+	if nil != err {
+		return rc.InvalidPath.Specf("cannot open %s export: %q: %s",
+			server, file, err)
+	}
+	items, ret := read(f)
+	f.Close()
+	if nil != ret {
+		return ret
+	}
+	console.Info.Logf("importing %d item(s) from %s export: %q",
+		len(items), server, file)
+
+	var numUpdated, numUnchanged, numMissing uint
+	for _, item := range items {
+		absPath := pathMap.Map(item.Path)
+		var owner *library.Library
+		for _, l := range libs {
+			if rel, err := filepath.Rel(l.AbsPath(), absPath); nil == err &&
+				!strings.HasPrefix(rel, "..") {
+				owner = l
+				break
+			}
+		}
+		if nil == owner {
+			console.Warn.Tracef("not in any library: %q", absPath)
+			numMissing++
+			continue
+		}
+		found := false
+		updated, ret := owner.UpdateMedia(absPath, func(m *media.Media) bool {
+			found = true
+			return item.Apply(m)
+		})
+		switch {
+		case nil != ret:
+			console.Warn.Log(ret)
+		case updated:
+			numUpdated++
+		case found:
+			numUnchanged++
+		default:
+			console.Warn.Tracef("not in library database: %q", absPath)
+			numMissing++
+		}
+	}
+
+	console.Info.Logf("finished importing from %s (%d updated, %d unchanged, %d not found)",
+		server, numUpdated, numUnchanged, numMissing)
+	if numMissing > 0 {
+		console.Info.Log("items not found must be scanned into a library first, or may need -pathmap")
+	}
+	return nil
+}
+
+// function initPlugins() starts each of the plugin executables given with the
+// -plugins option and registers the shell hooks given with the -on* options.
+// returns nil if neither plugins nor shell hooks were requested.
+func initPlugins(options *Options) *plugin.Host {
+
+	hook := map[plugin.Event]string{
+		plugin.EventScanComplete:  options.OnScanComplete.string,
+		plugin.EventNewMedia:      options.OnNewMedia.string,
+		plugin.EventMediaRemoved:  options.OnMediaRemoved.string,
+		plugin.EventPlaybackStart: options.OnPlaybackStart.string,
+		plugin.EventPlaybackDone:  options.OnPlaybackDone.string,
+	}
+
+	anyHook := false
+	for _, command := range hook {
+		if "" != strings.TrimSpace(command) {
+			anyHook = true
+		}
+	}
+	if !anyHook && "" == strings.TrimSpace(options.Plugins.string) {
+		return nil
+	}
+
+	path := []string{}
+	for _, p := range strings.Split(options.Plugins.string, ",") {
+		path = append(path, strings.TrimSpace(p))
+	}
+	host := plugin.NewHost(path...)
+	if host.Len() > 0 {
+		console.Info.Verbosef("started %d plugin(s)", host.Len())
+	}
+	for event, command := range hook {
+		host.AddShellHook(event, strings.TrimSpace(command))
+	}
+
+	return host
+}
+
+// function initMPRIS() exports the MPRIS interface on the D-Bus session bus,
+// through which the desktop controls the media played. returns nil if -nompris
+// was given or the session bus is unavailable (e.g. on a headless machine).
+func initMPRIS(options *Options) *mpris.Server {
+
+	if options.NoMPRIS.bool {
+		return nil
+	}
+	server, ret := mpris.New()
+	if nil != ret {
+		console.Info.Verbose(ret)
+		return nil
+	}
+	return server
+}
+
+// function initLibrary() validates all library paths provided, returning a list
+// of the valid ones.
+func initLibrary(options *Options, busyState *library.BusyState) ([]*library.Library, *rc.ReturnCode) {
+
+	var libs []*library.Library
+
+	// any remaining args were not handled by the options parser (or selecting
+	// a command). they are then considered to be file paths of libraries, the
+	// names of those registered (see "lib add"), all of which are used if none
+	// are given, or specifications of libraries with their own settings, e.g.
+	// "Music:~/Music?kinds=audio&depth=3" (see registry.Parse()).
+	registered, ret := loadRegistry(options)
+	if nil != ret {
+		return nil, ret
+	}
+	libArgs := options.libArgs
+	if 0 == len(libArgs) {
+		for _, r := range registered {
+			libArgs = append(libArgs, r.Path)
+		}
+	}
+
+	// dispatch a single goroutine per library to verify each concurrently.
+	for _, libArg := range libArgs {
+		libPath := libArg
+		if r := registry.Find(registered, libArg); nil != r {
+			if _, err := os.Stat(libArg); nil != err {
+				libPath = r.Path
+			}
+		}
+		// a registered library, or one given with its settings, uses its own
+		// settings in place of the global options.
+		reg := registry.FindPath(registered, libPath)
+		if _, err := os.Stat(libPath); nil != err {
+			spec, ret := registry.Parse(libArg)
+			if nil != ret {
+				console.Warn.Log(ret)
+				continue
+			}
+			reg, libPath = spec, spec.Path
+		}
+		maxDepth := options.maxDepth
+		if nil != reg && reg.MaxDepth > 0 {
+			maxDepth = reg.MaxDepth
+		}
+		lib, err := library.NewLibrary(options.LibData.string, options.dbConfig(),
+			busyState, libPath, maxDepth, libs)
+
+		// if we encounter an error, issue a warning, do NOT add it to the list
+		// of valid libraries, and continue. if it is truly a fatal error, then
+		// all user-provided libraries will fail for the same reason; the list
+		// of valid libraries will be empty on return, and the program will
+		// terminate with error "no libraries found".
+		if nil != err {
+			console.Warn.Log(err)
+		} else {
+			// no error encountered, so the library is considered valid. add it
+			// to the queue.
+			if nil != reg {
+				lib.SetName(reg.Name)
+				lib.SetKinds(reg.MediaKinds())
+				if ret := lib.SetLibraryExclude(reg.Exclude); nil != ret {
+					console.Warn.Log(ret)
+				}
+				if nil != reg.Probe && *reg.Probe && !probe.Available() {
+					console.Warn.Logf("ffprobe not found, scanning library %q without it", reg.Name)
+					reg.Probe = nil
+				}
+				lib.SetLibraryProbe(reg.Probe)
+				if t, ok := library.ParseType(reg.Type); ok {
+					if ret := lib.SetType(t); nil != ret {
+						console.Warn.Log(ret)
+					}
+				}
+			}
+			console.Info.Verbosef("using library: %s (%s)", lib, lib.Type())
+			libs = append(libs, lib)
+		}
+	}
+
+	return libs, nil
+}
+
+// function populateLibrary() spawns goroutines to scan each library
+// concurrently. the files found are published on the event bus.
+func populateLibrary(options *Options, libs []*library.Library) {
+
+	// the user may stop the scanners early, keeping whatever they've found.
+	interruptOnSignal(options)
+
+	// for each library, dispatch a pair (2) of goroutines in order:
+	//   1. dump all of the content from the library's database, verifying it
+	//       and notifying the discovery channels;
+	//   2. recursively traverse the library's filesystem, identifying which
+	//       content is valid and desirable, then notify the discovery channels
+	//       accordingly.
+	for _, lib := range libs {
+
+		// 1. pull all of the media already known to exist in the library from
+		//    the local database, verify it still exists, and then publish it on
+		//    the event bus.
+		go func(l *library.Library) {
+			var numMedia uint = 0
+			if !l.DB().IsFirstAppearance() {
+				loadCount, loadErr := l.Load(options.ctx, options.bus)
+				numMedia += loadCount
+				if nil != loadErr {
+					console.Error.Verbose(loadErr)
+				}
+			}
+			if isAccessible {
+				announcer.announce("loaded library %q: %d item(s) from database", l.Name(), numMedia)
+			}
+			l.LoadComplete() <- numMedia
+		}(lib)
+
+		// 2. recursively walks a library's file system, publishing on the event
+		//    bus whenever any sort of content is found.
+		go func(l *library.Library) {
+			// postpone the scanning until the load routine has completed.
+			var numMedia uint = <-l.LoadComplete()
+			scanCount, scanErr := l.Scan(options.ctx, options.bus)
+			numMedia += scanCount
+			if nil != scanErr {
+				console.Error.Verbose(scanErr)
+			}
+			if 0 == numMedia {
+				console.Warn.Logf("no media in %q: library is empty!", l.Name())
+				if !console.IsVerbose() && !console.IsTrace() {
+					console.Warn.Logf("try using program options -%s or -%s for more info",
+						options.Verbose.name, options.Trace.name)
+				}
+			}
+			if isAccessible {
+				announcer.announce("scanned library %q: %d item(s) total", l.Name(), numMedia)
+			}
+			l.ScanComplete() <- numMedia
+		}(lib)
+	}
+}
+
+// function subscribeLayout() subscribes the given layout to the files published
+// on the given event bus, adding the media found to its media browser and
+// removing those whose files were removed.
+func subscribeLayout(bus *library.Bus, layout *Layout) {
+	bus.Subscribe(func(e library.Event) {
+		switch e.Kind {
+		case library.MediaDiscovered:
+			layout.addDiscovery(e.Discovery)
+		case library.SupportDiscovered:
+			// the watcher finds supporting files before the media they belong
+			// to are known, so only those loaded or scanned are added.
+			if library.SourceWatch != e.Source {
+				layout.addDiscovery(e.Discovery)
+			}
+		case library.FileRemoved:
+			if library.SourceWatch == e.Source {
+				layout.removeDiscovery(e.Library, e.Path)
+			}
+		}
+	}, library.MediaDiscovered, library.SupportDiscovered, library.FileRemoved)
+}
+
+// function subscribeLog() subscribes the log to the changes to the files of the
+// libraries published on the given event bus by their watchers.
+func subscribeLog(bus *library.Bus) {
+	bus.Subscribe(func(e library.Event) {
+		if library.SourceWatch != e.Source {
+			return
+		}
+		switch e.Kind {
+		case library.MediaDiscovered:
+			console.Info.Logf("new media in %q: %q", e.Library.Name(), e.Path)
+		case library.SupportDiscovered:
+			console.Info.Verbosef("new supporting file in %q: %q", e.Library.Name(), e.Path)
+		case library.FileRemoved:
+			console.Info.Logf("removed from %q: %q", e.Library.Name(), e.Path)
+		}
+	}, library.MediaDiscovered, library.SupportDiscovered, library.FileRemoved)
+}
+
+// the formats of the summary printed once the libraries are scanned (see
+// option -summary).
+const (
+	summaryText = "text" // the usual status message, logged
+	summaryJSON = "json" // a single JSON document, written to standard output
+)
+
+// type scanSummary is the JSON document written as the summary of the scans of
+// the libraries (see option -summary).
+type scanSummary struct {
+	Found          uint               `json:"found"`
+	Errors         int                `json:"errors"` // scans ended by an error
+	Warnings       int                `json:"warnings"`
+	ElapsedSeconds float64            `json:"elapsedSeconds"`
+	Libraries      []*library.Summary `json:"libraries"`
+}
+
+// function subscribeSummary() subscribes to the scans finished on the event
+// bus, returning the function that writes the summary of the scans of the given
+// libraries, once each has completed, in the format given by option -summary.
+// the text summary is the status message already logged, so only JSON is
+// written.
+func subscribeSummary(options *Options) func(libs []*library.Library, found uint, elapsed time.Duration) *rc.ReturnCode {
+
+	var mutex sync.Mutex
+	scanErr := map[*library.Library]*rc.ReturnCode{}
+	unsubscribe := options.bus.Subscribe(func(e library.Event) {
+		mutex.Lock()
+		scanErr[e.Library] = e.Err
+		mutex.Unlock()
+	}, library.ScanFinished)
+
+	return func(libs []*library.Library, found uint, elapsed time.Duration) *rc.ReturnCode {
+		unsubscribe()
+		if !strings.EqualFold(summaryJSON, options.Summary.string) {
+			return nil
+		}
+		sum := &scanSummary{
+			Found:          found,
+			ElapsedSeconds: elapsed.Seconds(),
+			Libraries:      []*library.Summary{},
+		}
+		mutex.Lock()
+		for _, l := range libs {
+			ls := l.Summary(scanErr[l])
+			if nil != scanErr[l] {
+				sum.Errors++
+			}
+			sum.Warnings += ls.Warnings
+			sum.Libraries = append(sum.Libraries, ls)
+		}
+		mutex.Unlock()
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(sum); nil != err {
+			return rc.InvalidJSONData.Specf("subscribeSummary(): json.Encode(): %s", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,121 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: profile.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    records the execution trace and the blocking and mutex contention
+//    profiles requested by the global options, and serves the pprof HTTP
+//    endpoints so that long-running sessions (the TUI, serve, or a daemon)
+//    can be profiled while they run.
+//
+// =============================================================================
+
+package main
+
+import (
+	"net"
+	"net/http"
+	httppprof "net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// function startProfiling() starts recording the execution trace, enables the
+// blocking and mutex profilers, and starts serving the pprof endpoints, each
+// only if requested by the given Options. returns the function stopping the
+// trace and the server, which should be deferred.
+func startProfiling(options *Options) (func(), *rc.ReturnCode) {
+
+	stop := []func(){}
+	stopAll := func() {
+		for i := len(stop) - 1; i >= 0; i-- {
+			stop[i]()
+		}
+	}
+
+	if options.TraceProfile.bool && "" != options.TraceName.string {
+		console.Info.Verbosef("writing execution trace: %q", options.TraceName.string)
+		f, err := os.Create(options.TraceName.string)
+		if nil != err {
+			return nil, rc.InvalidFile.Specf("could not create execution trace: %s", err)
+		}
+		if err := trace.Start(f); nil != err {
+			f.Close()
+			return nil, rc.InvalidFile.Specf("could not start execution trace: %s", err)
+		}
+		stop = append(stop, func() { trace.Stop(); f.Close() })
+	}
+
+	// every blocking event and every contended mutex is sampled, which costs
+	// little next to the I/O of scanning.
+	if options.BlockProfile.bool {
+		runtime.SetBlockProfileRate(1)
+	}
+	if options.MutexProfile.bool {
+		runtime.SetMutexProfileFraction(1)
+	}
+
+	if "" != options.PprofAddr.string {
+		ln, err := net.Listen("tcp", options.PprofAddr.string)
+		if nil != err {
+			stopAll()
+			return nil, rc.InvalidArgs.Specf("could not serve pprof endpoints: %s", err)
+		}
+		// the endpoints are registered with a mux of their own, rather than
+		// the default mux, so that they're never served by anything else.
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", httppprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", httppprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", httppprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", httppprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", httppprof.Trace)
+		srv := &http.Server{Handler: mux}
+		go func() {
+			if err := srv.Serve(ln); nil != err && http.ErrServerClosed != err {
+				console.Warn.Logf("pprof endpoints stopped: %s", err)
+			}
+		}()
+		console.Info.Logf("serving pprof endpoints: http://%s/debug/pprof/", ln.Addr())
+		stop = append(stop, func() { srv.Close() })
+	}
+
+	return stopAll, nil
+}
+
+// function writeProfiles() writes the blocking and mutex contention profiles,
+// each only if requested by the given Options.
+func writeProfiles(options *Options) *rc.ReturnCode {
+
+	write := func(profile, path, desc string) *rc.ReturnCode {
+		console.Info.Verbosef("writing %s profile: %q", desc, path)
+		f, err := os.Create(path)
+		if nil != err {
+			return rc.InvalidFile.Specf("could not create %s profile: %s", desc, err)
+		}
+		defer f.Close()
+		if err := pprof.Lookup(profile).WriteTo(f, 0); nil != err {
+			return rc.InvalidFile.Specf("could not write %s profile: %s", desc, err)
+		}
+		return nil
+	}
+
+	if options.BlockProfile.bool && "" != options.BlockName.string {
+		if ret := write("block", options.BlockName.string, "blocking"); nil != ret {
+			return ret
+		}
+	}
+	if options.MutexProfile.bool && "" != options.MutexName.string {
+		if ret := write("mutex", options.MutexName.string, "mutex"); nil != ret {
+			return ret
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: progress.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    formats the progress of the tasks keeping the libraries busy, printed
+//    periodically in CLI mode (option -progress) and drawn as a progress bar
+//    in the status bar of the TUI.
+//
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/library"
+	"ardnew.com/pimmp/pkg/report"
+)
+
+// local unexported constants for the progress bar.
+const (
+	progressBarFull  = '█'
+	progressBarEmpty = '░'
+)
+
+// function progressString() describes the progress of the given task, e.g.
+// "scanning: Movies: 1200 of 5000 (24%), 3.1 MiB/s, 1m20s left". the number
+// of items expected, and thus the time left, are only estimates.
+func progressString(t library.BusyTask) string {
+
+	status := fmt.Sprintf("%s: %d", t.Label, t.Done)
+	if frac, ok := t.Fraction(); ok {
+		status = fmt.Sprintf("%s: %d of %d (%.0f%%)", t.Label, t.Done, t.Total, 100*frac)
+	}
+	if rate := t.Rate(); rate > 0 {
+		status += fmt.Sprintf(", %s/s", report.HumanSize(int64(rate)))
+	}
+	if eta, ok := t.ETA(); ok {
+		status += fmt.Sprintf(", %s left", eta.Round(time.Second))
+	}
+	return status
+}
+
+// function progressBar() draws the fraction of the items expected by the given
+// task that have been processed as a bar of the given width, followed by the
+// estimated time left. returns the empty string if the number of items
+// expected is unknown.
+func progressBar(t library.BusyTask, width int) string {
+
+	frac, ok := t.Fraction()
+	if !ok {
+		return ""
+	}
+	full := int(frac * float64(width))
+	bar := strings.Repeat(string(progressBarFull), full) +
+		strings.Repeat(string(progressBarEmpty), width-full)
+	if eta, ok := t.ETA(); ok {
+		return fmt.Sprintf("%s %s %3.0f%% %s", t.Label, bar, 100*frac, eta.Round(time.Second))
+	}
+	return fmt.Sprintf("%s %s %3.0f%%", t.Label, bar, 100*frac)
+}
+
+// function reportProgress() prints the progress of each busy task at the given
+// interval until the given Context is done. this should be called in its own
+// goroutine.
+func reportProgress(ctx context.Context, busy *library.BusyState, every time.Duration) {
+
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			for _, t := range busy.Tasks() {
+				console.Info.Log(progressString(t))
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: audiotag.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    reads the metadata embedded in audio files: the tags (ID3v1/ID3v2, Vorbis
+//    comments, FLAC, and MP4 atoms) naming the artist, album, track, etc., and
+//    the length of the audio, read from the stream headers.
+//
+// =============================================================================
+
+// package audiotag reads the metadata embedded in audio files, so that the
+// records of audio media describe more than their file names.
+package audiotag
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dhowden/tag"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Info is the metadata read from an audio file. fields the file doesn't
+// define are left zero.
+type Info struct {
+	Title    string        // title of the track
+	Artist   string        // performer of the track (or else of the album)
+	Album    string        // name of the album on which the track appears
+	Track    int           // number of the track on the album
+	Year     int           // year the track was released
+	Genre    string        // genre of the track
+	Duration time.Duration // length of the audio
+}
+
+// function Supported() returns true if metadata can be read from files with
+// the given file name extension.
+func Supported(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".mp3", ".flac", ".ogg", ".oga", ".opus", ".m4a", ".m4b":
+		return true
+	}
+	return false
+}
+
+// function Read() reads the metadata of the audio file at the given path. a
+// file without any tags is not an error, as long as its length can be read.
+func Read(path string) (*Info, *rc.ReturnCode) {
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if !Supported(ext) {
+		return nil, rc.MetadataError.Specf("Read(%q): unsupported file type", path)
+	}
+	f, err := os.Open(path)
+	if nil != err {
+		return nil, rc.MetadataError.Specf("Read(%q): %s", path, err)
+	}
+	defer f.Close()
+
+	info := &Info{}
+	m, err := tag.ReadFrom(f)
+	switch {
+	case nil == err:
+		info.Title = strings.TrimSpace(m.Title())
+		info.Artist = strings.TrimSpace(m.Artist())
+		if "" == info.Artist {
+			info.Artist = strings.TrimSpace(m.AlbumArtist())
+		}
+		info.Album = strings.TrimSpace(m.Album())
+		info.Track, _ = m.Track()
+		info.Year = m.Year()
+		info.Genre = strings.TrimSpace(m.Genre())
+	case tag.ErrNoTagsFound == err:
+	default:
+		return nil, rc.MetadataError.Specf("Read(%q): %s", path, err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); nil != err {
+		return nil, rc.MetadataError.Specf("Read(%q): %s", path, err)
+	}
+	if info.Duration, err = duration(f, ext); nil != err {
+		// the tags are still worth keeping without the length.
+		return info, rc.MetadataError.Specf("Read(%q): cannot read length: %s", path, err)
+	}
+	return info, nil
+}
@@ -0,0 +1,342 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: duration.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    reads the length of audio from the stream headers of MP3, FLAC, Ogg
+//    (Vorbis and Opus), and MP4 files, without decoding any of the audio.
+//
+// =============================================================================
+
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// local unexported constants for reading the stream headers.
+const (
+	id3HeaderLen = 10        // length of an ID3v2 tag header
+	id3v1Len     = 128       // length of an ID3v1 tag, at the end of the file
+	mp3SyncLimit = 64 << 10  // how far into the audio the first frame is searched for
+	oggTailLen   = 64 << 10  // how far from the end the last Ogg page is searched for
+	opusRate     = 48000     // sample rate of every Opus granule position
+	mp4MaxDepth  = 8         // deepest nesting of MP4 atoms searched
+	maxBlockLen  = 16 << 20  // longest FLAC metadata block skipped
+	maxAtomLen   = 256 << 20 // longest MP4 atom read into memory
+)
+
+// variable errNoLength is returned when a file's stream headers don't define
+// its length.
+var errNoLength = errors.New("length not found in stream headers")
+
+// function duration() returns the length of the audio read from f, a file with
+// the given (lower case) file name extension.
+func duration(f *os.File, ext string) (time.Duration, error) {
+	switch ext {
+	case ".mp3":
+		return mp3Duration(f)
+	case ".flac":
+		return flacDuration(f)
+	case ".ogg", ".oga", ".opus":
+		return oggDuration(f)
+	case ".m4a", ".m4b":
+		return mp4Duration(f)
+	}
+	return 0, errNoLength
+}
+
+// function seconds() returns the length of the given number of samples at the
+// given sample rate.
+func seconds(samples, rate uint64) time.Duration {
+	if 0 == rate {
+		return 0
+	}
+	return time.Duration(samples * uint64(time.Second) / rate)
+}
+
+// function skipID3() returns the offset of the data following the ID3v2 tag at
+// the start of f, or 0 if there is none.
+func skipID3(f io.ReaderAt) int64 {
+	head := make([]byte, id3HeaderLen)
+	if _, err := f.ReadAt(head, 0); nil != err || "ID3" != string(head[:3]) {
+		return 0
+	}
+	// the size is "syncsafe": 7 bits in each byte, excluding the header (and
+	// the footer, if flagged).
+	size := int64(head[6]&0x7F)<<21 | int64(head[7]&0x7F)<<14 |
+		int64(head[8]&0x7F)<<7 | int64(head[9]&0x7F)
+	size += id3HeaderLen
+	if 0 != head[5]&0x10 {
+		size += id3HeaderLen
+	}
+	return size
+}
+
+// the bitrates (kbps) of MPEG audio Layer III, by version and bitrate index.
+var (
+	mp3Bitrate1 = [16]uint64{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3Bitrate2 = [16]uint64{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+)
+
+// function mp3Duration() returns the length of an MP3 file from the frame count
+// of its Xing/Info or VBRI header, or else estimated from the constant bitrate
+// of its first frame.
+func mp3Duration(f *os.File) (time.Duration, error) {
+
+	start := skipID3(f)
+	buf := make([]byte, mp3SyncLimit)
+	n, err := f.ReadAt(buf, start)
+	if nil != err && io.EOF != err {
+		return 0, err
+	}
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		// frame sync: 11 set bits, followed by a valid version and layer III.
+		if 0xFF != buf[i] || 0xE0 != buf[i+1]&0xE0 {
+			continue
+		}
+		version := (buf[i+1] >> 3) & 0x03 // 3 = MPEG-1, 2 = MPEG-2, 0 = MPEG-2.5
+		layer := (buf[i+1] >> 1) & 0x03   // 1 = layer III
+		brIndex := buf[i+2] >> 4
+		srIndex := (buf[i+2] >> 2) & 0x03
+		if 1 == version || 1 != layer || 0 == brIndex || 0x0F == brIndex || 3 == srIndex {
+			continue
+		}
+		mono := 3 == buf[i+3]>>6
+
+		var rate, bitrate, samplesPerFrame uint64
+		var sideInfo int
+		switch version {
+		case 3:
+			rate = [3]uint64{44100, 48000, 32000}[srIndex]
+			bitrate = mp3Bitrate1[brIndex] * 1000
+			samplesPerFrame = 1152
+			sideInfo = 32
+			if mono {
+				sideInfo = 17
+			}
+		default:
+			rate = [3]uint64{22050, 24000, 16000}[srIndex]
+			if 0 == version {
+				rate /= 2
+			}
+			bitrate = mp3Bitrate2[brIndex] * 1000
+			samplesPerFrame = 576
+			sideInfo = 17
+			if mono {
+				sideInfo = 9
+			}
+		}
+
+		// a VBR file's first frame is a Xing (or Info) or VBRI header counting
+		// the frames of the file.
+		if x := i + 4 + sideInfo; x+12 <= len(buf) {
+			if tag := string(buf[x : x+4]); "Xing" == tag || "Info" == tag {
+				if 0 != buf[x+7]&0x01 {
+					frames := binary.BigEndian.Uint32(buf[x+8 : x+12])
+					return seconds(uint64(frames)*samplesPerFrame, rate), nil
+				}
+			}
+		}
+		if v := i + 4 + 32; v+18 <= len(buf) && "VBRI" == string(buf[v:v+4]) {
+			frames := binary.BigEndian.Uint32(buf[v+14 : v+18])
+			return seconds(uint64(frames)*samplesPerFrame, rate), nil
+		}
+
+		// otherwise, assume a constant bitrate throughout.
+		info, err := f.Stat()
+		if nil != err {
+			return 0, err
+		}
+		size := info.Size() - start - int64(i)
+		tail := make([]byte, 3)
+		if _, err := f.ReadAt(tail, info.Size()-id3v1Len); nil == err && "TAG" == string(tail) {
+			size -= id3v1Len
+		}
+		if size <= 0 {
+			return 0, errNoLength
+		}
+		return time.Duration(uint64(size) * 8 * uint64(time.Second) / bitrate), nil
+	}
+	return 0, fmt.Errorf("no MPEG audio frame found")
+}
+
+// function flacDuration() returns the length of a FLAC file from the sample
+// count of its STREAMINFO metadata block.
+func flacDuration(f *os.File) (time.Duration, error) {
+
+	pos := skipID3(f)
+	magic := make([]byte, 4)
+	if _, err := f.ReadAt(magic, pos); nil != err {
+		return 0, err
+	}
+	if "fLaC" != string(magic) {
+		return 0, fmt.Errorf("not a FLAC stream")
+	}
+	pos += 4
+
+	head := make([]byte, 4)
+	for {
+		if _, err := f.ReadAt(head, pos); nil != err {
+			return 0, err
+		}
+		last, kind := 0 != head[0]&0x80, head[0]&0x7F
+		size := int64(head[1])<<16 | int64(head[2])<<8 | int64(head[3])
+		pos += 4
+		if 0 == kind { // STREAMINFO
+			info := make([]byte, 18)
+			if _, err := f.ReadAt(info, pos); nil != err {
+				return 0, err
+			}
+			rate := uint64(info[10])<<12 | uint64(info[11])<<4 | uint64(info[12])>>4
+			samples := uint64(info[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(info[14:18]))
+			if 0 == samples {
+				return 0, errNoLength // unknown, e.g. a stream still being encoded
+			}
+			return seconds(samples, rate), nil
+		}
+		if last || size > maxBlockLen {
+			return 0, errNoLength
+		}
+		pos += size
+	}
+}
+
+// function oggDuration() returns the length of an Ogg Vorbis or Opus file from
+// the granule position (sample count) of its last page.
+func oggDuration(f *os.File) (time.Duration, error) {
+
+	// the identification header is the first packet of the first page.
+	head := make([]byte, 27+255+19)
+	n, err := f.ReadAt(head, 0)
+	if nil != err && io.EOF != err {
+		return 0, err
+	}
+	head = head[:n]
+	if len(head) < 27 || "OggS" != string(head[:4]) || len(head) < 27+int(head[26]) {
+		return 0, fmt.Errorf("not an Ogg stream")
+	}
+	packet := head[27+int(head[26]):]
+
+	var rate, preSkip uint64
+	switch {
+	case len(packet) >= 16 && bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		rate = uint64(binary.LittleEndian.Uint32(packet[12:16]))
+	case len(packet) >= 12 && bytes.HasPrefix(packet, []byte("OpusHead")):
+		rate = opusRate
+		preSkip = uint64(binary.LittleEndian.Uint16(packet[10:12]))
+	default:
+		return 0, fmt.Errorf("unsupported Ogg codec")
+	}
+
+	info, err := f.Stat()
+	if nil != err {
+		return 0, err
+	}
+	off := info.Size() - oggTailLen
+	if off < 0 {
+		off = 0
+	}
+	tail := make([]byte, info.Size()-off)
+	if _, err := f.ReadAt(tail, off); nil != err && io.EOF != err {
+		return 0, err
+	}
+	last := bytes.LastIndex(tail, []byte("OggS"))
+	if last < 0 || last+14 > len(tail) {
+		return 0, errNoLength
+	}
+	granule := binary.LittleEndian.Uint64(tail[last+6 : last+14])
+	if granule <= preSkip || 0xFFFFFFFFFFFFFFFF == granule {
+		return 0, errNoLength
+	}
+	return seconds(granule-preSkip, rate), nil
+}
+
+// function mp4Duration() returns the length of an MP4 file from the time scale
+// and duration of its movie header (moov/mvhd atom).
+func mp4Duration(f *os.File) (time.Duration, error) {
+
+	info, err := f.Stat()
+	if nil != err {
+		return 0, err
+	}
+	mvhd, err := findAtom(f, 0, info.Size(), []string{"moov", "mvhd"}, 0)
+	if nil != err {
+		return 0, err
+	}
+	if len(mvhd) < 1 {
+		return 0, errNoLength
+	}
+	var scale, length uint64
+	switch mvhd[0] { // version
+	case 0:
+		if len(mvhd) < 20 {
+			return 0, errNoLength
+		}
+		scale = uint64(binary.BigEndian.Uint32(mvhd[12:16]))
+		length = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	case 1:
+		if len(mvhd) < 32 {
+			return 0, errNoLength
+		}
+		scale = uint64(binary.BigEndian.Uint32(mvhd[20:24]))
+		length = binary.BigEndian.Uint64(mvhd[24:32])
+	default:
+		return 0, fmt.Errorf("unsupported mvhd version: %d", mvhd[0])
+	}
+	return seconds(length, scale), nil
+}
+
+// function findAtom() returns the content of the MP4 atom at the given path of
+// nested atom types, searching the atoms between the given offsets of f.
+func findAtom(f io.ReaderAt, start, end int64, path []string, depth int) ([]byte, error) {
+
+	if depth > mp4MaxDepth {
+		return nil, errNoLength
+	}
+	head := make([]byte, 16)
+	for pos := start; pos+8 <= end; {
+		if _, err := f.ReadAt(head[:8], pos); nil != err {
+			return nil, err
+		}
+		size, kind, headLen := int64(binary.BigEndian.Uint32(head[:4])), string(head[4:8]), int64(8)
+		switch size {
+		case 0: // extends to the end of its parent
+			size = end - pos
+		case 1: // 64-bit size follows the type
+			if _, err := f.ReadAt(head[8:16], pos+8); nil != err {
+				return nil, err
+			}
+			size, headLen = int64(binary.BigEndian.Uint64(head[8:16])), 16
+		}
+		if size < headLen || pos+size > end {
+			return nil, fmt.Errorf("malformed atom %q at offset %d", kind, pos)
+		}
+		if path[0] == kind {
+			if 1 == len(path) {
+				if size-headLen > maxAtomLen {
+					return nil, fmt.Errorf("atom %q too large", kind)
+				}
+				data := make([]byte, size-headLen)
+				if _, err := f.ReadAt(data, pos+headLen); nil != err {
+					return nil, err
+				}
+				return data, nil
+			}
+			return findAtom(f, pos+headLen, pos+size, path[1:], depth+1)
+		}
+		pos += size
+	}
+	return nil, errNoLength
+}
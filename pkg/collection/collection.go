@@ -0,0 +1,156 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: collection.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the virtual collections grouping media from any library by their
+//    tags and a saved query.
+//
+// =============================================================================
+
+// package collection defines collections: named groupings of media, e.g.
+// "Studio Ghibli", defined by the tags their media must have and/or the text
+// their title, name, or path must contain. collections are virtual -- their
+// media remain wherever they are in their libraries -- and are shared by all
+// libraries, so they are saved in a single file alongside the configuration.
+package collection
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant DefaultFileName is the name of the file, in the configuration
+// directory, in which collections are saved.
+const DefaultFileName = "collections.json"
+
+// type Collection is a single named grouping of media.
+type Collection struct {
+	Name  string   // unique name of the collection
+	Tags  []string // tags that each media in the collection must have
+	Match string   // text that each media's title, name, or path must contain
+}
+
+// function New() creates a new Collection with the given name, containing the
+// media having all of the given tags and matching the given text. at least one
+// tag or the text is required, otherwise every media would belong to it.
+func New(name string, tags []string, match string) (*Collection, *rc.ReturnCode) {
+
+	name = strings.TrimSpace(name)
+	if "" == name {
+		return nil, rc.InvalidArgs.Spec("collection name must not be empty")
+	}
+	tag := []string{}
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); "" != t {
+			tag = append(tag, t)
+		}
+	}
+	if 0 == len(tag) && "" == match {
+		return nil, rc.InvalidArgs.Specf(
+			"collection %q must be defined by tags, a match, or both", name)
+	}
+	return &Collection{Name: name, Tags: tag, Match: match}, nil
+}
+
+// function Contains() returns true if the given media belongs to the Collection.
+func (c *Collection) Contains(m *media.Media) bool {
+	return nil != m && m.HasTags(c.Tags...) && m.Matches(c.Match)
+}
+
+// function String() returns a description of the Collection for display.
+func (c *Collection) String() string {
+	rule := []string{}
+	if len(c.Tags) > 0 {
+		rule = append(rule, "tags: "+strings.Join(c.Tags, ", "))
+	}
+	if "" != c.Match {
+		rule = append(rule, "match: \""+c.Match+"\"")
+	}
+	return c.Name + " (" + strings.Join(rule, "; ") + ")"
+}
+
+// function Load() reads the collections saved in the file at the given path,
+// sorted by name. a file that doesn't exist contains no collections.
+func Load(path string) ([]*Collection, *rc.ReturnCode) {
+
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		if os.IsNotExist(err) {
+			return []*Collection{}, nil
+		}
+		return nil, rc.InvalidConfig.Specf("Load(%q): %s", path, err)
+	}
+	list := []*Collection{}
+	if err := json.Unmarshal(data, &list); nil != err {
+		return nil, rc.InvalidJSONData.Specf("Load(%q): json.Unmarshal(): %s", path, err)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Name < list[b].Name })
+	return list, nil
+}
+
+// function Save() writes the given collections to the file at the given path,
+// replacing its content.
+func Save(path string, list []*Collection) *rc.ReturnCode {
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if nil != err {
+		return rc.InvalidJSONData.Specf("Save(%q): json.MarshalIndent(): %s", path, err)
+	}
+	// write a temporary file first, so that an interrupted write never leaves
+	// behind a truncated file.
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); nil != err {
+		return rc.InvalidConfig.Specf("Save(%q): %s", path, err)
+	}
+	if err := os.Rename(tmp, path); nil != err {
+		os.Remove(tmp)
+		return rc.InvalidConfig.Specf("Save(%q): %s", path, err)
+	}
+	return nil
+}
+
+// function Find() returns the collection in the given list with the given
+// name (ignoring case), or nil if there is none.
+func Find(list []*Collection, name string) *Collection {
+	for _, c := range list {
+		if strings.EqualFold(c.Name, strings.TrimSpace(name)) {
+			return c
+		}
+	}
+	return nil
+}
+
+// function Put() returns the given list with the given collection added, or
+// replacing the collection of the same name. returns true if it was replaced.
+func Put(list []*Collection, c *Collection) ([]*Collection, bool) {
+	for i, d := range list {
+		if strings.EqualFold(d.Name, c.Name) {
+			list[i] = c
+			return list, true
+		}
+	}
+	list = append(list, c)
+	sort.Slice(list, func(a, b int) bool { return list[a].Name < list[b].Name })
+	return list, false
+}
+
+// function Remove() returns the given list without the collection with the
+// given name (ignoring case). returns true if it was found and removed.
+func Remove(list []*Collection, name string) ([]*Collection, bool) {
+	for i, c := range list {
+		if strings.EqualFold(c.Name, strings.TrimSpace(name)) {
+			return append(list[:i], list[i+1:]...), true
+		}
+	}
+	return list, false
+}
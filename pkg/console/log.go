@@ -0,0 +1,507 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 02 Oct 2018
+//  FILE: log.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    provides a collection of types and functions for logging data to a file
+//    or to an output stream such as STDOUT and STDERR.
+//
+// =============================================================================
+
+// package console provides the loggers shared by all of pimmp's packages.
+package console
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Logger represents an object that logs data to one of the output
+// streams of the user's console. the different loggers use different streams
+// and various prefixes to distinguish between benign and fatal messages.
+//
+// the loggers of a Component write through the built-in logger of the same
+// kind, and so only by the methods defined below (Log(), Verbose(), etc.).
+type Logger struct {
+	prefix    string
+	console   io.Writer
+	writer    io.Writer
+	level     Level   // level of the messages of Log() and Logf()
+	component string  // name of the Component logging, empty if built-in
+	parent    *Logger // built-in logger writing the messages of a Component's
+	*log.Logger
+	*sync.Mutex
+}
+
+// type Component is the set of loggers of a named part of the program, e.g.
+// "scan" or "db", whose messages are filtered by a level of their own, if one
+// is set (see SetLevel()).
+type Component struct {
+	Name  string
+	Raw   *Logger
+	Info  *Logger
+	Warn  *Logger
+	Error *Logger
+}
+
+// type Level is the importance of a message: only messages at most as detailed
+// as the level set (see SetLevel()) are logged.
+type Level int
+
+const (
+	LevelError Level = iota // failures
+	LevelWarn               // problems that don't stop anything
+	LevelInfo               // status of normal operation (default)
+	LevelDebug              // additional status information (-verbose)
+	LevelTrace              // very detailed status information (-trace)
+	LevelCOUNT
+)
+
+// var levelName is the name of each Level, as given to ParseLevel().
+var levelName = [LevelCOUNT]string{"error", "warn", "info", "debug", "trace"}
+
+// unexported constants
+const (
+	logFlags        = log.Ldate | log.Ltime // flags defining format of log.Logger
+	logDelimNormal  = "  "                  // log detail fields delimiter
+	logDelimVerbose = "- "                  // ^ delimiter for verbose sessions
+	logDelimTrace   = "| "                  // ^ delimiter for trace sessions
+)
+
+// type LogID is an enum identifying the different kinds of built-in loggers.
+type LogID int
+
+const (
+	liRaw LogID = iota
+	liInfo
+	liWarn
+	liError
+	liCOUNT
+)
+
+// Madmen toil surreptitiously in rituals to beckon the moon. Uncover their secrets.
+var MoonPhase = []rune("🌘🌗🌖🌕🌔🌓🌒🌑")
+var MoonPhaseLength = len(MoonPhase)
+
+// var consoleLogPrefix defines the substring prefixes included in log messages
+// to help visually grep for anything you might find significant.
+var (
+	consoleLogPrefix = [liCOUNT]string{
+		"",    // liRaw
+		"   ", // liInfo
+		" » ", // liWarn
+		" × ", // liError
+	}
+	// prefixes used in place of the above in accessible mode. symbols are
+	// either skipped or read aloud awkwardly by screen readers, so every
+	// message is instead labeled with a plain word.
+	accessibleLogPrefix = [liCOUNT]string{
+		"",          // liRaw
+		"info: ",    // liInfo
+		"warning: ", // liWarn
+		"error: ",   // liError
+	}
+)
+
+// var consoleLog defines each of our loggers. the output of the program itself
+// (see Raw) is always logged, like errors.
+var consoleLog = [liCOUNT]*Logger{
+	// Raw:
+	newLogger(
+		consoleLogPrefix[liRaw],
+		os.Stdout,
+		LevelError,
+		log.New(os.Stdout, consoleLogPrefix[liRaw], 0)),
+	// Info:
+	newLogger(
+		consoleLogPrefix[liInfo],
+		os.Stdout,
+		LevelInfo,
+		log.New(os.Stdout, consoleLogPrefix[liInfo], logFlags)),
+	// Warn:
+	newLogger(
+		consoleLogPrefix[liWarn],
+		os.Stderr,
+		LevelWarn,
+		log.New(os.Stderr, consoleLogPrefix[liWarn], logFlags)),
+	// Error:
+	newLogger(
+		consoleLogPrefix[liError],
+		os.Stderr,
+		LevelError,
+		log.New(os.Stderr, consoleLogPrefix[liError], logFlags)),
+}
+
+// single instantiation of each of the loggers for all goroutines to share
+// indirectly through use of the exported subroutines below.
+var (
+	// levels used by loggers -only- for determining verbosity, guarded by
+	// levelLock.
+	logLevel       = LevelInfo
+	componentLevel = map[string]Level{}
+	levelLock      sync.RWMutex
+
+	// every Component registered, by name.
+	components     = map[string]*Component{}
+	componentsLock sync.Mutex
+
+	areOptionsParsed bool
+	isAccessible     bool
+
+	Raw   *Logger = consoleLog[liRaw]
+	Info  *Logger = consoleLog[liInfo]
+	Warn  *Logger = consoleLog[liWarn]
+	Error *Logger = consoleLog[liError]
+)
+
+// function Register() returns the loggers of the Component with the given name,
+// creating them if not yet registered. packages register their components
+// when initialized, so that their names are known by the time the levels are
+// parsed (see ParseLevels()).
+func Register(name string) *Component {
+
+	componentsLock.Lock()
+	defer componentsLock.Unlock()
+
+	if c, ok := components[name]; ok {
+		return c
+	}
+	derive := func(parent *Logger) *Logger {
+		return &Logger{
+			prefix:    parent.prefix,
+			level:     parent.level,
+			component: name,
+			parent:    parent,
+			Mutex:     parent.Mutex,
+		}
+	}
+	c := &Component{
+		Name:  name,
+		Raw:   derive(Raw),
+		Info:  derive(Info),
+		Warn:  derive(Warn),
+		Error: derive(Error),
+	}
+	components[name] = c
+	return c
+}
+
+// function Components() returns the names of every Component registered, in
+// sorted order.
+func Components() []string {
+	componentsLock.Lock()
+	defer componentsLock.Unlock()
+	name := make([]string, 0, len(components))
+	for n := range components {
+		name = append(name, n)
+	}
+	sort.Strings(name)
+	return name
+}
+
+// function String() returns the name of the Level, e.g. "warn".
+func (v Level) String() string {
+	if v < 0 || v >= LevelCOUNT {
+		return fmt.Sprintf("Level(%d)", int(v))
+	}
+	return levelName[v]
+}
+
+// function ParseLevel() returns the Level with the given name
+// (case-insensitive). returns false if there is no such Level.
+func ParseLevel(name string) (Level, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, n := range levelName {
+		if n == name {
+			return Level(i), true
+		}
+	}
+	return LevelInfo, false
+}
+
+// function ParseLevels() parses a comma-separated list of levels, e.g.
+// "warn,scan=trace,db=error": the name of a Level alone is the level of every
+// message, and the pairs "component=level" override it for the messages of
+// the named Component. the default level is LevelInfo.
+func ParseLevels(spec string) (Level, map[string]Level, *rc.ReturnCode) {
+
+	level, filter := LevelInfo, map[string]Level{}
+	for _, f := range strings.Split(spec, ",") {
+		if f = strings.TrimSpace(f); "" == f {
+			continue
+		}
+		name, value := "", f
+		if i := strings.Index(f, "="); i >= 0 {
+			name, value = strings.TrimSpace(f[:i]), f[i+1:]
+		}
+		v, ok := ParseLevel(value)
+		if !ok {
+			return level, nil, rc.InvalidArgs.Specf("ParseLevels(%q): unknown level %q (expected one of: %s)",
+				spec, value, strings.Join(levelName[:], ", "))
+		}
+		if "" == name {
+			level = v
+			continue
+		}
+		componentsLock.Lock()
+		_, known := components[name]
+		componentsLock.Unlock()
+		if !known {
+			return level, nil, rc.InvalidArgs.Specf("ParseLevels(%q): unknown component %q (expected one of: %s)",
+				spec, name, strings.Join(Components(), ", "))
+		}
+		filter[name] = v
+	}
+	return level, filter, nil
+}
+
+// function SetLevel() updates the loggers' verbosity settings: only messages at
+// most as detailed as the given level are logged, or as the level given by
+// filter for the messages of the Component of that name. without options
+// parsed, we cannot know where to print any status or other info, so we always
+// print everything to the console until this has been called.
+func SetLevel(level Level, filter map[string]Level) {
+	levelLock.Lock()
+	logLevel = level
+	componentLevel = map[string]Level{}
+	for name, v := range filter {
+		componentLevel[name] = v
+	}
+	levelLock.Unlock()
+	areOptionsParsed = true
+}
+
+// function SetAccessible() switches all of the loggers into (or out of)
+// accessible mode, in which messages are labeled with words instead of symbols
+// and are not timestamped, so that they read clearly with a screen reader.
+func SetAccessible(accessible bool) {
+	isAccessible = accessible
+	for i, c := range consoleLog {
+		prefix, flags := consoleLogPrefix[i], logFlags
+		if accessible {
+			prefix, flags = accessibleLogPrefix[i], 0
+		}
+		if LogID(i) == liRaw {
+			flags = 0
+		}
+		c.Lock()
+		c.prefix = prefix
+		c.Logger = log.New(c.writer, prefix, flags)
+		c.Unlock()
+	}
+}
+
+// function IsAccessible() returns true if and only if accessible mode is set.
+func IsAccessible() bool {
+	return isAccessible
+}
+
+// function IsVerbose() returns true if and only if debug messages are logged
+// (by the loggers of any Component).
+func IsVerbose() bool {
+	return maxLevel() >= LevelDebug
+}
+
+// function IsTrace() returns true if and only if trace messages are logged (by
+// the loggers of any Component).
+func IsTrace() bool {
+	return maxLevel() >= LevelTrace
+}
+
+// function maxLevel() returns the most detailed level set for any messages.
+func maxLevel() Level {
+	levelLock.RLock()
+	defer levelLock.RUnlock()
+	max := logLevel
+	for _, v := range componentLevel {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// function newLogger() creates a new Logger struct with the given args as
+// fields and a new sync.Mutex semaphore all its very own.
+func newLogger(prefix string, writer io.Writer, level Level, logger *log.Logger) *Logger {
+	return &Logger{
+		prefix:  prefix,
+		console: writer, // retain this as a fallback, don't ever overwrite.
+		writer:  writer,
+		level:   level,
+		Logger:  logger,
+		Mutex:   new(sync.Mutex),
+	}
+}
+
+// function enabled() returns true if messages of the given level are logged by
+// the Logger.
+func (l *Logger) enabled(level Level) bool {
+	if !areOptionsParsed {
+		return true
+	}
+	levelLock.RLock()
+	defer levelLock.RUnlock()
+	max := logLevel
+	if v, ok := componentLevel[l.component]; ok && "" != l.component {
+		max = v
+	}
+	return level <= max
+}
+
+// function SetWriter() changes the log writer to anything conforming to the
+// io.Writer interface. this may be a file, I/O stream, ncurses panel, etc. the
+// logger of a Component changes that of the built-in logger it writes through.
+func (l *Logger) SetWriter(w io.Writer) {
+	if nil != l.parent {
+		l.parent.SetWriter(w)
+		return
+	}
+	if l.writer != w {
+		l.Lock()
+		l.writer = w
+		l.Logger = log.New(w, l.prefix, l.Flags())
+		l.Unlock()
+	}
+}
+
+// function SetWriterAll() changes the log writer using the SetWriter() method
+// defined above for all standard Loggers.
+func SetWriterAll(w io.Writer) {
+	for _, c := range consoleLog {
+		c.SetWriter(w)
+	}
+}
+
+// function ResetWriter() changes the log writer using the SetWriter() method
+// defined above to the default console IO stream. this is useful for returning
+// a logger back to the shell session from which it launched.
+func (l *Logger) ResetWriter() {
+	if nil != l.parent {
+		l.parent.ResetWriter()
+		return
+	}
+	l.SetWriter(l.console)
+}
+
+// function ResetWriterAll() restores all of the log writers back to their
+// default console IO stream. see function ResetWriter().
+func ResetWriterAll() {
+	for _, c := range consoleLog {
+		c.ResetWriter()
+	}
+}
+
+// function output() outputs a given string s, with an optional delimiter d,
+// using the current properties of the target logger. this function is the final
+// stop in the call stack for all of the logging subroutines exported by this
+// unit, so any global formatting or handling should be performed here.
+func (l *Logger) output(d, s string) {
+	if nil != l.parent {
+		l.parent.output(d, s)
+		return
+	}
+	if true /* toggles printing globally */ {
+		if l != Raw && !isAccessible {
+			if d == "" {
+				d = logDelimNormal
+			}
+			s = fmt.Sprintf("%s%s", d, s)
+		}
+		l.Print(s)
+	}
+}
+
+// function Log() outputs a given string using the current properties of the
+// logger and each of the variable-number-of arguments.
+func (l *Logger) Log(v ...interface{}) {
+	if l.enabled(l.level) {
+		s := fmt.Sprint(v...)
+		l.output(logDelimNormal, s)
+	}
+}
+
+// function Logf() outputs a given string using the current properties of the
+// logger and any specified printf-style format string + arguments.
+func (l *Logger) Logf(format string, v ...interface{}) {
+	if l.enabled(l.level) {
+		s := fmt.Sprintf(format, v...)
+		l.output(logDelimNormal, s)
+	}
+}
+
+// function Verbose() is a wrapper for function Log() that will prevent the
+// data from being output unless debug messages are logged.
+func (l *Logger) Verbose(v ...interface{}) {
+	if l.enabled(LevelDebug) {
+		s := fmt.Sprint(v...)
+		l.output(logDelimVerbose, s)
+	}
+}
+
+// function Verbosef() is a wrapper for function Logf() that will prevent the
+// data from being output unless debug messages are logged.
+func (l *Logger) Verbosef(format string, v ...interface{}) {
+	if l.enabled(LevelDebug) {
+		s := fmt.Sprintf(format, v...)
+		l.output(logDelimVerbose, s)
+	}
+}
+
+// function Trace() is a wrapper for function Log() that will prevent the
+// data from being output unless trace messages are logged.
+func (l *Logger) Trace(v ...interface{}) {
+	if l.enabled(LevelTrace) {
+		s := fmt.Sprint(v...)
+		l.output(logDelimTrace, s)
+	}
+}
+
+// function Tracef() is a wrapper for function Logf() that will prevent the
+// data from being output unless trace messages are logged.
+func (l *Logger) Tracef(format string, v ...interface{}) {
+	if l.enabled(LevelTrace) {
+		s := fmt.Sprintf(format, v...)
+		l.output(logDelimTrace, s)
+	}
+}
+
+// function LogStackTrace() prints the entire stack trace
+func (l *Logger) LogStackTrace() {
+	byt := debug.Stack()
+	str := string(byt[:])
+	res := regexp.MustCompile("[\\r\\n]+").Split(str, -1)
+
+	for n, s := range res[:len(res)-1] {
+		l.Logf("%d: %s", n, s)
+	}
+}
+
+// function Die() outputs the details of a given ReturnCode object, and then
+// terminates program execution with the ReturnCode object's return value. the
+// output from this method is always printed to the terminal regardless of
+// whichever io.Writer was defined for the logger.
+func (l *Logger) Die(c *rc.ReturnCode, trace bool) {
+	l.ResetWriter()
+	if !errors.Is(c, rc.Usage) {
+		s := fmt.Sprintf("%s", error(c))
+		l.output("", s)
+		if trace && l.enabled(LevelTrace) {
+			l.LogStackTrace()
+		}
+	}
+	os.Exit(c.Code())
+}
@@ -0,0 +1,81 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: contenthash.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    computes the fast content hashes of media files, by which copies of the
+//    same file are recognized wherever they reside.
+//
+// =============================================================================
+
+// package contenthash computes a fast (non-cryptographic) hash of the content
+// of each media file when it is scanned, so that identical files are found
+// across libraries by comparing records instead of reading every file again.
+// the hash is xxHash (XXH64), which is limited by the speed of the disk rather
+// than the CPU, even on a Raspberry Pi.
+//
+// large files may be hashed partially: only their first and last bytes, along
+// with their size. this reads a small fraction of a video, and distinct media
+// files practically never agree in all three. a hash records how it was
+// computed, so whole and partial hashes (or partial hashes of different
+// lengths) never compare equal, even of identical files.
+package contenthash
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cespare/xxhash/v2"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant DefaultPartial is the default number of bytes hashed at each end of
+// a large file (see Sum()).
+const DefaultPartial = 16 << 20
+
+// function Sum() returns the content hash of the file at the given path. if
+// partial is greater than 0 and the file is more than twice that size, only
+// the given number of bytes at the start and at the end of the file are hashed,
+// along with its size; otherwise the entire file is hashed.
+func Sum(path string, partial int64) (string, *rc.ReturnCode) {
+
+	f, err := os.Open(path)
+	if nil != err {
+		return "", rc.InvalidFile.Specf("contenthash.Sum(%q): os.Open(): %s", path, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if nil != err {
+		return "", rc.InvalidStat.Specf("contenthash.Sum(%q): os.Stat(): %s", path, err)
+	}
+
+	h := xxhash.New()
+	size := info.Size()
+	if partial <= 0 || size <= 2*partial {
+		if _, err := io.Copy(h, f); nil != err {
+			return "", rc.InvalidFile.Specf("contenthash.Sum(%q): %s", path, err)
+		}
+		return fmt.Sprintf("xxh64:%016x", h.Sum64()), nil
+	}
+
+	if _, err := io.CopyN(h, f, partial); nil != err {
+		return "", rc.InvalidFile.Specf("contenthash.Sum(%q): %s", path, err)
+	}
+	if _, err := f.Seek(-partial, io.SeekEnd); nil != err {
+		return "", rc.InvalidFile.Specf("contenthash.Sum(%q): %s", path, err)
+	}
+	if _, err := io.CopyN(h, f, partial); nil != err {
+		return "", rc.InvalidFile.Specf("contenthash.Sum(%q): %s", path, err)
+	}
+	// files differing only in the middle must at least differ in size.
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(size))
+	h.Write(n[:])
+	return fmt.Sprintf("xxh64/%d:%016x", partial, h.Sum64()), nil
+}
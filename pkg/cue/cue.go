@@ -0,0 +1,268 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: cue.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    parses cue sheets: the text files describing the tracks contained in a
+//    single audio file, as written by CD rippers ripping a whole disc at once.
+//
+// =============================================================================
+
+// package cue parses cue sheets, which describe the layout of the tracks of an
+// album ripped to a single audio "image" file (FLAC, APE, WAV, etc.), e.g.:
+//
+//	PERFORMER "Artist"
+//	TITLE "Album"
+//	FILE "Album.flac" WAVE
+//	  TRACK 01 AUDIO
+//	    TITLE "First Track"
+//	    INDEX 01 00:00:00
+//	  TRACK 02 AUDIO
+//	    TITLE "Second Track"
+//	    INDEX 00 04:11:60
+//	    INDEX 01 04:13:12
+//
+// times are given as minutes, seconds, and frames, of which there are 75 per
+// second (the sectors of a CD). a track starts at its INDEX 01; the pregap
+// before it (from INDEX 00) belongs to the track preceding it.
+package cue
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant FramesPerSecond is the number of frames in each second of a cue
+// sheet's times.
+const FramesPerSecond = 75
+
+// type Track is a single track of a cue sheet.
+type Track struct {
+	Number    int64         // number of the track on the album
+	Title     string        // title of the track
+	Performer string        // performer of the track, or else of the album
+	Start     time.Duration // offset into the file at which the track starts (INDEX 01)
+}
+
+// type File is an audio file of a cue sheet, and the tracks it contains.
+type File struct {
+	Name   string  // name of the file, usually relative to the cue sheet
+	Type   string  // format of the file, e.g. "WAVE" or "MP3"
+	Tracks []Track // tracks contained in the file, in order
+}
+
+// type Sheet is the content of a cue sheet.
+type Sheet struct {
+	Title     string // title of the album
+	Performer string // performer of the album
+	Files     []File // audio files the tracks are contained in, in order
+}
+
+// function Read() reads the cue sheet at the given path (see Parse()).
+func Read(path string) (*Sheet, *rc.ReturnCode) {
+
+	f, err := os.Open(path)
+	if nil != err {
+		return nil, rc.InvalidFile.Specf("cue.Read(%q): os.Open(): %s", path, err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// function Parse() reads a cue sheet from the given reader. only the commands
+// describing the files and tracks are interpreted (FILE, TRACK, INDEX, TITLE,
+// PERFORMER); the rest (REM, FLAGS, ISRC, etc.) are ignored. tracks that aren't
+// audio (e.g. the data track of an enhanced CD) are skipped. it is an error if
+// the sheet has no audio tracks at all, or a track without a start.
+func Parse(r io.Reader) (*Sheet, *rc.ReturnCode) {
+
+	sheet := &Sheet{Files: []File{}}
+	var (
+		file  *File  // file currently being described
+		track *Track // track currently being described, nil if not audio
+		start bool   // the current track's start (INDEX 01) was found
+		other bool   // the current track isn't audio, so is being skipped
+	)
+	// each track is added to its file once it is completely described.
+	flush := func() *rc.ReturnCode {
+		if nil != track {
+			if !start {
+				return rc.InvalidFile.Specf("cue.Parse(): track %02d has no INDEX 01", track.Number)
+			}
+			if "" == track.Performer {
+				track.Performer = sheet.Performer
+			}
+			file.Tracks = append(file.Tracks, *track)
+		}
+		track, start, other = nil, false, false
+		return nil
+	}
+
+	scan := bufio.NewScanner(r)
+	first := true
+	for num := 1; scan.Scan(); num++ {
+		line := scan.Text()
+		if first {
+			line, first = strings.TrimPrefix(line, "\ufeff"), false
+		}
+		cmd, args := fields(line)
+		switch strings.ToUpper(cmd) {
+		case "FILE":
+			if ret := flush(); nil != ret {
+				return nil, ret
+			}
+			if len(args) < 1 {
+				return nil, rc.InvalidFile.Specf("cue.Parse(): line %d: FILE without name", num)
+			}
+			f := File{Name: args[0], Tracks: []Track{}}
+			if len(args) > 1 {
+				f.Type = strings.ToUpper(args[1])
+			}
+			sheet.Files = append(sheet.Files, f)
+			file = &sheet.Files[len(sheet.Files)-1]
+
+		case "TRACK":
+			if ret := flush(); nil != ret {
+				return nil, ret
+			}
+			if nil == file {
+				return nil, rc.InvalidFile.Specf("cue.Parse(): line %d: TRACK before FILE", num)
+			}
+			if len(args) < 2 {
+				return nil, rc.InvalidFile.Specf("cue.Parse(): line %d: malformed TRACK", num)
+			}
+			n, err := strconv.ParseInt(args[0], 10, 64)
+			if nil != err {
+				return nil, rc.InvalidFile.Specf("cue.Parse(): line %d: invalid track number: %q", num, args[0])
+			}
+			if "AUDIO" == strings.ToUpper(args[1]) {
+				track = &Track{Number: n}
+			} else {
+				other = true
+			}
+
+		case "INDEX":
+			if nil == track || len(args) < 2 {
+				continue
+			}
+			if n, err := strconv.Atoi(args[0]); nil != err || 1 != n {
+				continue // only the start of the track is of interest
+			}
+			t, ok := parseTime(args[1])
+			if !ok {
+				return nil, rc.InvalidFile.Specf("cue.Parse(): line %d: invalid time: %q", num, args[1])
+			}
+			track.Start, start = t, true
+
+		case "TITLE", "PERFORMER":
+			if len(args) < 1 {
+				continue
+			}
+			if other {
+				continue // describes a track that isn't audio
+			}
+			// before the first track, these describe the album.
+			title, performer := &sheet.Title, &sheet.Performer
+			if nil != track {
+				title, performer = &track.Title, &track.Performer
+			}
+			if "TITLE" == strings.ToUpper(cmd) {
+				*title = args[0]
+			} else {
+				*performer = args[0]
+			}
+		}
+	}
+	if err := scan.Err(); nil != err {
+		return nil, rc.InvalidFile.Specf("cue.Parse(): %s", err)
+	}
+	if ret := flush(); nil != ret {
+		return nil, ret
+	}
+	if 0 == sheet.NumTracks() {
+		return nil, rc.InvalidFile.Spec("cue.Parse(): no audio tracks")
+	}
+	return sheet, nil
+}
+
+// function NumTracks() returns the number of audio tracks in every file of the
+// cue sheet.
+func (s *Sheet) NumTracks() int {
+	n := 0
+	for _, f := range s.Files {
+		n += len(f.Tracks)
+	}
+	return n
+}
+
+// function End() returns the offset into its file at which the track with the
+// given index in the file's list of tracks ends, i.e. where the next track
+// starts, or 0 if it is the last track (ending with the file).
+func (f *File) End(index int) time.Duration {
+	if index+1 < len(f.Tracks) {
+		return f.Tracks[index+1].Start
+	}
+	return 0
+}
+
+// function fields() splits a line of a cue sheet into its command and
+// arguments, separated by white space. an argument may be quoted to contain
+// white space.
+func fields(line string) (string, []string) {
+
+	list := []string{}
+	for line = strings.TrimSpace(line); "" != line; line = strings.TrimSpace(line) {
+		if '"' == line[0] {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				// unterminated, so the rest of the line is taken as quoted.
+				list, line = append(list, line[1:]), ""
+				continue
+			}
+			list, line = append(list, line[1:end+1]), line[end+2:]
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			end = len(line)
+		}
+		list, line = append(list, line[:end]), line[end:]
+	}
+	if 0 == len(list) {
+		return "", list
+	}
+	return list[0], list[1:]
+}
+
+// function parseTime() returns the duration of the given time of a cue sheet,
+// formatted mm:ss:ff (minutes, seconds, frames). returns false if the time is
+// malformed.
+func parseTime(s string) (time.Duration, bool) {
+
+	part := strings.Split(s, ":")
+	if 3 != len(part) {
+		return 0, false
+	}
+	n := [3]int{}
+	for i, p := range part {
+		v, err := strconv.Atoi(p)
+		if nil != err || v < 0 {
+			return 0, false
+		}
+		n[i] = v
+	}
+	if n[1] >= 60 || n[2] >= FramesPerSecond {
+		return 0, false
+	}
+	return time.Duration(n[0])*time.Minute + time.Duration(n[1])*time.Second +
+		time.Duration(n[2])*time.Second/FramesPerSecond, true
+}
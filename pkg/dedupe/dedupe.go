@@ -0,0 +1,253 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: dedupe.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    finds media files that are byte-identical copies of each other and
+//    replaces the copies with hard links to a single file.
+//
+// =============================================================================
+
+// package dedupe reclaims the space used by duplicate media files. files with
+// identical content on the same file system are replaced by hard links to one
+// of them, so that every path remains valid while the content is stored once.
+package dedupe
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant linkSuffix is appended to the name of the temporary hard link made
+// beside each copy before it replaces the copy.
+const linkSuffix = ".pimmp-link"
+
+// type File is a single file considered for deduplication.
+type File struct {
+	Path string // absolute path to the file
+	Size int64  // length of the file in bytes
+}
+
+// type Group is a set of files with identical content on the same file system.
+type Group struct {
+	Keep   string   // the file kept, to which the copies are linked
+	Copies []string // the files replaced by hard links to Keep
+	Size   int64    // length in bytes of each file
+}
+
+// function Reclaimed() returns the number of bytes freed by replacing the
+// Group's copies with hard links.
+func (g *Group) Reclaimed() int64 {
+	return g.Size * int64(len(g.Copies))
+}
+
+// function Find() returns each Group of the given files having identical
+// content and residing on the same file system. files that are already hard
+// links to the same content are never reported as copies of one another. the
+// file kept in each group is the first by path. problems reading individual
+// files are returned, and those files omitted.
+func Find(files []File) ([]*Group, []*rc.ReturnCode) {
+
+	type key struct {
+		dev  uint64
+		size int64
+	}
+
+	problems := []*rc.ReturnCode{}
+
+	// only files of the same size on the same device can possibly be linked, so
+	// the content is compared only within those candidates.
+	candidate := map[key][]string{}
+	for _, f := range files {
+		// empty files are all the same, but linking them reclaims nothing.
+		if f.Size <= 0 {
+			continue
+		}
+		dev, ok := platform.DeviceID(f.Path)
+		if !ok {
+			problems = append(problems, rc.InvalidStat.Specf(
+				"Find(): cannot determine device of file: %q", f.Path))
+			continue
+		}
+		k := key{dev, f.Size}
+		candidate[k] = append(candidate[k], f.Path)
+	}
+
+	group := []*Group{}
+	for k, path := range candidate {
+		if len(path) < 2 {
+			continue
+		}
+		sort.Strings(path)
+		found, prob := identical(path)
+		problems = append(problems, prob...)
+		for _, same := range found {
+			group = append(group, &Group{Keep: same[0], Copies: same[1:], Size: k.size})
+		}
+	}
+
+	sort.Slice(group, func(a, b int) bool { return group[a].Keep < group[b].Keep })
+	return group, problems
+}
+
+// function identical() partitions the given paths (sorted, all of the same
+// size) by their content, returning each partition of two or more files whose
+// content is not already shared via hard links.
+func identical(path []string) ([][]string, []*rc.ReturnCode) {
+
+	problems := []*rc.ReturnCode{}
+
+	// files that are already the same file (hard links) are collapsed into one,
+	// using the first path seen.
+	unique := []string{}
+	info := []os.FileInfo{}
+	for _, p := range path {
+		fi, err := os.Stat(p)
+		if nil != err {
+			problems = append(problems, rc.InvalidStat.Specf("identical(): os.Stat(): %s", err))
+			continue
+		}
+		linked := false
+		for _, seen := range info {
+			if os.SameFile(seen, fi) {
+				linked = true
+				break
+			}
+		}
+		if !linked {
+			unique = append(unique, p)
+			info = append(info, fi)
+		}
+	}
+
+	digest := map[string][]string{}
+	order := []string{}
+	for _, p := range unique {
+		sum, ret := checksum(p)
+		if nil != ret {
+			problems = append(problems, ret)
+			continue
+		}
+		if _, ok := digest[sum]; !ok {
+			order = append(order, sum)
+		}
+		digest[sum] = append(digest[sum], p)
+	}
+
+	same := [][]string{}
+	for _, sum := range order {
+		if len(digest[sum]) > 1 {
+			same = append(same, digest[sum])
+		}
+	}
+	return same, problems
+}
+
+// function checksum() returns the SHA-256 digest of the content of the file at
+// the given path.
+func checksum(path string) (string, *rc.ReturnCode) {
+
+	f, err := os.Open(path)
+	if nil != err {
+		return "", rc.InvalidFile.Specf("checksum(%q): os.Open(): %s", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); nil != err {
+		return "", rc.InvalidFile.Specf("checksum(%q): %s", path, err)
+	}
+	return string(h.Sum(nil)), nil
+}
+
+// function Link() replaces the file at path dup with a hard link to the file
+// at path keep, after verifying once more that their content is identical. the
+// copy is replaced atomically: a hard link is first made beside it, and then
+// renamed over it, so the copy's path never ceases to exist. nothing is done
+// if they are already the same file.
+func Link(keep, dup string) *rc.ReturnCode {
+
+	keepInfo, err := os.Stat(keep)
+	if nil != err {
+		return rc.InvalidFile.Specf("Link(%q, %q): os.Stat(): %s", keep, dup, err)
+	}
+	dupInfo, err := os.Stat(dup)
+	if nil != err {
+		return rc.InvalidFile.Specf("Link(%q, %q): os.Stat(): %s", keep, dup, err)
+	}
+	// renaming a link over another link to the same file does nothing at all,
+	// which would leave the temporary link behind.
+	if os.SameFile(keepInfo, dupInfo) {
+		return nil
+	}
+
+	same, ret := sameContent(keep, dup)
+	if nil != ret {
+		return ret
+	}
+	if !same {
+		return rc.InvalidFile.Specf("Link(%q, %q): content differs", keep, dup)
+	}
+
+	tmp := filepath.Join(filepath.Dir(dup), "."+filepath.Base(dup)+linkSuffix)
+	// a link left behind by an interrupted run is only ever a link, never the
+	// file itself, so it is safe to remove.
+	if err := os.Remove(tmp); nil != err && !os.IsNotExist(err) {
+		return rc.InvalidFile.Specf("Link(%q, %q): os.Remove(): %s", keep, dup, err)
+	}
+	if err := os.Link(keep, tmp); nil != err {
+		return rc.InvalidFile.Specf("Link(%q, %q): os.Link(): %s", keep, dup, err)
+	}
+	if err := os.Rename(tmp, dup); nil != err {
+		os.Remove(tmp)
+		return rc.InvalidFile.Specf("Link(%q, %q): os.Rename(): %s", keep, dup, err)
+	}
+	return nil
+}
+
+// function sameContent() compares the content of the two given files byte for
+// byte. the files may have been modified since their digests were computed.
+func sameContent(a, b string) (bool, *rc.ReturnCode) {
+
+	fa, err := os.Open(a)
+	if nil != err {
+		return false, rc.InvalidFile.Specf("sameContent(%q): os.Open(): %s", a, err)
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if nil != err {
+		return false, rc.InvalidFile.Specf("sameContent(%q): os.Open(): %s", b, err)
+	}
+	defer fb.Close()
+
+	const bufSize = 64 * 1024
+	ba, bb := make([]byte, bufSize), make([]byte, bufSize)
+	for {
+		na, errA := io.ReadFull(fa, ba)
+		nb, errB := io.ReadFull(fb, bb)
+		if na != nb || !bytes.Equal(ba[:na], bb[:nb]) {
+			return false, nil
+		}
+		endA := io.EOF == errA || io.ErrUnexpectedEOF == errA
+		endB := io.EOF == errB || io.ErrUnexpectedEOF == errB
+		if nil != errA && !endA {
+			return false, rc.InvalidFile.Specf("sameContent(%q): %s", a, errA)
+		}
+		if nil != errB && !endB {
+			return false, rc.InvalidFile.Specf("sameContent(%q): %s", b, errB)
+		}
+		if endA || endB {
+			return endA && endB, nil
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: ebook.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    reads the metadata of ebooks and comics: the title, author, and series of
+//    each, and the number of pages where the format records it.
+//
+// =============================================================================
+
+// package ebook reads the metadata of documents, so that the records of
+// document media describe more than their file names. each format keeps it
+// differently:
+//
+//	EPUB       the OPF package document named by META-INF/container.xml
+//	CBZ        ComicInfo.xml, if present; the pages are the images archived
+//	PDF        the document information dictionary, and the page tree
+//	MOBI, AZW  the EXTH header following the MOBI header
+//
+// series are read from the calibre metadata of EPUB files (or the EPUB 3
+// collection properties), and from ComicInfo.xml. formats not listed (CBR,
+// DjVu, etc.) are recognized as documents by pimmp, but only by file name.
+package ebook
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Info is the metadata read from a document. fields the document doesn't
+// define are left zero.
+type Info struct {
+	Title       string  // title of the document
+	Author      string  // author of the document, or all of them separated by commas
+	Series      string  // name of the series the document belongs to
+	SeriesIndex float64 // position in the series, 0 if unknown
+	Pages       int     // number of pages, 0 if unknown
+	Year        int     // year the document was published, 0 if unknown
+}
+
+// function Supported() returns true if metadata can be read from files with
+// the given file name extension.
+func Supported(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".epub", ".cbz", ".pdf", ".mobi", ".prc", ".azw", ".azw3":
+		return true
+	}
+	return false
+}
+
+// function Read() reads the metadata of the document at the given path. a
+// document without any metadata is not an error, as long as its format could
+// be read.
+func Read(path string) (*Info, *rc.ReturnCode) {
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if !Supported(ext) {
+		return nil, rc.MetadataError.Specf("ebook.Read(%q): unsupported file type", path)
+	}
+	f, err := os.Open(path)
+	if nil != err {
+		return nil, rc.MetadataError.Specf("ebook.Read(%q): %s", path, err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if nil != err {
+		return nil, rc.MetadataError.Specf("ebook.Read(%q): %s", path, err)
+	}
+
+	info := &Info{}
+	switch ext {
+	case ".epub":
+		err = readEPUB(f, stat.Size(), info)
+	case ".cbz":
+		err = readCBZ(f, stat.Size(), info)
+	case ".pdf":
+		err = readPDF(f, info)
+	default:
+		err = readMOBI(f, info)
+	}
+	if nil != err {
+		return nil, rc.MetadataError.Specf("ebook.Read(%q): %s", path, err)
+	}
+	info.Title = strings.TrimSpace(info.Title)
+	info.Author = strings.TrimSpace(info.Author)
+	info.Series = strings.TrimSpace(info.Series)
+	return info, nil
+}
+
+// function parseYear() returns the year at the start of the given date, e.g.
+// 2019 for "2019-06-01", or 0 if it doesn't start with one.
+func parseYear(date string) int {
+	date = strings.TrimSpace(date)
+	if len(date) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(date[:4])
+	if nil != err || year <= 0 {
+		return 0
+	}
+	return year
+}
+
+// function parseIndex() returns the position in a series given by the given
+// text, e.g. 3 for "3" or 1.5 for "1.5", or 0 if it isn't a number.
+func parseIndex(s string) float64 {
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if nil != err || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// function joinNames() joins the given names of authors into the single
+// string of an Info, skipping any that are empty or repeated.
+func joinNames(names []string) string {
+	list := []string{}
+	seen := map[string]bool{}
+	for _, n := range names {
+		n = strings.Join(strings.Fields(n), " ")
+		if "" == n || seen[strings.ToLower(n)] {
+			continue
+		}
+		seen[strings.ToLower(n)] = true
+		list = append(list, n)
+	}
+	return strings.Join(list, ", ")
+}
+
+// type formatError is a malformed structure found in a document.
+type formatError string
+
+// function Error() returns the description of the formatError.
+func (e formatError) Error() string { return "malformed " + string(e) }
+
+// function malformed() returns a formatError naming the given structure.
+func malformed(what string) error { return formatError(what) }
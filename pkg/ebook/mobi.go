@@ -0,0 +1,107 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: mobi.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    reads the metadata of Mobipocket ebooks, including Kindle's AZW formats:
+//    the title and author of the EXTH header.
+//
+// =============================================================================
+
+package ebook
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// local unexported constants for the MOBI reader.
+const (
+	mobiRecord0   = 78      // offset of the first entry of the PalmDB record list
+	mobiMaxHeader = 1 << 16 // bytes of the first record read
+	mobiHasEXTH   = 0x40    // flag of the MOBI header indicating an EXTH header
+	mobiUTF8      = 65001   // text encoding of the MOBI header meaning UTF-8
+)
+
+// the types of EXTH records read.
+const (
+	exthAuthor      = 100
+	exthPublishDate = 106
+	exthTitle       = 503
+)
+
+// function readMOBI() reads the metadata of the Mobipocket file from the given
+// reader into the given Info. the first record of the PalmDB file starts with
+// a PalmDOC header (16 bytes) followed by the MOBI header, then the EXTH
+// header if it has one.
+func readMOBI(r io.ReaderAt, info *Info) error {
+
+	head := make([]byte, mobiRecord0+4)
+	if _, err := r.ReadAt(head, 0); nil != err {
+		return err
+	}
+	// the PalmDB type and creator identify the format.
+	switch string(head[60:68]) {
+	case "BOOKMOBI", "TEXtREAd":
+	default:
+		return malformed("MOBI header")
+	}
+	offset := int64(binary.BigEndian.Uint32(head[mobiRecord0:]))
+	rec := make([]byte, mobiMaxHeader)
+	n, err := r.ReadAt(rec, offset)
+	if nil != err && io.EOF != err {
+		return err
+	}
+	rec = rec[:n]
+	if len(rec) < 132 || "MOBI" != string(rec[16:20]) {
+		return nil // a plain PalmDOC book has no metadata but its name
+	}
+	be := binary.BigEndian
+	utf8 := mobiUTF8 == be.Uint32(rec[28:])
+	text := func(b []byte) string {
+		if utf8 {
+			return string(b)
+		}
+		// Windows-1252 mostly, which is treated as Latin-1.
+		s := make([]rune, len(b))
+		for i, c := range b {
+			s[i] = rune(c)
+		}
+		return string(s)
+	}
+
+	// the full name is the title, unless an EXTH record updates it.
+	if start, size := int(be.Uint32(rec[84:])), int(be.Uint32(rec[88:])); start+size <= len(rec) {
+		info.Title = text(rec[start : start+size])
+	}
+	if 0 == be.Uint32(rec[128:])&mobiHasEXTH {
+		return nil
+	}
+	exth := 16 + int(be.Uint32(rec[20:]))
+	if exth+12 > len(rec) || "EXTH" != string(rec[exth:exth+4]) {
+		return malformed("EXTH header")
+	}
+	authors := []string{}
+	pos := exth + 12
+	for i := be.Uint32(rec[exth+8:]); i > 0 && pos+8 <= len(rec); i-- {
+		typ, size := be.Uint32(rec[pos:]), int(be.Uint32(rec[pos+4:]))
+		if size < 8 || pos+size > len(rec) {
+			return malformed("EXTH record")
+		}
+		data := rec[pos+8 : pos+size]
+		switch typ {
+		case exthAuthor:
+			authors = append(authors, text(data))
+		case exthTitle:
+			info.Title = text(data)
+		case exthPublishDate:
+			info.Year = parseYear(string(data))
+		}
+		pos += size
+	}
+	info.Author = joinNames(authors)
+	return nil
+}
@@ -0,0 +1,257 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: pdf.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    reads the metadata of PDF documents: the title and author of the document
+//    information dictionary, and the number of pages of the page tree.
+//
+// =============================================================================
+
+package ebook
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+)
+
+// local unexported constants for the PDF reader.
+const (
+	pdfChunk   = 1 << 20  // bytes of the file searched at once
+	pdfOverlap = 64 << 10 // bytes of each chunk searched again with the next
+	pdfMaxDict = 16 << 10 // longest information dictionary read
+)
+
+// the patterns sought in PDF files. objects are found by their text, without
+// parsing the cross-reference table, so objects compressed into object streams
+// (PDF 1.5 and later) are not found. this is mostly harmless: most writers
+// leave the page tree's root and the information dictionary uncompressed.
+var (
+	pdfPages = regexp.MustCompile(
+		`/Type\s*/Pages\b[^>]{0,1000}?/Count\s+(\d+)|/Count\s+(\d+)[^>]{0,1000}?/Type\s*/Pages\b`)
+	pdfPage = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfInfo = regexp.MustCompile(`/Info\s+(\d+)\s+(\d+)\s+R\b`)
+	pdfKey  = regexp.MustCompile(`/(Title|Author|CreationDate)\s*([(<])`)
+)
+
+// function readPDF() reads the metadata of the PDF file from the given reader
+// into the given Info. the file is searched twice: first for the number of
+// pages and the object holding the information dictionary, then for that
+// object.
+func readPDF(r io.ReadSeeker, info *Info) error {
+
+	head := make([]byte, 5)
+	if _, err := io.ReadFull(r, head); nil != err || "%PDF-" != string(head) {
+		return malformed("PDF header")
+	}
+
+	// the page tree's root counts every page, and each node below it counts
+	// the pages below it, so the greatest count is the number of pages. if no
+	// count is found, the page objects themselves are counted.
+	var count, pages int
+	var infoRef string
+	err := search(r, func(chunk []byte, limit int) {
+		for _, m := range pdfPages.FindAllSubmatchIndex(chunk, -1) {
+			if m[0] < limit {
+				var n []byte
+				if m[2] >= 0 {
+					n = chunk[m[2]:m[3]]
+				} else {
+					n = chunk[m[4]:m[5]]
+				}
+				if c, err := strconv.Atoi(string(n)); nil == err && c > count {
+					count = c
+				}
+			}
+		}
+		for _, m := range pdfPage.FindAllIndex(chunk, -1) {
+			if m[0] < limit {
+				pages++
+			}
+		}
+		// an updated file appends a new trailer, so the last one is current.
+		for _, m := range pdfInfo.FindAllSubmatch(chunk, -1) {
+			infoRef = fmt.Sprintf(`\b%s\s+%s\s+obj\b`, m[1], m[2])
+		}
+	})
+	if nil != err {
+		return err
+	}
+	if count > 0 {
+		info.Pages = count
+	} else {
+		info.Pages = pages
+	}
+	if "" == infoRef {
+		return nil
+	}
+
+	obj := regexp.MustCompile(infoRef)
+	var dict []byte
+	err = search(r, func(chunk []byte, limit int) {
+		for _, m := range obj.FindAllIndex(chunk, -1) {
+			if m[0] >= limit {
+				continue
+			}
+			// the last definition of the object is current too.
+			body := chunk[m[1]:]
+			if len(body) > pdfMaxDict {
+				body = body[:pdfMaxDict]
+			}
+			if end := bytes.Index(body, []byte("endobj")); end >= 0 {
+				body = body[:end]
+			}
+			dict = append([]byte{}, body...)
+		}
+	})
+	if nil != err || nil == dict {
+		return err
+	}
+	for _, m := range pdfKey.FindAllSubmatchIndex(dict, -1) {
+		value := pdfString(dict[m[4]:])
+		switch string(dict[m[2]:m[3]]) {
+		case "Title":
+			info.Title = value
+		case "Author":
+			info.Author = value
+		case "CreationDate":
+			// dates are formatted "D:YYYYMMDDHHmmSS...".
+			if len(value) > 2 && "D:" == value[:2] {
+				value = value[2:]
+			}
+			info.Year = parseYear(value)
+		}
+	}
+	return nil
+}
+
+// function search() calls the given function with each chunk of the file read
+// from the given reader, from its start. consecutive chunks overlap, so that
+// text spanning the end of one is found whole in the next: the function should
+// ignore matches starting at or beyond the given limit of the chunk, which are
+// found again in the next one.
+func search(r io.ReadSeeker, fn func(chunk []byte, limit int)) error {
+
+	if _, err := r.Seek(0, io.SeekStart); nil != err {
+		return err
+	}
+	buf := make([]byte, 0, pdfChunk+pdfOverlap)
+	for {
+		n, err := io.ReadFull(r, buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if io.EOF == err || io.ErrUnexpectedEOF == err {
+			fn(buf, len(buf))
+			return nil
+		}
+		if nil != err {
+			return err
+		}
+		limit := len(buf) - pdfOverlap
+		fn(buf, limit)
+		buf = buf[:copy(buf, buf[limit:])]
+	}
+}
+
+// function pdfString() returns the text of the PDF string object at the start
+// of the given data, either literal "(...)" or hexadecimal "<...>". text is
+// encoded as UTF-16 if it starts with a byte order mark, or else in
+// PDFDocEncoding, which is treated as Latin-1.
+func pdfString(data []byte) string {
+
+	var raw []byte
+	switch {
+	case len(data) > 0 && '<' == data[0]:
+		end := bytes.IndexByte(data, '>')
+		if end < 0 {
+			return ""
+		}
+		digits := bytes.Join(bytes.Fields(data[1:end]), nil)
+		if 1 == len(digits)%2 {
+			digits = append(digits, '0')
+		}
+		var err error
+		if raw, err = hex.DecodeString(string(digits)); nil != err {
+			return ""
+		}
+	case len(data) > 0 && '(' == data[0]:
+		raw = pdfLiteral(data[1:])
+	default:
+		return ""
+	}
+
+	if len(raw) >= 2 && 0xFE == raw[0] && 0xFF == raw[1] {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	text := make([]rune, len(raw))
+	for i, b := range raw {
+		text[i] = rune(b)
+	}
+	return string(text)
+}
+
+// function pdfLiteral() returns the bytes of the literal string whose content
+// starts the given data (following its opening parenthesis), interpreting its
+// escape sequences. parentheses within it are balanced, unless escaped.
+func pdfLiteral(data []byte) []byte {
+
+	raw := []byte{}
+	depth := 0
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if 0 == depth {
+				return raw
+			}
+			depth--
+		case '\\':
+			if i++; i >= len(data) {
+				return raw
+			}
+			c = data[i]
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// a line continued on the next.
+				if '\r' == c && i+1 < len(data) && '\n' == data[i+1] {
+					i++
+				}
+				continue
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				// an octal character code of up to 3 digits.
+				n := 0
+				for j := 0; j < 3 && i < len(data) && data[i] >= '0' && data[i] <= '7'; j++ {
+					n = n*8 + int(data[i]-'0')
+					i++
+				}
+				i--
+				c = byte(n)
+			}
+		}
+		raw = append(raw, c)
+	}
+	return raw
+}
@@ -0,0 +1,204 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: zip.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    reads the metadata of the zip-based formats: EPUB ebooks, and CBZ comics.
+//
+// =============================================================================
+
+package ebook
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"path"
+	"strings"
+)
+
+// local unexported constants for the zip-based readers.
+const (
+	maxXMLSize = 4 << 20 // largest metadata document read from an archive
+)
+
+// type container is the content of an EPUB's META-INF/container.xml.
+type container struct {
+	Rootfile []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// type opfMeta is a <meta> element of an OPF package document, in either the
+// EPUB 2 form (name and content attributes) or the EPUB 3 form (property
+// attribute, with its value as text).
+type opfMeta struct {
+	ID       string `xml:"id,attr"`
+	Name     string `xml:"name,attr"`
+	Content  string `xml:"content,attr"`
+	Property string `xml:"property,attr"`
+	Refines  string `xml:"refines,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// type opfPackage is the metadata of an OPF package document.
+type opfPackage struct {
+	Title   []string `xml:"metadata>title"`
+	Creator []struct {
+		Role  string `xml:"role,attr"` // opf:role (EPUB 2) of the creator
+		Value string `xml:",chardata"`
+	} `xml:"metadata>creator"`
+	Date []string  `xml:"metadata>date"`
+	Meta []opfMeta `xml:"metadata>meta"`
+}
+
+// function readEPUB() reads the metadata of the EPUB file from the given
+// reader of the given size into the given Info.
+func readEPUB(r io.ReaderAt, size int64, info *Info) error {
+
+	z, err := zip.NewReader(r, size)
+	if nil != err {
+		return err
+	}
+	var c container
+	if err := readXML(z, "META-INF/container.xml", &c); nil != err {
+		return err
+	}
+	opf := ""
+	for _, f := range c.Rootfile {
+		if "" == f.MediaType || "application/oebps-package+xml" == f.MediaType {
+			opf = f.FullPath
+			break
+		}
+	}
+	if "" == opf {
+		return malformed("EPUB container")
+	}
+	var p opfPackage
+	if err := readXML(z, opf, &p); nil != err {
+		return err
+	}
+
+	if len(p.Title) > 0 {
+		info.Title = p.Title[0]
+	}
+	// contributors (editors, illustrators, etc.) are creators too, but only
+	// the authors are wanted.
+	authors := []string{}
+	for _, c := range p.Creator {
+		if "" == c.Role || "aut" == c.Role {
+			authors = append(authors, c.Value)
+		}
+	}
+	info.Author = joinNames(authors)
+	if len(p.Date) > 0 {
+		info.Year = parseYear(p.Date[0])
+	}
+
+	// calibre records the series as named meta elements (EPUB 2); EPUB 3 has
+	// collections, refined by their positions.
+	collection := ""
+	for _, m := range p.Meta {
+		switch {
+		case "calibre:series" == m.Name:
+			info.Series = m.Content
+		case "calibre:series_index" == m.Name:
+			info.SeriesIndex = parseIndex(m.Content)
+		case "belongs-to-collection" == m.Property && "" == m.Refines && "" == collection:
+			collection = m.ID
+			if "" == info.Series {
+				info.Series = m.Value
+			}
+		}
+	}
+	if "" != collection {
+		for _, m := range p.Meta {
+			if "#"+collection == m.Refines && "group-position" == m.Property && 0 == info.SeriesIndex {
+				info.SeriesIndex = parseIndex(m.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// type comicInfo is the content of a comic archive's ComicInfo.xml, as
+// written by ComicRack and most comic managers since.
+type comicInfo struct {
+	Title     string `xml:"Title"`
+	Series    string `xml:"Series"`
+	Number    string `xml:"Number"`
+	Year      int    `xml:"Year"`
+	Writer    string `xml:"Writer"`
+	PageCount int    `xml:"PageCount"`
+}
+
+// function readCBZ() reads the metadata of the CBZ file from the given reader
+// of the given size into the given Info. every image archived is a page.
+func readCBZ(r io.ReaderAt, size int64, info *Info) error {
+
+	z, err := zip.NewReader(r, size)
+	if nil != err {
+		return err
+	}
+	meta := ""
+	for _, f := range z.File {
+		name := path.Base(f.Name)
+		if f.FileInfo().IsDir() || strings.HasPrefix(name, ".") ||
+			strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		switch strings.ToLower(path.Ext(name)) {
+		case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp", ".jxl", ".avif":
+			info.Pages++
+		case ".xml":
+			if strings.EqualFold(name, "ComicInfo.xml") && "" == meta {
+				meta = f.Name
+			}
+		}
+	}
+	if "" == meta {
+		return nil
+	}
+	var c comicInfo
+	if err := readXML(z, meta, &c); nil != err {
+		return nil // damaged metadata doesn't spoil the count of pages.
+	}
+	info.Title, info.Series, info.Author = c.Title, c.Series, joinNames(strings.Split(c.Writer, ","))
+	info.SeriesIndex = parseIndex(c.Number)
+	if c.Year > 0 {
+		info.Year = c.Year
+	}
+	if c.PageCount > 0 {
+		info.Pages = c.PageCount
+	}
+	return nil
+}
+
+// function readXML() decodes the XML document with the given name in the given
+// archive into the given value.
+func readXML(z *zip.Reader, name string, v interface{}) error {
+
+	for _, f := range z.File {
+		if f.Name != name {
+			continue
+		}
+		if f.UncompressedSize64 > maxXMLSize {
+			return malformed(name + " (too large)")
+		}
+		in, err := f.Open()
+		if nil != err {
+			return err
+		}
+		defer in.Close()
+		dec := xml.NewDecoder(io.LimitReader(in, maxXMLSize))
+		// documents declaring other encodings are decoded as if UTF-8, which
+		// is right for their ASCII content at least.
+		dec.CharsetReader = func(_ string, in io.Reader) (io.Reader, error) { return in, nil }
+		return dec.Decode(v)
+	}
+	return malformed(name + " (missing)")
+}
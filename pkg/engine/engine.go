@@ -0,0 +1,131 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: engine.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the interface of the database engines in which libraries persist
+//    their records, and selects among the engines implementing it.
+//
+// =============================================================================
+
+// package engine abstracts the database engine of each library's database (see
+// package storage), so that it can be chosen per library. an engine keeps
+// named collections of JSON documents (records), each identified by an integer
+// ID, and indexes them by the values found at given paths into the documents
+// so that they can be found without reading every one.
+//
+// two engines are provided:
+//
+//	tiedot  the original engine, fast but holding much of each collection in
+//	        memory (the default)
+//	sqlite  a single SQLite file, whose memory use is independent of the
+//	        number of records, for very large libraries
+//
+// the engine of a database is fixed once it is created. to change it, export
+// the database to a JSON document and import it into a new database.
+package engine
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// the names of the engines, as given to Open().
+const (
+	Tiedot  = "tiedot"
+	SQLite  = "sqlite"
+	Default = Tiedot
+)
+
+// type Collection is a named collection of records in a Store. the methods
+// are those of tiedot's collections, which the others imitate.
+type Collection interface {
+	// inserts a new record, returning its ID.
+	Insert(doc map[string]interface{}) (int, error)
+	// inserts all of the given records, much faster than one at a time,
+	// returning their IDs in the same order. on error, the ID of each record
+	// that wasn't inserted is -1.
+	InsertMany(docs []map[string]interface{}) ([]int, error)
+	// returns the record with the given ID.
+	Read(id int) (map[string]interface{}, error)
+	// replaces the record with the given ID.
+	Update(id int, doc map[string]interface{}) error
+	// deletes the record with the given ID.
+	Delete(id int) error
+	// calls the given function with the ID and JSON data of each record,
+	// until it returns false. the function must not modify the collection.
+	ForEachDoc(fn func(id int, doc []byte) (moveOn bool))
+	// indexes the records by the values at the given path.
+	Index(path []string) error
+	// returns the path of every index of the collection.
+	AllIndexes() [][]string
+	// returns the IDs of the records having any of the given values at the
+	// given indexed path. if the path leads to an array, each of its elements
+	// is compared.
+	Find(path []string, values ...string) (map[int]struct{}, error)
+}
+
+// type Store is a database containing any number of Collections.
+type Store interface {
+	// returns true if the collection with the given name exists.
+	ColExists(name string) bool
+	// creates a new, empty collection with the given name.
+	Create(name string) error
+	// returns the existing collection with the given name, or nil.
+	Use(name string) Collection
+	// repairs and compacts the collection with the given name.
+	Scrub(name string) error
+	// flushes all changes to disk.
+	Sync() error
+	// closes the database.
+	Close() error
+}
+
+// function Names() returns the names of all engines.
+func Names() []string {
+	return []string{Tiedot, SQLite}
+}
+
+// function Valid() returns true if the given name identifies an engine,
+// ignoring case.
+func Valid(name string) bool {
+	for _, n := range Names() {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// function Open() opens the database of the named engine in the given
+// directory, creating it if it doesn't exist.
+func Open(name, dir string) (Store, error) {
+	switch strings.ToLower(name) {
+	case Tiedot:
+		return openTiedot(dir)
+	case SQLite:
+		return openSQLite(dir)
+	}
+	return nil, fmt.Errorf("unknown database engine: %q (expected one of: %s)",
+		name, strings.Join(Names(), ", "))
+}
+
+// function Detect() returns the name of the engine of the database in the
+// given directory, or an empty string if the directory holds no database.
+// databases created before engines were selectable are always tiedot's, so
+// any directory not empty is taken as one.
+func Detect(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, sqliteFileName)); nil == err {
+		return SQLite
+	}
+	if list, err := ioutil.ReadDir(dir); nil == err && len(list) > 0 {
+		return Tiedot
+	}
+	return ""
+}
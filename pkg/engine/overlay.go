@@ -0,0 +1,396 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: overlay.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines a write-intercepting layer around a Store, which keeps the
+//    changes made to its collections in memory instead of writing them, and
+//    counts them, for the read-only and dry-run modes of the libraries.
+//
+// =============================================================================
+
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// constant overlayFirstID is the first ID given to the records inserted into
+// an Overlay, chosen to rarely collide with those of the underlying Store
+// (which are skipped when they do).
+const overlayFirstID = 1 << 30
+
+// type Changes counts the changes made to a collection of an Overlay, which
+// would have been written to the underlying Store.
+type Changes struct {
+	Inserted int // records inserted (and not since deleted)
+	Updated  int // records of the Store replaced
+	Deleted  int // records of the Store deleted
+}
+
+// type Overlay is a Store that reads from an underlying Store but never writes
+// to it: records inserted, updated, and deleted are kept in memory, where they
+// are read back from (and found, if indexed) as though they were written, and
+// counted (see Changes()). collections created, indexes added, and scrubs and
+// syncs requested are likewise never written. closing an Overlay closes the
+// underlying Store, discarding the changes.
+type Overlay struct {
+	store Store
+	mutex sync.Mutex
+	col   map[string]*overlayCol
+}
+
+// type overlayCol is a collection of an Overlay, reading from the collection of
+// the underlying Store of the same name (nil if created by the Overlay).
+type overlayCol struct {
+	base     Collection
+	mutex    sync.RWMutex
+	doc      map[int][]byte // JSON data of each record inserted or updated
+	inserted map[int]bool   // records inserted, as opposed to updated
+	deleted  map[int]bool   // records of the base collection deleted
+	index    [][]string     // indexes added, in addition to those of the base
+	nextID   int
+	changes  Changes
+}
+
+// function NewOverlay() creates an Overlay reading from the given Store.
+func NewOverlay(store Store) *Overlay {
+	return &Overlay{store: store, col: map[string]*overlayCol{}}
+}
+
+// function Changes() returns the changes made to each collection of the
+// Overlay that has any, keyed by its name.
+func (o *Overlay) Changes() map[string]Changes {
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	changes := map[string]Changes{}
+	for name, c := range o.col {
+		c.mutex.RLock()
+		if (Changes{}) != c.changes {
+			changes[name] = c.changes
+		}
+		c.mutex.RUnlock()
+	}
+	return changes
+}
+
+// function ColExists() returns true if the collection with the given name
+// exists in the underlying Store or was created by the Overlay.
+func (o *Overlay) ColExists(name string) bool {
+	o.mutex.Lock()
+	_, ok := o.col[name]
+	o.mutex.Unlock()
+	return ok || o.store.ColExists(name)
+}
+
+// function Create() creates a new, empty collection with the given name in the
+// Overlay only.
+func (o *Overlay) Create(name string) error {
+	if o.ColExists(name) {
+		return fmt.Errorf("collection %q already exists", name)
+	}
+	o.mutex.Lock()
+	o.col[name] = newOverlayCol(nil)
+	o.mutex.Unlock()
+	return nil
+}
+
+// function Use() returns the existing collection with the given name, or nil.
+func (o *Overlay) Use(name string) Collection {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if c, ok := o.col[name]; ok {
+		return c
+	}
+	base := o.store.Use(name)
+	if nil == base {
+		return nil
+	}
+	o.col[name] = newOverlayCol(base)
+	return o.col[name]
+}
+
+// function Scrub() does nothing, since scrubbing rewrites the collection.
+func (o *Overlay) Scrub(name string) error { return nil }
+
+// function Sync() does nothing, since nothing is written.
+func (o *Overlay) Sync() error { return nil }
+
+// function Close() closes the underlying Store, discarding the changes.
+func (o *Overlay) Close() error { return o.store.Close() }
+
+// function newOverlayCol() creates a collection of an Overlay reading from the
+// given collection, or from none if nil.
+func newOverlayCol(base Collection) *overlayCol {
+	return &overlayCol{
+		base:     base,
+		doc:      map[int][]byte{},
+		inserted: map[int]bool{},
+		deleted:  map[int]bool{},
+		nextID:   overlayFirstID,
+	}
+}
+
+// function exists() returns true if the record with the given ID exists. the
+// collection must be locked.
+func (c *overlayCol) exists(id int) bool {
+	if _, ok := c.doc[id]; ok {
+		return true
+	}
+	if c.deleted[id] || nil == c.base {
+		return false
+	}
+	_, err := c.base.Read(id)
+	return nil == err
+}
+
+// function Insert() inserts a new record in memory, returning its ID.
+func (c *overlayCol) Insert(doc map[string]interface{}) (int, error) {
+
+	data, err := json.Marshal(doc)
+	if nil != err {
+		return -1, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	id := c.nextID
+	for c.exists(id) {
+		id++
+	}
+	c.nextID = id + 1
+	c.doc[id] = data
+	c.inserted[id] = true
+	c.changes.Inserted++
+	return id, nil
+}
+
+// function InsertMany() inserts all of the given records in memory, returning
+// their IDs in the same order.
+func (c *overlayCol) InsertMany(docs []map[string]interface{}) ([]int, error) {
+
+	ids := make([]int, len(docs))
+	var first error
+	for i, doc := range docs {
+		var err error
+		if ids[i], err = c.Insert(doc); nil != err && nil == first {
+			first = err
+		}
+	}
+	return ids, first
+}
+
+// function Read() returns the record with the given ID, as changed in memory.
+func (c *overlayCol) Read(id int) (map[string]interface{}, error) {
+
+	c.mutex.RLock()
+	data, ok := c.doc[id]
+	deleted := c.deleted[id]
+	c.mutex.RUnlock()
+
+	if ok {
+		doc := map[string]interface{}{}
+		if err := json.Unmarshal(data, &doc); nil != err {
+			return nil, err
+		}
+		return doc, nil
+	}
+	if deleted || nil == c.base {
+		return nil, fmt.Errorf("record %d does not exist", id)
+	}
+	return c.base.Read(id)
+}
+
+// function Update() replaces the record with the given ID in memory.
+func (c *overlayCol) Update(id int, doc map[string]interface{}) error {
+
+	data, err := json.Marshal(doc)
+	if nil != err {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.exists(id) {
+		return fmt.Errorf("record %d does not exist", id)
+	}
+	if _, ok := c.doc[id]; !ok {
+		c.changes.Updated++
+	}
+	c.doc[id] = data
+	return nil
+}
+
+// function Delete() deletes the record with the given ID in memory.
+func (c *overlayCol) Delete(id int) error {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.exists(id) {
+		return fmt.Errorf("record %d does not exist", id)
+	}
+	if c.inserted[id] {
+		c.changes.Inserted--
+		delete(c.inserted, id)
+	} else {
+		if _, ok := c.doc[id]; ok {
+			c.changes.Updated--
+		}
+		c.deleted[id] = true
+		c.changes.Deleted++
+	}
+	delete(c.doc, id)
+	return nil
+}
+
+// function snapshot() returns a copy of the records inserted or updated, and
+// of those deleted, so that they can be read without holding the lock.
+func (c *overlayCol) snapshot() (map[int][]byte, map[int]bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	doc := make(map[int][]byte, len(c.doc))
+	for id, data := range c.doc {
+		doc[id] = data
+	}
+	deleted := make(map[int]bool, len(c.deleted))
+	for id := range c.deleted {
+		deleted[id] = true
+	}
+	return doc, deleted
+}
+
+// function ForEachDoc() calls the given function with the ID and JSON data of
+// each record, as changed in memory, until it returns false: first those of the
+// base collection, then those inserted.
+func (c *overlayCol) ForEachDoc(fn func(id int, doc []byte) (moveOn bool)) {
+
+	doc, deleted := c.snapshot()
+	moveOn := true
+	if nil != c.base {
+		c.base.ForEachDoc(func(id int, data []byte) bool {
+			if deleted[id] {
+				return true
+			}
+			if changed, ok := doc[id]; ok {
+				data = changed
+				delete(doc, id)
+			}
+			moveOn = fn(id, data)
+			return moveOn
+		})
+	}
+	if !moveOn {
+		return
+	}
+	id := make([]int, 0, len(doc))
+	for i := range doc {
+		id = append(id, i)
+	}
+	sort.Ints(id)
+	for _, i := range id {
+		if !fn(i, doc[i]) {
+			return
+		}
+	}
+}
+
+// function Index() indexes the records by the values at the given path, in
+// memory only.
+func (c *overlayCol) Index(path []string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.index = append(c.index, path)
+	return nil
+}
+
+// function AllIndexes() returns the path of every index of the collection,
+// both those of the base collection and those added in memory.
+func (c *overlayCol) AllIndexes() [][]string {
+	all := [][]string{}
+	if nil != c.base {
+		all = append(all, c.base.AllIndexes()...)
+	}
+	c.mutex.RLock()
+	all = append(all, c.index...)
+	c.mutex.RUnlock()
+	return all
+}
+
+// function Find() returns the IDs of the records having any of the given
+// values at the given indexed path, as changed in memory. the records of the
+// base collection are found by its index, if it has one (or else compared one
+// by one, like those changed in memory).
+func (c *overlayCol) Find(path []string, values ...string) (map[int]struct{}, error) {
+
+	want := map[string]bool{}
+	for _, v := range values {
+		want[v] = true
+	}
+	match := func(data []byte) bool {
+		var rec interface{}
+		if err := json.Unmarshal(data, &rec); nil != err {
+			return false
+		}
+		for _, v := range valuesAt(rec, path) {
+			if want[v] {
+				return true
+			}
+		}
+		return false
+	}
+
+	found := map[int]struct{}{}
+	doc, deleted := c.snapshot()
+	if nil != c.base {
+		if hasIndex(c.base.AllIndexes(), path) {
+			base, err := c.base.Find(path, values...)
+			if nil != err {
+				return nil, err
+			}
+			for id := range base {
+				if _, changed := doc[id]; !changed && !deleted[id] {
+					found[id] = struct{}{}
+				}
+			}
+		} else {
+			c.base.ForEachDoc(func(id int, data []byte) bool {
+				if _, changed := doc[id]; !changed && !deleted[id] && match(data) {
+					found[id] = struct{}{}
+				}
+				return true
+			})
+		}
+	}
+	for id, data := range doc {
+		if match(data) {
+			found[id] = struct{}{}
+		}
+	}
+	return found, nil
+}
+
+// function hasIndex() returns true if the given indexes include the given path.
+func hasIndex(index [][]string, path []string) bool {
+	for _, p := range index {
+		if len(p) != len(path) {
+			continue
+		}
+		match := true
+		for i := range p {
+			if p[i] != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,480 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: sqlite.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    implements the engine interface with a single SQLite database file.
+//
+// =============================================================================
+
+package engine
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	_ "modernc.org/sqlite" // pure Go, so pimmp still cross-compiles without cgo
+)
+
+// local unexported constants for the SQLite engine.
+const (
+	sqliteFileName = "pimmp.sqlite" // name of the database file in its directory
+	sqlitePageSize = 512            // records read at once by ForEachDoc()
+	sqliteIndexSep = ","            // separator of the segments of an index path
+)
+
+// the schema of the database. the records of all collections share a table,
+// each identified by an ID unique to the database, and the values indexed
+// from them share another, like the hash tables of tiedot.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS collections (
+	name TEXT PRIMARY KEY
+);
+CREATE TABLE IF NOT EXISTS indexes (
+	col  TEXT NOT NULL,
+	path TEXT NOT NULL,
+	PRIMARY KEY (col, path)
+);
+CREATE TABLE IF NOT EXISTS docs (
+	id  INTEGER PRIMARY KEY,
+	col TEXT NOT NULL,
+	doc TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS docs_col ON docs (col, id);
+CREATE TABLE IF NOT EXISTS keys (
+	col   TEXT NOT NULL,
+	path  TEXT NOT NULL,
+	value TEXT NOT NULL,
+	id    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS keys_value ON keys (col, path, value);
+CREATE INDEX IF NOT EXISTS keys_id ON keys (id);
+`
+
+// type sqliteStore is a SQLite database file.
+type sqliteStore struct {
+	db       *sql.DB
+	mutex    sync.Mutex            // guards the fields below
+	cols     map[string]*sqliteCol // collections used, by name
+	scrubbed bool                  // the database was compacted (see Scrub())
+}
+
+// type sqliteCol is a collection of a SQLite database.
+type sqliteCol struct {
+	store *sqliteStore
+	name  string
+	paths []string // paths indexed, segments joined by sqliteIndexSep
+}
+
+// function openSQLite() opens the SQLite database in the given directory,
+// creating it if it doesn't exist.
+func openSQLite(dir string) (Store, error) {
+
+	// writes are serialized through a single connection, which is plenty for
+	// one library, and leaves no other connection to be kept waiting.
+	dsn := "file:" + filepath.Join(dir, sqliteFileName) +
+		"?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(5000)"
+	conn, err := sql.Open("sqlite", dsn)
+	if nil != err {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(1)
+	if _, err := conn.Exec(sqliteSchema); nil != err {
+		conn.Close()
+		return nil, err
+	}
+	return &sqliteStore{db: conn, cols: map[string]*sqliteCol{}}, nil
+}
+
+// function ColExists() returns true if the collection with the given name
+// exists.
+func (s *sqliteStore) ColExists(name string) bool {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM collections WHERE name = ?`, name).Scan(&n)
+	return nil == err && n > 0
+}
+
+// function Create() creates a new, empty collection with the given name.
+func (s *sqliteStore) Create(name string) error {
+	if s.ColExists(name) {
+		return fmt.Errorf("collection %q already exists", name)
+	}
+	_, err := s.db.Exec(`INSERT INTO collections (name) VALUES (?)`, name)
+	return err
+}
+
+// function Use() returns the existing collection with the given name, or nil.
+func (s *sqliteStore) Use(name string) Collection {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if col, ok := s.cols[name]; ok {
+		return col
+	}
+	if !s.ColExists(name) {
+		return nil
+	}
+	rows, err := s.db.Query(`SELECT path FROM indexes WHERE col = ? ORDER BY path`, name)
+	if nil != err {
+		return nil
+	}
+	defer rows.Close()
+	col := &sqliteCol{store: s, name: name, paths: []string{}}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); nil != err {
+			return nil
+		}
+		col.paths = append(col.paths, path)
+	}
+	if nil != rows.Err() {
+		return nil
+	}
+	s.cols[name] = col
+	return col
+}
+
+// function Scrub() compacts the database. SQLite records aren't damaged by
+// crashes like tiedot's, so there is nothing to repair, and the entire file is
+// compacted just once, whichever collection is named.
+func (s *sqliteStore) Scrub(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.scrubbed {
+		return nil
+	}
+	s.scrubbed = true
+	_, err := s.db.Exec(`VACUUM`)
+	return err
+}
+
+// function Sync() writes every change of the write-ahead log into the
+// database file.
+func (s *sqliteStore) Sync() error {
+	_, err := s.db.Exec(`PRAGMA wal_checkpoint(FULL)`)
+	return err
+}
+
+// function Close() closes the database.
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// function indexes() returns the paths indexed in the collection.
+func (c *sqliteCol) indexes() []string {
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+	return append([]string{}, c.paths...)
+}
+
+// function Insert() inserts a new record, returning its ID.
+func (c *sqliteCol) Insert(doc map[string]interface{}) (int, error) {
+
+	ids, err := c.InsertMany([]map[string]interface{}{doc})
+	if nil != err {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// function InsertMany() inserts all of the given records in a single
+// transaction, so either all of them are inserted or none are.
+func (c *sqliteCol) InsertMany(docs []map[string]interface{}) ([]int, error) {
+
+	ids := make([]int, len(docs))
+	data := make([][]byte, len(docs))
+	for i, doc := range docs {
+		ids[i] = -1
+		enc, err := json.Marshal(doc)
+		if nil != err {
+			return ids, err
+		}
+		data[i] = enc
+	}
+	// the paths are read before the connection is taken, which Use() may be
+	// waiting for while holding the mutex guarding them.
+	paths := c.indexes()
+	tx, err := c.store.db.Begin()
+	if nil != err {
+		return ids, err
+	}
+	defer tx.Rollback()
+	added := make([]int, len(docs))
+	for i := range data {
+		res, err := tx.Exec(`INSERT INTO docs (col, doc) VALUES (?, ?)`, c.name, string(data[i]))
+		if nil != err {
+			return ids, err
+		}
+		id, err := res.LastInsertId()
+		if nil != err {
+			return ids, err
+		}
+		if err := c.addKeys(tx, int(id), data[i], paths); nil != err {
+			return ids, err
+		}
+		added[i] = int(id)
+	}
+	if err := tx.Commit(); nil != err {
+		return ids, err
+	}
+	return added, nil
+}
+
+// function Read() returns the record with the given ID.
+func (c *sqliteCol) Read(id int) (map[string]interface{}, error) {
+
+	var data string
+	err := c.store.db.QueryRow(
+		`SELECT doc FROM docs WHERE id = ? AND col = ?`, id, c.name).Scan(&data)
+	if sql.ErrNoRows == err {
+		return nil, fmt.Errorf("document %d does not exist in collection %q", id, c.name)
+	}
+	if nil != err {
+		return nil, err
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(data), &doc); nil != err {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// function Update() replaces the record with the given ID.
+func (c *sqliteCol) Update(id int, doc map[string]interface{}) error {
+
+	data, err := json.Marshal(doc)
+	if nil != err {
+		return err
+	}
+	paths := c.indexes()
+	tx, err := c.store.db.Begin()
+	if nil != err {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`UPDATE docs SET doc = ? WHERE id = ? AND col = ?`, string(data), id, c.name)
+	if nil != err {
+		return err
+	}
+	if n, err := res.RowsAffected(); nil != err || 0 == n {
+		return fmt.Errorf("document %d does not exist in collection %q", id, c.name)
+	}
+	if _, err := tx.Exec(`DELETE FROM keys WHERE id = ?`, id); nil != err {
+		return err
+	}
+	if err := c.addKeys(tx, id, data, paths); nil != err {
+		return err
+	}
+	return tx.Commit()
+}
+
+// function Delete() deletes the record with the given ID.
+func (c *sqliteCol) Delete(id int) error {
+
+	tx, err := c.store.db.Begin()
+	if nil != err {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`DELETE FROM docs WHERE id = ? AND col = ?`, id, c.name)
+	if nil != err {
+		return err
+	}
+	if n, err := res.RowsAffected(); nil != err || 0 == n {
+		return fmt.Errorf("document %d does not exist in collection %q", id, c.name)
+	}
+	if _, err := tx.Exec(`DELETE FROM keys WHERE id = ?`, id); nil != err {
+		return err
+	}
+	return tx.Commit()
+}
+
+// function ForEachDoc() calls the given function with the ID and JSON data of
+// each record, in order of ID, until it returns false. records are read a
+// page at a time, and no query is open while the function is called.
+func (c *sqliteCol) ForEachDoc(fn func(id int, doc []byte) (moveOn bool)) {
+
+	type record struct {
+		id  int
+		doc []byte
+	}
+	last := -1
+	for {
+		rows, err := c.store.db.Query(
+			`SELECT id, doc FROM docs WHERE col = ? AND id > ? ORDER BY id LIMIT ?`,
+			c.name, last, sqlitePageSize)
+		if nil != err {
+			return
+		}
+		page := make([]record, 0, sqlitePageSize)
+		for rows.Next() {
+			var r record
+			if err := rows.Scan(&r.id, &r.doc); nil != err {
+				break
+			}
+			page = append(page, r)
+		}
+		rows.Close()
+		for _, r := range page {
+			if !fn(r.id, r.doc) {
+				return
+			}
+		}
+		if len(page) < sqlitePageSize {
+			return
+		}
+		last = page[len(page)-1].id
+	}
+}
+
+// function Index() indexes the records by the values at the given path,
+// including those already in the collection.
+func (c *sqliteCol) Index(path []string) error {
+
+	key := strings.Join(path, sqliteIndexSep)
+	for _, p := range c.indexes() {
+		if key == p {
+			return fmt.Errorf("path %v is already indexed in collection %q", path, c.name)
+		}
+	}
+	tx, err := c.store.db.Begin()
+	if nil != err {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`INSERT INTO indexes (col, path) VALUES (?, ?)`, c.name, key); nil != err {
+		return err
+	}
+	rows, err := tx.Query(`SELECT id, doc FROM docs WHERE col = ?`, c.name)
+	if nil != err {
+		return err
+	}
+	type record struct {
+		id  int
+		doc []byte
+	}
+	all := []record{}
+	for rows.Next() {
+		var r record
+		if err := rows.Scan(&r.id, &r.doc); nil != err {
+			rows.Close()
+			return err
+		}
+		all = append(all, r)
+	}
+	rows.Close()
+	for _, r := range all {
+		if err := c.addKeys(tx, r.id, r.doc, []string{key}); nil != err {
+			return err
+		}
+	}
+	if err := tx.Commit(); nil != err {
+		return err
+	}
+	c.store.mutex.Lock()
+	c.paths = append(c.paths, key)
+	c.store.mutex.Unlock()
+	return nil
+}
+
+// function AllIndexes() returns the path of every index of the collection.
+func (c *sqliteCol) AllIndexes() [][]string {
+	list := [][]string{}
+	for _, p := range c.indexes() {
+		list = append(list, strings.Split(p, sqliteIndexSep))
+	}
+	return list
+}
+
+// function Find() returns the IDs of the records having any of the given
+// values at the given indexed path.
+func (c *sqliteCol) Find(path []string, values ...string) (map[int]struct{}, error) {
+
+	result := map[int]struct{}{}
+	if 0 == len(values) {
+		return result, nil
+	}
+	key := strings.Join(path, sqliteIndexSep)
+	indexed := false
+	for _, p := range c.indexes() {
+		indexed = indexed || key == p
+	}
+	if !indexed {
+		return nil, fmt.Errorf("path %v is not indexed in collection %q", path, c.name)
+	}
+	args := []interface{}{c.name, key}
+	for _, v := range values {
+		args = append(args, v)
+	}
+	rows, err := c.store.db.Query(
+		`SELECT DISTINCT id FROM keys WHERE col = ? AND path = ? AND value IN (?`+
+			strings.Repeat(`, ?`, len(values)-1)+`)`, args...)
+	if nil != err {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); nil != err {
+			return nil, err
+		}
+		result[id] = struct{}{}
+	}
+	return result, rows.Err()
+}
+
+// function addKeys() records the values of the record with the given ID and
+// JSON data at each of the given indexed paths.
+func (c *sqliteCol) addKeys(tx *sql.Tx, id int, data []byte, paths []string) error {
+
+	if 0 == len(paths) {
+		return nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); nil != err {
+		return err
+	}
+	for _, key := range paths {
+		for _, v := range valuesAt(doc, strings.Split(key, sqliteIndexSep)) {
+			if _, err := tx.Exec(`INSERT INTO keys (col, path, value, id) VALUES (?, ?, ?, ?)`,
+				c.name, key, v, id); nil != err {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// function valuesAt() returns the values found at the given path into the
+// given decoded JSON document, formatted as strings. like tiedot, an array met
+// along the path is searched element by element, so the elements of an array
+// at the end of the path are each a value.
+func valuesAt(doc interface{}, path []string) []string {
+
+	switch v := doc.(type) {
+	case []interface{}:
+		list := []string{}
+		for _, e := range v {
+			list = append(list, valuesAt(e, path)...)
+		}
+		return list
+	case map[string]interface{}:
+		if 0 == len(path) {
+			return nil
+		}
+		return valuesAt(v[path[0]], path[1:])
+	case nil:
+		return nil
+	}
+	if len(path) > 0 {
+		return nil
+	}
+	return []string{fmt.Sprint(doc)}
+}
@@ -0,0 +1,107 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: tiedot.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    adapts the tiedot document database to the engine interface.
+//
+// =============================================================================
+
+package engine
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/HouzuoGuo/tiedot/db"
+)
+
+// type tiedotStore is a tiedot database.
+type tiedotStore struct {
+	*db.DB
+}
+
+// type tiedotCol is a collection of a tiedot database, which implements all
+// of Collection but Find() itself.
+type tiedotCol struct {
+	*db.Col
+}
+
+// function openTiedot() opens the tiedot database in the given directory,
+// creating it if it doesn't exist. the directory may also contain tiedot's
+// configuration file, which is read when it is opened.
+func openTiedot(dir string) (Store, error) {
+	store, err := db.OpenDB(dir)
+	if nil != err {
+		return nil, err
+	}
+	return &tiedotStore{store}, nil
+}
+
+// function Use() returns the existing collection with the given name, or nil.
+func (s *tiedotStore) Use(name string) Collection {
+	col := s.DB.Use(name)
+	if nil == col {
+		return nil
+	}
+	return &tiedotCol{col}
+}
+
+// function InsertMany() inserts all of the given records concurrently, one
+// goroutine per CPU core. tiedot locks each partition of a collection
+// separately, so the inserts mostly proceed in parallel.
+func (c *tiedotCol) InsertMany(docs []map[string]interface{}) ([]int, error) {
+
+	ids := make([]int, len(docs))
+	errs := make([]error, len(docs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < runtime.NumCPU(); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if ids[i], errs[i] = c.Col.Insert(docs[i]); nil != errs[i] {
+					ids[i] = -1
+				}
+			}
+		}()
+	}
+	for i := range docs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if nil != err {
+			return ids, err
+		}
+	}
+	return ids, nil
+}
+
+// function Find() returns the IDs of the records having any of the given
+// values at the given indexed path, by a union of tiedot equality queries.
+func (c *tiedotCol) Find(path []string, values ...string) (map[int]struct{}, error) {
+
+	result := map[int]struct{}{}
+	if 0 == len(values) {
+		return result, nil
+	}
+	in := make([]interface{}, len(path))
+	for i, p := range path {
+		in[i] = p
+	}
+	query := make([]interface{}, len(values))
+	for i, v := range values {
+		query[i] = map[string]interface{}{"eq": v, "in": in}
+	}
+	if err := db.EvalQuery(query, c.Col, &result); nil != err {
+		return nil, err
+	}
+	return result, nil
+}
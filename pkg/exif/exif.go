@@ -0,0 +1,315 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: exif.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    reads the dimensions of image files and the EXIF data embedded in them by
+//    cameras: the date each photo was taken and the camera taking it.
+//
+// =============================================================================
+
+// package exif reads the metadata of image files, so that the records of image
+// media describe more than their file names. EXIF data is stored as a TIFF
+// structure: embedded in an APP1 segment of a JPEG file, or as the file itself
+// for TIFF and most of the raw formats of cameras (which are TIFF-based). only
+// the few tags pimmp has a use for are read.
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	_ "image/gif" // registers the GIF decoder with image.DecodeConfig()
+	_ "image/png" // registers the PNG decoder with image.DecodeConfig()
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Info is the metadata read from an image file. fields the file doesn't
+// define are left zero.
+type Info struct {
+	Width  int       // width in pixels, as displayed (rotated per the EXIF orientation)
+	Height int       // height in pixels, as displayed
+	Make   string    // manufacturer of the camera
+	Model  string    // model of the camera
+	Taken  time.Time // date the photo was taken
+}
+
+// local unexported constants for the EXIF reader.
+const (
+	exifDateFormat = "2006:01:02 15:04:05" // format of the EXIF date tags
+	maxEntries     = 1024                  // more entries in an IFD than any real file has
+	maxString      = 256                   // longest string tag read
+)
+
+// the TIFF tags read, from the main image's IFD (IFD0) and the EXIF IFD.
+const (
+	tagImageWidth   = 0x0100
+	tagImageLength  = 0x0101
+	tagMake         = 0x010F
+	tagModel        = 0x0110
+	tagOrientation  = 0x0112
+	tagDateTime     = 0x0132
+	tagExifIFD      = 0x8769
+	tagDateOriginal = 0x9003
+	tagOffsetOrig   = 0x9011
+	tagPixelXDim    = 0xA002
+	tagPixelYDim    = 0xA003
+)
+
+// function Supported() returns true if metadata can be read from files with
+// the given file name extension.
+func Supported(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg", ".jpe", ".png", ".gif", ".tif", ".tiff",
+		".cr2", ".dng", ".nef", ".nrw", ".orf", ".rw2", ".pef", ".arw", ".srf", ".sr2":
+		return true
+	}
+	return false
+}
+
+// function Read() reads the metadata of the image file at the given path. a
+// file without EXIF data is not an error, as long as its dimensions can be
+// read.
+func Read(path string) (*Info, *rc.ReturnCode) {
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if !Supported(ext) {
+		return nil, rc.MetadataError.Specf("exif.Read(%q): unsupported file type", path)
+	}
+	f, err := os.Open(path)
+	if nil != err {
+		return nil, rc.MetadataError.Specf("exif.Read(%q): %s", path, err)
+	}
+	defer f.Close()
+
+	info := &Info{}
+	magic := make([]byte, 4)
+	if _, err := f.ReadAt(magic, 0); nil != err {
+		return nil, rc.MetadataError.Specf("exif.Read(%q): %s", path, err)
+	}
+	var orientation uint32
+	switch {
+	case 0xFF == magic[0] && 0xD8 == magic[1]:
+		orientation, err = readJPEG(f, info)
+	case "II" == string(magic[:2]) || "MM" == string(magic[:2]):
+		orientation, err = readTIFF(f, info)
+	default:
+		// neither carries EXIF data pimmp reads, but the standard library
+		// knows their dimensions.
+		var cfg image.Config
+		if cfg, _, err = image.DecodeConfig(f); nil == err {
+			info.Width, info.Height = cfg.Width, cfg.Height
+		}
+	}
+	if nil != err {
+		return nil, rc.MetadataError.Specf("exif.Read(%q): %s", path, err)
+	}
+	// orientations 5-8 are rotated a quarter turn, so displayed transposed.
+	if orientation >= 5 && orientation <= 8 {
+		info.Width, info.Height = info.Height, info.Width
+	}
+	return info, nil
+}
+
+// function readJPEG() reads the dimensions of the JPEG image from the given
+// file, and the EXIF data of its APP1 segment if it has one. returns the
+// orientation of the image given by the EXIF data, or 0 if none.
+func readJPEG(f io.ReadSeeker, info *Info) (uint32, error) {
+
+	if _, err := f.Seek(2, io.SeekStart); nil != err {
+		return 0, err
+	}
+	var orientation uint32
+	exif := false
+	head := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(f, head); nil != err {
+			return orientation, err
+		}
+		if 0xFF != head[0] {
+			return orientation, malformed("JPEG segment")
+		}
+		marker := head[1]
+		length := int64(binary.BigEndian.Uint16(head[2:])) - 2
+		if length < 0 {
+			return orientation, malformed("JPEG segment length")
+		}
+		switch {
+		case 0xDA == marker || 0xD9 == marker:
+			// the image data follows (or the image ended), no more metadata.
+			return orientation, nil
+
+		case 0xE1 == marker && !exif:
+			seg := make([]byte, length)
+			if _, err := io.ReadFull(f, seg); nil != err {
+				return orientation, err
+			}
+			if bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+				// damaged EXIF data doesn't spoil the dimensions of the image.
+				orientation, _ = readTIFF(bytes.NewReader(seg[6:]), info)
+				exif = true
+			}
+			continue
+
+		case marker >= 0xC0 && marker <= 0xCF && 0xC4 != marker && 0xC8 != marker && 0xCC != marker:
+			// start of frame: precision (1), height (2), width (2), ... these
+			// are the dimensions of the file, whatever the EXIF data says.
+			seg := make([]byte, 5)
+			if _, err := io.ReadFull(f, seg); nil != err {
+				return orientation, err
+			}
+			info.Width = int(binary.BigEndian.Uint16(seg[3:]))
+			info.Height = int(binary.BigEndian.Uint16(seg[1:]))
+			return orientation, nil
+		}
+		if _, err := f.Seek(length, io.SeekCurrent); nil != err {
+			return orientation, err
+		}
+	}
+}
+
+// type tiff reads the IFDs of a TIFF structure.
+type tiff struct {
+	r     io.ReaderAt
+	order binary.ByteOrder
+}
+
+// type entry is a single entry of an IFD.
+type entry struct {
+	typ   uint16 // type of the values
+	count uint32 // number of values
+	value []byte // the values themselves if they fit (4 bytes), else their offset
+}
+
+// function readTIFF() reads the EXIF data of the TIFF structure from the given
+// reader, including the dimensions of its main image (IFD0), which are those
+// of the file if it is TIFF-based. returns the orientation of the image, or 0
+// if none is given.
+func readTIFF(r io.ReaderAt, info *Info) (uint32, error) {
+
+	head := make([]byte, 8)
+	if _, err := r.ReadAt(head, 0); nil != err {
+		return 0, err
+	}
+	t := &tiff{r: r}
+	switch string(head[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return 0, malformed("TIFF header")
+	}
+	ifd0, err := t.ifd(int64(t.order.Uint32(head[4:])))
+	if nil != err {
+		return 0, err
+	}
+	info.Make = t.str(ifd0[tagMake])
+	info.Model = t.str(ifd0[tagModel])
+	taken := t.str(ifd0[tagDateTime])
+	width, height := t.num(ifd0[tagImageWidth]), t.num(ifd0[tagImageLength])
+	offset := ""
+
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if sub, err := t.ifd(int64(t.num(e))); nil == err {
+			if s := t.str(sub[tagDateOriginal]); "" != s {
+				taken = s
+			}
+			offset = t.str(sub[tagOffsetOrig])
+			if w, h := t.num(sub[tagPixelXDim]), t.num(sub[tagPixelYDim]); w > 0 && h > 0 {
+				width, height = w, h
+			}
+		}
+	}
+	info.Width, info.Height = int(width), int(height)
+
+	// EXIF dates are local to the camera, and rarely say which zone that was.
+	loc := time.Local
+	if z, err := time.Parse("-07:00", offset); nil == err {
+		_, sec := z.Zone()
+		loc = time.FixedZone(offset, sec)
+	}
+	if d, err := time.ParseInLocation(exifDateFormat, taken, loc); nil == err {
+		info.Taken = d
+	}
+	return t.num(ifd0[tagOrientation]), nil
+}
+
+// function ifd() reads the entries of the IFD at the given offset, keyed by
+// their tags.
+func (t *tiff) ifd(offset int64) (map[uint16]entry, error) {
+
+	n := make([]byte, 2)
+	if _, err := t.r.ReadAt(n, offset); nil != err {
+		return nil, err
+	}
+	count := int(t.order.Uint16(n))
+	if count > maxEntries {
+		return nil, malformed("TIFF IFD")
+	}
+	buf := make([]byte, 12*count)
+	if _, err := t.r.ReadAt(buf, offset+2); nil != err {
+		return nil, err
+	}
+	list := map[uint16]entry{}
+	for i := 0; i < count; i++ {
+		e := buf[12*i : 12*(i+1)]
+		list[t.order.Uint16(e)] = entry{
+			typ:   t.order.Uint16(e[2:]),
+			count: t.order.Uint32(e[4:]),
+			value: e[8:12],
+		}
+	}
+	return list, nil
+}
+
+// function num() returns the (first) value of the given SHORT or LONG entry,
+// or 0 if it is neither.
+func (t *tiff) num(e entry) uint32 {
+	switch e.typ {
+	case 3: // SHORT
+		return uint32(t.order.Uint16(e.value))
+	case 4, 13: // LONG, IFD
+		return t.order.Uint32(e.value)
+	}
+	return 0
+}
+
+// function str() returns the value of the given ASCII entry, or an empty
+// string if it isn't one.
+func (t *tiff) str(e entry) string {
+
+	if 2 != e.typ || 0 == e.count {
+		return ""
+	}
+	n := int(e.count)
+	if n > maxString {
+		n = maxString
+	}
+	buf := e.value
+	if n > 4 {
+		buf = make([]byte, n)
+		if _, err := t.r.ReadAt(buf, int64(t.order.Uint32(e.value))); nil != err {
+			return ""
+		}
+	}
+	return strings.TrimSpace(strings.TrimRight(string(buf[:n]), "\x00"))
+}
+
+// type formatError is a malformed structure found in an image file.
+type formatError string
+
+// function Error() returns the description of the formatError.
+func (e formatError) Error() string { return "malformed " + string(e) }
+
+// function malformed() returns a formatError naming the given structure.
+func malformed(what string) error { return formatError(what) }
@@ -0,0 +1,250 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: kodi.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines an exporter that writes Kodi-compatible .nfo files and artwork
+//    alongside each video so that a library can be consumed directly by Kodi.
+//
+// =============================================================================
+
+// package export writes the contents of library databases in the formats
+// understood by other media software.
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// var logs are the loggers of the messages about the exported files, filtered
+// as component "export" (see console.Register()).
+var logs = console.Register("export")
+
+// local unexported constants for the Kodi exporter.
+const (
+	kodiNFOExt     = ".nfo"                // file name extension of Kodi metadata
+	kodiDateFormat = "2006-01-02"          // date format used by Kodi
+	kodiTimeFormat = "2006-01-02 15:04:05" // datetime format used by Kodi
+	placeholder    = "--"                  // value of fields never set by user
+)
+
+// type kodiArtwork describes one kind of artwork recognized by Kodi. Kodi looks
+// for the artwork of a video in the file named "<video base name>-<kind><ext>"
+// in the video's directory; the generic names are those commonly used for the
+// artwork of an entire directory (e.g. a movie in its own folder).
+type kodiArtwork struct {
+	kind    string   // Kodi's name for the artwork kind
+	generic []string // base names of equivalent directory-wide artwork
+}
+
+var (
+	kodiArtworkKind = []kodiArtwork{
+		{kind: "poster", generic: []string{"poster", "folder", "cover", "movie"}},
+		{kind: "fanart", generic: []string{"fanart", "backdrop", "background"}},
+		{kind: "thumb", generic: []string{"thumb"}},
+	}
+	kodiArtworkExt = []string{".jpg", ".jpeg", ".png", ".tbn"}
+)
+
+// type kodiThumb is a single artwork element of a Kodi .nfo file.
+type kodiThumb struct {
+	Aspect string `xml:"aspect,attr,omitempty"`
+	Path   string `xml:",chardata"`
+}
+
+// type kodiFanart is the container element of the fanart in a Kodi .nfo file.
+type kodiFanart struct {
+	Thumb []kodiThumb `xml:"thumb"`
+}
+
+// type kodiMovie is the root element of a Kodi .nfo file describing a movie.
+// only the elements for which pimmp stores metadata are defined.
+type kodiMovie struct {
+	XMLName   xml.Name    `xml:"movie"`
+	Title     string      `xml:"title"`
+	Plot      string      `xml:"plot,omitempty"`
+	Premiered string      `xml:"premiered,omitempty"`
+	Year      int         `xml:"year,omitempty"`
+	DateAdded string      `xml:"dateadded,omitempty"`
+	MPAA      string      `xml:"mpaa,omitempty"`
+	Genre     []string    `xml:"genre,omitempty"`
+	Thumb     []kodiThumb `xml:"thumb"`
+	Fanart    *kodiFanart `xml:"fanart,omitempty"`
+}
+
+// type Kodi writes the .nfo files and artwork of videos.
+type Kodi struct {
+	overwrite bool // replace existing .nfo files
+}
+
+// function NewKodi() creates a new Kodi exporter. existing .nfo files are left
+// untouched unless overwrite is true, since they may well have been written or
+// curated by hand.
+func NewKodi(overwrite bool) *Kodi {
+	return &Kodi{overwrite: overwrite}
+}
+
+// function NFOPath() returns the path of the .nfo file of the given video.
+func NFOPath(v *media.VideoMedia) string {
+	return filepath.Join(v.AbsDir, v.AbsBase+kodiNFOExt)
+}
+
+// function ExportVideo() writes the .nfo file of the given video, linking any
+// artwork found in the video's directory to the names Kodi expects. returns
+// true if the .nfo file was written, or false if it was skipped because it
+// already exists.
+func (k *Kodi) ExportVideo(v *media.VideoMedia) (bool, *rc.ReturnCode) {
+
+	if nil == v || nil == v.Media || nil == v.Entity {
+		return false, rc.ExportError.Spec("ExportVideo(): no video provided")
+	}
+
+	nfoPath := NFOPath(v)
+	if _, err := os.Stat(nfoPath); nil == err && !k.overwrite {
+		logs.Info.Verbosef("skipping existing NFO: %q", nfoPath)
+		return false, nil
+	}
+
+	movie := &kodiMovie{
+		Title: kodiTitle(v),
+		Thumb: []kodiThumb{},
+	}
+	if placeholder != v.Description {
+		movie.Plot = v.Description
+	}
+	if !v.ReleaseDate.IsZero() {
+		movie.Premiered = v.ReleaseDate.Format(kodiDateFormat)
+		movie.Year = v.ReleaseDate.Year()
+	}
+	movie.MPAA = v.ContentRating
+	movie.Genre = v.Genres
+	if !v.TimeAdded.IsZero() {
+		movie.DateAdded = v.TimeAdded.Local().Format(kodiTimeFormat)
+	}
+
+	// artwork is referenced by file name relative to the .nfo file, so the
+	// video's directory can be moved wholesale without breaking anything.
+	for _, art := range kodiArtworkKind {
+		name, ret := linkArtwork(v, &art)
+		if nil != ret {
+			logs.Warn.Verbose(ret)
+			continue
+		}
+		if "" == name {
+			continue
+		}
+		if "fanart" == art.kind {
+			movie.Fanart = &kodiFanart{Thumb: []kodiThumb{{Path: name}}}
+		} else {
+			movie.Thumb = append(movie.Thumb, kodiThumb{Aspect: art.kind, Path: name})
+		}
+	}
+
+	data, err := xml.MarshalIndent(movie, "", "  ")
+	if nil != err {
+		return false, rc.ExportError.Specf(
+			"ExportVideo(%q): xml.MarshalIndent(): %s", nfoPath, err)
+	}
+	data = append([]byte(xml.Header), append(data, '\n')...)
+
+	if err := ioutil.WriteFile(nfoPath, data, 0644); nil != err {
+		return false, rc.ExportError.Specf(
+			"ExportVideo(%q): ioutil.WriteFile(): %s", nfoPath, err)
+	}
+	logs.Info.Verbosef("wrote NFO: %q", nfoPath)
+
+	return true, nil
+}
+
+// function kodiTitle() returns the title of the given video, falling back on
+// its displayed name and then its file name when no title has been set.
+func kodiTitle(v *media.VideoMedia) string {
+	for _, t := range []string{v.Title, v.Name} {
+		// the title and name default to the full file name, extension and
+		// all, which is never what anyone wants to see in Kodi.
+		if "" != t && placeholder != t && v.AbsName != t {
+			return t
+		}
+	}
+	return v.AbsBase
+}
+
+// function linkArtwork() finds the given kind of artwork for a video, returning
+// the file name (relative to the video's directory) of the artwork as Kodi
+// expects it, or "" if none was found. artwork already named for the video is
+// used as-is; otherwise, any directory-wide artwork of the same kind is hard
+// linked (or copied, if links aren't supported) to the expected name.
+func linkArtwork(v *media.VideoMedia, art *kodiArtwork) (string, *rc.ReturnCode) {
+
+	for _, ext := range kodiArtworkExt {
+		name := v.AbsBase + "-" + art.kind + ext
+		if _, err := os.Stat(filepath.Join(v.AbsDir, name)); nil == err {
+			return name, nil
+		}
+	}
+
+	for _, base := range art.generic {
+		for _, ext := range kodiArtworkExt {
+			src := filepath.Join(v.AbsDir, base+ext)
+			if info, err := os.Stat(src); nil != err || !info.Mode().IsRegular() {
+				continue
+			}
+			name := v.AbsBase + "-" + art.kind + ext
+			dst := filepath.Join(v.AbsDir, name)
+			if ret := linkOrCopy(src, dst); nil != ret {
+				return "", ret
+			}
+			logs.Info.Verbosef("linked artwork: %q -> %q", src, dst)
+			return name, nil
+		}
+	}
+
+	return "", nil
+}
+
+// function linkOrCopy() creates a hard link at dst to the file at src. if the
+// file system doesn't support hard links (or src and dst are on different
+// devices), the file is copied instead.
+func linkOrCopy(src, dst string) *rc.ReturnCode {
+
+	if err := os.Link(src, dst); nil == err {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if nil != err {
+		return rc.ExportError.Specf("linkOrCopy(%q): os.Open(): %s", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if nil != err {
+		return rc.ExportError.Specf("linkOrCopy(%q): os.Create(%q): %s", src, dst, err)
+	}
+	if _, err := io.Copy(out, in); nil != err {
+		out.Close()
+		os.Remove(dst)
+		return rc.ExportError.Specf("linkOrCopy(%q): io.Copy(%q): %s", src, dst, err)
+	}
+	if err := out.Close(); nil != err {
+		return rc.ExportError.Specf("linkOrCopy(%q): close(%q): %s", src, dst, err)
+	}
+
+	// preserve the modification time so the copy doesn't look like new artwork.
+	if info, err := os.Stat(src); nil == err {
+		os.Chtimes(dst, time.Now(), info.ModTime())
+	}
+	return nil
+}
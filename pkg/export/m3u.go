@@ -0,0 +1,85 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: m3u.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines an exporter that writes lists of media as extended M3U (.m3u8)
+//    playlists for use in other players.
+//
+// =============================================================================
+
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the M3U exporter.
+const (
+	m3uHeader      = "#EXTM3U"
+	m3uInfo        = "#EXTINF"
+	m3uUnknownTime = -1 // duration used when the length of media is unknown
+)
+
+// type M3U writes lists of media as extended M3U playlists encoded as UTF-8,
+// i.e. the .m3u8 format understood by nearly every media player.
+type M3U struct {
+	base string // directory to which paths are relative ("" for absolute paths)
+}
+
+// function NewM3U() creates a new M3U exporter. if base is non-empty, each path
+// in the playlist is written relative to the directory base -- which should be
+// the directory containing the playlist -- so that the playlist and media can
+// be moved together. otherwise, absolute paths are written.
+func NewM3U(base string) *M3U {
+	return &M3U{base: base}
+}
+
+// function Write() writes a playlist of the given media to w, in order.
+func (p *M3U) Write(w io.Writer, list []*media.Media) *rc.ReturnCode {
+
+	buf := bufio.NewWriter(w)
+	fmt.Fprintln(buf, m3uHeader)
+
+	for _, m := range list {
+		if nil == m || nil == m.Entity {
+			continue
+		}
+		path := m.AbsPath
+		if "" != p.base {
+			rel, err := filepath.Rel(p.base, m.AbsPath)
+			if nil != err {
+				return rc.ExportError.Specf(
+					"Write(): filepath.Rel(%q, %q): %s", p.base, m.AbsPath, err)
+			}
+			path = rel
+		}
+		fmt.Fprintf(buf, "%s:%d,%s\n", m3uInfo, m3uUnknownTime, m3uTitle(m))
+		fmt.Fprintln(buf, path)
+	}
+
+	if err := buf.Flush(); nil != err {
+		return rc.ExportError.Specf("Write(): %s", err)
+	}
+	return nil
+}
+
+// function m3uTitle() returns the title displayed for the given media. line
+// breaks would corrupt the playlist, so they are replaced with spaces.
+func m3uTitle(m *media.Media) string {
+	title := m.Title
+	if "" == title || placeholder == title || m.AbsName == title {
+		title = m.AbsBase
+	}
+	return strings.Join(strings.Fields(title), " ")
+}
@@ -0,0 +1,77 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: pls.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines an exporter that writes lists of media as PLS (.pls) playlists
+//    for use in other players.
+//
+// =============================================================================
+
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the PLS exporter.
+const (
+	plsHeader      = "[playlist]"
+	plsVersion     = 2
+	plsUnknownTime = -1 // length used when the length of media is unknown
+)
+
+// type PLS writes lists of media as PLS playlists, the INI-style format of
+// Winamp and SHOUTcast.
+type PLS struct {
+	base string // directory to which paths are relative ("" for absolute paths)
+}
+
+// function NewPLS() creates a new PLS exporter. paths are written relative to
+// base, if non-empty, as described by NewM3U().
+func NewPLS(base string) *PLS {
+	return &PLS{base: base}
+}
+
+// function Write() writes a playlist of the given media to w, in order.
+func (p *PLS) Write(w io.Writer, list []*media.Media) *rc.ReturnCode {
+
+	buf := bufio.NewWriter(w)
+	fmt.Fprintln(buf, plsHeader)
+
+	num := 0
+	for _, m := range list {
+		if nil == m || nil == m.Entity {
+			continue
+		}
+		path := m.AbsPath
+		if "" != p.base {
+			rel, err := filepath.Rel(p.base, m.AbsPath)
+			if nil != err {
+				return rc.ExportError.Specf(
+					"Write(): filepath.Rel(%q, %q): %s", p.base, m.AbsPath, err)
+			}
+			path = rel
+		}
+		num++
+		fmt.Fprintf(buf, "File%d=%s\n", num, path)
+		fmt.Fprintf(buf, "Title%d=%s\n", num, m3uTitle(m))
+		fmt.Fprintf(buf, "Length%d=%d\n", num, plsUnknownTime)
+	}
+	fmt.Fprintf(buf, "NumberOfEntries=%d\n", num)
+	fmt.Fprintf(buf, "Version=%d\n", plsVersion)
+
+	if err := buf.Flush(); nil != err {
+		return rc.ExportError.Specf("Write(): %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: filecache.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines a directory of cached files (downloaded artwork and generated
+//    thumbnails) limited in size, evicting the least recently used files
+//    once it grows past its limit.
+//
+// =============================================================================
+
+// package filecache keeps files that can always be downloaded or generated
+// again, e.g. artwork and thumbnails, in a directory limited in size. each file
+// is keyed by a slash-separated path relative to the directory, and its
+// modification time records when it was last used, so that once the files
+// grow larger than the limit, the least recently used are removed first.
+package filecache
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// exported constants for configuring the Cache.
+const (
+	DirName        = "cache"   // name of the cache directory, in the library data directory
+	DefaultMaxSize = 512 << 20 // size (bytes) to which the files are limited by default
+)
+
+// local unexported constants for the Cache.
+const (
+	tmpMark = ".tmp" // marks the name of a file being written
+	// the fraction of the limit to which the files are reduced once they grow
+	// past it, so that they aren't pruned again by every file added.
+	pruneTarget = 0.9
+	// the age of temporary files considered abandoned by an interrupted write,
+	// and removed by Prune().
+	tmpMaxAge = time.Hour
+)
+
+// type Entry describes a file in a Cache.
+type Entry struct {
+	Key  string    // path of the file, relative to the Cache directory
+	Path string    // absolute path of the file
+	Size int64     // length in bytes
+	Used time.Time // date the file was last used
+}
+
+// type Cache is a directory of files limited in size. a Cache is safe for use
+// by multiple goroutines; other processes may use the same directory, though
+// each only prunes the files it knows of when it does.
+type Cache struct {
+	dir     string
+	maxSize int64 // size to which the files are limited (0 = unlimited)
+	mutex   sync.Mutex
+	size    int64 // total size of the files, -1 until measured
+}
+
+// function New() creates a new Cache of the files in the given directory, which
+// is created when the first file is stored, limited to the given size in bytes
+// (0 = unlimited).
+func New(dir string, maxSize int64) *Cache {
+	return &Cache{dir: dir, maxSize: maxSize, size: -1}
+}
+
+// function Dir() returns the directory of the Cache.
+func (c *Cache) Dir() string { return c.dir }
+
+// function MaxSize() returns the size to which the files are limited.
+func (c *Cache) MaxSize() int64 { return c.maxSize }
+
+// function Path() returns the path of the file with the given key.
+func (c *Cache) Path(key string) string {
+	return filepath.Join(c.dir, filepath.FromSlash(key))
+}
+
+// function Touch() records that the file with the given key was just used,
+// keeping it from being evicted before the files used longer ago.
+func (c *Cache) Touch(key string) {
+	now := time.Now()
+	os.Chtimes(c.Path(key), now, now)
+}
+
+// function Get() returns the path of the file with the given key, if it is
+// cached, recording that it was used.
+func (c *Cache) Get(key string) (string, bool) {
+	path := c.Path(key)
+	if info, err := os.Stat(path); nil != err || info.IsDir() {
+		return "", false
+	}
+	c.Touch(key)
+	return path, true
+}
+
+// function Put() stores the file with the given key, written by the given
+// function to the temporary path it is given (which has the same extension),
+// then evicts the least recently used files if the Cache grew past its limit.
+// returns the path of the file stored.
+func (c *Cache) Put(key string, write func(tmp string) *rc.ReturnCode) (string, *rc.ReturnCode) {
+
+	path := c.Path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); nil != err {
+		return "", rc.InvalidPath.Specf("Put(%q): os.MkdirAll(): %s", key, err)
+	}
+	// the file is written under a temporary name first, so that an interrupted
+	// write never leaves behind a truncated file.
+	ext := filepath.Ext(path)
+	tmp := strings.TrimSuffix(path, ext) + tmpMark + ext
+	if ret := write(tmp); nil != ret {
+		os.Remove(tmp)
+		return "", ret
+	}
+	info, err := os.Stat(tmp)
+	if nil != err {
+		return "", rc.InvalidPath.Specf("Put(%q): os.Stat(): %s", key, err)
+	}
+	var replaced int64
+	if old, err := os.Stat(path); nil == err {
+		replaced = old.Size()
+	}
+	if err := os.Rename(tmp, path); nil != err {
+		os.Remove(tmp)
+		return "", rc.InvalidPath.Specf("Put(%q): os.Rename(): %s", key, err)
+	}
+
+	c.mutex.Lock()
+	if c.size >= 0 {
+		c.size += info.Size() - replaced
+	}
+	c.mutex.Unlock()
+	if ret := c.limit(key); nil != ret {
+		return path, ret
+	}
+	return path, nil
+}
+
+// function limit() evicts the least recently used files, other than the one
+// with the given key, if the Cache grew past its limit.
+func (c *Cache) limit(keep string) *rc.ReturnCode {
+
+	if c.maxSize <= 0 {
+		return nil
+	}
+	c.mutex.Lock()
+	size := c.size
+	c.mutex.Unlock()
+	if size < 0 {
+		_, total, ret := c.Entries()
+		if nil != ret {
+			return ret
+		}
+		size = total
+	}
+	if size <= c.maxSize {
+		c.mutex.Lock()
+		c.size = size
+		c.mutex.Unlock()
+		return nil
+	}
+	_, ret := c.prune(int64(float64(c.maxSize)*pruneTarget), keep, false)
+	return ret
+}
+
+// function Entries() returns every file in the Cache, least recently used
+// first, and their total size. files being written are omitted.
+func (c *Cache) Entries() ([]*Entry, int64, *rc.ReturnCode) {
+
+	list := []*Entry{}
+	var total int64
+	err := filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if nil != err {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || isTemp(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(c.dir, path)
+		if nil != err {
+			return err
+		}
+		list = append(list, &Entry{Key: filepath.ToSlash(rel), Path: path, Size: info.Size(), Used: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if nil != err {
+		return nil, 0, rc.InvalidPath.Specf("Entries(%q): %s", c.dir, err)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if !list[a].Used.Equal(list[b].Used) {
+			return list[a].Used.Before(list[b].Used)
+		}
+		return list[a].Key < list[b].Key
+	})
+	return list, total, nil
+}
+
+// function Prune() removes the least recently used files until the rest total
+// no more than the given size in bytes (0 removes them all), along with the
+// temporary files abandoned by interrupted writes. returns the files removed,
+// or that would be if dryRun is true, in which case nothing is removed.
+func (c *Cache) Prune(maxSize int64, dryRun bool) ([]*Entry, *rc.ReturnCode) {
+	return c.prune(maxSize, "", dryRun)
+}
+
+// function prune() removes the least recently used files, other than the one
+// with the given key, until the rest total no more than the given size.
+func (c *Cache) prune(maxSize int64, keep string, dryRun bool) ([]*Entry, *rc.ReturnCode) {
+
+	list, total, ret := c.Entries()
+	if nil != ret {
+		return nil, ret
+	}
+	removed := []*Entry{}
+	for _, e := range list {
+		if total <= maxSize {
+			break
+		}
+		if keep == e.Key {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(e.Path); nil != err && !os.IsNotExist(err) {
+				return removed, rc.InvalidPath.Specf("prune(%q): os.Remove(): %s", e.Key, err)
+			}
+		}
+		removed = append(removed, e)
+		total -= e.Size
+	}
+	if !dryRun {
+		c.removeAbandoned()
+		c.mutex.Lock()
+		c.size = total
+		c.mutex.Unlock()
+	}
+	return removed, nil
+}
+
+// function removeAbandoned() removes the temporary files left behind by writes
+// interrupted long ago, and the directories left empty.
+func (c *Cache) removeAbandoned() {
+
+	dirs := []string{}
+	filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if nil != err {
+			return nil
+		}
+		if info.IsDir() {
+			if path != c.dir {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		if isTemp(path) && time.Since(info.ModTime()) > tmpMaxAge {
+			os.Remove(path)
+		}
+		return nil
+	})
+	// the deepest directories are removed first, so that their parents may
+	// then be empty. removing a directory that isn't empty fails harmlessly.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+}
+
+// function isTemp() returns true if the file at the given path is being
+// written (see Put()).
+func isTemp(path string) bool {
+	base := filepath.Base(path)
+	return strings.HasSuffix(strings.TrimSuffix(base, filepath.Ext(base)), tmpMark)
+}
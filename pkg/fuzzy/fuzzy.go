@@ -0,0 +1,133 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: fuzzy.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    scores how well a search pattern matches some text, where the pattern's
+//    characters need only appear in the text in order, not adjacent, so that
+//    e.g. "lotr" finds "The Lord of the Rings".
+//
+// =============================================================================
+
+// package fuzzy ranks text by how well it matches an incomplete or abbreviated
+// search pattern, as typed incrementally by a user.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// the points awarded (or deducted) for each character of a pattern matched.
+const (
+	matchScore       = 16 // any character matched
+	consecutiveBonus = 16 // matched immediately after the previous match
+	boundaryBonus    = 12 // matched at the start of a word
+	maxGapPenalty    = 8  // most deducted for the characters skipped between matches
+	maxLeadPenalty   = 12 // most deducted for the characters skipped before the first match
+)
+
+// function Score() returns how well the given pattern matches the given text,
+// and whether it matches at all, i.e. all of the pattern's characters appear
+// in the text in the same order. case is ignored. matches of consecutive
+// characters and at the start of words score higher, and the characters
+// skipped score lower, so that the closest matches have the highest scores.
+// the empty pattern matches everything with a score of 0.
+func Score(pattern, text string) (int, bool) {
+
+	pat := []rune(strings.ToLower(pattern))
+	if 0 == len(pat) {
+		return 0, true
+	}
+	txt := []rune(text)
+	low := []rune(strings.ToLower(text))
+	if len(low) != len(txt) {
+		// a few runes change length when lowered, compare them unlowered.
+		low = txt
+	}
+
+	score, p, prev := 0, 0, -1
+	for t := 0; t < len(low) && p < len(pat); t++ {
+		if low[t] != pat[p] {
+			continue
+		}
+		s := matchScore
+		switch {
+		case prev >= 0 && t == prev+1:
+			s += consecutiveBonus
+		case 0 == t || isBoundary(txt[t-1], txt[t]):
+			s += boundaryBonus
+		}
+		if prev >= 0 {
+			s -= min(t-prev-1, maxGapPenalty)
+		} else {
+			s -= min(t, maxLeadPenalty)
+		}
+		score += s
+		prev = t
+		p++
+	}
+	if p < len(pat) {
+		return 0, false
+	}
+	return score, true
+}
+
+// function isBoundary() returns true if the given rune begins a word, given the
+// rune preceding it.
+func isBoundary(prev, curr rune) bool {
+	switch {
+	case !unicode.IsLetter(prev) && !unicode.IsDigit(prev):
+		return true
+	case unicode.IsLower(prev) && unicode.IsUpper(curr):
+		return true // camelCase
+	case unicode.IsLetter(prev) != unicode.IsLetter(curr):
+		return true // e.g. "S01E02"
+	}
+	return false
+}
+
+// function min() returns the lesser of the given integers.
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// type Match is an item matched by Rank().
+type Match struct {
+	Index int // index of the item matched
+	Score int // score of its best matching text
+}
+
+// function Rank() matches the given pattern against the texts of each of the
+// given number of items, returning those matched in order of decreasing score
+// (ties keep their original order). each item's score is that of its best
+// matching text. at most limit items are returned, unless limit is 0.
+func Rank(pattern string, count int, texts func(index int) []string, limit int) []Match {
+
+	match := []Match{}
+	for i := 0; i < count; i++ {
+		best, found := 0, false
+		for _, t := range texts(i) {
+			if s, ok := Score(pattern, t); ok && (!found || s > best) {
+				best, found = s, true
+			}
+		}
+		if found {
+			match = append(match, Match{Index: i, Score: best})
+		}
+	}
+	sort.SliceStable(match, func(i, j int) bool {
+		return match[i].Score > match[j].Score
+	})
+	if limit > 0 && len(match) > limit {
+		match = match[:limit]
+	}
+	return match
+}
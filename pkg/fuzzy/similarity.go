@@ -0,0 +1,71 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: similarity.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    measures how similar two names are, e.g. those of a video and of its
+//    subtitles, which are rarely identical but usually share most of their
+//    words and characters.
+//
+// =============================================================================
+
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+)
+
+// function Similarity() returns how similar the given names are, from 0 (no
+// characters in common) to 1 (identical), ignoring case and the separators
+// used between words (e.g. "Foo.Bar" and "foo bar" are identical). it is the
+// Dice coefficient of the names' character bigrams, which tolerates words
+// added, removed, or reordered (e.g. release info appended to one name).
+func Similarity(a, b string) float64 {
+
+	a, b = normalize(a), normalize(b)
+	if a == b {
+		return 1
+	}
+	ba, bb := bigrams(a), bigrams(b)
+	if 0 == len(ba) || 0 == len(bb) {
+		return 0
+	}
+	count := map[string]int{}
+	for _, g := range ba {
+		count[g]++
+	}
+	shared := 0
+	for _, g := range bb {
+		if count[g] > 0 {
+			count[g]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ba)+len(bb))
+}
+
+// function normalize() returns the given name in lower case, with each run of
+// characters other than letters and digits replaced by a single space.
+func normalize(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// function bigrams() returns every pair of adjacent characters of the given
+// text, in order.
+func bigrams(s string) []string {
+	r := []rune(s)
+	if len(r) < 2 {
+		return nil
+	}
+	g := make([]string, len(r)-1)
+	for i := range g {
+		g[i] = string(r[i : i+2])
+	}
+	return g
+}
@@ -0,0 +1,200 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: incoming.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    watches a designated "incoming" folder for new files, and moves them from
+//    there into a library once they are completely written.
+//
+// =============================================================================
+
+// package incoming watches a folder into which new media files are dropped,
+// e.g. by a download client, so that they can be moved into a library and
+// indexed automatically. the folder is polled rather than monitored, so that
+// it works the same on every platform and file system (including network
+// shares), and a file is only reported once its size and modification time
+// have stopped changing between two polls, i.e. once it is completely written.
+package incoming
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// partialSuffix lists the file name extensions commonly used by download
+// clients and browsers for files that are still being written.
+var partialSuffix = []string{
+	".part", ".partial", ".crdownload", ".download", ".tmp", ".!qb", ".!ut",
+}
+
+// type stamp identifies the state of a file at the time it was polled.
+type stamp struct {
+	size int64
+	mod  time.Time
+}
+
+// type Watcher polls a single incoming folder for new files.
+type Watcher struct {
+	dir     string           // absolute path of the incoming folder
+	pending map[string]stamp // files seen by the last poll, not yet settled
+	ignored map[string]stamp // files never reported again, unless changed
+}
+
+// function NewWatcher() creates a new Watcher of the folder at the given path,
+// which must exist.
+func NewWatcher(dir string) (*Watcher, *rc.ReturnCode) {
+
+	abs, err := filepath.Abs(dir)
+	if nil != err {
+		return nil, rc.InvalidPath.Specf("NewWatcher(%q): filepath.Abs(): %s", dir, err)
+	}
+	info, err := os.Stat(abs)
+	if nil != err {
+		return nil, rc.InvalidStat.Specf("NewWatcher(%q): os.Stat(): %s", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, rc.InvalidPath.Specf("NewWatcher(%q): not a directory", dir)
+	}
+	return &Watcher{dir: abs, pending: map[string]stamp{}, ignored: map[string]stamp{}}, nil
+}
+
+// function Dir() returns the absolute path of the incoming folder.
+func (w *Watcher) Dir() string { return w.dir }
+
+// function Poll() returns the absolute paths (sorted) of the files in the
+// incoming folder, or any of its subdirectories, that are unchanged since the
+// previous poll. files that appear to be partial downloads, and hidden files,
+// are never returned. problems reading the folder are returned, and the files
+// affected omitted.
+func (w *Watcher) Poll() ([]string, []*rc.ReturnCode) {
+
+	problems := []*rc.ReturnCode{}
+	settled := []string{}
+	current := map[string]stamp{}
+
+	filepath.Walk(w.dir, func(path string, info os.FileInfo, err error) error {
+		if nil != err {
+			problems = append(problems, rc.InvalidStat.Specf("Poll(%q): %s", w.dir, err))
+			return nil
+		}
+		if path == w.dir {
+			return nil
+		}
+		if isPartial(info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		now := stamp{size: info.Size(), mod: info.ModTime()}
+		if was, ok := w.ignored[path]; ok {
+			if was == now {
+				return nil
+			}
+			delete(w.ignored, path)
+		}
+		if was, ok := w.pending[path]; ok && was == now {
+			settled = append(settled, path)
+		} else {
+			current[path] = now
+		}
+		return nil
+	})
+
+	// forget the files that have since settled or disappeared.
+	w.pending = current
+	sort.Strings(settled)
+	return settled, problems
+}
+
+// function Ignore() prevents Poll() from returning the file at the given path
+// again, e.g. because it couldn't be moved, until the file is changed.
+func (w *Watcher) Ignore(path string) {
+	if info, err := os.Stat(path); nil == err {
+		w.ignored[path] = stamp{size: info.Size(), mod: info.ModTime()}
+	}
+}
+
+// function isPartial() returns true if the given file name is hidden, or looks
+// like that of a file still being downloaded.
+func isPartial(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+	lower := strings.ToLower(name)
+	for _, s := range partialSuffix {
+		if strings.HasSuffix(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// function Move() moves the file at path from to path to, creating any missing
+// parent directories of to. an existing file at path to is never replaced. if
+// the two paths are on different file systems, the file is copied and then the
+// original removed.
+func Move(from, to string) *rc.ReturnCode {
+
+	if _, err := os.Lstat(to); nil == err {
+		return rc.InvalidPath.Specf("Move(%q, %q): file exists", from, to)
+	}
+	if err := os.MkdirAll(filepath.Dir(to), os.ModePerm); nil != err {
+		return rc.InvalidPath.Specf("Move(%q, %q): os.MkdirAll(): %s", from, to, err)
+	}
+	if err := os.Rename(from, to); nil == err {
+		return nil
+	}
+	// the rename fails for any number of reasons, but only crossing file
+	// systems is worth the trouble of copying. copying will fail anyway for
+	// most of the others.
+	if ret := copyFile(from, to); nil != ret {
+		return ret
+	}
+	if err := os.Remove(from); nil != err {
+		return rc.InvalidFile.Specf("Move(%q, %q): os.Remove(): %s", from, to, err)
+	}
+	return nil
+}
+
+// function copyFile() copies the content and permissions of the file at path
+// from to a new file at path to. a partially written copy is removed.
+func copyFile(from, to string) *rc.ReturnCode {
+
+	src, err := os.Open(from)
+	if nil != err {
+		return rc.InvalidFile.Specf("copyFile(%q): os.Open(): %s", from, err)
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if nil != err {
+		return rc.InvalidStat.Specf("copyFile(%q): Stat(): %s", from, err)
+	}
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if nil != err {
+		return rc.InvalidFile.Specf("copyFile(%q): os.OpenFile(): %s", to, err)
+	}
+	_, err = io.Copy(dst, src)
+	if cerr := dst.Close(); nil == err {
+		err = cerr
+	}
+	if nil != err {
+		os.Remove(to)
+		return rc.InvalidFile.Specf("copyFile(%q, %q): %s", from, to, err)
+	}
+	os.Chtimes(to, info.ModTime(), info.ModTime())
+	return nil
+}
@@ -0,0 +1,101 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: added.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    keeps the index of the dates media were added to a library, by which the
+//    media added recently, or on this day in previous years, are found.
+//
+// =============================================================================
+
+package library
+
+import (
+	"time"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant onThisDayYears is the number of previous years searched for media
+// added on the same day (see OnThisDay()).
+const onThisDayYears = 50
+
+// function syncDates() brings the date added of each media in this library's
+// database (see DateAdded of Media) up to date with its time added, e.g. for
+// media recorded before it was, or whose time added was imported from another
+// media server.
+func (l *Library) syncDates() *rc.ReturnCode {
+
+	for kind := media.MediaKind(0); kind < media.KindCOUNT; kind++ {
+		col := l.db.Col[media.ClassMedia][kind]
+		changed := map[int]media.StorableEntity{}
+		col.ForEachDoc(
+			func(id int, data []byte) (willMoveOn bool) {
+				ent, med := newMediaOfKind(kind)
+				if nil == ent || nil != ent.FromRecord(data) || nil == med.Entity {
+					return true // move on to next record, Load() quarantines it
+				}
+				if date := media.DateOf(med.TimeAdded); date != med.DateAdded {
+					med.DateAdded = date
+					changed[id] = ent
+				}
+				return true // move on to next record
+			})
+		for id, ent := range changed {
+			rec, ret := ent.ToRecord()
+			if nil != ret {
+				return ret
+			}
+			if err := col.Update(id, *rec); nil != err {
+				return rc.DatabaseError.Specf(
+					"syncDates(): failed to update record (ID={%q,%X}): %s", l.name, id, err)
+			}
+		}
+		if len(changed) > 0 {
+			logs.Info.Verbosef("indexed the date added of %d %s: %q",
+				len(changed), l.db.ColName[media.ClassMedia][kind], l.name)
+		}
+	}
+	return nil
+}
+
+// function RecentDates() returns the dates (see DateAdded of Media) of the
+// given number of days ending with the day of the given time, most recent
+// first, by which the media added in those days are found (see LoadBy()).
+func RecentDates(now time.Time, days int) []string {
+	date := []string{}
+	for d := 0; d < days; d++ {
+		date = append(date, media.DateOf(now.AddDate(0, 0, -d)))
+	}
+	return date
+}
+
+// function OnThisDay() returns the dates (see DateAdded of Media) of the same
+// day as the given time in each previous year, most recent first, by which the
+// media added on this day in previous years are found (see LoadBy()). the 29th
+// of February is only found in leap years.
+func OnThisDay(now time.Time) []string {
+	now = now.Local()
+	date := []string{}
+	for y := 1; y <= onThisDayYears; y++ {
+		t := time.Date(now.Year()-y, now.Month(), now.Day(), 12, 0, 0, 0, time.Local)
+		if t.Month() == now.Month() {
+			date = append(date, media.DateOf(t))
+		}
+	}
+	return date
+}
+
+// function IsOnThisDay() returns true if the given media was added on the same
+// day as the given time in a previous year (see OnThisDay()).
+func IsOnThisDay(m *media.Media, now time.Time) bool {
+	if nil == m || m.TimeAdded.IsZero() {
+		return false
+	}
+	added, now := m.TimeAdded.Local(), now.Local()
+	return added.Year() < now.Year() && added.Month() == now.Month() && added.Day() == now.Day()
+}
@@ -0,0 +1,139 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: artwork.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the operations on the artwork files (covers, posters, folder
+//    images) stored in a library's database: discovering them during scans and
+//    associating each with the media it depicts.
+//
+// =============================================================================
+
+package library
+
+import (
+	"path"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// function syncArtwork() brings the artwork of each media in this library's
+// database up to date with the artwork files known: each media is associated
+// with the most preferred artwork depicting it (see Rank() and Depicts() of
+// Artwork), and the association of media whose artwork is gone is removed.
+func (l *Library) syncArtwork() *rc.ReturnCode {
+
+	// group the artwork by the directory it is found in, which is where it is
+	// looked up from (see Depicts()).
+	byDir := map[string][]*media.Artwork{}
+	l.db.Col[media.ClassSupport][media.SupportArtwork].ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			art := &media.Artwork{}
+			if nil != art.FromRecord(data) {
+				return true // move on to next record, Load() quarantines it
+			}
+			byDir[art.AbsDir] = append(byDir[art.AbsDir], art)
+			return true // move on to next record
+		})
+
+	for kind := media.MediaKind(0); kind < media.KindCOUNT; kind++ {
+		if media.KindImage == kind {
+			continue // a photo is its own picture
+		}
+		col := l.db.Col[media.ClassMedia][kind]
+		changed := map[int]media.StorableEntity{}
+		col.ForEachDoc(
+			func(id int, data []byte) (willMoveOn bool) {
+				med := &media.Media{}
+				var ent media.StorableEntity
+				switch kind {
+				case media.KindAudio:
+					ent = &media.AudioMedia{Media: med}
+				case media.KindVideo:
+					ent = &media.VideoMedia{Media: med}
+				case media.KindDocument:
+					ent = &media.DocumentMedia{Media: med}
+				}
+				if nil != ent.FromRecord(data) || nil == med.Entity {
+					return true // move on to next record, Load() quarantines it
+				}
+				best := ""
+				if art := bestArtwork(byDir, med.AbsDir, med.AbsBase); nil != art {
+					best = art.AbsPath
+				}
+				if best != med.ArtworkFile {
+					med.ArtworkFile = best
+					changed[id] = ent
+				}
+				return true // move on to next record
+			})
+		for id, ent := range changed {
+			rec, ret := ent.ToRecord()
+			if nil != ret {
+				return ret
+			}
+			if err := col.Update(id, *rec); nil != err {
+				return rc.DatabaseError.Specf(
+					"syncArtwork(): failed to update record (ID={%q,%X}): %s", l.name, id, err)
+			}
+			logs.Info.Tracef("updated artwork of %s (ID={%q,%X})",
+				media.MediaColName[kind], l.name, id)
+		}
+	}
+	return nil
+}
+
+// function bestArtwork() returns the most preferred of the given artwork,
+// grouped by directory, depicting the media with the given base name in the
+// given directory, or nil if none depicts it.
+func bestArtwork(byDir map[string][]*media.Artwork, dir, base string) *media.Artwork {
+
+	var best *media.Artwork
+	for _, d := range []string{dir, path.Dir(dir)} {
+		for _, art := range byDir[d] {
+			if !art.Depicts(dir, base) {
+				continue
+			}
+			if nil == best || art.Rank() < best.Rank() ||
+				(art.Rank() == best.Rank() && art.AbsPath < best.AbsPath) {
+				best = art
+			}
+		}
+		if nil != best {
+			break // artwork of the media's own directory is preferred
+		}
+	}
+	return best
+}
+
+// function ArtworkOf() returns the path of the artwork file depicting the media
+// at the given absolute path, or "" if it has none (or isn't in this library).
+func (l *Library) ArtworkOf(absPath string) string {
+
+	kind, id, ret := l.findMedia(absPath)
+	if nil != ret || media.KindUnknown == kind {
+		return ""
+	}
+	med := &media.Media{}
+	var ent media.StorableEntity
+	switch kind {
+	case media.KindAudio:
+		ent = &media.AudioMedia{Media: med}
+	case media.KindVideo:
+		ent = &media.VideoMedia{Media: med}
+	case media.KindImage:
+		ent = &media.ImageMedia{Media: med}
+	case media.KindDocument:
+		ent = &media.DocumentMedia{Media: med}
+	}
+	if nil != ent.FromID(l.db.Col[media.ClassMedia][kind], id) {
+		return ""
+	}
+	return med.ArtworkFile
+}
@@ -0,0 +1,191 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: bus.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the event bus on which the loaders, scanners, and watchers of the
+//    libraries publish what they find, and to which any number of subscribers
+//    (the TUI, the log, the web interface, the plugins, etc.) register
+//    independently.
+//
+// =============================================================================
+
+package library
+
+import (
+	"sync"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type EventKind is an enum identifying the kinds of Event published on a Bus.
+type EventKind int
+
+const (
+	EventUnknown      EventKind = iota - 1 // = -1
+	MediaDiscovered                        // =  0 a media file was found
+	SupportDiscovered                      // =  1 a support file was found
+	OtherDiscovered                        // =  2 a file neither media nor support was found
+	FileRemoved                            // =  3 the record of a file was removed
+	ScanFinished                           // =  4 a scan of a library finished
+	EventCOUNT                             // =  5
+)
+
+var (
+	// variable EventKindName maps the EventKind enum values to their names.
+	EventKindName = [EventCOUNT]string{
+		"media discovered",   // 0 = MediaDiscovered
+		"support discovered", // 1 = SupportDiscovered
+		"other discovered",   // 2 = OtherDiscovered
+		"file removed",       // 3 = FileRemoved
+		"scan finished",      // 4 = ScanFinished
+	}
+)
+
+// function String() returns the name of the EventKind.
+func (k EventKind) String() string {
+	if k > EventUnknown && k < EventCOUNT {
+		return EventKindName[k]
+	}
+	return "unknown"
+}
+
+// type EventSource is an enum identifying what published an Event.
+type EventSource int
+
+const (
+	SourceUnknown EventSource = iota - 1 // = -1
+	SourceLoad                           // =  0 read from the database (see Load())
+	SourceScan                           // =  1 found by file system traversal (see Scan())
+	SourceWatch                          // =  2 found changed on the file system (see Watch())
+	SourceCommand                        // =  3 changed by a command (see RemoveMedia())
+	SourceCOUNT                          // =  4
+)
+
+// type Event is a single occurrence published on a Bus.
+type Event struct {
+	Kind      EventKind      // what occurred
+	Source    EventSource    // what published the event
+	Library   *Library       // library concerned
+	Path      string         // absolute path of the file concerned (empty for ScanFinished)
+	Discovery *Discovery     // file entity concerned (nil for ScanFinished)
+	New       bool           // the file's record was just inserted, not already known (*Discovered)
+	Record    []byte         // JSON record of the file removed, nil if still in its collection (FileRemoved)
+	Found     uint           // number of media found (ScanFinished)
+	Updated   uint           // number of files found changed (ScanFinished)
+	Ignored   uint           // number of files and directories skipped (ScanFinished)
+	Elapsed   time.Duration  // time taken by the scan (ScanFinished)
+	Err       *rc.ReturnCode // error ending the scan, nil if it completed (ScanFinished)
+}
+
+// type subscriber is a function registered with a Bus, along with the kinds of
+// Event it receives.
+type subscriber struct {
+	id   uint64
+	kind map[EventKind]bool // kinds received (nil = all)
+	fn   func(Event)
+}
+
+// type Bus delivers the events published to every subscriber registered for
+// them. events are delivered synchronously, in the order published, to each
+// subscriber in the order registered, so subscribers should return quickly.
+// the nil Bus discards every event.
+type Bus struct {
+	mutex  sync.RWMutex
+	sub    []*subscriber
+	nextID uint64
+}
+
+// function NewBus() creates a new Bus without any subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// function Subscribe() registers the given function to receive the events of
+// the given kinds published on the Bus, or of every kind if none are given.
+// returns the function unregistering it.
+func (b *Bus) Subscribe(fn func(Event), kind ...EventKind) func() {
+
+	s := &subscriber{fn: fn}
+	if len(kind) > 0 {
+		s.kind = map[EventKind]bool{}
+		for _, k := range kind {
+			s.kind[k] = true
+		}
+	}
+
+	b.mutex.Lock()
+	b.nextID++
+	s.id = b.nextID
+	b.sub = append(b.sub, s)
+	b.mutex.Unlock()
+
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		for i, t := range b.sub {
+			if s.id == t.id {
+				b.sub = append(b.sub[:i:i], b.sub[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// function Publish() delivers the given event to each subscriber registered for
+// its kind.
+func (b *Bus) Publish(e Event) {
+
+	if nil == b {
+		return
+	}
+	b.mutex.RLock()
+	sub := b.sub
+	b.mutex.RUnlock()
+
+	for _, s := range sub {
+		if nil == s.kind || s.kind[e.Kind] {
+			s.fn(e)
+		}
+	}
+}
+
+// type publisher publishes the files found by a single load, scan, or watch of
+// a library on a Bus, as events from the given source. the nil publisher
+// discards every event.
+type publisher struct {
+	bus    *Bus
+	source EventSource
+}
+
+// function newPublisher() returns a publisher of the events from the given
+// source on the given Bus, or nil if the Bus is nil.
+func newPublisher(b *Bus, source EventSource) *publisher {
+	if nil == b {
+		return nil
+	}
+	return &publisher{bus: b, source: source}
+}
+
+// function publish() publishes the given file entity as an event of the given
+// kind, which is new if the file's record was just inserted.
+func (p *publisher) publish(kind EventKind, d *Discovery, isNew bool) {
+	if nil != p {
+		p.bus.Publish(Event{Kind: kind, Source: p.source, Library: d.Library,
+			Path: d.AbsPath, Discovery: d, New: isNew})
+	}
+}
+
+// function remove() publishes the removal of the given file entity, whose
+// record rec was removed from its collection (or nil if it wasn't, e.g. a part
+// of a release grouped with its first part).
+func (p *publisher) remove(d *Discovery, rec []byte) {
+	if nil != p {
+		p.bus.Publish(Event{Kind: FileRemoved, Source: p.source, Library: d.Library,
+			Path: d.AbsPath, Discovery: d, Record: rec})
+	}
+}
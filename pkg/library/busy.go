@@ -0,0 +1,299 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: busy.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the busy state shared by all goroutines wishing to indicate to the
+//    UI that they are active, and how far along they are, and the subscriptions
+//    through which any number of UI components observe its changes.
+//
+// =============================================================================
+
+package library
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// local unexported constants for the busy state.
+const (
+	busyChangeBuffer     = 8                      // changes queued for each subscriber before dropping the oldest
+	busyProgressInterval = 250 * time.Millisecond // shortest time between the progress published of each task
+)
+
+// type BusyTask describes the progress of a single busy goroutine.
+type BusyTask struct {
+	Label string    // what the goroutine is busy with
+	Start time.Time // time at which it began
+	Done  int64     // number of items processed
+	Total int64     // number of items expected to be processed (0 if unknown)
+	Bytes int64     // number of bytes processed
+
+	published time.Time // time at which its progress was last published
+}
+
+// function Fraction() returns the fraction (0 to 1) of the items expected that
+// have been processed, and false if the number expected is unknown.
+func (t BusyTask) Fraction() (float64, bool) {
+	if t.Total <= 0 {
+		return 0, false
+	}
+	if t.Done >= t.Total {
+		return 1, true // the total is only an estimate, it may be exceeded.
+	}
+	return float64(t.Done) / float64(t.Total), true
+}
+
+// function Rate() returns the number of bytes processed per second since the
+// task began.
+func (t BusyTask) Rate() float64 {
+	if elapsed := time.Since(t.Start).Seconds(); elapsed > 0 {
+		return float64(t.Bytes) / elapsed
+	}
+	return 0
+}
+
+// function ETA() returns the estimated time remaining until the items expected
+// have all been processed, at the rate they have been so far, and false if it
+// can't be estimated yet.
+func (t BusyTask) ETA() (time.Duration, bool) {
+	frac, ok := t.Fraction()
+	if !ok || 0 == t.Done {
+		return 0, false
+	}
+	elapsed := time.Since(t.Start)
+	return time.Duration(float64(elapsed)/frac) - elapsed, true
+}
+
+// type BusyChange describes the busy state immediately after a goroutine has
+// declared itself busy or done.
+type BusyChange struct {
+	Count int        // number of busy goroutines
+	Tasks []BusyTask // progress of each busy goroutine, in the order they began
+}
+
+// type BusyState keeps track of the number of goroutines that are wishing to
+// indicate to the UI that they are active or busy, that the user should hold
+// their horses. each change, including the progress each goroutine reports, is
+// published to every subscriber without ever blocking, so goroutines may
+// declare themselves busy whether or not anyone is listening. all of its
+// methods are safe to call on a nil BusyState, which is never busy.
+type BusyState struct {
+	busyCycle uint64 // number of UI updates performed while busy (first for 64-bit alignment of atomic ops)
+
+	lock  sync.Mutex
+	tasks []*BusyTask                           // progress of each busy goroutine
+	subs  map[<-chan BusyChange]chan BusyChange // subscribers, keyed by their receive-only channel
+}
+
+// function NewBusyState() instantiates a new BusyState object with zeroized
+// counter and update cycle, and no subscribers.
+func NewBusyState() *BusyState {
+	return &BusyState{
+		busyCycle: 0,
+		tasks:     []*BusyTask{},
+		subs:      map[<-chan BusyChange]chan BusyChange{},
+	}
+}
+
+// function Subscribe() returns a channel on which each subsequent change to the
+// busy state is sent, beginning with the current state. a subscriber that
+// falls behind misses the oldest of the changes queued for it, but always
+// receives the most recent. the channel is closed by Unsubscribe().
+func (s *BusyState) Subscribe() <-chan BusyChange {
+	if nil == s {
+		return nil
+	}
+	ch := make(chan BusyChange, busyChangeBuffer)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.subs[ch] = ch
+	ch <- s.change()
+	return ch
+}
+
+// function Unsubscribe() stops sending changes on the given channel, returned
+// by Subscribe(), and closes it.
+func (s *BusyState) Unsubscribe(ch <-chan BusyChange) {
+	if nil == s {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if c, ok := s.subs[ch]; ok {
+		delete(s.subs, ch)
+		close(c)
+	}
+}
+
+// function Count() safely returns the number of goroutines currently declaring
+// themselves as busy.
+func (s *BusyState) Count() int {
+	if nil == s {
+		return 0
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.tasks)
+}
+
+// function Tasks() safely returns the progress of each goroutine currently
+// declaring itself as busy, in the order they began.
+func (s *BusyState) Tasks() []BusyTask {
+	if nil == s {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.change().Tasks
+}
+
+// function Inc() safely increments the number of goroutines currently declaring
+// themselves as busy by 1, labeling the task it is busy with.
+func (s *BusyState) Inc(label string) int {
+	if nil == s {
+		return 0
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.tasks = append(s.tasks, &BusyTask{Label: label, Start: time.Now()})
+	// reset the cycle if we were not busy before this increment
+	if 1 == len(s.tasks) {
+		s.Reset()
+	}
+	s.publish()
+	return len(s.tasks)
+}
+
+// function Dec() safely decrements the number of goroutines currently declaring
+// themselves as busy by 1, removing the most recent task with the given label.
+// the count is unchanged if no such task is busy.
+func (s *BusyState) Dec(label string) int {
+	if nil == s {
+		return 0
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i := len(s.tasks) - 1; i >= 0; i-- {
+		if label == s.tasks[i].Label {
+			s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
+			// reset the cycle if we are not busy after this decrement
+			if 0 == len(s.tasks) {
+				s.Reset()
+			}
+			s.publish()
+			break
+		}
+	}
+	return len(s.tasks)
+}
+
+// function SetTotal() safely sets the number of items the most recent task
+// with the given label expects to process, e.g. as estimated from the last time
+// it was performed.
+func (s *BusyState) SetTotal(label string, total int64) {
+	if nil == s {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if t := s.task(label); nil != t {
+		t.Total = total
+		t.published = time.Now()
+		s.publish()
+	}
+}
+
+// function Advance() safely adds the given number of items and bytes to those
+// processed by the most recent task with the given label. to spare the
+// subscribers, the progress of each task is published at most once every
+// busyProgressInterval.
+func (s *BusyState) Advance(label string, items, bytes int64) {
+	if nil == s {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if t := s.task(label); nil != t {
+		t.Done += items
+		t.Bytes += bytes
+		if now := time.Now(); now.Sub(t.published) >= busyProgressInterval {
+			t.published = now
+			s.publish()
+		}
+	}
+}
+
+// function Cycle() returns the number of iterations that have elapsed since the
+// the beginning of the current busy state (returns 0 if not busy).
+func (s *BusyState) Cycle() int {
+	if nil == s {
+		return 0
+	}
+	cycle := atomic.LoadUint64(&s.busyCycle)
+	return int(cycle)
+}
+
+// function Next() safely increments by 1 the UI cycles elapsed since the
+// current busy state was initiated.
+func (s *BusyState) Next() int {
+	if nil == s {
+		return 0
+	}
+	cycle := atomic.AddUint64(&s.busyCycle, 1)
+	return int(cycle)
+}
+
+// function Reset() safely resets the current UI cycles elapsed to 0.
+func (s *BusyState) Reset() {
+	if nil == s {
+		return
+	}
+	atomic.StoreUint64(&s.busyCycle, 0)
+}
+
+// function task() returns the most recent task with the given label, or nil if
+// no such task is busy. the lock must be held.
+func (s *BusyState) task(label string) *BusyTask {
+	for i := len(s.tasks) - 1; i >= 0; i-- {
+		if label == s.tasks[i].Label {
+			return s.tasks[i]
+		}
+	}
+	return nil
+}
+
+// function change() returns the current busy state. the lock must be held.
+func (s *BusyState) change() BusyChange {
+	tasks := make([]BusyTask, len(s.tasks))
+	for i, t := range s.tasks {
+		tasks[i] = *t
+	}
+	return BusyChange{Count: len(s.tasks), Tasks: tasks}
+}
+
+// function publish() sends the current busy state to every subscriber without
+// blocking: if a subscriber's queue is full, its oldest change is dropped to
+// make room. the lock must be held, so that this is the only sender.
+func (s *BusyState) publish() {
+	c := s.change()
+	for _, ch := range s.subs {
+		select {
+		case ch <- c:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- c:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: cuesheet.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the operations on the cue sheets stored in a library's database:
+//    keeping a media record of each track they describe, so that an album
+//    ripped to a single audio file is browsed track by track.
+//
+// =============================================================================
+
+package library
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/cue"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type cueTrack is a track described by a cue sheet, located in its image.
+type cueTrack struct {
+	image  string        // absolute path of the audio file containing the track
+	length time.Duration // duration of the image file, 0 if unknown
+	sheet  *cue.Sheet    // cue sheet describing the track
+	track  cue.Track     // the track itself
+	end    time.Duration // offset into the image at which the track ends, 0 if with it
+}
+
+// function syncCueSheets() brings the tracks of the cue sheets in this
+// library's database up to date with the cue sheets known: each track of a
+// cue sheet is recorded as an audio media of its own (see TrackPath()), located
+// by its offsets into the image file containing it. new tracks are published
+// like the media discovered by a scan. the tracks of cue sheets which have
+// since changed are relocated (but keep any edits made to their titles, etc.),
+// and those of cue sheets which are gone are removed.
+func (l *Library) syncCueSheets(pub *publisher) *rc.ReturnCode {
+
+	// read every cue sheet, collecting the tracks they describe by path.
+	want := map[string]*cueTrack{}
+	l.db.Col[media.ClassSupport][media.SupportCueSheet].ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			cs := &media.CueSheet{}
+			if nil != cs.FromRecord(data) {
+				return true // move on to next record, Load() quarantines it
+			}
+			sheet, ret := cue.Read(cs.AbsPath)
+			if nil != ret {
+				logs.Warn.Verbosef("cannot read cue sheet, ignoring: %q: %s", cs.AbsPath, ret)
+				return true // move on to next record
+			}
+			for _, file := range sheet.Files {
+				image := cs.ImagePath(file.Name)
+				if "" == image {
+					logs.Info.Tracef("no image file %q of cue sheet: %q", file.Name, cs.AbsPath)
+					continue
+				}
+				for i, track := range file.Tracks {
+					want[media.TrackPath(image, track.Number)] =
+						&cueTrack{image: image, sheet: sheet, track: track, end: file.End(i)}
+				}
+			}
+			return true // move on to next record
+		})
+
+	// the last track of each image lasts as long as what remains of it.
+	length := map[string]time.Duration{}
+	for _, ct := range want {
+		if _, ok := length[ct.image]; !ok {
+			length[ct.image] = l.imageDuration(ct.image)
+		}
+		ct.length = length[ct.image]
+	}
+
+	// relocate the tracks already known, and remove those no longer described.
+	col := l.db.Col[media.ClassMedia][media.KindAudio]
+	changed := map[int]*media.AudioMedia{}
+	removed := map[int]string{}
+	known := map[string]bool{}
+	col.ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			audio := &media.AudioMedia{}
+			if nil != audio.FromRecord(data) || nil == audio.Media || nil == audio.Entity || !audio.IsTrack() {
+				return true // move on to next record
+			}
+			ct, ok := want[audio.AbsPath]
+			if !ok {
+				removed[id] = audio.AbsPath
+				return true // move on to next record
+			}
+			known[audio.AbsPath] = true
+			if locateTrack(audio, ct) {
+				changed[id] = audio
+			}
+			return true // move on to next record
+		})
+
+	for id, absPath := range removed {
+		if err := col.Delete(id); nil != err {
+			return rc.DatabaseError.Specf(
+				"syncCueSheets(): failed to delete record (ID={%q,%X}): %s", l.name, id, err)
+		}
+		logs.Info.Tracef("removed track of cue sheet (ID={%q,%X}): %q", l.name, id, absPath)
+	}
+	for id, audio := range changed {
+		rec, ret := audio.ToRecord()
+		if nil != ret {
+			return ret
+		}
+		if err := col.Update(id, *rec); nil != err {
+			return rc.DatabaseError.Specf(
+				"syncCueSheets(): failed to update record (ID={%q,%X}): %s", l.name, id, err)
+		}
+		logs.Info.Tracef("relocated track of cue sheet (ID={%q,%X}): %q", l.name, id, audio.AbsPath)
+	}
+
+	for absPath, ct := range want {
+		if known[absPath] {
+			continue
+		}
+		if ret := l.insertTrack(pub, absPath, ct); nil != ret {
+			return ret
+		}
+	}
+	return nil
+}
+
+// function insertTrack() inserts a new audio media into this library's
+// database for the given track of a cue sheet, identified by the given path,
+// and publishes it as new media.
+func (l *Library) insertTrack(pub *publisher, absPath string, ct *cueTrack) *rc.ReturnCode {
+
+	info, err := os.Stat(ct.image)
+	if nil != err {
+		return rc.InvalidStat.Specf("insertTrack(%q): os.Stat(): %s", absPath, err)
+	}
+	relPath, err := filepath.Rel(l.absPath, ct.image)
+	if nil != err {
+		relPath = ct.image
+	}
+	ext := filepath.Ext(ct.image)
+	_, extName := media.MediaKindOfFileExt(ext)
+
+	audio := media.NewAudioMedia(absPath, media.TrackPath(relPath, ct.track.Number), ext, extName, info)
+	name := ct.track.Title
+	if "" == name {
+		name = fmt.Sprintf("Track %02d", ct.track.Number)
+	}
+	audio.Name, audio.Title = name, name
+	audio.Artist = ct.track.Performer
+	audio.Album = ct.sheet.Title
+	audio.Track = ct.track.Number
+	locateTrack(audio, ct)
+
+	rec, ret := audio.ToRecord()
+	if nil != ret {
+		return ret
+	}
+	col := l.db.Col[media.ClassMedia][media.KindAudio]
+	id, insErr := col.Insert(*rec)
+	if nil != insErr {
+		return rc.DatabaseError.Specf(
+			"insertTrack(%q): failed to insert record: %s", absPath, insErr)
+	}
+	l.db.NumRecordsScan[media.ClassMedia][media.KindAudio]++
+	logs.Info.Tracef("discovered track of cue sheet (ID={%q,%X}): %s", l.name, id, audio)
+
+	l.handleMedia(pub, absPath, audio, audio.Media, id, true)
+	return nil
+}
+
+// function locateTrack() sets the image file and offsets of the given audio
+// media to those of the given track of a cue sheet. the duration of the last
+// track of an image is known only if the duration of the image is. returns
+// true if any were changed.
+func locateTrack(audio *media.AudioMedia, ct *cueTrack) bool {
+
+	end := ct.end
+	duration := time.Duration(0)
+	if end > 0 {
+		duration = end - ct.track.Start
+	} else if ct.length > ct.track.Start {
+		duration = ct.length - ct.track.Start
+	}
+	if audio.ImageFile == ct.image && audio.Start == ct.track.Start &&
+		audio.End == end && (0 == duration || audio.Duration == duration) {
+		return false
+	}
+	audio.ImageFile, audio.Start, audio.End = ct.image, ct.track.Start, end
+	if duration > 0 {
+		audio.Duration = duration
+	}
+	return true
+}
+
+// function imageDuration() returns the duration of the audio media of the
+// given image file in this library's database, or 0 if it is unknown.
+func (l *Library) imageDuration(image string) time.Duration {
+
+	kind, id, ret := l.findMedia(image)
+	if nil != ret || media.KindAudio != kind {
+		return 0
+	}
+	audio := &media.AudioMedia{Media: &media.Media{}}
+	if nil != audio.FromID(l.db.Col[media.ClassMedia][kind], id) || nil == audio.Entity {
+		return 0
+	}
+	return audio.Duration
+}
@@ -0,0 +1,111 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: filter.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the filter skipping the audio and video files not worth adding to
+//    a library, i.e. those too small to be anything but fragments, and the
+//    samples and trailers accompanying a release.
+//
+// =============================================================================
+
+package library
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Filter selects the audio and video files skipped by scans, which are
+// handled like files that aren't media at all. the zero value skips none.
+type Filter struct {
+	MinSize    int64    // size (in bytes) below which files are skipped (0 = none)
+	Sample     []string // glob patterns of the names of samples and trailers, or of their directories
+	SampleSize int64    // size (in bytes) up to which files matching Sample are skipped (0 = any)
+}
+
+// function NewFilter() creates a new Filter from the given sizes, in the form
+// accepted by ParseSize(), and patterns, which are matched against the base
+// name of each file and that of its directory, ignoring case.
+func NewFilter(minSize string, sample []string, sampleSize string) (Filter, *rc.ReturnCode) {
+
+	var f Filter
+	var ret *rc.ReturnCode
+	if f.MinSize, ret = ParseSize(minSize); nil != ret {
+		return Filter{}, ret
+	}
+	if f.SampleSize, ret = ParseSize(sampleSize); nil != ret {
+		return Filter{}, ret
+	}
+	for _, p := range sample {
+		if p = strings.ToLower(strings.TrimSpace(p)); "" == p {
+			continue
+		}
+		if _, err := filepath.Match(p, ""); nil != err {
+			return Filter{}, rc.InvalidArgs.Specf("NewFilter(): invalid pattern: %q: %s", p, err)
+		}
+		f.Sample = append(f.Sample, p)
+	}
+	return f, nil
+}
+
+// function ParseSize() parses the given size, a number optionally followed by
+// a unit, e.g. "50MB". the units are B (the default), KB, MB, GB (powers of
+// 1000) and KiB, MiB, GiB (powers of 1024). the empty string is 0.
+func ParseSize(s string) (int64, *rc.ReturnCode) {
+
+	lower := strings.ToLower(strings.TrimSpace(s))
+	if "" == lower {
+		return 0, nil
+	}
+	i := strings.IndexFunc(lower, func(c rune) bool {
+		return (c < '0' || c > '9') && '.' != c
+	})
+	name := "b"
+	if i < 0 {
+		i = len(lower)
+	} else if name = strings.TrimSpace(lower[i:]); "files" == name {
+		return 0, rc.InvalidArgs.Specf("ParseSize(): invalid size: %q", s)
+	}
+	n, err := strconv.ParseFloat(lower[:i], 64)
+	unit, ok := scanRateUnit[name]
+	if nil != err || !ok || n < 0 {
+		return 0, rc.InvalidArgs.Specf("ParseSize(): invalid size: %q", s)
+	}
+	return int64(n * unit), nil
+}
+
+// function skips() returns the reason the file of the given kind of media, at
+// the given path relative to the library root, of the given size is skipped, or
+// the empty string if it isn't.
+func (f Filter) skips(kind media.MediaKind, relPath string, size int64) string {
+
+	if media.KindAudio != kind && media.KindVideo != kind {
+		return ""
+	}
+	if f.MinSize > 0 && size < f.MinSize {
+		return "smaller than the minimum size"
+	}
+	if f.SampleSize > 0 && size > f.SampleSize {
+		return ""
+	}
+	name := []string{strings.ToLower(filepath.Base(relPath))}
+	if dir := filepath.Dir(relPath); "." != dir {
+		name = append(name, strings.ToLower(filepath.Base(dir)))
+	}
+	for _, p := range f.Sample {
+		for _, n := range name {
+			if ok, _ := filepath.Match(p, n); ok {
+				return "a sample or trailer"
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: ignore.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the patterns of files and directories skipped by the scanners,
+//    read from the .pimmpignore file in a library's root directory and from
+//    the -exclude options.
+//
+// =============================================================================
+
+package library
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant IgnoreFileName is the name of the file, in a library's root
+// directory, listing the patterns of the files and directories never scanned.
+const IgnoreFileName = ".pimmpignore"
+
+// type ignorePattern is a single glob pattern (see path.Match) of an Ignore.
+type ignorePattern struct {
+	glob    string // pattern matched against the base name or relative path
+	negate  bool   // pattern re-includes what an earlier pattern excluded
+	relPath bool   // pattern is matched against the library-relative path
+}
+
+// type Ignore decides which files and directories the scanners skip, using a
+// list of patterns in the style of .gitignore: one glob pattern per line, with
+// empty lines and lines beginning with '#' ignored. a pattern containing a '/'
+// (other than a trailing one, which is dropped) is matched against the path
+// relative to the library's root directory, and all others are matched against
+// the base name only. a pattern beginning with '!' re-includes anything
+// matched by an earlier pattern. the last pattern matching a path decides.
+// whatever is inside an ignored directory is ignored with it.
+type Ignore struct {
+	pattern []ignorePattern
+}
+
+// function newIgnore() creates an Ignore from the given list of patterns.
+// returns an error identifying the first invalid pattern, if any.
+func newIgnore(patterns []string) (*Ignore, *rc.ReturnCode) {
+	ig := &Ignore{pattern: []ignorePattern{}}
+	for _, p := range patterns {
+		if ret := ig.add(p); nil != ret {
+			return nil, ret
+		}
+	}
+	return ig, nil
+}
+
+// function loadIgnore() creates an Ignore from the patterns of the ignore file
+// in the given library root directory, if one exists, followed by the given
+// additional patterns.
+func loadIgnore(absPath string, extra []string) (*Ignore, *rc.ReturnCode) {
+
+	ig := &Ignore{pattern: []ignorePattern{}}
+
+	name := filepath.Join(absPath, IgnoreFileName)
+	file, err := os.Open(name)
+	if nil != err && !os.IsNotExist(err) {
+		return nil, rc.InvalidFile.Specf("loadIgnore(%q): %s", name, err)
+	}
+	if nil == err {
+		defer file.Close()
+		scan := bufio.NewScanner(file)
+		for line := 1; scan.Scan(); line++ {
+			if ret := ig.add(scan.Text()); nil != ret {
+				return nil, rc.InvalidFile.Specf("loadIgnore(%q): line %d: %s", name, line, ret)
+			}
+		}
+		if err := scan.Err(); nil != err {
+			return nil, rc.InvalidFile.Specf("loadIgnore(%q): %s", name, err)
+		}
+	}
+
+	for _, p := range extra {
+		if ret := ig.add(p); nil != ret {
+			return nil, ret
+		}
+	}
+	return ig, nil
+}
+
+// function add() appends the given pattern, in the syntax of an ignore file
+// line, to the Ignore. comments and blank lines are skipped.
+func (ig *Ignore) add(line string) *rc.ReturnCode {
+
+	p := strings.TrimSpace(line)
+	if "" == p || strings.HasPrefix(p, "#") {
+		return nil
+	}
+	pat := ignorePattern{}
+	if strings.HasPrefix(p, "!") {
+		pat.negate, p = true, p[1:]
+	}
+	p = strings.Trim(filepath.ToSlash(p), "/")
+	if "" == p {
+		return rc.InvalidArgs.Specf("invalid ignore pattern: %q", line)
+	}
+	if _, err := path.Match(p, ""); nil != err {
+		return rc.InvalidArgs.Specf("invalid ignore pattern: %q: %s", line, err)
+	}
+	pat.glob, pat.relPath = p, strings.Contains(p, "/")
+	ig.pattern = append(ig.pattern, pat)
+	return nil
+}
+
+// function Match() returns true if the file or directory at the given path,
+// relative to the library's root directory, is ignored.
+func (ig *Ignore) Match(relPath string) bool {
+
+	if nil == ig {
+		return false
+	}
+	rel := filepath.ToSlash(relPath)
+	base := path.Base(rel)
+	ignored := false
+	for _, p := range ig.pattern {
+		name := base
+		if p.relPath {
+			name = rel
+		}
+		if ok, _ := path.Match(p.glob, name); ok {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// function Covers() returns true if the file or directory at the given path,
+// relative to the library's root directory, or any directory containing it is
+// ignored.
+func (ig *Ignore) Covers(relPath string) bool {
+	for rel := filepath.ToSlash(relPath); "." != rel && "/" != rel && "" != rel; rel = path.Dir(rel) {
+		if ig.Match(rel) {
+			return true
+		}
+	}
+	return false
+}
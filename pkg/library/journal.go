@@ -0,0 +1,303 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: journal.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    records the changes made to the files of a library by commands such as
+//    organize and delete in its journal, from which the most recent batch of
+//    changes may be reverted, restoring both the files and their records.
+//
+// =============================================================================
+
+package library
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/storage"
+	"ardnew.com/pimmp/pkg/trash"
+)
+
+// constant journalBatches is the number of most recent batches of changes
+// retained in the journal (see BeginJournal()).
+const journalBatches = 8
+
+// type journalEntry is a single record of the journal collection.
+type journalEntry struct {
+	id  int
+	rec *storage.JournalRecord
+}
+
+// function readJournal() returns every record of this library's journal.
+func (l *Library) readJournal() []*journalEntry {
+
+	entry := []*journalEntry{}
+	l.db.JournalCol.ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			jr := &storage.JournalRecord{}
+			if err := json.Unmarshal(data, jr); nil != err {
+				logs.Warn.Verbosef("cannot read journal record (ID={%q,%X}): %s", l.name, id, err)
+				return true // move on to next record
+			}
+			entry = append(entry, &journalEntry{id: id, rec: jr})
+			return true // move on to next record
+		})
+	return entry
+}
+
+// function BeginJournal() begins a new batch of changes to this library's files,
+// identified by the given time, each of which is recorded in its journal until
+// the batch is reverted (see UndoJournal()). the changes made by a single
+// command should share the same batch, even across libraries. the zero time
+// stops journaling changes. only the most recent batches are retained.
+func (l *Library) BeginJournal(batch time.Time) *rc.ReturnCode {
+
+	l.journal = batch
+	if batch.IsZero() {
+		return nil
+	}
+
+	// the batches retained are those of the most recent changes, plus the one
+	// begun now.
+	entry := l.readJournal()
+	seen := map[time.Time]bool{}
+	recent := []time.Time{}
+	for _, e := range entry {
+		if t := e.rec.Batch.UTC(); !seen[t] {
+			seen[t] = true
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) < journalBatches {
+		return nil
+	}
+	sort.Slice(recent, func(a, b int) bool { return recent[a].After(recent[b]) })
+	oldest := recent[journalBatches-2]
+	for _, e := range entry {
+		if e.rec.Batch.Before(oldest) {
+			if err := l.db.JournalCol.Delete(e.id); nil != err {
+				return rc.DatabaseError.Specf(
+					"BeginJournal(): failed to delete record (ID={%q,%X}): %s", l.name, e.id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// function journalChange() records the given change to a file in this
+// library's journal, as part of the current batch (if any, see BeginJournal()).
+// a change that can't be recorded is still made, it just can't be reverted.
+func (l *Library) journalChange(jr *storage.JournalRecord) {
+
+	if l.journal.IsZero() {
+		return
+	}
+	jr.Batch, jr.Time = l.journal, time.Now()
+	if ret := l.db.Journal(jr); nil != ret {
+		logs.Warn.Log(ret)
+	}
+}
+
+// function TrashMedia() deletes the record of the media at the given absolute
+// path, whose file was moved to the trash as the given item, publishing its
+// removal on the given Bus (see RemoveMedia()). the deletion is journaled (see
+// BeginJournal()) with the original record, so that both are restored if
+// reverted. returns true if the media was found.
+func (l *Library) TrashMedia(bus *Bus, absPath string, item *trash.Item) (bool, *rc.ReturnCode) {
+
+	kind, id, ret := l.findMedia(absPath)
+	if nil != ret || media.KindUnknown == kind {
+		return false, ret
+	}
+	doc, err := l.db.Col[media.ClassMedia][kind].Read(id)
+	if nil != err {
+		return false, rc.DatabaseError.Specf(
+			"TrashMedia(%q): failed to read record (ID={%q,%X}): %s", absPath, l.name, id, err)
+	}
+	data, err := json.Marshal(doc)
+	if nil != err {
+		return false, rc.InvalidJSONData.Specf("TrashMedia(%q): json.Marshal(): %s", absPath, err)
+	}
+	if removed, ret := l.RemoveMedia(bus, absPath); nil != ret || !removed {
+		return removed, ret
+	}
+	l.journalChange(&storage.JournalRecord{
+		Op:        storage.JournalDelete,
+		Class:     media.ClassMedia,
+		Kind:      int(kind),
+		AbsPath:   absPath,
+		Trash:     item.Trash().String(),
+		TrashName: item.Name,
+		Data:      string(data),
+	})
+	return true, nil
+}
+
+// function CleanJunk() moves each file of the junk report of this library (see
+// JunkReport()) accepted by the given function to the trash by the given
+// function, removing it from the report. files changed since the scan found
+// them, or since added to the library, are left alone. each file moved is
+// journaled (see BeginJournal()), and the files moved are returned.
+func (l *Library) CleanJunk(accept func(storage.JunkEntry) bool, put func(absPath string) (*trash.Item, *rc.ReturnCode)) ([]storage.JunkEntry, *rc.ReturnCode) {
+
+	report, ret := l.db.JunkReport()
+	if nil != ret {
+		return nil, ret
+	}
+	keep := []storage.JunkEntry{}
+	cleaned := []storage.JunkEntry{}
+	for _, e := range report.Entries {
+		if !accept(e) {
+			keep = append(keep, e)
+			continue
+		}
+		absPath := filepath.Join(l.absPath, e.Path)
+		info, err := os.Lstat(absPath)
+		if nil != err {
+			continue // already gone
+		}
+		if !info.Mode().IsRegular() || info.Size() != e.Size {
+			logs.Warn.Logf("not cleaning changed file: %q", absPath)
+			keep = append(keep, e)
+			continue
+		}
+		if kind, _, ret := l.findMedia(absPath); nil != ret || media.KindUnknown != kind {
+			keep = append(keep, e)
+			continue
+		}
+		item, ret := put(absPath)
+		if nil != ret {
+			logs.Warn.Log(ret)
+			keep = append(keep, e)
+			continue
+		}
+		l.journalChange(&storage.JournalRecord{
+			Op:        storage.JournalDelete,
+			Class:     media.ClassUnknown,
+			Kind:      -1,
+			AbsPath:   absPath,
+			Trash:     item.Trash().String(),
+			TrashName: item.Name,
+		})
+		cleaned = append(cleaned, e)
+	}
+	if len(keep) != len(report.Entries) {
+		report.Entries = keep
+		if ret := l.db.SetJunkReport(report); nil != ret {
+			return cleaned, ret
+		}
+	}
+	return cleaned, nil
+}
+
+// function LastJournal() returns the most recent batch of changes recorded in
+// this library's journal (see BeginJournal()), or the zero time if there is
+// none.
+func (l *Library) LastJournal() time.Time {
+
+	var last time.Time
+	for _, e := range l.readJournal() {
+		if e.rec.Batch.After(last) {
+			last = e.rec.Batch
+		}
+	}
+	return last
+}
+
+// function UndoJournal() reverts every change of the given batch recorded in
+// this library's journal, the most recent first: moved files are moved back,
+// and deleted files are restored from the trash along with their records. the
+// changes reverted are returned, and removed from the journal. the changes that
+// can't be reverted (e.g. the file was since moved again) are logged, returned
+// separately, and removed as well, so that earlier batches may still be
+// reverted.
+func (l *Library) UndoJournal(batch time.Time) ([]*storage.JournalRecord, []*storage.JournalRecord, *rc.ReturnCode) {
+
+	// reverting a change is not itself a change worth reverting.
+	defer func(journal time.Time) { l.journal = journal }(l.journal)
+	l.journal = time.Time{}
+
+	entry := []*journalEntry{}
+	for _, e := range l.readJournal() {
+		if e.rec.Batch.Equal(batch) {
+			entry = append(entry, e)
+		}
+	}
+	sort.Slice(entry, func(a, b int) bool {
+		if !entry[a].rec.Time.Equal(entry[b].rec.Time) {
+			return entry[a].rec.Time.After(entry[b].rec.Time)
+		}
+		return entry[a].id > entry[b].id
+	})
+
+	undone, failed := []*storage.JournalRecord{}, []*storage.JournalRecord{}
+	for _, e := range entry {
+		if ret := l.undoChange(e.rec); nil != ret {
+			logs.Warn.Log(ret)
+			failed = append(failed, e.rec)
+		} else {
+			undone = append(undone, e.rec)
+		}
+		if err := l.db.JournalCol.Delete(e.id); nil != err {
+			return undone, failed, rc.DatabaseError.Specf(
+				"UndoJournal(): failed to delete record (ID={%q,%X}): %s", l.name, e.id, err)
+		}
+	}
+	return undone, failed, nil
+}
+
+// function undoChange() reverts the given change recorded in this library's
+// journal.
+func (l *Library) undoChange(jr *storage.JournalRecord) *rc.ReturnCode {
+
+	switch jr.Op {
+	case storage.JournalMove:
+		moved, ret := l.MoveMedia(jr.NewPath, jr.AbsPath)
+		if nil != ret {
+			return ret
+		}
+		if !moved {
+			return rc.InvalidPath.Specf("undoChange(%q): media no longer in library: %q",
+				jr.AbsPath, jr.NewPath)
+		}
+
+	case storage.JournalDelete:
+		item, ret := trash.New(jr.Trash).Item(jr.TrashName)
+		if nil != ret {
+			return ret
+		}
+		if ret := item.Restore(); nil != ret {
+			return ret
+		}
+		if "" == jr.Data {
+			return nil // not media, e.g. junk (see CleanJunk())
+		}
+		// the file is restored even if its record isn't, the next scan adds it
+		// back as new media.
+		rec := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(jr.Data), &rec); nil != err {
+			return rc.InvalidJSONData.Specf("undoChange(%q): json.Unmarshal(): %s", jr.AbsPath, err)
+		}
+		if jr.Class < 0 || jr.Class >= media.ClassCOUNT || jr.Kind < 0 || jr.Kind >= len(l.db.Col[jr.Class]) {
+			return rc.InvalidArgs.Specf("undoChange(%q): invalid collection: %d/%d",
+				jr.AbsPath, jr.Class, jr.Kind)
+		}
+		if _, err := l.db.Col[jr.Class][jr.Kind].Insert(rec); nil != err {
+			return rc.DatabaseError.Specf(
+				"undoChange(%q): failed to insert record: %s", jr.AbsPath, err)
+		}
+
+	default:
+		return rc.InvalidArgs.Specf("undoChange(%q): unrecognized change: %q", jr.AbsPath, jr.Op)
+	}
+	return nil
+}
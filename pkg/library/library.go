@@ -0,0 +1,2899 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 26 Sept 2018
+//  FILE: library.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines types and operations for interacting with library databases,
+//    traversing file systems, and managing user-defined collections of media.
+//
+// =============================================================================
+
+// package library provides the Library type, which indexes the media found in
+// a directory tree and keeps the results in a persistent database.
+package library
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/audiotag"
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/contenthash"
+	"ardnew.com/pimmp/pkg/ebook"
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/exif"
+	"ardnew.com/pimmp/pkg/fuzzy"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/naming"
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/plugin"
+	"ardnew.com/pimmp/pkg/probe"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/storage"
+	"ardnew.com/pimmp/pkg/sublang"
+	"ardnew.com/pimmp/pkg/trash"
+	"ardnew.com/pimmp/pkg/verify"
+)
+
+// var logs are the loggers of the messages about scanning, loading, and
+// updating the libraries, filtered as component "scan" (see
+// console.Register()).
+var logs = console.Register("scan")
+
+// type Library represents a collection of a specified kind of media files
+// together with a rooted search path from which all media file discovery
+// is performed.
+type Library struct {
+	workingDir string // current working directory
+	absPath    string // absolute path to library
+	name       string // library name (default: basename of path)
+	maxDepth   uint   // maximum traversal depth (unlimited: 0)
+
+	dataDir string            // directory containing all known library databases
+	db      *storage.Database // database containing all known media in this library
+
+	busyState *BusyState // reference to the global busy state mutex (nil if unused)
+	scheduler *Scheduler // scheduler shared with the other libraries (nil if unlimited)
+
+	plugins *plugin.Host // external plugins consulted during scans (nil if unused)
+
+	hidden func(*media.Media) bool // media never published on the bus (nil if unused)
+
+	prune bool // delete the records of missing files, rather than orphaning them
+
+	journal time.Time // batch of the changes to files journaled (zero = none, see BeginJournal())
+
+	subLangs     []string // preferred languages of subtitles, most preferred first
+	subThreshold float64  // lowest score of a video associated with subtitles
+	subDirWeight float64  // weight of directory proximity in the scores of videos
+
+	noMetadata  bool  // skip reading the tags embedded in audio files
+	probe       bool  // describe the streams of video files using ffprobe
+	sniff       bool  // identify files of unknown extension by their content
+	hashPartial int64 // bytes hashed at each end of large files (0 = whole files, < 0 = none)
+
+	followLinks bool             // traverse symbolic links rather than skipping them
+	visited     map[fileKey]bool // directories traversed by the current scan (if followLinks)
+
+	moved map[media.MediaKind][]*movedMedia // orphaned media the current scan may find moved (nil until read)
+
+	exclude    []string          // patterns of files never scanned, in addition to the ignore file
+	libExclude []string          // patterns of files never scanned in this library only, in addition to exclude
+	kinds      []media.MediaKind // kinds of media added by scans (nil = all)
+	libType    Type              // type of library, recorded in its database, restricting kinds further
+	filter     Filter            // audio and video files skipped by scans, e.g. samples
+	libProbe   *bool             // probe video files of this library only, in place of probe (nil = probe)
+	ignore     *Ignore           // patterns of files skipped by the current scan
+	numIgnored uint              // number of files and directories skipped by the current scan
+	numFiles   uint              // number of regular files examined by the current scan
+
+	junk []storage.JunkEntry // files neither media nor support files found by the current scan
+
+	scanRate ScanRate  // rate at which scans examine and read files
+	throttle *throttle // throttle of the current scan (nil if unlimited)
+
+	warnings *scanWarnings // warnings raised by the current load or scan, collapsed by message
+
+	loadComplete chan uint      // synchronization lock, carrying the number of media loaded
+	loadStart    chan time.Time // counting semaphore to limit number of concurrent loaders
+	loadElapsed  time.Duration  // measures time elapsed for load to complete (use internally, not thread-safe!)
+	loadWarned   int            // number of warnings raised by the most recent load
+
+	scanComplete chan uint      // synchronization lock, carrying the number of media found
+	scanStart    chan time.Time // counting semaphore to limit number of concurrent scanners
+	scanElapsed  time.Duration  // measures time elapsed for scan to complete (use internally, not thread-safe!)
+	scanWarned   int            // number of warnings raised by the most recent scan
+
+	lastScan time.Time // the datetime at which this library was last scanned
+}
+
+// type Discovery represents any sort of file entity discovered during a file
+// system traversal of the library; we can capture here any other useful info
+// describing the state of the file system traversal / search at the exact
+// moment in time in which it was discovered.
+type Discovery struct {
+	Time     time.Time            // time at which the file was discovered
+	Library  *Library             // library in which the file was discovered
+	AbsPath  string               // absolute path of the file
+	Entity   media.StorableEntity // entity of the file (nil for other files and removed records)
+	RecordID int                  // database ID of the entity's record (-1 if none)
+	Class    media.EntityClass    // class of the entity (ClassUnknown if none)
+	Kind     int                  // kind of the entity within its class, e.g. MediaKind (-1 if none)
+}
+
+// function discovery() constructs a new instance of a Discovery struct of this
+// library with the current time and the given file entity.
+func (l *Library) discovery(absPath string, ent media.StorableEntity, id int, class media.EntityClass, kind int) *Discovery {
+	return &Discovery{
+		Time:     time.Now(),
+		Library:  l,
+		AbsPath:  absPath,
+		Entity:   ent,
+		RecordID: id,
+		Class:    class,
+		Kind:     kind,
+	}
+}
+
+// function Media() returns the Media embedded in the entity discovered, or nil
+// if it isn't media (e.g. a support file).
+func (d *Discovery) Media() *media.Media { return mediaOf(d.Entity) }
+
+// constants controlling the behavior of the library scanners.
+const (
+	DepthUnlimited     = 0
+	maxLibraryScanners = 1
+)
+
+// constants controlling the association of subtitles with videos by default
+// (see SetSubtitleMatching()).
+const (
+	DefaultSubThreshold = 0.6
+	DefaultSubDirWeight = 0.25
+)
+
+// function init() initializes all of the locally-declared data for use both
+// locally and globally
+func init() {}
+
+// function NewLibrary() creates and initializes a new Library ready to scan.
+// the library database is also created if one doesn't already exist, otherwise
+// it is opened for business. the busy state may be nil if there is no UI to
+// notify of busy activity.
+func NewLibrary(dat string, cfg *storage.Config, busy *BusyState, lib string, lim uint, curr []*Library) (*Library, *rc.ReturnCode) {
+
+	// determine the user's current working dir -- from where they invoked us.
+	dir, err := os.Getwd()
+	if nil != err {
+		return nil, rc.InvalidLibrary.Specf(
+			"NewLibrary(%q, %q): os.Getwd(): %s", dat, lib, err)
+	}
+
+	// determine the absolute path to the directory tree containing media.
+	abs, err := filepath.Abs(lib)
+	if nil != err {
+		return nil, rc.InvalidLibrary.Specf(
+			"NewLibrary(%q, %q): filepath.Abs(): %s", dat, lib, err)
+	}
+
+	// verify we haven't already seen this path in our library list.
+	for _, p := range curr {
+		if p.absPath == abs {
+			return nil, rc.DuplicateLibrary.Specf(
+				"NewLibrary(%q, %q): library already exists (skipping): %q", dat, lib, abs)
+		}
+	}
+
+	// open the root directory of the library file system for reading.
+	fds, err := os.Open(abs)
+	if nil != err {
+		return nil, rc.InvalidLibrary.Specf(
+			"NewLibrary(%q, %q): os.Open(): %s", dat, lib, err)
+	}
+
+	// read all content of the root directory in the library file system.
+	_, err = fds.Readdir(0)
+	fds.Close()
+	if nil != err {
+		return nil, rc.InvalidLibrary.Specf(
+			"NewLibrary(%q, %q): Readdir(): %s", dat, lib, err)
+	}
+
+	// open or create the library database if it doesn't exist.
+	db, ret := storage.NewDatabase(cfg, abs, dat)
+	if nil != ret {
+		return nil, ret
+	}
+
+	l := &Library{
+		workingDir: dir,
+		absPath:    abs,
+		name:       path.Base(abs),
+		maxDepth:   lim,
+
+		// path to the library database directory.
+		dataDir: dat,
+		db:      db,
+
+		// mutex which controls interaction by the various goroutines to limited
+		// system resources such as database tables, UI primitives, etc.
+		busyState: busy,
+
+		subThreshold: DefaultSubThreshold,
+		subDirWeight: DefaultSubDirWeight,
+		hashPartial:  contenthash.DefaultPartial,
+
+		loadComplete: make(chan uint),
+		loadStart:    make(chan time.Time, maxLibraryScanners),
+		loadElapsed:  0,
+
+		scanComplete: make(chan uint),
+		scanStart:    make(chan time.Time, maxLibraryScanners),
+		scanElapsed:  0,
+
+		lastScan: time.Time{},
+		warnings: newScanWarnings(),
+	}
+	l.loadType()
+
+	return l, nil
+}
+
+// function String() creates a string representation of the Library for easy
+// identification in logs.
+func (l *Library) String() string {
+	return fmt.Sprintf("{%q,%q,%s}", l.name, l.absPath, l.db)
+}
+
+// function Name() returns the display name of the library.
+func (l *Library) Name() string { return l.name }
+
+// function AbsPath() returns the absolute path to the library root directory.
+func (l *Library) AbsPath() string { return l.absPath }
+
+// function DB() returns the database containing all known media in the
+// library.
+func (l *Library) DB() *storage.Database { return l.db }
+
+// function ReadOnly() returns true if the library's database is never written
+// to (see ReadOnly() of storage.Database), e.g. with -readonly or -dryrun.
+func (l *Library) ReadOnly() bool { return l.db.ReadOnly() }
+
+// function LastScan() returns the datetime at which the library was last
+// scanned.
+func (l *Library) LastScan() time.Time { return l.lastScan }
+
+// function SessionStart() returns the time at which the n-th most recent scan
+// of the library began, i.e. n = 1 is the most recent (including one completed
+// during this run). media added since then were discovered by the last n scans.
+// returns the zero time if the library hasn't been scanned n times, in which
+// case all of its media were discovered by those scans.
+func (l *Library) SessionStart(n int) time.Time {
+	list, ret := l.db.Sessions()
+	if nil != ret {
+		logs.Warn.Log(ret)
+		return time.Time{}
+	}
+	if n < 1 || n > len(list) {
+		return time.Time{}
+	}
+	return list[n-1].Start
+}
+
+// function lastSession() returns the most recent scan session of the library,
+// or the zero Session if it was never scanned.
+func (l *Library) lastSession() storage.Session {
+	list, ret := l.db.Sessions()
+	if nil != ret {
+		logs.Warn.Log(ret)
+		return storage.Session{}
+	}
+	if 0 == len(list) {
+		return storage.Session{}
+	}
+	return list[0]
+}
+
+// function loadTask() returns the label of the busy state while the library is
+// loaded.
+func (l *Library) loadTask() string { return "loading: " + l.name }
+
+// function scanTask() returns the label of the busy state while the library is
+// scanned.
+func (l *Library) scanTask() string { return "scanning: " + l.name }
+
+// function ScanReport() returns the problems encountered by the most recent
+// load and scan of the library (see storage.ScanReport).
+func (l *Library) ScanReport() (*storage.ScanReport, *rc.ReturnCode) {
+	return l.db.ScanReport()
+}
+
+// function JunkReport() returns the files found by the most recent complete
+// scan of the library that are neither media nor support files (see
+// storage.JunkReport), less those cleaned since (see CleanJunk()).
+func (l *Library) JunkReport() (*storage.JunkReport, *rc.ReturnCode) {
+	return l.db.JunkReport()
+}
+
+// function Snapshot() returns a new Snapshot, with the given name, of every
+// record currently in the library's database, media and support alike. the
+// records are keyed by their path relative to the library, so that snapshots
+// remain comparable after the library is mounted elsewhere. the Snapshot is
+// not saved (see (*storage.Database).SaveSnapshot()).
+func (l *Library) Snapshot(name string) (*storage.Snapshot, *rc.ReturnCode) {
+
+	snap := storage.NewSnapshot(name)
+	var ret *rc.ReturnCode = nil
+	for class := range l.db.Col {
+		for kind := range l.db.Col[class] {
+			l.db.Col[class][kind].ForEachDoc(
+				func(id int, data []byte) (willMoveOn bool) {
+					rec := media.EntityRecord{}
+					if err := json.Unmarshal(data, &rec); nil != err {
+						ret = rc.InvalidJSONData.Specf(
+							"Snapshot(%q): json.Unmarshal(): record (ID={%q,%X}): %s", name, l.name, id, err)
+						return false
+					}
+					absPath, _ := rec["AbsPath"].(string)
+					relPath, err := filepath.Rel(l.absPath, absPath)
+					if "" == absPath || nil != err {
+						relPath = absPath
+					}
+					snap.Add(relPath, rec)
+					return true // move on to next record
+				})
+			if nil != ret {
+				return nil, ret
+			}
+		}
+	}
+	return snap, nil
+}
+
+// function SetPlugins() sets the external plugins that will be consulted while
+// scanning the library, and notified of its changes by SubscribePlugins(). a
+// nil Host disables plugins.
+func (l *Library) SetPlugins(h *plugin.Host) { l.plugins = h }
+
+// function Plugins() returns the external plugins consulted while scanning the
+// library, or nil if unused.
+func (l *Library) Plugins() *plugin.Host { return l.plugins }
+
+// function SetHidden() sets the filter identifying the media that must never be
+// published on the bus by loads and scans, e.g. media hidden by a parental
+// controls profile. the media are still stored in the library's database.
+func (l *Library) SetHidden(hidden func(*media.Media) bool) { l.hidden = hidden }
+
+// function SetFollowLinks() selects whether scans follow symbolic links to
+// files and directories, rather than skipping them. links to directories that
+// were already traversed by the same scan, e.g. an ancestor, are skipped.
+func (l *Library) SetFollowLinks(follow bool) { l.followLinks = follow }
+
+// function SetExclude() sets the patterns of the files and directories never
+// scanned, in addition to those of the library's ignore file (see Ignore).
+func (l *Library) SetExclude(patterns []string) *rc.ReturnCode {
+	if _, ret := newIgnore(patterns); nil != ret {
+		return ret
+	}
+	l.exclude = patterns
+	return nil
+}
+
+// function SetLibraryExclude() sets the patterns of the files and directories
+// never scanned in this library only, e.g. from its registration, in addition
+// to those set by SetExclude(), which typically apply to every library.
+func (l *Library) SetLibraryExclude(patterns []string) *rc.ReturnCode {
+	if _, ret := newIgnore(patterns); nil != ret {
+		return ret
+	}
+	l.libExclude = patterns
+	return nil
+}
+
+// function SetKinds() restricts the media added by scans to the given kinds,
+// e.g. so that a music library never adds the odd video found in it. files of
+// other kinds are skipped like ignored files. all kinds are added if empty, the
+// default.
+func (l *Library) SetKinds(kinds []media.MediaKind) {
+	if 0 == len(kinds) {
+		kinds = nil
+	}
+	l.kinds = kinds
+}
+
+// function SetFilter() sets the Filter selecting the audio and video files
+// skipped by scans, e.g. the samples and trailers accompanying a release,
+// which are then handled like files that aren't media at all. by default, none
+// are skipped.
+func (l *Library) SetFilter(f Filter) { l.filter = f }
+
+// function allowsKind() returns true if scans add media of the given kind (see
+// SetKinds() and SetType()). files other than media (KindUnknown) are always
+// allowed.
+func (l *Library) allowsKind(kind media.MediaKind) bool {
+	if media.KindUnknown == kind {
+		return true
+	}
+	if !l.libType.allows(kind) {
+		return false
+	}
+	if nil == l.kinds {
+		return true
+	}
+	for _, k := range l.kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// function SetName() sets the name of the library, shown in place of the base
+// name of its root directory, e.g. from its registration.
+func (l *Library) SetName(name string) {
+	if name = strings.TrimSpace(name); "" != name {
+		l.name = name
+	}
+}
+
+// function loadIgnore() reads the patterns of the files and directories skipped
+// by the scan beginning, so that changes to the ignore file take effect without
+// restarting. an unreadable ignore file is reported, and only the patterns set
+// by SetExclude() and SetLibraryExclude() are used instead.
+func (l *Library) loadIgnore() {
+	l.numIgnored = 0
+	l.numFiles = 0
+	l.junk = nil
+	exclude := append(append([]string{}, l.exclude...), l.libExclude...)
+	ig, ret := loadIgnore(l.absPath, exclude)
+	if nil != ret {
+		logs.Warn.Log(ret)
+		ig, _ = newIgnore(exclude)
+	}
+	l.ignore = ig
+}
+
+// function SetPrune() selects what happens to the records of files found
+// missing while loading: if true, they are deleted; otherwise, they are moved to
+// the orphaned collection of the library's database.
+func (l *Library) SetPrune(prune bool) { l.prune = prune }
+
+// function SetReadMetadata() selects whether scans read the tags embedded in
+// newly discovered or changed audio files (artist, album, track, etc.), the
+// EXIF data of images, and the metadata of documents before storing their
+// records. reading is enabled by default.
+func (l *Library) SetReadMetadata(read bool) { l.noMetadata = !read }
+
+// function SetSubtitleMatching() sets how subtitles are associated with
+// videos. each video considered is scored by the similarity of its name to
+// that of the subtitles and by the proximity of its directory, the latter
+// having the given weight (0 to 1); the best are associated if their score
+// reaches the given threshold (0 to 1). returns an error if either is out of
+// range.
+func (l *Library) SetSubtitleMatching(threshold, dirWeight float64) *rc.ReturnCode {
+
+	if threshold < 0 || threshold > 1 {
+		return rc.InvalidArgs.Specf("SetSubtitleMatching(): threshold out of range [0, 1]: %g", threshold)
+	}
+	if dirWeight < 0 || dirWeight > 1 {
+		return rc.InvalidArgs.Specf("SetSubtitleMatching(): directory weight out of range [0, 1]: %g", dirWeight)
+	}
+	l.subThreshold, l.subDirWeight = threshold, dirWeight
+	return nil
+}
+
+// function SetSubtitleLanguages() sets the languages of the subtitles played
+// with videos in preference to others, most preferred first, given by their
+// ISO 639 codes or names (see package sublang). returns an error if any of the
+// languages isn't recognized.
+func (l *Library) SetSubtitleLanguages(langs []string) *rc.ReturnCode {
+
+	codes := []string{}
+	for _, lang := range langs {
+		code := sublang.Normalize(lang)
+		if "" == code {
+			return rc.InvalidArgs.Specf("SetSubtitleLanguages(): unrecognized language: %q", lang)
+		}
+		codes = append(codes, code)
+	}
+	l.subLangs = codes
+	return nil
+}
+
+// function SetProbe() selects whether scans describe the streams of newly
+// discovered or changed video files using ffprobe (see package probe), which
+// is much slower than scanning without. disabled by default.
+func (l *Library) SetProbe(probe bool) { l.probe = probe }
+
+// function SetLibraryProbe() selects whether scans of this library only, e.g.
+// from its registration, describe the streams of video files, in place of the
+// selection made by SetProbe(). nil restores the latter.
+func (l *Library) SetLibraryProbe(probe *bool) { l.libProbe = probe }
+
+// function SetSniff() selects whether scans identify the kind of media of the
+// files whose name extension is missing or unknown by the signature at the
+// start of their content (see media.SniffMediaKind()), which reads every such
+// file. disabled by default.
+func (l *Library) SetSniff(sniff bool) { l.sniff = sniff }
+
+// function mediaKindOfFile() is like media.MediaKindOfFile(), but identifies
+// the files it doesn't by their content, if enabled by SetSniff(). the files
+// identified by their extension as any other kind of file are never read.
+func (l *Library) mediaKindOfFile(absPath string) (media.MediaKind, string) {
+	kind, name := media.MediaKindOfFile(absPath)
+	if media.KindUnknown != kind || !l.sniff {
+		return kind, name
+	}
+	if sk, _ := media.SupportKindOfFile(absPath); media.SupportUnknown != sk {
+		return kind, name
+	}
+	if pk, _ := media.PlaylistKindOfFileExt(path.Ext(absPath)); media.PlaylistUnknown != pk {
+		return kind, name
+	}
+	l.throttle.bytes(media.SniffSize)
+	sniffed, sniffedName, ret := media.SniffMediaKind(absPath)
+	if nil != ret {
+		logs.Warn.Verbose(ret)
+		return kind, name
+	}
+	if media.KindUnknown != sniffed {
+		logs.Info.Tracef("identified %s file by content: %q (%s)",
+			strings.ToLower(media.MediaColName[sniffed]), absPath, sniffedName)
+	}
+	return sniffed, sniffedName
+}
+
+// function SetScheduler() sets the Scheduler, shared with other libraries, for
+// which the library's loads and scans wait their turn. nil by default, which
+// permits any number of them at once.
+func (l *Library) SetScheduler(s *Scheduler) { l.scheduler = s }
+
+// function SetScanRate() limits the rate at which scans examine and read the
+// library's files (see ParseScanRate()). unlimited by default.
+func (l *Library) SetScanRate(rate ScanRate) { l.scanRate = rate }
+
+// function SetHashPartial() sets the number of bytes hashed at the start and
+// at the end of each media file larger than twice that size when computing
+// its content hash (see package contenthash). if 0, entire files are hashed;
+// if negative, none are. contenthash.DefaultPartial by default.
+func (l *Library) SetHashPartial(partial int64) { l.hashPartial = partial }
+
+// function hashMedia() computes the content hash of the given media's file,
+// unless disabled by SetHashPartial(). the tracks of cue sheets aren't files
+// of their own, so they are never hashed.
+func (l *Library) hashMedia(med *media.Media) {
+	if l.hashPartial < 0 || med.IsTrack() || med.IsDisc() {
+		return
+	}
+	l.throttle.bytes(hashSize(med.Size, l.hashPartial))
+	sum, ret := contenthash.Sum(med.AbsPath, l.hashPartial)
+	if nil != ret {
+		logs.Warn.Verbose(ret)
+		return
+	}
+	med.Hash = sum
+}
+
+// function probeVideo() populates the technical info of the given video from
+// its file's streams, if enabled by SetProbe() or SetLibraryProbe().
+func (l *Library) probeVideo(video *media.VideoMedia) {
+	enabled := l.probe
+	if nil != l.libProbe {
+		enabled = *l.libProbe
+	}
+	if !enabled || video.IsDisc() {
+		return
+	}
+	release := l.scheduler.probe()
+	defer release()
+	if ret := probe.Probe(video); nil != ret {
+		logs.Warn.Verbose(ret)
+	}
+}
+
+// function readAudioTags() populates the given audio media with the metadata
+// read from its file, unless disabled by SetReadMetadata(). the fields of any
+// tags the file doesn't define are left unchanged.
+func (l *Library) readAudioTags(audio *media.AudioMedia) {
+
+	if l.noMetadata || !audiotag.Supported(audio.Ext) {
+		return
+	}
+	info, ret := audiotag.Read(audio.AbsPath)
+	if nil != ret {
+		logs.Warn.Verbose(ret)
+	}
+	if nil == info {
+		return
+	}
+	if "" != info.Title {
+		audio.Title = info.Title
+	}
+	if "" != info.Artist {
+		audio.Artist = info.Artist
+	}
+	if "" != info.Album {
+		audio.Album = info.Album
+	}
+	if info.Track > 0 {
+		audio.Track = int64(info.Track)
+	}
+	if info.Year > 0 {
+		audio.ReleaseDate = time.Date(info.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if "" != info.Genre {
+		known := false
+		for _, g := range audio.Genres {
+			if strings.EqualFold(g, info.Genre) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			audio.Genres = append(audio.Genres, info.Genre)
+		}
+	}
+	if info.Duration > 0 {
+		audio.Duration = info.Duration
+	}
+}
+
+// function readImageInfo() populates the given image media with its
+// dimensions and the EXIF data read from its file, unless disabled by
+// SetReadMetadata(). the date the photo was taken is also its release date.
+func (l *Library) readImageInfo(image *media.ImageMedia) {
+
+	if l.noMetadata || !exif.Supported(image.Ext) {
+		return
+	}
+	info, ret := exif.Read(image.AbsPath)
+	if nil != ret {
+		logs.Warn.Verbose(ret)
+		return
+	}
+	if info.Width > 0 && info.Height > 0 {
+		image.Width, image.Height = int64(info.Width), int64(info.Height)
+	}
+	if camera := strings.TrimSpace(info.Make + " " + info.Model); "" != camera {
+		// the model of most cameras already begins with the make.
+		if strings.HasPrefix(strings.ToLower(info.Model), strings.ToLower(info.Make)) {
+			camera = info.Model
+		}
+		image.Camera = camera
+	}
+	if !info.Taken.IsZero() {
+		image.Taken = info.Taken
+		image.ReleaseDate = info.Taken
+	}
+}
+
+// function readDocumentInfo() populates the given document media with the
+// title, author, series, and number of pages read from its file, unless
+// disabled by SetReadMetadata().
+func (l *Library) readDocumentInfo(doc *media.DocumentMedia) {
+
+	if l.noMetadata || !ebook.Supported(doc.Ext) {
+		return
+	}
+	info, ret := ebook.Read(doc.AbsPath)
+	if nil != ret {
+		logs.Warn.Verbose(ret)
+		return
+	}
+	if "" != info.Title {
+		doc.Title = info.Title
+	}
+	if "" != info.Author {
+		doc.Author = info.Author
+	}
+	if "" != info.Series {
+		doc.Series, doc.SeriesIndex = info.Series, info.SeriesIndex
+	}
+	if info.Pages > 0 {
+		doc.Pages = int64(info.Pages)
+	}
+	if info.Year > 0 {
+		doc.ReleaseDate = time.Date(info.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// function LoadComplete() returns the channel used to synchronize with the
+// completion of a load.
+func (l *Library) LoadComplete() chan uint { return l.loadComplete }
+
+// function ScanComplete() returns the channel used to synchronize with the
+// completion of a scan.
+func (l *Library) ScanComplete() chan uint { return l.scanComplete }
+
+// function RecandidateSubtitles() attempts to find candidate VideoMedia in the
+// library for all Subtitles that are currently unassociated with any VideoMedia
+// objects. if force is true, then it attempts to find candidate VideoMedia for
+// ALL Subtitles objects and not only the orphaned/unassociated ones.
+func (l *Library) RecandidateSubtitles(force bool) *rc.ReturnCode {
+	_, _, err := l.recandidateSubtitles(force)
+	return err
+}
+
+// function recandidateSubtitles() performs the actual search for candidates of
+// RecandidateSubtitles(), returning the number of subtitles searched and the
+// number of those still unassociated with any VideoMedia afterwards.
+func (l *Library) recandidateSubtitles(force bool) (int, int, *rc.ReturnCode) {
+
+	orphan := []storage.RecordID{}
+	remain := []storage.RecordID{}
+
+	l.db.Col[media.ClassSupport][media.SupportSubtitles].ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			subs := &media.Subtitles{}
+			subs.FromRecord(data)
+			if force || 0 >= len(subs.KnownVideoMedia) {
+				orphan = append(orphan, storage.RecordID{ID: id, Rec: subs})
+			}
+			return true // move on to next record
+		})
+
+	numOrphan := len(orphan)
+	if numOrphan > 0 {
+		logs.Warn.Tracef("identified %d orphan subtitles in \"%s\" (unassociated with any media)", numOrphan, l.name)
+		for _, o := range orphan {
+			subs := o.Rec.(*media.Subtitles)
+			logs.Info.Tracef("scanning media for subtitles: %s", subs)
+			vid, err := l.findCandidates(subs, true, o.ID)
+			if nil != err {
+				return numOrphan, len(remain), err
+			}
+			if 0 == len(vid) && 0 == len(subs.KnownVideoMedia) {
+				remain = append(remain, o)
+			}
+		}
+		logs.Warn.Tracef("still unable to associate %d orphan subtitles with any media. consider renaming or moving the files to something more conventional.", len(remain))
+	}
+
+	return numOrphan, len(remain), nil
+}
+
+// function RelinkSubtitles() re-runs the association of subtitles with videos
+// using the current settings (see SetSubtitleMatching()), without rescanning
+// the library. if force is false, only subtitles currently unassociated with
+// any video are relinked. otherwise, every association is first discarded, so
+// that all subtitles are relinked from scratch; the subtitles selected for a
+// video (see SelectSubtitles()) remain selected only if still associated with
+// it afterwards. returns the number of subtitles relinked and the number of
+// those left unassociated.
+func (l *Library) RelinkSubtitles(force bool) (int, int, *rc.ReturnCode) {
+
+	l.busyState.Inc("relinking subtitles: " + l.name)
+	defer l.busyState.Dec("relinking subtitles: " + l.name)
+
+	vidCol := l.db.Col[media.ClassMedia][media.KindVideo]
+	subCol := l.db.Col[media.ClassSupport][media.SupportSubtitles]
+
+	if force {
+		if err := l.clearAssociations(subCol, func(ent media.StorableEntity) bool {
+			subs := ent.(*media.Subtitles)
+			if 0 == len(subs.KnownVideoMedia) {
+				return false
+			}
+			subs.KnownVideoMedia = []media.VideoMedia{}
+			return true
+		}, func() media.StorableEntity { return &media.Subtitles{} }); nil != err {
+			return 0, 0, err
+		}
+		if err := l.clearAssociations(vidCol, func(ent media.StorableEntity) bool {
+			video := ent.(*media.VideoMedia)
+			if 0 == len(video.KnownSubtitles) {
+				return false
+			}
+			video.KnownSubtitles = []media.Subtitles{}
+			return true
+		}, func() media.StorableEntity { return &media.VideoMedia{} }); nil != err {
+			return 0, 0, err
+		}
+	}
+
+	relinked, remain, err := l.recandidateSubtitles(force)
+	if nil != err {
+		return relinked, remain, err
+	}
+
+	if force {
+		// discard any selection no longer among the video's subtitles.
+		err = l.clearAssociations(vidCol, func(ent media.StorableEntity) bool {
+			video := ent.(*media.VideoMedia)
+			if nil == video.Subtitles.Support {
+				return false
+			}
+			for _, subs := range video.KnownSubtitles {
+				if subs.AbsPath == video.Subtitles.AbsPath {
+					return false
+				}
+			}
+			video.Subtitles = media.Subtitles{}
+			return true
+		}, func() media.StorableEntity { return &media.VideoMedia{} })
+	}
+	return relinked, remain, err
+}
+
+// function clearAssociations() updates each record of the given collection
+// which the given function modifies (returning true), after instantiating it
+// with the other given function. records that cannot be instantiated are left
+// as they are, since Load() quarantines them.
+func (l *Library) clearAssociations(col engine.Collection, modify func(media.StorableEntity) bool, alloc func() media.StorableEntity) *rc.ReturnCode {
+
+	changed := map[int]media.StorableEntity{}
+	col.ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			ent := alloc()
+			if nil == ent.FromRecord(data) && modify(ent) {
+				changed[id] = ent
+			}
+			return true // move on to next record
+		})
+	for id, ent := range changed {
+		rec, ret := ent.ToRecord()
+		if nil != ret {
+			return ret
+		}
+		if err := col.Update(id, *rec); nil != err {
+			return rc.DatabaseError.Specf(
+				"clearAssociations(): failed to update record (ID={%q,%X}): %s", l.name, id, err)
+		}
+	}
+	return nil
+}
+
+// function Subtitles() returns the paths of the subtitle files associated with
+// the video at the given absolute path, most preferred first: the subtitles
+// selected for the video (see SelectSubtitles()), then those in each of the
+// preferred languages in turn (see SetSubtitleLanguages()), then those of
+// unknown language, and then the rest. subtitles equally preferred are sorted
+// by path.
+func (l *Library) Subtitles(absPath string) []string {
+
+	list := l.SubtitlesOf(absPath)
+	path := make([]string, len(list))
+	for i, subs := range list {
+		path[i] = subs.AbsPath
+	}
+	return path
+}
+
+// function SubtitlesOf() returns the subtitles associated with the video at
+// the given absolute path, in the same order as Subtitles().
+func (l *Library) SubtitlesOf(absPath string) []*media.Subtitles {
+
+	list := []*media.Subtitles{}
+	l.db.Col[media.ClassSupport][media.SupportSubtitles].ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			subs := &media.Subtitles{}
+			if nil != subs.FromRecord(data) {
+				return true // move on to next record, Load() quarantines it
+			}
+			for _, v := range subs.KnownVideoMedia {
+				if nil != v.Media && nil != v.Entity && absPath == v.AbsPath {
+					list = append(list, subs)
+					break
+				}
+			}
+			return true // move on to next record
+		})
+
+	selected := ""
+	if video, _ := l.VideoMedia(absPath); nil != video && nil != video.Subtitles.Support {
+		selected = video.Subtitles.AbsPath
+	}
+	rank := func(subs *media.Subtitles) int {
+		if "" != selected && selected == subs.AbsPath {
+			return 0
+		}
+		for i, lang := range l.subLangs {
+			if lang == subs.Language {
+				return 1 + i
+			}
+		}
+		if "" == subs.Language {
+			return 1 + len(l.subLangs)
+		}
+		return 2 + len(l.subLangs)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if ra, rb := rank(list[a]), rank(list[b]); ra != rb {
+			return ra < rb
+		}
+		return list[a].AbsPath < list[b].AbsPath
+	})
+	return list
+}
+
+// function SelectSubtitles() selects the subtitles at the given absolute path,
+// which must be associated with the video at the other given path, to be
+// played with the video in preference to all others. an empty subtitles path
+// clears the selection, leaving it to the preferred languages. returns true if
+// the video was found and its selection changed.
+func (l *Library) SelectSubtitles(absPath, subsPath string) (bool, *rc.ReturnCode) {
+
+	var selected *media.Subtitles
+	if "" != subsPath {
+		for _, subs := range l.SubtitlesOf(absPath) {
+			if subsPath == subs.AbsPath {
+				selected = subs
+				break
+			}
+		}
+		if nil == selected {
+			return false, rc.InvalidArgs.Specf(
+				"SelectSubtitles(%q): not subtitles of the video: %q", absPath, subsPath)
+		}
+		// the copy kept in the video's record needn't refer back to it.
+		selected.KnownVideoMedia = nil
+	}
+
+	return l.editMedia(absPath, func(ent media.StorableEntity, med *media.Media) (media.StorableEntity, *rc.ReturnCode) {
+		video, ok := ent.(*media.VideoMedia)
+		if !ok {
+			return nil, rc.InvalidArgs.Specf("SelectSubtitles(%q): not a video", absPath)
+		}
+		switch {
+		case nil == selected && nil == video.Subtitles.Support:
+			return nil, nil
+		case nil == selected:
+			video.Subtitles = media.Subtitles{}
+		case nil != video.Subtitles.Support && selected.AbsPath == video.Subtitles.AbsPath:
+			return nil, nil
+		default:
+			video.Subtitles = *selected
+		}
+		return video, nil
+	})
+}
+
+// function loadDive() performs the actual iterated loading of all objects in
+// this Library. as each object is instantiated using the data from the data
+// store, it is published on the bus for handling by all subscribers.
+// any record that cannot be instantiated is moved to the quarantine collection
+// so that it never interrupts a load again (see function Repair()). likewise,
+// the record of any file that no longer exists is either deleted or moved to
+// the orphaned collection (see SetPrune()) instead of being handed off. the
+// load stops early, returning rc.Canceled, once the given Context is done.
+func (l *Library) loadDive(ctx context.Context, pub *publisher, class media.EntityClass, kind int) (uint, *rc.ReturnCode) {
+
+	var count uint = 0
+	var ret *rc.ReturnCode = nil
+
+	// records that fail to parse are collected and quarantined only after the
+	// iteration completes, because we shouldn't modify the collection while
+	// tiedot is still walking it.
+	corrupt := []storage.RecordID{}
+	missing := []storage.RecordID{}
+
+	// verify the file of each record still exists before handing it off,
+	// collecting those that don't for the same reason as the corrupt records.
+	isMissing := func(id int, data []byte, absPath string) bool {
+		if _, err := os.Stat(absPath); !os.IsNotExist(err) {
+			return false
+		}
+		buf := make([]byte, len(data))
+		copy(buf, data)
+		missing = append(missing, storage.RecordID{ID: id, Rec: &missingRecord{buf, absPath}})
+		return true
+	}
+
+	// iterate over every record in the specified collection, unmarshalling the
+	// data stored in the database into a real, fully-typed and populated object
+	// before publishing what we found.
+	l.db.Col[class][kind].ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			if nil != ctx.Err() {
+				ret = rc.Canceled.Specf("loadDive(%q): %s", l.db.ColName[class][kind], ctx.Err())
+				return false // stop iterating
+			}
+			l.busyState.Advance(l.loadTask(), 1, int64(len(data)))
+			var recErr *rc.ReturnCode
+			switch class {
+			case media.ClassMedia:
+				switch media.MediaKind(kind) {
+				case media.KindAudio:
+					audio := &media.AudioMedia{}
+					if recErr = audio.FromRecord(data); nil == recErr {
+						// the track of a cue sheet is missing with its image.
+						if isMissing(id, data, audio.File()) {
+							return true // move on to next record
+						}
+						logs.Info.Tracef("loaded audio (ID={%q,%X}): %s", l.name, id, audio)
+						l.handleMedia(pub, audio.AbsPath, audio, audio.Media, id, false)
+					}
+				case media.KindVideo:
+					video := &media.VideoMedia{}
+					if recErr = video.FromRecord(data); nil == recErr {
+						if isMissing(id, data, video.AbsPath) {
+							return true // move on to next record
+						}
+						logs.Info.Tracef("loaded video (ID={%q,%X}): %s", l.name, id, video)
+						l.handleMedia(pub, video.AbsPath, video, video.Media, id, false)
+					}
+				case media.KindImage:
+					image := &media.ImageMedia{}
+					if recErr = image.FromRecord(data); nil == recErr {
+						if isMissing(id, data, image.AbsPath) {
+							return true // move on to next record
+						}
+						logs.Info.Tracef("loaded image (ID={%q,%X}): %s", l.name, id, image)
+						l.handleMedia(pub, image.AbsPath, image, image.Media, id, false)
+					}
+				case media.KindDocument:
+					doc := &media.DocumentMedia{}
+					if recErr = doc.FromRecord(data); nil == recErr {
+						if isMissing(id, data, doc.AbsPath) {
+							return true // move on to next record
+						}
+						logs.Info.Tracef("loaded document (ID={%q,%X}): %s", l.name, id, doc)
+						l.handleMedia(pub, doc.AbsPath, doc, doc.Media, id, false)
+					}
+				default:
+				}
+			case media.ClassSupport:
+				switch media.SupportKind(kind) {
+				case media.SupportSubtitles:
+					subs := &media.Subtitles{}
+					if recErr = subs.FromRecord(data); nil == recErr {
+						if isMissing(id, data, subs.AbsPath) {
+							return true // move on to next record
+						}
+						logs.Info.Tracef("loaded subtitles (ID={%q,%X}): %s", l.name, id, subs)
+						l.handleSupport(pub, subs.AbsPath, subs, media.SupportSubtitles, id, false)
+					}
+				case media.SupportArtwork:
+					art := &media.Artwork{}
+					if recErr = art.FromRecord(data); nil == recErr {
+						if isMissing(id, data, art.AbsPath) {
+							return true // move on to next record
+						}
+						logs.Info.Tracef("loaded artwork (ID={%q,%X}): %s", l.name, id, art)
+						l.handleSupport(pub, art.AbsPath, art, media.SupportArtwork, id, false)
+					}
+				case media.SupportMetadata:
+					meta := &media.Metadata{}
+					if recErr = meta.FromRecord(data); nil == recErr {
+						if isMissing(id, data, meta.AbsPath) {
+							return true // move on to next record
+						}
+						logs.Info.Tracef("loaded metadata (ID={%q,%X}): %s", l.name, id, meta)
+						l.handleSupport(pub, meta.AbsPath, meta, media.SupportMetadata, id, false)
+					}
+				case media.SupportLyrics:
+					lyr := &media.Lyrics{}
+					if recErr = lyr.FromRecord(data); nil == recErr {
+						if isMissing(id, data, lyr.AbsPath) {
+							return true // move on to next record
+						}
+						logs.Info.Tracef("loaded lyrics (ID={%q,%X}): %s", l.name, id, lyr)
+						l.handleSupport(pub, lyr.AbsPath, lyr, media.SupportLyrics, id, false)
+					}
+				case media.SupportCueSheet:
+					cs := &media.CueSheet{}
+					if recErr = cs.FromRecord(data); nil == recErr {
+						if isMissing(id, data, cs.AbsPath) {
+							return true // move on to next record
+						}
+						logs.Info.Tracef("loaded cue sheet (ID={%q,%X}): %s", l.name, id, cs)
+						l.handleSupport(pub, cs.AbsPath, cs, media.SupportCueSheet, id, false)
+					}
+				default:
+				}
+			case media.ClassPlaylist:
+				switch media.PlaylistKind(kind) {
+				case media.PlaylistStatic, media.PlaylistSmart:
+					list := &media.Playlist{}
+					if recErr = list.FromRecord(data); nil == recErr {
+						// only the playlists imported from a file can go missing.
+						if list.IsImported() && isMissing(id, data, list.AbsPath) {
+							return true // move on to next record
+						}
+						logs.Info.Tracef("loaded playlist (ID={%q,%X}): %s", l.name, id, list)
+					}
+				default:
+				}
+			case media.ClassSeries:
+				switch media.SeriesKind(kind) {
+				case media.SeriesShow:
+					series := &media.Series{}
+					if recErr = series.FromRecord(data); nil == recErr {
+						logs.Info.Tracef("loaded series (ID={%q,%X}): %s", l.name, id, series)
+					}
+				case media.SeriesSeason:
+					season := &media.Season{}
+					if recErr = season.FromRecord(data); nil == recErr {
+						logs.Info.Tracef("loaded season (ID={%q,%X}): %s", l.name, id, season)
+					}
+				default:
+				}
+			default:
+			}
+			if nil != recErr {
+				// keep a private copy of the data, tiedot may reuse its buffer.
+				buf := make([]byte, len(data))
+				copy(buf, data)
+				corrupt = append(corrupt, storage.RecordID{ID: id, Rec: &corruptRecord{buf, recErr.Error()}})
+			} else {
+				count++
+			}
+			return true // move on to next record
+		})
+
+	// now move each of the bad records out of the way.
+	for _, c := range corrupt {
+		rec := c.Rec.(*corruptRecord)
+		logs.Warn.Verbosef("quarantining corrupt record (ID={%q,%X}) in %q: %s",
+			l.name, c.ID, l.db.ColName[class][kind], rec.reason)
+		l.warnings.record("", rc.CorruptRecord.Specf(
+			"quarantined record (ID=%X) in %q: %s", c.ID, l.db.ColName[class][kind], rec.reason))
+		if err := l.db.Quarantine(class, kind, c.ID, rec.data, rec.reason); nil != err {
+			logs.Warn.Verbose(err)
+			l.warnings.record("", err)
+		}
+	}
+
+	// and remove the records of the files that have disappeared.
+	l.dropMissing(pub, class, kind, missing)
+
+	return count, ret
+}
+
+// function dropMissing() deletes the given records (of type *missingRecord) of
+// files that no longer exist from the given collection, or moves them to the
+// orphaned collection, depending on SetPrune(), and publishes their removal.
+func (l *Library) dropMissing(pub *publisher, class media.EntityClass, kind int, missing []storage.RecordID) {
+	for _, m := range missing {
+		rec := m.Rec.(*missingRecord)
+		l.handleRemove(pub, rec.absPath, class, kind, rec.data)
+		if l.prune {
+			logs.Info.Verbosef("pruning record of missing file (ID={%q,%X}): %q",
+				l.name, m.ID, rec.absPath)
+			if err := l.db.Col[class][kind].Delete(m.ID); nil != err {
+				logs.Warn.Verbosef("cannot prune record (ID={%q,%X}): %s", l.name, m.ID, err)
+			}
+		} else {
+			logs.Info.Verbosef("orphaning record of missing file (ID={%q,%X}): %q",
+				l.name, m.ID, rec.absPath)
+			if err := l.db.Orphan(class, kind, m.ID, rec.absPath, rec.data); nil != err {
+				logs.Warn.Verbose(err)
+				rel, _ := filepath.Rel(l.absPath, rec.absPath)
+				l.warnings.record(rel, err)
+			}
+		}
+	}
+}
+
+// type corruptRecord retains the raw data and parse error of a record which
+// failed to load, pending its removal to the quarantine collection.
+type corruptRecord struct {
+	data   []byte
+	reason string
+}
+
+// type missingRecord retains the raw data and file path of a record whose file
+// no longer exists, pending its deletion or removal to the orphaned collection.
+type missingRecord struct {
+	data    []byte
+	absPath string
+}
+
+// function Repair() first moves every unparseable record of all collections
+// into the quarantine collection, and then attempts to rebuild each record in
+// quarantine using the facts available on disk. any record whose file path can
+// be recovered and still refers to a recognized file is recreated as a new
+// entity and removed from quarantine. records that cannot be recovered remain
+// in quarantine. returns the number of records repaired and the number of
+// records that could not be repaired.
+func (l *Library) Repair() (uint, uint, *rc.ReturnCode) {
+
+	var (
+		numFixed  uint = 0
+		numFailed uint = 0
+	)
+
+	// load every collection without publishing it; the only side-effect is that
+	// corrupt records are quarantined.
+	for classID, count := range l.db.NumRecordsLoad {
+		for kind := range count {
+			if _, err := l.loadDive(context.Background(), nil, media.EntityClass(classID), kind); nil != err {
+				return numFixed, numFailed, err
+			}
+		}
+	}
+
+	quarantined := []storage.RecordID{}
+	l.db.QuarantineCol.ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			qr := &storage.QuarantineRecord{}
+			if err := json.Unmarshal(data, qr); nil != err {
+				logs.Warn.Verbosef("cannot read quarantine record (ID={%q,%X}): %s", l.name, id, err)
+			} else {
+				quarantined = append(quarantined, storage.RecordID{ID: id, Rec: qr})
+			}
+			return true // move on to next record
+		})
+
+	for _, q := range quarantined {
+		qr := q.Rec.(*storage.QuarantineRecord)
+		if err := l.repairRecord(qr); nil != err {
+			logs.Warn.Verbosef("cannot repair record (ID={%q,%X}) from %q: %s",
+				l.name, qr.ID, qr.Collection, err)
+			numFailed++
+			continue
+		}
+		if err := l.db.QuarantineCol.Delete(q.ID); nil != err {
+			return numFixed, numFailed, rc.DatabaseError.Specf(
+				"Repair(): failed to delete quarantine record: %s", err)
+		}
+		numFixed++
+	}
+
+	if numFixed > 0 {
+		// subtitles associations were lost with the corrupt records.
+		if err := l.RecandidateSubtitles(false); nil != err {
+			return numFixed, numFailed, err
+		}
+	}
+
+	return numFixed, numFailed, nil
+}
+
+// function repairRecord() recreates a single quarantined record from the facts
+// available on disk. the absolute path is the only field we try to salvage
+// from the original data -- everything else is recomputed from the file itself
+// exactly as a scan would have.
+func (l *Library) repairRecord(qr *storage.QuarantineRecord) *rc.ReturnCode {
+
+	// decode the original data as loosely as possible.
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(qr.Data), &fields); nil != err {
+		return rc.CorruptRecord.Specf("repairRecord(): unrecognized data: %s", err)
+	}
+	absPath, ok := fields["AbsPath"].(string)
+	if !ok || "" == absPath {
+		return rc.CorruptRecord.Spec("repairRecord(): no path to recover")
+	}
+
+	fileInfo, err := os.Stat(absPath)
+	if nil != err {
+		return rc.InvalidStat.Specf("repairRecord(%q): os.Stat(): %s", absPath, err)
+	}
+	if !fileInfo.Mode().IsRegular() {
+		return rc.InvalidFile.Specf("repairRecord(%q): not a regular file", absPath)
+	}
+	relPath, err := filepath.Rel(l.absPath, absPath)
+	if nil != err {
+		return rc.InvalidPath.Specf(
+			"repairRecord(%q): filepath.Rel(%q): %s", absPath, l.absPath, err)
+	}
+
+	// reclassify the file. it is possible the file's name changed since the
+	// record was stored, so don't assume it belongs to the same collection.
+	var (
+		class   media.EntityClass = media.ClassUnknown
+		kind    int
+		extName string
+	)
+	ext := path.Ext(absPath)
+	if mk, name := l.mediaKindOfFile(absPath); media.KindUnknown != mk {
+		class, kind, extName = media.ClassMedia, int(mk), name
+	} else if sk, name := media.SupportKindOfFile(absPath); media.SupportUnknown != sk {
+		class, kind, extName = media.ClassSupport, int(sk), name
+	} else if pc, pk, name, ok := l.plugins.Classify(absPath); ok {
+		class, kind, extName = pc, pk, name
+	}
+	ent := media.NewStorableEntity(class, kind, absPath, relPath, ext, extName, fileInfo)
+	if nil == ent {
+		return rc.InvalidFile.Specf("repairRecord(%q): unrecognized file type", absPath)
+	}
+
+	// don't insert a duplicate if the file has since been rescanned.
+	if _, seen, err := l.seenFile(class, kind, absPath); nil != err {
+		return rc.QueryError.Specf("repairRecord(%q): %s", absPath, err)
+	} else if seen {
+		logs.Info.Tracef("record already rebuilt by scan: %q", absPath)
+		return nil
+	}
+
+	rec, recErr := ent.ToRecord()
+	if nil != recErr {
+		return recErr
+	}
+	id, insErr := l.db.Col[class][kind].Insert(*rec)
+	if nil != insErr {
+		return rc.DatabaseError.Specf(
+			"repairRecord(%q): failed to insert record: %s", absPath, insErr)
+	}
+	logs.Info.Tracef("repaired record (ID={%q,%X}): %s", l.name, id, ent)
+
+	return nil
+}
+
+// function Load() is the entry point for initiating a load on the library's
+// backing data store, publishing each file loaded on the given Bus (if not nil)
+// from SourceLoad. the load is interrupted once the given Context is done, in
+// which case rc.Canceled is returned along with the number of entities loaded
+// until then. you must wait for the load to finish before restarting.
+func (l *Library) Load(ctx context.Context, bus *Bus) (uint, *rc.ReturnCode) {
+
+	pub := newPublisher(bus, SourceLoad)
+
+	var (
+		numLoad uint = 0 // number of known files loaded from database
+		err     *rc.ReturnCode
+	)
+
+	//
+	// the loadStart channel is buffered so that we can limit the number of
+	// goroutines concurrently reading this library's database:
+	//
+	//     writes to the channel will fail and fallback on the default select
+	//     case if the max number of loaders is reached -- which sets an error
+	//     code that is returned to the caller -- so be sure to check
+	//     the return value when calling function Load()!
+	//
+
+	// wait for the other libraries sharing our scheduler, if need be.
+	release, err := l.scheduler.load(ctx, l)
+	if nil != err {
+		return numLoad, err
+	}
+	defer release()
+
+	// try writing to the buffered channel. this will succeed if and only if it
+	// isn't already filled to capacity.
+	select {
+	case l.loadStart <- time.Now():
+
+		// notify the user that a potentially time-intensive operation has
+		// begun and user interactions will be limited.
+		if nil != l.busyState {
+			l.busyState.Inc(l.loadTask())
+			// the database held as many records after the last scan.
+			l.busyState.SetTotal(l.loadTask(), int64(l.lastSession().Records))
+		}
+
+		// the write succeeded, so we can initiate loading. keep track of the
+		// time at which we began so that the time elapsed can be calculated and
+		// notified to the user.
+		logs.Info.Verbosef("loading: %q", l.name)
+		l.warnings = newScanWarnings()
+		// multi-dimensional numRecordsLoad contains fixed outer-array dimension
+		// equal to number of collections (i.e. classes) equal to media.ClassCOUNT
+	load:
+		for classID, count := range l.db.NumRecordsLoad {
+			class := media.EntityClass(classID)
+			for kind := range count {
+				if count[kind], err = l.loadDive(ctx, pub, class, kind); nil != err {
+					// release the busy indicator below, the same as a load
+					// that finished.
+					break load
+				}
+			}
+		}
+
+		// we've finished the loading operations, so remove the busy indicator
+		// to indicate that normal user interactions may resume (if no other
+		// event has the semaphore still incremented).
+		l.loadElapsed = time.Since(<-l.loadStart)
+		if nil != l.busyState {
+			l.busyState.Dec(l.loadTask())
+		}
+
+		// construct a summary message for the load operation.
+		total, summary := l.db.TotalRecordsString(storage.MethodLoad, -1, -1)
+		if total > 0 {
+			logs.Info.Verbosef(
+				"finished loading: %q (%s loaded in %s)",
+				l.name, summary, l.loadElapsed.Round(time.Millisecond))
+		} else {
+			logs.Info.Verbosef(
+				"finished loading: %q (no media loaded in %s)",
+				l.name, l.loadElapsed.Round(time.Millisecond))
+		}
+		numLoad = total
+		l.loadWarned = l.warnings.total()
+
+		// an interrupted load would have found just some of the problems.
+		if !errors.Is(err, rc.Canceled) {
+			if ret := l.warnings.save(l.db, storage.MethodLoad); nil != ret {
+				logs.Warn.Log(ret)
+			}
+		}
+
+	default:
+		// if the write failed, we fall back to this default case. the only
+		// reason it should fail is if the buffer is already filled to capacity,
+		// meaning we already have the max allowed number of goroutines loading
+		// this library's database.
+		err = rc.LibraryBusy.Specf(
+			"Load(): max number of loaders reached: %q (max = %d)",
+			l.absPath, maxLibraryScanners)
+	}
+
+	// return a count of the total number of entities successfully loaded.
+	return numLoad, err
+}
+
+// function UpdateMedia() finds the media at the given absolute path in this
+// library's database and passes it to the given update function. if update
+// returns true, the modified media is written back to the database, and each
+// field changed is added to the media's edit history (see UndoMedia()).
+// returns true if the media was found and its record updated.
+func (l *Library) UpdateMedia(absPath string, update func(m *media.Media) bool) (bool, *rc.ReturnCode) {
+	return l.updateEntity(absPath, func(_ media.StorableEntity, med *media.Media) bool {
+		return update(med)
+	})
+}
+
+// function UpdateAudio() is like UpdateMedia(), but passes the audio media's
+// concrete type to the update function, so that its audio-specific fields
+// (artist, album, etc.) can be modified too. returns false if the media at the
+// given path isn't audio.
+func (l *Library) UpdateAudio(absPath string, update func(a *media.AudioMedia) bool) (bool, *rc.ReturnCode) {
+	return l.updateEntity(absPath, func(ent media.StorableEntity, _ *media.Media) bool {
+		audio, ok := ent.(*media.AudioMedia)
+		return ok && update(audio)
+	})
+}
+
+// function updateEntity() implements UpdateMedia() and UpdateAudio(), passing
+// both the media entity and its embedded Media to the given update function.
+func (l *Library) updateEntity(absPath string, update func(ent media.StorableEntity, med *media.Media) bool) (bool, *rc.ReturnCode) {
+
+	return l.editMedia(absPath, func(ent media.StorableEntity, med *media.Media) (media.StorableEntity, *rc.ReturnCode) {
+
+		before, ret := ent.ToRecord()
+		if nil != ret {
+			return nil, ret
+		}
+		if !update(ent, med) {
+			return nil, nil
+		}
+		after, ret := ent.ToRecord()
+		if nil != ret {
+			return nil, ret
+		}
+		edit := media.DiffRecords(before, after, time.Now())
+		if 0 == len(edit) {
+			return nil, nil
+		}
+		med.AddHistory(edit...)
+		return ent, nil
+	})
+}
+
+// function SetResumePosition() records the given offset at which playback of
+// the media at the given absolute path in this library's database was last
+// stopped. unlike UpdateMedia(), the change isn't added to the media's edit
+// history: it is recorded repeatedly during playback and would soon push out
+// every edit worth undoing. returns true if the media was found.
+func (l *Library) SetResumePosition(absPath string, position time.Duration) (bool, *rc.ReturnCode) {
+
+	return l.editMedia(absPath, func(ent media.StorableEntity, med *media.Media) (media.StorableEntity, *rc.ReturnCode) {
+		if position == med.ResumePosition {
+			return nil, nil
+		}
+		med.ResumePosition = position
+		return ent, nil
+	})
+}
+
+// function UndoMedia() reverts the most recent change recorded in the edit
+// history of the media at the given absolute path in this library's database,
+// returning the edits that were reverted. the returned list is empty if the
+// media was not found or has no history.
+func (l *Library) UndoMedia(absPath string) ([]media.Edit, *rc.ReturnCode) {
+
+	undone := []media.Edit{}
+	_, ret := l.editMedia(absPath, func(ent media.StorableEntity, med *media.Media) (media.StorableEntity, *rc.ReturnCode) {
+
+		if undone = med.PopHistory(); 0 == len(undone) {
+			return nil, nil
+		}
+		rec, ret := ent.ToRecord()
+		if nil != ret {
+			return nil, ret
+		}
+		data, ret := media.RevertRecord(rec, undone)
+		if nil != ret {
+			return nil, ret
+		}
+		// decode into a fresh entity, otherwise the fields being reverted
+		// (maps in particular) would be merged with their current values.
+		var reverted media.StorableEntity
+		switch med.Kind {
+		case media.KindAudio:
+			reverted = &media.AudioMedia{}
+		case media.KindVideo:
+			reverted = &media.VideoMedia{}
+		case media.KindImage:
+			reverted = &media.ImageMedia{}
+		case media.KindDocument:
+			reverted = &media.DocumentMedia{}
+		default:
+			return nil, rc.CorruptRecord.Specf(
+				"UndoMedia(%q): unrecognized media kind: %d", absPath, int(med.Kind))
+		}
+		if ret := reverted.FromRecord(data); nil != ret {
+			return nil, ret
+		}
+		return reverted, nil
+	})
+	if nil != ret {
+		return []media.Edit{}, ret
+	}
+	return undone, nil
+}
+
+// function editMedia() finds the media at the given absolute path in this
+// library's database and passes it to the given edit function, both as the
+// concrete entity and its embedded Media. if edit returns a non-nil entity,
+// it replaces the media's record in the database. returns true if the media
+// was found and its record replaced.
+func (l *Library) editMedia(absPath string,
+	edit func(media.StorableEntity, *media.Media) (media.StorableEntity, *rc.ReturnCode)) (bool, *rc.ReturnCode) {
+
+	kind, id, ret := l.findMedia(absPath)
+	if nil != ret || media.KindUnknown == kind {
+		return false, ret
+	}
+	col := l.db.Col[media.ClassMedia][kind]
+
+	// the embedded Media is allocated up front so that we retain a reference
+	// to it regardless of the concrete type.
+	med := &media.Media{}
+	var ent media.StorableEntity
+	switch kind {
+	case media.KindAudio:
+		ent = &media.AudioMedia{Media: med}
+	case media.KindVideo:
+		ent = &media.VideoMedia{Media: med}
+	case media.KindImage:
+		ent = &media.ImageMedia{Media: med}
+	case media.KindDocument:
+		ent = &media.DocumentMedia{Media: med}
+	}
+	if ret := ent.FromID(col, id); nil != ret {
+		return false, ret
+	}
+	replace, ret := edit(ent, med)
+	if nil != ret || nil == replace {
+		return false, ret
+	}
+	rec, ret := replace.ToRecord()
+	if nil != ret {
+		return false, ret
+	}
+	if err := col.Update(id, *rec); nil != err {
+		return false, rc.DatabaseError.Specf(
+			"editMedia(%q): failed to update record (ID={%q,%X}): %s",
+			absPath, l.name, id, err)
+	}
+	logs.Info.Tracef("updated media (ID={%q,%X}): %q", l.name, id, absPath)
+	return true, nil
+}
+
+// function RemoveMedia() deletes the record of the media at the given absolute
+// path from this library's database, and publishes its removal on the given
+// Bus (if not nil). the file itself is left untouched. returns true if the
+// media was found and its record deleted.
+func (l *Library) RemoveMedia(bus *Bus, absPath string) (bool, *rc.ReturnCode) {
+
+	kind, id, ret := l.findMedia(absPath)
+	if nil != ret || media.KindUnknown == kind {
+		return false, ret
+	}
+	doc, _ := l.db.Col[media.ClassMedia][kind].Read(id)
+	if err := l.db.Col[media.ClassMedia][kind].Delete(id); nil != err {
+		return false, rc.DatabaseError.Specf(
+			"RemoveMedia(%q): failed to delete record (ID={%q,%X}): %s",
+			absPath, l.name, id, err)
+	}
+	var data []byte
+	if nil != doc {
+		data, _ = json.Marshal(doc)
+	}
+	l.handleRemove(newPublisher(bus, SourceCommand), absPath, media.ClassMedia, int(kind), data)
+	logs.Info.Tracef("removed media (ID={%q,%X}): %q", l.name, id, absPath)
+	return true, nil
+}
+
+// function MoveMedia() moves the file of the media at the given absolute path
+// to the new absolute path, within this library, and updates its record to
+// match. the file and its record are never left out of sync: if the record
+// can't be updated, the file is moved back. an existing file at the new path
+// is never overwritten. the move is journaled (see BeginJournal()). returns
+// true if the media was found and moved.
+func (l *Library) MoveMedia(absPath, newPath string) (bool, *rc.ReturnCode) {
+
+	relPath, err := filepath.Rel(l.absPath, newPath)
+	if nil != err || filepath.IsAbs(relPath) || "." == relPath ||
+		".." == relPath || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return false, rc.InvalidPath.Specf(
+			"MoveMedia(%q): destination not in library %q: %q", absPath, l.name, newPath)
+	}
+	if path.Ext(absPath) != path.Ext(newPath) {
+		return false, rc.InvalidPath.Specf(
+			"MoveMedia(%q): file name extension must not change: %q", absPath, newPath)
+	}
+	kind, _, ret := l.findMedia(absPath)
+	if nil != ret || media.KindUnknown == kind {
+		return false, ret
+	}
+	if _, err := os.Lstat(absPath); nil != err {
+		// e.g. the track of a cue sheet, which is moved with its image.
+		return false, rc.InvalidPath.Specf("MoveMedia(%q): os.Lstat(): %s", absPath, err)
+	}
+
+	// the destination may differ from the source only in case, which is the
+	// same file on case-insensitive file systems.
+	if _, err := os.Lstat(newPath); nil == err && !strings.EqualFold(absPath, newPath) {
+		return false, rc.InvalidPath.Specf(
+			"MoveMedia(%q): destination already exists: %q", absPath, newPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(newPath), os.ModePerm); nil != err {
+		return false, rc.InvalidPath.Specf(
+			"MoveMedia(%q): os.MkdirAll(): %s", absPath, err)
+	}
+	if err := os.Rename(absPath, newPath); nil != err {
+		return false, rc.InvalidPath.Specf(
+			"MoveMedia(%q): os.Rename(%q): %s", absPath, newPath, err)
+	}
+
+	moved, ret := l.editMedia(absPath, func(ent media.StorableEntity, med *media.Media) (media.StorableEntity, *rc.ReturnCode) {
+		med.Relocate(newPath, relPath)
+		if video, ok := ent.(*media.VideoMedia); ok {
+			video.ParseName()
+		}
+		return ent, nil
+	})
+	if nil != ret || !moved {
+		if err := os.Rename(newPath, absPath); nil != err {
+			logs.Error.Logf("MoveMedia(%q): failed to restore file moved to %q: %s",
+				absPath, newPath, err)
+		}
+		return false, ret
+	}
+	l.journalChange(&storage.JournalRecord{
+		Op:      storage.JournalMove,
+		Class:   media.ClassMedia,
+		Kind:    int(kind),
+		AbsPath: absPath,
+		NewPath: newPath,
+	})
+	return true, nil
+}
+
+// function RestatMedia() updates the file attributes (size, mode, modification
+// time) in the record of the media at the given absolute path to those of the
+// file currently at that path, e.g. after it was replaced. returns true if the
+// media was found and its record updated.
+func (l *Library) RestatMedia(absPath string) (bool, *rc.ReturnCode) {
+
+	return l.editMedia(absPath, func(ent media.StorableEntity, med *media.Media) (media.StorableEntity, *rc.ReturnCode) {
+		// the track of a cue sheet has the attributes of its image.
+		info, err := os.Stat(med.File())
+		if nil != err {
+			return nil, rc.InvalidStat.Specf("RestatMedia(%q): os.Stat(): %s", absPath, err)
+		}
+		if med.IsDisc() {
+			info = media.DiscInfo(med.AbsPath, info)
+		}
+		med.Refresh(info)
+		return ent, nil
+	})
+}
+
+// function VerifyMedia() verifies the integrity of the file of the media at the
+// given absolute path (see package verify), recording the outcome in its
+// record. verification is bookkeeping rather than an edit, so it isn't added
+// to the media's edit history. returns the reason verification failed, or an
+// empty string if it passed (or the media wasn't found).
+func (l *Library) VerifyMedia(absPath string, decode bool) (string, *rc.ReturnCode) {
+
+	reason := ""
+	_, ret := l.editMedia(absPath, func(ent media.StorableEntity, med *media.Media) (media.StorableEntity, *rc.ReturnCode) {
+		if fail := verify.Verify(med, decode); nil != fail {
+			reason = fail.Error()
+		}
+		return ent, nil
+	})
+	return reason, ret
+}
+
+// function VideoMedia() returns the record of the video with the given path,
+// including its subtitles and technical info, or nil if there is none.
+func (l *Library) VideoMedia(absPath string) (*media.VideoMedia, *rc.ReturnCode) {
+
+	kind, id, ret := l.findMedia(absPath)
+	if nil != ret || media.KindVideo != kind {
+		return nil, ret
+	}
+	video := &media.VideoMedia{Media: &media.Media{}}
+	if ret := video.FromID(l.db.Col[media.ClassMedia][kind], id); nil != ret {
+		return nil, ret
+	}
+	return video, nil
+}
+
+// function ImageMedia() returns the record of the image with the given path,
+// including its dimensions and EXIF info, or nil if there is none.
+func (l *Library) ImageMedia(absPath string) (*media.ImageMedia, *rc.ReturnCode) {
+
+	kind, id, ret := l.findMedia(absPath)
+	if nil != ret || media.KindImage != kind {
+		return nil, ret
+	}
+	image := &media.ImageMedia{Media: &media.Media{}}
+	if ret := image.FromID(l.db.Col[media.ClassMedia][kind], id); nil != ret {
+		return nil, ret
+	}
+	return image, nil
+}
+
+// function DocumentMedia() returns the record of the document with the given
+// path, including its author, series, and number of pages, or nil if there is
+// none.
+func (l *Library) DocumentMedia(absPath string) (*media.DocumentMedia, *rc.ReturnCode) {
+
+	kind, id, ret := l.findMedia(absPath)
+	if nil != ret || media.KindDocument != kind {
+		return nil, ret
+	}
+	doc := &media.DocumentMedia{Media: &media.Media{}}
+	if ret := doc.FromID(l.db.Col[media.ClassMedia][kind], id); nil != ret {
+		return nil, ret
+	}
+	return doc, nil
+}
+
+// function MediaByID() returns the record of the media of the given kind with
+// the given record ID, along with its embedded Media.
+func (l *Library) MediaByID(kind media.MediaKind, id int) (media.StorableEntity, *media.Media, *rc.ReturnCode) {
+
+	ent, med := newMediaOfKind(kind)
+	if nil == ent {
+		return nil, nil, rc.InvalidArgs.Specf("MediaByID(%d, %d): unrecognized kind of media", int(kind), id)
+	}
+	if ret := ent.FromID(l.db.Col[media.ClassMedia][kind], id); nil != ret {
+		return nil, nil, ret
+	}
+	return ent, med, nil
+}
+
+// function findMedia() returns the kind and record ID of the media at the given
+// absolute path in this library's database. the kind returned is KindUnknown if
+// no such media exists.
+func (l *Library) findMedia(absPath string) (media.MediaKind, int, *rc.ReturnCode) {
+
+	for kind := media.MediaKind(0); kind < media.KindCOUNT; kind++ {
+		id, found, err := l.db.FindOne(media.ClassMedia, int(kind), "AbsPath", absPath)
+		if nil != err {
+			return media.KindUnknown, -1, rc.QueryError.Specf(
+				"findMedia(%q): Find(): %s", absPath, err)
+		}
+		if found {
+			return kind, id, nil
+		}
+	}
+	return media.KindUnknown, -1, nil
+}
+
+// function LoadBy() publishes on the given Bus (if not nil), from SourceLoad,
+// each media in this library's database having any of the given values in the
+// indexed field with the given name, e.g. "Tags" or "Title" (see
+// media.EntityIndexes), returning the number of media found. only the matching
+// records are read, using the index, so it is much faster than Load() when few
+// media match. unlike Load(), the files of the media aren't verified to exist.
+func (l *Library) LoadBy(bus *Bus, field string, values ...string) (uint, *rc.ReturnCode) {
+
+	pub := newPublisher(bus, SourceLoad)
+
+	result, err := l.db.QueryBy(media.ClassMedia, field, values...)
+	if nil != err {
+		return 0, rc.QueryError.Specf("LoadBy(%q): %s", field, err)
+	}
+
+	var count uint = 0
+	for kind, ids := range result {
+		for id := range ids {
+			ent, med := newMediaOfKind(media.MediaKind(kind))
+			if nil == ent {
+				break
+			}
+			if ret := ent.FromID(l.db.Col[media.ClassMedia][kind], id); nil != ret {
+				logs.Warn.Verbose(ret)
+				continue
+			}
+			logs.Info.Tracef("loaded %s (ID={%q,%X}): %s",
+				l.db.ColName[media.ClassMedia][kind], l.name, id, ent)
+			l.handleMedia(pub, med.AbsPath, ent, med, id, false)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// function handleMedia() publishes the given media entity (with embedded Media
+// med), which is new if its record was just inserted, unless it is hidden (see
+// SetHidden()), or a part of a release other than its first (see syncParts()).
+func (l *Library) handleMedia(pub *publisher, absPath string, ent media.StorableEntity, med *media.Media, id int, isNew bool) {
+	if nil == pub {
+		return
+	}
+	kind := media.KindUnknown
+	if nil != med {
+		if med.IsPart() {
+			return
+		}
+		if nil != l.hidden && l.hidden(med) {
+			return
+		}
+		kind = med.Kind
+	}
+	pub.publish(MediaDiscovered, l.discovery(absPath, ent, id, media.ClassMedia, int(kind)), isNew)
+}
+
+// function handleSupport() publishes the given support entity of the given
+// kind, which is new if its record was just inserted.
+func (l *Library) handleSupport(pub *publisher, absPath string, ent media.StorableEntity, kind media.SupportKind, id int, isNew bool) {
+	pub.publish(SupportDiscovered, l.discovery(absPath, ent, id, media.ClassSupport, int(kind)), isNew)
+}
+
+// function handleRemove() publishes the removal of the record of the given
+// class and kind of the file at the given path, whose data rec was deleted from
+// the database (nil if it wasn't, see remove() of publisher).
+func (l *Library) handleRemove(pub *publisher, absPath string, class media.EntityClass, kind int, rec []byte) {
+	pub.remove(l.discovery(absPath, nil, -1, class, kind), rec)
+}
+
+// function seenFile() checks if the file specified by path and kind of media
+// exists in the associated collection of this library's database, returning the
+// ID of its record if so.
+func (l *Library) seenFile(class media.EntityClass, kind int, path string) (int, bool, error) {
+
+	fieldRef := [media.ClassCOUNT]string{
+		"AbsPath", // media.ClassMedia
+		"AbsPath", // media.ClassSupport
+		"AbsPath", // media.ClassPlaylist
+		"Key",     // media.ClassSeries (keyed, not a path)
+	}
+
+	// verify we've received a file of a known specific class.
+	var field string
+	if class != media.ClassUnknown && class < media.ClassCOUNT {
+		field = fieldRef[class]
+	} else {
+		return -1, false, fmt.Errorf("seenFile(): unrecognized class: %d", int(class))
+	}
+
+	// perform a simple database query on the appropriate table to check if
+	// we've ever seen this file before based on its absolute path.
+	return l.db.FindOne(class, kind, field, path)
+}
+
+// function rescanFile() compares the record with the given ID, of a file seen
+// before, with the file's current info on the file system. if the file has
+// changed since, e.g. it was replaced by a better encoding, the record is
+// updated in place to match.
+func (l *Library) rescanFile(class media.EntityClass, kind int, id int, dispPath string, info os.FileInfo) *rc.ReturnCode {
+
+	var ent media.StorableEntity
+	var fs **media.Entity
+	var med *media.Media
+	switch class {
+	case media.ClassMedia:
+		med = &media.Media{}
+		switch media.MediaKind(kind) {
+		case media.KindAudio:
+			audio := &media.AudioMedia{Media: med}
+			ent, fs = audio, &audio.Entity
+		case media.KindVideo:
+			video := &media.VideoMedia{Media: med}
+			ent, fs = video, &video.Entity
+		case media.KindImage:
+			image := &media.ImageMedia{Media: med}
+			ent, fs = image, &image.Entity
+		case media.KindDocument:
+			doc := &media.DocumentMedia{Media: med}
+			ent, fs = doc, &doc.Entity
+		}
+	case media.ClassSupport:
+		switch media.SupportKind(kind) {
+		case media.SupportSubtitles:
+			subs := &media.Subtitles{Support: &media.Support{}}
+			ent, fs = subs, &subs.Entity
+		case media.SupportArtwork:
+			art := &media.Artwork{Support: &media.Support{}}
+			ent, fs = art, &art.Entity
+		case media.SupportMetadata:
+			meta := &media.Metadata{Support: &media.Support{}}
+			ent, fs = meta, &meta.Entity
+		case media.SupportLyrics:
+			lyr := &media.Lyrics{Support: &media.Support{}}
+			ent, fs = lyr, &lyr.Entity
+		case media.SupportCueSheet:
+			cs := &media.CueSheet{Support: &media.Support{}}
+			ent, fs = cs, &cs.Entity
+		}
+	}
+	if nil == ent {
+		return rc.InvalidFile.Specf(
+			"rescanFile(%q): unsupported class/kind: %d/%d (skipping)", dispPath, int(class), kind)
+	}
+
+	col := l.db.Col[class][kind]
+	if ret := ent.FromID(col, id); nil != ret {
+		return ret
+	}
+	if nil == *fs {
+		return nil // corrupt, which is left to Load() to quarantine
+	}
+	changed := (*fs).Changed(info)
+	// media recorded before content hashes were computed are hashed once, even
+	// though unchanged.
+	unhashed := nil != med && "" == med.Hash && l.hashPartial >= 0 && !med.IsTrack() && !med.IsDisc()
+	// likewise the file IDs, by which moved media are recognized (see
+	// relocateMedia()), and which change when the file is replaced in place.
+	_, ino, ok := platform.FileID(info)
+	unidentified := ok && ino != (*fs).Inode
+	if !changed && !unhashed && !unidentified {
+		return nil
+	}
+	if changed || unidentified {
+		(*fs).Refresh(info)
+	}
+	if changed {
+		if nil != med {
+			// the checksum and hash are of the file's previous content, so the
+			// media is due for verification as though it never was.
+			med.Checksum, med.Verified, med.Hash = "", time.Time{}, ""
+		}
+		switch e := ent.(type) {
+		case *media.AudioMedia:
+			// the tags may have been edited along with the content.
+			l.readAudioTags(e)
+		case *media.VideoMedia:
+			l.probeVideo(e)
+		case *media.ImageMedia:
+			l.readImageInfo(e)
+		case *media.DocumentMedia:
+			l.readDocumentInfo(e)
+		case *media.Subtitles:
+			e.DetectLanguage()
+		case *media.Metadata:
+			// the changed content is applied again (see syncMetadata()).
+			e.Applied = time.Time{}
+		}
+	}
+	if nil != med {
+		l.hashMedia(med)
+	}
+
+	rec, ret := ent.ToRecord()
+	if nil != ret {
+		return ret
+	}
+	if err := col.Update(id, *rec); nil != err {
+		return rc.DatabaseError.Specf(
+			"rescanFile(%q): failed to update record (ID={%q,%X}): %s", dispPath, l.name, id, err)
+	}
+	l.db.NumRecordsUpdate[class][kind]++
+	logs.Info.Tracef("updated %s (ID={%q,%X}): %s",
+		l.db.ColName[class][kind], l.name, id, *fs)
+
+	return nil
+}
+
+// type fileKey uniquely identifies a file regardless of the paths by which it
+// is reached, by its device and inode IDs if the platform provides them, or by
+// its resolved path otherwise.
+type fileKey struct {
+	dev, ino uint64
+	path     string
+}
+
+// function visit() marks the directory at the given path, with the given info,
+// as traversed by the current scan. returns false if it already was, i.e. the
+// directory was reached again by way of a symbolic link.
+func (l *Library) visit(absPath string, info os.FileInfo) bool {
+
+	var key fileKey
+	if dev, ino, ok := platform.FileID(info); ok {
+		key = fileKey{dev: dev, ino: ino}
+	} else if real, err := filepath.EvalSymlinks(absPath); nil == err {
+		key = fileKey{path: real}
+	} else {
+		key = fileKey{path: absPath}
+	}
+	if l.visited[key] {
+		return false
+	}
+	l.visited[key] = true
+	return true
+}
+
+// function scanDive() is the recursive step for the file system traversal,
+// invoked initially by function Scan(). error codes generated in this routine
+// will be returned to the caller of scanDive() -and- the caller of Scan(). the
+// traversal stops, returning rc.Canceled, once the given Context is done.
+func (l *Library) scanDive(ctx context.Context, pub *publisher, absPath string, depth uint) *rc.ReturnCode {
+
+	if nil != ctx.Err() {
+		return rc.Canceled.Specf("scanDive(%q, %d): %s", absPath, depth, ctx.Err())
+	}
+	l.throttle.file()
+
+	// get a path to the file relative to the library root dir (useful for
+	// displaying diagnostic info to the user).
+	relPath, err := filepath.Rel(l.absPath, absPath)
+	if nil != err {
+		return rc.InvalidPath.Specf(
+			"scanDive(%q, %d): filepath.Rel(%q): %s", absPath, depth, l.absPath, err)
+	}
+
+	// for concision, show the relative path by default in any diagnostics/logs.
+	dispPath := relPath
+
+	// read fs attributes to determine how we handle the file.
+	fileInfo, err := os.Lstat(absPath)
+	if nil != err {
+		return rc.InvalidStat.Specf(
+			"scanDive(%q, %d): os.Lstat(): %s", dispPath, depth, err)
+	}
+	mode := fileInfo.Mode()
+
+	// if following symbolic links, handle the file to which it resolves as if
+	// it were located at the link's path, remembering where it really is.
+	linkTarget := ""
+	if l.followLinks && (mode&os.ModeSymlink) > 0 {
+		if linkTarget, err = filepath.EvalSymlinks(absPath); nil != err {
+			return rc.InvalidFile.Specf(
+				"scanDive(%q, %d): broken symlink: %s (skipping)", dispPath, depth, err)
+		}
+		if fileInfo, err = os.Stat(absPath); nil != err {
+			return rc.InvalidStat.Specf(
+				"scanDive(%q, %d): os.Stat(): %s", dispPath, depth, err)
+		}
+		mode = fileInfo.Mode()
+	}
+
+	// operate on the file based on its file mode.
+	switch {
+	case (mode & os.ModeDir) > 0:
+		// file is directory, scanDive its contents unless we are at max depth.
+		if DepthUnlimited != l.maxDepth && depth > l.maxDepth {
+			return rc.DirDepth.Specf(
+				"scanDive(%q, %d): limit = %d", dispPath, depth, l.maxDepth)
+		}
+		// symbolic links may lead back to a directory we're already in.
+		if l.followLinks && !l.visit(absPath, fileInfo) {
+			return rc.InvalidFile.Specf(
+				"scanDive(%q, %d): symlink cycle, directory already scanned (skipping)", dispPath, depth)
+		}
+		// the structure of a video disc is a single video, not the fragments
+		// it contains.
+		if format, ok := media.DiscFormat(fileInfo.Name()); ok && absPath != l.absPath {
+			return l.scanDisc(pub, absPath, relPath, format, linkTarget, fileInfo)
+		}
+		dir, err := os.Open(absPath)
+		if nil != err {
+			return rc.DirOpen.Specf(
+				"scanDive(%q, %d): os.Open(): %s", dispPath, depth, err)
+		}
+		dirName, err := dir.Readdirnames(0)
+		dir.Close()
+		if nil != err {
+			return rc.DirOpen.Specf(
+				"scanDive(%q, %d): dir.Readdirnames(): %s", dispPath, depth, err)
+		}
+
+		// recursively scan all of this subdirectory's contents.
+		var scanErr *rc.ReturnCode
+		for _, name := range dirName {
+			// never rediscover the files we've deleted.
+			if trash.IsTrashDir(name) {
+				logs.Info.Tracef("skipping trash directory: %q", path.Join(dispPath, name))
+				continue
+			}
+			// nor the files the user doesn't want, which are skipped before
+			// we even stat them.
+			if l.ignore.Match(filepath.Join(relPath, name)) {
+				logs.Info.Tracef("skipping ignored file: %q", path.Join(dispPath, name))
+				l.numIgnored++
+				continue
+			}
+			scanErr = l.scanDive(ctx, pub, path.Join(absPath, name), depth+1)
+			if errors.Is(scanErr, rc.Canceled) {
+				// abandon the rest of the traversal, everything found so far
+				// has already been inserted, or is buffered to be.
+				return scanErr
+			}
+			if nil != scanErr {
+				// a file/subdir of the current directory threw an error.
+				l.warnings.add(filepath.Join(relPath, name), scanErr)
+			}
+		}
+		// the records buffered while scanning a large library are inserted at
+		// the end of each directory once they've waited long enough, here on
+		// the scanning goroutine, which the functions given with them expect.
+		if ret := l.db.FlushDue(); nil != ret {
+			logs.Warn.Log(ret)
+		}
+		return nil
+
+	case (mode & os.ModeSymlink) > 0:
+		// symlinks are only followed if requested (see SetFollowLinks()).
+		return rc.InvalidFile.Specf(
+			"scanDive(%q, %d): symlinks not followed (skipping)", dispPath, depth)
+
+	case (mode & (os.ModeDevice | os.ModeNamedPipe | os.ModeSocket | os.ModeCharDevice)) > 0:
+		// file is not a regular file, not supported.
+		return rc.InvalidFile.Specf(
+			"scanDive(%q, %d): not a regular file (skipping)", dispPath, depth)
+
+	default:
+		// count the file towards the progress of the scan.
+		l.numFiles++
+		l.busyState.Advance(l.scanTask(), 1, fileInfo.Size())
+
+		// first extract the file name extension. this is how we determine file
+		// type; not very intelligible, but fast and mostly reliable for media
+		// files (~my~ media files, at least).
+		ext := path.Ext(absPath)
+
+		// check if it looks like a regular media file, of a kind this library
+		// contains.
+		kind, extName := l.mediaKindOfFile(absPath)
+		if !l.allowsKind(kind) {
+			logs.Info.Tracef("skipping %s file: %q (not a kind of media of this library)",
+				strings.ToLower(media.MediaColName[kind]), dispPath)
+			l.numIgnored++
+			return nil
+		}
+		// nor the media the user doesn't consider worth adding.
+		if reason := l.filter.skips(kind, relPath, fileInfo.Size()); "" != reason {
+			logs.Info.Tracef("skipping %s file: %q (%s)",
+				strings.ToLower(media.MediaColName[kind]), dispPath, reason)
+			l.handleOther(pub, absPath, relPath, fileInfo.Size())
+			return nil
+		}
+		switch kind {
+		case media.KindAudio:
+
+			// select the audio database collection to determine if this is a
+			// previously-known file or if we need to insert a new entity.
+			ab := l.db.Batch[media.ClassMedia][media.KindAudio]
+			id, seen, err := l.seenFile(media.ClassMedia, int(kind), absPath)
+			if err != nil {
+				return rc.InvalidFile.Specf(
+					"scanDive(%q, %d): failed to evaluate query: %s (skipping)", dispPath, depth, err)
+			}
+			if !seen {
+				// it may be a file we've seen before at another path.
+				if moved, ret := l.relocateMedia(pub, kind, absPath, relPath, linkTarget, fileInfo); moved || nil != ret {
+					return ret
+				}
+				// this is a legitimately unknown file, create a new AudioMedia
+				// entity and insert it into the database.
+				audio := media.NewAudioMedia(absPath, relPath, ext, extName, fileInfo)
+				audio.LinkTarget = linkTarget
+				l.readAudioTags(audio)
+				l.hashMedia(audio.Media)
+				if err := l.plugins.Enrich(audio); nil != err {
+					logs.Warn.Verbose(err)
+				}
+				if rec, recErr := audio.ToRecord(); nil == recErr {
+					// the record is inserted with the next batch, once the
+					// new media is published.
+					return ab.Insert(*rec, func(id int) {
+						l.db.NumRecordsScan[media.ClassMedia][kind]++
+						logs.Info.Tracef("discovered audio (ID={%q,%X}): %s", l.name, id, audio)
+						// publish the new AudioMedia.
+						l.handleMedia(pub, absPath, audio, audio.Media, id, true)
+					})
+				} else {
+					// failed to construct a new Audio object.
+					return recErr
+				}
+			} else {
+				// a file we've seen before, but it may have changed since.
+				return l.rescanFile(media.ClassMedia, int(kind), id, dispPath, fileInfo)
+			}
+
+		case media.KindVideo:
+
+			// select the video database collection to determine if this is a
+			// previously-known file or if we need to insert a new entity.
+			vb := l.db.Batch[media.ClassMedia][media.KindVideo]
+			id, seen, err := l.seenFile(media.ClassMedia, int(kind), absPath)
+			if err != nil {
+				return rc.InvalidFile.Specf(
+					"scanDive(%q, %d): failed to evaluate query: %s (skipping)", dispPath, depth, err)
+			}
+			if !seen {
+				if moved, ret := l.relocateMedia(pub, kind, absPath, relPath, linkTarget, fileInfo); moved || nil != ret {
+					return ret
+				}
+				// this is a legitimately unknown file, create a new VideoMedia
+				// entity and insert it into the database.
+				video := media.NewVideoMedia(absPath, relPath, ext, extName, fileInfo)
+				video.LinkTarget = linkTarget
+				l.probeVideo(video)
+				l.hashMedia(video.Media)
+				if err := l.plugins.Enrich(video); nil != err {
+					logs.Warn.Verbose(err)
+				}
+				if rec, recErr := video.ToRecord(); nil == recErr {
+					return vb.Insert(*rec, func(id int) {
+						l.db.NumRecordsScan[media.ClassMedia][kind]++
+						logs.Info.Tracef("discovered video (ID={%q,%X}): %s", l.name, id, video)
+						// publish the new VideoMedia.
+						l.handleMedia(pub, absPath, video, video.Media, id, true)
+					})
+				} else {
+					// failed to construct a new Video object.
+					return recErr
+				}
+			} else {
+				// a file we've seen before, but it may have changed since.
+				return l.rescanFile(media.ClassMedia, int(kind), id, dispPath, fileInfo)
+			}
+
+		case media.KindImage, media.KindDocument:
+			// photos and documents need nothing special beyond the info read
+			// from them.
+			return l.scanPluginFile(pub, media.ClassMedia, int(kind),
+				absPath, relPath, ext, extName, linkTarget, fileInfo)
+
+		default:
+
+			// doesn't have an extension typically associated with media files.
+			// check if it is a media-supporting file.
+			switch kind, extName := media.SupportKindOfFile(absPath); kind {
+			case media.SupportSubtitles:
+				// select the media support database collection to determine if
+				// this is a previously-known file or if we need to insert a new
+				// entity.
+				sb := l.db.Batch[media.ClassSupport][media.SupportSubtitles]
+				id, seen, err := l.seenFile(media.ClassSupport, int(kind), absPath)
+				if err != nil {
+					return rc.InvalidFile.Specf(
+						"scanDive(%q, %d): failed to evaluate query: %s (skipping)", dispPath, depth, err)
+				}
+				if !seen {
+					// this is a legitimately unknown file, create a new media
+					// support entity and insert it into the database.
+					subs := media.NewSubtitles(absPath, relPath, ext, extName, fileInfo)
+					subs.LinkTarget = linkTarget
+					subs.DetectLanguage()
+					if rec, recErr := subs.ToRecord(); nil == recErr {
+						return sb.Insert(*rec, func(id int) {
+							l.db.NumRecordsScan[media.ClassSupport][kind]++
+							logs.Info.Tracef("discovered subtitles (ID={%q,%X}): %s", l.name, id, subs)
+							// publish the new Subtitles.
+							l.handleSupport(pub, absPath, subs, media.SupportSubtitles, id, true)
+						})
+					} else {
+						// failed to construct a new Subtitles object.
+						return recErr
+					}
+				} else {
+					// a file we've seen before, but it may have changed since.
+					return l.rescanFile(media.ClassSupport, int(kind), id, dispPath, fileInfo)
+				}
+
+			case media.SupportArtwork, media.SupportMetadata, media.SupportLyrics, media.SupportCueSheet:
+				// these are associated with media once the scan completes (see
+				// syncArtwork(), syncMetadata(), syncLyrics(), and
+				// syncCueSheets()), so need nothing special.
+				return l.scanPluginFile(pub, media.ClassSupport, int(kind),
+					absPath, relPath, ext, extName, linkTarget, fileInfo)
+
+			default:
+				// playlists list media rather than support them, but are just
+				// as useless on their own.
+				if kind, extName := media.PlaylistKindOfFileExt(ext); media.PlaylistUnknown != kind {
+					return l.scanPlaylistFile(absPath, relPath, ext, extName, linkTarget, fileInfo)
+				}
+				// we can't identify the file, but one of the plugins might.
+				if class, kind, extName, ok := l.plugins.Classify(absPath); ok {
+					return l.scanPluginFile(pub, class, kind,
+						absPath, relPath, ext, extName, linkTarget, fileInfo)
+				}
+				// cannot identify the file, probably an undesirable piece of
+				// trash. well-suited for being ignored, though it's recorded
+				// in the junk report (see JunkReport()).
+				l.handleOther(pub, absPath, relPath, fileInfo.Size())
+			}
+		}
+		return nil
+	}
+}
+
+// function handleOther() records the file at the given path, relative to the
+// library, of the given size, which is neither media nor a support file, in the
+// junk report of the current scan (see JunkReport()), and publishes it.
+func (l *Library) handleOther(pub *publisher, absPath, relPath string, size int64) {
+	l.junk = append(l.junk, storage.JunkEntry{
+		Path: relPath,
+		Ext:  strings.ToLower(path.Ext(relPath)),
+		Size: size,
+	})
+	pub.publish(OtherDiscovered, l.discovery(absPath, nil, -1, media.ClassUnknown, -1), false)
+}
+
+// function scanDisc() inserts the video disc whose structure is held by the
+// directory at the given path (see media.DiscFormat()), in the given format,
+// into the database as a single video, unless it is already known, and
+// publishes it. its files are never scanned on their own.
+func (l *Library) scanDisc(pub *publisher, absPath, relPath, format, linkTarget string, dirInfo os.FileInfo) *rc.ReturnCode {
+
+	if !l.allowsKind(media.KindVideo) {
+		logs.Info.Tracef("skipping video disc: %q (not a kind of media of this library)", relPath)
+		l.numIgnored++
+		return nil
+	}
+	// the disc counts towards the progress of the scan like a single file.
+	l.numFiles++
+	info := media.DiscInfo(absPath, dirInfo)
+	l.busyState.Advance(l.scanTask(), 1, info.Size())
+
+	kind := media.KindVideo
+	id, seen, err := l.seenFile(media.ClassMedia, int(kind), absPath)
+	if nil != err {
+		return rc.InvalidFile.Specf(
+			"scanDisc(%q): failed to evaluate query: %s (skipping)", relPath, err)
+	}
+	if seen {
+		// a disc we've seen before, but its files may have changed since.
+		return l.rescanFile(media.ClassMedia, int(kind), id, relPath, info)
+	}
+	if moved, ret := l.relocateMedia(pub, kind, absPath, relPath, linkTarget, info); moved || nil != ret {
+		return ret
+	}
+	video := media.NewDiscVideoMedia(absPath, relPath, format, info)
+	video.LinkTarget = linkTarget
+	if err := l.plugins.Enrich(video); nil != err {
+		logs.Warn.Verbose(err)
+	}
+	rec, ret := video.ToRecord()
+	if nil != ret {
+		return ret
+	}
+	return l.db.Batch[media.ClassMedia][kind].Insert(*rec, func(id int) {
+		l.db.NumRecordsScan[media.ClassMedia][kind]++
+		logs.Info.Tracef("discovered video disc (ID={%q,%X}): %s", l.name, id, video)
+		l.handleMedia(pub, absPath, video, video.Media, id, true)
+	})
+}
+
+// function scanPluginFile() inserts a file identified by one of the plugins into
+// the database as a new entity of the given class and kind, unless it is
+// already known, and publishes it. it serves just as well for the
+// files pimmp identifies itself which need no special handling when scanned.
+func (l *Library) scanPluginFile(pub *publisher, class media.EntityClass, kind int, absPath, relPath, ext, extName, linkTarget string, info os.FileInfo) *rc.ReturnCode {
+
+	id, seen, err := l.seenFile(class, kind, absPath)
+	if nil != err {
+		return rc.InvalidFile.Specf(
+			"scanPluginFile(%q): failed to evaluate query: %s (skipping)", relPath, err)
+	}
+	if seen {
+		return l.rescanFile(class, kind, id, relPath, info)
+	}
+	if media.ClassMedia == class {
+		if moved, ret := l.relocateMedia(pub, media.MediaKind(kind), absPath, relPath, linkTarget, info); moved || nil != ret {
+			return ret
+		}
+	}
+
+	ent := media.NewStorableEntity(class, kind, absPath, relPath, ext, extName, info)
+	if nil == ent {
+		return rc.InvalidFile.Specf(
+			"scanPluginFile(%q): unsupported class/kind: %d/%d (skipping)", relPath, int(class), kind)
+	}
+	switch e := ent.(type) {
+	case *media.AudioMedia:
+		e.LinkTarget = linkTarget
+		l.readAudioTags(e)
+		l.hashMedia(e.Media)
+	case *media.VideoMedia:
+		e.LinkTarget = linkTarget
+		l.probeVideo(e)
+		l.hashMedia(e.Media)
+	case *media.ImageMedia:
+		e.LinkTarget = linkTarget
+		l.readImageInfo(e)
+		l.hashMedia(e.Media)
+	case *media.DocumentMedia:
+		e.LinkTarget = linkTarget
+		l.readDocumentInfo(e)
+		l.hashMedia(e.Media)
+	case *media.Subtitles:
+		e.LinkTarget = linkTarget
+		e.DetectLanguage()
+	case *media.Artwork:
+		e.LinkTarget = linkTarget
+	case *media.Metadata:
+		e.LinkTarget = linkTarget
+	case *media.Lyrics:
+		e.LinkTarget = linkTarget
+	case *media.CueSheet:
+		e.LinkTarget = linkTarget
+	}
+	if media.ClassMedia == class {
+		if err := l.plugins.Enrich(ent); nil != err {
+			logs.Warn.Verbose(err)
+		}
+	}
+	rec, recErr := ent.ToRecord()
+	if nil != recErr {
+		return recErr
+	}
+	// the record is inserted with the next batch, once the new entity is
+	// published.
+	return l.db.Batch[class][kind].Insert(*rec, func(id int) {
+		l.db.NumRecordsScan[class][kind]++
+		logs.Info.Tracef("discovered %s (ID={%q,%X}): %s",
+			l.db.ColName[class][kind], l.name, id, ent)
+
+		switch class {
+		case media.ClassMedia:
+			var med *media.Media
+			switch e := ent.(type) {
+			case *media.AudioMedia:
+				med = e.Media
+			case *media.VideoMedia:
+				med = e.Media
+			case *media.ImageMedia:
+				med = e.Media
+			case *media.DocumentMedia:
+				med = e.Media
+			}
+			l.handleMedia(pub, absPath, ent, med, id, true)
+		case media.ClassSupport:
+			l.handleSupport(pub, absPath, ent, media.SupportKind(kind), id, true)
+		}
+	})
+}
+
+// function Scan() is the entry point for initiating a scan on the library's
+// root file system, publishing each file found on the given Bus (if not nil)
+// from SourceScan, and finally ScanFinished. the scan is interrupted once the
+// given Context is done, in which case the media discovered until then are
+// flushed to the database and rc.Canceled is returned. you must wait for the
+// scan to finish before restarting.
+func (l *Library) Scan(ctx context.Context, bus *Bus) (uint, *rc.ReturnCode) {
+
+	var (
+		numScan  uint = 0 // number of -new- files discovered on file system
+		err      *rc.ReturnCode
+		finished *Event // published once the scan is no longer running
+	)
+
+	pub := newPublisher(bus, SourceScan)
+
+	//
+	// the scanStart channel is buffered so that we can limit the number of
+	// goroutines concurrently traversing this library's file system:
+	//
+	//     writes to the channel will fail and fallback on the default select
+	//     case if the max number of scanners is reached -- which sets an error
+	//     code that is returned to the caller -- so be sure to check
+	//     the return value when calling function Scan()!
+	//
+
+	// wait for the other libraries sharing our scheduler, if need be.
+	release, err := l.scheduler.scan(ctx, l)
+	if nil != err {
+		return numScan, err
+	}
+
+	// try writing to the buffered channel. this will succeed if and only if it
+	// isn't already filled to capacity.
+	select {
+	case l.scanStart <- time.Now():
+
+		// notify the user that a potentially time-intensive operation has
+		// begun and user interactions will be limited.
+		if nil != l.busyState {
+			l.busyState.Inc(l.scanTask())
+			// the library contained as many files at the last scan.
+			l.busyState.SetTotal(l.scanTask(), int64(l.lastSession().Files))
+		}
+
+		// the write succeeded, so we can initiate scanning. keep track of the
+		// time at which we began so that the time elapsed can be calculated and
+		// notified to the user.
+		logs.Info.Verbosef("scanning: %q", l.name)
+		l.visited = map[fileKey]bool{}
+		l.moved = nil
+		l.throttle = newThrottle(ctx, l.scanRate)
+		l.warnings = newScanWarnings()
+		l.loadIgnore()
+		err = l.scanDive(ctx, pub, l.absPath, 1)
+		// the records of the new files are inserted in batches, the last of
+		// which must be inserted before any can be associated.
+		if ret := l.db.Flush(); nil != ret {
+			logs.Warn.Log(ret)
+		}
+		if nil == err {
+			l.RecandidateSubtitles(false)
+			if ret := l.syncMetadata(); nil != ret {
+				logs.Warn.Log(ret)
+			}
+			if ret := l.syncSeries(); nil != ret {
+				logs.Warn.Log(ret)
+			}
+			if ret := l.syncArtwork(); nil != ret {
+				logs.Warn.Log(ret)
+			}
+			if ret := l.syncLyrics(); nil != ret {
+				logs.Warn.Log(ret)
+			}
+			if ret := l.syncCueSheets(pub); nil != ret {
+				logs.Warn.Log(ret)
+			}
+			if ret := l.syncParts(pub); nil != ret {
+				logs.Warn.Log(ret)
+			}
+			if ret := l.syncDates(); nil != ret {
+				logs.Warn.Log(ret)
+			}
+		} else if errors.Is(err, rc.Canceled) {
+			// keep the partial results, the next scan won't rediscover them.
+			logs.Warn.Logf("interrupted scanning: %q", l.name)
+			if ret := l.db.Sync(); nil != ret {
+				logs.Warn.Log(ret)
+			}
+		}
+
+		// we've finished the scanning operations, so remove the busy indicator
+		// to indicate that normal user interactions may resume (if no other
+		// event has the semaphore still incremented).
+		start := <-l.scanStart
+		l.lastScan = time.Now()
+		l.scanElapsed = l.lastScan.Sub(start)
+		if nil != l.busyState {
+			l.busyState.Dec(l.scanTask())
+		}
+
+		// construct a summary message for the load operation.
+		total, summary := l.db.TotalRecordsString(storage.MethodScan, -1, -1)
+		ignored := ""
+		if l.numIgnored > 0 {
+			ignored = fmt.Sprintf(", %d ignored", l.numIgnored)
+		}
+		if total > 0 {
+			logs.Info.Verbosef(
+				"finished scanning: %q (%s found%s in %s)",
+				l.name, summary, ignored, l.scanElapsed.Round(time.Millisecond))
+		} else {
+			logs.Info.Verbosef(
+				"finished scanning: %q (no new media found%s in %s)",
+				l.name, ignored, l.scanElapsed.Round(time.Millisecond))
+		}
+		numScan = total
+		l.scanWarned = l.warnings.total()
+		l.warnings.report(l.name)
+
+		// files seen before that have since changed are counted separately,
+		// since they aren't new media.
+		updated, updSummary := l.db.TotalRecordsString(storage.MethodUpdate, -1, -1)
+		if updated > 0 {
+			logs.Info.Verbosef("updated changed files: %q (%s)", l.name, updSummary)
+		}
+
+		// only complete scans are recorded, an interrupted scan would have
+		// discovered just some of the new media.
+		if nil == err {
+			loaded, _ := l.db.TotalRecordsString(storage.MethodLoad, -1, -1)
+			if ret := l.db.AddSession(storage.Session{
+				Start: start, Stop: l.lastScan, Found: total,
+				Files: l.numFiles, Records: loaded + total}); nil != ret {
+				logs.Warn.Log(ret)
+			}
+			if ret := l.db.SetJunkReport(&storage.JunkReport{
+				Time: l.lastScan, Entries: l.junk}); nil != ret {
+				logs.Warn.Log(ret)
+			}
+			l.junk = nil
+		}
+		// as are its problems, though even a scan that failed has some.
+		if !errors.Is(err, rc.Canceled) {
+			if ret := l.warnings.save(l.db, storage.MethodScan); nil != ret {
+				logs.Warn.Log(ret)
+			}
+		}
+
+		finished = &Event{Kind: ScanFinished, Source: SourceScan, Library: l,
+			Found: total, Updated: updated, Ignored: l.numIgnored, Elapsed: l.scanElapsed, Err: err}
+
+	default:
+		// if the write failed, we fall back to this default case. the only
+		// reason it should fail is if the buffer is already filled to capacity,
+		// meaning we already have the max allowed number of goroutines scanning
+		// this library's file system.
+		err = rc.LibraryBusy.Specf(
+			"Scan(): max number of scanners reached: %q (max = %d)",
+			l.absPath, maxLibraryScanners)
+	}
+
+	// the scan is published finished only once its slot is released, since the
+	// subscribers may well start loading or scanning the library again.
+	release()
+	if nil != finished {
+		bus.Publish(*finished)
+	}
+
+	// return a count of the total number of entities successfully loaded.
+	return numScan, err
+}
+
+// function ScanFile() indexes the single file at the given absolute path, which
+// must be located within the library's root directory, e.g. after it was moved
+// there, publishing it on the given Bus (if not nil) from SourceScan. like
+// Scan(), it fails if the library is already being scanned, and it skips the
+// file if it is ignored (see Ignore).
+func (l *Library) ScanFile(bus *Bus, absPath string) *rc.ReturnCode {
+	return l.scanFile(newPublisher(bus, SourceScan), absPath)
+}
+
+// function scanFile() indexes the single file at the given absolute path like
+// ScanFile(), publishing it with the given publisher.
+func (l *Library) scanFile(pub *publisher, absPath string) *rc.ReturnCode {
+
+	relPath, err := filepath.Rel(l.absPath, absPath)
+	if nil != err || ".." == relPath || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return rc.InvalidPath.Specf("ScanFile(%q): not within library: %q", absPath, l.absPath)
+	}
+	// the files of a video disc are indexed as part of the disc.
+	if disc := media.DiscFolder(relPath); "" != disc {
+		relPath, absPath = disc, filepath.Join(l.absPath, disc)
+	}
+
+	select {
+	case l.scanStart <- time.Now():
+		if nil != l.busyState {
+			l.busyState.Inc(l.scanTask())
+		}
+		depth := uint(len(strings.Split(relPath, string(filepath.Separator))))
+		l.visited = map[fileKey]bool{}
+		l.moved = nil
+		l.throttle = nil // a single file isn't worth throttling.
+		l.warnings = newScanWarnings()
+		l.loadIgnore()
+		var err *rc.ReturnCode
+		if l.ignore.Covers(relPath) {
+			logs.Info.Verbosef("skipping ignored file: %q", relPath)
+		} else {
+			err = l.scanDive(context.Background(), pub, absPath, depth+1)
+		}
+		// the record of a new file must be inserted before it can be found.
+		if ret := l.db.Flush(); nil == err {
+			err = ret
+		}
+		if nil == err {
+			// the file may be subtitles of media already known, or media with
+			// subtitles already known.
+			err = l.RecandidateSubtitles(false)
+		}
+		if nil == err {
+			// or the metadata of a video, or a video with metadata.
+			err = l.syncMetadata()
+		}
+		if nil == err {
+			// or an episode of a series or season not yet known.
+			err = l.syncSeries()
+		}
+		if nil == err {
+			// or artwork of media already known, or media with artwork.
+			err = l.syncArtwork()
+		}
+		if nil == err {
+			// or lyrics of audio already known, or audio with lyrics.
+			err = l.syncLyrics()
+		}
+		if nil == err {
+			// or a cue sheet of an image already known, or an image.
+			err = l.syncCueSheets(pub)
+		}
+		if nil == err {
+			// or a part of a release already known.
+			err = l.syncParts(pub)
+		}
+		l.warnings.report(l.name)
+		<-l.scanStart
+		if nil != l.busyState {
+			l.busyState.Dec(l.scanTask())
+		}
+		return err
+
+	default:
+		return rc.LibraryBusy.Specf(
+			"ScanFile(%q): max number of scanners reached: %q (max = %d)",
+			absPath, l.absPath, maxLibraryScanners)
+	}
+}
+
+// function NumMedia() returns the number of media of the given kind that have
+// been loaded from the library's database or discovered by scanning.
+func (l *Library) NumMedia(kind media.MediaKind) uint {
+	if kind <= media.KindUnknown || kind >= media.KindCOUNT {
+		return 0
+	}
+	return l.db.NumRecordsLoad[media.ClassMedia][kind] +
+		l.db.NumRecordsScan[media.ClassMedia][kind]
+}
+
+// function findCandidates() scans the database for video media that appears to
+// be related to the given subtitles file in some nominal/positional way, and
+// associates the subtitles with the most likely. the videos considered are
+// those whose base name matches that of the subtitles exactly, and those in the
+// same directory as the subtitles, its parent, or the directory containing a
+// common subtitles subdirectory (e.g. "Subs") holding them. each is scored by
+// the similarity of its name to that of the subtitles (see fuzzy.Similarity()),
+// weighted with the proximity of its directory (see SetSubtitleMatching()),
+// and the subtitles are associated with those scoring best, if they reach the
+// threshold. TV episodes are never associated with the subtitles of another
+// episode, however similar their names.
+// --
+// if argument update is true, then the database is updated to store all of the
+// bi-directional associations discovered between the Subtitles object and its
+// VideoMedia objects. the argument subID is the current doc ID of the given
+// Subtitles object in this library's subtitles table (only required if update
+// is true).
+func (l *Library) findCandidates(s *media.Subtitles, update bool, subID int) ([]*media.VideoMedia, *rc.ReturnCode) {
+
+	vidCol := l.db.Col[media.ClassMedia][media.KindVideo]
+	subCol := l.db.Col[media.ClassSupport][media.SupportSubtitles]
+	candidate := []*media.VideoMedia{}
+
+	// the directories in which videos are considered, and how near each is to
+	// the subtitles (1 = same directory).
+	proximity := map[string]float64{
+		s.AbsDir:           1.0,
+		path.Dir(s.AbsDir): 0.5,
+	}
+	// e.g., "/a/b/Foo.avi" <- "/a/b/Subs/Bar.srt"
+	if found, dir := s.IsInSubtitlesSubdir(); found {
+		proximity[dir] = 0.8
+	}
+
+	// e.g., "Foo.avi" <- "Foo.srt", anywhere in the library, and
+	// "Foo.avi" <- "Foo.en.srt"
+	queryResult, err := l.db.Find(media.ClassMedia, int(media.KindVideo), "AbsBase", s.AbsBase, s.VideoBase())
+	if nil != err {
+		return nil, rc.QueryError.Specf(
+			"findCandidates(%s): Find(%s): %s", l, s.AbsBase, err)
+	}
+	dirs := []string{}
+	for dir := range proximity {
+		dirs = append(dirs, dir)
+	}
+	inDirs, err := l.db.Find(media.ClassMedia, int(media.KindVideo), "AbsDir", dirs...)
+	if nil != err {
+		return nil, rc.QueryError.Specf(
+			"findCandidates(%s): Find(%s): %s", l, s.AbsBase, err)
+	}
+	for id := range inDirs {
+		queryResult[id] = struct{}{}
+	}
+
+	type scored struct {
+		id    int
+		video *media.VideoMedia
+		score float64
+	}
+	name := naming.Parse(s.VideoBase())
+	list := []scored{}
+	best := 0.0
+	numInDir := 0
+	for id := range queryResult {
+		video := &media.VideoMedia{}
+		if nil != video.FromID(vidCol, id) || nil == video.Media || nil == video.Entity {
+			continue
+		}
+		if s.AbsDir == video.AbsDir {
+			numInDir++
+		}
+		score := l.scoreSubtitles(s, name, video, proximity[video.AbsDir])
+		logs.Info.Tracef("scored subtitles (%q) for video %q: %.2f", s.AbsName, video.Name, score)
+		list = append(list, scored{id, video, score})
+		if score > best {
+			best = score
+		}
+	}
+
+	// the best scoring videos, which are usually just one (or several encodings
+	// of the same video), are associated if they score high enough. failing
+	// that, the subtitles are associated with every video of its directory if
+	// it has at most N, since they likely belong to one of them even if named
+	// inconsistently.
+	//   e.g. (N=2), {"Foo1.avi","Foo2.avi"} <- "Bar.srt"
+	accept := func(c scored) bool {
+		return best >= l.subThreshold && c.score >= best-scoreTolerance
+	}
+	method := "score"
+	if best < l.subThreshold && numInDir > 0 && numInDir <= media.MaxNumMediaAssocSubs {
+		method = "directory"
+		accept = func(c scored) bool {
+			return s.AbsDir == c.video.AbsDir && !conflictingEpisode(name, c.video)
+		}
+	}
+	for _, c := range list {
+		if !accept(c) {
+			continue
+		}
+		added, addErr := c.video.AddSubtitles(vidCol, subCol, c.id, subID, update, false, s)
+		if nil != addErr {
+			return nil, addErr
+		}
+		if added {
+			logs.Info.Tracef("associated subtitles (%q, [%s %.2f]) with video: %q",
+				s.AbsName, method, c.score, c.video.Name)
+			candidate = append(candidate, c.video)
+		}
+	}
+
+	return candidate, nil
+}
+
+// constant scoreTolerance is how much lower than the best score a video may
+// score and still be associated with subtitles, i.e. be considered a tie.
+const scoreTolerance = 1e-6
+
+// function scoreSubtitles() returns how likely the given subtitles, whose name
+// was parsed as the given name, belong to the given video, whose directory has
+// the given proximity to that of the subtitles (0 if unrelated), from 0 to 1.
+func (l *Library) scoreSubtitles(s *media.Subtitles, name *naming.Name, video *media.VideoMedia, proximity float64) float64 {
+
+	if conflictingEpisode(name, video) {
+		return 0
+	}
+	var similar float64
+	switch {
+	case s.AbsBase == video.AbsBase || s.VideoBase() == video.AbsBase:
+		similar = 1
+	default:
+		similar = fuzzy.Similarity(s.VideoBase(), video.AbsBase)
+		// e.g., "/a/b/Foo/Foo.avi" <- "/a/b/Foo/Bar.srt"
+		if s.AbsDir == video.AbsDir {
+			if d := fuzzy.Similarity(path.Base(s.AbsDir), video.AbsBase); d > similar {
+				similar = d
+			}
+		}
+		// the same episode of the same series is as good as named the same,
+		// whatever else the names contain.
+		if name.IsEpisode() && video.IsEpisode() &&
+			media.SeriesKey(name.Series, int64(name.Year)) == video.SeriesKey && similar < 0.9 {
+			similar = 0.9
+		}
+	}
+	return (1-l.subDirWeight)*similar + l.subDirWeight*proximity
+}
+
+// function conflictingEpisode() returns true if the given name, parsed from
+// that of some subtitles, and the given video are both TV episodes, yet not the
+// same episode.
+func conflictingEpisode(name *naming.Name, video *media.VideoMedia) bool {
+	return name.IsEpisode() && video.IsEpisode() &&
+		(int64(name.Season) != video.Season || int64(name.Episode) != video.Episode)
+}
@@ -0,0 +1,136 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: libtype.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the type of a library, which restricts the kinds of media its
+//    scans add, e.g. so that a music library never adds the odd video.
+//
+// =============================================================================
+
+package library
+
+import (
+	"strings"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/storage"
+)
+
+// type Type identifies the kinds of media a library contains. it is recorded
+// in the library's database, so it applies however the library is opened.
+type Type int
+
+const (
+	TypeMixed Type = iota // = 0, media of every kind
+	TypeAudio             // = 1, audio only
+	TypeVideo             // = 2, videos only
+	TypePhoto             // = 3, images only
+	TypeCOUNT             // = 4
+)
+
+var (
+	// variable typeName maps the Type enum values to their names.
+	typeName = [TypeCOUNT]string{
+		"mixed", // 0 = TypeMixed
+		"audio", // 1 = TypeAudio
+		"video", // 2 = TypeVideo
+		"photo", // 3 = TypePhoto
+	}
+	// variable typeKind maps the Type enum values to the kind of media added
+	// by the scans of libraries of that type.
+	typeKind = [TypeCOUNT]media.MediaKind{
+		media.KindUnknown, // 0 = TypeMixed
+		media.KindAudio,   // 1 = TypeAudio
+		media.KindVideo,   // 2 = TypeVideo
+		media.KindImage,   // 3 = TypePhoto
+	}
+)
+
+// function ParseType() returns the Type with the given name (ignoring case),
+// e.g. "audio", and false if there is none.
+func ParseType(name string) (Type, bool) {
+	for t, n := range typeName {
+		if strings.EqualFold(n, strings.TrimSpace(name)) {
+			return Type(t), true
+		}
+	}
+	return TypeMixed, false
+}
+
+// function TypeNames() returns the names of every Type, in order.
+func TypeNames() []string {
+	return append([]string{}, typeName[:]...)
+}
+
+// function String() returns the name of the Type.
+func (t Type) String() string {
+	if t < 0 || t >= TypeCOUNT {
+		return typeName[TypeMixed]
+	}
+	return typeName[t]
+}
+
+// function allows() returns true if libraries of the Type contain media of the
+// given kind.
+func (t Type) allows(kind media.MediaKind) bool {
+	if t <= TypeMixed || t >= TypeCOUNT {
+		return true
+	}
+	return typeKind[t] == kind
+}
+
+// function Type() returns the type of the library (see SetType()).
+func (l *Library) Type() Type { return l.libType }
+
+// function SetType() sets the type of the library, restricting the media added
+// by its scans to the kind of that type, in addition to any set by SetKinds().
+// the type is recorded in the library's database, so it is only set once.
+func (l *Library) SetType(t Type) *rc.ReturnCode {
+	if t < 0 || t >= TypeCOUNT {
+		return rc.InvalidArgs.Specf("SetType(%d): invalid library type", int(t))
+	}
+	if t == l.libType {
+		return nil
+	}
+	info, ret := l.db.Info()
+	if nil != ret {
+		// a damaged info file is replaced entirely.
+		logs.Warn.Log(ret)
+		info = &storage.Info{}
+	}
+	info.Type = ""
+	if TypeMixed != t {
+		info.Type = t.String()
+	}
+	if ret := l.db.SetInfo(info); nil != ret {
+		return ret
+	}
+	l.libType = t
+	return nil
+}
+
+// function loadType() reads the type of the library recorded in its database.
+// libraries without one, or an unknown one, are mixed.
+func (l *Library) loadType() {
+	l.libType = TypeMixed
+	info, ret := l.db.Info()
+	if nil != ret {
+		logs.Warn.Log(ret)
+		return
+	}
+	if "" == info.Type {
+		return
+	}
+	t, ok := ParseType(info.Type)
+	if !ok {
+		logs.Warn.Logf("unknown library type: %q (treated as %s): %q",
+			info.Type, TypeMixed, l.absPath)
+		return
+	}
+	l.libType = t
+}
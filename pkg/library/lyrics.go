@@ -0,0 +1,112 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: lyrics.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the operations on the lyrics files stored in a library's
+//    database: associating each with its audio track, and reading the lyrics
+//    of a track for display.
+//
+// =============================================================================
+
+package library
+
+import (
+	"path"
+	"strings"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/lyrics"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// function syncLyrics() brings the lyrics of each audio track in this library's
+// database up to date with the lyrics files known: each track is associated
+// with the lyrics file of the same base name in its directory, preferring
+// synchronized lyrics (.lrc) to plain text, and the association of tracks
+// whose lyrics are gone is removed.
+func (l *Library) syncLyrics() *rc.ReturnCode {
+
+	// index the lyrics by directory and base name, the way they are looked up.
+	byName := map[string]*media.Lyrics{}
+	key := func(dir, base string) string { return path.Join(dir, strings.ToLower(base)) }
+	l.db.Col[media.ClassSupport][media.SupportLyrics].ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			lyr := &media.Lyrics{}
+			if nil != lyr.FromRecord(data) {
+				return true // move on to next record, Load() quarantines it
+			}
+			k := key(lyr.AbsDir, lyr.AbsBase)
+			if other, ok := byName[k]; !ok || preferLyrics(lyr, other) {
+				byName[k] = lyr
+			}
+			return true // move on to next record
+		})
+
+	col := l.db.Col[media.ClassMedia][media.KindAudio]
+	changed := map[int]*media.AudioMedia{}
+	col.ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			audio := &media.AudioMedia{}
+			if nil != audio.FromRecord(data) || nil == audio.Media || nil == audio.Entity {
+				return true // move on to next record, Load() quarantines it
+			}
+			found := ""
+			if lyr, ok := byName[key(audio.AbsDir, audio.AbsBase)]; ok && lyr.Describes(audio.AbsDir, audio.AbsBase) {
+				found = lyr.AbsPath
+			}
+			if found != audio.Lyrics {
+				audio.Lyrics = found
+				changed[id] = audio
+			}
+			return true // move on to next record
+		})
+
+	for id, audio := range changed {
+		rec, ret := audio.ToRecord()
+		if nil != ret {
+			return ret
+		}
+		if err := col.Update(id, *rec); nil != err {
+			return rc.DatabaseError.Specf(
+				"syncLyrics(): failed to update record (ID={%q,%X}): %s", l.name, id, err)
+		}
+		logs.Info.Tracef("updated lyrics of audio (ID={%q,%X}): %q", l.name, id, audio.Lyrics)
+	}
+	return nil
+}
+
+// function preferLyrics() returns true if the lyrics file a is preferred to b
+// for the same track: synchronized lyrics (.lrc) before plain text, and then
+// the first by path.
+func preferLyrics(a, b *media.Lyrics) bool {
+	synced := func(lyr *media.Lyrics) bool { return ".lrc" == strings.ToLower(lyr.Ext) }
+	if synced(a) != synced(b) {
+		return synced(a)
+	}
+	return a.AbsPath < b.AbsPath
+}
+
+// function LyricsOf() reads the lyrics of the audio track at the given absolute
+// path, returning nil if it has none (or isn't in this library). synchronized
+// lyrics tag each line with the time at which it is sung (see package lyrics).
+func (l *Library) LyricsOf(absPath string) (*lyrics.Lyrics, *rc.ReturnCode) {
+
+	kind, id, ret := l.findMedia(absPath)
+	if nil != ret || media.KindAudio != kind {
+		return nil, ret
+	}
+	audio := &media.AudioMedia{Media: &media.Media{}}
+	if ret := audio.FromID(l.db.Col[media.ClassMedia][kind], id); nil != ret {
+		return nil, ret
+	}
+	if "" == audio.Lyrics {
+		return nil, nil
+	}
+	return lyrics.Read(audio.Lyrics)
+}
@@ -0,0 +1,126 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: metadata.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the operations on the metadata sidecar files (Kodi .nfo files)
+//    stored in a library's database: applying the content of each to the
+//    videos it describes.
+//
+// =============================================================================
+
+package library
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/migrate"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant movieNFO is the base name of the .nfo file Kodi reads for a movie in
+// a directory of its own, whatever the video's name.
+const movieNFO = "movie"
+
+// function syncMetadata() applies the content of each metadata file not yet
+// applied (i.e. new, or changed since) to the videos it describes: those of the
+// same base name in its directory, or every video in its directory if named
+// like Kodi's movie.nfo. the fields it defines take precedence over those
+// parsed from the videos' file names, and the changes are recorded in their
+// edit histories like any other. files whose videos aren't known yet are left
+// for a later scan; files that can't be read (e.g. the release notes often
+// sharing the extension) are never tried again unless changed.
+func (l *Library) syncMetadata() *rc.ReturnCode {
+
+	col := l.db.Col[media.ClassSupport][media.SupportMetadata]
+	pending := map[int]*media.Metadata{}
+	col.ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			meta := &media.Metadata{}
+			if nil == meta.FromRecord(data) && meta.Applied.IsZero() {
+				pending[id] = meta
+			}
+			return true // move on to next record, Load() quarantines the corrupt
+		})
+
+	for id, meta := range pending {
+		videos, ret := l.describedVideos(meta)
+		if nil != ret {
+			return ret
+		}
+		if 0 == len(videos) {
+			logs.Info.Tracef("no videos described by metadata (yet): %q", meta.AbsPath)
+			continue
+		}
+		if item, ret := readMetadata(meta); nil != ret {
+			logs.Warn.Tracef("cannot read metadata, ignoring: %q: %s", meta.AbsPath, ret)
+		} else {
+			for _, absPath := range videos {
+				updated, ret := l.updateEntity(absPath, func(ent media.StorableEntity, _ *media.Media) bool {
+					video, ok := ent.(*media.VideoMedia)
+					return ok && item.ApplyVideo(video)
+				})
+				if nil != ret {
+					return ret
+				}
+				if updated {
+					logs.Info.Tracef("applied metadata %q to video: %q", meta.AbsName, absPath)
+				}
+			}
+		}
+		meta.Applied = time.Now()
+		rec, ret := meta.ToRecord()
+		if nil != ret {
+			return ret
+		}
+		if err := col.Update(id, *rec); nil != err {
+			return rc.DatabaseError.Specf(
+				"syncMetadata(): failed to update record (ID={%q,%X}): %s", l.name, id, err)
+		}
+	}
+	return nil
+}
+
+// function describedVideos() returns the absolute paths of the videos in this
+// library's database described by the given metadata file (see syncMetadata()).
+func (l *Library) describedVideos(meta *media.Metadata) ([]string, *rc.ReturnCode) {
+
+	col := l.db.Col[media.ClassMedia][media.KindVideo]
+
+	result, err := l.db.Find(media.ClassMedia, int(media.KindVideo), "AbsDir", meta.AbsDir)
+	if nil != err {
+		return nil, rc.QueryError.Specf("describedVideos(%q): Find(): %s", meta.AbsPath, err)
+	}
+
+	whole := strings.EqualFold(movieNFO, meta.AbsBase)
+	list := []string{}
+	for id := range result {
+		video := &media.VideoMedia{Media: &media.Media{}}
+		if nil != video.FromID(col, id) || nil == video.Entity || meta.AbsDir != video.AbsDir {
+			continue
+		}
+		if whole || meta.AbsBase == video.AbsBase {
+			list = append(list, video.AbsPath)
+		}
+	}
+	return list, nil
+}
+
+// function readMetadata() reads the content of the given metadata file.
+func readMetadata(meta *media.Metadata) (*migrate.Item, *rc.ReturnCode) {
+
+	f, err := os.Open(meta.AbsPath)
+	if nil != err {
+		return nil, rc.InvalidFile.Specf("readMetadata(%q): os.Open(): %s", meta.AbsPath, err)
+	}
+	defer f.Close()
+	return migrate.ReadKodi(f, meta.AbsDir)
+}
@@ -0,0 +1,188 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: moved.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    recognizes media files moved or renamed outside of pimmp, so that their
+//    records follow them rather than being replaced by new ones.
+//
+// =============================================================================
+
+package library
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"time"
+
+	"ardnew.com/pimmp/pkg/contenthash"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/storage"
+)
+
+// type movedMedia is the record of media in the orphaned collection, i.e. of a
+// file found missing, retained by a scan in case the file is found again at
+// another path.
+type movedMedia struct {
+	orphanID      int       // ID of the record in the orphaned collection
+	data          []byte    // original, unmodified record data
+	ext           string    // file name extension
+	size          int64     // length in bytes
+	modTime       time.Time // modification time
+	device, inode uint64    // file IDs, 0 if unknown
+	hash          string    // content hash, empty if not computed
+}
+
+// function newMediaOfKind() allocates an empty media entity of the given kind,
+// returning it along with its embedded Media. returns nil entity if the kind
+// is unknown.
+func newMediaOfKind(kind media.MediaKind) (media.StorableEntity, *media.Media) {
+	med := &media.Media{}
+	switch kind {
+	case media.KindAudio:
+		return &media.AudioMedia{Media: med}, med
+	case media.KindVideo:
+		return &media.VideoMedia{Media: med}, med
+	case media.KindImage:
+		return &media.ImageMedia{Media: med}, med
+	case media.KindDocument:
+		return &media.DocumentMedia{Media: med}, med
+	}
+	return nil, nil
+}
+
+// function readMoved() collects the media records of the orphaned collection
+// which may be recognized by relocateMedia(). the tracks of cue sheets aren't
+// files of their own, so they are never recognized.
+func (l *Library) readMoved() {
+
+	l.moved = map[media.MediaKind][]*movedMedia{}
+	l.db.OrphanCol.ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			or := &storage.OrphanRecord{}
+			if err := json.Unmarshal(data, or); nil != err {
+				logs.Warn.Verbosef("cannot read orphan record (ID={%q,%X}): %s", l.name, id, err)
+				return true // move on to next record
+			}
+			if media.ClassMedia != or.Class {
+				return true
+			}
+			kind := media.MediaKind(or.Kind)
+			ent, med := newMediaOfKind(kind)
+			if nil == ent || nil != ent.FromRecord([]byte(or.Data)) || med.IsTrack() {
+				return true
+			}
+			l.moved[kind] = append(l.moved[kind], &movedMedia{
+				orphanID: id,
+				data:     []byte(or.Data),
+				ext:      med.Ext,
+				size:     med.Size,
+				modTime:  med.TimeModified,
+				device:   med.Device,
+				inode:    med.Inode,
+				hash:     med.Hash,
+			})
+			return true
+		})
+}
+
+// function relocateMedia() checks if the unknown media file of the given kind
+// at the given path, with the given info, is a file that went missing from
+// another path, i.e. that it was moved or renamed. if so, its orphaned record
+// is restored with the new path, retaining its playback history, tags, and
+// all else, and it is published as though it were loaded.
+// returns true if the file was recognized.
+//
+// a file is recognized by its device and inode IDs, along with its size and
+// modification time, which are unchanged when it is renamed within a file
+// system. otherwise, e.g. when moved to another file system, it is recognized
+// by its content hash (see package contenthash), if the record has one. the
+// records of missing files which were pruned (see SetPrune()) are gone, so
+// these files are always found as new.
+func (l *Library) relocateMedia(pub *publisher, kind media.MediaKind, absPath, relPath, linkTarget string, info os.FileInfo) (bool, *rc.ReturnCode) {
+
+	if nil == l.moved {
+		l.readMoved()
+	}
+	cand := l.moved[kind]
+	ext := path.Ext(absPath)
+
+	match := -1
+	if dev, ino, ok := platform.FileID(info); ok {
+		for i, m := range cand {
+			if ext == m.ext && 0 != m.inode && ino == m.inode && dev == m.device &&
+				info.Size() == m.size && info.ModTime().Equal(m.modTime) {
+				match = i
+				break
+			}
+		}
+	}
+	// only hash the file if some record could match it.
+	hash := ""
+	if match < 0 && l.hashPartial >= 0 {
+		for i, m := range cand {
+			if ext != m.ext || info.Size() != m.size || "" == m.hash {
+				continue
+			}
+			if "" == hash {
+				sum, ret := contenthash.Sum(absPath, l.hashPartial)
+				if nil != ret {
+					logs.Warn.Verbose(ret)
+					return false, nil
+				}
+				hash = sum
+			}
+			if hash == m.hash {
+				match = i
+				break
+			}
+		}
+	}
+	if match < 0 {
+		return false, nil
+	}
+
+	m := cand[match]
+	ent, med := newMediaOfKind(kind)
+	if ret := ent.FromRecord(m.data); nil != ret {
+		return false, ret
+	}
+	oldPath := med.AbsPath
+	med.Relocate(absPath, relPath)
+	med.LinkTarget = linkTarget
+	med.Refresh(info)
+	if "" != hash {
+		med.Hash = hash
+	} else if "" == med.Hash {
+		l.hashMedia(med)
+	}
+	if video, ok := ent.(*media.VideoMedia); ok {
+		video.ParseName()
+	}
+
+	rec, ret := ent.ToRecord()
+	if nil != ret {
+		return false, ret
+	}
+	id, err := l.db.Col[media.ClassMedia][kind].Insert(*rec)
+	if nil != err {
+		return false, rc.DatabaseError.Specf(
+			"relocateMedia(%q): failed to insert record: %s", relPath, err)
+	}
+	if err := l.db.OrphanCol.Delete(m.orphanID); nil != err {
+		logs.Warn.Verbosef("cannot delete orphan record (ID={%q,%X}): %s", l.name, m.orphanID, err)
+	}
+	l.moved[kind] = append(cand[:match], cand[match+1:]...)
+	l.db.NumRecordsUpdate[media.ClassMedia][kind]++
+	logs.Info.Verbosef("restored record of moved file (ID={%q,%X}): %q => %q",
+		l.name, id, oldPath, absPath)
+
+	l.handleMedia(pub, absPath, ent, med, id, false)
+	return true, nil
+}
@@ -0,0 +1,169 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: parts.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    groups the files of a release split into several parts, e.g. "CD1" and
+//    "CD2", into a single media, listed and played as one.
+//
+// =============================================================================
+
+package library
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/naming"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type mediaPart is a media of this library's database, with the part number
+// parsed from its file name (see naming.ParsePart()).
+type mediaPart struct {
+	id   int
+	ent  media.StorableEntity
+	med  *media.Media
+	base string // file name without the part number and extension
+	num  int    // part number, 0 if not a part
+}
+
+// function syncParts() brings the parts of the releases in this library's
+// database up to date with the media known: the audio and video files in the
+// same directory, with the same name and extension but for their part numbers
+// 1, 2, ... (see naming.ParsePart()), are grouped into the media of the first
+// part, which records the paths of every part in order (see Files() of Media),
+// while each other part records the path of the first and is no longer
+// published. parts no longer in a complete group are separated again. every
+// part newly grouped (as removed) or separated (as media) is published.
+func (l *Library) syncParts(pub *publisher) *rc.ReturnCode {
+
+	for _, kind := range []media.MediaKind{media.KindAudio, media.KindVideo} {
+
+		// collect every part, by release, and every media grouped before.
+		col := l.db.Col[media.ClassMedia][kind]
+		group := map[string][]*mediaPart{}
+		grouped := []*mediaPart{}
+		col.ForEachDoc(
+			func(id int, data []byte) (willMoveOn bool) {
+				p := &mediaPart{id: id, med: &media.Media{}}
+				if media.KindAudio == kind {
+					p.ent = &media.AudioMedia{Media: p.med}
+				} else {
+					p.ent = &media.VideoMedia{Media: p.med}
+				}
+				if nil != p.ent.FromRecord(data) || nil == p.med.Entity || p.med.IsTrack() || p.med.IsDisc() {
+					return true // move on to next record, Load() quarantines it
+				}
+				if p.base, p.num = naming.ParsePart(p.med.AbsBase); p.num > 0 {
+					key := strings.ToLower(filepath.Join(p.med.AbsDir, p.base) + p.med.Ext)
+					group[key] = append(group[key], p)
+				} else if len(p.med.Parts) > 0 || p.med.IsPart() {
+					grouped = append(grouped, p)
+				}
+				return true // move on to next record
+			})
+
+		// the parts of each release must be numbered 1, 2, ... without gaps,
+		// or else they're unrelated files that happen to be named alike.
+		want := map[*mediaPart][]string{}
+		for _, g := range group {
+			sort.Slice(g, func(a, b int) bool { return g[a].num < g[b].num })
+			path := []string{}
+			for i, p := range g {
+				if i+1 != p.num {
+					path = nil
+					break
+				}
+				path = append(path, p.med.AbsPath)
+			}
+			if len(path) < 2 {
+				path = nil
+			}
+			for _, p := range g {
+				want[p] = path
+			}
+			grouped = append(grouped, g...)
+		}
+
+		for _, p := range grouped {
+			if ret := l.groupPart(pub, col, kind, p, want[p]); nil != ret {
+				return ret
+			}
+		}
+	}
+	return nil
+}
+
+// function groupPart() updates the record of the given media, in the given
+// collection of media of the given kind, as a part of the release with the
+// given parts (nil if it isn't one), publishing it if it is newly grouped or
+// separated.
+func (l *Library) groupPart(pub *publisher, col engine.Collection, kind media.MediaKind, p *mediaPart, path []string) *rc.ReturnCode {
+
+	med := p.med
+	wasPart := med.IsPart()
+	parts, partOf := []string(nil), ""
+	if len(path) > 0 {
+		if path[0] == med.AbsPath {
+			parts = path
+		} else {
+			partOf = path[0]
+		}
+	}
+	if partOf == med.PartOf && equalPaths(parts, med.Parts) {
+		return nil
+	}
+
+	// the first part is named for the release, unless named by the user.
+	whole := p.base + med.Ext
+	if nil != parts && med.AbsName == med.Name && med.AbsName == med.Title {
+		med.Name, med.Title = whole, whole
+	} else if nil == parts && len(med.Parts) > 0 && whole == med.Name && whole == med.Title {
+		med.Name, med.Title = med.AbsName, med.AbsName
+	}
+	med.Parts, med.PartOf = parts, partOf
+
+	rec, ret := p.ent.ToRecord()
+	if nil != ret {
+		return ret
+	}
+	if err := col.Update(p.id, *rec); nil != err {
+		return rc.DatabaseError.Specf(
+			"syncParts(): failed to update record (ID={%q,%X}): %s", l.name, p.id, err)
+	}
+	switch {
+	case nil != parts:
+		logs.Info.Tracef("grouped %d parts of media (ID={%q,%X}): %q", len(parts), l.name, p.id, med.AbsPath)
+	case "" != partOf:
+		logs.Info.Tracef("grouped part of media (ID={%q,%X}): %q", l.name, p.id, med.AbsPath)
+	default:
+		logs.Info.Tracef("separated part of media (ID={%q,%X}): %q", l.name, p.id, med.AbsPath)
+	}
+
+	if !wasPart && med.IsPart() {
+		l.handleRemove(pub, med.AbsPath, media.ClassMedia, int(kind), nil)
+	} else if wasPart && !med.IsPart() {
+		l.handleMedia(pub, med.AbsPath, p.ent, med, p.id, false)
+	}
+	return nil
+}
+
+// function equalPaths() returns true if the given lists of paths are equal.
+func equalPaths(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,483 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: playlist.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the operations on the playlists stored in a library's database:
+//    creating, reading, updating, and deleting them, and importing them from
+//    the playlist files found during scans or given on the command line.
+//
+// =============================================================================
+
+package library
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/playlist"
+	"ardnew.com/pimmp/pkg/query"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// function Playlists() returns all of the playlists in this library's
+// database, sorted by name.
+func (l *Library) Playlists() ([]*media.Playlist, *rc.ReturnCode) {
+
+	list := []*media.Playlist{}
+	var ret *rc.ReturnCode
+	for kind := media.PlaylistKind(0); kind < media.PlaylistCOUNT; kind++ {
+		l.db.Col[media.ClassPlaylist][kind].ForEachDoc(
+			func(id int, data []byte) (willMoveOn bool) {
+				p := &media.Playlist{}
+				if ret = p.FromRecord(data); nil != ret {
+					return false // stop iterating
+				}
+				list = append(list, p)
+				return true // move on to next record
+			})
+		if nil != ret {
+			return nil, ret
+		}
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Name < list[b].Name })
+	return list, nil
+}
+
+// function FindPlaylist() returns the playlist with the given name (ignoring
+// case) in this library's database, or nil if there is none.
+func (l *Library) FindPlaylist(name string) (*media.Playlist, *rc.ReturnCode) {
+
+	kind, id, ret := l.findPlaylist(name)
+	if nil != ret || media.PlaylistUnknown == kind {
+		return nil, ret
+	}
+	p := &media.Playlist{}
+	if ret := p.FromID(l.db.Col[media.ClassPlaylist][kind], id); nil != ret {
+		return nil, ret
+	}
+	return p, nil
+}
+
+// function CreatePlaylist() adds a new, empty playlist with the given name to
+// this library's database. the name must be unique among the library's
+// playlists, ignoring case.
+func (l *Library) CreatePlaylist(name string) (*media.Playlist, *rc.ReturnCode) {
+
+	name = strings.TrimSpace(name)
+	if "" == name {
+		return nil, rc.InvalidArgs.Spec("CreatePlaylist(): playlist name must not be empty")
+	}
+	p := media.NewPlaylist(name)
+	if ret := l.insertPlaylist(p); nil != ret {
+		return nil, ret
+	}
+	return p, nil
+}
+
+// function CreateSmartPlaylist() adds a new smart playlist with the given name
+// to this library's database, selecting the media matched by the given rule
+// (see package query). the name must be unique as with CreatePlaylist().
+func (l *Library) CreateSmartPlaylist(name, rule string) (*media.Playlist, *rc.ReturnCode) {
+
+	name = strings.TrimSpace(name)
+	if "" == name {
+		return nil, rc.InvalidArgs.Spec("CreateSmartPlaylist(): playlist name must not be empty")
+	}
+	q, ret := query.Parse(rule)
+	if nil != ret {
+		return nil, ret
+	}
+	p := media.NewSmartPlaylist(name, q.String())
+	if ret := l.insertPlaylist(p); nil != ret {
+		return nil, ret
+	}
+	return p, nil
+}
+
+// function UpdatePlaylist() finds the playlist with the given name in this
+// library's database and passes it to the given update function. if update
+// returns true, the modified playlist is written back to the database.
+// returns true if the playlist was found and its record updated.
+func (l *Library) UpdatePlaylist(name string, update func(p *media.Playlist) bool) (bool, *rc.ReturnCode) {
+
+	kind, id, ret := l.findPlaylist(name)
+	if nil != ret || media.PlaylistUnknown == kind {
+		return false, ret
+	}
+	col := l.db.Col[media.ClassPlaylist][kind]
+
+	p := &media.Playlist{}
+	if ret := p.FromID(col, id); nil != ret {
+		return false, ret
+	}
+	if !update(p) {
+		return false, nil
+	}
+	rec, ret := p.ToRecord()
+	if nil != ret {
+		return false, ret
+	}
+	if err := col.Update(id, *rec); nil != err {
+		return false, rc.DatabaseError.Specf(
+			"UpdatePlaylist(%q): failed to update record (ID={%q,%X}): %s", name, l.name, id, err)
+	}
+	logs.Info.Tracef("updated playlist (ID={%q,%X}): %s", l.name, id, p)
+	return true, nil
+}
+
+// function DeletePlaylist() deletes the playlist with the given name from this
+// library's database. the media it refers to, and the playlist file from
+// which it may have been imported, are left untouched. returns true if the
+// playlist was found and its record deleted.
+func (l *Library) DeletePlaylist(name string) (bool, *rc.ReturnCode) {
+
+	kind, id, ret := l.findPlaylist(name)
+	if nil != ret || media.PlaylistUnknown == kind {
+		return false, ret
+	}
+	if err := l.db.Col[media.ClassPlaylist][kind].Delete(id); nil != err {
+		return false, rc.DatabaseError.Specf(
+			"DeletePlaylist(%q): failed to delete record (ID={%q,%X}): %s", name, l.name, id, err)
+	}
+	logs.Info.Tracef("deleted playlist (ID={%q,%X}): %q", l.name, id, name)
+	return true, nil
+}
+
+// function AddToPlaylist() appends the media at the given absolute path in this
+// library's database to the playlist with the given name, creating the
+// playlist if it doesn't exist. media cannot be added to a smart playlist.
+func (l *Library) AddToPlaylist(name, absPath string) *rc.ReturnCode {
+
+	if p, ret := l.FindPlaylist(name); nil != ret {
+		return ret
+	} else if nil != p && p.IsSmart() {
+		return rc.InvalidArgs.Specf("AddToPlaylist(%q): cannot add media to a smart playlist", name)
+	}
+
+	kind, id, ret := l.findMedia(absPath)
+	if nil != ret {
+		return ret
+	}
+	if media.KindUnknown == kind {
+		return rc.InvalidPath.Specf("AddToPlaylist(%q): no such media in library %q: %q",
+			name, l.name, absPath)
+	}
+	add := func(p *media.Playlist) bool {
+		p.Add(kind, id, absPath)
+		return true
+	}
+	if found, ret := l.UpdatePlaylist(name, add); nil != ret || found {
+		return ret
+	}
+	p, ret := l.CreatePlaylist(name)
+	if nil != ret {
+		return ret
+	}
+	_, ret = l.UpdatePlaylist(p.Name, add)
+	return ret
+}
+
+// function PlaylistMedia() returns the media referred to by the items of the
+// given playlist, in order. an item whose record no longer refers to its file
+// (e.g. it was rebuilt by a repair) is found again by its path. items whose
+// media isn't in this library's database are skipped. the media of a smart
+// playlist are those currently matching its rule, sorted by path.
+func (l *Library) PlaylistMedia(p *media.Playlist) []*media.Media {
+
+	if p.IsSmart() {
+		return l.matchMedia(p)
+	}
+
+	list := []*media.Media{}
+	for _, item := range p.Items {
+		if m := l.readMedia(item.Kind, item.RecordID); nil != m && item.AbsPath == m.AbsPath {
+			list = append(list, m)
+			continue
+		}
+		kind, id, ret := l.findMedia(item.AbsPath)
+		if nil != ret {
+			logs.Warn.Verbose(ret)
+			continue
+		}
+		if m := l.readMedia(kind, id); nil != m {
+			list = append(list, m)
+		} else {
+			logs.Info.Verbosef("playlist %q: media not found: %q", p.Name, item.AbsPath)
+		}
+	}
+	return list
+}
+
+// function ImportPlaylist() adds a new playlist to this library's database
+// with the media listed by the playlist file at the given path, which need not
+// be in the library. the playlist is named after the file, unless name is
+// non-empty. files listed that aren't media in this library are skipped. the
+// playlist is a copy, independent of the file once imported.
+func (l *Library) ImportPlaylist(absPath, name string) (*media.Playlist, *rc.ReturnCode) {
+
+	info, err := os.Stat(absPath)
+	if nil != err {
+		return nil, rc.InvalidStat.Specf("ImportPlaylist(%q): os.Stat(): %s", absPath, err)
+	}
+	ext := path.Ext(absPath)
+	kind, extName := media.PlaylistKindOfFileExt(ext)
+	if media.PlaylistUnknown == kind {
+		return nil, rc.InvalidFile.Specf("ImportPlaylist(%q): not a playlist file", absPath)
+	}
+	p := media.NewPlaylistFile(absPath, absPath, ext, extName, info)
+	if name = strings.TrimSpace(name); "" != name {
+		p.Name = name
+	}
+	if ret := l.readPlaylistFile(p); nil != ret {
+		return nil, ret
+	}
+	p.Entity = nil
+	if ret := l.insertPlaylist(p); nil != ret {
+		return nil, ret
+	}
+	return p, nil
+}
+
+// function scanPlaylistFile() imports the playlist file at the given path,
+// found by a scan, unless it is already known and unchanged. if it has changed
+// since it was imported, its items are read again.
+func (l *Library) scanPlaylistFile(absPath, relPath, ext, extName, linkTarget string, info os.FileInfo) *rc.ReturnCode {
+
+	id, seen, err := l.seenFile(media.ClassPlaylist, int(media.PlaylistStatic), absPath)
+	if nil != err {
+		return rc.InvalidFile.Specf(
+			"scanPlaylistFile(%q): failed to evaluate query: %s (skipping)", relPath, err)
+	}
+	col := l.db.Col[media.ClassPlaylist][media.PlaylistStatic]
+
+	if !seen {
+		p := media.NewPlaylistFile(absPath, relPath, ext, extName, info)
+		p.LinkTarget = linkTarget
+		// playlists of the same name may exist in different directories.
+		if _, other, ret := l.findPlaylist(p.Name); nil != ret {
+			return ret
+		} else if other >= 0 {
+			p.Name = relPath
+		}
+		if ret := l.readPlaylistFile(p); nil != ret {
+			return ret
+		}
+		rec, ret := p.ToRecord()
+		if nil != ret {
+			return ret
+		}
+		id, insErr := col.Insert(*rec)
+		if nil != insErr {
+			return rc.DatabaseError.Specf(
+				"scanPlaylistFile(%q): failed to insert record: %s (skipping)", relPath, insErr)
+		}
+		l.db.NumRecordsScan[media.ClassPlaylist][media.PlaylistStatic]++
+		logs.Info.Tracef("discovered playlist (ID={%q,%X}): %s (%d items)", l.name, id, p, len(p.Items))
+		return nil
+	}
+
+	// a file we've seen before, but it may have changed since.
+	p := &media.Playlist{}
+	if ret := p.FromID(col, id); nil != ret {
+		return ret
+	}
+	if !p.IsImported() || !p.Changed(info) {
+		return nil
+	}
+	p.Refresh(info)
+	if ret := l.readPlaylistFile(p); nil != ret {
+		return ret
+	}
+	rec, ret := p.ToRecord()
+	if nil != ret {
+		return ret
+	}
+	if err := col.Update(id, *rec); nil != err {
+		return rc.DatabaseError.Specf(
+			"scanPlaylistFile(%q): failed to update record (ID={%q,%X}): %s", relPath, l.name, id, err)
+	}
+	l.db.NumRecordsUpdate[media.ClassPlaylist][media.PlaylistStatic]++
+	logs.Info.Tracef("updated playlist (ID={%q,%X}): %s (%d items)", l.name, id, p, len(p.Items))
+	return nil
+}
+
+// function readPlaylistFile() replaces the items of the given playlist, which
+// must have been imported, with the files listed in its playlist file. files
+// not yet discovered are kept by path alone (see PlaylistMedia()), unless
+// they are outside of the library.
+func (l *Library) readPlaylistFile(p *media.Playlist) *rc.ReturnCode {
+
+	entry, ret := playlist.ReadFile(p.AbsPath)
+	if nil != ret {
+		return ret
+	}
+	p.Items = []media.PlaylistItem{}
+	for _, e := range entry {
+		kind, id, ret := l.findMedia(e.Path)
+		if nil != ret {
+			return ret
+		}
+		if media.KindUnknown == kind {
+			rel, err := filepath.Rel(l.absPath, e.Path)
+			if nil != err || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || ".." == rel {
+				logs.Info.Tracef("playlist %q: not in library %q (skipping): %q", p.Name, l.name, e.Path)
+				continue
+			}
+			kind, _ = media.MediaKindOfFileExt(path.Ext(e.Path))
+			if media.KindUnknown == kind {
+				logs.Info.Tracef("playlist %q: not a media file (skipping): %q", p.Name, e.Path)
+				continue
+			}
+		}
+		p.Add(kind, id, e.Path)
+	}
+	return nil
+}
+
+// function insertPlaylist() inserts a record of the given playlist into this
+// library's database, unless its name is already in use.
+func (l *Library) insertPlaylist(p *media.Playlist) *rc.ReturnCode {
+
+	if _, id, ret := l.findPlaylist(p.Name); nil != ret {
+		return ret
+	} else if id >= 0 {
+		return rc.InvalidArgs.Specf("playlist already exists in library %q: %q", l.name, p.Name)
+	}
+	rec, ret := p.ToRecord()
+	if nil != ret {
+		return ret
+	}
+	id, err := l.db.Col[media.ClassPlaylist][p.Kind].Insert(*rec)
+	if nil != err {
+		return rc.DatabaseError.Specf(
+			"insertPlaylist(%q): failed to insert record: %s", p.Name, err)
+	}
+	logs.Info.Tracef("created playlist (ID={%q,%X}): %s", l.name, id, p)
+	return nil
+}
+
+// function findPlaylist() returns the kind and record ID of the playlist with
+// the given name (ignoring case) in this library's database. the kind returned
+// is PlaylistUnknown, and the ID -1, if no such playlist exists.
+func (l *Library) findPlaylist(name string) (media.PlaylistKind, int, *rc.ReturnCode) {
+
+	// names are compared ignoring case, which an index can't do, so every
+	// playlist is compared in turn. libraries have few playlists.
+	for kind := media.PlaylistKind(0); kind < media.PlaylistCOUNT; kind++ {
+		found, foundID := false, -1
+		l.db.Col[media.ClassPlaylist][kind].ForEachDoc(
+			func(id int, data []byte) (willMoveOn bool) {
+				p := &media.Playlist{}
+				if nil == p.FromRecord(data) && strings.EqualFold(name, p.Name) {
+					found, foundID = true, id
+					return false // stop iterating
+				}
+				return true // move on to next record
+			})
+		if found {
+			return kind, foundID, nil
+		}
+	}
+	return media.PlaylistUnknown, -1, nil
+}
+
+// function matchMedia() returns the media in this library's database matching
+// the rule of the given smart playlist, sorted by path. the rule is evaluated
+// anew on each call, so the playlist always reflects the current media.
+func (l *Library) matchMedia(p *media.Playlist) []*media.Media {
+
+	q, ret := query.Parse(p.Rule)
+	if nil != ret {
+		logs.Warn.Logf("smart playlist %q: %s", p.Name, ret)
+		return []*media.Media{}
+	}
+	return l.Query(q)
+}
+
+// function Query() returns the media in this library's database matching the
+// given Query, sorted by path. if the Query compares an indexed field (see
+// Index() of Query), only the records found by the index are read. media never
+// published by loads (see handleMedia()) are never returned.
+func (l *Library) Query(q *query.Query) []*media.Media {
+
+	list := []*media.Media{}
+	if field, values, ok := q.Index(); ok {
+		bus := NewBus()
+		bus.Subscribe(func(e Event) {
+			if m := e.Discovery.Media(); nil != m && q.Match(m) {
+				list = append(list, m)
+			}
+		}, MediaDiscovered)
+		_, ret := l.LoadBy(bus, field, values...)
+		if nil == ret {
+			sort.Slice(list, func(a, b int) bool { return list[a].AbsPath < list[b].AbsPath })
+			return list
+		}
+		logs.Warn.Log(ret)
+		list = list[:0]
+	}
+	for kind := media.MediaKind(0); kind < media.KindCOUNT; kind++ {
+		l.db.Col[media.ClassMedia][kind].ForEachDoc(
+			func(id int, data []byte) (willMoveOn bool) {
+				ent, med := newMediaOfKind(kind)
+				if nil != ent && nil == ent.FromRecord(data) && nil != med.Entity && !med.IsPart() &&
+					(nil == l.hidden || !l.hidden(med)) && q.Match(med) {
+					list = append(list, med)
+				}
+				return true // move on to next record
+			})
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].AbsPath < list[b].AbsPath })
+	return list
+}
+
+// function mediaOf() returns the Media embedded in the given media entity, or
+// nil if it isn't one.
+func mediaOf(ent interface{}) *media.Media {
+	switch item := ent.(type) {
+	case *media.AudioMedia:
+		return item.Media
+	case *media.VideoMedia:
+		return item.Media
+	case *media.ImageMedia:
+		return item.Media
+	case *media.DocumentMedia:
+		return item.Media
+	}
+	return nil
+}
+
+// function readMedia() returns the media of the given kind with the given
+// record ID in this library's database, or nil if there is none.
+func (l *Library) readMedia(kind media.MediaKind, id int) *media.Media {
+
+	if kind < 0 || kind >= media.KindCOUNT || id < 0 {
+		return nil
+	}
+	med := &media.Media{}
+	var ent media.StorableEntity
+	switch kind {
+	case media.KindAudio:
+		ent = &media.AudioMedia{Media: med}
+	case media.KindVideo:
+		ent = &media.VideoMedia{Media: med}
+	case media.KindImage:
+		ent = &media.ImageMedia{Media: med}
+	case media.KindDocument:
+		ent = &media.DocumentMedia{Media: med}
+	}
+	if nil != ent.FromID(l.db.Col[media.ClassMedia][kind], id) || nil == med.Entity {
+		return nil
+	}
+	return med
+}
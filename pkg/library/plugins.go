@@ -0,0 +1,61 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: plugins.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    forwards the changes to the libraries published on an event bus to the
+//    plugins and shell hooks of each library, as their events.
+//
+// =============================================================================
+
+package library
+
+import (
+	"encoding/json"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/plugin"
+)
+
+// function SubscribePlugins() subscribes the plugins and shell hooks of each
+// library (see SetPlugins()) to the changes published on the given Bus: the new
+// media and support files inserted (plugin.EventNewMedia, EventNewSupport), the
+// records of media deleted (EventMediaRemoved), and the scans finished
+// (EventScanComplete). the changes of a read-only library (see ReadOnly()) are
+// discarded, so they are never forwarded. returns the function unsubscribing
+// them.
+func SubscribePlugins(b *Bus) func() {
+
+	return b.Subscribe(func(e Event) {
+		l := e.Library
+		if nil == l || nil == l.plugins || l.ReadOnly() {
+			return
+		}
+		switch e.Kind {
+		case MediaDiscovered:
+			if e.New {
+				l.plugins.Notify(plugin.EventNewMedia, e.Discovery.Entity)
+			}
+		case SupportDiscovered:
+			if e.New {
+				l.plugins.Notify(plugin.EventNewSupport, e.Discovery.Entity)
+			}
+		case FileRemoved:
+			if nil != e.Record && nil != e.Discovery && media.ClassMedia == e.Discovery.Class {
+				l.plugins.Notify(plugin.EventMediaRemoved, json.RawMessage(e.Record))
+			}
+		case ScanFinished:
+			l.plugins.Notify(plugin.EventScanComplete, map[string]interface{}{
+				"Library": l.name,
+				"AbsPath": l.absPath,
+				"Found":   e.Found,
+				"Updated": e.Updated,
+				"Ignored": e.Ignored,
+				"Elapsed": e.Elapsed.Seconds(),
+			})
+		}
+	}, MediaDiscovered, SupportDiscovered, FileRemoved, ScanFinished)
+}
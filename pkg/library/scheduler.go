@@ -0,0 +1,152 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: scheduler.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the scheduler shared by all libraries, limiting the number of
+//    them loaded and scanned at once, and the number of ffprobe processes run
+//    at once, so that the load on each disk can be tuned to what it sustains.
+//
+// =============================================================================
+
+package library
+
+import (
+	"context"
+	"sync"
+
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Limits defines the number of operations the Scheduler permits at once.
+// a limit of 0 permits any number.
+type Limits struct {
+	Loads     int // libraries loaded at once
+	Scans     int // libraries scanned at once
+	DiskScans int // libraries residing on the same device scanned at once
+	Probes    int // ffprobe processes run at once, by all scans
+}
+
+// type Scheduler makes the loads and scans of the libraries sharing it wait
+// their turn, as permitted by its Limits. each library is only ever loaded
+// and scanned by one goroutine at a time regardless. all of its methods are
+// safe to call on a nil Scheduler, which permits everything at once.
+type Scheduler struct {
+	lock   sync.Mutex
+	limit  Limits
+	loads  chan struct{} // counting semaphores of each Limits (nil if unlimited)
+	scans  chan struct{}
+	probes chan struct{}
+	disks  map[uint64]chan struct{} // counting semaphore of each device
+}
+
+// function NewScheduler() creates a new Scheduler with the given limits,
+// returning rc.InvalidArgs if any is negative.
+func NewScheduler(limit Limits) (*Scheduler, *rc.ReturnCode) {
+
+	if limit.Loads < 0 || limit.Scans < 0 || limit.DiskScans < 0 || limit.Probes < 0 {
+		return nil, rc.InvalidArgs.Specf("NewScheduler(): negative limit: %+v", limit)
+	}
+	semaphore := func(n int) chan struct{} {
+		if 0 == n {
+			return nil
+		}
+		return make(chan struct{}, n)
+	}
+	return &Scheduler{
+		limit:  limit,
+		loads:  semaphore(limit.Loads),
+		scans:  semaphore(limit.Scans),
+		probes: semaphore(limit.Probes),
+		disks:  map[uint64]chan struct{}{},
+	}, nil
+}
+
+// function load() waits until the given library may be loaded, returning the
+// function which must be called once it is. returns rc.Canceled if the given
+// Context is done first.
+func (s *Scheduler) load(ctx context.Context, l *Library) (func(), *rc.ReturnCode) {
+	if nil == s {
+		return func() {}, nil
+	}
+	return acquire(ctx, l, "load", s.loads)
+}
+
+// function scan() waits until the given library may be scanned, i.e. until
+// fewer than the limits of scans are in progress, overall and of the libraries
+// on its device, returning the function which must be called once it is.
+// returns rc.Canceled if the given Context is done first.
+func (s *Scheduler) scan(ctx context.Context, l *Library) (func(), *rc.ReturnCode) {
+	if nil == s {
+		return func() {}, nil
+	}
+	// the device is acquired first, so that a library waiting for its disk
+	// doesn't hold up those on other disks.
+	releaseDisk, ret := acquire(ctx, l, "scan", s.disk(l))
+	if nil != ret {
+		return nil, ret
+	}
+	release, ret := acquire(ctx, l, "scan", s.scans)
+	if nil != ret {
+		releaseDisk()
+		return nil, ret
+	}
+	return func() { release(); releaseDisk() }, nil
+}
+
+// function probe() waits until another ffprobe process may be run, returning
+// the function which must be called once it has exited.
+func (s *Scheduler) probe() func() {
+	if nil == s || nil == s.probes {
+		return func() {}
+	}
+	s.probes <- struct{}{}
+	return func() { <-s.probes }
+}
+
+// function disk() returns the counting semaphore of the device containing the
+// given library, or nil if unlimited or its device is unknown.
+func (s *Scheduler) disk(l *Library) chan struct{} {
+
+	if 0 == s.limit.DiskScans {
+		return nil
+	}
+	dev, ok := platform.DeviceID(l.absPath)
+	if !ok {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	sem, ok := s.disks[dev]
+	if !ok {
+		sem = make(chan struct{}, s.limit.DiskScans)
+		s.disks[dev] = sem
+	}
+	return sem
+}
+
+// function acquire() waits until the given counting semaphore has room, which
+// a nil semaphore always has, returning the function releasing it. returns
+// rc.Canceled if the given Context is done first.
+func acquire(ctx context.Context, l *Library, op string, sem chan struct{}) (func(), *rc.ReturnCode) {
+
+	if nil == sem {
+		return func() {}, nil
+	}
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	default:
+	}
+	logs.Info.Verbosef("waiting to %s: %q", op, l.name)
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, rc.Canceled.Specf("%s(%q): %s", op, l.name, ctx.Err())
+	}
+}
@@ -0,0 +1,219 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: series.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the operations on the TV series and seasons stored in a library's
+//    database: keeping them in sync with the episodes found by scans, and
+//    browsing the episodes of each as a hierarchy.
+//
+// =============================================================================
+
+package library
+
+import (
+	"encoding/json"
+	"sort"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// function Series() returns all of the TV series in this library's database,
+// sorted by name and year.
+func (l *Library) Series() ([]*media.Series, *rc.ReturnCode) {
+
+	list := []*media.Series{}
+	var ret *rc.ReturnCode
+	l.db.Col[media.ClassSeries][media.SeriesShow].ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			s := &media.Series{}
+			if ret = s.FromRecord(data); nil != ret {
+				return false // stop iterating
+			}
+			list = append(list, s)
+			return true // move on to next record
+		})
+	if nil != ret {
+		return nil, ret
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].Key == list[b].Key {
+			return list[a].Year < list[b].Year
+		}
+		return list[a].Key < list[b].Key
+	})
+	return list, nil
+}
+
+// function Seasons() returns the seasons of the TV series with the given key in
+// this library's database, sorted by number.
+func (l *Library) Seasons(seriesKey string) ([]*media.Season, *rc.ReturnCode) {
+
+	list := []*media.Season{}
+	var ret *rc.ReturnCode
+	l.db.Col[media.ClassSeries][media.SeriesSeason].ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			s := &media.Season{}
+			if ret = s.FromRecord(data); nil != ret {
+				return false // stop iterating
+			}
+			if seriesKey == s.SeriesKey {
+				list = append(list, s)
+			}
+			return true // move on to next record
+		})
+	if nil != ret {
+		return nil, ret
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Number < list[b].Number })
+	return list, nil
+}
+
+// function Episodes() returns the episodes of the season with the given key in
+// this library's database, sorted by episode number.
+func (l *Library) Episodes(seasonKey string) ([]*media.VideoMedia, *rc.ReturnCode) {
+
+	col := l.db.Col[media.ClassMedia][media.KindVideo]
+
+	result, err := l.db.Find(media.ClassMedia, int(media.KindVideo), "SeasonKey", seasonKey)
+	if nil != err {
+		return nil, rc.QueryError.Specf("Episodes(%q): Find(): %s", seasonKey, err)
+	}
+
+	list := []*media.VideoMedia{}
+	for id := range result {
+		video := &media.VideoMedia{Media: &media.Media{}}
+		if nil != video.FromID(col, id) || nil == video.Entity || seasonKey != video.SeasonKey {
+			continue
+		}
+		if nil == l.hidden || !l.hidden(video.Media) {
+			list = append(list, video)
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].Episode == list[b].Episode {
+			return list[a].AbsPath < list[b].AbsPath
+		}
+		return list[a].Episode < list[b].Episode
+	})
+	return list, nil
+}
+
+// function syncSeries() brings the series and seasons in this library's
+// database up to date with its video media: a record is added for the series
+// and season of each episode not yet known, and the records of any series or
+// season no longer having episodes are deleted. video records which predate
+// the series (and thus lack the keys of their season) are updated as well, so
+// that Episodes() finds them.
+func (l *Library) syncSeries() *rc.ReturnCode {
+
+	type season struct {
+		series *media.Series
+		number int64
+	}
+	series := map[string]*media.Series{}
+	seasons := map[string]season{}
+	stale := map[int]*media.VideoMedia{}
+
+	videoCol := l.db.Col[media.ClassMedia][media.KindVideo]
+	videoCol.ForEachDoc(
+		func(id int, data []byte) (willMoveOn bool) {
+			video := &media.VideoMedia{}
+			if nil != video.FromRecord(data) || !video.IsEpisode() {
+				return true // move on to next record, Load() quarantines it
+			}
+			stored := struct{ SeasonKey string }{}
+			if nil == json.Unmarshal(data, &stored) && stored.SeasonKey != video.SeasonKey {
+				stale[id] = video
+			}
+			if _, ok := series[video.SeriesKey]; !ok {
+				series[video.SeriesKey] = media.NewSeries(video.Series, video.Year)
+			}
+			seasons[video.SeasonKey] = season{series[video.SeriesKey], video.Season}
+			return true // move on to next record
+		})
+
+	for id, video := range stale {
+		rec, ret := video.ToRecord()
+		if nil != ret {
+			return ret
+		}
+		if err := videoCol.Update(id, *rec); nil != err {
+			return rc.DatabaseError.Specf(
+				"syncSeries(): failed to update record (ID={%q,%X}): %s", l.name, id, err)
+		}
+	}
+
+	// collect the keys of the existing records, deleting those left without
+	// episodes.
+	known := [media.SeriesCOUNT]map[string]bool{{}, {}}
+	for kind := media.SeriesKind(0); kind < media.SeriesCOUNT; kind++ {
+		col := l.db.Col[media.ClassSeries][kind]
+		empty := []int{}
+		col.ForEachDoc(
+			func(id int, data []byte) (willMoveOn bool) {
+				rec := struct{ Key string }{}
+				if err := json.Unmarshal(data, &rec); nil != err {
+					return true // move on to next record, Load() quarantines it
+				}
+				_, inSeries := series[rec.Key]
+				_, inSeason := seasons[rec.Key]
+				if (media.SeriesShow == kind && !inSeries) ||
+					(media.SeriesSeason == kind && !inSeason) || known[kind][rec.Key] {
+					empty = append(empty, id) // duplicates are deleted too
+				} else {
+					known[kind][rec.Key] = true
+				}
+				return true // move on to next record
+			})
+		for _, id := range empty {
+			logs.Info.Tracef("deleting %s record without episodes (ID={%q,%X})",
+				media.SeriesColName[kind], l.name, id)
+			if err := col.Delete(id); nil != err {
+				logs.Warn.Verbosef("cannot delete record (ID={%q,%X}): %s", l.name, id, err)
+			}
+		}
+	}
+
+	// and add the records of those not yet known.
+	for key, s := range series {
+		if !known[media.SeriesShow][key] {
+			if ret := l.insertSeries(media.SeriesShow, s); nil != ret {
+				return ret
+			}
+		}
+	}
+	for key, s := range seasons {
+		if !known[media.SeriesSeason][key] {
+			if ret := l.insertSeries(media.SeriesSeason, media.NewSeason(s.series, s.number)); nil != ret {
+				return ret
+			}
+		}
+	}
+	return nil
+}
+
+// function insertSeries() inserts a record of the given Series or Season (of
+// the given kind) into this library's database.
+func (l *Library) insertSeries(kind media.SeriesKind, ent media.StorableEntity) *rc.ReturnCode {
+
+	rec, ret := ent.ToRecord()
+	if nil != ret {
+		return ret
+	}
+	id, err := l.db.Col[media.ClassSeries][kind].Insert(*rec)
+	if nil != err {
+		return rc.DatabaseError.Specf(
+			"insertSeries(%s): failed to insert record: %s", ent, err)
+	}
+	l.db.NumRecordsScan[media.ClassSeries][kind]++
+	logs.Info.Tracef("discovered %s (ID={%q,%X}): %s",
+		media.SeriesColName[kind], l.name, id, ent)
+	return nil
+}
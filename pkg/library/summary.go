@@ -0,0 +1,59 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: summary.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the summary of the most recent load and scan of a library, which
+//    is printed as JSON (option -summary=json) for scripts to parse.
+//
+// =============================================================================
+
+package library
+
+import (
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/storage"
+)
+
+// type Summary describes the outcome of the most recent load and scan of a
+// library. the records are counted by class and then by kind, e.g.
+// Found["media"]["video"] (see RecordCounts() of package storage).
+type Summary struct {
+	Name        string                     `json:"name"`
+	Path        string                     `json:"path"`
+	Loaded      map[string]map[string]uint `json:"loaded"`  // records read from the database
+	Found       map[string]map[string]uint `json:"found"`   // records of the new files found
+	Updated     map[string]map[string]uint `json:"updated"` // records of the changed files found
+	Files       uint                       `json:"files"`   // regular files examined
+	Ignored     uint                       `json:"ignored"` // files and directories skipped
+	LoadSeconds float64                    `json:"loadSeconds"`
+	ScanSeconds float64                    `json:"scanSeconds"`
+	Warnings    int                        `json:"warnings"`        // warnings raised by the load and scan
+	Error       string                     `json:"error,omitempty"` // error ending the scan, if any
+}
+
+// function Summary() summarizes the most recent load and scan of this library,
+// ended by the given error (nil if it completed). this should only be called
+// once both have finished (see ScanComplete()).
+func (l *Library) Summary(err *rc.ReturnCode) *Summary {
+
+	s := &Summary{
+		Name:        l.name,
+		Path:        l.absPath,
+		Loaded:      l.db.RecordCounts(storage.MethodLoad),
+		Found:       l.db.RecordCounts(storage.MethodScan),
+		Updated:     l.db.RecordCounts(storage.MethodUpdate),
+		Files:       l.numFiles,
+		Ignored:     l.numIgnored,
+		LoadSeconds: l.loadElapsed.Seconds(),
+		ScanSeconds: l.scanElapsed.Seconds(),
+		Warnings:    l.loadWarned + l.scanWarned,
+	}
+	if nil != err {
+		s.Error = err.Error()
+	}
+	return s
+}
@@ -0,0 +1,152 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: throttle.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the throttle limiting the rate at which a scan examines and reads
+//    the files of a library, so that a background rescan doesn't starve other
+//    users of the disk, e.g. playback from the same NAS.
+//
+// =============================================================================
+
+package library
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type ScanRate limits the rate at which a scan examines and reads files. a
+// rate of 0 is unlimited.
+type ScanRate struct {
+	Bytes float64 // bytes read (to hash the files) per second
+	Files float64 // files and directories examined per second
+}
+
+// var scanRateUnit maps the units of the rates accepted by ParseScanRate() to
+// the number of bytes (or files) each represents.
+var scanRateUnit = map[string]float64{
+	"b":     1,
+	"kb":    1e3,
+	"mb":    1e6,
+	"gb":    1e9,
+	"kib":   1 << 10,
+	"mib":   1 << 20,
+	"gib":   1 << 30,
+	"files": 1,
+}
+
+// function ParseScanRate() parses the given comma-separated list of rates, each
+// a number followed by a unit per second, e.g. "20MB/s" or "20MB/s,500files/s".
+// the byte units are B, KB, MB, GB (powers of 1000) and KiB, MiB, GiB (powers
+// of 1024); files are counted with "files". the empty string is unlimited.
+func ParseScanRate(s string) (ScanRate, *rc.ReturnCode) {
+
+	var rate ScanRate
+	for _, r := range strings.Split(s, ",") {
+		if r = strings.TrimSpace(r); "" == r {
+			continue
+		}
+		lower := strings.ToLower(r)
+		if !strings.HasSuffix(lower, "/s") {
+			return ScanRate{}, rc.InvalidArgs.Specf("ParseScanRate(): missing \"/s\": %q", r)
+		}
+		lower = strings.TrimSuffix(lower, "/s")
+		i := strings.IndexFunc(lower, func(c rune) bool {
+			return (c < '0' || c > '9') && '.' != c
+		})
+		if i <= 0 {
+			return ScanRate{}, rc.InvalidArgs.Specf("ParseScanRate(): invalid rate: %q", r)
+		}
+		name := strings.TrimSpace(lower[i:])
+		n, err := strconv.ParseFloat(lower[:i], 64)
+		unit, ok := scanRateUnit[name]
+		if nil != err || !ok || n <= 0 {
+			return ScanRate{}, rc.InvalidArgs.Specf("ParseScanRate(): invalid rate: %q", r)
+		}
+		if "files" == name {
+			rate.Files = n
+		} else {
+			rate.Bytes = n * unit
+		}
+	}
+	return rate, nil
+}
+
+// type throttle delays the scan to which it belongs as necessary to keep the
+// files examined and bytes read within its ScanRate, waiting no longer once the
+// scan's Context is done. a nil throttle never delays.
+type throttle struct {
+	ctx       context.Context
+	rate      ScanRate
+	nextFile  time.Time // time at which the next file may be examined
+	nextBytes time.Time // time at which the next bytes may be read
+}
+
+// function newThrottle() creates a new throttle of the scan with the given
+// Context, or nil if the given rate is unlimited.
+func newThrottle(ctx context.Context, rate ScanRate) *throttle {
+	if rate.Bytes <= 0 && rate.Files <= 0 {
+		return nil
+	}
+	return &throttle{ctx: ctx, rate: rate}
+}
+
+// function file() waits until another file may be examined.
+func (t *throttle) file() {
+	if nil == t {
+		return
+	}
+	t.wait(&t.nextFile, 1, t.rate.Files)
+}
+
+// function bytes() waits until the given number of bytes may be read.
+func (t *throttle) bytes(n int64) {
+	if nil == t {
+		return
+	}
+	t.wait(&t.nextBytes, float64(n), t.rate.Bytes)
+}
+
+// function wait() consumes the given amount at the given rate per second,
+// waiting until the time at which it may be consumed, i.e. the given next time,
+// which is then advanced by the time it takes to consume the amount at that
+// rate. the throttle is only used by the single goroutine of its scan.
+func (t *throttle) wait(next *time.Time, amount, rate float64) {
+
+	if rate <= 0 || amount <= 0 {
+		return
+	}
+	now := time.Now()
+	if next.Before(now) {
+		*next = now // idle time isn't saved up for a burst later.
+	}
+	delay := next.Sub(now)
+	*next = next.Add(time.Duration(amount / rate * float64(time.Second)))
+	if delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-t.ctx.Done():
+	}
+}
+
+// function hashSize() returns the number of bytes of a file of the given size
+// read to compute its content hash with the given partial size (see
+// contenthash.Sum()).
+func hashSize(size, partial int64) int64 {
+	if partial <= 0 || size <= 2*partial {
+		return size
+	}
+	return 2 * partial
+}
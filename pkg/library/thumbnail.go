@@ -0,0 +1,55 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: thumbnail.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the thumbnails of the media in a library's database, generated
+//    and cached by package thumbnail under the records' IDs.
+//
+// =============================================================================
+
+package library
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/thumbnail"
+)
+
+// function ThumbnailByID() returns the path of the thumbnail of the given media
+// of the given kind with the given record ID, in the given Cache, generating
+// it if needed. the thumbnails of each library are kept apart, by the name of
+// its database.
+func (l *Library) ThumbnailByID(c *thumbnail.Cache, kind media.MediaKind, id int, m *media.Media) (string, *rc.ReturnCode) {
+
+	if kind < 0 || kind >= media.KindCOUNT {
+		return "", rc.InvalidArgs.Specf("ThumbnailByID(%d, %d): unrecognized kind of media", int(kind), id)
+	}
+	key := path.Join(l.db.Name(), strings.ToLower(media.MediaColName[kind]), strconv.Itoa(id))
+	return c.Get(key, m)
+}
+
+// function Thumbnail() returns the path of the thumbnail of the media at the
+// given absolute path, in the given Cache, generating it if needed.
+func (l *Library) Thumbnail(c *thumbnail.Cache, absPath string) (string, *rc.ReturnCode) {
+
+	kind, id, ret := l.findMedia(absPath)
+	if nil != ret {
+		return "", ret
+	}
+	if media.KindUnknown == kind {
+		return "", rc.InvalidArgs.Specf("Thumbnail(%q): media not found", absPath)
+	}
+	_, m, ret := l.MediaByID(kind, id)
+	if nil != ret {
+		return "", ret
+	}
+	return l.ThumbnailByID(c, kind, id, m)
+}
@@ -0,0 +1,132 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: warnings.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the aggregator of the warnings raised while scanning a library,
+//    which collapses repeated messages so that a large and messy library does
+//    not flood the log with thousands of identical lines, and records every
+//    one of them in the library's scan report.
+//
+// =============================================================================
+
+package library
+
+import (
+	"regexp"
+	"sort"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/storage"
+)
+
+// local unexported constants limiting the warnings logged by each scan.
+const (
+	warnRepeatLimit = 3  // occurrences of the same message logged before suppressing it
+	warnReportLimit = 20 // distinct messages listed by the report at scan end
+)
+
+// var warnCallSite matches the call site prefixed to the messages of
+// scanDive(), i.e. its function name, path, and depth, which are stripped to
+// recognize the same message raised for different files.
+var warnCallSite = regexp.MustCompile(`\w+\("(?:[^"\\]|\\.)*", \d+\): `)
+
+// type scanWarnings counts the warnings raised by a load or scan, keyed by
+// their message stripped of the call site (see warnCallSite), in order of first
+// occurrence, and keeps each of them for the scan report.
+type scanWarnings struct {
+	count map[string]int
+	first map[string]string // the complete first message of each key
+	order []string
+
+	entries []storage.ReportEntry
+}
+
+// function newScanWarnings() creates an empty aggregator of warnings.
+func newScanWarnings() *scanWarnings {
+	return &scanWarnings{
+		count: map[string]int{},
+		first: map[string]string{},
+	}
+}
+
+// function add() records the given warning about the file at the given path
+// (relative to the library), logging it only if the same message hasn't
+// already been logged warnRepeatLimit times by this scan.
+func (w *scanWarnings) add(relPath string, ret *rc.ReturnCode) {
+	msg, key, n := w.record(relPath, ret)
+	switch {
+	case n <= warnRepeatLimit:
+		logs.Warn.Trace(msg)
+	case n == warnRepeatLimit+1:
+		logs.Warn.Tracef("%s (further occurrences suppressed)", key)
+	}
+}
+
+// function record() is like add(), but never logs the warning, for callers
+// having already done so. returns the complete message, its key, and the number
+// of times it occurred. the message is captured immediately, since the
+// ReturnCode is shared and respecified by every subsequent error of its kind.
+func (w *scanWarnings) record(relPath string, ret *rc.ReturnCode) (string, string, int) {
+	msg := ret.Error()
+	key := warnCallSite.ReplaceAllString(msg, "")
+	n := w.count[key] + 1
+	w.count[key] = n
+	if 1 == n {
+		w.first[key] = msg
+		w.order = append(w.order, key)
+	}
+	w.entries = append(w.entries, storage.ReportEntry{
+		Path:    relPath,
+		Code:    ret.Code(),
+		Message: key,
+	})
+	return msg, key, n
+}
+
+// function total() returns the number of warnings counted.
+func (w *scanWarnings) total() int {
+	return len(w.entries)
+}
+
+// function report() logs the summary of the warnings raised while scanning the
+// named library: each distinct message once, followed by the number of times
+// it occurred, most frequent first.
+func (w *scanWarnings) report(name string) {
+
+	if 0 == len(w.order) {
+		return
+	}
+	logs.Warn.Verbosef("%d warning(s) scanning: %q (see \"report\")", w.total(), name)
+
+	// order is already by first occurrence, so a stable sort keeps messages of
+	// equal frequency in the order they were raised.
+	keys := append([]string{}, w.order...)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return w.count[keys[i]] > w.count[keys[j]]
+	})
+	for i, key := range keys {
+		if i == warnReportLimit {
+			logs.Warn.Verbosef("  … and %d other message(s)", len(keys)-i)
+			break
+		}
+		if n := w.count[key]; n > 1 {
+			logs.Warn.Verbosef("  %s ×%d", key, n)
+		} else {
+			logs.Warn.Verbosef("  %s", w.first[key])
+		}
+	}
+}
+
+// function save() replaces the load (MethodLoad) or scan (MethodScan) section
+// of the given database's scan report with the warnings recorded.
+func (w *scanWarnings) save(db *storage.Database, m storage.DiscoveryMethod) *rc.ReturnCode {
+	return db.SetReportSection(m, storage.ReportSection{
+		Time:    time.Now(),
+		Entries: w.entries,
+	})
+}
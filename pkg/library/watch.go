@@ -0,0 +1,191 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: watch.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    watches a library's file system for files added, changed, removed, and
+//    renamed after the initial scan, keeping the database up to date without
+//    rescanning the entire library.
+//
+// =============================================================================
+
+package library
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/storage"
+	"ardnew.com/pimmp/pkg/trash"
+)
+
+// local unexported constants for the file system watcher.
+const (
+	// a path is only handled once no event has been received for it for this
+	// long, so that a file being copied or downloaded is handled just once,
+	// after it is complete.
+	watchSettle = 2 * time.Second
+	// how often the paths with pending events are checked for having settled.
+	watchTick = time.Second
+)
+
+// function Watch() watches the library's file system for changes until the
+// given Context is done, which is the only way it returns rc.Canceled. new and
+// changed files (and directories) are scanned like ScanFile(), and the records
+// of files removed are deleted or moved to the orphaned collection as though
+// found missing while loading (see SetPrune()), publishing each on the given
+// Bus (if not nil) from SourceWatch. a renamed file is handled as removed from
+// its old path and added at its new path. ignored files (see Ignore) and trash
+// directories are never watched.
+func (l *Library) Watch(ctx context.Context, bus *Bus) *rc.ReturnCode {
+
+	pub := newPublisher(bus, SourceWatch)
+
+	w, err := fsnotify.NewWatcher()
+	if nil != err {
+		return rc.WatchError.Specf("Watch(%q): fsnotify.NewWatcher(): %s", l.name, err)
+	}
+	defer w.Close()
+
+	ignore := l.watchIgnore()
+	l.watchTree(w, ignore, l.absPath)
+	logs.Info.Verbosef("watching for changes: %q", l.name)
+
+	pending := map[string]time.Time{} // paths changed, by time of last event
+	tick := time.NewTicker(watchTick)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return rc.Canceled.Specf("Watch(%q): %s", l.name, ctx.Err())
+
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			logs.Warn.Verbosef("watching %q: %s", l.name, err)
+
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Join(l.absPath, IgnoreFileName) == ev.Name {
+				ignore = l.watchIgnore()
+				continue
+			}
+			rel, err := filepath.Rel(l.absPath, ev.Name)
+			if nil != err || ignore.Covers(rel) || trash.IsTrashDir(filepath.Base(ev.Name)) {
+				continue
+			}
+			if fsnotify.Chmod != ev.Op {
+				pending[ev.Name] = time.Now()
+			}
+
+		case now := <-tick.C:
+			for absPath, last := range pending {
+				if now.Sub(last) < watchSettle {
+					continue
+				}
+				ret := l.watchPath(w, ignore, pub, absPath)
+				if errors.Is(ret, rc.LibraryBusy) {
+					continue // being scanned, try again next time
+				}
+				if nil != ret {
+					logs.Warn.Verbose(ret)
+				}
+				delete(pending, absPath)
+			}
+		}
+	}
+}
+
+// function watchIgnore() reads the patterns of the files never watched, which
+// are the same as those never scanned.
+func (l *Library) watchIgnore() *Ignore {
+	ig, ret := loadIgnore(l.absPath, l.exclude)
+	if nil != ret {
+		logs.Warn.Log(ret)
+		ig, _ = newIgnore(l.exclude)
+	}
+	return ig
+}
+
+// function watchTree() adds the directory at the given path, and every
+// directory below it that isn't ignored, to the given watcher.
+func (l *Library) watchTree(w *fsnotify.Watcher, ignore *Ignore, absPath string) {
+
+	filepath.Walk(absPath, func(p string, info os.FileInfo, err error) error {
+		if nil != err || !info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(l.absPath, p)
+		if nil != err || (p != l.absPath && (ignore.Covers(rel) || trash.IsTrashDir(info.Name()))) {
+			return filepath.SkipDir
+		}
+		if err := w.Add(p); nil != err {
+			logs.Warn.Verbosef("cannot watch directory: %q: %s", p, err)
+		}
+		return nil
+	})
+}
+
+// function watchPath() brings the database up to date with the current state
+// of the file or directory at the given path, which has changed.
+func (l *Library) watchPath(w *fsnotify.Watcher, ignore *Ignore, pub *publisher, absPath string) *rc.ReturnCode {
+
+	info, err := os.Lstat(absPath)
+	if os.IsNotExist(err) {
+		l.forget(pub, absPath)
+		return nil
+	}
+	if nil != err {
+		return rc.InvalidStat.Specf("watchPath(%q): os.Lstat(): %s", absPath, err)
+	}
+	if info.IsDir() {
+		// a directory created or moved into the library, which may already
+		// contain files (or directories) of its own.
+		l.watchTree(w, ignore, absPath)
+	}
+	return l.scanFile(pub, absPath)
+}
+
+// function forget() removes the records of the file at the given path, or of
+// every file below it if it was a directory, exactly as though the files were
+// found missing while loading (see SetPrune()), and publishes their removal.
+func (l *Library) forget(pub *publisher, absPath string) {
+
+	prefix := absPath + string(filepath.Separator)
+	for classID, names := range l.db.ColName {
+		class := media.EntityClass(classID)
+		for kind := range names {
+			gone := []storage.RecordID{}
+			l.db.Col[class][kind].ForEachDoc(
+				func(id int, data []byte) (willMoveOn bool) {
+					rec := struct{ AbsPath string }{}
+					if err := json.Unmarshal(data, &rec); nil != err {
+						return true // move on to next record, Load() quarantines it
+					}
+					if absPath == rec.AbsPath || strings.HasPrefix(rec.AbsPath, prefix) {
+						buf := make([]byte, len(data))
+						copy(buf, data)
+						gone = append(gone, storage.RecordID{ID: id, Rec: &missingRecord{buf, rec.AbsPath}})
+					}
+					return true // move on to next record
+				})
+			l.dropMissing(pub, class, kind, gone)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: lyrics.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    parses lyrics files: LRC files, whose lines are tagged with the time at
+//    which each is sung, and plain text files, whose lines are not.
+//
+// =============================================================================
+
+// package lyrics parses the lyrics files accompanying audio tracks, so that the
+// lines of the lyrics can be displayed in time with playback. the LRC format is
+// a line of text preceded by one or more timestamps, e.g.:
+//
+//	[ti:Song Title]
+//	[offset:+250]
+//	[00:12.00]first line
+//	[00:17.20][01:05.50]chorus, sung twice
+//
+// the "enhanced" LRC format's timestamps of each word (<mm:ss.xx>) are ignored.
+package lyrics
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Line is a single line of lyrics.
+type Line struct {
+	Time time.Duration // offset into the track at which the line is sung (0 if unsynchronized)
+	Text string        // text of the line, empty for an instrumental break
+}
+
+// type Lyrics is the content of a lyrics file.
+type Lyrics struct {
+	Title  string // title of the track ([ti:] tag)
+	Artist string // performer of the track ([ar:] tag)
+	Album  string // album on which the track appears ([al:] tag)
+	Synced bool   // lines are tagged with times, and sorted by them
+	Lines  []Line // lines of the lyrics, in order
+}
+
+var (
+	// variable timestamp matches a single timestamp of an LRC line, e.g.
+	// "[01:05.50]", capturing the minutes, seconds, and fraction.
+	timestamp = regexp.MustCompile(`^\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	// variable idTag matches an ID tag of an LRC file, e.g. "[ar:Artist]",
+	// capturing the tag name and value.
+	idTag = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]\s*$`)
+	// variable wordTime matches the timestamp of a word of enhanced LRC.
+	wordTime = regexp.MustCompile(`<\d+:\d{1,2}(?:[.:]\d{1,3})?>`)
+)
+
+// function Read() reads the lyrics file at the given path (see Parse()).
+func Read(path string) (*Lyrics, *rc.ReturnCode) {
+
+	f, err := os.Open(path)
+	if nil != err {
+		return nil, rc.InvalidFile.Specf("lyrics.Read(%q): os.Open(): %s", path, err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// function Parse() reads lyrics from the given reader. if any line has an LRC
+// timestamp, the lyrics are synchronized: lines without timestamps (other than
+// ID tags) are dropped, and the rest are sorted by time, adjusted by the
+// [offset:] tag if any. otherwise, every line is kept as text, unsynchronized.
+func Parse(r io.Reader) (*Lyrics, *rc.ReturnCode) {
+
+	lyr := &Lyrics{Lines: []Line{}}
+	plain := []Line{}
+	var offset time.Duration
+
+	scan := bufio.NewScanner(r)
+	scan.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	first := true
+	for scan.Scan() {
+		line := strings.TrimRight(scan.Text(), "\r")
+		if first {
+			line, first = strings.TrimPrefix(line, "\ufeff"), false
+		}
+		trim := strings.TrimSpace(line)
+
+		// each timestamp preceding the text is a time at which it is sung.
+		times := []time.Duration{}
+		for {
+			m := timestamp.FindStringSubmatch(trim)
+			if nil == m {
+				break
+			}
+			times = append(times, parseTime(m[1], m[2], m[3]))
+			trim = strings.TrimSpace(trim[len(m[0]):])
+		}
+		if len(times) > 0 {
+			text := strings.Join(strings.Fields(wordTime.ReplaceAllString(trim, "")), " ")
+			for _, t := range times {
+				lyr.Lines = append(lyr.Lines, Line{Time: t, Text: text})
+			}
+			continue
+		}
+
+		if m := idTag.FindStringSubmatch(trim); nil != m {
+			value := strings.TrimSpace(m[2])
+			switch strings.ToLower(m[1]) {
+			case "ti":
+				lyr.Title = value
+			case "ar":
+				lyr.Artist = value
+			case "al":
+				lyr.Album = value
+			case "offset":
+				// a positive offset means the lines are sung earlier.
+				if ms, err := strconv.Atoi(strings.TrimPrefix(value, "+")); nil == err {
+					offset = time.Duration(ms) * time.Millisecond
+				}
+			}
+			continue
+		}
+		plain = append(plain, Line{Text: strings.TrimSpace(line)})
+	}
+	if err := scan.Err(); nil != err {
+		return nil, rc.InvalidFile.Specf("lyrics.Parse(): %s", err)
+	}
+
+	if len(lyr.Lines) > 0 {
+		lyr.Synced = true
+		for i := range lyr.Lines {
+			if lyr.Lines[i].Time -= offset; lyr.Lines[i].Time < 0 {
+				lyr.Lines[i].Time = 0
+			}
+		}
+		sort.SliceStable(lyr.Lines, func(a, b int) bool { return lyr.Lines[a].Time < lyr.Lines[b].Time })
+		return lyr, nil
+	}
+
+	// unsynchronized lyrics keep their blank lines between verses, but not
+	// those leading or trailing.
+	for len(plain) > 0 && "" == plain[0].Text {
+		plain = plain[1:]
+	}
+	for len(plain) > 0 && "" == plain[len(plain)-1].Text {
+		plain = plain[:len(plain)-1]
+	}
+	lyr.Lines = append(lyr.Lines, plain...)
+	return lyr, nil
+}
+
+// function parseTime() returns the duration of the given minutes, seconds,
+// and fraction of a second (hundredths if 2 digits, etc.) of a timestamp.
+func parseTime(min, sec, frac string) time.Duration {
+	m, _ := strconv.Atoi(min)
+	s, _ := strconv.Atoi(sec)
+	d := time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+	if "" != frac {
+		f, _ := strconv.Atoi(frac)
+		for i := len(frac); i < 9; i++ {
+			f *= 10
+		}
+		d += time.Duration(f)
+	}
+	return d
+}
+
+// function At() returns the index of the line being sung at the given offset
+// into the track, i.e. the last line whose time has passed, or -1 if none has
+// (or the lyrics aren't synchronized).
+func (l *Lyrics) At(pos time.Duration) int {
+	if !l.Synced {
+		return -1
+	}
+	return sort.Search(len(l.Lines), func(i int) bool { return l.Lines[i].Time > pos }) - 1
+}
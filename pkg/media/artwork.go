@@ -0,0 +1,210 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: artwork.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the support type of artwork files (album covers, movie posters,
+//    folder images) found beside media, and how they are recognized by name.
+//
+// =============================================================================
+
+package media
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Artwork is a specialized type of support containing struct fields
+// relevant only to artwork, i.e. an image depicting the media beside it.
+type Artwork struct {
+	*Support        // common support info
+	Role     string // role of the image, named by its file (see ArtworkRole())
+	Prefix   string // base name of the media it depicts, empty if all in its directory
+}
+
+var (
+	// variable ArtworkRoles lists the names of artwork files, each naming the
+	// role of the image, in order of preference when several depict the same
+	// media.
+	ArtworkRoles = []string{"poster", "cover", "folder"}
+
+	// var artExt is a struct defining how SupportArtwork support files will be
+	// identified through file name inspection. unlike subtitles, the extension
+	// alone is insufficient: the file must also be named by one of the
+	// ArtworkRoles (see ArtworkRole()).
+	artExt = SupportExt{
+		kind: SupportArtwork,
+		table: &ExtTable{
+			"JPEG": []string{".jpg", ".jpeg"},
+			"PNG":  []string{".png"},
+			"WebP": []string{".webp"},
+		},
+	}
+)
+
+// function ArtworkRole() returns the role of the artwork file with the given
+// base name (without its extension), or "" if the name isn't one of artwork.
+// the name is either the role itself (e.g. "cover", depicting all media in its
+// directory) or the role appended to the base name of the media it depicts
+// (e.g. "Movie-poster" or "Movie.poster"), ignoring case.
+func ArtworkRole(base string) string {
+	role, _ := splitArtworkName(base)
+	return role
+}
+
+// function splitArtworkName() returns the role and media base name (prefix)
+// named by the given artwork base name (see ArtworkRole()).
+func splitArtworkName(base string) (string, string) {
+
+	lower := strings.ToLower(base)
+	for _, role := range ArtworkRoles {
+		if lower == role {
+			return role, ""
+		}
+		if n := len(lower) - len(role) - 1; n > 0 && strings.HasSuffix(lower, role) {
+			switch lower[n] {
+			case '-', '.', '_':
+				return role, base[:n]
+			}
+		}
+	}
+	return "", ""
+}
+
+// function NewArtwork() creates and initializes a new Artwork object by
+// invoking the embedded types' constructors and then populating any unique
+// specialization fields.
+func NewArtwork(absPath, relPath, ext, extName string, info os.FileInfo) *Artwork {
+
+	support := NewSupport(SupportArtwork, absPath, relPath, ext, extName, info)
+	role, prefix := splitArtworkName(support.AbsBase)
+
+	return &Artwork{
+		Support: support, // common support info
+		Role:    role,    // role of the image
+		Prefix:  prefix,  // base name of the media it depicts
+	}
+}
+
+// function Rank() returns the preference of the Artwork among others depicting
+// the same media, lower ranks preferred: artwork named for the media before
+// artwork of its directory, and then by the order of ArtworkRoles.
+func (a *Artwork) Rank() int {
+	rank := len(ArtworkRoles)
+	for i, role := range ArtworkRoles {
+		if role == a.Role {
+			rank = i
+			break
+		}
+	}
+	if "" == a.Prefix {
+		rank += len(ArtworkRoles) + 1
+	}
+	return rank
+}
+
+// function Depicts() returns true if the Artwork depicts the media with the
+// given base name located in the given directory: artwork named for media
+// depicts the media of that name in its own directory, while artwork named
+// only by its role depicts all media in its directory and in the directories
+// immediately beneath it (e.g. the discs of an album, or seasons of a series).
+func (a *Artwork) Depicts(dir, base string) bool {
+	if "" != a.Prefix {
+		return dir == a.AbsDir && strings.EqualFold(base, a.Prefix)
+	}
+	return dir == a.AbsDir || filepath.Dir(dir) == a.AbsDir
+}
+
+// function ToRecord() creates a struct capable of being stored in the database.
+// defines type Artwork's implementation of the StorableEntity interface.
+func (a *Artwork) ToRecord() (*EntityRecord, *rc.ReturnCode) {
+
+	var (
+		record *EntityRecord = &EntityRecord{}
+		data   []byte
+		err    error
+	)
+
+	if data, err = json.Marshal(a); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Marshal(%s): cannot marshal Artwork struct into JSON object: %s", a, err)
+	}
+
+	if err = json.Unmarshal(data, record); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into EntityRecord struct: %s", string(data), err)
+	}
+
+	return record, nil
+}
+
+// function FromRecord() creates a struct using the record stored in the
+// database. defines type Artwork's implementation of the StorableEntity
+// interface.
+func (a *Artwork) FromRecord(data []byte) *rc.ReturnCode {
+
+	// guard the embedded Support struct pointer (see Subtitles.FromRecord()).
+	if nil == a.Support {
+		a.Support = &Support{}
+	}
+
+	if err := json.Unmarshal(data, a); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"FromRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into Artwork struct: %s", string(data), err)
+	}
+
+	if err := a.Entity.Validate(ClassSupport); nil != err {
+		return err
+	}
+	if SupportArtwork != a.Kind {
+		return rc.CorruptRecord.Specf(
+			"FromRecord(): support kind mismatch: %d (expected %d)", int(a.Kind), int(SupportArtwork))
+	}
+	if "" == a.Role {
+		a.Role, a.Prefix = splitArtworkName(a.AbsBase)
+	}
+
+	return nil
+}
+
+// function FromID() creates a concrete Artwork struct using the record stored
+// in the given collection with the given hash key id.
+func (a *Artwork) FromID(col engine.Collection, id int) *rc.ReturnCode {
+
+	read, readErr := col.Read(id)
+	if nil != readErr {
+		return rc.DatabaseError.Specf(
+			"FromID(%v): Read(%d): cannot read record from database: %s",
+			col, id, readErr)
+	}
+
+	data, marshalErr := json.Marshal(read)
+	if nil != marshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Marshal(%s): cannot marshal query result into JSON object: %s",
+			col, read, marshalErr)
+	}
+
+	if nil == a.Support {
+		a.Support = &Support{}
+	}
+	unmarshalErr := json.Unmarshal(data, a)
+	if nil != unmarshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Unmarshal(%s): cannot unmarshal JSON object into Artwork struct: %s",
+			col, data, unmarshalErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: cuesheet.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the support type of cue sheets, describing the tracks of an album
+//    ripped to a single audio file, and the paths of those tracks.
+//
+// =============================================================================
+
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type CueSheet is a specialized type of support for cue sheets, whose content
+// describes the tracks contained in an audio "image" file, e.g. an album ripped
+// to a single FLAC or APE file (see package cue). each track is browsed as a
+// media of its own (see TrackPath()).
+type CueSheet struct {
+	*Support // common support info
+}
+
+var (
+	// var cueExt is a struct defining how SupportCueSheet support files will
+	// be identified through file name inspection.
+	cueExt = SupportExt{
+		kind: SupportCueSheet,
+		table: &ExtTable{
+			"Cue Sheet": []string{".cue"},
+		},
+	}
+)
+
+// function NewCueSheet() creates and initializes a new CueSheet object by
+// invoking the embedded types' constructors and then populating any unique
+// specialization fields.
+func NewCueSheet(absPath, relPath, ext, extName string, info os.FileInfo) *CueSheet {
+
+	support := NewSupport(SupportCueSheet, absPath, relPath, ext, extName, info)
+
+	return &CueSheet{
+		Support: support, // common support info
+	}
+}
+
+// function ImagePath() returns the absolute path of the audio file with the
+// given name in the CueSheet, or an empty string if there is none. the name is
+// relative to the CueSheet's directory unless absolute. rippers often encode
+// the image file after writing the cue sheet, so if the named file doesn't
+// exist, the audio file of the same base name in any other format is sought,
+// e.g. "Album.flac" for "Album.wav".
+func (c *CueSheet) ImagePath(name string) string {
+
+	// sheets written on Windows separate directories with backslashes.
+	name = filepath.FromSlash(strings.ReplaceAll(name, `\`, "/"))
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(c.AbsDir, name)
+	}
+	regular := func(p string) bool {
+		info, err := os.Stat(p)
+		return nil == err && info.Mode().IsRegular()
+	}
+	if regular(name) {
+		return name
+	}
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	for _, list := range *audioExt.table {
+		for _, e := range list {
+			for _, p := range []string{base + e, base + strings.ToUpper(e)} {
+				if regular(p) {
+					return p
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// function TrackPath() returns the path identifying the track with the given
+// number contained in the audio image file at the given path, e.g.
+// "/music/Album.flac#03" for the third track. it is not the path of any file,
+// but it is unique to the track, so it is the AbsPath of the track's media
+// (whose ImageFile is the path of the file).
+func TrackPath(image string, number int64) string {
+	return fmt.Sprintf("%s#%02d", image, number)
+}
+
+// function ToRecord() creates a struct capable of being stored in the database.
+// defines type CueSheet's implementation of the StorableEntity interface.
+func (c *CueSheet) ToRecord() (*EntityRecord, *rc.ReturnCode) {
+
+	var (
+		record *EntityRecord = &EntityRecord{}
+		data   []byte
+		err    error
+	)
+
+	if data, err = json.Marshal(c); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Marshal(%s): cannot marshal CueSheet struct into JSON object: %s", c, err)
+	}
+
+	if err = json.Unmarshal(data, record); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into EntityRecord struct: %s", string(data), err)
+	}
+
+	return record, nil
+}
+
+// function FromRecord() creates a struct using the record stored in the
+// database. defines type CueSheet's implementation of the StorableEntity
+// interface.
+func (c *CueSheet) FromRecord(data []byte) *rc.ReturnCode {
+
+	// guard the embedded Support struct pointer (see Subtitles.FromRecord()).
+	if nil == c.Support {
+		c.Support = &Support{}
+	}
+
+	if err := json.Unmarshal(data, c); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"FromRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into CueSheet struct: %s", string(data), err)
+	}
+
+	if err := c.Entity.Validate(ClassSupport); nil != err {
+		return err
+	}
+	if SupportCueSheet != c.Kind {
+		return rc.CorruptRecord.Specf(
+			"FromRecord(): support kind mismatch: %d (expected %d)", int(c.Kind), int(SupportCueSheet))
+	}
+
+	return nil
+}
+
+// function FromID() creates a concrete CueSheet struct using the record stored
+// in the given collection with the given hash key id.
+func (c *CueSheet) FromID(col engine.Collection, id int) *rc.ReturnCode {
+
+	read, readErr := col.Read(id)
+	if nil != readErr {
+		return rc.DatabaseError.Specf(
+			"FromID(%v): Read(%d): cannot read record from database: %s",
+			col, id, readErr)
+	}
+
+	data, marshalErr := json.Marshal(read)
+	if nil != marshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Marshal(%s): cannot marshal query result into JSON object: %s",
+			col, read, marshalErr)
+	}
+
+	if nil == c.Support {
+		c.Support = &Support{}
+	}
+	unmarshalErr := json.Unmarshal(data, c)
+	if nil != unmarshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Unmarshal(%s): cannot unmarshal JSON object into CueSheet struct: %s",
+			col, data, unmarshalErr)
+	}
+
+	return nil
+}
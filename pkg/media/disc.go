@@ -0,0 +1,108 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: disc.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    identifies the folder structure of a video disc (DVD or Blu-ray) copied
+//    to the file system, which is a single video rather than the hundreds of
+//    fragments it contains.
+//
+// =============================================================================
+
+package media
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// var discFolder maps the (lower case) name of the directory holding the
+	// structure of each format of video disc to the name of the format.
+	discFolder = map[string]string{
+		"video_ts": "DVD-Video",
+		"bdmv":     "Blu-ray Disc Movie",
+	}
+)
+
+// function DiscFormat() returns the format of video disc whose structure is
+// held by a directory with the given name, e.g. "DVD-Video" for "VIDEO_TS",
+// and false if there is none.
+func DiscFormat(name string) (string, bool) {
+	format, ok := discFolder[strings.ToLower(name)]
+	return format, ok
+}
+
+// function DiscFolder() returns the path of the directory holding the structure
+// of a video disc (see DiscFormat()) containing the file at the given path, or
+// the empty string if there is none. the path returned is relative if the given
+// path is.
+func DiscFolder(path string) string {
+	elem := strings.Split(filepath.ToSlash(path), "/")
+	for i, e := range elem {
+		if _, ok := DiscFormat(e); ok {
+			return filepath.FromSlash(strings.Join(elem[:i+1], "/"))
+		}
+	}
+	return ""
+}
+
+// type discInfo is the os.FileInfo of the directory holding the structure of a
+// video disc, whose size and modification time are those of its content.
+type discInfo struct {
+	os.FileInfo
+	size    int64
+	modTime time.Time
+}
+
+// function Size() returns the total size of the files of the disc.
+func (d *discInfo) Size() int64 { return d.size }
+
+// function ModTime() returns the latest modification time of the disc's files.
+func (d *discInfo) ModTime() time.Time { return d.modTime }
+
+// function DiscInfo() returns the os.FileInfo of the directory at the given
+// path, with the given info, holding the structure of a video disc, whose size
+// is the total size of every file below it, and whose modification time is the
+// latest of theirs (or the directory's own), so that the disc is changed (see
+// Entity.Changed()) whenever any of its files are.
+func DiscInfo(absPath string, info os.FileInfo) os.FileInfo {
+	d := &discInfo{FileInfo: info, size: 0, modTime: info.ModTime()}
+	filepath.Walk(absPath, func(p string, fi os.FileInfo, err error) error {
+		if nil != err {
+			return nil
+		}
+		if fi.ModTime().After(d.modTime) {
+			d.modTime = fi.ModTime()
+		}
+		if fi.Mode().IsRegular() {
+			d.size += fi.Size()
+		}
+		return nil
+	})
+	return d
+}
+
+// function NewDiscVideoMedia() creates and initializes a new VideoMedia object
+// of the video disc whose structure is held by the directory at the given path
+// (see DiscFormat()), in the given format, with the given info (see
+// DiscInfo()). its name and title are those of the directory containing it,
+// which is typically named for the movie, e.g. "Movie (2010)/VIDEO_TS".
+func NewDiscVideoMedia(absPath, relPath, format string, info os.FileInfo) *VideoMedia {
+
+	video := NewVideoMedia(absPath, relPath, "", format, info)
+	if name := filepath.Base(filepath.Dir(absPath)); "." != name && string(filepath.Separator) != name {
+		video.Name, video.Title = name, name
+	}
+	video.ParseName()
+	return video
+}
+
+// function IsDisc() returns true if the media is a video disc, i.e. the folder
+// holding its structure rather than a file (see DiscFormat()).
+func (m *Media) IsDisc() bool { return nil != m.Entity && m.Mode.IsDir() }
@@ -0,0 +1,167 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: document.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the media type of ebooks, comics, and other documents, which are
+//    opened with a reader rather than played.
+//
+// =============================================================================
+
+package media
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type DocumentMedia is a specialized type of media containing struct fields
+// relevant only to ebooks, comics, and other documents. the fields read from
+// the document's own metadata are left zero if it has none (see package
+// ebook).
+type DocumentMedia struct {
+	*Media              // common media info
+	Author      string  // author (or writer, of a comic) of the document
+	Series      string  // name of the series the document belongs to
+	SeriesIndex float64 // position in its series (may be fractional, e.g. 1.5), 0 if unknown
+	Pages       int64   // number of pages, 0 if unknown
+}
+
+var (
+	// var documentExt is a struct defining how KindDocument media files will
+	// be identified through file name inspection (see discussion of audioExt).
+	documentExt = MediaExt{
+		kind: KindDocument,
+		table: &ExtTable{
+			"Comic Book 7z":            []string{".cb7"},
+			"Comic Book RAR":           []string{".cbr"},
+			"Comic Book Zip":           []string{".cbz"},
+			"DjVu":                     []string{".djvu"},
+			"Electronic Publication":   []string{".epub"},
+			"Kindle":                   []string{".azw", ".azw3"},
+			"Mobipocket":               []string{".mobi", ".prc"},
+			"Portable Document Format": []string{".pdf"},
+		},
+	}
+)
+
+// function NewDocumentMedia() creates and initializes a new DocumentMedia
+// object by invoking the embedded types' constructors and then populating the
+// unique specialization fields.
+func NewDocumentMedia(absPath, relPath, ext, extName string, info os.FileInfo) *DocumentMedia {
+
+	media := NewMedia(KindDocument, absPath, relPath, ext, extName, info)
+
+	return &DocumentMedia{
+		Media:       media, // common media info
+		Author:      "",    // author (or writer, of a comic) of the document
+		Series:      "",    // name of the series the document belongs to
+		SeriesIndex: 0,     // position in its series, 0 if unknown
+		Pages:       0,     // number of pages, 0 if unknown
+	}
+}
+
+// function SeriesPosition() returns the document's series and its position in
+// it formatted for display, e.g. "Discworld #3", or an empty string if the
+// document isn't known to belong to any.
+func (m *DocumentMedia) SeriesPosition() string {
+	if "" == m.Series {
+		return ""
+	}
+	if m.SeriesIndex <= 0 {
+		return m.Series
+	}
+	return m.Series + " #" + strconv.FormatFloat(m.SeriesIndex, 'f', -1, 64)
+}
+
+// function ToRecord() creates a struct capable of being stored in the database.
+// defines type DocumentMedia's implementation of the StorableEntity interface.
+func (m *DocumentMedia) ToRecord() (*EntityRecord, *rc.ReturnCode) {
+
+	var (
+		record *EntityRecord = &EntityRecord{}
+		data   []byte
+		err    error
+	)
+
+	if data, err = json.Marshal(m); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Marshal(%s): cannot marshal DocumentMedia struct into JSON object: %s", m, err)
+	}
+
+	if err = json.Unmarshal(data, record); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into EntityRecord struct: %s", string(data), err)
+	}
+
+	return record, nil
+}
+
+// function FromRecord() creates a struct using the record stored in the
+// database. defines type DocumentMedia's implementation of the StorableEntity
+// interface.
+func (m *DocumentMedia) FromRecord(data []byte) *rc.ReturnCode {
+
+	// DocumentMedia has an embedded Media struct -pointer- (not struct). so if we
+	// create a zeroized DocumentMedia, the embedded Media will be a null pointer.
+	// we can protect this method from that null pointer by creating a zeroized
+	// Media and updating DocumentMedia's embedded pointer to reference it.
+	if nil == m.Media {
+		m.Media = &Media{}
+	}
+
+	// unmarshal our media object directly into the target
+	if err := json.Unmarshal(data, m); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"FromRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into DocumentMedia struct: %s", string(data), err)
+	}
+
+	// a record may unmarshal successfully and still be unusable, e.g. if any
+	// of the embedded structs or essential fields were missing.
+	if err := m.Entity.Validate(ClassMedia); nil != err {
+		return err
+	}
+	if KindDocument != m.Kind {
+		return rc.CorruptRecord.Specf(
+			"FromRecord(): media kind mismatch: %d (expected %d)", int(m.Kind), int(KindDocument))
+	}
+
+	return nil
+}
+
+// function FromID() creates a concrete DocumentMedia struct using the record
+// stored in the given collection with the given hash key id.
+func (m *DocumentMedia) FromID(col engine.Collection, id int) *rc.ReturnCode {
+
+	read, readErr := col.Read(id)
+	if nil != readErr {
+		return rc.DatabaseError.Specf(
+			"FromID(%v): Read(%d): cannot read record from database: %s",
+			col, id, readErr)
+	}
+
+	data, marshalErr := json.Marshal(read)
+	if nil != marshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Marshal(%s): cannot marshal query result into JSON object: %s",
+			col, read, marshalErr)
+	}
+
+	unmarshalErr := json.Unmarshal(data, m)
+	if nil != unmarshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Unmarshal(%s): cannot unmarshal JSON object into DocumentMedia struct: %s",
+			col, data, unmarshalErr)
+	}
+
+	return nil
+}
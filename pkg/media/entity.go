@@ -0,0 +1,285 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 06 Nov 2018
+//  FILE: entity.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines types related to file entities, which is the base type of all real
+//    files stored on disk (media files, subtitles, etc.).
+//
+// =============================================================================
+
+package media
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type EntityClass is an enum identifying the different types of file entities
+// stored in the persistent database.
+type EntityClass int
+
+// constant enum IDs for the various structs that embed/subclass Entity.
+const (
+	ClassUnknown  EntityClass = iota - 1 // = -1
+	ClassMedia                           // =  0
+	ClassSupport                         // =  1
+	ClassPlaylist                        // =  2
+	ClassSeries                          // =  3
+	ClassCOUNT                           // =  4
+)
+
+// type Entity is used to describe any sort of file encountered on the file
+// system. this includes not just audio/video media, but also subtitles and any
+// other auxiliary data files.
+type Entity struct {
+	Class        EntityClass // type of entity
+	AbsPath      string      `db:"index"` // absolute path to media file
+	AbsDir       string      `db:"index"` // directory portion of AbsPath
+	AbsName      string      `db:"index"` // file name portion of AbsPath
+	AbsBase      string      `db:"index"` // AbsName without file name extension
+	RelPath      string      // CWD-relative path to media file
+	Size         int64       // length in bytes for regular files; system-dependent for others
+	Mode         os.FileMode // file mode bits
+	TimeModified time.Time   // modification time
+	SysInfo      interface{} // underlying data source (can return nil)
+	Ext          string      `db:"index"` // file name extension
+	ExtName      string      // name of file type/encoding (per file name extension)
+	LinkTarget   string      // absolute path to which AbsPath resolves, if it is a symbolic link
+	Device       uint64      // ID of the device containing the file, 0 if unknown
+	Inode        uint64      // ID of the file on its device (inode number), 0 if unknown
+}
+
+// type EntityRecord represents the struct stored in the database for an
+// individual media or support record
+type EntityRecord map[string]interface{}
+
+// type EntityIndex is a slice in which each string element corresponds to the
+// name of the corresponding type's struct field used as a database column that
+// needs to be indexed for searching purposes.
+type EntityIndex []string
+
+// the struct tag marking each field of an entity indexed in the database, e.g.
+// `db:"index"` (see indexesOf()).
+const (
+	indexTagKey   = "db"
+	indexTagValue = "index"
+)
+
+// type StorableEntity defines the functions that must be defined for any struct
+// that embeds/subclasses Entity and supports storage in the database engine.
+// note that Entity itself does not implement these functions!
+type StorableEntity interface {
+	ToRecord() (*EntityRecord, *rc.ReturnCode)
+	FromRecord([]byte) *rc.ReturnCode
+	FromID(engine.Collection, int) *rc.ReturnCode
+}
+
+// storage for the names and database indices for each enum ID of the various
+// structs that embed/subclass Entity.
+var (
+	EntityColName = [ClassCOUNT][]string{
+		MediaColName[:],    // 0 = ClassMedia
+		SupportColName[:],  // 1 = ClassSupport
+		PlaylistColName[:], // 2 = ClassPlaylist
+		SeriesColName[:],   // 3 = ClassSeries
+	}
+	EntityIndexes = [ClassCOUNT][]*EntityIndex{
+		indexesOf(AudioMedia{}, VideoMedia{}, ImageMedia{}, DocumentMedia{}), // 0 = ClassMedia
+		indexesOf(Subtitles{}, Artwork{}, Metadata{}, Lyrics{}, CueSheet{}),  // 1 = ClassSupport
+		indexesOf(Playlist{}),         // 2 = ClassPlaylist
+		indexesOf(Series{}, Season{}), // 3 = ClassSeries
+	}
+)
+
+// function indexesOf() returns an EntityIndex of each field tagged with
+// `db:"index"` in the given structs and the structs they embed, in the order
+// declared and without duplicates. the collections of an entity class store
+// the records of several structs, so the indexes of a class are those of all
+// of its structs.
+func indexesOf(structs ...interface{}) []*EntityIndex {
+
+	index := []*EntityIndex{}
+	seen := map[string]bool{}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for reflect.Ptr == t.Kind() {
+			t = t.Elem()
+		}
+		if reflect.Struct != t.Kind() {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			// the fields of embedded structs are encoded into the record as
+			// though declared in the embedding struct.
+			if field.Anonymous {
+				walk(field.Type)
+				continue
+			}
+			for _, opt := range strings.Split(field.Tag.Get(indexTagKey), ",") {
+				if indexTagValue == opt && !seen[field.Name] {
+					seen[field.Name] = true
+					index = append(index, &EntityIndex{field.Name})
+				}
+			}
+		}
+	}
+	for _, s := range structs {
+		walk(reflect.TypeOf(s))
+	}
+	return index
+}
+
+// function NewEntity() creates a new file object that serves as the fundamental
+// type constituting any sort of file capable of being referenced on the file
+// system. this includes media files, supporting auxiliary files, etc.
+func NewEntity(class EntityClass, absPath, relPath, ext, extName string, info os.FileInfo) *Entity {
+
+	// the lack of file name extension abstracts any encoding info from the
+	// release name of the media, convenient for lookup via indexed queries.
+	absBase := strings.TrimSuffix(info.Name(), ext)
+
+	// the file IDs are retained to recognize the file if it is moved.
+	dev, ino, _ := platform.FileID(info)
+
+	return &Entity{
+		Class:        class,             // (EntityClass) type of entity
+		AbsPath:      absPath,           // (string)      absolute path to media file
+		AbsDir:       path.Dir(absPath), // (string)      directory portion of AbsPath
+		AbsName:      info.Name(),       // (string)      file name portion of AbsPath
+		AbsBase:      absBase,           // (string)      AbsName without file name extension
+		RelPath:      relPath,           // (string)      CWD-relative path to media file
+		Size:         info.Size(),       // (int64)       length in bytes for regular files; system-dependent for others
+		Mode:         info.Mode(),       // (os.FileMode) file mode bits
+		TimeModified: info.ModTime(),    // (time.Time)   modification time
+		SysInfo:      info.Sys(),        // (interface{}) underlying data source (can return nil)
+		Ext:          ext,               // (string)      file name extension
+		ExtName:      extName,           // (string)      name of file type/encoding (per file name extension)
+		Device:       dev,               // (uint64)      ID of the device containing the file, 0 if unknown
+		Inode:        ino,               // (uint64)      ID of the file on its device (inode number), 0 if unknown
+	}
+}
+
+// function Validate() verifies the fields of an Entity unmarshalled from the
+// database are sane enough to be used. malformed records may unmarshal without
+// error yet leave the embedded Entity nil or its essential fields zeroized, so
+// this should be called on every Entity loaded from a record.
+func (e *Entity) Validate(class EntityClass) *rc.ReturnCode {
+
+	if nil == e {
+		return rc.CorruptRecord.Spec("Validate(): missing entity info")
+	}
+	if class != e.Class {
+		return rc.CorruptRecord.Specf(
+			"Validate(): entity class mismatch: %d (expected %d)", int(e.Class), int(class))
+	}
+	if "" == e.AbsPath {
+		return rc.CorruptRecord.Spec("Validate(): missing absolute path")
+	}
+	return nil
+}
+
+// function Relocate() updates the path fields of an Entity whose file was moved
+// to the given absolute path. the file name extension must be unchanged.
+func (e *Entity) Relocate(absPath, relPath string) {
+	e.AbsPath = absPath
+	e.AbsDir = path.Dir(absPath)
+	e.AbsName = path.Base(absPath)
+	e.AbsBase = strings.TrimSuffix(e.AbsName, e.Ext)
+	e.RelPath = relPath
+}
+
+// function Changed() returns true if the given file info, read from the file
+// system, differs in size or modification time from the Entity, i.e. the file
+// was modified since the Entity was last updated.
+func (e *Entity) Changed(info os.FileInfo) bool {
+	return e.Size != info.Size() || !e.TimeModified.Equal(info.ModTime())
+}
+
+// function Refresh() updates the fields of an Entity which are read from the
+// file system using the given file info, e.g. after Changed() returns true.
+func (e *Entity) Refresh(info os.FileInfo) {
+	e.Size = info.Size()
+	e.Mode = info.Mode()
+	e.TimeModified = info.ModTime()
+	e.SysInfo = info.Sys()
+	e.Device, e.Inode, _ = platform.FileID(info)
+}
+
+// constant IDLength is the number of hex digits in the ID of an Entity.
+const IDLength = 10
+
+// function ID() returns the short identifier by which users refer to the
+// Entity on the command line, e.g. "pimmp play 3f2a9c01be". unlike database
+// record IDs, it is the same in every library and every run, since it is
+// derived from the absolute path (so it changes if the file is moved).
+func (e *Entity) ID() string {
+	sum := sha1.Sum([]byte(e.AbsPath))
+	return hex.EncodeToString(sum[:])[:IDLength]
+}
+
+// function String() creates a string representation of the Entity for easy
+// identification in logs.
+func (e *Entity) String() string {
+	path := e.AbsPath
+	if "" != e.RelPath && len(e.RelPath) < len(e.AbsPath) {
+		path = e.RelPath
+	}
+	return fmt.Sprintf("\"%s\" [%s (%s)] (%d bytes) %v",
+		path, e.ExtName, e.Ext, e.Size, e.TimeModified)
+}
+
+// function NewStorableEntity() creates a new entity of the concrete type
+// identified by the given class and kind. returns nil if the class and kind do
+// not identify a known type.
+func NewStorableEntity(class EntityClass, kind int, absPath, relPath, ext, extName string, info os.FileInfo) StorableEntity {
+
+	switch class {
+	case ClassMedia:
+		switch MediaKind(kind) {
+		case KindAudio:
+			return NewAudioMedia(absPath, relPath, ext, extName, info)
+		case KindVideo:
+			return NewVideoMedia(absPath, relPath, ext, extName, info)
+		case KindImage:
+			return NewImageMedia(absPath, relPath, ext, extName, info)
+		case KindDocument:
+			return NewDocumentMedia(absPath, relPath, ext, extName, info)
+		}
+	case ClassSupport:
+		switch SupportKind(kind) {
+		case SupportSubtitles:
+			return NewSubtitles(absPath, relPath, ext, extName, info)
+		case SupportArtwork:
+			return NewArtwork(absPath, relPath, ext, extName, info)
+		case SupportMetadata:
+			return NewMetadata(absPath, relPath, ext, extName, info)
+		case SupportLyrics:
+			return NewLyrics(absPath, relPath, ext, extName, info)
+		case SupportCueSheet:
+			return NewCueSheet(absPath, relPath, ext, extName, info)
+		}
+	case ClassPlaylist:
+		switch PlaylistKind(kind) {
+		case PlaylistStatic:
+			return NewPlaylistFile(absPath, relPath, ext, extName, info)
+		}
+	}
+	return nil
+}
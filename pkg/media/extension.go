@@ -0,0 +1,203 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: extension.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    extends or overrides the file name extensions identifying each kind of
+//    media and support file, e.g. from the configuration file.
+//
+// =============================================================================
+
+package media
+
+import (
+	"sort"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type extGroup is a set of ExtTables searched together for the kind of a file
+// (see MediaKindOfFileExt() and SupportKindOfFileExt()), so that an extension
+// must never appear in more than one of them.
+type extGroup map[string]*ExtTable
+
+// function extGroups() returns the ExtTables that may be customized, by the
+// name given to CustomizeExt(), in their groups. artwork shares the extensions
+// of images, but is identified by name as well (see ArtworkRole()), so it is a
+// group of its own.
+func extGroups() []extGroup {
+	return []extGroup{
+		{
+			"audio":    audioExt.table,
+			"video":    videoExt.table,
+			"image":    imageExt.table,
+			"document": documentExt.table,
+		},
+		{
+			"subtitles": subsExt.table,
+			"metadata":  nfoExt.table,
+			"lyrics":    lrcExt.table,
+			"cuesheet":  cueExt.table,
+		},
+		{
+			"artwork": artExt.table,
+		},
+	}
+}
+
+// type extChange is a single change to an ExtTable parsed by CustomizeExt().
+type extChange struct {
+	spec   string // change as given
+	table  string // name of the ExtTable changed
+	ext    string // file name extension added or removed, lowercase
+	name   string // name of the file type of an extension added
+	remove bool   // remove the extension rather than add it
+}
+
+// function CustomizeExt() changes the file name extensions identifying each
+// kind of media and support file as given, each change of the form
+// "kind:.ext" to add the extension, optionally followed by "=name" naming its
+// file type (its upper case extension by default), or "-kind:.ext" to remove
+// it, e.g. "audio:.dsf=DSD Stream File" or "-video:.ogg". the kinds are audio,
+// video, image, document, subtitles, metadata, lyrics, cuesheet, and artwork.
+// extensions are removed first, so one can be moved from a kind to another, but
+// none may identify more than one kind of media, nor of support file. nothing
+// changes unless all changes are valid.
+func CustomizeExt(change []string) *rc.ReturnCode {
+
+	if 0 == len(change) {
+		return nil
+	}
+	parsed := []extChange{}
+	for _, c := range change {
+		p, ret := parseExtChange(c)
+		if nil != ret {
+			return ret
+		}
+		parsed = append(parsed, p)
+	}
+	// removals first, so that an extension may be moved.
+	sort.SliceStable(parsed, func(a, b int) bool { return parsed[a].remove && !parsed[b].remove })
+
+	// work on copies of the tables, replacing the originals once all changes
+	// were applied without conflict.
+	groups := extGroups()
+	copied := []extGroup{}
+	for _, g := range groups {
+		c := extGroup{}
+		for name, table := range g {
+			t := ExtTable{}
+			for n, list := range *table {
+				t[n] = append([]string{}, list...)
+			}
+			c[name] = &t
+		}
+		copied = append(copied, c)
+	}
+
+	for _, p := range parsed {
+		var group extGroup
+		for _, g := range copied {
+			if _, ok := g[p.table]; ok {
+				group = g
+			}
+		}
+		if nil == group {
+			return rc.InvalidArgs.Specf("CustomizeExt(%q): unknown kind: %q (expected one of: %s)",
+				p.spec, p.table, strings.Join(extKindNames(), ", "))
+		}
+		table := group[p.table]
+		if p.remove {
+			if !removeExt(table, p.ext) {
+				return rc.InvalidArgs.Specf("CustomizeExt(%q): not a %s extension", p.spec, p.table)
+			}
+			continue
+		}
+		for other, t := range group {
+			if _, ok := kindOfFileExt(t, p.ext); ok {
+				if other == p.table {
+					return rc.InvalidArgs.Specf("CustomizeExt(%q): already a %s extension", p.spec, p.table)
+				}
+				return rc.InvalidArgs.Specf("CustomizeExt(%q): already a %s extension (remove it first: \"-%s:%s\")",
+					p.spec, other, other, p.ext)
+			}
+		}
+		(*table)[p.name] = append((*table)[p.name], p.ext)
+	}
+
+	for i, g := range groups {
+		for name, table := range g {
+			*table = *copied[i][name]
+		}
+	}
+	return nil
+}
+
+// function parseExtChange() parses a single change given to CustomizeExt().
+func parseExtChange(change string) (extChange, *rc.ReturnCode) {
+
+	c := extChange{spec: change}
+	s := strings.TrimSpace(change)
+	if strings.HasPrefix(s, "-") {
+		c.remove, s = true, s[1:]
+	}
+	i := strings.Index(s, ":")
+	if i < 0 {
+		return c, rc.InvalidArgs.Specf("CustomizeExt(%q): expected kind:.ext", change)
+	}
+	c.table, s = strings.ToLower(strings.TrimSpace(s[:i])), s[i+1:]
+	if i = strings.Index(s, "="); i >= 0 {
+		if c.remove {
+			return c, rc.InvalidArgs.Specf("CustomizeExt(%q): extensions removed have no name", change)
+		}
+		c.name, s = strings.TrimSpace(s[i+1:]), s[:i]
+	}
+	c.ext = strings.ToLower(strings.TrimSpace(s))
+	if !strings.HasPrefix(c.ext, ".") {
+		c.ext = "." + c.ext
+	}
+	if len(c.ext) < 2 || strings.ContainsAny(c.ext[1:], `./\ `) {
+		return c, rc.InvalidArgs.Specf("CustomizeExt(%q): invalid extension: %q", change, c.ext)
+	}
+	if "" == c.name {
+		c.name = strings.ToUpper(c.ext[1:])
+	}
+	return c, nil
+}
+
+// function removeExt() removes the given extension from the given ExtTable,
+// along with any file type left without extensions. returns false if it wasn't
+// found.
+func removeExt(table *ExtTable, ext string) bool {
+	for n, list := range *table {
+		for i, e := range list {
+			if e == ext {
+				list = append(list[:i:i], list[i+1:]...)
+				if 0 == len(list) {
+					delete(*table, n)
+				} else {
+					(*table)[n] = list
+				}
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// function extKindNames() returns the names of every kind whose ExtTable may
+// be customized, sorted.
+func extKindNames() []string {
+	name := []string{}
+	for _, g := range extGroups() {
+		for n := range g {
+			name = append(name, n)
+		}
+	}
+	sort.Strings(name)
+	return name
+}
@@ -0,0 +1,127 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: history.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the bounded edit history kept with each media record, so that
+//    changes to its metadata can be undone.
+//
+// =============================================================================
+
+package media
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+const (
+	// max number of edits kept in the history of each media record. the
+	// oldest edits are discarded first.
+	MaxHistory int = 32
+
+	// name of the struct field holding the edit history, which is never
+	// itself recorded in the history.
+	historyField = "History"
+)
+
+// type Edit records a single change to one field of a media record. the old
+// and new values are stored as they appear in the record's JSON encoding.
+type Edit struct {
+	Field string      // name of the field changed
+	Old   interface{} // value of the field before the change
+	New   interface{} // value of the field after the change
+	Time  time.Time   // time at which the change was made
+}
+
+// function DiffRecords() returns an Edit for each field whose value differs
+// between the two given records of the same media, sorted by field name.
+func DiffRecords(before, after *EntityRecord, when time.Time) []Edit {
+
+	field := map[string]bool{}
+	for k := range *before {
+		field[k] = true
+	}
+	for k := range *after {
+		field[k] = true
+	}
+	delete(field, historyField)
+
+	edit := []Edit{}
+	for k := range field {
+		was, now := (*before)[k], (*after)[k]
+		if !reflect.DeepEqual(was, now) {
+			edit = append(edit, Edit{Field: k, Old: was, New: now, Time: when})
+		}
+	}
+	sort.Slice(edit, func(a, b int) bool { return edit[a].Field < edit[b].Field })
+	return edit
+}
+
+// function AddHistory() appends the given edits to the media's history,
+// discarding the oldest edits if the history would exceed MaxHistory.
+func (m *Media) AddHistory(edit ...Edit) {
+	m.History = append(m.History, edit...)
+	if over := len(m.History) - MaxHistory; over > 0 {
+		m.History = append([]Edit{}, m.History[over:]...)
+	}
+}
+
+// function EditedAt() returns the time at which the named field of the media
+// was last changed, according to its history. returns false if the history
+// holds no change to the field, e.g. if it was never edited or its edits were
+// discarded.
+func (m *Media) EditedAt(field string) (time.Time, bool) {
+	for i := len(m.History) - 1; i >= 0; i-- {
+		if field == m.History[i].Field {
+			return m.History[i].Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// function PopHistory() removes and returns the most recent change from the
+// media's history. a change consists of all edits made at the same time, e.g.
+// every field updated by a single import. returns an empty list if there is
+// no history.
+func (m *Media) PopHistory() []Edit {
+	n := len(m.History)
+	if 0 == n {
+		return []Edit{}
+	}
+	last := m.History[n-1].Time
+	i := n - 1
+	for i > 0 && m.History[i-1].Time.Equal(last) {
+		i--
+	}
+	edit := append([]Edit{}, m.History[i:]...)
+	m.History = m.History[:i]
+	return edit
+}
+
+// function RevertRecord() returns the JSON encoding of the given record with
+// each of the given edits reverted, i.e. with each field restored to its old
+// value. edits are reverted in reverse order.
+func RevertRecord(rec *EntityRecord, edit []Edit) ([]byte, *rc.ReturnCode) {
+
+	for i := len(edit) - 1; i >= 0; i-- {
+		if nil == edit[i].Old {
+			delete(*rec, edit[i].Field)
+		} else {
+			(*rec)[edit[i].Field] = edit[i].Old
+		}
+	}
+	data, err := json.Marshal(rec)
+	if nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"RevertRecord(): json.Marshal(): cannot marshal reverted record: %s", err)
+	}
+	return data, nil
+}
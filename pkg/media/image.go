@@ -0,0 +1,173 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: image.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the media type of photos and other still images, so that photo
+//    libraries can be managed alongside audio and video.
+//
+// =============================================================================
+
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type ImageMedia is a specialized type of media containing struct fields
+// relevant only to photos and other still images. the fields read from the
+// image's EXIF data are left zero if it has none (see package exif).
+type ImageMedia struct {
+	*Media        // common media info
+	Width  int64  // width in pixels, 0 if unknown
+	Height int64  // height in pixels, 0 if unknown
+	Camera string // make and model of the camera that took the photo
+	// date the photo was taken (EXIF DateTimeOriginal), zero if unknown. it is
+	// the photographer's local time, since EXIF rarely records the zone.
+	Taken time.Time
+}
+
+var (
+	// var imageExt is a struct defining how KindImage media files will be
+	// identified through file name inspection (see discussion of audioExt).
+	// the raw formats of cameras are included, since a photo library often
+	// keeps them beside (or instead of) the developed JPEG.
+	imageExt = MediaExt{
+		kind: KindImage,
+		table: &ExtTable{
+			"Bitmap":                       []string{".bmp"},
+			"Canon Raw":                    []string{".cr2", ".cr3", ".crw"},
+			"Digital Negative":             []string{".dng"},
+			"Fujifilm Raw":                 []string{".raf"},
+			"Graphics Interchange Format":  []string{".gif"},
+			"High Efficiency Image Format": []string{".heic", ".heif"},
+			"JPEG":                         []string{".jpg", ".jpeg", ".jpe"},
+			"Nikon Raw":                    []string{".nef", ".nrw"},
+			"Olympus Raw":                  []string{".orf"},
+			"Panasonic Raw":                []string{".rw2"},
+			"Pentax Raw":                   []string{".pef"},
+			"Portable Network Graphics":    []string{".png"},
+			"Sony Raw":                     []string{".arw", ".srf", ".sr2"},
+			"Tagged Image File Format":     []string{".tif", ".tiff"},
+			"WebP":                         []string{".webp"},
+		},
+	}
+)
+
+// function NewImageMedia() creates and initializes a new ImageMedia object
+// by invoking the embedded types' constructors and then populating the unique
+// specialization fields.
+func NewImageMedia(absPath, relPath, ext, extName string, info os.FileInfo) *ImageMedia {
+
+	media := NewMedia(KindImage, absPath, relPath, ext, extName, info)
+
+	return &ImageMedia{
+		Media:  media, // common media info
+		Width:  0,     // width in pixels, 0 if unknown
+		Height: 0,     // height in pixels, 0 if unknown
+		Camera: "",    // make and model of the camera that took the photo
+	}
+}
+
+// function Dimensions() returns the size of the image formatted for display,
+// e.g. "4032x3024", or an empty string if unknown.
+func (m *ImageMedia) Dimensions() string {
+	if m.Width <= 0 || m.Height <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", m.Width, m.Height)
+}
+
+// function ToRecord() creates a struct capable of being stored in the database.
+// defines type ImageMedia's implementation of the StorableEntity interface.
+func (m *ImageMedia) ToRecord() (*EntityRecord, *rc.ReturnCode) {
+
+	var (
+		record *EntityRecord = &EntityRecord{}
+		data   []byte
+		err    error
+	)
+
+	if data, err = json.Marshal(m); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Marshal(%s): cannot marshal ImageMedia struct into JSON object: %s", m, err)
+	}
+
+	if err = json.Unmarshal(data, record); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into EntityRecord struct: %s", string(data), err)
+	}
+
+	return record, nil
+}
+
+// function FromRecord() creates a struct using the record stored in the
+// database. defines type ImageMedia's implementation of the StorableEntity
+// interface.
+func (m *ImageMedia) FromRecord(data []byte) *rc.ReturnCode {
+
+	// ImageMedia has an embedded Media struct -pointer- (not struct). so if we
+	// create a zeroized ImageMedia, the embedded Media will be a null pointer.
+	// we can protect this method from that null pointer by creating a zeroized
+	// Media and updating ImageMedia's embedded pointer to reference it.
+	if nil == m.Media {
+		m.Media = &Media{}
+	}
+
+	// unmarshal our media object directly into the target
+	if err := json.Unmarshal(data, m); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"FromRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into ImageMedia struct: %s", string(data), err)
+	}
+
+	// a record may unmarshal successfully and still be unusable, e.g. if any
+	// of the embedded structs or essential fields were missing.
+	if err := m.Entity.Validate(ClassMedia); nil != err {
+		return err
+	}
+	if KindImage != m.Kind {
+		return rc.CorruptRecord.Specf(
+			"FromRecord(): media kind mismatch: %d (expected %d)", int(m.Kind), int(KindImage))
+	}
+
+	return nil
+}
+
+// function FromID() creates a concrete ImageMedia struct using the record
+// stored in the given collection with the given hash key id.
+func (m *ImageMedia) FromID(col engine.Collection, id int) *rc.ReturnCode {
+
+	read, readErr := col.Read(id)
+	if nil != readErr {
+		return rc.DatabaseError.Specf(
+			"FromID(%v): Read(%d): cannot read record from database: %s",
+			col, id, readErr)
+	}
+
+	data, marshalErr := json.Marshal(read)
+	if nil != marshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Marshal(%s): cannot marshal query result into JSON object: %s",
+			col, read, marshalErr)
+	}
+
+	unmarshalErr := json.Unmarshal(data, m)
+	if nil != unmarshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Unmarshal(%s): cannot unmarshal JSON object into ImageMedia struct: %s",
+			col, data, unmarshalErr)
+	}
+
+	return nil
+}
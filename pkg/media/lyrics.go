@@ -0,0 +1,171 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: lyrics.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the support type of lyrics files (.lrc, or plain text named like
+//    the track) accompanying audio media.
+//
+// =============================================================================
+
+package media
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Lyrics is a specialized type of support for lyrics files, whose content
+// is the lyrics of the audio track of the same base name (see package lyrics
+// for the formats).
+type Lyrics struct {
+	*Support // common support info
+}
+
+// constant lyricsTextName is the type/encoding name of plain text lyrics.
+const lyricsTextName = "Text"
+
+var (
+	// var lrcExt is a struct defining how SupportLyrics support files will be
+	// identified through file name inspection. plain text lyrics aren't listed,
+	// since they are only identified by the audio beside them (see
+	// IsLyricsText()).
+	lrcExt = SupportExt{
+		kind: SupportLyrics,
+		table: &ExtTable{
+			"LRC": []string{".lrc"},
+		},
+	}
+)
+
+// function IsLyricsText() returns true if the file at the given path is plain
+// text (.txt) sharing its base name with an audio file in the same directory,
+// e.g. "Song.txt" beside "Song.mp3", in which case it is taken to be the
+// song's lyrics rather than any other text.
+func IsLyricsText(absPath string) bool {
+
+	ext := filepath.Ext(absPath)
+	if ".txt" != strings.ToLower(ext) {
+		return false
+	}
+	base := strings.TrimSuffix(absPath, ext)
+	for _, list := range *audioExt.table {
+		for _, e := range list {
+			for _, name := range []string{base + e, base + strings.ToUpper(e)} {
+				if info, err := os.Stat(name); nil == err && info.Mode().IsRegular() {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// function NewLyrics() creates and initializes a new Lyrics object by invoking
+// the embedded types' constructors and then populating any unique
+// specialization fields.
+func NewLyrics(absPath, relPath, ext, extName string, info os.FileInfo) *Lyrics {
+
+	support := NewSupport(SupportLyrics, absPath, relPath, ext, extName, info)
+
+	return &Lyrics{
+		Support: support, // common support info
+	}
+}
+
+// function Describes() returns true if the Lyrics are those of the audio with
+// the given base name in the given directory, i.e. they share both (ignoring
+// the case of the name).
+func (l *Lyrics) Describes(dir, base string) bool {
+	return dir == l.AbsDir && strings.EqualFold(base, l.AbsBase)
+}
+
+// function ToRecord() creates a struct capable of being stored in the database.
+// defines type Lyrics's implementation of the StorableEntity interface.
+func (l *Lyrics) ToRecord() (*EntityRecord, *rc.ReturnCode) {
+
+	var (
+		record *EntityRecord = &EntityRecord{}
+		data   []byte
+		err    error
+	)
+
+	if data, err = json.Marshal(l); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Marshal(%s): cannot marshal Lyrics struct into JSON object: %s", l, err)
+	}
+
+	if err = json.Unmarshal(data, record); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into EntityRecord struct: %s", string(data), err)
+	}
+
+	return record, nil
+}
+
+// function FromRecord() creates a struct using the record stored in the
+// database. defines type Lyrics's implementation of the StorableEntity
+// interface.
+func (l *Lyrics) FromRecord(data []byte) *rc.ReturnCode {
+
+	// guard the embedded Support struct pointer (see Subtitles.FromRecord()).
+	if nil == l.Support {
+		l.Support = &Support{}
+	}
+
+	if err := json.Unmarshal(data, l); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"FromRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into Lyrics struct: %s", string(data), err)
+	}
+
+	if err := l.Entity.Validate(ClassSupport); nil != err {
+		return err
+	}
+	if SupportLyrics != l.Kind {
+		return rc.CorruptRecord.Specf(
+			"FromRecord(): support kind mismatch: %d (expected %d)", int(l.Kind), int(SupportLyrics))
+	}
+
+	return nil
+}
+
+// function FromID() creates a concrete Lyrics struct using the record stored
+// in the given collection with the given hash key id.
+func (l *Lyrics) FromID(col engine.Collection, id int) *rc.ReturnCode {
+
+	read, readErr := col.Read(id)
+	if nil != readErr {
+		return rc.DatabaseError.Specf(
+			"FromID(%v): Read(%d): cannot read record from database: %s",
+			col, id, readErr)
+	}
+
+	data, marshalErr := json.Marshal(read)
+	if nil != marshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Marshal(%s): cannot marshal query result into JSON object: %s",
+			col, read, marshalErr)
+	}
+
+	if nil == l.Support {
+		l.Support = &Support{}
+	}
+	unmarshalErr := json.Unmarshal(data, l)
+	if nil != unmarshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Unmarshal(%s): cannot unmarshal JSON object into Lyrics struct: %s",
+			col, data, unmarshalErr)
+	}
+
+	return nil
+}
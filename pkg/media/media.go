@@ -0,0 +1,775 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 26 Sept 2018
+//  FILE: media.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines types related to media and their auxiliary files and also provides
+//    subroutines for inspecting and classifying media files.
+//
+// =============================================================================
+
+// package media defines the file entities stored in a library (audio, video,
+// subtitles, etc.) and how files are classified into them.
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/naming"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type MediaKind is an enum identifying the different supported types of
+// playable media.
+type MediaKind int
+
+const (
+	KindUnknown  MediaKind = iota - 1 // = -1
+	KindAudio                         // =  0
+	KindVideo                         // =  1
+	KindImage                         // =  2
+	KindDocument                      // =  3
+	KindCOUNT                         // =  4
+)
+
+// constant MaxRating is the highest rating a user may assign to media.
+const MaxRating = 10
+
+var (
+	// variable MediaColName maps the MediaKind enum values to the string name
+	// of their corresponding collection in the database.
+	MediaColName = [KindCOUNT]string{
+		"Audio",    // 0 = KindAudio
+		"Video",    // 1 = KindVideo
+		"Image",    // 2 = KindImage
+		"Document", // 3 = KindDocument
+	}
+)
+
+// function ParseMediaKind() returns the MediaKind with the given name (ignoring
+// case), e.g. "video", and false if there is none.
+func ParseMediaKind(name string) (MediaKind, bool) {
+	for k, n := range MediaColName {
+		if strings.EqualFold(n, strings.TrimSpace(name)) {
+			return MediaKind(k), true
+		}
+	}
+	return KindUnknown, false
+}
+
+// type Media is used to reference every kind of playable media -- the struct
+// fields are common among audio, video, images, and documents.
+type Media struct {
+	// fixed, read-only system info
+	*Entity           // common entity info
+	Kind    MediaKind // type of media
+	// location within an audio image file of a track of a cue sheet (see
+	// TrackPath()), zeroized for media that are files of their own
+	ImageFile string        // path of the file containing the track
+	Start     time.Duration // offset into ImageFile at which the track starts
+	End       time.Duration // offset into ImageFile at which the track ends, 0 if with the file
+	// files of a release split into several parts, e.g. "CD1" and "CD2" (see
+	// Files()), empty for media in a single file
+	Parts  []string // paths of every part, in order, recorded by the first part
+	PartOf string   // path of the first part, recorded by each of the others
+	// user-writable system info
+	Name            string    // displayed name
+	TimeAdded       time.Time // date media was discovered and added to library
+	DateAdded       string    `db:"index"` // local date of TimeAdded (see DateOf()), by which media are found by day
+	PlaybackCommand string    // full system command used to play media
+	// playback history
+	PlayCount      int64         // number of times media was played to completion
+	LastPlayed     time.Time     // date media was last played
+	ResumePosition time.Duration // offset at which playback was last stopped
+	Duration       time.Duration // length of the media, 0 if unknown
+	Watched        bool          // media was played to completion at least once
+	// user-writable public media info
+	Title       string            `db:"index"` // official name of media
+	Description string            // synopsis/summary of media content
+	ReleaseDate time.Time         // date media was produced/released
+	Artwork     map[string]string // path or URL of artwork, keyed by kind (poster, fanart, etc.)
+	ArtworkFile string            // artwork file found beside the media (see type Artwork), empty if none
+	Tags        []string          `db:"index"` // user-assigned tags, e.g. for grouping into collections
+	Rating      int64             // user-assigned rating, from 1 to MaxRating (0 = unrated)
+	Genres      []string          // genres of the media content, e.g. "Comedy" or "Jazz"
+	// parental guidance
+	ContentRating string // official content/age rating, e.g. "PG-13" or "TV-MA"
+	// integrity verification (not edits, so never recorded in History)
+	Checksum    string    // SHA-256 digest (hex) of the file content when last verified
+	Verified    time.Time // date the file was last verified
+	VerifyError string    // reason the last verification failed, empty if it passed
+	// content hash of the file (see package contenthash), by which copies of
+	// it are recognized; empty if not computed
+	Hash string
+	// changes made to the fields above, oldest first (see AddHistory())
+	History []Edit
+}
+
+// type AudioMedia is a specialized type of media containing struct fields
+// relevant only to video.
+type AudioMedia struct {
+	*Media        // common media info
+	Artist string // performer of the track
+	Album  string // name of the album on which the track appears
+	Track  int64  // numbered index of where track is located on album
+	Lyrics string // lyrics file of the track (see type Lyrics), empty if none
+}
+
+// type VideoMedia is a specialized type of media containing struct fields
+// relevant only to audio.
+type VideoMedia struct {
+	*Media                     // common media info
+	KnownSubtitles []Subtitles // absolute path to all associated subtitles
+	Subtitles      Subtitles   // absolute path to selected subtitles
+	// description parsed from the file name (see ParseName())
+	Series      string // name of the TV series of an episode (empty for movies)
+	Season      int64  // season number of an episode
+	Episode     int64  // (first) episode number of an episode within its season
+	LastEpisode int64  // last episode number of a multi-episode file (= Episode otherwise)
+	Year        int64  // year of release of a movie or series (0 if unknown)
+	SeriesKey   string // key of the Series record of an episode (see SeriesKey())
+	SeasonKey   string `db:"index"` // key of the Season record of an episode (see SeasonKey())
+	// technical info, read from the file's streams (see SetProbe() of Library)
+	Container      string  // container format, e.g. "matroska" or "mov"
+	Width          int64   // frame width in pixels of the primary video stream
+	Height         int64   // frame height in pixels of the primary video stream
+	VideoCodec     string  // codec of the primary video stream, e.g. "h264"
+	AudioCodec     string  // codec of the primary audio stream, e.g. "aac"
+	AudioTracks    []Track // all audio streams embedded in the file
+	SubtitleTracks []Track // all subtitle streams embedded in the file
+}
+
+// type Track describes a single audio or subtitle stream embedded in a video
+// file.
+type Track struct {
+	Index    int    // index of the stream in the file
+	Codec    string // codec of the stream, e.g. "ac3" or "subrip"
+	Language string // language of the stream (ISO 639-2, e.g. "eng"), if tagged
+	Title    string // descriptive title of the stream, if tagged
+	Default  bool   // stream is selected by default during playback
+}
+
+// function String() returns a brief description of the track, e.g. "eng ac3
+// (Director's Commentary)".
+func (t Track) String() string {
+	s := t.Codec
+	if "" != t.Language {
+		s = fmt.Sprintf("%s %s", t.Language, s)
+	}
+	if "" != t.Title {
+		s = fmt.Sprintf("%s (%s)", s, t.Title)
+	}
+	if t.Default {
+		s = fmt.Sprintf("%s*", s)
+	}
+	return s
+}
+
+// function Resolution() returns the frame size of the video, e.g. "1920x1080",
+// or the empty string if unknown.
+func (m *VideoMedia) Resolution() string {
+	if m.Width <= 0 || m.Height <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", m.Width, m.Height)
+}
+
+// function NewMedia() creates and initializes a new Media object by invoking
+// the embedded types' constructors and then populating any unique
+// specialization fields.
+func NewMedia(kind MediaKind, absPath, relPath, ext, extName string, info os.FileInfo) *Media {
+
+	entity := NewEntity(ClassMedia, absPath, relPath, ext, extName, info)
+	added := time.Now()
+
+	m := &Media{
+		Entity:          entity,      // (*Entity)   common entity info
+		Kind:            kind,        // (MediaKind) type of media
+		Name:            info.Name(), // (string)    displayed name
+		TimeAdded:       added,       // (time.Time) date media was discovered and added to library
+		PlaybackCommand: "--",        // (string)    full system command used to play media
+		PlayCount:       0,           // (int64)     number of times media was played to completion
+		LastPlayed:      time.Time{}, // (time.Time) date media was last played
+		ResumePosition:  0,           // (time.Duration) offset at which playback was last stopped
+		Duration:        0,           // (time.Duration) length of the media, 0 if unknown
+		Watched:         false,       // (bool)      media was played to completion at least once
+		Title:           info.Name(), // (string)    official name of media
+		Description:     "--",        // (string)    synopsis/summary of media content
+		ReleaseDate:     time.Time{}, // (time.Time) date media was produced/released
+		Rating:          0,           // (int64)     user-assigned rating (0 = unrated)
+	}
+	m.DateAdded = DateOf(added)
+	return m
+}
+
+// constant DateLayout is the format of the dates of media, e.g. DateAdded.
+const DateLayout = "2006-01-02"
+
+// function DateOf() returns the local date of the given time, in the format of
+// the dates of media (see DateLayout), or the empty string if it is zero.
+func DateOf(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(DateLayout)
+}
+
+// function File() returns the path of the file containing the media: its
+// ImageFile if it is a track of a cue sheet, or else its AbsPath.
+func (m *Media) File() string {
+	if "" != m.ImageFile {
+		return m.ImageFile
+	}
+	return m.AbsPath
+}
+
+// function Files() returns the paths of the files played, in order: those of
+// every part of a release split into several (see Parts), or else just File().
+func (m *Media) Files() []string {
+	if len(m.Parts) > 1 {
+		return append([]string{}, m.Parts...)
+	}
+	return []string{m.File()}
+}
+
+// function IsPart() returns true if the media is a part, other than the first,
+// of a release split into several, which is listed and played as its first
+// part (see Files()).
+func (m *Media) IsPart() bool { return "" != m.PartOf }
+
+// function IsTrack() returns true if the media is a track of a cue sheet, i.e.
+// a part of the file containing it rather than the whole (see File()).
+func (m *Media) IsTrack() bool { return "" != m.ImageFile }
+
+// function InProgress() returns true if playback of the media was last stopped
+// before reaching its end, so that it can be continued from there.
+func (m *Media) InProgress() bool {
+	return m.ResumePosition > 0
+}
+
+// function Matches() returns true if the media's title, name, or path contains
+// the given text, ignoring case. every media matches the empty string.
+func (m *Media) Matches(text string) bool {
+	if "" == text {
+		return true
+	}
+	text = strings.ToLower(text)
+	for _, s := range []string{m.Title, m.Name, m.AbsPath} {
+		if strings.Contains(strings.ToLower(s), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// function HasTags() returns true if the media has been assigned every one of
+// the given tags, ignoring case.
+func (m *Media) HasTags(tag ...string) bool {
+	for _, t := range tag {
+		found := false
+		for _, u := range m.Tags {
+			if strings.EqualFold(t, u) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// function AddTag() assigns the given tag to the media, unless already assigned
+// (ignoring case). returns true if it was added.
+func (m *Media) AddTag(tag string) bool {
+	if "" == tag || m.HasTags(tag) {
+		return false
+	}
+	m.Tags = append(m.Tags, tag)
+	return true
+}
+
+// function RemoveTag() unassigns the given tag (ignoring case) from the media.
+// returns true if it was removed.
+func (m *Media) RemoveTag(tag string) bool {
+	for i, t := range m.Tags {
+		if strings.EqualFold(tag, t) {
+			m.Tags = append(m.Tags[:i:i], m.Tags[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// function SetRating() changes the rating of the media, limited to between 0
+// (unrated) and MaxRating. returns true if it was changed.
+func (m *Media) SetRating(rating int64) bool {
+	switch {
+	case rating < 0:
+		rating = 0
+	case rating > MaxRating:
+		rating = MaxRating
+	}
+	if rating == m.Rating {
+		return false
+	}
+	m.Rating = rating
+	return true
+}
+
+// function NewAudioMedia() creates and initializes a new AudioMedia object
+// by invoking the embedded types' constructors and then populating the unique
+// specialization fields.
+func NewAudioMedia(absPath, relPath, ext, extName string, info os.FileInfo) *AudioMedia {
+
+	media := NewMedia(KindAudio, absPath, relPath, ext, extName, info)
+
+	return &AudioMedia{
+		Media:  media, // common media info
+		Artist: "",    // performer of the track
+		Album:  "",    // name of the album on which the track appears
+		Track:  -1,    // numbered index of where track is located on album
+	}
+}
+
+// function NewVideoMedia() creates and initializes a new VideoMedia object
+// by invoking the embedded types' constructors and then populating the unique
+// specialization fields.
+func NewVideoMedia(absPath, relPath, ext, extName string, info os.FileInfo) *VideoMedia {
+
+	media := NewMedia(KindVideo, absPath, relPath, ext, extName, info)
+
+	video := &VideoMedia{
+		Media:          media,         // common media info
+		KnownSubtitles: []Subtitles{}, // absolute path to all associated subtitles
+		Subtitles:      Subtitles{},   // absolute path to selected subtitles
+		AudioTracks:    []Track{},     // all audio streams embedded in the file
+		SubtitleTracks: []Track{},     // all subtitle streams embedded in the file
+	}
+	video.ParseName()
+	return video
+}
+
+// function ParseName() sets the series, season, episode, and year of the video
+// to those parsed from its file name (see package naming), or the name of its
+// directory if it is a disc (see IsDisc()), clearing any the name doesn't
+// define. the number of a part of a multi-part release is ignored.
+func (m *VideoMedia) ParseName() {
+	name := m.AbsBase
+	if m.IsDisc() {
+		name = filepath.Base(m.AbsDir)
+	}
+	name, _ = naming.ParsePart(name)
+	n := naming.Parse(name)
+	m.Series = n.Series
+	m.Season, m.Episode, m.LastEpisode = int64(n.Season), int64(n.Episode), int64(n.LastEpisode)
+	m.Year = int64(n.Year)
+	m.UpdateKeys()
+}
+
+// function UpdateKeys() sets the keys of the Series and Season records of the
+// video to match its series, season, and year, e.g. after they were changed by
+// something other than ParseName(). the keys of a movie are empty.
+func (m *VideoMedia) UpdateKeys() {
+	m.SeriesKey, m.SeasonKey = "", ""
+	if m.IsEpisode() {
+		m.SeriesKey = SeriesKey(m.Series, m.Year)
+		m.SeasonKey = SeasonKey(m.SeriesKey, m.Season)
+	}
+}
+
+// function IsEpisode() returns true if the video's file name identifies it as
+// an episode of a TV series.
+func (m *VideoMedia) IsEpisode() bool { return "" != m.Series }
+
+func (m *VideoMedia) String() string {
+	s := m.Entity.String()
+	if len(m.KnownSubtitles) > 0 {
+		t := ""
+		for i, u := range m.KnownSubtitles {
+			if i > 0 {
+				t = fmt.Sprintf("%s, ", t)
+			}
+			t = fmt.Sprintf("%s[%d:\"%s\"]", t, i, u.RelPath)
+		}
+		s = fmt.Sprintf("%s Subtitles:{%s}", s, t)
+	}
+	return s
+}
+
+// function AddSubtitles() adds the given Subtitles to this VideoMedia object
+// if and only if the subs do not already exist in the object's list of known
+// subtitles. additionally, the subs are optionally set as the preferred subs to
+// be used during playback; the database record of this video is also optionally
+// updated to store the subs in the list of known subtitles.
+func (m *VideoMedia) AddSubtitles(vidCol, subCol engine.Collection, vidID, subID int, update, preferred bool, subs *Subtitles) (bool, *rc.ReturnCode) {
+
+	var (
+		rec     *EntityRecord
+		recErr  *rc.ReturnCode
+		subSeen bool
+	)
+
+	// walk the current list of known subtitles, setting a flag if we have
+	// already seen this one before.
+	for _, s := range m.KnownSubtitles {
+		if s.AbsPath == subs.AbsPath {
+			subSeen = true
+			break
+		}
+	}
+	// append it to the list if we haven't seen it before.
+	if !subSeen {
+		m.KnownSubtitles = append(m.KnownSubtitles, *subs)
+	}
+	// and update the actively selected subtitles if desired.
+	if preferred {
+		m.Subtitles = *subs
+	}
+
+	// update the database record of this VideoMedia to include the new
+	// subtitles association. any subsequent queries should thus include it.
+	if rec, recErr = m.ToRecord(); nil != recErr {
+		return false, recErr
+	}
+	if update {
+		if err := vidCol.Update(vidID, *rec); nil != err {
+			return false, rc.DatabaseError.Specf(
+				"AddSubtitles(%v, %d, %s): failed to update record: %s", vidCol, vidID, *subs, err)
+		}
+	}
+
+	if ok, err := subs.AddVideoMedia(subCol, subID, update, m); !ok {
+		return false, err
+	}
+
+	// return true if and only if we added this subtitles reference to the list.
+	// return(ed) false if we've either seen it before or if there was an error
+	// somewhere (e.g. updating the database).
+	return !subSeen, nil
+}
+
+// type ExtTable is a mapping of the name of file types to their common file
+// name extensions.
+type ExtTable map[string][]string
+
+// function kindOfFileExt() searches a given ExtTable for the provided extension
+// string, returning both the name of the encoding and a boolean flag indicating
+// whether or not it was found in the table.
+func kindOfFileExt(table *ExtTable, ext string) (string, bool) {
+	// iter: each entry in current media's file extension table
+	for n, l := range *table {
+		// iter: each file extension in current table entry
+		for _, e := range l {
+			// cond: wanted file extension matches current file extension
+			if e == ext {
+				// return: current media kind, file type of extension
+				return n, true
+			}
+		}
+	}
+	return "", false
+}
+
+// type MediaExt is a struct pairing MediaKind values to their corresponding
+// ExtTable map.
+type MediaExt struct {
+	kind  MediaKind
+	table *ExtTable
+}
+
+var (
+	// var audioExt is a struct defining how KindAudio media files will be
+	// identified through file name inspection. if a file name extension matches
+	// at least one string in any of the string slices below, then that file is
+	// assumed to be KindAudio. the audio type/encoding of that file is also
+	// assumed to be the map key corresponding to the matching string slice.
+	audioExt = MediaExt{
+		kind: KindAudio,
+		table: &ExtTable{
+			"3D Solar UK Ltd":               []string{".ivs"},
+			"ACT Lossy ADPCM":               []string{".act"},
+			"Adaptive Multi-Rate":           []string{".amr"},
+			"Adaptive Multi-Rate Wideband":  []string{".awb"},
+			"Advanced Audio Coding":         []string{".aac"},
+			"Apple AIFF":                    []string{".aiff"},
+			"Audible Audiobook":             []string{".aa", ".aax"},
+			"Dialogic ADPCM":                []string{".vox"},
+			"Digital Speech Standard":       []string{".dss"},
+			"Electronic Arts IFF-8SVX":      []string{".8svx"},
+			"Free Lossless Audio Codec":     []string{".flac"},
+			"GSM Telephony":                 []string{".gsm"},
+			"iKlax Media":                   []string{".iklax"},
+			"Linear PCM":                    []string{".sln"},
+			"Microsoft WAV":                 []string{".wav"},
+			"Microsoft Windows Media Audio": []string{".wma"},
+			"Monkey's Audio":                []string{".ape"},
+			"MPEG Layer III":                []string{".mp3"},
+			"MPEG-4 Part 14":                []string{".m4a", ".m4b"},
+			"Musepack/MPC/MPEG":             []string{".mpc"},
+			"NCH Dictation":                 []string{".dct"},
+			"Nintendo (NES) Sound Format":   []string{".nsf"},
+			"Ogg Audio":                     []string{".oga", ".mogg"},
+			"Opus":                          []string{".opus"},
+			"RAW Audio Format":              []string{".raw"},
+			"RealAudio":                     []string{".ra"},
+			"Samsung Yamaha Ringtone":       []string{".mmf"},
+			"Sony Compressed Voice":         []string{".dvf", ".msv"},
+			"Sun/Unix/Java Audio":           []string{".au"},
+			"True Audio Lossless":           []string{".tta"},
+			"WavPack":                       []string{".wv"},
+		},
+	}
+	// var videoExt is a struct defining how KindAudio media files will be
+	// identified through file name inspection. see discussion of audioExt
+	// above. the same assumptions are made here but with KindVideo instead.
+	videoExt = MediaExt{
+		kind: KindVideo,
+		table: &ExtTable{
+			"3GPP":                              []string{".3gp"},
+			"3GPP2":                             []string{".3g2"},
+			"Advanced Systems Format":           []string{".asf"},
+			"AMV video format":                  []string{".amv"},
+			"Audio Video Interleave":            []string{".avi"},
+			"Dirac":                             []string{".drc"},
+			"Disc Image":                        []string{".iso"},
+			"Flash Video":                       []string{".flv", ".f4v", ".f4p", ".f4a", ".f4b"},
+			"Graphics Interchange Format Video": []string{".gifv"},
+			"Material Exchange Format":          []string{".mxf"},
+			"Matroska":                          []string{".mkv"},
+			"MPEG Transport Stream":             []string{".mts", ".m2ts"},
+			"MPEG-1":                            []string{".mp2", ".mpe", ".mpv"},
+			"MPEG-1/MPEG-2":                     []string{".mpg", ".mpeg"},
+			"MPEG-2":                            []string{".m2v"},
+			"MPEG-4 Part 14":                    []string{".mp4", ".m4p", ".m4v"},
+			"Multiple-image Network Graphics":   []string{".mng"},
+			"Nullsoft Streaming Video":          []string{".nsv"},
+			"Ogg Video":                         []string{".ogv", ".ogg"},
+			"QuickTime File Format":             []string{".mov", ".qt"},
+			"Raw video format":                  []string{".yuv"},
+			"RealMedia":                         []string{".rm"},
+			"RealMedia Variable Bitrate":        []string{".rmvb"},
+			"RoQ FMV":                           []string{".roq"},
+			"Standardized Video Interview":      []string{".svi"},
+			"Video Object":                      []string{".vob"},
+			"WebM":                              []string{".webm"},
+			"Windows Media Video":               []string{".wmv"},
+		},
+	}
+)
+
+// function MediaKindOfFileExt() searches all MediaExt mappings for a given
+// file name extension, returning both the MediaKind and the type/encoding name
+// associated with that file name extension.
+func MediaKindOfFileExt(ext string) (MediaKind, string) {
+
+	// constant values in file extension tables are all lowercase. convert the
+	// search key to lowercase for case-insensitivity.
+	extLower := strings.ToLower(ext)
+
+	// iter: all supported kinds of media
+	for _, m := range []MediaExt{audioExt, videoExt, imageExt, documentExt} {
+		if n, ok := kindOfFileExt(m.table, extLower); ok {
+			return m.kind, n
+		}
+	}
+	return KindUnknown, ""
+}
+
+// function MediaKindOfFile() is like MediaKindOfFileExt(), given the file's
+// path, but doesn't identify artwork (see ArtworkRole()) as image media, even
+// though it shares the file name extensions of images: a cover or poster is a
+// support file of the media it depicts, not a photo of its own.
+func MediaKindOfFile(absPath string) (MediaKind, string) {
+
+	ext := filepath.Ext(absPath)
+	kind, name := MediaKindOfFileExt(ext)
+	if KindImage == kind && "" != ArtworkRole(strings.TrimSuffix(filepath.Base(absPath), ext)) {
+		return KindUnknown, ""
+	}
+	return kind, name
+}
+
+// function ToRecord() creates a struct capable of being stored in the database.
+// defines type AudioMedia's implementation of the StorableEntity interface.
+func (m *AudioMedia) ToRecord() (*EntityRecord, *rc.ReturnCode) {
+
+	var (
+		record *EntityRecord = &EntityRecord{}
+		data   []byte
+		err    error
+	)
+
+	if data, err = json.Marshal(m); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Marshal(%s): cannot marshal AudioMedia struct into JSON object: %s", m, err)
+	}
+
+	if err = json.Unmarshal(data, record); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into EntityRecord struct: %s", string(data), err)
+	}
+
+	return record, nil
+}
+
+// function FromRecord() creates a struct using the record stored in the
+// database. defines type AudioMedia's implementation of the StorableEntity
+// interface.
+func (m *AudioMedia) FromRecord(data []byte) *rc.ReturnCode {
+
+	// AudioMedia has an embedded Media struct -pointer- (not struct). so if we
+	// create a zeroized AudioMedia, the embedded Media will be a null pointer.
+	// we can protect this method from that null pointer by creating a zeroized
+	// Media and updating AudioMedia's embedded pointer to reference it.
+	if nil == m.Media {
+		m.Media = &Media{}
+	}
+
+	// unmarshal our media object directly into the target
+	if err := json.Unmarshal(data, m); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"FromRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into AudioMedia struct: %s", string(data), err)
+	}
+
+	// a record may unmarshal successfully and still be unusable, e.g. if any
+	// of the embedded structs or essential fields were missing.
+	if err := m.Entity.Validate(ClassMedia); nil != err {
+		return err
+	}
+	if KindAudio != m.Kind {
+		return rc.CorruptRecord.Specf(
+			"FromRecord(): media kind mismatch: %d (expected %d)", int(m.Kind), int(KindAudio))
+	}
+
+	return nil
+}
+
+// function FromID() creates a concrete AudioMedia struct using the record
+// stored in the given collection with the given hash key id.
+func (m *AudioMedia) FromID(col engine.Collection, id int) *rc.ReturnCode {
+
+	read, readErr := col.Read(id)
+	if nil != readErr {
+		return rc.DatabaseError.Specf(
+			"FromID(%v): Read(%d): cannot read record from database: %s",
+			col, id, readErr)
+	}
+
+	data, marshalErr := json.Marshal(read)
+	if nil != marshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Marshal(%s): cannot marshal query result into JSON object: %s",
+			col, read, marshalErr)
+	}
+
+	unmarshalErr := json.Unmarshal(data, m)
+	if nil != unmarshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Unmarshal(%s): cannot unmarshal JSON object into AudioMedia struct: %s",
+			col, data, unmarshalErr)
+	}
+
+	return nil
+}
+
+// function ToRecord() creates a struct capable of being stored in the database.
+// defines type VideoMedia's implementation of the StorableEntity interface.
+func (m *VideoMedia) ToRecord() (*EntityRecord, *rc.ReturnCode) {
+
+	var (
+		record *EntityRecord = &EntityRecord{}
+		data   []byte
+		err    error
+	)
+
+	if data, err = json.Marshal(m); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Marshal(%s): cannot marshal VideoMedia struct into JSON object: %s", m, err)
+	}
+
+	if err = json.Unmarshal(data, record); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into EntityRecord struct: %s", string(data), err)
+	}
+
+	return record, nil
+}
+
+// function FromRecord() creates a struct using the record stored in the
+// database. defines type VideoMedia's implementation of the StorableEntity
+// interface.
+func (m *VideoMedia) FromRecord(data []byte) *rc.ReturnCode {
+
+	// VideoMedia has an embedded Media struct -pointer- (not struct). so if we
+	// create a zeroized VideoMedia, the embedded Media will be a null pointer.
+	// we can protect this method from that null pointer by creating a zeroized
+	// Media and updating VideoMedia's embedded pointer to reference it.
+	if nil == m.Media {
+		m.Media = &Media{}
+	}
+
+	// unmarshal our media object directly into the target
+	if err := json.Unmarshal(data, m); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"FromRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into VideoMedia struct: %s", string(data), err)
+	}
+
+	// a record may unmarshal successfully and still be unusable, e.g. if any
+	// of the embedded structs or essential fields were missing.
+	if err := m.Entity.Validate(ClassMedia); nil != err {
+		return err
+	}
+	if KindVideo != m.Kind {
+		return rc.CorruptRecord.Specf(
+			"FromRecord(): media kind mismatch: %d (expected %d)", int(m.Kind), int(KindVideo))
+	}
+
+	// records created before file names were parsed lack the description, and
+	// those created before series were recorded lack the keys.
+	if ("" == m.Series && 0 == m.Year) || (m.IsEpisode() && "" == m.SeasonKey) {
+		m.ParseName()
+	}
+
+	return nil
+}
+
+// function FromID() creates a concrete VideoMedia struct using the record
+// stored in the given collection with the given hash key id.
+func (m *VideoMedia) FromID(col engine.Collection, id int) *rc.ReturnCode {
+
+	read, readErr := col.Read(id)
+	if nil != readErr {
+		return rc.DatabaseError.Specf(
+			"FromID(%v): Read(%d): cannot read record from database: %s",
+			col, id, readErr)
+	}
+
+	data, marshalErr := json.Marshal(read)
+	if nil != marshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Marshal(%s): cannot marshal query result into JSON object: %s",
+			col, read, marshalErr)
+	}
+
+	unmarshalErr := json.Unmarshal(data, m)
+	if nil != unmarshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Unmarshal(%s): cannot unmarshal JSON object into VideoMedia struct: %s",
+			col, data, unmarshalErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: metadata.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the support type of metadata sidecar files (Kodi-style .nfo files)
+//    describing the media beside them.
+//
+// =============================================================================
+
+package media
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Metadata is a specialized type of support containing struct fields
+// relevant only to metadata sidecar files, whose content describes the media
+// of the same base name (see package migrate for the format).
+type Metadata struct {
+	*Support           // common support info
+	Applied  time.Time // date the content was applied to its media, zero if not yet (or changed since)
+}
+
+var (
+	// var nfoExt is a struct defining how SupportMetadata support files will be
+	// identified through file name inspection.
+	nfoExt = SupportExt{
+		kind: SupportMetadata,
+		table: &ExtTable{
+			"Kodi NFO": []string{".nfo"},
+		},
+	}
+)
+
+// function NewMetadata() creates and initializes a new Metadata object by
+// invoking the embedded types' constructors and then populating any unique
+// specialization fields.
+func NewMetadata(absPath, relPath, ext, extName string, info os.FileInfo) *Metadata {
+
+	support := NewSupport(SupportMetadata, absPath, relPath, ext, extName, info)
+
+	return &Metadata{
+		Support: support, // common support info
+	}
+}
+
+// function ToRecord() creates a struct capable of being stored in the database.
+// defines type Metadata's implementation of the StorableEntity interface.
+func (m *Metadata) ToRecord() (*EntityRecord, *rc.ReturnCode) {
+
+	var (
+		record *EntityRecord = &EntityRecord{}
+		data   []byte
+		err    error
+	)
+
+	if data, err = json.Marshal(m); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Marshal(%s): cannot marshal Metadata struct into JSON object: %s", m, err)
+	}
+
+	if err = json.Unmarshal(data, record); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into EntityRecord struct: %s", string(data), err)
+	}
+
+	return record, nil
+}
+
+// function FromRecord() creates a struct using the record stored in the
+// database. defines type Metadata's implementation of the StorableEntity
+// interface.
+func (m *Metadata) FromRecord(data []byte) *rc.ReturnCode {
+
+	// guard the embedded Support struct pointer (see Subtitles.FromRecord()).
+	if nil == m.Support {
+		m.Support = &Support{}
+	}
+
+	if err := json.Unmarshal(data, m); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"FromRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into Metadata struct: %s", string(data), err)
+	}
+
+	if err := m.Entity.Validate(ClassSupport); nil != err {
+		return err
+	}
+	if SupportMetadata != m.Kind {
+		return rc.CorruptRecord.Specf(
+			"FromRecord(): support kind mismatch: %d (expected %d)", int(m.Kind), int(SupportMetadata))
+	}
+
+	return nil
+}
+
+// function FromID() creates a concrete Metadata struct using the record stored
+// in the given collection with the given hash key id.
+func (m *Metadata) FromID(col engine.Collection, id int) *rc.ReturnCode {
+
+	read, readErr := col.Read(id)
+	if nil != readErr {
+		return rc.DatabaseError.Specf(
+			"FromID(%v): Read(%d): cannot read record from database: %s",
+			col, id, readErr)
+	}
+
+	data, marshalErr := json.Marshal(read)
+	if nil != marshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Marshal(%s): cannot marshal query result into JSON object: %s",
+			col, read, marshalErr)
+	}
+
+	if nil == m.Support {
+		m.Support = &Support{}
+	}
+	unmarshalErr := json.Unmarshal(data, m)
+	if nil != unmarshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Unmarshal(%s): cannot unmarshal JSON object into Metadata struct: %s",
+			col, data, unmarshalErr)
+	}
+
+	return nil
+}
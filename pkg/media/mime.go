@@ -0,0 +1,109 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: mime.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    maps the file types of media, as named by their ExtTable, to the MIME
+//    types by which they are served over HTTP.
+//
+// =============================================================================
+
+package media
+
+import (
+	"mime"
+	"strings"
+)
+
+var (
+	// var mimeType maps the name of each file type in the ExtTable of each
+	// MediaKind to its MIME type. the names are those of the ExtTables, so
+	// any type missing here is looked up by its file name extension instead
+	// (see MIMEType()).
+	mimeType = [KindCOUNT]map[string]string{
+		// 0 = KindAudio
+		{
+			"Adaptive Multi-Rate":           "audio/amr",
+			"Adaptive Multi-Rate Wideband":  "audio/amr-wb",
+			"Advanced Audio Coding":         "audio/aac",
+			"Apple AIFF":                    "audio/aiff",
+			"Free Lossless Audio Codec":     "audio/flac",
+			"Microsoft WAV":                 "audio/wav",
+			"Microsoft Windows Media Audio": "audio/x-ms-wma",
+			"Monkey's Audio":                "audio/x-ape",
+			"MPEG Layer III":                "audio/mpeg",
+			"MPEG-4 Part 14":                "audio/mp4",
+			"Musepack/MPC/MPEG":             "audio/x-musepack",
+			"Ogg Audio":                     "audio/ogg",
+			"Opus":                          "audio/opus",
+			"RealAudio":                     "audio/x-pn-realaudio",
+			"Sun/Unix/Java Audio":           "audio/basic",
+			"True Audio Lossless":           "audio/x-tta",
+			"WavPack":                       "audio/x-wavpack",
+		},
+		// 1 = KindVideo
+		{
+			"3GPP":                            "video/3gpp",
+			"3GPP2":                           "video/3gpp2",
+			"Advanced Systems Format":         "video/x-ms-asf",
+			"Audio Video Interleave":          "video/x-msvideo",
+			"Flash Video":                     "video/x-flv",
+			"Material Exchange Format":        "application/mxf",
+			"Matroska":                        "video/x-matroska",
+			"MPEG Transport Stream":           "video/mp2t",
+			"MPEG-1":                          "video/mpeg",
+			"MPEG-1/MPEG-2":                   "video/mpeg",
+			"MPEG-2":                          "video/mpeg",
+			"MPEG-4 Part 14":                  "video/mp4",
+			"Multiple-image Network Graphics": "video/x-mng",
+			"Ogg Video":                       "video/ogg",
+			"QuickTime File Format":           "video/quicktime",
+			"RealMedia":                       "application/vnd.rn-realmedia",
+			"RealMedia Variable Bitrate":      "application/vnd.rn-realmedia-vbr",
+			"Video Object":                    "video/mpeg",
+			"WebM":                            "video/webm",
+			"Windows Media Video":             "video/x-ms-wmv",
+		},
+		// 2 = KindImage
+		{
+			"Bitmap":                       "image/bmp",
+			"Graphics Interchange Format":  "image/gif",
+			"High Efficiency Image Format": "image/heif",
+			"JPEG":                         "image/jpeg",
+			"Portable Network Graphics":    "image/png",
+			"Tagged Image File Format":     "image/tiff",
+			"WebP":                         "image/webp",
+		},
+		// 3 = KindDocument
+		{
+			"Comic Book 7z":            "application/x-cb7",
+			"Comic Book RAR":           "application/vnd.comicbook-rar",
+			"Comic Book Zip":           "application/vnd.comicbook+zip",
+			"DjVu":                     "image/vnd.djvu",
+			"Electronic Publication":   "application/epub+zip",
+			"Kindle":                   "application/vnd.amazon.ebook",
+			"Mobipocket":               "application/x-mobipocket-ebook",
+			"Portable Document Format": "application/pdf",
+		},
+	}
+)
+
+// function MIMEType() returns the MIME type of the media's file, e.g.
+// "video/x-matroska", by the name of its file type (see ExtName of Entity).
+// types not known by name are looked up by file name extension in the system's
+// tables, and are otherwise "application/octet-stream".
+func (m *Media) MIMEType() string {
+
+	if m.Kind >= 0 && m.Kind < KindCOUNT {
+		if t, ok := mimeType[m.Kind][m.ExtName]; ok {
+			return t
+		}
+	}
+	if t := mime.TypeByExtension(strings.ToLower(m.Ext)); "" != t {
+		return t
+	}
+	return "application/octet-stream"
+}
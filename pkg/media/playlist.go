@@ -0,0 +1,297 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: playlist.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines types related to playlists, which are ordered lists of references
+//    to the media records of a library, either created by the user or imported
+//    from the playlist files (.m3u, .m3u8, .pls) found in the library, and
+//    smart playlists, which select their media by a rule (see package query).
+//
+// =============================================================================
+
+package media
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type PlaylistKind is an enum identifying the different types of playlists.
+type PlaylistKind int
+
+const (
+	PlaylistUnknown PlaylistKind = iota - 1 // = -1
+	PlaylistStatic                          // =  0
+	PlaylistSmart                           // =  1
+	PlaylistCOUNT                           // =  2
+)
+
+var (
+	// variable PlaylistColName maps the PlaylistKind enum values to the string
+	// name of their corresponding collection in the database.
+	PlaylistColName = [PlaylistCOUNT]string{
+		"Playlist",      // 0 = PlaylistStatic
+		"SmartPlaylist", // 1 = PlaylistSmart
+	}
+)
+
+// type PlaylistItem is a reference to a single media record in a Playlist. the
+// path of the media is kept along with its record ID, so that the media can be
+// found again if its record is ever replaced (e.g. by a repair), or if it had
+// not yet been discovered when the playlist was imported.
+type PlaylistItem struct {
+	Kind     MediaKind // type of media, i.e. the collection containing the record
+	RecordID int       // ID of the media's record in the database (-1 if unknown)
+	AbsPath  string    // absolute path to the media file
+}
+
+// type Playlist is an ordered list of media from a single library. the
+// embedded Entity is the playlist file from which it was imported, and is nil
+// if the playlist was created by the user. a smart playlist has no items, its
+// media are instead selected by its rule each time it is opened.
+type Playlist struct {
+	*Entity                    // playlist file info (nil if not imported)
+	Kind        PlaylistKind   // type of playlist
+	Name        string         // unique name of the playlist
+	Items       []PlaylistItem // media of the playlist, in order
+	Rule        string         // rule selecting the media of a smart playlist
+	TimeCreated time.Time      // date playlist was created or first imported
+	TimeUpdated time.Time      // date the items of the playlist last changed
+}
+
+// function NewPlaylist() creates and initializes a new, empty Playlist with
+// the given name, created by the user.
+func NewPlaylist(name string) *Playlist {
+
+	now := time.Now()
+
+	return &Playlist{
+		Entity:      nil,              // (*Entity)       playlist file info (nil if not imported)
+		Kind:        PlaylistStatic,   // (PlaylistKind)  type of playlist
+		Name:        name,             // (string)        unique name of the playlist
+		Items:       []PlaylistItem{}, // ([]PlaylistItem) media of the playlist, in order
+		Rule:        "",               // (string)        rule selecting the media of a smart playlist
+		TimeCreated: now,              // (time.Time)     date playlist was created or first imported
+		TimeUpdated: now,              // (time.Time)     date the items of the playlist last changed
+	}
+}
+
+// function NewSmartPlaylist() creates and initializes a new smart Playlist with
+// the given name, selecting the media matched by the given rule. the rule must
+// be validated separately (see package query).
+func NewSmartPlaylist(name, rule string) *Playlist {
+
+	p := NewPlaylist(name)
+	p.Kind = PlaylistSmart
+	p.Rule = rule
+
+	return p
+}
+
+// function NewPlaylistFile() creates and initializes a new, empty Playlist
+// for the playlist file at the given path, named after the file. its items
+// must be read from the file separately (see package playlist).
+func NewPlaylistFile(absPath, relPath, ext, extName string, info os.FileInfo) *Playlist {
+
+	p := NewPlaylist(strings.TrimSuffix(info.Name(), ext))
+	p.Entity = NewEntity(ClassPlaylist, absPath, relPath, ext, extName, info)
+
+	return p
+}
+
+// function IsSmart() returns true if the Playlist selects its media by a rule.
+func (p *Playlist) IsSmart() bool {
+	return PlaylistSmart == p.Kind
+}
+
+// function IsImported() returns true if the Playlist was imported from a
+// playlist file, rather than created by the user.
+func (p *Playlist) IsImported() bool {
+	return nil != p.Entity
+}
+
+// function Add() appends the media with the given record ID, of the given
+// kind, to the end of the Playlist.
+func (p *Playlist) Add(kind MediaKind, id int, absPath string) {
+	p.Items = append(p.Items, PlaylistItem{Kind: kind, RecordID: id, AbsPath: absPath})
+	p.TimeUpdated = time.Now()
+}
+
+// function Remove() removes the item at the given index from the Playlist.
+// returns false if there is no such item.
+func (p *Playlist) Remove(index int) bool {
+	if index < 0 || index >= len(p.Items) {
+		return false
+	}
+	p.Items = append(p.Items[:index:index], p.Items[index+1:]...)
+	p.TimeUpdated = time.Now()
+	return true
+}
+
+// function Move() moves the item at the given index to the other given index,
+// shifting the items between them. returns false if either is out of range.
+func (p *Playlist) Move(from, to int) bool {
+	if from < 0 || from >= len(p.Items) || to < 0 || to >= len(p.Items) {
+		return false
+	}
+	item := p.Items[from]
+	p.Items = append(p.Items[:from:from], p.Items[from+1:]...)
+	p.Items = append(p.Items[:to], append([]PlaylistItem{item}, p.Items[to:]...)...)
+	p.TimeUpdated = time.Now()
+	return true
+}
+
+// function IndexOf() returns the index of the first item of the Playlist
+// referring to the media at the given path, or -1 if there is none.
+func (p *Playlist) IndexOf(absPath string) int {
+	for i, item := range p.Items {
+		if absPath == item.AbsPath {
+			return i
+		}
+	}
+	return -1
+}
+
+// function String() creates a string representation of the Playlist for easy
+// identification in logs.
+func (p *Playlist) String() string {
+	if p.IsImported() {
+		return p.Entity.String()
+	}
+	return "\"" + p.Name + "\" [playlist]"
+}
+
+// type PlaylistExt is a struct pairing PlaylistKind values to their
+// corresponding ExtTable map.
+type PlaylistExt struct {
+	kind  PlaylistKind
+	table *ExtTable
+}
+
+var (
+	// var staticExt is a struct defining how playlist files will be identified
+	// through file name inspection. if a file name extension matches at least
+	// one string in any of the string slices below, then that file is assumed
+	// to be a playlist, of the format given by the map key.
+	staticExt = PlaylistExt{
+		kind: PlaylistStatic,
+		table: &ExtTable{
+			"M3U":         []string{".m3u"},
+			"M3U (UTF-8)": []string{".m3u8"},
+			"PLS":         []string{".pls"},
+		},
+	}
+)
+
+// function PlaylistKindOfFileExt() searches all PlaylistExt mappings for a
+// given file name extension, returning both the PlaylistKind and the format
+// name associated with that file name extension.
+func PlaylistKindOfFileExt(ext string) (PlaylistKind, string) {
+
+	// constant values in file extension tables are all lowercase. convert the
+	// search key to lowercase for case-insensitivity.
+	extLower := strings.ToLower(ext)
+
+	// iter: all supported kinds of playlists
+	for _, m := range []PlaylistExt{staticExt} {
+		if n, ok := kindOfFileExt(m.table, extLower); ok {
+			return m.kind, n
+		}
+	}
+	return PlaylistUnknown, ""
+}
+
+// function ToRecord() creates a struct capable of being stored in the database.
+// defines type Playlist's implementation of the StorableEntity interface.
+func (p *Playlist) ToRecord() (*EntityRecord, *rc.ReturnCode) {
+
+	var (
+		record *EntityRecord = &EntityRecord{}
+		data   []byte
+		err    error
+	)
+
+	if data, err = json.Marshal(p); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Marshal(%s): cannot marshal Playlist struct into JSON object: %s", p, err)
+	}
+
+	if err = json.Unmarshal(data, record); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into EntityRecord struct: %s", string(data), err)
+	}
+
+	return record, nil
+}
+
+// function FromRecord() creates a struct using the record stored in the
+// database. defines type Playlist's implementation of the StorableEntity
+// interface.
+func (p *Playlist) FromRecord(data []byte) *rc.ReturnCode {
+
+	// unmarshal our playlist object directly into the target. unlike media,
+	// the embedded Entity is only allocated if the record has one.
+	if err := json.Unmarshal(data, p); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"FromRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into Playlist struct: %s", string(data), err)
+	}
+
+	// a record may unmarshal successfully and still be unusable, e.g. if any
+	// of the embedded structs or essential fields were missing.
+	if p.IsImported() {
+		if err := p.Entity.Validate(ClassPlaylist); nil != err {
+			return err
+		}
+	}
+	if "" == p.Name {
+		return rc.CorruptRecord.Spec("FromRecord(): missing playlist name")
+	}
+	if p.Kind < 0 || p.Kind >= PlaylistCOUNT {
+		return rc.CorruptRecord.Specf(
+			"FromRecord(): unknown playlist kind: %d", int(p.Kind))
+	}
+	if p.IsSmart() && "" == p.Rule {
+		return rc.CorruptRecord.Specf("FromRecord(): smart playlist %q has no rule", p.Name)
+	}
+
+	return nil
+}
+
+// function FromID() creates a concrete Playlist struct using the record
+// stored in the given collection with the given hash key id.
+func (p *Playlist) FromID(col engine.Collection, id int) *rc.ReturnCode {
+
+	read, readErr := col.Read(id)
+	if nil != readErr {
+		return rc.DatabaseError.Specf(
+			"FromID(%v): Read(%d): cannot read record from database: %s",
+			col, id, readErr)
+	}
+
+	data, marshalErr := json.Marshal(read)
+	if nil != marshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Marshal(%s): cannot marshal query result into JSON object: %s",
+			col, read, marshalErr)
+	}
+
+	unmarshalErr := json.Unmarshal(data, p)
+	if nil != unmarshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Unmarshal(%s): cannot unmarshal JSON object into Playlist struct: %s",
+			col, data, unmarshalErr)
+	}
+
+	return nil
+}
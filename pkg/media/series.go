@@ -0,0 +1,241 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: series.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines types related to TV series and their seasons, which group the
+//    episodes of a library's video media so that they may be browsed as a
+//    hierarchy (series, season, episode) rather than as a flat list of files.
+//
+// =============================================================================
+
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type SeriesKind is an enum identifying the different levels of the TV series
+// hierarchy.
+type SeriesKind int
+
+const (
+	SeriesUnknown SeriesKind = iota - 1 // = -1
+	SeriesShow                          // =  0
+	SeriesSeason                        // =  1
+	SeriesCOUNT                         // =  2
+)
+
+var (
+	// variable SeriesColName maps the SeriesKind enum values to the string name
+	// of their corresponding collection in the database.
+	SeriesColName = [SeriesCOUNT]string{
+		"Series", // 0 = SeriesShow
+		"Season", // 1 = SeriesSeason
+	}
+)
+
+// type Series is a TV series, grouping the seasons of its episodes. unlike the
+// other entities, it doesn't describe a file; it is derived from the names of
+// its episodes (see ParseName() of VideoMedia), and is identified by its Key.
+type Series struct {
+	Kind        SeriesKind // type of record (always SeriesShow)
+	Key         string     `db:"index"` // unique key of the series (see SeriesKey())
+	Name        string     // name of the series
+	Year        int64      // year the series premiered, distinguishing remakes (0 if unknown)
+	TimeCreated time.Time  // date the first episode of the series was discovered
+}
+
+// type Season is a single season of a TV series, grouping its episodes. it is
+// identified by its Key, and refers to its Series by the series' key.
+type Season struct {
+	Kind        SeriesKind // type of record (always SeriesSeason)
+	Key         string     `db:"index"` // unique key of the season (see SeasonKey())
+	SeriesKey   string     // key of the series containing the season
+	Series      string     // name of the series containing the season
+	Number      int64      // season number
+	TimeCreated time.Time  // date the first episode of the season was discovered
+}
+
+// function SeriesKey() returns the key identifying the series with the given
+// name and year (0 if unknown). names are compared ignoring case, since the
+// file names of episodes are rarely consistent.
+func SeriesKey(name string, year int64) string {
+	key := strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if year > 0 {
+		key = fmt.Sprintf("%s (%d)", key, year)
+	}
+	return key
+}
+
+// function SeasonKey() returns the key identifying the given season of the
+// series with the given key.
+func SeasonKey(seriesKey string, number int64) string {
+	return fmt.Sprintf("%s/%d", seriesKey, number)
+}
+
+// function NewSeries() creates and initializes a new Series with the given
+// name and year.
+func NewSeries(name string, year int64) *Series {
+
+	return &Series{
+		Kind:        SeriesShow,            // (SeriesKind) type of record
+		Key:         SeriesKey(name, year), // (string)     unique key of the series
+		Name:        name,                  // (string)     name of the series
+		Year:        year,                  // (int64)      year the series premiered
+		TimeCreated: time.Now(),            // (time.Time)  date the first episode was discovered
+	}
+}
+
+// function NewSeason() creates and initializes a new Season of the given
+// series with the given number.
+func NewSeason(series *Series, number int64) *Season {
+
+	return &Season{
+		Kind:        SeriesSeason,                  // (SeriesKind) type of record
+		Key:         SeasonKey(series.Key, number), // (string)     unique key of the season
+		SeriesKey:   series.Key,                    // (string)     key of the series containing the season
+		Series:      series.Name,                   // (string)     name of the series containing the season
+		Number:      number,                        // (int64)      season number
+		TimeCreated: time.Now(),                    // (time.Time)  date the first episode was discovered
+	}
+}
+
+// function String() creates a string representation of the Series for easy
+// identification in logs.
+func (s *Series) String() string {
+	if s.Year > 0 {
+		return fmt.Sprintf("\"%s (%d)\" [series]", s.Name, s.Year)
+	}
+	return "\"" + s.Name + "\" [series]"
+}
+
+// function String() creates a string representation of the Season for easy
+// identification in logs.
+func (s *Season) String() string {
+	return fmt.Sprintf("\"%s\" [season %d]", s.Series, s.Number)
+}
+
+// function ToRecord() creates a struct capable of being stored in the database.
+// defines type Series's implementation of the StorableEntity interface.
+func (s *Series) ToRecord() (*EntityRecord, *rc.ReturnCode) {
+	return seriesToRecord(s, s)
+}
+
+// function FromRecord() creates a struct using the record stored in the
+// database. defines type Series's implementation of the StorableEntity
+// interface.
+func (s *Series) FromRecord(data []byte) *rc.ReturnCode {
+
+	if err := json.Unmarshal(data, s); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"FromRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into Series struct: %s", string(data), err)
+	}
+	if SeriesShow != s.Kind {
+		return rc.CorruptRecord.Specf(
+			"FromRecord(): series kind mismatch: %d (expected %d)", int(s.Kind), int(SeriesShow))
+	}
+	if "" == s.Key || "" == s.Name {
+		return rc.CorruptRecord.Spec("FromRecord(): missing series name")
+	}
+	return nil
+}
+
+// function FromID() creates a concrete Series struct using the record stored
+// in the given collection with the given hash key id.
+func (s *Series) FromID(col engine.Collection, id int) *rc.ReturnCode {
+	return seriesFromID(col, id, s)
+}
+
+// function ToRecord() creates a struct capable of being stored in the database.
+// defines type Season's implementation of the StorableEntity interface.
+func (s *Season) ToRecord() (*EntityRecord, *rc.ReturnCode) {
+	return seriesToRecord(s, s)
+}
+
+// function FromRecord() creates a struct using the record stored in the
+// database. defines type Season's implementation of the StorableEntity
+// interface.
+func (s *Season) FromRecord(data []byte) *rc.ReturnCode {
+
+	if err := json.Unmarshal(data, s); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"FromRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into Season struct: %s", string(data), err)
+	}
+	if SeriesSeason != s.Kind {
+		return rc.CorruptRecord.Specf(
+			"FromRecord(): series kind mismatch: %d (expected %d)", int(s.Kind), int(SeriesSeason))
+	}
+	if "" == s.Key || "" == s.SeriesKey {
+		return rc.CorruptRecord.Spec("FromRecord(): missing season key")
+	}
+	return nil
+}
+
+// function FromID() creates a concrete Season struct using the record stored
+// in the given collection with the given hash key id.
+func (s *Season) FromID(col engine.Collection, id int) *rc.ReturnCode {
+	return seriesFromID(col, id, s)
+}
+
+// function seriesToRecord() marshals the given Series or Season (v) into a
+// record. desc identifies it in error messages.
+func seriesToRecord(v interface{}, desc fmt.Stringer) (*EntityRecord, *rc.ReturnCode) {
+
+	var (
+		record *EntityRecord = &EntityRecord{}
+		data   []byte
+		err    error
+	)
+
+	if data, err = json.Marshal(v); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Marshal(%s): cannot marshal struct into JSON object: %s", desc, err)
+	}
+
+	if err = json.Unmarshal(data, record); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into EntityRecord struct: %s", string(data), err)
+	}
+
+	return record, nil
+}
+
+// function seriesFromID() unmarshals the record stored in the given collection
+// with the given hash key id into the given Series or Season (v).
+func seriesFromID(col engine.Collection, id int, v interface{}) *rc.ReturnCode {
+
+	read, readErr := col.Read(id)
+	if nil != readErr {
+		return rc.DatabaseError.Specf(
+			"FromID(%v): Read(%d): cannot read record from database: %s",
+			col, id, readErr)
+	}
+
+	data, marshalErr := json.Marshal(read)
+	if nil != marshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Marshal(%s): cannot marshal query result into JSON object: %s",
+			col, read, marshalErr)
+	}
+
+	unmarshalErr := json.Unmarshal(data, v)
+	if nil != unmarshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Unmarshal(%s): cannot unmarshal JSON object into %T struct: %s",
+			col, data, v, unmarshalErr)
+	}
+
+	return nil
+}
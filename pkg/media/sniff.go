@@ -0,0 +1,160 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: sniff.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    identifies the kind of media of a file by the signature at the start of
+//    its content, for files whose name extension is missing or misleading.
+//
+// =============================================================================
+
+package media
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant SniffSize is the number of bytes at the start of a file read by
+// SniffMediaKind(), enough to hold the signature of every format recognized.
+const SniffSize = 512
+
+// type signature identifies a single file format by the content at the start
+// of its files.
+type signature struct {
+	kind  MediaKind         // kind of media of the format
+	name  string            // name of the format, as in its kind's ExtTable
+	match func([]byte) bool // returns true if the given content is of the format
+}
+
+// function magicAt() returns a match function of a signature, matching content
+// that begins with the given bytes at the given offset.
+func magicAt(offset int, magic string) func([]byte) bool {
+	return func(b []byte) bool {
+		return len(b) >= offset+len(magic) && string(b[offset:offset+len(magic)]) == magic
+	}
+}
+
+// function riff() returns a match function of a signature, matching the RIFF
+// (or IFF, if form is "FORM") container of the given form type.
+func riff(chunk, form string) func([]byte) bool {
+	return func(b []byte) bool {
+		return magicAt(0, chunk)(b) && magicAt(8, form)(b)
+	}
+}
+
+// function ftyp() returns a match function of a signature, matching an ISO base
+// media file (e.g. MP4) whose major brand begins with any of the given brands,
+// or any brand if none are given.
+func ftyp(brand ...string) func([]byte) bool {
+	return func(b []byte) bool {
+		if !magicAt(4, "ftyp")(b) {
+			return false
+		}
+		if 0 == len(brand) {
+			return true
+		}
+		for _, m := range brand {
+			if magicAt(8, m)(b) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+var (
+	// var signatures lists every format recognized by SniffMediaKind(), in the
+	// order tested. more specific signatures precede those they would be
+	// mistaken for, e.g. the WebM variant of Matroska.
+	signatures = []signature{
+		// audio
+		{KindAudio, "Free Lossless Audio Codec", magicAt(0, "fLaC")},
+		{KindAudio, "Microsoft WAV", riff("RIFF", "WAVE")},
+		{KindAudio, "Apple AIFF", riff("FORM", "AIFF")},
+		{KindAudio, "Opus", func(b []byte) bool { return magicAt(0, "OggS")(b) && magicAt(28, "OpusHead")(b) }},
+		{KindVideo, "Ogg Video", func(b []byte) bool { return magicAt(0, "OggS")(b) && magicAt(28, "\x80theora")(b) }},
+		{KindAudio, "Ogg Audio", magicAt(0, "OggS")},
+		{KindAudio, "Monkey's Audio", magicAt(0, "MAC ")},
+		{KindAudio, "WavPack", magicAt(0, "wvpk")},
+		{KindAudio, "Musepack/MPC/MPEG", func(b []byte) bool { return magicAt(0, "MPCK")(b) || magicAt(0, "MP+")(b) }},
+		{KindAudio, "Adaptive Multi-Rate Wideband", magicAt(0, "#!AMR-WB\n")},
+		{KindAudio, "Adaptive Multi-Rate", magicAt(0, "#!AMR\n")},
+		{KindAudio, "MPEG-4 Part 14", ftyp("M4A ", "M4B ", "M4P ")},
+		{KindAudio, "MPEG Layer III", func(b []byte) bool {
+			// an ID3v2 tag, or the frame sync of an MPEG audio layer III frame.
+			return magicAt(0, "ID3")(b) || (len(b) > 1 && 0xFF == b[0] && 0xE2 == b[1]&0xE6)
+		}},
+		// video
+		{KindVideo, "WebM", func(b []byte) bool {
+			return magicAt(0, "\x1A\x45\xDF\xA3")(b) && bytes.Contains(b, []byte("webm"))
+		}},
+		{KindVideo, "Matroska", magicAt(0, "\x1A\x45\xDF\xA3")},
+		{KindVideo, "QuickTime File Format", ftyp("qt  ")},
+		{KindVideo, "3GPP2", ftyp("3g2")},
+		{KindVideo, "3GPP", ftyp("3gp")},
+		{KindImage, "High Efficiency Image Format", ftyp("heic", "heix", "mif1", "msf1")},
+		{KindVideo, "MPEG-4 Part 14", ftyp()},
+		{KindVideo, "Audio Video Interleave", riff("RIFF", "AVI ")},
+		{KindVideo, "Advanced Systems Format", magicAt(0, "\x30\x26\xB2\x75\x8E\x66\xCF\x11")},
+		{KindVideo, "Flash Video", magicAt(0, "FLV\x01")},
+		{KindVideo, "MPEG-1/MPEG-2", magicAt(0, "\x00\x00\x01\xBA")},
+		{KindVideo, "MPEG Transport Stream", func(b []byte) bool {
+			// the sync byte of two consecutive 188-byte packets.
+			return len(b) > 188 && 0x47 == b[0] && 0x47 == b[188]
+		}},
+		{KindVideo, "RealMedia", magicAt(0, ".RMF")},
+		// images
+		{KindImage, "JPEG", magicAt(0, "\xFF\xD8\xFF")},
+		{KindImage, "Portable Network Graphics", magicAt(0, "\x89PNG\r\n\x1A\n")},
+		{KindImage, "Graphics Interchange Format", func(b []byte) bool { return magicAt(0, "GIF87a")(b) || magicAt(0, "GIF89a")(b) }},
+		{KindImage, "WebP", riff("RIFF", "WEBP")},
+		{KindImage, "Tagged Image File Format", func(b []byte) bool { return magicAt(0, "II*\x00")(b) || magicAt(0, "MM\x00*")(b) }},
+		// documents
+		{KindDocument, "Portable Document Format", magicAt(0, "%PDF-")},
+		{KindDocument, "Electronic Publication", func(b []byte) bool {
+			// a zip archive whose first entry is the uncompressed "mimetype".
+			return magicAt(0, "PK\x03\x04")(b) && magicAt(30, "mimetypeapplication/epub+zip")(b)
+		}},
+		{KindDocument, "DjVu", magicAt(0, "AT&TFORM")},
+		{KindDocument, "Mobipocket", magicAt(60, "BOOKMOBI")},
+	}
+)
+
+// function SniffMediaKind() identifies the kind of media of the file at the
+// given path by the signature at the start of its content, returning both the
+// MediaKind and the name of its format, as named by MediaKindOfFileExt(), or
+// KindUnknown if none is recognized. only the first SniffSize bytes are read.
+func SniffMediaKind(absPath string) (MediaKind, string, *rc.ReturnCode) {
+
+	f, err := os.Open(absPath)
+	if nil != err {
+		return KindUnknown, "", rc.InvalidFile.Specf("SniffMediaKind(%q): os.Open(): %s", absPath, err)
+	}
+	defer f.Close()
+
+	head := make([]byte, SniffSize)
+	n, err := io.ReadFull(f, head)
+	if nil != err && io.ErrUnexpectedEOF != err && io.EOF != err {
+		return KindUnknown, "", rc.InvalidFile.Specf("SniffMediaKind(%q): f.Read(): %s", absPath, err)
+	}
+	kind, name := sniffHead(head[:n])
+	return kind, name, nil
+}
+
+// function sniffHead() is like SniffMediaKind(), given the content at the start
+// of a file.
+func sniffHead(head []byte) (MediaKind, string) {
+	for _, s := range signatures {
+		if s.match(head) {
+			return s.kind, s.name
+		}
+	}
+	return KindUnknown, ""
+}
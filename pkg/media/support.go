@@ -0,0 +1,353 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 06 Nov 2018
+//  FILE: support.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines types related to auxiliary support files and also provides
+//    subroutines for inspecting and classifying support files.
+//
+// =============================================================================
+
+package media
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/sublang"
+)
+
+// type SupportKind is an enum identifying different types of files that support
+// media in some way. these files should somehow be associated with the media
+// files; they are not necessarily useful on their own.
+type SupportKind int
+
+const (
+	SupportUnknown   SupportKind = iota - 1 // = -1
+	SupportSubtitles                        // =  0
+	SupportArtwork                          // =  1
+	SupportMetadata                         // =  2
+	SupportLyrics                           // =  3
+	SupportCueSheet                         // =  4
+	SupportCOUNT                            // =  5
+)
+
+var (
+	// variable SupportColName maps the SupportKind enum values to the string
+	// name of their corresponding collection in the database.
+	SupportColName = [SupportCOUNT]string{
+		"Subtitles", // 0 = SupportSubtitles
+		"Artwork",   // 1 = SupportArtwork
+		"Metadata",  // 2 = SupportMetadata
+		"Lyrics",    // 3 = SupportLyrics
+		"CueSheet",  // 4 = SupportCueSheet
+	}
+)
+
+// type Support is used to reference every kind of support file that is capable
+// of supplementing media file capabilites.
+type Support struct {
+	*Entity             // common entity info
+	Kind    SupportKind // type of support file
+}
+
+// type Subtitles is a specialized type of support containing struct fields
+// relevant only to subtitles.
+type Subtitles struct {
+	*Support        // common support info
+	KnownVideoMedia []VideoMedia
+	Language        string // language of the subtitles (ISO 639-1, e.g. "en"), empty if unknown
+}
+
+const (
+	// max number of media that can exist in a directory coincidently with a
+	// subtitles file to consider them associated (see findCandidates() case 3).
+	MaxNumMediaAssocSubs int = 2
+)
+
+// function NewSupport() creates and initializes a new Support object by
+// invoking the embedded types' constructors and then populating any unique
+// specialization fields.
+func NewSupport(kind SupportKind, absPath, relPath, ext, extName string, info os.FileInfo) *Support {
+
+	entity := NewEntity(ClassSupport, absPath, relPath, ext, extName, info)
+
+	return &Support{
+		Entity: entity, // (*Entity)     common entity info
+		Kind:   kind,   // (SupportKind) type of support file
+	}
+}
+
+// function NewSubtitles() creates and initializes a new Subtitles object by
+// invoking the embedded types' constructors and then populating any unique
+// specialization fields.
+func NewSubtitles(absPath, relPath, ext, extName string, info os.FileInfo) *Subtitles {
+
+	support := NewSupport(SupportSubtitles, absPath, relPath, ext, extName, info)
+
+	return &Subtitles{
+		Support:         support, // common support info
+		KnownVideoMedia: []VideoMedia{},
+	}
+}
+
+// function DetectLanguage() sets the language of the Subtitles to that named
+// by its file name, or else identified by sniffing its content (see package
+// sublang). returns true if the language was identified.
+func (s *Subtitles) DetectLanguage() bool {
+	s.Language = sublang.Detect(s.AbsPath)
+	return "" != s.Language
+}
+
+// function VideoBase() returns the base name of the Subtitles without any
+// language suffix, e.g. "Movie" for "Movie.en.srt", which is the base name of
+// the video it most likely accompanies.
+func (s *Subtitles) VideoBase() string {
+	_, base := sublang.FromName(s.AbsBase)
+	return base
+}
+
+// function AddVideoMedia() adds the given VideoMedia to this Subtitles object
+// if and only if the video does not already exist in the object's list of known
+// videos. additionally, the database record of these subtitles is also
+// optionally updated to store the video in the list of known VideoMedia.
+func (s *Subtitles) AddVideoMedia(col engine.Collection, id int, update bool, vid *VideoMedia) (bool, *rc.ReturnCode) {
+
+	var (
+		rec     *EntityRecord
+		recErr  *rc.ReturnCode
+		vidSeen bool
+	)
+
+	// walk the current list of known videos, setting a flag if we have already
+	// seen this one before.
+	for _, v := range s.KnownVideoMedia {
+		if v.AbsPath == vid.AbsPath {
+			vidSeen = true
+			break
+		}
+	}
+	// append it to the list if we haven't seen it before.
+	if !vidSeen {
+		s.KnownVideoMedia = append(s.KnownVideoMedia, *vid)
+	}
+
+	// update the database record of this Subtitles to include the new
+	// video association. any subsequent queries should thus include it.
+	if rec, recErr = s.ToRecord(); nil != recErr {
+		return false, recErr
+	}
+	if update {
+		if err := col.Update(id, *rec); nil != err {
+			return false, rc.DatabaseError.Specf(
+				"AddVideoMedia(%v, %d, %s): failed to update record: %s", col, id, *vid, err)
+		}
+	}
+
+	// return true if and only if we added this subtitles reference to the list.
+	// return(ed) false if we've either seen it before or if there was an error
+	// somewhere (e.g. updating the database).
+	return !vidSeen, nil
+}
+
+// type SupportExt is a struct pairing SupportKind values to their corresponding
+// ExtTable map.
+type SupportExt struct {
+	kind  SupportKind
+	table *ExtTable
+}
+
+var (
+	// var subsExt is a struct defining how SupportSubtitles support files will be
+	// identified through file name inspection. if a file name extension matches
+	// at least one string in any of the string slices below, then that file is
+	// assumed to be SupportSubtitles. the subtitles type/encoding of that file is
+	// also assumed to be the map key corresponding to the matching slice.
+	subsExt = SupportExt{
+		kind: SupportSubtitles,
+		table: &ExtTable{
+			"AQTitle":                    []string{".aqt"},
+			"CVD":                        []string{".cvd"},
+			"DKS":                        []string{".dks"},
+			"Gloss Subtitle":             []string{".gsub"},
+			"JACOSub":                    []string{".jss"},
+			"MPL2":                       []string{".mpl"},
+			"Phoenix Subtitle":           []string{".pjs"},
+			"PowerDivX":                  []string{".psb"},
+			"RealText / SMIL":            []string{".rt"},
+			"SAMI":                       []string{".smi"},
+			"SubRip":                     []string{".srt"},
+			"SubStation Alpha":           []string{".ssa"},
+			"Advanced SubStation Alpha":  []string{".ass"},
+			"Structured Subtitle Format": []string{".ssf"},
+			"Spruce subtitle format":     []string{".stl"},
+			"VobSub":                     []string{".sub", ".idx"},
+			"SVCD":                       []string{".svcd"},
+			"MPEG-4 Timed Text":          []string{".ttxt"},
+			"Universal Subtitle Format":  []string{".usf"},
+		},
+	}
+)
+
+// function SupportKindOfFileExt() searches all SupportExt mappings for a given
+// file name extension, returning both the SupportKind and the type/encoding
+// name associated with that file name extension.
+func SupportKindOfFileExt(ext string) (SupportKind, string) {
+
+	// constant values in file extension tables are all lowercase. convert the
+	// search key to lowercase for case-insensitivity.
+	extLower := strings.ToLower(ext)
+
+	// iter: all supported kinds of media
+	for _, m := range []SupportExt{subsExt, nfoExt, lrcExt, cueExt} {
+		if n, ok := kindOfFileExt(m.table, extLower); ok {
+			return m.kind, n
+		}
+	}
+	return SupportUnknown, ""
+}
+
+// function SupportKindOfFile() is like SupportKindOfFileExt(), but identifies
+// the support files recognized by their name as well as their extension, i.e.
+// artwork (see ArtworkRole()) and plain text lyrics (see IsLyricsText()), given
+// the file's path.
+func SupportKindOfFile(absPath string) (SupportKind, string) {
+
+	ext := filepath.Ext(absPath)
+	if kind, name := SupportKindOfFileExt(ext); SupportUnknown != kind {
+		return kind, name
+	}
+	if n, ok := kindOfFileExt(artExt.table, strings.ToLower(ext)); ok {
+		if "" != ArtworkRole(strings.TrimSuffix(filepath.Base(absPath), ext)) {
+			return artExt.kind, n
+		}
+	}
+	if IsLyricsText(absPath) {
+		return SupportLyrics, lyricsTextName
+	}
+	return SupportUnknown, ""
+}
+
+// function IsInSubtitlesSubdir() inspects this subtitles file's absolute file
+// path to determine if one of its parent directories is one of the known,
+// common names typically used to store subtitles in a directory relative to the
+// location of a video media file. if found, an absolute path to the parent of
+// the deepest matching directory found is returned.
+func (s *Subtitles) IsInSubtitlesSubdir() (bool, string) {
+
+	dir := strings.Split(s.AbsDir, platform.PathSep)
+	for i := len(dir) - 1; i >= 0; i-- {
+		switch name := dir[i]; strings.ToLower(name) {
+		case "sub", "subs", "subtitle", "subtitles", "vobsub", "srt":
+			if i > 1 {
+				return true, strings.Join(dir[:i], platform.PathSep)
+			} else {
+				if i > 0 {
+					return true, platform.PathSep
+				} else {
+					return true, platform.CurrDir
+				}
+			}
+		}
+	}
+	return false, ""
+}
+
+// function ToRecord() creates a struct capable of being stored in the database.
+// defines type Subtitles's implementation of the StorableEntity interface.
+func (s *Subtitles) ToRecord() (*EntityRecord, *rc.ReturnCode) {
+
+	var (
+		record *EntityRecord = &EntityRecord{}
+		data   []byte
+		err    error
+	)
+
+	if data, err = json.Marshal(s); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Marshal(%s): cannot marshal Subtitles struct into JSON object: %s", s, err)
+	}
+
+	if err = json.Unmarshal(data, record); nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"ToRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into EntityRecord struct: %s", string(data), err)
+	}
+
+	return record, nil
+}
+
+// function FromRecord() creates a struct using the record stored in the
+// database. defines type Subtitles's implementation of the StorableEntity
+// interface.
+func (s *Subtitles) FromRecord(data []byte) *rc.ReturnCode {
+
+	// Subtitles has an embedded Support struct -pointer- (not struct). so if we
+	// create a zeroized Subtitles, the embedded Support will be a null pointer.
+	// we can protect this method from that null pointer by creating a zeroized
+	// Support and updating Subtitles's embedded pointer to reference it.
+	if nil == s.Support {
+		s.Support = &Support{}
+	}
+
+	// unmarshal our media object directly into the target
+	if err := json.Unmarshal(data, s); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"FromRecord(): json.Unmarshal(%s): cannot unmarshal JSON object into Subtitles struct: %s", string(data), err)
+	}
+
+	// a record may unmarshal successfully and still be unusable, e.g. if any
+	// of the embedded structs or essential fields were missing.
+	if err := s.Entity.Validate(ClassSupport); nil != err {
+		return err
+	}
+	if SupportSubtitles != s.Kind {
+		return rc.CorruptRecord.Specf(
+			"FromRecord(): support kind mismatch: %d (expected %d)", int(s.Kind), int(SupportSubtitles))
+	}
+
+	// records created before languages were detected lack the language, of
+	// which the file name is the only cheap indication.
+	if "" == s.Language {
+		s.Language, _ = sublang.FromName(s.AbsBase)
+	}
+
+	return nil
+}
+
+// function FromID() creates a concrete Subtitles struct using the record
+// stored in the given collection with the given hash key id.
+func (s *Subtitles) FromID(col engine.Collection, id int) *rc.ReturnCode {
+
+	read, readErr := col.Read(id)
+	if nil != readErr {
+		return rc.DatabaseError.Specf(
+			"FromID(%v): Read(%d): cannot read record from database: %s",
+			col, id, readErr)
+	}
+
+	data, marshalErr := json.Marshal(read)
+	if nil != marshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Marshal(%s): cannot marshal query result into JSON object: %s",
+			col, read, marshalErr)
+	}
+
+	unmarshalErr := json.Unmarshal(data, s)
+	if nil != unmarshalErr {
+		return rc.InvalidJSONData.Specf(
+			"FromID(%v): json.Unmarshal(%s): cannot unmarshal JSON object into Subtitles struct: %s",
+			col, data, unmarshalErr)
+	}
+
+	return nil
+}
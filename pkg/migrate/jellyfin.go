@@ -0,0 +1,114 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: jellyfin.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the importer of Jellyfin library exports.
+//
+// =============================================================================
+
+package migrate
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the Jellyfin importer.
+const (
+	jellyfinTicksPerSecond = 10000000 // Jellyfin measures positions in 100ns ticks
+)
+
+// type jellyfinUserData is the watch state of a Jellyfin item for one user.
+type jellyfinUserData struct {
+	PlaybackPositionTicks int64
+	PlayCount             int64
+	Played                bool
+	LastPlayedDate        string
+}
+
+// type jellyfinItem is a Jellyfin item (a movie, episode, or track). only the
+// fields pimmp has a use for are defined.
+type jellyfinItem struct {
+	Id                string
+	Name              string
+	Path              string
+	Overview          string
+	PremiereDate      string
+	OfficialRating    string
+	Genres            []string
+	ImageTags         map[string]string
+	BackdropImageTags []string
+	UserData          *jellyfinUserData
+}
+
+// type jellyfinResult is the root object of the responses of Jellyfin's item
+// queries.
+type jellyfinResult struct {
+	Items []jellyfinItem
+}
+
+// function ReadJellyfin() reads the Items from a Jellyfin library export, which
+// is the JSON returned by Jellyfin's HTTP API for a user's items, e.g.:
+//
+//	curl -o jellyfin.json -H "X-Emby-Token: ..." \
+//	  "http://server:8096/Users/<user id>/Items?Recursive=true&Fields=Path,Overview,PremiereDate,OfficialRating,Genres"
+//
+// the watch state imported is that of the user whose items were requested.
+func ReadJellyfin(r io.Reader) ([]*Item, *rc.ReturnCode) {
+
+	res := &jellyfinResult{}
+	if err := json.NewDecoder(r).Decode(res); nil != err {
+		return nil, rc.ImportError.Specf("ReadJellyfin(): json.Decode(): %s", err)
+	}
+
+	items := []*Item{}
+	for _, ji := range res.Items {
+		// folders, series, seasons, etc. have no file of their own.
+		if "" == ji.Path {
+			continue
+		}
+		item := &Item{
+			Path:        ji.Path,
+			Title:       ji.Name,
+			Description: ji.Overview,
+			Artwork:     map[string]string{},
+		}
+		item.ContentRating = ji.OfficialRating
+		item.Genres = ji.Genres
+		if "" != ji.PremiereDate {
+			if date, err := time.Parse(time.RFC3339Nano, ji.PremiereDate); nil == err {
+				item.ReleaseDate = date
+			}
+		}
+		if ud := ji.UserData; nil != ud {
+			item.PlayCount = ud.PlayCount
+			if ud.Played && 0 == item.PlayCount {
+				item.PlayCount = 1
+			}
+			item.ResumePosition = time.Duration(ud.PlaybackPositionTicks) *
+				(time.Second / jellyfinTicksPerSecond)
+			if "" != ud.LastPlayedDate {
+				if date, err := time.Parse(time.RFC3339Nano, ud.LastPlayedDate); nil == err {
+					item.LastPlayed = date
+				}
+			}
+		}
+		// artwork is served by Jellyfin itself, so these are paths relative to
+		// the server's URL.
+		if _, ok := ji.ImageTags["Primary"]; ok {
+			item.Artwork["poster"] = "/Items/" + ji.Id + "/Images/Primary"
+		}
+		if len(ji.BackdropImageTags) > 0 {
+			item.Artwork["fanart"] = "/Items/" + ji.Id + "/Images/Backdrop/0"
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
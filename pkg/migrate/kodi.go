@@ -0,0 +1,183 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: kodi.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the importer of Kodi .nfo files, the XML sidecar files in which
+//    Kodi (and the tools curating Kodi libraries) store the metadata of each
+//    video beside it.
+//
+// =============================================================================
+
+package migrate
+
+import (
+	"encoding/xml"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the Kodi importer.
+const (
+	kodiDateFormat = "2006-01-02" // format of premiered and aired
+)
+
+// type kodiThumb is a single artwork element of a Kodi .nfo file.
+type kodiThumb struct {
+	Aspect string `xml:"aspect,attr"`
+	Path   string `xml:",chardata"`
+}
+
+// type kodiNFO is the root element of a Kodi .nfo file describing a movie
+// (<movie>), an episode (<episodedetails>), or a music video (<musicvideo>),
+// which share the elements pimmp has a use for. numbers are read as strings,
+// since Kodi tolerates (and writes) empty elements where they are unknown.
+type kodiNFO struct {
+	XMLName    xml.Name
+	Title      string      `xml:"title"`
+	ShowTitle  string      `xml:"showtitle"`
+	Season     string      `xml:"season"`
+	Episode    string      `xml:"episode"`
+	Plot       string      `xml:"plot"`
+	Outline    string      `xml:"outline"`
+	MPAA       string      `xml:"mpaa"`
+	Genre      []string    `xml:"genre"`
+	Premiered  string      `xml:"premiered"`
+	Aired      string      `xml:"aired"`
+	Year       string      `xml:"year"`
+	UserRating string      `xml:"userrating"`
+	Thumb      []kodiThumb `xml:"thumb"`
+	Fanart     struct {
+		Thumb []kodiThumb `xml:"thumb"`
+	} `xml:"fanart"`
+}
+
+// function ReadKodi() reads the Item described by a Kodi .nfo file. paths of
+// artwork relative to the file are resolved against the given directory, the
+// one containing it. the Item's path is left empty, since the file describes
+// whichever video shares its base name (see SupportMetadata).
+//
+// only the user's own rating (<userrating>) is kept, as the media's rating is
+// the user's; the ratings of online databases (<ratings>) are not.
+func ReadKodi(r io.Reader, dir string) (*Item, *rc.ReturnCode) {
+
+	nfo := &kodiNFO{}
+	if err := xml.NewDecoder(r).Decode(nfo); nil != err {
+		return nil, rc.ImportError.Specf("ReadKodi(): xml.Decode(): %s", err)
+	}
+	switch nfo.XMLName.Local {
+	case "movie", "episodedetails", "musicvideo":
+	default:
+		return nil, rc.ImportError.Specf(
+			"ReadKodi(): unsupported root element: <%s>", nfo.XMLName.Local)
+	}
+
+	number := func(s string) int64 {
+		n, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return int64(math.Round(n))
+	}
+	item := &Item{
+		Title:       strings.TrimSpace(nfo.Title),
+		Description: strings.TrimSpace(nfo.Plot),
+		Artwork:     map[string]string{},
+		Genres:      []string{},
+		Year:        number(nfo.Year),
+	}
+	if "" == item.Description {
+		item.Description = strings.TrimSpace(nfo.Outline)
+	}
+	if "episodedetails" == nfo.XMLName.Local {
+		item.Series = strings.TrimSpace(nfo.ShowTitle)
+		item.Season, item.Episode = number(nfo.Season), number(nfo.Episode)
+	}
+	if r := number(nfo.UserRating); r > 0 && r <= media.MaxRating {
+		item.Rating = r
+	}
+
+	// Kodi's MPAA element is often prefixed by the country, e.g. "Rated PG".
+	item.ContentRating = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(nfo.MPAA), "Rated "))
+
+	for _, g := range nfo.Genre {
+		// genres are sometimes listed in a single element, slash-separated.
+		for _, s := range strings.Split(g, " / ") {
+			if s = strings.TrimSpace(s); "" != s {
+				item.Genres = append(item.Genres, s)
+			}
+		}
+	}
+
+	for _, d := range []string{nfo.Premiered, nfo.Aired} {
+		if t, err := time.Parse(kodiDateFormat, strings.TrimSpace(d)); nil == err {
+			item.ReleaseDate = t
+			break
+		}
+	}
+	if item.ReleaseDate.IsZero() && item.Year > 0 {
+		item.ReleaseDate = time.Date(int(item.Year), time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if 0 == item.Year && !item.ReleaseDate.IsZero() {
+		item.Year = int64(item.ReleaseDate.Year())
+	}
+
+	artwork := func(kind, path string) {
+		if path = strings.TrimSpace(path); "" == path || "" != item.Artwork[kind] {
+			return
+		}
+		if !strings.Contains(path, "://") && !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		item.Artwork[kind] = path
+	}
+	for _, t := range nfo.Thumb {
+		kind := t.Aspect
+		if "" == kind {
+			kind = "poster"
+		}
+		artwork(kind, t.Path)
+	}
+	for _, t := range nfo.Fanart.Thumb {
+		artwork("fanart", t.Path)
+	}
+
+	return item, nil
+}
+
+// function ApplyVideo() is like Apply(), but also copies the year, series,
+// season, and episode of the Item into the given video, in preference to those
+// parsed from its file name. returns true if any field of the video was
+// changed.
+func (i *Item) ApplyVideo(v *media.VideoMedia) bool {
+
+	changed := i.Apply(v.Media)
+	setInt := func(dst *int64, src int64) {
+		if src > 0 && *dst != src {
+			*dst, changed = src, true
+		}
+	}
+
+	setInt(&v.Year, i.Year)
+	if "" != i.Series {
+		if v.Series != i.Series {
+			v.Series, changed = i.Series, true
+		}
+		setInt(&v.Season, i.Season)
+		setInt(&v.Episode, i.Episode)
+		if v.LastEpisode < v.Episode {
+			v.LastEpisode, changed = v.Episode, true
+		}
+	}
+	if changed {
+		v.UpdateKeys()
+	}
+	return changed
+}
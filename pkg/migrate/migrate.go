@@ -0,0 +1,157 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: migrate.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the types common to all importers of other media servers' data,
+//    and the rules by which imported data is merged into existing records.
+//
+// =============================================================================
+
+// package migrate reads the libraries of other media servers (Plex, Jellyfin,
+// Kodi) so that their metadata and watch state can seed the records of pimmp.
+package migrate
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Item is the metadata and watch state of a single file as recorded by
+// some other media server. fields the server didn't define are left zero.
+type Item struct {
+	Path           string            // absolute path to the file, as seen by the server
+	Title          string            // official name of media
+	Description    string            // synopsis/summary of media content
+	ReleaseDate    time.Time         // date media was produced/released
+	PlayCount      int64             // number of times media was played to completion
+	LastPlayed     time.Time         // date media was last played
+	ResumePosition time.Duration     // offset at which playback was last stopped
+	Artwork        map[string]string // path or URL of artwork, keyed by kind
+	ContentRating  string            // official content/age rating
+	Genres         []string          // genres of the media content
+	Rating         int64             // user-assigned rating, from 1 to media.MaxRating
+	Year           int64             // year of release of a movie or series (see ApplyVideo())
+	Series         string            // name of the TV series of an episode
+	Season         int64             // season number of an episode
+	Episode        int64             // episode number of an episode within its season
+}
+
+// function Apply() copies the metadata of the Item into the given media. only
+// the fields the server actually defined are copied, and the watch state is
+// merged such that media already played in pimmp never loses its history.
+// returns true if any field of the media was changed.
+func (i *Item) Apply(m *media.Media) bool {
+
+	changed := false
+	setString := func(dst *string, src string) {
+		if "" != src && *dst != src {
+			*dst, changed = src, true
+		}
+	}
+	setTime := func(dst *time.Time, src time.Time) {
+		if !src.IsZero() && src.After(*dst) {
+			*dst, changed = src, true
+		}
+	}
+
+	setString(&m.Title, i.Title)
+	setString(&m.Description, i.Description)
+	setString(&m.ContentRating, i.ContentRating)
+	if !i.ReleaseDate.IsZero() && !i.ReleaseDate.Equal(m.ReleaseDate) {
+		m.ReleaseDate, changed = i.ReleaseDate, true
+	}
+
+	if i.PlayCount > m.PlayCount {
+		m.PlayCount, changed = i.PlayCount, true
+	}
+	if m.PlayCount > 0 && !m.Watched {
+		m.Watched, changed = true, true
+	}
+	// the resume position only makes sense relative to the most recent time
+	// the media was played, so it is kept only if the server's is newer.
+	if !i.LastPlayed.IsZero() && i.LastPlayed.After(m.LastPlayed) {
+		if i.ResumePosition != m.ResumePosition {
+			m.ResumePosition, changed = i.ResumePosition, true
+		}
+	}
+	setTime(&m.LastPlayed, i.LastPlayed)
+
+	if i.Rating > 0 && i.Rating != m.Rating {
+		m.Rating, changed = i.Rating, true
+	}
+
+	if len(i.Genres) > 0 && strings.Join(i.Genres, "\n") != strings.Join(m.Genres, "\n") {
+		m.Genres, changed = append([]string{}, i.Genres...), true
+	}
+
+	for kind, art := range i.Artwork {
+		if "" == art {
+			continue
+		}
+		if nil == m.Artwork {
+			m.Artwork = map[string]string{}
+		}
+		if m.Artwork[kind] != art {
+			m.Artwork[kind], changed = art, true
+		}
+	}
+
+	return changed
+}
+
+// type PathMap translates the paths used by another media server into the
+// paths used by pimmp, e.g. when the server runs in a container or on another
+// host with the library mounted at a different location.
+type PathMap struct {
+	prefix [][2]string // {from, to} pairs, longest from-prefix first
+}
+
+// function NewPathMap() parses a comma-separated list of "from=to" prefix
+// substitutions. an empty string yields a PathMap that changes nothing.
+func NewPathMap(spec string) (*PathMap, *rc.ReturnCode) {
+
+	pm := &PathMap{prefix: [][2]string{}}
+	for _, pair := range strings.Split(spec, ",") {
+		if "" == strings.TrimSpace(pair) {
+			continue
+		}
+		sep := strings.Index(pair, "=")
+		if sep <= 0 {
+			return nil, rc.InvalidArgs.Specf(
+				"NewPathMap(%q): expected \"from=to\": %q", spec, pair)
+		}
+		from := filepath.Clean(strings.TrimSpace(pair[:sep]))
+		to := filepath.Clean(strings.TrimSpace(pair[sep+1:]))
+		pm.prefix = append(pm.prefix, [2]string{from, to})
+	}
+
+	// prefer the most specific substitution when prefixes are nested.
+	sort.SliceStable(pm.prefix, func(a, b int) bool {
+		return len(pm.prefix[a][0]) > len(pm.prefix[b][0])
+	})
+	return pm, nil
+}
+
+// function Map() translates the given server path into a local path.
+func (pm *PathMap) Map(path string) string {
+
+	path = filepath.Clean(path)
+	for _, p := range pm.prefix {
+		if path == p[0] {
+			return p[1]
+		}
+		if strings.HasPrefix(path, p[0]+string(filepath.Separator)) {
+			return filepath.Join(p[1], strings.TrimPrefix(path, p[0]))
+		}
+	}
+	return path
+}
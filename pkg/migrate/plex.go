@@ -0,0 +1,124 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: plex.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the importer of Plex library exports.
+//
+// =============================================================================
+
+package migrate
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the Plex importer.
+const (
+	plexDateFormat = "2006-01-02" // format of originallyAvailableAt
+)
+
+// type plexPart is a single file of a Plex metadata item.
+type plexPart struct {
+	File string `xml:"file,attr"`
+}
+
+// type plexTag is a single tag (genre, director, etc.) of a Plex metadata item.
+type plexTag struct {
+	Tag string `xml:"tag,attr"`
+}
+
+// type plexMedia is a single version of a Plex metadata item, which may be
+// split across several files.
+type plexMedia struct {
+	Part []plexPart `xml:"Part"`
+}
+
+// type plexItem is a Plex metadata item (a movie, episode, or track). only the
+// attributes pimmp has a use for are defined.
+type plexItem struct {
+	Title        string      `xml:"title,attr"`
+	Summary      string      `xml:"summary,attr"`
+	Available    string      `xml:"originallyAvailableAt,attr"`
+	ViewCount    int64       `xml:"viewCount,attr"`
+	ViewOffset   int64       `xml:"viewOffset,attr"`   // milliseconds
+	LastViewedAt int64       `xml:"lastViewedAt,attr"` // seconds since epoch
+	Thumb        string      `xml:"thumb,attr"`
+	Art          string      `xml:"art,attr"`
+	Rated        string      `xml:"contentRating,attr"`
+	Genre        []plexTag   `xml:"Genre"`
+	Media        []plexMedia `xml:"Media"`
+}
+
+// type plexContainer is the root element of the responses of Plex's HTTP API.
+type plexContainer struct {
+	XMLName xml.Name   `xml:"MediaContainer"`
+	Video   []plexItem `xml:"Video"`
+	Track   []plexItem `xml:"Track"`
+}
+
+// function ReadPlex() reads the Items from a Plex library export, which is the
+// XML returned by Plex's HTTP API for the contents of a library section, e.g.:
+//
+//	curl -o plex.xml "http://server:32400/library/sections/1/all?X-Plex-Token=..."
+//
+// for TV libraries, request the episodes with "all?type=4". an Item is
+// returned for each file of each movie, episode, or track.
+func ReadPlex(r io.Reader) ([]*Item, *rc.ReturnCode) {
+
+	mc := &plexContainer{}
+	if err := xml.NewDecoder(r).Decode(mc); nil != err {
+		return nil, rc.ImportError.Specf("ReadPlex(): xml.Decode(): %s", err)
+	}
+
+	items := []*Item{}
+	for _, pi := range append(mc.Video, mc.Track...) {
+		item := &Item{
+			Title:          pi.Title,
+			Description:    pi.Summary,
+			PlayCount:      pi.ViewCount,
+			ResumePosition: time.Duration(pi.ViewOffset) * time.Millisecond,
+			Artwork:        map[string]string{},
+			ContentRating:  pi.Rated,
+		}
+		if "" != pi.Available {
+			if date, err := time.Parse(plexDateFormat, pi.Available); nil == err {
+				item.ReleaseDate = date
+			}
+		}
+		for _, g := range pi.Genre {
+			if "" != g.Tag {
+				item.Genres = append(item.Genres, g.Tag)
+			}
+		}
+		if pi.LastViewedAt > 0 {
+			item.LastPlayed = time.Unix(pi.LastViewedAt, 0)
+		}
+		// artwork is served by Plex itself, so these are paths relative to
+		// the server's URL.
+		if "" != pi.Thumb {
+			item.Artwork["poster"] = pi.Thumb
+		}
+		if "" != pi.Art {
+			item.Artwork["fanart"] = pi.Art
+		}
+		for _, m := range pi.Media {
+			for _, p := range m.Part {
+				if "" == p.File {
+					continue
+				}
+				part := *item
+				part.Path = p.File
+				items = append(items, &part)
+			}
+		}
+	}
+	return items, nil
+}
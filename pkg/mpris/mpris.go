@@ -0,0 +1,487 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: mpris.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    exposes the media playing on the D-Bus session bus by the MPRIS interface
+//    (org.mpris.MediaPlayer2), so that desktop environments, media keys, and
+//    tools like playerctl can control playback.
+//
+// =============================================================================
+
+// package mpris implements the Media Player Remote Interfacing Specification
+// (https://specifications.freedesktop.org/mpris-spec/latest/) for the players
+// launched by pimmp. a Server is a player.Controller: while media is playing,
+// it owns the bus name org.mpris.MediaPlayer2.pimmp (or a unique instance of
+// it, if taken by another pimmp), describes the media by its metadata, and
+// forwards the requests of other programs to the player's Session.
+package mpris
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/player"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// var logs are the loggers of the messages about playback, filtered as
+// component "play" like those of package player (see console.Register()).
+var logs = console.Register("play")
+
+// local unexported constants defined by the MPRIS specification.
+const (
+	busName     = "org.mpris.MediaPlayer2.pimmp"              // name owned while playing
+	objectPath  = "/org/mpris/MediaPlayer2"                   // path of the exported object
+	rootIface   = "org.mpris.MediaPlayer2"                    // interface of the application
+	playerIface = "org.mpris.MediaPlayer2.Player"             // interface of playback
+	trackPath   = "/org/ardnew/pimmp/track"                   // prefix of the track IDs
+	noTrack     = "/org/mpris/MediaPlayer2/TrackList/NoTrack" // track ID when none is playing
+
+	statusPlaying = "Playing"
+	statusPaused  = "Paused"
+	statusStopped = "Stopped"
+)
+
+// constant seekTolerance is how far the position of playback may differ from
+// where it is expected to be, given the time passed, before it is considered
+// to have been sought (see signal Seeked).
+const seekTolerance = time.Second
+
+// type Server exports the MPRIS interface of the media playing on the session
+// bus. its methods are safe for concurrent use, and those of a nil Server do
+// nothing.
+type Server struct {
+	conn  *dbus.Conn
+	props *prop.Properties
+
+	lock     sync.Mutex      // guards the fields below
+	name     string          // bus name owned while playing, empty if none
+	session  *player.Session // controls the media playing, nil if it cannot be controlled
+	track    dbus.ObjectPath // ID of the media playing
+	tracks   int             // number of media played, identifying each
+	duration time.Duration   // length of the media playing, 0 if unknown
+	position time.Duration   // position of playback when last reported
+	reported time.Time       // when the position was last reported
+	paused   bool            // playback is paused
+}
+
+// type root implements the methods of interface org.mpris.MediaPlayer2.
+type root struct{ s *Server }
+
+// type control implements the methods of interface
+// org.mpris.MediaPlayer2.Player.
+type control struct{ s *Server }
+
+// variable controlMethods maps the methods of control named differently from
+// the D-Bus methods they implement: a method named Seek must have the
+// signature of io.Seeker's, which go vet enforces.
+var controlMethods = map[string]string{"SeekBy": "Seek"}
+
+// function New() connects to the session bus and exports the MPRIS interface
+// on it. the bus name is only requested once media starts playing (see
+// Started()). use Close() to disconnect.
+func New() (*Server, *rc.ReturnCode) {
+
+	conn, err := dbus.ConnectSessionBus()
+	if nil != err {
+		return nil, rc.BusError.Specf("New(): cannot connect to session bus: %s", err)
+	}
+	s := &Server{conn: conn, track: noTrack}
+
+	fail := func(err error) (*Server, *rc.ReturnCode) {
+		conn.Close()
+		return nil, rc.BusError.Specf("New(): cannot export %s: %s", rootIface, err)
+	}
+
+	if err := conn.Export(root{s}, objectPath, rootIface); nil != err {
+		return fail(err)
+	}
+	if err := conn.ExportWithMap(control{s}, controlMethods, objectPath, playerIface); nil != err {
+		return fail(err)
+	}
+
+	constant := func(v interface{}) *prop.Prop {
+		return &prop.Prop{Value: v, Writable: false, Emit: prop.EmitConst}
+	}
+	changing := func(v interface{}) *prop.Prop {
+		return &prop.Prop{Value: v, Writable: false, Emit: prop.EmitTrue}
+	}
+	props, err := prop.Export(conn, objectPath, prop.Map{
+		rootIface: {
+			"CanQuit":             constant(false),
+			"CanRaise":            constant(false),
+			"HasTrackList":        constant(false),
+			"Identity":            constant("pimmp"),
+			"SupportedUriSchemes": constant([]string{}),
+			"SupportedMimeTypes":  constant([]string{}),
+		},
+		playerIface: {
+			"PlaybackStatus": changing(statusStopped),
+			"Rate":           constant(1.0),
+			"MinimumRate":    constant(1.0),
+			"MaximumRate":    constant(1.0),
+			"Volume":         constant(1.0),
+			"Metadata":       changing(map[string]dbus.Variant{"mpris:trackid": dbus.MakeVariant(dbus.ObjectPath(noTrack))}),
+			// the position changes continuously, so its changes are never
+			// signaled; only jumps are, by signal Seeked.
+			"Position":      {Value: int64(0), Writable: false, Emit: prop.EmitFalse},
+			"CanGoNext":     constant(false),
+			"CanGoPrevious": constant(false),
+			"CanPlay":       changing(false),
+			"CanPause":      changing(false),
+			"CanSeek":       changing(false),
+			"CanControl":    changing(false),
+		},
+	})
+	if nil != err {
+		return fail(err)
+	}
+	s.props = props
+
+	node := &introspect.Node{
+		Name: objectPath,
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name:       rootIface,
+				Methods:    introspect.Methods(root{s}),
+				Properties: props.Introspection(rootIface),
+			},
+			{
+				Name:       playerIface,
+				Methods:    controlIntrospection(s),
+				Properties: props.Introspection(playerIface),
+				Signals: []introspect.Signal{{
+					Name: "Seeked",
+					Args: []introspect.Arg{{Name: "Position", Type: "x"}},
+				}},
+			},
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), objectPath,
+		"org.freedesktop.DBus.Introspectable"); nil != err {
+		return fail(err)
+	}
+
+	return s, nil
+}
+
+// function controlIntrospection() describes the methods of the player
+// interface, by the names under which they are exported.
+func controlIntrospection(s *Server) []introspect.Method {
+	methods := introspect.Methods(control{s})
+	for i := range methods {
+		if name, ok := controlMethods[methods[i].Name]; ok {
+			methods[i].Name = name
+		}
+	}
+	return methods
+}
+
+// function Close() releases the bus name, if owned, and disconnects from the
+// session bus.
+func (s *Server) Close() {
+	if nil == s {
+		return
+	}
+	s.lock.Lock()
+	s.release()
+	s.lock.Unlock()
+	s.conn.Close()
+}
+
+// function Started() describes the given media as playing, controlled by the
+// given Session (nil if it cannot be controlled), and requests the bus name so
+// that other programs find it.
+func (s *Server) Started(m *media.Media, session *player.Session) {
+	if nil == s || nil == m || nil == m.Entity {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.tracks++
+	s.session = session
+	s.track = dbus.ObjectPath(fmt.Sprintf("%s/%d", trackPath, s.tracks))
+	s.duration = m.Duration
+	s.position, s.reported, s.paused = m.ResumePosition, time.Now(), false
+
+	controlled := nil != session
+	s.props.SetMust(playerIface, "Metadata", s.metadata(m))
+	s.props.SetMust(playerIface, "Position", micro(m.ResumePosition))
+	s.props.SetMust(playerIface, "PlaybackStatus", statusPlaying)
+	for _, name := range []string{"CanPlay", "CanPause", "CanSeek", "CanControl"} {
+		s.props.SetMust(playerIface, name, controlled)
+	}
+
+	if "" == s.name {
+		s.request()
+	}
+}
+
+// function Changed() updates the position and status of the media playing
+// with the given progress of playback, signaling Seeked if the position
+// jumped.
+func (s *Server) Changed(p player.Progress) {
+	if nil == s {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if noTrack == s.track {
+		return
+	}
+
+	now := time.Now()
+	expect := s.position
+	if !s.paused {
+		expect += now.Sub(s.reported)
+	}
+	jump := p.Position - expect
+	s.position, s.reported = p.Position, now
+
+	s.props.SetMust(playerIface, "Position", micro(p.Position))
+	if jump > seekTolerance || jump < -seekTolerance {
+		if err := s.conn.Emit(objectPath, playerIface+".Seeked", micro(p.Position)); nil != err {
+			logs.Warn.Verbosef("mpris: cannot signal Seeked: %s", err)
+		}
+	}
+	if p.Paused != s.paused {
+		s.paused = p.Paused
+		status := statusPlaying
+		if p.Paused {
+			status = statusPaused
+		}
+		s.props.SetMust(playerIface, "PlaybackStatus", status)
+	}
+	if p.Duration > 0 && p.Duration != s.duration {
+		s.duration = p.Duration
+		meta := s.props.GetMust(playerIface, "Metadata").(map[string]dbus.Variant)
+		update := make(map[string]dbus.Variant, len(meta)+1)
+		for k, v := range meta {
+			update[k] = v
+		}
+		update["mpris:length"] = dbus.MakeVariant(micro(p.Duration))
+		s.props.SetMust(playerIface, "Metadata", update)
+	}
+}
+
+// function Stopped() describes nothing as playing, and releases the bus name,
+// since pimmp is not a player once playback has finished.
+func (s *Server) Stopped(m *media.Media) {
+	if nil == s {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.session = nil
+	s.track = noTrack
+	s.props.SetMust(playerIface, "PlaybackStatus", statusStopped)
+	s.props.SetMust(playerIface, "Metadata",
+		map[string]dbus.Variant{"mpris:trackid": dbus.MakeVariant(dbus.ObjectPath(noTrack))})
+	s.props.SetMust(playerIface, "Position", int64(0))
+	for _, name := range []string{"CanPlay", "CanPause", "CanSeek", "CanControl"} {
+		s.props.SetMust(playerIface, name, false)
+	}
+	s.release()
+}
+
+// function request() requests the bus name, or a unique instance of it if
+// another pimmp owns it already. the lock must be held.
+func (s *Server) request() {
+	for _, name := range []string{busName, fmt.Sprintf("%s.instance%d", busName, os.Getpid())} {
+		reply, err := s.conn.RequestName(name, dbus.NameFlagDoNotQueue)
+		if nil != err {
+			logs.Warn.Verbosef("mpris: cannot request bus name %q: %s", name, err)
+			return
+		}
+		if dbus.RequestNameReplyPrimaryOwner == reply {
+			s.name = name
+			return
+		}
+	}
+	logs.Warn.Verbosef("mpris: bus name %q already taken", busName)
+}
+
+// function release() releases the bus name, if owned. the lock must be held.
+func (s *Server) release() {
+	if "" == s.name {
+		return
+	}
+	if _, err := s.conn.ReleaseName(s.name); nil != err {
+		logs.Warn.Verbosef("mpris: cannot release bus name %q: %s", s.name, err)
+	}
+	s.name = ""
+}
+
+// function metadata() returns the MPRIS metadata of the given media, played
+// as the current track. the lock must be held.
+func (s *Server) metadata(m *media.Media) map[string]dbus.Variant {
+
+	title := m.Title
+	if "" == title {
+		title = m.Name
+	}
+	meta := map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(s.track),
+		"xesam:title":   dbus.MakeVariant(title),
+		"xesam:url":     dbus.MakeVariant(fileURL(m.File())),
+	}
+	if m.Duration > 0 {
+		meta["mpris:length"] = dbus.MakeVariant(micro(m.Duration))
+	}
+	if "" != m.ArtworkFile {
+		meta["mpris:artUrl"] = dbus.MakeVariant(fileURL(m.ArtworkFile))
+	}
+	if len(m.Genres) > 0 {
+		meta["xesam:genre"] = dbus.MakeVariant(m.Genres)
+	}
+	if "" != m.Description {
+		meta["xesam:comment"] = dbus.MakeVariant([]string{m.Description})
+	}
+	if m.Rating > 0 {
+		meta["xesam:userRating"] = dbus.MakeVariant(float64(m.Rating) / float64(media.MaxRating))
+	}
+	if m.PlayCount > 0 {
+		meta["xesam:useCount"] = dbus.MakeVariant(int32(m.PlayCount))
+	}
+	return meta
+}
+
+// function controlled() returns the Session controlling the media playing, or
+// a D-Bus error if nothing playing can be controlled.
+func (s *Server) controlled() (*player.Session, *dbus.Error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if nil == s.session {
+		return nil, dbus.MakeFailedError(fmt.Errorf("no controllable media playing"))
+	}
+	return s.session, nil
+}
+
+// function fileURL() returns the file:// URL of the file at the given path.
+func fileURL(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// function micro() returns the given duration in microseconds, the unit of all
+// times in MPRIS.
+func micro(d time.Duration) int64 {
+	return int64(d / time.Microsecond)
+}
+
+// function dbusError() converts the given error, if any, to a D-Bus error.
+func dbusError(ret *rc.ReturnCode) *dbus.Error {
+	if nil == ret {
+		return nil
+	}
+	return dbus.MakeFailedError(ret)
+}
+
+// function Raise() does nothing, since pimmp has no window of its own to raise
+// (see property CanRaise).
+func (r root) Raise() *dbus.Error { return nil }
+
+// function Quit() does nothing, since pimmp cannot be quit remotely (see
+// property CanQuit).
+func (r root) Quit() *dbus.Error { return nil }
+
+// function Next() does nothing, since there is no track list (see property
+// CanGoNext).
+func (c control) Next() *dbus.Error { return nil }
+
+// function Previous() does nothing, since there is no track list (see
+// property CanGoPrevious).
+func (c control) Previous() *dbus.Error { return nil }
+
+// function Pause() pauses playback.
+func (c control) Pause() *dbus.Error {
+	session, err := c.s.controlled()
+	if nil != err {
+		return err
+	}
+	return dbusError(session.Pause())
+}
+
+// function Play() resumes playback once paused.
+func (c control) Play() *dbus.Error {
+	session, err := c.s.controlled()
+	if nil != err {
+		return err
+	}
+	return dbusError(session.Resume())
+}
+
+// function PlayPause() pauses playback if playing, or resumes it if paused.
+func (c control) PlayPause() *dbus.Error {
+	session, err := c.s.controlled()
+	if nil != err {
+		return err
+	}
+	return dbusError(session.TogglePause())
+}
+
+// function Stop() stops playback, exiting the player.
+func (c control) Stop() *dbus.Error {
+	session, err := c.s.controlled()
+	if nil != err {
+		return err
+	}
+	return dbusError(session.Stop())
+}
+
+// function SeekBy() moves playback by the given offset in microseconds from the
+// current position (a negative offset moves backward). it is exported as the
+// method Seek (see controlMethods).
+func (c control) SeekBy(offset int64) *dbus.Error {
+	session, err := c.s.controlled()
+	if nil != err {
+		return err
+	}
+	return dbusError(session.Seek(time.Duration(offset)*time.Microsecond, true))
+}
+
+// function SetPosition() moves playback of the track with the given ID to the
+// given position in microseconds. requests for any other track, or positions
+// beyond the end of the track, are ignored as the specification requires.
+func (c control) SetPosition(track dbus.ObjectPath, position int64) *dbus.Error {
+	session, err := c.s.controlled()
+	if nil != err {
+		return err
+	}
+	c.s.lock.Lock()
+	current, duration := c.s.track, c.s.duration
+	c.s.lock.Unlock()
+
+	at := time.Duration(position) * time.Microsecond
+	if track != current || at < 0 || (duration > 0 && at > duration) {
+		return nil
+	}
+	return dbusError(session.Seek(at, false))
+}
+
+// function OpenUri() refuses to open anything, since pimmp only plays the
+// media of its libraries (see property SupportedUriSchemes).
+func (c control) OpenUri(uri string) *dbus.Error {
+	return dbus.MakeFailedError(fmt.Errorf("cannot open %q: unsupported", uri))
+}
@@ -0,0 +1,184 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: naming.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    recognizes the common naming conventions of video files, extracting the
+//    series, season, and episode numbers of TV episodes and the title and year
+//    of movies.
+//
+// =============================================================================
+
+// package naming parses the names of video files, which are the only reliable
+// description of most media until it is looked up online. the conventions
+// recognized are those of the scene and of common media servers, e.g.:
+//
+//	Show.Name.S02E05.Episode.Title      (episode 5 of season 2)
+//	Show Name - 2x05 - Episode Title    (same)
+//	Show.Name.S02E05E06, S02E05-E06     (episodes 5 and 6 of season 2)
+//	Movie.Title.2019.1080p.BluRay       (movie released in 2019)
+//	Movie Title (2019)                  (same)
+//	Movie.Title.2019.CD1                (part 1 of a multi-part release)
+package naming
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// seasonEpisode recognizes the "S02E05" naming of TV episodes, capturing the
+// series, season, first episode, any further episodes of a multi-episode file
+// (e.g. "E06", "-E06", or "-06"), and the (optional) episode title.
+var seasonEpisode = regexp.MustCompile(
+	`(?i)^(.*?)[ ._-]*\bs(\d{1,3})[ ._-]?e(\d{1,3})((?:[ ._-]?e\d{1,3}|-\d{1,3})*)(?:[ ._-]+(.*))?$`)
+
+// crossEpisode recognizes the "2x05" naming of TV episodes, capturing the same
+// as seasonEpisode. further episodes are written "-06" or "-2x06".
+var crossEpisode = regexp.MustCompile(
+	`(?i)^(.+?)[ ._-]+(\d{1,2})x(\d{2,3})((?:-(?:\d{1,2}x)?\d{2,3})*)(?:[ ._-]+(.*))?$`)
+
+// movieYear recognizes the naming of movies by title and year, capturing both.
+// anything following the year is release info (resolution, source, etc.), not
+// title.
+var movieYear = regexp.MustCompile(
+	`^(.+?)[ ._-]*[(\[]?((?:19|20)\d{2})[)\]]?(?:[ ._-].*)?$`)
+
+// trailingYear recognizes a year at the end of a series name, e.g.
+// "Show Name 2019" or "Show Name (2019)", distinguishing remakes.
+var trailingYear = regexp.MustCompile(`^(.+?)[ ._-]*[(\[]?((?:19|20)\d{2})[)\]]?$`)
+
+// releaseInfo recognizes the first of the tags describing a release rather than
+// its content, e.g. "1080p", "x264", or "BluRay", which end a title.
+var releaseInfo = regexp.MustCompile(
+	`(?i)(?:^|[ ._-])(?:\d{3,4}[pi]|[xh][ .]?26[45]|hevc|avc|xvid|divx|10bit|hdr|` +
+		`blu-?ray|bdrip|brrip|remux|web-?dl|webrip|hdtv|dvdrip|dvd|proper|repack|internal|` +
+		`aac|ac3|dts|multi|subbed)(?:[ ._-]|$)`)
+
+// partNumber recognizes the part number ending the name of a file of a release
+// split into several, e.g. "CD1", "Disc 2", "part3", or "(pt 1)", capturing the
+// name preceding it and the number.
+var partNumber = regexp.MustCompile(
+	`(?i)^(.+?)[ ._-]+[(\[]?(?:cd|dis[ck]|part|pt)[ ._-]?(\d{1,2})[)\]]?$`)
+
+// episodeNumber finds each episode number of a multi-episode suffix.
+var episodeNumber = regexp.MustCompile(`(\d{1,3})(?:\D|$)`)
+
+// type Name is the description of a video parsed from its file name. fields
+// the name doesn't define are left zero.
+type Name struct {
+	Title       string // title of the movie, or of the episode (if named)
+	Year        int    // year of release of the movie or series
+	Series      string // name of the TV series (empty for movies)
+	Season      int    // season number of the episode
+	Episode     int    // (first) episode number within its season
+	LastEpisode int    // last episode number of a multi-episode file (= Episode otherwise)
+}
+
+// function Parse() parses the given file name, without its extension. every
+// name parses as at least a title.
+func Parse(base string) *Name {
+
+	for _, re := range []*regexp.Regexp{seasonEpisode, crossEpisode} {
+		match := re.FindStringSubmatch(base)
+		if nil == match || "" == Spaced(match[1]) {
+			continue
+		}
+		n := &Name{Series: Spaced(match[1]), Title: title(match[5])}
+		n.Season, _ = strconv.Atoi(match[2])
+		n.Episode, _ = strconv.Atoi(match[3])
+		n.LastEpisode = n.Episode
+		for _, more := range episodeNumber.FindAllStringSubmatch(crossSuffix(match[4]), -1) {
+			if e, err := strconv.Atoi(more[1]); nil == err && e > n.LastEpisode {
+				n.LastEpisode = e
+			}
+		}
+		if year := trailingYear.FindStringSubmatch(n.Series); nil != year {
+			n.Series = Spaced(year[1])
+			n.Year, _ = strconv.Atoi(year[2])
+		}
+		return n
+	}
+	if match := movieYear.FindStringSubmatch(base); nil != match && "" != Spaced(match[1]) {
+		n := &Name{Title: Spaced(match[1])}
+		n.Year, _ = strconv.Atoi(match[2])
+		return n
+	}
+	if t := title(base); "" != t {
+		return &Name{Title: t}
+	}
+	return &Name{Title: Spaced(base)}
+}
+
+// function ParsePart() parses the given file name, without its extension, as a
+// part of a release split into several, returning the name without the part
+// and the part's number, or the name and 0 if it isn't a part. parts of the
+// same release have the same name and extension, in the same directory.
+func ParsePart(base string) (string, int) {
+	match := partNumber.FindStringSubmatch(base)
+	if nil == match {
+		return base, 0
+	}
+	part, err := strconv.Atoi(match[2])
+	if nil != err || part < 1 {
+		return base, 0
+	}
+	return strings.TrimRight(match[1], " ._-"), part
+}
+
+// function title() returns the given text, up to any release info following
+// it, with spaces in place of separators.
+func title(s string) string {
+	if loc := releaseInfo.FindStringIndex(s); nil != loc {
+		s = s[:loc[0]]
+	}
+	return Spaced(s)
+}
+
+// function crossSuffix() removes the season numbers from the further episodes
+// of a "2x05-2x06" name, leaving only their episode numbers.
+func crossSuffix(s string) string {
+	if strings.ContainsAny(s, "xX") {
+		parts := strings.Split(s, "-")
+		for k, p := range parts {
+			if j := strings.IndexAny(p, "xX"); j >= 0 {
+				parts[k] = p[j+1:]
+			}
+		}
+		return strings.Join(parts, "-")
+	}
+	return s
+}
+
+// function IsEpisode() returns true if the name is of a TV episode.
+func (n *Name) IsEpisode() bool { return "" != n.Series }
+
+// function IsMultiEpisode() returns true if the name is of a file containing
+// several consecutive TV episodes.
+func (n *Name) IsMultiEpisode() bool { return n.IsEpisode() && n.LastEpisode > n.Episode }
+
+// function String() returns the canonical form of the name, e.g. "Show Name
+// S02E05-E06" or "Movie Title (2019)".
+func (n *Name) String() string {
+	switch {
+	case n.IsMultiEpisode():
+		return fmt.Sprintf("%s S%02dE%02d-E%02d", n.Series, n.Season, n.Episode, n.LastEpisode)
+	case n.IsEpisode():
+		return fmt.Sprintf("%s S%02dE%02d", n.Series, n.Season, n.Episode)
+	case n.Year > 0:
+		return fmt.Sprintf("%s (%d)", n.Title, n.Year)
+	}
+	return n.Title
+}
+
+// function Spaced() replaces the dots and underscores commonly used in place of
+// spaces in file names with spaces, and trims any separators left at either
+// end.
+func Spaced(s string) string {
+	s = strings.NewReplacer(".", " ", "_", " ").Replace(s)
+	return strings.Trim(strings.Join(strings.Fields(s), " "), " -")
+}
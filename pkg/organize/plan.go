@@ -0,0 +1,125 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: plan.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    determines which media files must be moved to conform to a template, so
+//    that the moves can be previewed before any of them are performed.
+//
+// =============================================================================
+
+package organize
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Move is a single media file to be moved, from and to absolute paths.
+type Move struct {
+	From string
+	To   string
+}
+
+// function String() returns a description of the Move for display.
+func (m *Move) String() string {
+	return m.From + " -> " + m.To
+}
+
+// function Plan() returns the moves needed for the given media entities, all
+// in the library rooted at the given directory, to conform to the Template.
+// media already in place are omitted. media that can't be moved -- because a
+// field has no value, or because the destination is taken by another file or
+// by another of the media -- are omitted as well, with the reason for each
+// returned in the list of problems.
+func (t *Template) Plan(root string, ents []media.StorableEntity) ([]*Move, []*rc.ReturnCode) {
+
+	moves := []*Move{}
+	problems := []*rc.ReturnCode{}
+	taken := map[string]string{}
+
+	for _, ent := range ents {
+		from := pathOf(ent)
+		if "" == from {
+			continue
+		}
+		rel, ret := t.Render(FieldsOf(ent))
+		if nil != ret {
+			problems = append(problems, rc.InvalidPath.Specf("%q: %s", from, ret))
+			continue
+		}
+		to := filepath.Join(root, rel)
+		if to == from {
+			taken[to] = from
+			continue
+		}
+		if other, ok := taken[to]; ok {
+			problems = append(problems, rc.InvalidPath.Specf(
+				"%q: destination also chosen for %q: %q", from, other, to))
+			continue
+		}
+		// a destination differing only in case may be the source itself on a
+		// case-insensitive file system, which is fine to "move" over.
+		if _, err := os.Lstat(to); nil == err && !strings.EqualFold(to, from) {
+			problems = append(problems, rc.InvalidPath.Specf(
+				"%q: destination already exists: %q", from, to))
+			continue
+		}
+		taken[to] = from
+		moves = append(moves, &Move{From: from, To: to})
+	}
+
+	sort.Slice(moves, func(a, b int) bool { return moves[a].From < moves[b].From })
+	return moves, problems
+}
+
+// function pathOf() returns the absolute path of the given media entity, or an
+// empty string if it isn't a file of its own to be moved, i.e. the track of a
+// cue sheet, the folder of a video disc (whose name identifies its format), or
+// a part of a release split into several (see Parts of Media).
+func pathOf(ent media.StorableEntity) string {
+	switch e := ent.(type) {
+	case *media.AudioMedia:
+		if nil != e.Media && nil != e.Entity && !e.IsTrack() && 0 == len(e.Parts) && !e.IsPart() {
+			return e.AbsPath
+		}
+	case *media.VideoMedia:
+		if nil != e.Media && nil != e.Entity && !e.IsDisc() && 0 == len(e.Parts) && !e.IsPart() {
+			return e.AbsPath
+		}
+	case *media.ImageMedia:
+		if nil != e.Media && nil != e.Entity {
+			return e.AbsPath
+		}
+	case *media.DocumentMedia:
+		if nil != e.Media && nil != e.Entity {
+			return e.AbsPath
+		}
+	}
+	return ""
+}
+
+// function PruneDirs() removes the given directory if it is empty, and then
+// each of its parents that are left empty in turn, stopping at (and never
+// removing) the given root directory.
+func PruneDirs(dir, root string) {
+	for {
+		rel, err := filepath.Rel(root, dir)
+		if nil != err || "." == rel || strings.HasPrefix(rel, "..") {
+			return
+		}
+		// os.Remove() refuses to remove non-empty directories.
+		if err := os.Remove(dir); nil != err {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,258 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: template.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the path templates describing where each media file belongs, and
+//    the metadata fields that may be substituted into them.
+//
+// =============================================================================
+
+// package organize moves media files into a directory layout described by a
+// path template, e.g. "{show}/Season {s}/{show} - S{s:2}E{e:2} - {title}.{ext}",
+// filled in with each file's metadata.
+package organize
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/naming"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Fields maps the name of each metadata field to its value for a single
+// media file. values are either strings or ints; fields without a known value
+// are omitted.
+type Fields map[string]interface{}
+
+// the names of all fields recognized in templates, and a description of each.
+var FieldName = map[string]string{
+	"title":   "title of the media (or episode)",
+	"name":    "displayed name of the media",
+	"base":    "file name without extension",
+	"ext":     "file name extension",
+	"kind":    "kind of media (audio, video, image, document)",
+	"year":    "year of release",
+	"artist":  "artist performing an audio track",
+	"album":   "album on which an audio track appears",
+	"track":   "track number of an audio track",
+	"show":    "name of the TV show of an episode",
+	"s":       "season number of an episode",
+	"e":       "episode number of an episode",
+	"season":  "season number of an episode (same as s)",
+	"episode": "episode number of an episode (same as e)",
+	"author":  "author of a document",
+	"series":  "series to which a document belongs, or TV show of an episode (same as show)",
+}
+
+// type segment is a single piece of a parsed Template: either literal text, or
+// a field substituted with its value (padded with zeros to width, if numeric).
+type segment struct {
+	literal string
+	field   string
+	width   int
+}
+
+// type Template is a parsed path template. fields appear in braces, e.g.
+// "{title}", optionally with the minimum number of digits of a numeric field,
+// e.g. "{e:2}". the path separator "/" separates directories.
+type Template struct {
+	text    string
+	segment []segment
+}
+
+// function ParseTemplate() parses the given template text. the template must
+// describe a relative path which stays within the directory it is relative to.
+func ParseTemplate(text string) (*Template, *rc.ReturnCode) {
+
+	if "" == strings.TrimSpace(text) {
+		return nil, rc.InvalidArgs.Spec("ParseTemplate(): empty template")
+	}
+	if strings.HasPrefix(text, "/") || filepath.IsAbs(text) {
+		return nil, rc.InvalidArgs.Specf("ParseTemplate(%q): template must be a relative path", text)
+	}
+	for _, elem := range strings.Split(text, "/") {
+		if "" == elem || "." == elem || ".." == elem {
+			return nil, rc.InvalidArgs.Specf(
+				"ParseTemplate(%q): invalid path element: %q", text, elem)
+		}
+	}
+
+	t := &Template{text: text, segment: []segment{}}
+	rest := text
+	for "" != rest {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			t.segment = append(t.segment, segment{literal: rest})
+			break
+		}
+		if open > 0 {
+			t.segment = append(t.segment, segment{literal: rest[:open]})
+		}
+		shut := strings.IndexByte(rest[open:], '}')
+		if shut < 0 {
+			return nil, rc.InvalidArgs.Specf("ParseTemplate(%q): unterminated field", text)
+		}
+		seg, ret := parseField(rest[open+1 : open+shut])
+		if nil != ret {
+			return nil, rc.InvalidArgs.Specf("ParseTemplate(%q): %s", text, ret)
+		}
+		t.segment = append(t.segment, seg)
+		rest = rest[open+shut+1:]
+	}
+	return t, nil
+}
+
+// function parseField() parses the contents of a field in braces: its name and
+// optional width, separated by ':'.
+func parseField(spec string) (segment, error) {
+
+	seg := segment{field: spec}
+	if i := strings.IndexByte(spec, ':'); i >= 0 {
+		w, err := strconv.Atoi(spec[i+1:])
+		if nil != err || w < 0 {
+			return seg, fmt.Errorf("invalid width of field: %q", spec)
+		}
+		seg.field, seg.width = spec[:i], w
+	}
+	if _, ok := FieldName[seg.field]; !ok {
+		return seg, fmt.Errorf("unrecognized field: %q", seg.field)
+	}
+	return seg, nil
+}
+
+// function String() returns the text from which the Template was parsed.
+func (t *Template) String() string {
+	return t.text
+}
+
+// function Render() substitutes the given field values into the Template,
+// returning the relative path it describes. the values are sanitized so that
+// they never introduce path separators or characters invalid in file names.
+// returns an error if any field in the Template has no value.
+func (t *Template) Render(fields Fields) (string, *rc.ReturnCode) {
+
+	var sb strings.Builder
+	for _, seg := range t.segment {
+		if "" == seg.field {
+			sb.WriteString(seg.literal)
+			continue
+		}
+		val, ok := fields[seg.field]
+		if !ok {
+			return "", rc.InvalidArgs.Specf("Render(%q): no value for field: %q", t.text, seg.field)
+		}
+		var s string
+		switch v := val.(type) {
+		case int:
+			s = fmt.Sprintf("%0*d", seg.width, v)
+		default:
+			s = fmt.Sprintf("%v", v)
+		}
+		// the extension includes its leading '.', which is dropped if the
+		// template already has one, i.e. both "{base}{ext}" and "{base}.{ext}"
+		// do the obvious thing.
+		if "ext" == seg.field {
+			if strings.HasSuffix(sb.String(), ".") {
+				s = strings.TrimPrefix(s, ".")
+			}
+			sb.WriteString(sanitize(s, false))
+		} else {
+			sb.WriteString(sanitize(s, true))
+		}
+	}
+	return filepath.FromSlash(sb.String()), nil
+}
+
+// function sanitize() replaces the characters that are not allowed in file
+// names (on any common file system) with '_'. if trim is true, the leading and
+// trailing spaces and dots that some file systems silently discard are also
+// removed.
+func sanitize(s string, trim bool) string {
+	s = strings.Map(func(r rune) rune {
+		if r < ' ' || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, s)
+	if trim {
+		s = strings.Trim(s, " .")
+	}
+	return s
+}
+
+// function FieldsOf() returns the values of each field known for the given
+// media entity (an *AudioMedia, *VideoMedia, *ImageMedia, or *DocumentMedia).
+func FieldsOf(ent media.StorableEntity) Fields {
+
+	var m *media.Media
+	var video *media.VideoMedia
+	var image *media.ImageMedia
+	fields := Fields{}
+	switch e := ent.(type) {
+	case *media.AudioMedia:
+		m = e.Media
+		if "" != e.Artist {
+			fields["artist"] = e.Artist
+		}
+		if "" != e.Album {
+			fields["album"] = e.Album
+		}
+		if e.Track > 0 {
+			fields["track"] = int(e.Track)
+		}
+	case *media.VideoMedia:
+		m, video = e.Media, e
+	case *media.ImageMedia:
+		m, image = e.Media, e
+	case *media.DocumentMedia:
+		m = e.Media
+		if "" != e.Author {
+			fields["author"] = e.Author
+		}
+		if "" != e.Series {
+			fields["series"] = e.Series
+		}
+	}
+	if nil == m || nil == m.Entity {
+		return fields
+	}
+
+	fields["name"] = m.Name
+	fields["base"] = m.AbsBase
+	fields["ext"] = m.Ext
+	if m.Kind > media.KindUnknown && m.Kind < media.KindCOUNT {
+		fields["kind"] = strings.ToLower(media.MediaColName[m.Kind])
+	}
+	if !m.ReleaseDate.IsZero() {
+		fields["year"] = m.ReleaseDate.Year()
+	} else if nil != video && video.Year > 0 {
+		fields["year"] = int(video.Year)
+	} else if nil != image && !image.Taken.IsZero() {
+		fields["year"] = image.Taken.Year()
+	}
+
+	// until the media's title has been set by some other means, it is simply
+	// its file name, which is no better than the file name's base.
+	title := m.Title
+	if "" == title || title == m.AbsName {
+		title = m.AbsBase
+	}
+	if nil != video && video.IsEpisode() {
+		fields["show"], fields["series"] = video.Series, video.Series
+		fields["s"], fields["e"] = int(video.Season), int(video.Episode)
+		fields["season"], fields["episode"] = fields["s"], fields["e"]
+		if name := naming.Parse(m.AbsBase); title == m.AbsBase && "" != name.Title {
+			title = name.Title
+		}
+	}
+	fields["title"] = title
+	return fields
+}
@@ -0,0 +1,109 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: pidfile.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the lock files held by a single process at a time, identified by
+//    the process ID written in each.
+//
+// =============================================================================
+
+// package pidfile implements lock files containing the ID of the process
+// holding them. a lock file left behind by a process that has since exited,
+// e.g. one that crashed or was killed, is stale and taken over by the next
+// process acquiring it.
+package pidfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the lock files.
+const (
+	filePerms   = 0644
+	maxAttempts = 3 // times a stale lock file is removed before giving up
+)
+
+// type File is a lock file held by this process.
+type File struct {
+	path string
+}
+
+// function Acquire() creates the lock file at the given path, writing the ID of
+// this process to it. returns rc.Locked if the file already exists and the
+// process whose ID it contains is still running.
+func Acquire(path string) (*File, *rc.ReturnCode) {
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		// the exclusive create ensures only one of any processes racing to
+		// acquire the file succeeds.
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, filePerms)
+		if nil == err {
+			_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+			if cerr := f.Close(); nil == err {
+				err = cerr
+			}
+			if nil != err {
+				os.Remove(path)
+				return nil, rc.InvalidFile.Specf("Acquire(%q): %s", path, err)
+			}
+			return &File{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, rc.InvalidFile.Specf("Acquire(%q): os.OpenFile(): %s", path, err)
+		}
+		if pid, alive := Owner(path); alive {
+			return nil, rc.Locked.Specf("Acquire(%q): held by process %d", path, pid)
+		}
+		// the owner has exited without releasing the file.
+		if err := os.Remove(path); nil != err && !os.IsNotExist(err) {
+			return nil, rc.InvalidFile.Specf("Acquire(%q): os.Remove(): %s", path, err)
+		}
+	}
+	return nil, rc.Locked.Specf("Acquire(%q): contended by other processes", path)
+}
+
+// function Owner() returns the process ID written in the lock file at the given
+// path, and whether that process is still running. the ID is 0 if the file
+// doesn't exist or is unreadable.
+func Owner(path string) (int, bool) {
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if nil != err {
+		return 0, false
+	}
+	return pid, pid == os.Getpid() || platform.ProcessAlive(pid)
+}
+
+// function Path() returns the path of the lock file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// function Release() removes the lock file, if still held by this process.
+// safe to call on a nil File.
+func (f *File) Release() *rc.ReturnCode {
+	if nil == f {
+		return nil
+	}
+	if pid, _ := Owner(f.path); os.Getpid() != pid {
+		return nil // taken over, or removed, by someone else.
+	}
+	if err := os.Remove(f.path); nil != err && !os.IsNotExist(err) {
+		return rc.InvalidFile.Specf("Release(%q): os.Remove(): %s", f.path, err)
+	}
+	return nil
+}
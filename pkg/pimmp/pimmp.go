@@ -0,0 +1,195 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: pimmp.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the Engine, which opens, loads, and scans a set of libraries the
+//    way the pimmp executable does, for other tools embedding its indexing.
+//
+// =============================================================================
+
+// package pimmp embeds the library and scanning engine of pimmp in other Go
+// programs. an Engine opens the libraries at the given paths, configured by a
+// Config, and loads and scans them, publishing the files found on its event
+// bus (see Bus of package library):
+//
+//	eng, ret := pimmp.New(pimmp.NewConfig(dataDir), "/srv/movies", "/srv/music")
+//	if nil != ret {
+//		return ret
+//	}
+//	defer eng.Close()
+//	eng.Bus().Subscribe(func(e library.Event) {
+//		fmt.Println(e.Discovery.Media())
+//	}, library.MediaDiscovered)
+//	found, ret := eng.Scan(ctx)
+//
+// the libraries themselves (package library), their databases (package
+// storage), and the media they hold (package media) remain available through
+// Libraries() for anything beyond loading and scanning. the pimmp executable
+// (see cmd/pimmp) is a frontend to the same packages.
+package pimmp
+
+import (
+	"context"
+
+	"ardnew.com/pimmp/pkg/library"
+	"ardnew.com/pimmp/pkg/plugin"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/storage"
+)
+
+// type Config holds the settings of the libraries opened by an Engine, which
+// correspond to the global options of the pimmp executable of the same name.
+type Config struct {
+	LibData  string          // directory holding the library databases (see -libdata)
+	Database *storage.Config // settings of new databases (nil for the defaults)
+	MaxDepth uint            // max traversal depth of the scanners (unlimited: 0)
+
+	Limits   library.Limits // loads and scans run at once (see NewScheduler())
+	ScanRate string         // rate at which scans read files (see ParseScanRate())
+	MinSize  string         // smallest media files indexed (see NewFilter())
+	Exclude  []string       // patterns of the files and directories skipped (see SetExclude())
+
+	Prune        bool // forget the records of files found missing (see SetPrune())
+	FollowLinks  bool // follow symbolic links while scanning (see SetFollowLinks())
+	ReadMetadata bool // read the tags and metadata of media (see SetReadMetadata())
+
+	Plugins *plugin.Host // plugins consulted by the scanners, and told of their changes (nil for none)
+}
+
+// function NewConfig() creates a Config with the defaults of the pimmp
+// executable, keeping the library databases in the given directory.
+func NewConfig(libData string) *Config {
+	return &Config{
+		LibData:      libData,
+		Database:     storage.NewConfig(),
+		ReadMetadata: true,
+	}
+}
+
+// type Engine is a set of libraries sharing a scheduler and an event bus.
+type Engine struct {
+	libs []*library.Library
+	bus  *library.Bus
+	busy *library.BusyState
+}
+
+// function New() opens (or creates) the databases of the libraries at the given
+// paths, configured by the given Config, ready to load and scan. the libraries
+// already opened are closed again if any of them cannot be.
+func New(cfg *Config, path ...string) (*Engine, *rc.ReturnCode) {
+
+	if nil == cfg {
+		return nil, rc.InvalidArgs.Spec("New(): nil Config")
+	}
+	if 0 == len(path) {
+		return nil, rc.InvalidArgs.Spec("New(): no library paths")
+	}
+	dbConfig := cfg.Database
+	if nil == dbConfig {
+		dbConfig = storage.NewConfig()
+	}
+	scheduler, ret := library.NewScheduler(cfg.Limits)
+	if nil != ret {
+		return nil, ret
+	}
+	scanRate, ret := library.ParseScanRate(cfg.ScanRate)
+	if nil != ret {
+		return nil, ret
+	}
+	filter, ret := library.NewFilter(cfg.MinSize, nil, "")
+	if nil != ret {
+		return nil, ret
+	}
+
+	e := &Engine{bus: library.NewBus(), busy: library.NewBusyState()}
+	for _, p := range path {
+		l, ret := library.NewLibrary(cfg.LibData, dbConfig, e.busy, p, cfg.MaxDepth, e.libs)
+		if nil == ret {
+			ret = l.SetExclude(cfg.Exclude)
+			e.libs = append(e.libs, l)
+		}
+		if nil != ret {
+			e.Close()
+			return nil, ret
+		}
+		l.SetPlugins(cfg.Plugins)
+		l.SetScheduler(scheduler)
+		l.SetScanRate(scanRate)
+		l.SetFilter(filter)
+		l.SetPrune(cfg.Prune)
+		l.SetFollowLinks(cfg.FollowLinks)
+		l.SetReadMetadata(cfg.ReadMetadata)
+	}
+	library.SubscribePlugins(e.bus)
+	return e, nil
+}
+
+// function Libraries() returns the libraries of the Engine, in the order their
+// paths were given.
+func (e *Engine) Libraries() []*library.Library { return e.libs }
+
+// function Bus() returns the event bus on which the libraries of the Engine
+// publish the files they find.
+func (e *Engine) Bus() *library.Bus { return e.bus }
+
+// function Busy() returns the state shared by the libraries of the Engine to
+// indicate they are loading or scanning.
+func (e *Engine) Busy() *library.BusyState { return e.busy }
+
+// function Scan() loads the media already recorded in the database of each
+// library of the Engine, then scans its file system for the files added since,
+// each library concurrently, waiting until all of them are finished or the
+// given context is done. returns the total number of media found, and the
+// first error encountered, if any.
+func (e *Engine) Scan(ctx context.Context) (uint, *rc.ReturnCode) {
+
+	type result struct {
+		found uint
+		ret   *rc.ReturnCode
+	}
+
+	done := make(chan result, len(e.libs))
+	for _, lib := range e.libs {
+		go func(l *library.Library) {
+			var r result
+			if !l.DB().IsFirstAppearance() {
+				r.found, r.ret = l.Load(ctx, e.bus)
+			}
+			if nil == r.ret {
+				var n uint
+				n, r.ret = l.Scan(ctx, e.bus)
+				r.found += n
+			}
+			done <- r
+		}(lib)
+	}
+
+	var total uint
+	var first *rc.ReturnCode
+	for range e.libs {
+		r := <-done
+		total += r.found
+		if nil == first {
+			first = r.ret
+		}
+	}
+	return total, first
+}
+
+// function Close() closes the database of each library of the Engine, which
+// may not be used afterward. returns the first error encountered, if any.
+func (e *Engine) Close() *rc.ReturnCode {
+
+	var first *rc.ReturnCode
+	for _, l := range e.libs {
+		if _, ret := l.DB().Close(); nil != ret && nil == first {
+			first = ret
+		}
+	}
+	e.libs = nil
+	return first
+}
@@ -0,0 +1,16 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: doc.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    package documentation for the system-dependent definitions.
+//
+// =============================================================================
+
+// package platform contains the constants and subroutines whose definitions
+// depend on the operating system being targeted. see platform_nix.go and
+// platform_win.go.
+package platform
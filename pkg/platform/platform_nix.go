@@ -0,0 +1,136 @@
+// +build linux darwin
+
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 26 Sept 2018
+//  FILE: platform_nix.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    all data related to system-dependent operations can be stored here
+//    unconditionally. the Go compiler takes care of identifying which of the
+//    symbols to export based on whichever platform we are targeting.
+//
+//    if you add anything to one of these platform-specific definitions files
+//    (platform_nix.go, platform_win.go) that is used by any of the client
+//    application (pimmp) code, then be sure to implement it in ~ALL~ of the
+//    platform-specific definitions files.
+//
+// =============================================================================
+
+package platform
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+)
+
+const (
+	NewLine = "\n"
+	PathSep = "/"
+	CurrDir = "."
+)
+
+// variable Shell is the command (and its arguments) used to interpret a
+// command line given as a single string.
+var Shell = []string{"/bin/sh", "-c"}
+
+// variable Opener is the command opening a file with the application the
+// desktop associates with its type.
+var Opener = "xdg-open"
+
+// function HomeDir() returns the path to the user's home directory as defined
+// by the user's current HOME environment variable.
+func HomeDir() string {
+	return os.Getenv("HOME")
+}
+
+// function ConfigDir() returns the conventional path to the directory of the
+// named application's configuration: "~/Library/Application Support/<name>" on
+// macOS, or else "$XDG_CONFIG_HOME/<name>" ("~/.config/<name>" if undefined).
+func ConfigDir(name string) string {
+	if "darwin" == runtime.GOOS {
+		return filepath.Join(HomeDir(), "Library", "Application Support", name)
+	}
+	return filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), name)
+}
+
+// function DataDir() returns the conventional path to the directory of the
+// named application's data: the same as ConfigDir() on macOS, or else
+// "$XDG_DATA_HOME/<name>" ("~/.local/share/<name>" if undefined).
+func DataDir(name string) string {
+	if "darwin" == runtime.GOOS {
+		return ConfigDir(name)
+	}
+	return filepath.Join(xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share")), name)
+}
+
+// function xdgDir() returns the directory defined by the given XDG environment
+// variable, or else the given default relative to the home directory. per the
+// XDG Base Directory spec, relative paths in the variable are ignored.
+func xdgDir(env, home string) string {
+	if dir := os.Getenv(env); "" != dir && filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(HomeDir(), home)
+}
+
+// function DeviceID() returns the ID of the device (file system) containing the
+// given path, so that paths may be tested for residing on the same device.
+func DeviceID(path string) (uint64, bool) {
+	info, err := os.Stat(path)
+	if nil != err {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
+
+// function FileID() returns the IDs of the device and inode of the file with
+// the given info, which together uniquely identify the file regardless of the
+// paths (e.g. symbolic links) by which it is reached.
+func FileID(info os.FileInfo) (uint64, uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(stat.Dev), uint64(stat.Ino), true
+}
+
+// function IPCAddress() returns the address of a local socket with the given
+// name, over which another process can be controlled (see DialIPC()).
+func IPCAddress(name string) string {
+	return filepath.Join(os.TempDir(), name+".sock")
+}
+
+// function DialIPC() connects to the local socket at the given address, as
+// returned by IPCAddress(), which must already have been created by the process
+// listening on it.
+func DialIPC(address string) (io.ReadWriteCloser, error) {
+	return net.Dial("unix", address)
+}
+
+// function ProcessAlive() returns true if a process with the given ID exists,
+// even if it belongs to another user (and so can't be signaled).
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return nil == err || syscall.EPERM == err
+}
+
+// function DetachedProcess() returns the attributes of a child process detached
+// from the terminal, i.e. the leader of a new session, so that it keeps running
+// once the terminal (and the process starting it) is gone.
+func DetachedProcess() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,148 @@
+// +build windows
+
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 26 Sept 2018
+//  FILE: platform_win.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    all data related to system-dependent operations can be stored here
+//    unconditionally. the Go compiler takes care of identifying which of the
+//    symbols to export based on whichever platform we are targeting.
+//
+//    if you add anything to one of these platform-specific definitions files
+//    (platform_nix.go, platform_win.go) that is used by any of the client
+//    application (pimmp) code, then be sure to implement it in ~ALL~ of the
+//    platform-specific definitions files.
+//
+// =============================================================================
+
+package platform
+
+import (
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+const (
+	NewLine = "\r\n"
+	PathSep = "\\"
+	CurrDir = "."
+)
+
+// variable Shell is the command (and its arguments) used to interpret a
+// command line given as a single string.
+var Shell = []string{"cmd", "/C"}
+
+// variable Opener is the command opening a file with the application the
+// desktop associates with its type.
+var Opener = "explorer"
+
+// function HomeDir() returns the path to the user's home directory as defined
+// by several of the user's current environment variables.
+func HomeDir() string {
+	home := os.Getenv("HOMEDRIVE") + os.Getenv("HOMEPATH")
+	if home == "" {
+		home = os.Getenv("USERPROFILE")
+	}
+	return home
+}
+
+// function ConfigDir() returns the conventional path to the directory of the
+// named application's configuration, "%APPDATA%\<name>", which roams with the
+// user's profile.
+func ConfigDir(name string) string {
+	dir := os.Getenv("APPDATA")
+	if "" == dir {
+		dir = filepath.Join(HomeDir(), "AppData", "Roaming")
+	}
+	return filepath.Join(dir, name)
+}
+
+// function DataDir() returns the conventional path to the directory of the
+// named application's data, "%LOCALAPPDATA%\<name>", which stays on this
+// machine.
+func DataDir(name string) string {
+	dir := os.Getenv("LOCALAPPDATA")
+	if "" == dir {
+		dir = filepath.Join(HomeDir(), "AppData", "Local")
+	}
+	return filepath.Join(dir, name)
+}
+
+// function DeviceID() returns the ID of the device (volume) containing the given
+// path, so that paths may be tested for residing on the same device.
+func DeviceID(path string) (uint64, bool) {
+	abs, err := filepath.Abs(path)
+	if nil != err {
+		return 0, false
+	}
+	vol := filepath.VolumeName(abs)
+	if "" == vol {
+		return 0, false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToUpper(vol)))
+	return h.Sum64(), true
+}
+
+// function FileID() would return the IDs of the device and inode of the file
+// with the given info, but these aren't available from os.FileInfo on Windows;
+// callers must identify files by their resolved paths instead.
+func FileID(info os.FileInfo) (uint64, uint64, bool) {
+	return 0, 0, false
+}
+
+// function IPCAddress() returns the address of a named pipe with the given
+// name, over which another process can be controlled (see DialIPC()).
+func IPCAddress(name string) string {
+	return `\\.\pipe\` + name
+}
+
+// function DialIPC() connects to the named pipe at the given address, as
+// returned by IPCAddress(), which must already have been created by the process
+// listening on it. named pipes are opened like any other file.
+func DialIPC(address string) (io.ReadWriteCloser, error) {
+	return os.OpenFile(address, os.O_RDWR, 0)
+}
+
+// local unexported constants of the Windows API not defined by package syscall.
+const (
+	processQueryLimitedInformation = 0x1000     // access right of OpenProcess()
+	stillActive                    = 259        // exit code of a process still running
+	detachedProcess                = 0x00000008 // creation flag of a process without a console
+)
+
+// function ProcessAlive() returns true if a process with the given ID exists
+// and hasn't yet exited.
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if nil != err {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); nil != err {
+		return false
+	}
+	return stillActive == code
+}
+
+// function DetachedProcess() returns the attributes of a child process detached
+// from the console, in its own process group, so that it keeps running once
+// the console (and the process starting it) is gone.
+func DetachedProcess() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess,
+		HideWindow:    true,
+	}
+}
@@ -0,0 +1,349 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: mpv.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    controls playback by mpv over its JSON IPC socket, so that playback can
+//    be paused, sought, and stopped, and its position reported back while the
+//    media is playing.
+//
+// =============================================================================
+
+package player
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant MPVCommand is the name of the mpv executable. a Player running mpv
+// controls it over its IPC socket rather than simply waiting for it to exit.
+const MPVCommand = "mpv"
+
+// local unexported constants for the mpv IPC client.
+const (
+	mpvConnectTimeout = 5 * time.Second       // how long mpv may take to create its socket
+	mpvConnectRetry   = 50 * time.Millisecond // how often its socket is checked for
+	mpvEndOfFile      = "eof"                 // reason of an end-file event, playback finished
+	mpvPropPosition   = "time-pos"            // property observed for the playback position
+	mpvPropDuration   = "duration"            // property observed for the media length
+	mpvPropPause      = "pause"               // property observed for the paused state
+	mpvSeekRelative   = "relative"            // seek flag, offset from the current position
+	mpvSeekAbsolute   = "absolute"            // seek flag, offset from the beginning
+)
+
+// type Progress is the state of playback reported by a Session.
+type Progress struct {
+	Position time.Duration // offset of the media currently playing
+	Duration time.Duration // length of the media, 0 if unknown
+	Paused   bool          // playback is paused
+	Finished bool          // playback reached the end of the media
+}
+
+// type mpvMessage is a single line received over mpv's IPC socket: either a
+// reply to a command (identified by RequestID) or an event.
+type mpvMessage struct {
+	RequestID int64           `json:"request_id"`
+	Error     string          `json:"error"`
+	Event     string          `json:"event"`
+	Name      string          `json:"name"`
+	Reason    string          `json:"reason"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// type Session is a single playback of media by mpv, controlled over its IPC
+// socket. the methods controlling playback are safe for concurrent use.
+type Session struct {
+	cmd     *exec.Cmd
+	conn    io.ReadWriteCloser
+	address string
+	report  func(Progress) // called with each change of progress (nil if unused)
+	exited  chan struct{}  // closed once mpv exits
+	drained chan struct{}  // closed once everything mpv sent has been read
+	waitErr error          // reason mpv failed, valid once exited is closed
+	origin  time.Duration  // offset into the file at which the media starts
+	end     time.Duration  // offset into the file at which the media ends, 0 if with the file
+	parts   int            // number of files played in sequence (see Files() of Media)
+
+	lock     sync.Mutex // guards the fields below and writes to conn
+	nextID   int64
+	progress Progress
+	played   int           // number of parts played to the end
+	elapsed  time.Duration // total duration of the parts played to the end
+	length   time.Duration // duration of the part playing
+}
+
+// function IsMPV() returns true if the given command runs mpv.
+func IsMPV(command string) bool {
+	name := strings.ToLower(filepath.Base(command))
+	return MPVCommand == strings.TrimSuffix(name, ".exe")
+}
+
+// function Start() runs the Player (which must run mpv, see IsMPV()) on the
+// file at the given path, starting at the given offset, and returns once it is
+// ready to be controlled. the given function, unless nil, is called with the
+// progress of playback each time it changes. use Wait() to wait for playback
+// to finish.
+func (p *Player) Start(path string, start time.Duration, report func(Progress)) (*Session, *rc.ReturnCode) {
+	return p.start(path, append(append([]string{}, p.args...), path), 1, 0, 0, start, report)
+}
+
+// function start() runs mpv with the given arguments, playing the file at the
+// given path, as described by Start(). the options controlling mpv precede the
+// given arguments. only the part of the file from the given origin to the
+// given end (unless 0) is played, e.g. a track of a cue sheet: the offset at
+// which playback starts, and the progress reported, are relative to the
+// origin. the given number of parts of the media (see Files() of Media) play
+// in sequence as one, always from the beginning, their progress reported as
+// the sum of theirs.
+func (p *Player) start(path string, cmdArgs []string, parts int, origin, end, start time.Duration, report func(Progress)) (*Session, *rc.ReturnCode) {
+
+	if !IsMPV(p.command) {
+		return nil, rc.PlaybackError.Specf("Start(%q): %s: not %s", path, p, MPVCommand)
+	}
+
+	name := fmt.Sprintf("%s-%d-%d", MPVCommand, os.Getpid(), time.Now().UnixNano())
+	s := &Session{
+		address: platform.IPCAddress(name),
+		report:  report,
+		exited:  make(chan struct{}),
+		drained: make(chan struct{}),
+		origin:  origin,
+		end:     end,
+		parts:   parts,
+	}
+	if parts > 1 {
+		// mpv would start every part at the offset.
+		start = 0
+	}
+
+	args := []string{"--input-ipc-server=" + s.address}
+	if origin+start > 0 {
+		args = append(args, fmt.Sprintf("--start=%.3f", (origin+start).Seconds()))
+	}
+	if end > 0 {
+		args = append(args, fmt.Sprintf("--end=%.3f", end.Seconds()))
+	}
+	args = append(args, cmdArgs...)
+	s.cmd = exec.Command(p.command, args...)
+	s.cmd.Stdin, s.cmd.Stdout, s.cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	logs.Info.Verbosef("playing: %q (%s)", path, p)
+	if err := s.cmd.Start(); nil != err {
+		return nil, rc.PlaybackError.Specf("Start(%q): %s: %s", path, p, err)
+	}
+	go func() {
+		s.waitErr = s.cmd.Wait()
+		close(s.exited)
+	}()
+
+	// mpv creates its socket shortly after it starts, keep trying until then.
+	deadline := time.Now().Add(mpvConnectTimeout)
+	for {
+		conn, err := platform.DialIPC(s.address)
+		if nil == err {
+			s.conn = conn
+			break
+		}
+		if time.Now().After(deadline) {
+			s.cmd.Process.Kill()
+			return nil, rc.PlaybackError.Specf("Start(%q): cannot connect to %s: %s", path, MPVCommand, err)
+		}
+		select {
+		case <-s.exited:
+			return nil, rc.PlaybackError.Specf("Start(%q): %s exited: %v", path, MPVCommand, s.waitErr)
+		case <-time.After(mpvConnectRetry):
+		}
+	}
+
+	go s.listen()
+
+	for i, prop := range []string{mpvPropPosition, mpvPropDuration, mpvPropPause} {
+		if ret := s.send("observe_property", i+1, prop); nil != ret {
+			logs.Warn.Verbose(ret)
+		}
+	}
+	return s, nil
+}
+
+// function listen() reads the replies and events sent by mpv until its socket
+// is closed, updating the progress of playback.
+func (s *Session) listen() {
+
+	defer close(s.drained)
+	scan := bufio.NewScanner(s.conn)
+	for scan.Scan() {
+		msg := mpvMessage{}
+		if err := json.Unmarshal(scan.Bytes(), &msg); nil != err {
+			logs.Warn.Verbosef("invalid message from %s: %s", MPVCommand, err)
+			continue
+		}
+		switch {
+		case "" == msg.Event:
+			if "success" != msg.Error {
+				logs.Warn.Verbosef("%s request %d failed: %s", MPVCommand, msg.RequestID, msg.Error)
+			}
+		case "property-change" == msg.Event:
+			s.changed(msg.Name, msg.Data)
+		case "end-file" == msg.Event && mpvEndOfFile == msg.Reason:
+			s.update(func(p *Progress) {
+				s.played++
+				s.elapsed += s.length
+				p.Finished = s.played >= s.parts
+			})
+		}
+	}
+}
+
+// function changed() updates the progress of playback with the new value of
+// the observed property with the given name. mpv reports the position in, and
+// duration of, the whole file, which are made relative to the part of the file
+// played, and of the part of the media playing, which are added to those of
+// the parts played before it.
+func (s *Session) changed(name string, data json.RawMessage) {
+
+	seconds := func() (time.Duration, bool) {
+		var f float64
+		if err := json.Unmarshal(data, &f); nil != err {
+			return 0, false // property unavailable, e.g. while loading
+		}
+		return time.Duration(f * float64(time.Second)), true
+	}
+
+	switch name {
+	case mpvPropPosition:
+		if d, ok := seconds(); ok {
+			if d -= s.origin; d < 0 {
+				d = 0
+			}
+			s.update(func(p *Progress) { p.Position = s.elapsed + d })
+		}
+	case mpvPropDuration:
+		if d, ok := seconds(); ok {
+			if s.end > 0 && s.end < d {
+				d = s.end
+			}
+			if d -= s.origin; d < 0 {
+				d = 0
+			}
+			s.update(func(p *Progress) {
+				s.length = d
+				p.Duration = s.elapsed + d
+			})
+		}
+	case mpvPropPause:
+		var b bool
+		if err := json.Unmarshal(data, &b); nil == err {
+			s.update(func(p *Progress) { p.Paused = b })
+		}
+	}
+}
+
+// function update() modifies the progress of playback with the given function
+// and then reports it.
+func (s *Session) update(modify func(*Progress)) {
+	s.lock.Lock()
+	modify(&s.progress)
+	progress := s.progress
+	s.lock.Unlock()
+	if nil != s.report {
+		s.report(progress)
+	}
+}
+
+// function send() sends the command with the given arguments to mpv, without
+// waiting for its reply.
+func (s *Session) send(args ...interface{}) *rc.ReturnCode {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nextID++
+	line, err := json.Marshal(struct {
+		Command   []interface{} `json:"command"`
+		RequestID int64         `json:"request_id"`
+	}{args, s.nextID})
+	if nil != err {
+		return rc.PlaybackError.Specf("send(%v): %s", args, err)
+	}
+	if _, err := s.conn.Write(append(line, '\n')); nil != err {
+		return rc.PlaybackError.Specf("send(%v): %s", args, err)
+	}
+	return nil
+}
+
+// function Progress() returns the current progress of playback.
+func (s *Session) Progress() Progress {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.progress
+}
+
+// function Pause() pauses playback.
+func (s *Session) Pause() *rc.ReturnCode {
+	return s.send("set_property", mpvPropPause, true)
+}
+
+// function Resume() resumes playback once paused.
+func (s *Session) Resume() *rc.ReturnCode {
+	return s.send("set_property", mpvPropPause, false)
+}
+
+// function TogglePause() pauses playback if playing, or resumes it if paused.
+func (s *Session) TogglePause() *rc.ReturnCode {
+	return s.send("cycle", mpvPropPause)
+}
+
+// function Seek() moves playback to the given offset from the beginning of the
+// media or, if relative is true, from the current position (a negative offset
+// moves backward).
+func (s *Session) Seek(offset time.Duration, relative bool) *rc.ReturnCode {
+	flag := mpvSeekAbsolute
+	if relative {
+		flag = mpvSeekRelative
+	} else {
+		offset += s.origin
+	}
+	return s.send("seek", offset.Seconds(), flag)
+}
+
+// function Stop() stops playback, exiting mpv.
+func (s *Session) Stop() *rc.ReturnCode {
+	return s.send("quit")
+}
+
+// function Wait() waits for mpv to exit, returning the final progress of
+// playback.
+func (s *Session) Wait() (Progress, *rc.ReturnCode) {
+
+	<-s.exited
+	// the socket is closed once mpv exits, but the last of its events (e.g.
+	// reaching the end of the media) may not have been read yet.
+	select {
+	case <-s.drained:
+	case <-time.After(mpvConnectTimeout):
+	}
+	s.conn.Close()
+	// mpv removes its own socket, unless it was killed.
+	os.Remove(s.address)
+
+	progress := s.Progress()
+	if nil != s.waitErr {
+		return progress, rc.PlaybackError.Specf("Wait(): %s: %s", MPVCommand, s.waitErr)
+	}
+	return progress, nil
+}
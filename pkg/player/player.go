@@ -0,0 +1,212 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: player.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines types and functions for handing media off to an external program
+//    for playback.
+//
+// =============================================================================
+
+// package player launches the external programs used to play media.
+package player
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/plugin"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// var logs are the loggers of the messages about playback, filtered as
+// component "play" (see console.Register()).
+var logs = console.Register("play")
+
+// constant DefaultCommand is the program used for playback when no other is
+// configured. omxplayer is the hardware-accelerated player shipped with
+// Raspbian and doesn't require a graphical window manager.
+const (
+	DefaultCommand = "omxplayer"
+)
+
+// type Player represents an external program and the arguments passed to it
+// (preceding the media file path) each time media is played, or the Template
+// of its command line.
+type Player struct {
+	command  string       // name or path of the executable
+	args     []string     // arguments passed before the media file path
+	template *Template    // expanded for each media instead of args (nil if unused)
+	plugins  *plugin.Host // notified when playback finishes (nil if unused)
+	control  Controller   // notified of the media playing (nil if unused)
+}
+
+// type Controller is notified of the media played by a Player, so that its
+// playback can also be followed and controlled from elsewhere, e.g. by the
+// desktop (see package mpris). Started() is given the Session controlling
+// playback, or nil if the player cannot be controlled, and Changed() each
+// progress of playback that Session reports.
+type Controller interface {
+	Started(m *media.Media, s *Session)
+	Changed(p Progress)
+	Stopped(m *media.Media)
+}
+
+// type playback is the record sent with plugin.EventPlaybackDone.
+type playback struct {
+	*media.Media
+	Player string // command line of the player used
+	Error  string // reason playback failed, empty if successful
+}
+
+// function New() creates a new Player invoking the given command with the
+// given arguments. if command is empty, DefaultCommand is used.
+func New(command string, args ...string) *Player {
+	if "" == command {
+		command = DefaultCommand
+	}
+	return &Player{command: command, args: args}
+}
+
+// function NewTemplate() creates a new Player invoking the command line of the
+// given Template, expanded for each media played.
+func NewTemplate(t *Template) *Player {
+	return &Player{command: t.field[0], template: t}
+}
+
+// function SetPlugins() sets the plugins and shell hooks notified each time
+// playback of media finishes. a nil Host disables notifications.
+func (p *Player) SetPlugins(h *plugin.Host) {
+	p.plugins = h
+}
+
+// function SetController() sets the Controller notified of the media played.
+// a nil Controller disables notifications.
+func (p *Player) SetController(c Controller) {
+	p.control = c
+}
+
+// function String() creates a string representation of the Player for easy
+// identification in logs.
+func (p *Player) String() string {
+	if nil != p.template {
+		return p.template.String()
+	}
+	return strings.Join(append([]string{p.command}, p.args...), " ")
+}
+
+// function argsFor() returns the arguments passed to the Player's command to
+// play the given media with the given subtitle files.
+func (p *Player) argsFor(m *media.Media, subs []string) []string {
+	if nil != p.template {
+		return p.template.Expand(m, subs)
+	}
+	return append(append([]string{}, p.args...), m.Files()...)
+}
+
+// function Play() runs the player on the file at the given path and waits for
+// it to exit. the player inherits the standard streams of this process so that
+// it can take control of the terminal.
+func (p *Player) Play(path string) *rc.ReturnCode {
+	return p.run(path, append(append([]string{}, p.args...), path))
+}
+
+// function run() runs the Player's command with the given arguments, playing
+// the file at the given path, and waits for it to exit.
+func (p *Player) run(path string, args []string) *rc.ReturnCode {
+
+	cmd := exec.Command(p.command, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	logs.Info.Verbosef("playing: %q (%s)", path, p)
+	if err := cmd.Run(); nil != err {
+		return rc.PlaybackError.Specf("Play(%q): %s: %s", path, p, err)
+	}
+	return nil
+}
+
+// function PlayMedia() plays the given media with the given subtitle files and
+// returns the final progress of playback. if the media defines its own playback
+// command, it is used instead of this Player's command. mpv (see IsMPV())
+// starts playing at the media's resume position and reports its progress to
+// the given function, unless nil; the progress of any other player is unknown,
+// so the media is assumed to have played until finished. the track of a cue
+// sheet is played from its offsets into the file containing it (see File() of
+// Media), which other players are given by the variables of a Template.
+func (p *Player) PlayMedia(m *media.Media, subs []string, report func(Progress)) (Progress, *rc.ReturnCode) {
+
+	if nil == m || nil == m.Entity {
+		return Progress{}, rc.PlaybackError.Spec("PlayMedia(): no media provided")
+	}
+
+	// the placeholder "--" is used throughout the records to indicate a field
+	// has not been set by the user.
+	use := p
+	if cmd := strings.Fields(m.PlaybackCommand); len(cmd) > 0 && "--" != cmd[0] {
+		t, ret := ParseTemplate(m.PlaybackCommand)
+		if nil != ret {
+			return Progress{}, ret
+		}
+		use = NewTemplate(t)
+	}
+
+	if nil != p.control {
+		forward := report
+		report = func(progress Progress) {
+			p.control.Changed(progress)
+			if nil != forward {
+				forward(progress)
+			}
+		}
+	}
+
+	progress := Progress{Finished: true}
+	args := use.argsFor(m, subs)
+	var ret *rc.ReturnCode
+	if IsMPV(use.command) {
+		var s *Session
+		if s, ret = use.start(m.File(), args, len(m.Files()), m.Start, m.End, m.ResumePosition, report); nil == ret {
+			p.plugins.Notify(plugin.EventPlaybackStart, &playback{Media: m, Player: use.String()})
+			p.started(m, s)
+			progress, ret = s.Wait()
+			p.stopped(m)
+		} else {
+			progress = Progress{}
+		}
+	} else {
+		p.plugins.Notify(plugin.EventPlaybackStart, &playback{Media: m, Player: use.String()})
+		p.started(m, nil)
+		ret = use.run(m.File(), args)
+		p.stopped(m)
+	}
+	rec := &playback{Media: m, Player: use.String()}
+	if nil != ret {
+		rec.Error = ret.Error()
+	}
+	p.plugins.Notify(plugin.EventPlaybackDone, rec)
+
+	return progress, ret
+}
+
+// function started() notifies the Player's Controller, if any, that the given
+// media started playing, controlled by the given Session (nil if it cannot be
+// controlled).
+func (p *Player) started(m *media.Media, s *Session) {
+	if nil != p.control {
+		p.control.Started(m, s)
+	}
+}
+
+// function stopped() notifies the Player's Controller, if any, that the given
+// media stopped playing.
+func (p *Player) stopped(m *media.Media) {
+	if nil != p.control {
+		p.control.Stopped(m)
+	}
+}
@@ -0,0 +1,156 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: template.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the command line templates used to play each kind of media, in
+//    which variables such as {path} are replaced by the media's details.
+//
+// =============================================================================
+
+package player
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// the variables recognized in a Template.
+const (
+	varPath  = "{path}"  // absolute path of the media file (of each part, see Files() of Media)
+	varTitle = "{title}" // title of the media, or its name if untitled
+	varSubs  = "{subs}"  // absolute path of each subtitle file of the media
+	varSub   = "{sub}"   // absolute path of the preferred subtitle file of the media
+	varStart = "{start}" // offset (seconds) into the file at which the media starts
+	varEnd   = "{end}"   // offset (seconds) into the file at which the media ends
+)
+
+// variable templateVar matches anything in a Template that looks like a
+// variable, known or not.
+var templateVar = regexp.MustCompile(`\{[^{}\s]*\}`)
+
+// type Template is a command line playing media, split into fields like a
+// shell would (without any quoting), in which the variables {path}, {title},
+// {subs}, and {sub} are replaced by the details of the media played. variables
+// are replaced within each field, so a path containing spaces remains a single
+// argument. a field containing {subs} is repeated for each subtitle file, or
+// omitted if there are none, e.g. "mpv --sub-file={subs} {path}". a field
+// containing {sub} is likewise given just the most preferred subtitle file
+// (the first), e.g. to play only the subtitles in the preferred language. the
+// path is appended to the command line if {path} doesn't appear in it. the
+// parts of a release split into several (see Files() of Media) are each given
+// in order, by repeating the field containing {path} for each.
+//
+// the track of a cue sheet is just a part of the file played (see File() of
+// Media), given by the variables {start} and {end}, e.g. "--start={start}".
+// a field containing either is omitted for media playing from the beginning,
+// or to the end, of the file.
+type Template struct {
+	field []string
+}
+
+// function ParseTemplate() creates a Template from the given command line.
+// returns an error if it is empty or contains an unknown variable.
+func ParseTemplate(command string) (*Template, *rc.ReturnCode) {
+
+	field := strings.Fields(command)
+	if 0 == len(field) {
+		return nil, rc.InvalidArgs.Spec("ParseTemplate(): empty playback command")
+	}
+	for _, f := range field {
+		for _, v := range templateVar.FindAllString(f, -1) {
+			switch v {
+			case varPath, varTitle, varSubs, varSub, varStart, varEnd:
+			default:
+				return nil, rc.InvalidArgs.Specf("ParseTemplate(%q): unknown variable: %s", command, v)
+			}
+		}
+	}
+	for _, v := range []string{varSubs, varSub} {
+		if strings.Contains(field[0], v) {
+			return nil, rc.InvalidArgs.Specf("ParseTemplate(%q): %s cannot be the command", command, v)
+		}
+	}
+	return &Template{field: field}, nil
+}
+
+// function String() returns the command line of the Template.
+func (t *Template) String() string {
+	return strings.Join(t.field, " ")
+}
+
+// function Expand() returns the arguments following the command, of the
+// Template's command line playing the given media with the given subtitle
+// files.
+func (t *Template) Expand(m *media.Media, subs []string) []string {
+
+	args := []string{}
+	hasPath := false
+	for _, f := range t.field[1:] {
+		hasPath = hasPath || strings.Contains(f, varPath)
+		if (strings.Contains(f, varStart) && 0 == m.Start) ||
+			(strings.Contains(f, varEnd) && 0 == m.End) {
+			continue
+		}
+		if strings.Contains(f, varPath) {
+			for _, p := range m.Files() {
+				args = append(args, t.expandFile(f, m, p, ""))
+			}
+			continue
+		}
+		if strings.Contains(f, varSubs) {
+			for _, s := range subs {
+				args = append(args, t.expand(f, m, s))
+			}
+			continue
+		}
+		if strings.Contains(f, varSub) {
+			if len(subs) > 0 {
+				args = append(args, t.expand(f, m, subs[0]))
+			}
+			continue
+		}
+		args = append(args, t.expand(f, m, ""))
+	}
+	if !hasPath {
+		args = append(args, m.Files()...)
+	}
+	return args
+}
+
+// function expand() replaces the variables in the given field with the details
+// of the given media and subtitle file.
+func (t *Template) expand(field string, m *media.Media, subs string) string {
+	return t.expandFile(field, m, m.File(), subs)
+}
+
+// function expandFile() is like expand(), replacing {path} with the given path
+// of a file of the media.
+func (t *Template) expandFile(field string, m *media.Media, path, subs string) string {
+	title := m.Title
+	if "" == title || "--" == title {
+		title = m.Name
+	}
+	return strings.NewReplacer(
+		varPath, path,
+		varTitle, title,
+		varSubs, subs,
+		varSub, subs,
+		varStart, seconds(m.Start),
+		varEnd, seconds(m.End),
+	).Replace(field)
+}
+
+// function seconds() formats the given duration as a number of seconds, with
+// up to millisecond precision.
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Round(time.Millisecond).Seconds(), 'f', -1, 64)
+}
@@ -0,0 +1,266 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: playlist.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    reads the entries of playlist files in the M3U (.m3u, .m3u8) and PLS
+//    (.pls) formats.
+//
+// =============================================================================
+
+// package playlist reads playlist files written by other media players, so
+// that they can be imported as the playlists of a library. playlists are
+// written by package export.
+package playlist
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Format identifies the format of a playlist file.
+type Format int
+
+const (
+	FormatUnknown Format = iota - 1 // = -1
+	FormatM3U                       // =  0
+	FormatPLS                       // =  1
+)
+
+// local unexported constants for the playlist readers.
+const (
+	m3uInfo    = "#EXTINF:"
+	plsSection = "[playlist]"
+	plsFile    = "file"
+	plsTitle   = "title"
+	plsLength  = "length"
+)
+
+// type Entry is a single file listed by a playlist. fields the playlist didn't
+// define are left zero.
+type Entry struct {
+	Path     string        // absolute path to the file
+	Title    string        // title displayed for the file
+	Duration time.Duration // length of the file, 0 if unknown
+}
+
+// function FormatOfFileExt() returns the Format of playlist files with the given
+// file name extension.
+func FormatOfFileExt(ext string) Format {
+	switch strings.ToLower(ext) {
+	case ".m3u", ".m3u8":
+		return FormatM3U
+	case ".pls":
+		return FormatPLS
+	}
+	return FormatUnknown
+}
+
+// function ReadFile() reads the entries of the playlist file at the given
+// path, in order, in the format given by its file name extension. relative
+// paths are resolved against the directory containing the playlist, and
+// entries that aren't local files (e.g. streaming URLs) are skipped.
+func ReadFile(path string) ([]Entry, *rc.ReturnCode) {
+
+	format := FormatOfFileExt(filepath.Ext(path))
+	if FormatUnknown == format {
+		return nil, rc.ImportError.Specf("ReadFile(%q): unrecognized playlist format", path)
+	}
+	f, err := os.Open(path)
+	if nil != err {
+		return nil, rc.ImportError.Specf("ReadFile(%q): %s", path, err)
+	}
+	defer f.Close()
+
+	base, err := filepath.Abs(filepath.Dir(path))
+	if nil != err {
+		return nil, rc.ImportError.Specf("ReadFile(%q): filepath.Abs(): %s", path, err)
+	}
+	return Read(f, format, base)
+}
+
+// function Read() reads the entries of a playlist of the given format from r,
+// as described by ReadFile(). relative paths are resolved against the
+// directory base.
+func Read(r io.Reader, format Format, base string) ([]Entry, *rc.ReturnCode) {
+
+	line := []string{}
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		line = append(line, decodeLine(scan.Bytes()))
+	}
+	if err := scan.Err(); nil != err {
+		return nil, rc.ImportError.Specf("Read(): %s", err)
+	}
+	if len(line) > 0 {
+		line[0] = strings.TrimPrefix(line[0], "\ufeff") // byte order mark
+	}
+
+	var entry []Entry
+	switch format {
+	case FormatM3U:
+		entry = readM3U(line)
+	case FormatPLS:
+		entry = readPLS(line)
+	default:
+		return nil, rc.ImportError.Specf("Read(): unrecognized playlist format: %d", int(format))
+	}
+
+	list := []Entry{}
+	for _, e := range entry {
+		if path, ok := localPath(e.Path, base); ok {
+			e.Path = path
+			list = append(list, e)
+		}
+	}
+	return list, nil
+}
+
+// function readM3U() returns the entries of the given lines of an M3U playlist,
+// with their paths as written.
+func readM3U(line []string) []Entry {
+
+	list := []Entry{}
+	next := Entry{}
+	for _, l := range line {
+		l = strings.TrimSpace(l)
+		switch {
+		case "" == l:
+		case strings.HasPrefix(l, m3uInfo):
+			// #EXTINF:<seconds>[ <attributes>],<title>
+			info := strings.TrimPrefix(l, m3uInfo)
+			if sep := strings.Index(info, ","); sep >= 0 {
+				next.Title = strings.TrimSpace(info[sep+1:])
+				info = info[:sep]
+			}
+			if field := strings.Fields(info); len(field) > 0 {
+				next.Duration = seconds(field[0])
+			}
+		case strings.HasPrefix(l, "#"):
+			// any other directive or comment.
+		default:
+			next.Path = l
+			list = append(list, next)
+			next = Entry{}
+		}
+	}
+	return list
+}
+
+// function readPLS() returns the entries of the given lines of a PLS playlist,
+// with their paths as written, in the order of their numbered keys.
+func readPLS(line []string) []Entry {
+
+	entry := map[int]*Entry{}
+	inSection := false
+	for _, l := range line {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "[") {
+			inSection = strings.EqualFold(plsSection, l)
+			continue
+		}
+		sep := strings.Index(l, "=")
+		if !inSection || sep < 0 {
+			continue
+		}
+		// keys are of the form FileN, TitleN, or LengthN.
+		key, val := strings.ToLower(strings.TrimSpace(l[:sep])), strings.TrimSpace(l[sep+1:])
+		var name string
+		for _, n := range []string{plsFile, plsTitle, plsLength} {
+			if strings.HasPrefix(key, n) {
+				name = n
+				break
+			}
+		}
+		num, err := strconv.Atoi(strings.TrimPrefix(key, name))
+		if "" == name || nil != err {
+			continue
+		}
+		e, ok := entry[num]
+		if !ok {
+			e = &Entry{}
+			entry[num] = e
+		}
+		switch name {
+		case plsFile:
+			e.Path = val
+		case plsTitle:
+			e.Title = val
+		case plsLength:
+			e.Duration = seconds(val)
+		}
+	}
+
+	num := []int{}
+	for n, e := range entry {
+		if "" != e.Path {
+			num = append(num, n)
+		}
+	}
+	sort.Ints(num)
+	list := make([]Entry, len(num))
+	for i, n := range num {
+		list[i] = *entry[n]
+	}
+	return list
+}
+
+// function seconds() parses the given number of seconds. negative or invalid
+// numbers, used by playlists for unknown lengths, are returned as 0.
+func seconds(s string) time.Duration {
+	f, err := strconv.ParseFloat(s, 64)
+	if nil != err || f < 0 {
+		return 0
+	}
+	return time.Duration(f * float64(time.Second))
+}
+
+// function localPath() returns the absolute path of the given path or file://
+// URL written in a playlist, resolving relative paths against the directory
+// base. returns false if it doesn't refer to a local file.
+func localPath(path, base string) (string, bool) {
+
+	if strings.Contains(path, "://") {
+		u, err := url.Parse(path)
+		if nil != err || !strings.EqualFold("file", u.Scheme) {
+			return "", false
+		}
+		path = u.Path
+	}
+	// playlists written on Windows use backslashes.
+	if '/' == filepath.Separator {
+		path = strings.Replace(path, "\\", "/", -1)
+	}
+	path = filepath.FromSlash(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	return filepath.Clean(path), true
+}
+
+// function decodeLine() returns the given line of a playlist as a string. the
+// original .m3u format has no defined encoding; lines that aren't UTF-8 are
+// assumed to be Latin-1 (ISO 8859-1), which maps each byte to a rune.
+func decodeLine(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
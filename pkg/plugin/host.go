@@ -0,0 +1,257 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: host.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the Host, which dispatches each hook point to all of the running
+//    plugins subscribed to it and runs the shell hooks of each event.
+//
+// =============================================================================
+
+package plugin
+
+import (
+	"encoding/json"
+	"sync"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// the record fields a plugin is allowed to replace via HookEnrich. all other
+// fields are derived from the file system and are never overwritten.
+var enrichField = map[string]bool{
+	"Name":            true,
+	"Title":           true,
+	"Description":     true,
+	"ReleaseDate":     true,
+	"PlaybackCommand": true,
+	"Artist":          true,
+	"Album":           true,
+	"Track":           true,
+}
+
+// type Host is the collection of all running plugins and shell hooks. a nil
+// *Host is valid and has neither, so callers never need to check whether any
+// are in use.
+type Host struct {
+	plugin  []*Plugin
+	shell   []*ShellHook
+	running sync.WaitGroup // shell hooks still running
+}
+
+// function NewHost() starts each of the plugin executables at the given paths.
+// plugins that fail to start are reported and skipped.
+func NewHost(path ...string) *Host {
+
+	h := &Host{plugin: []*Plugin{}, shell: []*ShellHook{}}
+	for _, p := range path {
+		if "" == p {
+			continue
+		}
+		plug, err := Start(p)
+		if nil != err {
+			logs.Warn.Log(err)
+			continue
+		}
+		h.plugin = append(h.plugin, plug)
+	}
+	return h
+}
+
+// function Len() returns the number of plugins started by the Host.
+func (h *Host) Len() int {
+	if nil == h {
+		return 0
+	}
+	return len(h.plugin)
+}
+
+// function AddShellHook() registers a command line to run through the system
+// shell each time the given event occurs.
+func (h *Host) AddShellHook(event Event, command string) {
+	if nil == h || "" == command {
+		return
+	}
+	h.shell = append(h.shell, NewShellHook(event, command))
+}
+
+// function Close() stops all of the plugins and waits for any shell hooks that
+// are still running.
+func (h *Host) Close() {
+	if nil == h {
+		return
+	}
+	for _, p := range h.plugin {
+		p.Close()
+	}
+	h.plugin = nil
+	h.running.Wait()
+}
+
+// function Classify() asks each plugin subscribed to HookClassify to identify
+// the file at the given path. the answer from the first plugin that claims it
+// is returned along with true; if no plugin claims it, false is returned.
+func (h *Host) Classify(path string) (media.EntityClass, int, string, bool) {
+
+	if nil == h {
+		return media.ClassUnknown, -1, "", false
+	}
+
+	for _, p := range h.plugin {
+		if !p.Handles(HookClassify) {
+			continue
+		}
+		rsp, err := p.call(&Request{Hook: HookClassify, Path: path})
+		if nil != err {
+			logs.Warn.Verbose(err)
+			continue
+		}
+		if "" == rsp.Class {
+			continue
+		}
+		class, kind, ok := parseClassKind(rsp.Class, rsp.Kind)
+		if !ok {
+			logs.Warn.Verbosef("plugin %s: unrecognized classification of %q: %q/%q",
+				p, path, rsp.Class, rsp.Kind)
+			continue
+		}
+		extName := rsp.ExtName
+		if "" == extName {
+			extName = p.name
+		}
+		logs.Info.Tracef("plugin %s classified %q as %s/%s", p, path, rsp.Class, rsp.Kind)
+		return class, kind, extName, true
+	}
+	return media.ClassUnknown, -1, "", false
+}
+
+// function Enrich() sends the record of the given entity to each plugin
+// subscribed to HookEnrich, and updates the entity with the field values each
+// plugin returns. plugins are consulted in order, so later plugins see (and
+// may override) the changes made by earlier ones.
+func (h *Host) Enrich(ent media.StorableEntity) *rc.ReturnCode {
+
+	if nil == h {
+		return nil
+	}
+
+	for _, p := range h.plugin {
+		if !p.Handles(HookEnrich) {
+			continue
+		}
+		rec, ret := ent.ToRecord()
+		if nil != ret {
+			return ret
+		}
+		rsp, err := p.call(&Request{Hook: HookEnrich, Record: rec})
+		if nil != err {
+			logs.Warn.Verbose(err)
+			continue
+		}
+		if 0 == len(rsp.Fields) {
+			continue
+		}
+		for k, v := range rsp.Fields {
+			if !enrichField[k] {
+				logs.Warn.Verbosef("plugin %s: ignoring read-only field: %q", p, k)
+				continue
+			}
+			(*rec)[k] = v
+		}
+		data, mErr := json.Marshal(rec)
+		if nil != mErr {
+			return rc.InvalidJSONData.Specf(
+				"Enrich(%s): json.Marshal(): cannot marshal enriched record: %s", p, mErr)
+		}
+		if ret := ent.FromRecord(data); nil != ret {
+			return rc.PluginError.Specf("Enrich(%s): invalid field values: %s", p, ret)
+		}
+	}
+	return nil
+}
+
+// function Notify() sends the given event to each plugin subscribed to
+// HookEvent, and starts each shell hook registered for the event. shell hooks
+// run in the background so that slow commands don't hold up the caller. rec
+// may be nil if there is no record associated with the event.
+func (h *Host) Notify(event Event, rec interface{}) {
+
+	if nil == h {
+		return
+	}
+
+	// the environment and payload are constructed up front, because the
+	// record may well be modified by the caller before the hooks get around to
+	// running.
+	var env []string
+	var payload []byte
+	for _, s := range h.shell {
+		if event != s.event {
+			continue
+		}
+		if nil == env {
+			var ret *rc.ReturnCode
+			if env, ret = hookEnv(event, rec); nil != ret {
+				logs.Warn.Log(ret)
+				break
+			}
+			if payload, ret = hookPayload(event, rec); nil != ret {
+				logs.Warn.Log(ret)
+				break
+			}
+		}
+		h.running.Add(1)
+		go func(s *ShellHook) {
+			defer h.running.Done()
+			if err := s.run(env, payload); nil != err {
+				logs.Warn.Log(err)
+			}
+		}(s)
+	}
+
+	for _, p := range h.plugin {
+		if !p.Handles(HookEvent) {
+			continue
+		}
+		if _, err := p.call(&Request{Hook: HookEvent, Event: event, Record: rec}); nil != err {
+			logs.Warn.Verbose(err)
+		}
+	}
+}
+
+// function parseClassKind() converts the class and kind names used in the
+// protocol to their enum values.
+func parseClassKind(class, kind string) (media.EntityClass, int, bool) {
+
+	switch class {
+	case "media":
+		switch kind {
+		case "audio":
+			return media.ClassMedia, int(media.KindAudio), true
+		case "video":
+			return media.ClassMedia, int(media.KindVideo), true
+		case "image":
+			return media.ClassMedia, int(media.KindImage), true
+		case "document":
+			return media.ClassMedia, int(media.KindDocument), true
+		}
+	case "support":
+		switch kind {
+		case "subtitles":
+			return media.ClassSupport, int(media.SupportSubtitles), true
+		case "artwork":
+			return media.ClassSupport, int(media.SupportArtwork), true
+		case "metadata":
+			return media.ClassSupport, int(media.SupportMetadata), true
+		case "lyrics":
+			return media.ClassSupport, int(media.SupportLyrics), true
+		case "cuesheet":
+			return media.ClassSupport, int(media.SupportCueSheet), true
+		}
+	}
+	return media.ClassUnknown, -1, false
+}
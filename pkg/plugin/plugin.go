@@ -0,0 +1,310 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: plugin.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the protocol spoken between pimmp and its external plugins, and
+//    the management of each plugin's subprocess.
+//
+// =============================================================================
+
+// package plugin extends pimmp with external programs written in any language.
+//
+// each plugin is an executable started once as a subprocess. pimmp writes one
+// JSON-encoded Request per line to the plugin's stdin, and the plugin must
+// answer every request with exactly one JSON-encoded Response per line on its
+// stdout, echoing the request's "id". anything the plugin writes to stderr is
+// copied to pimmp's log.
+//
+// the first request is always the "hello" hook, to which the plugin responds
+// with its name and the list of hooks it wants to receive:
+//
+//	-> {"id":1,"hook":"hello","version":1}
+//	<- {"id":1,"name":"imdb","hooks":["classify","enrich","event"]}
+//
+// "classify" is sent for each file whose type pimmp could not identify. the
+// plugin may claim it by responding with a class ("media" or "support") and
+// kind ("audio", "video", "image", "document", "subtitles", "artwork",
+// "metadata", "lyrics", or "cuesheet"); an empty response leaves the file
+// unrecognized:
+//
+//	-> {"id":2,"hook":"classify","path":"/media/movies/foo.xyz"}
+//	<- {"id":2,"class":"media","kind":"video","extName":"XYZ Video"}
+//
+// "enrich" is sent with the record of each newly discovered media, before it
+// is stored. the plugin may respond with replacement values for any of the
+// user-writable fields (Name, Title, Description, ReleaseDate, Album, Track,
+// PlaybackCommand):
+//
+//	-> {"id":3,"hook":"enrich","record":{"AbsPath":"/media/movies/foo.mkv",...}}
+//	<- {"id":3,"fields":{"Title":"Foo","ReleaseDate":"1999-01-01T00:00:00Z"}}
+//
+// "event" notifies the plugin of something that happened; the response is
+// only an acknowledgement:
+//
+//	-> {"id":4,"hook":"event","event":"new-media","record":{...}}
+//	<- {"id":4}
+//
+// the events are "new-media", "new-support", "media-removed" (its record
+// deleted or orphaned), "scan-complete", "playback-started", and
+// "playback-finished".
+//
+// shell hooks are the simpler alternative to plugins: a command line run each
+// time an event occurs, given the event in its environment (see ShellHook) and
+// on its stdin as a single line of JSON, e.g.:
+//
+//	{"event":"media-removed","record":{"AbsPath":"/media/movies/foo.mkv",...}}
+//
+// any response may instead set "error" to a description of why the request
+// could not be handled.
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// var logs are the loggers of the messages about the plugins and shell hooks,
+// filtered as component "plugin" (see console.Register()).
+var logs = console.Register("plugin")
+
+// constant ProtocolVersion is sent to each plugin in the "hello" request so
+// that plugins can detect incompatible changes to the protocol.
+const ProtocolVersion = 1
+
+// local unexported constants controlling the plugin subprocesses.
+const (
+	defaultTimeout = 5 * time.Second // maximum time to wait for any response
+)
+
+// type Hook identifies the kind of a request sent to a plugin.
+type Hook string
+
+// the hooks plugins may subscribe to. HookHello is always sent and cannot be
+// subscribed to.
+const (
+	HookHello    Hook = "hello"
+	HookClassify Hook = "classify"
+	HookEnrich   Hook = "enrich"
+	HookEvent    Hook = "event"
+)
+
+// type Event identifies the occurrences about which plugins are notified via
+// HookEvent.
+type Event string
+
+// the events sent to plugins subscribed to HookEvent.
+const (
+	EventNewMedia      Event = "new-media"         // a new media file was discovered
+	EventNewSupport    Event = "new-support"       // a new support file was discovered
+	EventMediaRemoved  Event = "media-removed"     // the record of a media file was removed
+	EventScanComplete  Event = "scan-complete"     // a library scan finished
+	EventPlaybackStart Event = "playback-started"  // the player started
+	EventPlaybackDone  Event = "playback-finished" // the player exited
+)
+
+// type Request is the message written to a plugin's stdin.
+type Request struct {
+	ID      uint64      `json:"id"`
+	Hook    Hook        `json:"hook"`
+	Version int         `json:"version,omitempty"` // HookHello
+	Path    string      `json:"path,omitempty"`    // HookClassify
+	Event   Event       `json:"event,omitempty"`   // HookEvent
+	Record  interface{} `json:"record,omitempty"`  // HookEnrich, HookEvent
+}
+
+// type Response is the message read from a plugin's stdout.
+type Response struct {
+	ID      uint64                 `json:"id"`
+	Error   string                 `json:"error,omitempty"`
+	Name    string                 `json:"name,omitempty"`    // HookHello
+	Hooks   []Hook                 `json:"hooks,omitempty"`   // HookHello
+	Class   string                 `json:"class,omitempty"`   // HookClassify
+	Kind    string                 `json:"kind,omitempty"`    // HookClassify
+	ExtName string                 `json:"extName,omitempty"` // HookClassify
+	Fields  map[string]interface{} `json:"fields,omitempty"`  // HookEnrich
+}
+
+// type Plugin represents a single running plugin subprocess.
+type Plugin struct {
+	path  string        // path to the plugin executable
+	name  string        // name reported by the plugin (default: base name of path)
+	hooks map[Hook]bool // hooks the plugin subscribed to
+
+	cmd    *exec.Cmd      // the plugin subprocess
+	stdin  io.WriteCloser // requests are written here
+	lines  chan []byte    // each line read from the plugin's stdout
+	lock   sync.Mutex     // serializes request/response pairs
+	nextID uint64         // ID of the next request
+	dead   bool           // set once the plugin has stopped responding
+}
+
+// function Start() launches the plugin executable at the given path and
+// performs the "hello" handshake with it.
+func Start(path string) (*Plugin, *rc.ReturnCode) {
+
+	cmd := exec.Command(path)
+	stdin, err := cmd.StdinPipe()
+	if nil != err {
+		return nil, rc.PluginError.Specf("Start(%q): StdinPipe(): %s", path, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if nil != err {
+		return nil, rc.PluginError.Specf("Start(%q): StdoutPipe(): %s", path, err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if nil != err {
+		return nil, rc.PluginError.Specf("Start(%q): StderrPipe(): %s", path, err)
+	}
+	if err := cmd.Start(); nil != err {
+		return nil, rc.PluginError.Specf("Start(%q): %s", path, err)
+	}
+
+	p := &Plugin{
+		path:   path,
+		name:   filepath.Base(path),
+		hooks:  map[Hook]bool{},
+		cmd:    cmd,
+		stdin:  stdin,
+		lines:  make(chan []byte),
+		nextID: 1,
+		dead:   false,
+	}
+
+	// the reader goroutines live as long as the subprocess does. stdout is
+	// funneled through a channel so that reads can time out.
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := make([]byte, len(scanner.Bytes()))
+			copy(line, scanner.Bytes())
+			p.lines <- line
+		}
+		close(p.lines)
+	}()
+	go func(name string) {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			logs.Warn.Verbosef("plugin %s: %s", name, scanner.Text())
+		}
+	}(p.name)
+
+	rsp, ret := p.call(&Request{Hook: HookHello, Version: ProtocolVersion})
+	if nil != ret {
+		p.Close()
+		return nil, ret
+	}
+	if "" != rsp.Name {
+		p.name = rsp.Name
+	}
+	for _, h := range rsp.Hooks {
+		p.hooks[h] = true
+	}
+	logs.Info.Verbosef("started plugin: %s (%q) hooks: %v", p.name, path, rsp.Hooks)
+
+	return p, nil
+}
+
+// function String() creates a string representation of the Plugin for easy
+// identification in logs.
+func (p *Plugin) String() string {
+	return p.name
+}
+
+// function Handles() returns true if and only if the plugin subscribed to the
+// given hook and is still responding.
+func (p *Plugin) Handles(hook Hook) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return !p.dead && p.hooks[hook]
+}
+
+// function call() sends a single request to the plugin and waits for its
+// response. if the plugin fails to respond in time, or responds with garbage,
+// it is considered dead and no further requests are sent to it.
+func (p *Plugin) call(req *Request) (*Response, *rc.ReturnCode) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.dead {
+		return nil, rc.PluginError.Specf("call(%s): plugin is not responding", p)
+	}
+
+	req.ID = p.nextID
+	p.nextID++
+
+	data, err := json.Marshal(req)
+	if nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"call(%s): json.Marshal(): cannot marshal request: %s", p, err)
+	}
+	if _, err := p.stdin.Write(append(data, '\n')); nil != err {
+		p.dead = true
+		return nil, rc.PluginError.Specf("call(%s): write: %s", p, err)
+	}
+
+	timeout := time.After(defaultTimeout)
+	for {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				p.dead = true
+				return nil, rc.PluginError.Specf("call(%s): plugin exited", p)
+			}
+			rsp := &Response{}
+			if err := json.Unmarshal(line, rsp); nil != err {
+				p.dead = true
+				return nil, rc.PluginError.Specf(
+					"call(%s): invalid response: %q: %s", p, string(line), err)
+			}
+			if rsp.ID != req.ID {
+				// a stale response to some earlier request, keep waiting.
+				continue
+			}
+			if "" != rsp.Error {
+				return nil, rc.PluginError.Specf("call(%s): %s: %s", p, req.Hook, rsp.Error)
+			}
+			return rsp, nil
+		case <-timeout:
+			p.dead = true
+			return nil, rc.PluginError.Specf(
+				"call(%s): no response to %s within %s", p, req.Hook, defaultTimeout)
+		}
+	}
+}
+
+// function Close() closes the plugin's stdin, which signals it to exit, and
+// waits for the subprocess to terminate.
+func (p *Plugin) Close() {
+
+	p.lock.Lock()
+	p.dead = true
+	p.lock.Unlock()
+
+	p.stdin.Close()
+	done := make(chan error, 1)
+	go func() { done <- p.cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(defaultTimeout):
+		logs.Warn.Verbosef("killing unresponsive plugin: %s", p)
+		p.cmd.Process.Kill()
+	}
+	// drain anything left so the reader goroutine can exit.
+	go func() {
+		for range p.lines {
+		}
+	}()
+}
@@ -0,0 +1,150 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: shell.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines shell hooks, the simpler alternative to plugins: a command line
+//    run through the system shell each time an event occurs.
+//
+// =============================================================================
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// the prefixes of the names of the environment variables defined for a shell
+// hook. those of the record's fields are kept apart from the PIMMP_<OPTION>
+// variables configuring pimmp (whose option names never contain "_"), so that
+// a hook running pimmp isn't configured by the record, e.g. by its Tags.
+const (
+	EnvPrefix    = "PIMMP_"              // prepended to the name of every variable
+	RecordPrefix = EnvPrefix + "RECORD_" // prepended to the name of each field of the record
+)
+
+// type ShellHook is a command line run through the system shell whenever a
+// specific event occurs. the command inherits pimmp's environment, extended
+// with PIMMP_EVENT naming the event and a PIMMP_RECORD_<FIELD> variable for
+// each field of the record associated with the event (e.g.
+// PIMMP_RECORD_ABSPATH, PIMMP_RECORD_TITLE). fields that are not simple values
+// are encoded as JSON. the event and its record are also written to the
+// command's stdin as a single JSON object (see hookPayload()), like the event
+// requests sent to plugins.
+type ShellHook struct {
+	event   Event
+	command string
+}
+
+// function NewShellHook() creates a new ShellHook running command whenever the
+// given event occurs.
+func NewShellHook(event Event, command string) *ShellHook {
+	return &ShellHook{event: event, command: command}
+}
+
+// function String() creates a string representation of the ShellHook for easy
+// identification in logs.
+func (s *ShellHook) String() string {
+	return fmt.Sprintf("%s: %q", s.event, s.command)
+}
+
+// function run() runs the hook's command with the given environment variables
+// (see hookEnv()) and stdin (see hookPayload()), and waits for it to exit. the
+// command's output is copied to the log.
+func (s *ShellHook) run(env []string, payload []byte) *rc.ReturnCode {
+
+	args := append(append([]string{}, platform.Shell[1:]...), s.command)
+	cmd := exec.Command(platform.Shell[0], args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = bytes.NewReader(payload)
+
+	logs.Info.Tracef("running shell hook: %s", s)
+	out, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if "" != line {
+			logs.Info.Verbosef("shell hook %s: %s", s.event, line)
+		}
+	}
+	if nil != err {
+		return rc.PluginError.Specf("run(%s): %s", s, err)
+	}
+	return nil
+}
+
+// type hookEvent is the JSON object written to the stdin of a shell hook.
+type hookEvent struct {
+	Event  Event       `json:"event"`
+	Record interface{} `json:"record,omitempty"`
+}
+
+// function hookPayload() constructs the JSON object, followed by a newline,
+// describing the given event and record written to the stdin of shell hooks.
+func hookPayload(event Event, rec interface{}) ([]byte, *rc.ReturnCode) {
+
+	data, err := json.Marshal(&hookEvent{Event: event, Record: rec})
+	if nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"hookPayload(%s): cannot convert record to JSON: %s", event, err)
+	}
+	return append(data, '\n'), nil
+}
+
+// function hookEnv() constructs the environment variables describing the given
+// event and record.
+func hookEnv(event Event, rec interface{}) ([]string, *rc.ReturnCode) {
+
+	env := []string{EnvPrefix + "EVENT=" + string(event)}
+	if nil == rec {
+		return env, nil
+	}
+
+	// round-trip through JSON so that records of any type, including embedded
+	// structs, are flattened into a single map of field names.
+	field := map[string]interface{}{}
+	data, err := json.Marshal(rec)
+	if nil == err {
+		err = json.Unmarshal(data, &field)
+	}
+	if nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"hookEnv(%s): cannot convert record to environment: %s", event, err)
+	}
+
+	key := make([]string, 0, len(field))
+	for k := range field {
+		key = append(key, k)
+	}
+	sort.Strings(key)
+
+	for _, k := range key {
+		var val string
+		switch v := field[k].(type) {
+		case nil:
+			val = ""
+		case string:
+			val = v
+		case bool:
+			val = strconv.FormatBool(v)
+		case float64:
+			val = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			enc, _ := json.Marshal(v)
+			val = string(enc)
+		}
+		env = append(env, RecordPrefix+strings.ToUpper(k)+"="+val)
+	}
+	return env, nil
+}
@@ -0,0 +1,177 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: probe.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    reads the technical metadata of video files (length, resolution, codecs,
+//    and embedded audio and subtitle tracks) using ffprobe.
+//
+// =============================================================================
+
+// package probe describes the streams of video files using ffprobe, which is
+// part of ffmpeg. probing starts a process for every file, so it is optional,
+// and only available if ffprobe is installed.
+package probe
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant maxProbeError is the maximum length of the prober's output kept as
+// the reason a file couldn't be probed.
+const maxProbeError = 200
+
+// the prober invoked to describe a file, and its arguments preceding the file
+// path. ffprobe prints the container format and every stream as JSON.
+var (
+	prober    = "ffprobe"
+	proberPre = []string{"-v", "error", "-print_format", "json", "-show_format", "-show_streams"}
+)
+
+// type output is the subset of ffprobe's JSON output that is used.
+type output struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+	} `json:"format"`
+	Streams []stream `json:"streams"`
+}
+
+// type stream is a single stream of ffprobe's JSON output.
+type stream struct {
+	Index       int               `json:"index"`
+	CodecType   string            `json:"codec_type"`
+	CodecName   string            `json:"codec_name"`
+	Width       int64             `json:"width"`
+	Height      int64             `json:"height"`
+	Duration    string            `json:"duration"`
+	Tags        map[string]string `json:"tags"`
+	Disposition map[string]int    `json:"disposition"`
+}
+
+// function Available() returns true if the prober used by Probe() is
+// installed.
+func Available() bool {
+	_, err := exec.LookPath(prober)
+	return nil == err
+}
+
+// function Probe() describes the streams of the file of the given video,
+// recording them in its technical info fields and its Duration. fields that
+// ffprobe doesn't report are left unchanged.
+func Probe(m *media.VideoMedia) *rc.ReturnCode {
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(prober, append(append([]string{}, proberPre...), m.AbsPath)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); nil != err {
+		msg := strings.TrimSpace(stderr.String())
+		if "" == msg {
+			msg = err.Error()
+		}
+		if len(msg) > maxProbeError {
+			msg = msg[:maxProbeError] + "..."
+		}
+		return rc.ProbeError.Specf("Probe(%q): %s", m.AbsPath, strings.Replace(msg, "\n", "; ", -1))
+	}
+
+	var out output
+	if err := json.Unmarshal(stdout.Bytes(), &out); nil != err {
+		return rc.ProbeError.Specf("Probe(%q): json.Unmarshal(): %s", m.AbsPath, err)
+	}
+	if 0 == len(out.Streams) {
+		return rc.ProbeError.Specf("Probe(%q): no streams found", m.AbsPath)
+	}
+	apply(m, &out)
+	return nil
+}
+
+// function apply() records the given ffprobe output in the given video. the
+// first video and audio streams are considered primary, unless another is
+// flagged as the default.
+func apply(m *media.VideoMedia, out *output) {
+
+	if "" != out.Format.FormatName {
+		// ffprobe names every format the demuxer handles, e.g. "matroska,webm";
+		// the first is the most general.
+		m.Container = strings.SplitN(out.Format.FormatName, ",", 2)[0]
+	}
+
+	var video, audio *stream
+	audioTracks, subtitleTracks := []media.Track{}, []media.Track{}
+	for i := range out.Streams {
+		s := &out.Streams[i]
+		isDefault := 0 != s.Disposition["default"]
+		switch s.CodecType {
+		case "video":
+			// cover art is reported as a (single frame) video stream.
+			if 0 != s.Disposition["attached_pic"] {
+				continue
+			}
+			if nil == video || isDefault && 0 == video.Disposition["default"] {
+				video = s
+			}
+		case "audio":
+			if nil == audio || isDefault && 0 == audio.Disposition["default"] {
+				audio = s
+			}
+			audioTracks = append(audioTracks, track(s))
+		case "subtitle":
+			subtitleTracks = append(subtitleTracks, track(s))
+		}
+	}
+	if nil != video {
+		m.VideoCodec, m.Width, m.Height = video.CodecName, video.Width, video.Height
+	}
+	if nil != audio {
+		m.AudioCodec = audio.CodecName
+	}
+	m.AudioTracks, m.SubtitleTracks = audioTracks, subtitleTracks
+
+	// the container's length is preferred, since not every container records
+	// the length of each stream.
+	length := seconds(out.Format.Duration)
+	if 0 == length && nil != video {
+		length = seconds(video.Duration)
+	}
+	if length > 0 {
+		m.Duration = length
+	}
+}
+
+// function track() returns the description of the given audio or subtitle
+// stream.
+func track(s *stream) media.Track {
+	lang := s.Tags["language"]
+	if "und" == lang { // undetermined
+		lang = ""
+	}
+	return media.Track{
+		Index:    s.Index,
+		Codec:    s.CodecName,
+		Language: lang,
+		Title:    s.Tags["title"],
+		Default:  0 != s.Disposition["default"],
+	}
+}
+
+// function seconds() parses the given length in (fractional) seconds, as
+// printed by ffprobe, returning 0 if it is invalid.
+func seconds(s string) time.Duration {
+	f, err := strconv.ParseFloat(s, 64)
+	if nil != err || f <= 0 {
+		return 0
+	}
+	return time.Duration(f * float64(time.Second))
+}
@@ -0,0 +1,250 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: profile.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the viewing profiles which hide media from restricted viewers,
+//    e.g. children sharing a home theater PC.
+//
+// =============================================================================
+
+// package profile defines viewing profiles for parental controls. a profile
+// hides the media having any of its tags or content ratings, or residing in
+// any of its paths. the active profile is saved with the profiles, so that it
+// stays in effect until switched, and once a PIN is set, switching or changing
+// profiles requires it. of course, anyone able to edit the profiles file can
+// defeat this; it is meant to keep curious kids out, not determined adults.
+package profile
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant DefaultFileName is the name of the file, in the configuration
+// directory, in which profiles are saved.
+const DefaultFileName = "profiles.json"
+
+// constant saltSize is the number of random bytes hashed with each PIN.
+const saltSize = 16
+
+// type Profile is a single viewing profile.
+type Profile struct {
+	Name        string   // unique name of the profile
+	HideTags    []string // media having any of these tags are hidden
+	HideRatings []string // media having any of these content ratings are hidden
+	HidePaths   []string // media in (or matching, if a glob) these paths are hidden
+}
+
+// type Store is the set of all profiles, the one that is active, and the PIN
+// protecting them.
+type Store struct {
+	Active   string     // name of the active profile, empty if none
+	PINSalt  string     // random salt hashed with the PIN (hex)
+	PINHash  string     // SHA-256 hash of the salt and PIN (hex), empty if none
+	Profiles []*Profile // all defined profiles, sorted by name
+}
+
+// function Restricted() returns true if the Profile hides any media.
+func (p *Profile) Restricted() bool {
+	return nil != p && len(p.HideTags)+len(p.HideRatings)+len(p.HidePaths) > 0
+}
+
+// function Hides() returns true if the given media is hidden by the Profile.
+func (p *Profile) Hides(m *media.Media) bool {
+
+	if nil == p || nil == m {
+		return false
+	}
+	for _, t := range p.HideTags {
+		if m.HasTags(t) {
+			return true
+		}
+	}
+	for _, r := range p.HideRatings {
+		if "" != m.ContentRating && strings.EqualFold(r, m.ContentRating) {
+			return true
+		}
+	}
+	if nil != m.Entity {
+		for _, h := range p.HidePaths {
+			if inPath(m.AbsPath, h) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// function inPath() returns true if the given absolute path matches the given
+// glob pattern, or if it is the given path or any file below it.
+func inPath(absPath, pattern string) bool {
+	if strings.ContainsAny(pattern, "*?[") {
+		if ok, _ := filepath.Match(pattern, absPath); ok {
+			return true
+		}
+		ok, _ := filepath.Match(pattern, filepath.Base(absPath))
+		return ok
+	}
+	pattern = filepath.Clean(pattern)
+	return absPath == pattern ||
+		strings.HasPrefix(absPath, strings.TrimSuffix(pattern, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// function Protected() returns true if switching or changing profiles requires
+// a PIN.
+func (s *Store) Protected() bool {
+	return "" != s.PINHash
+}
+
+// function SetPIN() sets the PIN required to switch or change profiles. an
+// empty PIN removes the requirement.
+func (s *Store) SetPIN(pin string) *rc.ReturnCode {
+	if "" == pin {
+		s.PINSalt, s.PINHash = "", ""
+		return nil
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); nil != err {
+		return rc.InvalidConfig.Specf("SetPIN(): cannot generate salt: %s", err)
+	}
+	s.PINSalt = hex.EncodeToString(salt)
+	s.PINHash = hashPIN(s.PINSalt, pin)
+	return nil
+}
+
+// function CheckPIN() returns true if the given PIN is the one required to
+// switch or change profiles, or if no PIN is required.
+func (s *Store) CheckPIN(pin string) bool {
+	if !s.Protected() {
+		return true
+	}
+	return 1 == subtle.ConstantTimeCompare(
+		[]byte(s.PINHash), []byte(hashPIN(s.PINSalt, pin)))
+}
+
+// function hashPIN() returns the hash (hex) of the given salt (hex) and PIN.
+func hashPIN(salt, pin string) string {
+	sum := sha256.Sum256([]byte(salt + ":" + pin))
+	return hex.EncodeToString(sum[:])
+}
+
+// function Load() reads the profiles saved in the file at the given path. a
+// file that doesn't exist contains no profiles.
+func Load(path string) (*Store, *rc.ReturnCode) {
+
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		if os.IsNotExist(err) {
+			return &Store{Profiles: []*Profile{}}, nil
+		}
+		return nil, rc.InvalidConfig.Specf("Load(%q): %s", path, err)
+	}
+	s := &Store{}
+	if err := json.Unmarshal(data, s); nil != err {
+		return nil, rc.InvalidJSONData.Specf("Load(%q): json.Unmarshal(): %s", path, err)
+	}
+	if nil == s.Profiles {
+		s.Profiles = []*Profile{}
+	}
+	return s, nil
+}
+
+// function Save() writes the Store to the file at the given path, replacing
+// its content. since the file contains the PIN hashes, it is readable only by
+// its owner.
+func (s *Store) Save(path string) *rc.ReturnCode {
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if nil != err {
+		return rc.InvalidJSONData.Specf("Save(%q): json.MarshalIndent(): %s", path, err)
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); nil != err {
+		return rc.InvalidConfig.Specf("Save(%q): %s", path, err)
+	}
+	if err := os.Rename(tmp, path); nil != err {
+		os.Remove(tmp)
+		return rc.InvalidConfig.Specf("Save(%q): %s", path, err)
+	}
+	return nil
+}
+
+// function Find() returns the profile with the given name (ignoring case), or
+// nil if there is none.
+func (s *Store) Find(name string) *Profile {
+	for _, p := range s.Profiles {
+		if strings.EqualFold(p.Name, strings.TrimSpace(name)) {
+			return p
+		}
+	}
+	return nil
+}
+
+// function Current() returns the active profile, or nil if there is none, in
+// which case nothing is hidden.
+func (s *Store) Current() *Profile {
+	if "" == s.Active {
+		return nil
+	}
+	return s.Find(s.Active)
+}
+
+// function Put() adds the given profile, or replaces the profile of the same
+// name. returns true if it was replaced.
+func (s *Store) Put(p *Profile) bool {
+	for i, q := range s.Profiles {
+		if strings.EqualFold(q.Name, p.Name) {
+			s.Profiles[i] = p
+			return true
+		}
+	}
+	s.Profiles = append(s.Profiles, p)
+	sort.Slice(s.Profiles, func(a, b int) bool { return s.Profiles[a].Name < s.Profiles[b].Name })
+	return false
+}
+
+// function Remove() removes the profile with the given name (ignoring case).
+// returns true if it was found and removed. the active profile can't be
+// removed.
+func (s *Store) Remove(name string) bool {
+	for i, p := range s.Profiles {
+		if strings.EqualFold(p.Name, strings.TrimSpace(name)) {
+			if strings.EqualFold(p.Name, s.Active) {
+				return false
+			}
+			s.Profiles = append(s.Profiles[:i], s.Profiles[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// function Switch() makes the profile with the given name active. an empty name
+// makes no profile active, hiding nothing.
+func (s *Store) Switch(name string) *rc.ReturnCode {
+	if "" == strings.TrimSpace(name) {
+		s.Active = ""
+		return nil
+	}
+	p := s.Find(name)
+	if nil == p {
+		return rc.InvalidArgs.Specf("no such profile: %q", name)
+	}
+	s.Active = p.Name
+	return nil
+}
@@ -0,0 +1,190 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: client.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the HTTP client shared by all providers, which rate limits their
+//    requests and caches their responses on disk.
+//
+// =============================================================================
+
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the provider HTTP client.
+const (
+	requestTimeout = 20 * time.Second // maximum time waited for each response
+	maxResponseLen = 4 << 20          // longest response body read
+	cacheExt       = ".json"          // file name extension of cached responses
+	userAgent      = "pimmp/1.0 (https://github.com/ardnew/pimmp)"
+)
+
+// exported constants for configuring the Cache.
+const (
+	CacheDirName = "metadata-cache"    // name of the cache directory, in the library data directory
+	DefaultTTL   = 30 * 24 * time.Hour // age after which cached responses are requested again
+)
+
+// type Cache keeps the responses of providers on disk, keyed by the request
+// (excluding any credentials), so that repeated lookups need no network access.
+type Cache struct {
+	dir     string        // directory containing the cached responses
+	ttl     time.Duration // age after which responses are requested again
+	offline bool          // never make requests, using only the cached responses
+}
+
+// function NewCache() creates a Cache storing responses in the given directory,
+// which is created when the first response is stored.
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir, ttl: DefaultTTL}
+}
+
+// function SetOffline() selects whether requests are never made, so that only
+// the responses already cached are used; media not yet cached aren't found.
+func (c *Cache) SetOffline(offline bool) { c.offline = offline }
+
+// function SetTTL() sets the age after which cached responses are requested
+// again. a non-positive TTL keeps responses forever.
+func (c *Cache) SetTTL(ttl time.Duration) { c.ttl = ttl }
+
+// function path() returns the path of the file caching the response to the
+// given request of the given provider.
+func (c *Cache) path(provider, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, provider, hex.EncodeToString(sum[:])+cacheExt)
+}
+
+// function get() returns the cached response to the given request, if any and
+// it hasn't expired (responses never expire while offline).
+func (c *Cache) get(provider, key string) ([]byte, bool) {
+	if nil == c {
+		return nil, false
+	}
+	path := c.path(provider, key)
+	info, err := os.Stat(path)
+	if nil != err {
+		return nil, false
+	}
+	if !c.offline && c.ttl > 0 && time.Since(info.ModTime()) > c.ttl {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		return nil, false
+	}
+	return data, true
+}
+
+// function put() caches the response to the given request.
+func (c *Cache) put(provider, key string, data []byte) *rc.ReturnCode {
+	if nil == c {
+		return nil
+	}
+	path := c.path(provider, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); nil != err {
+		return rc.FetchError.Specf("put(%q): os.MkdirAll(): %s", path, err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); nil != err {
+		return rc.FetchError.Specf("put(%q): ioutil.WriteFile(): %s", path, err)
+	}
+	return nil
+}
+
+// type limiter spaces consecutive requests at least interval apart.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// function wait() blocks until another request is permitted.
+func (l *limiter) wait() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if d := time.Until(l.next); d > 0 {
+		time.Sleep(d)
+	}
+	l.next = time.Now().Add(l.interval)
+}
+
+// type client performs the HTTP requests of a single provider.
+type client struct {
+	name  string       // name of the provider, also the name of its cache directory
+	http  *http.Client // the underlying HTTP client
+	limit *limiter     // spaces the requests made (cached responses aren't limited)
+	cache *Cache       // cached responses (nil if unused)
+}
+
+// function newClient() creates a client for the named provider, permitting at
+// most one request per the given interval.
+func newClient(name string, interval time.Duration, cache *Cache) *client {
+	return &client{
+		name:  name,
+		http:  &http.Client{Timeout: requestTimeout},
+		limit: &limiter{interval: interval},
+		cache: cache,
+	}
+}
+
+// function get() performs the request constructed by the given function (only
+// if its response isn't cached), unmarshalling its JSON response into v. key
+// identifies the request in the cache, and so must exclude any credentials.
+// returns false (and no error) if the request isn't cached and the cache is
+// offline. a 404 response is cached as the JSON null, leaving v unchanged.
+func (c *client) get(key string, request func() (*http.Request, *rc.ReturnCode), v interface{}) (bool, *rc.ReturnCode) {
+
+	data, ok := c.cache.get(c.name, key)
+	if !ok {
+		if nil != c.cache && c.cache.offline {
+			return false, nil
+		}
+		req, ret := request()
+		if nil != ret {
+			return false, ret
+		}
+		c.limit.wait()
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", userAgent) // required by MusicBrainz
+		rsp, err := c.http.Do(req)
+		if nil != err {
+			return false, rc.FetchError.Specf("get(%s): %s", key, err)
+		}
+		data, err = ioutil.ReadAll(io.LimitReader(rsp.Body, maxResponseLen+1))
+		rsp.Body.Close()
+		if nil != err {
+			return false, rc.FetchError.Specf("get(%s): %s", key, err)
+		}
+		if len(data) > maxResponseLen {
+			return false, rc.FetchError.Specf("get(%s): response too long", key)
+		}
+		switch {
+		case http.StatusNotFound == rsp.StatusCode:
+			data = []byte("null")
+		case rsp.StatusCode < 200 || rsp.StatusCode > 299:
+			return false, rc.FetchError.Specf("get(%s): %s", key, rsp.Status)
+		}
+		// failing to cache the response is no reason to discard it.
+		_ = c.cache.put(c.name, key, data)
+	}
+	if err := json.Unmarshal(data, v); nil != err {
+		return false, rc.FetchError.Specf("get(%s): json.Unmarshal(): %s", key, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,62 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: fingerprint.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    computes the acoustic fingerprints of audio files using fpcalc, the
+//    command line tool of Chromaprint.
+//
+// =============================================================================
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// the fingerprinter invoked to fingerprint a file, and its arguments preceding
+// the file path. fpcalc prints the fingerprint and length as JSON.
+var (
+	fingerprinter    = "fpcalc"
+	fingerprinterPre = []string{"-json"}
+)
+
+// function CanFingerprint() returns true if the fingerprinter used by
+// Fingerprint() is installed.
+func CanFingerprint() bool {
+	_, err := exec.LookPath(fingerprinter)
+	return nil == err
+}
+
+// function Fingerprint() returns the Chromaprint fingerprint of the audio file
+// at the given path, and the length of its audio.
+func Fingerprint(path string) (string, time.Duration, *rc.ReturnCode) {
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(fingerprinter, append(append([]string{}, fingerprinterPre...), path)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); nil != err {
+		msg := strings.TrimSpace(stderr.String())
+		if "" == msg {
+			msg = err.Error()
+		}
+		return "", 0, rc.FetchError.Specf("Fingerprint(%q): %s", path, msg)
+	}
+	var out struct {
+		Duration    float64 `json:"duration"`
+		Fingerprint string  `json:"fingerprint"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); nil != err || "" == out.Fingerprint {
+		return "", 0, rc.FetchError.Specf("Fingerprint(%q): invalid output: %v", path, err)
+	}
+	return out.Fingerprint, time.Duration(out.Duration * float64(time.Second)), nil
+}
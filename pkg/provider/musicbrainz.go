@@ -0,0 +1,319 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: musicbrainz.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the provider of audio track metadata from MusicBrainz, identifying
+//    tracks by their tags or by their AcoustID fingerprints.
+//
+// =============================================================================
+
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the MusicBrainz provider.
+const (
+	mbName       = "MusicBrainz"
+	mbAPI        = "https://musicbrainz.org/ws/2"
+	mbInterval   = 1100 * time.Millisecond // MusicBrainz permits 1 request per second
+	mbMinScore   = 90                      // lowest search score (0-100) accepted as a match
+	acoustIDName = "AcoustID"
+	acoustIDAPI  = "https://api.acoustid.org/v2/lookup"
+	acoustIDRate = 340 * time.Millisecond // AcoustID permits 3 requests per second
+	acoustIDMin  = 0.8                    // lowest fingerprint score (0-1) accepted as a match
+)
+
+// type TrackQuery identifies an audio track by its tags, or by its acoustic
+// fingerprint if the tags are missing.
+type TrackQuery struct {
+	Title       string        // title of the track
+	Artist      string        // performer of the track
+	Album       string        // name of the album on which the track appears
+	Duration    time.Duration // length of the track (0 if unknown)
+	Path        string        // absolute path of the file, fingerprinted if untagged
+	Fingerprint string        // Chromaprint fingerprint (computed on demand)
+}
+
+// function NewTrackQuery() returns the TrackQuery identifying the given audio.
+// returns false if the media isn't audio.
+func NewTrackQuery(a *media.AudioMedia) (TrackQuery, bool) {
+	if nil == a || nil == a.Media || media.KindAudio != a.Kind {
+		return TrackQuery{}, false
+	}
+	q := TrackQuery{Artist: a.Artist, Album: a.Album, Duration: a.Duration, Path: a.AbsPath}
+	// a title that is just the file name isn't a tag.
+	if a.Title != a.AbsName {
+		q.Title = a.Title
+	}
+	return q, true
+}
+
+// function IsTagged() returns true if the query has enough tags to be searched
+// for by name, i.e. a title and an artist or album.
+func (q TrackQuery) IsTagged() bool {
+	return "" != q.Title && ("" != q.Artist || "" != q.Album)
+}
+
+// function String() returns a readable description of the query, e.g.
+// "Artist - Title" or the file path of an untagged track.
+func (q TrackQuery) String() string {
+	if q.IsTagged() {
+		return strings.TrimPrefix(q.Artist+" - "+q.Title, " - ")
+	}
+	return q.Path
+}
+
+// type Track is the metadata of an audio track found by MusicBrainz.
+type Track struct {
+	ID          string    // MusicBrainz recording ID (MBID)
+	Title       string    // title of the track
+	Artist      string    // performer of the track
+	Album       string    // title of the earliest release on which the track appears
+	Track       int64     // number of the track on that release (0 if unknown)
+	ReleaseDate time.Time // date the track was first released
+}
+
+// function Apply() copies the metadata of the Track into the given audio. only
+// the fields MusicBrainz actually defined are copied. returns true if any field
+// of the audio was changed.
+func (t *Track) Apply(a *media.AudioMedia) bool {
+
+	changed := false
+	setString := func(dst *string, src string) {
+		if "" != src && *dst != src {
+			*dst, changed = src, true
+		}
+	}
+	setString(&a.Title, t.Title)
+	setString(&a.Artist, t.Artist)
+	setString(&a.Album, t.Album)
+	if t.Track > 0 && a.Track != t.Track {
+		a.Track, changed = t.Track, true
+	}
+	if !t.ReleaseDate.IsZero() && !t.ReleaseDate.Equal(a.ReleaseDate) {
+		a.ReleaseDate, changed = t.ReleaseDate, true
+	}
+	return changed
+}
+
+// type mbRecording is a recording in the responses of MusicBrainz.
+type mbRecording struct {
+	ID           string `json:"id"`
+	Score        int    `json:"score"`
+	Title        string `json:"title"`
+	FirstRelease string `json:"first-release-date"`
+	ArtistCredit []struct {
+		Name       string `json:"name"`
+		JoinPhrase string `json:"joinphrase"`
+	} `json:"artist-credit"`
+	Releases []struct {
+		Title string `json:"title"`
+		Date  string `json:"date"`
+		Media []struct {
+			Track []struct {
+				Number string `json:"number"`
+			} `json:"track"`
+		} `json:"media"`
+	} `json:"releases"`
+}
+
+// type MusicBrainz is the provider of audio track metadata from MusicBrainz.
+// tagged tracks are searched for by title, artist, and album; untagged tracks
+// are identified by their acoustic fingerprints via AcoustID, which requires
+// an API key (see https://acoustid.org/new-application) and the fpcalc tool of
+// Chromaprint. no key is needed for MusicBrainz itself.
+type MusicBrainz struct {
+	acoustIDKey string
+	client      *client
+	acoustID    *client
+}
+
+// function NewMusicBrainz() creates a MusicBrainz provider caching its
+// responses in the given Cache (nil if unused). untagged tracks are only
+// identified if given an AcoustID API key.
+func NewMusicBrainz(acoustIDKey string, cache *Cache) *MusicBrainz {
+	return &MusicBrainz{
+		acoustIDKey: strings.TrimSpace(acoustIDKey),
+		client:      newClient(mbName, mbInterval, cache),
+		acoustID:    newClient(acoustIDName, acoustIDRate, cache),
+	}
+}
+
+// function Name() returns the name of the provider.
+func (p *MusicBrainz) Name() string { return mbName }
+
+// function get() requests the given API path with the given parameters,
+// unmarshalling the response into v.
+func (p *MusicBrainz) get(path string, param url.Values, v interface{}) (bool, *rc.ReturnCode) {
+
+	param.Set("fmt", "json")
+	key := path + "?" + param.Encode()
+	return p.client.get(key, func() (*http.Request, *rc.ReturnCode) {
+		req, err := http.NewRequest(http.MethodGet, mbAPI+key, nil)
+		if nil != err {
+			return nil, rc.FetchError.Specf("get(%s): %s", key, err)
+		}
+		return req, nil
+	}, v)
+}
+
+// function LookupTrack() returns the metadata of the track identified by the
+// given query, or nil if it wasn't found.
+func (p *MusicBrainz) LookupTrack(q TrackQuery) (*Track, *rc.ReturnCode) {
+
+	if q.IsTagged() {
+		return p.search(q)
+	}
+	if "" == p.acoustIDKey || "" == q.Path {
+		return nil, nil
+	}
+	id, ret := p.identify(q)
+	if "" == id {
+		return nil, ret
+	}
+	var rec mbRecording
+	if ok, ret := p.get("/recording/"+url.PathEscape(id),
+		url.Values{"inc": {"artist-credits+releases+media"}}, &rec); !ok || "" == rec.ID {
+		return nil, ret
+	}
+	return mbTrack(&rec), nil
+}
+
+// function search() returns the best match of searching MusicBrainz for the
+// recording with the query's tags, or nil if there is no good match.
+func (p *MusicBrainz) search(q TrackQuery) (*Track, *rc.ReturnCode) {
+
+	terms := []string{fmt.Sprintf("recording:%s", mbQuote(q.Title))}
+	if "" != q.Artist {
+		terms = append(terms, fmt.Sprintf("artist:%s", mbQuote(q.Artist)))
+	}
+	if "" != q.Album {
+		terms = append(terms, fmt.Sprintf("release:%s", mbQuote(q.Album)))
+	}
+	var found struct {
+		Recordings []mbRecording `json:"recordings"`
+	}
+	param := url.Values{"query": {strings.Join(terms, " AND ")}, "limit": {"1"}}
+	if ok, ret := p.get("/recording", param, &found); !ok || 0 == len(found.Recordings) {
+		return nil, ret
+	}
+	if found.Recordings[0].Score < mbMinScore {
+		return nil, nil
+	}
+	return mbTrack(&found.Recordings[0]), nil
+}
+
+// function identify() returns the MusicBrainz recording ID matching the
+// acoustic fingerprint of the query's file, or "" if there is no good match.
+func (p *MusicBrainz) identify(q TrackQuery) (string, *rc.ReturnCode) {
+
+	if "" == q.Fingerprint {
+		fp, length, ret := Fingerprint(q.Path)
+		if nil != ret {
+			return "", ret
+		}
+		q.Fingerprint = fp
+		if 0 == q.Duration {
+			q.Duration = length
+		}
+	}
+	var found struct {
+		Status  string `json:"status"`
+		Results []struct {
+			Score      float64 `json:"score"`
+			Recordings []struct {
+				ID string `json:"id"`
+			} `json:"recordings"`
+		} `json:"results"`
+	}
+	param := url.Values{
+		"meta":        {"recordingids"},
+		"duration":    {strconv.Itoa(int(q.Duration.Seconds()))},
+		"fingerprint": {q.Fingerprint},
+	}
+	key := "?" + param.Encode() // excludes the API key
+	ok, ret := p.acoustID.get(key, func() (*http.Request, *rc.ReturnCode) {
+		param.Set("client", p.acoustIDKey)
+		req, err := http.NewRequest(http.MethodPost, acoustIDAPI,
+			strings.NewReader(param.Encode()))
+		if nil != err {
+			return nil, rc.FetchError.Specf("identify(%q): %s", q.Path, err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	}, &found)
+	if !ok {
+		return "", ret
+	}
+	if "ok" != found.Status {
+		return "", rc.FetchError.Specf("identify(%q): AcoustID status %q", q.Path, found.Status)
+	}
+	for _, r := range found.Results {
+		if r.Score >= acoustIDMin && len(r.Recordings) > 0 {
+			return r.Recordings[0].ID, nil
+		}
+	}
+	return "", nil
+}
+
+// function mbTrack() returns the Track described by the given recording. the
+// album is its earliest release.
+func mbTrack(rec *mbRecording) *Track {
+
+	t := &Track{ID: rec.ID, Title: rec.Title}
+	for _, a := range rec.ArtistCredit {
+		t.Artist += a.Name + a.JoinPhrase
+	}
+	t.Artist = strings.TrimSpace(t.Artist)
+
+	first := -1
+	for i, r := range rec.Releases {
+		if "" != r.Date && (first < 0 || r.Date < rec.Releases[first].Date) {
+			first = i
+		}
+	}
+	if first < 0 && len(rec.Releases) > 0 {
+		first = 0
+	}
+	if first >= 0 {
+		r := rec.Releases[first]
+		t.Album = r.Title
+		if len(r.Media) > 0 && len(r.Media[0].Track) > 0 {
+			t.Track, _ = strconv.ParseInt(r.Media[0].Track[0].Number, 10, 64)
+		}
+	}
+	t.ReleaseDate = mbDate(rec.FirstRelease)
+	return t
+}
+
+// function mbDate() parses the given MusicBrainz date, which may be just a
+// year ("1999") or year and month ("1999-04"), returning the zero time if it is
+// invalid.
+func mbDate(s string) time.Time {
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, s); nil == err {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// function mbQuote() quotes the given text as a phrase of a MusicBrainz search
+// query (Lucene syntax).
+func mbQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,110 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: provider.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the interface of online metadata providers, and the queries for
+//    movies and TV episodes derived from the names of media files.
+//
+// =============================================================================
+
+// package provider fetches the metadata of movies and TV episodes (synopsis,
+// release date, genres, and artwork) from online databases, e.g. TMDB and
+// TVDB. every response is kept in an offline cache, so that media is looked up
+// only once, and requests are rate limited to stay within the terms of use of
+// each database.
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/migrate"
+	"ardnew.com/pimmp/pkg/naming"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Query identifies a movie by its title and (optional) year of release, or
+// a TV episode by its show, season, and episode number.
+type Query struct {
+	Title   string // title of the movie, or of the episode if known
+	Year    int    // year the movie was released (0 if unknown)
+	Show    string // name of the TV show (empty for movies)
+	Season  int    // season number of the episode
+	Episode int    // episode number of the episode within its season
+}
+
+// function NewQuery() returns the Query identifying the given video, from the
+// description parsed from its file name (see package naming), or else from its
+// title. returns false if the media isn't a video, which is all the providers
+// describe.
+func NewQuery(v *media.VideoMedia) (Query, bool) {
+
+	if nil == v || nil == v.Media || media.KindVideo != v.Kind {
+		return Query{}, false
+	}
+	name := naming.Parse(v.AbsBase)
+	if v.IsEpisode() {
+		return Query{
+			Title:   name.Title,
+			Year:    int(v.Year),
+			Show:    v.Series,
+			Season:  int(v.Season),
+			Episode: int(v.Episode),
+		}, true
+	}
+	// a title set by some other means (e.g. tags or an import) is preferred
+	// over the file name.
+	title := name.Title
+	if "" != v.Title && v.Title != v.AbsName {
+		title = v.Title
+	}
+	return Query{Title: title, Year: int(v.Year)}, true
+}
+
+// function IsEpisode() returns true if the query identifies a TV episode.
+func (q Query) IsEpisode() bool { return "" != q.Show }
+
+// function String() returns a readable description of the query, e.g.
+// "Show Name S02E05" or "Movie Name (1999)".
+func (q Query) String() string {
+	switch {
+	case q.IsEpisode():
+		return fmt.Sprintf("%s S%02dE%02d", q.Show, q.Season, q.Episode)
+	case q.Year > 0:
+		return fmt.Sprintf("%s (%d)", q.Title, q.Year)
+	}
+	return q.Title
+}
+
+// type Provider is an online database describing movies and TV episodes. the
+// metadata found is returned as a migrate.Item, so that it is merged into the
+// records of media just like metadata imported from other media servers.
+type Provider interface {
+	// returns the name of the provider, e.g. "TMDB".
+	Name() string
+	// returns the metadata of the movie or episode identified by the given
+	// query, or nil if it wasn't found.
+	Lookup(q Query) (*migrate.Item, *rc.ReturnCode)
+}
+
+// function New() returns the provider with the given name (case-insensitive),
+// authenticating with the given API key and caching its responses in the given
+// Cache.
+func New(name, apiKey string, cache *Cache) (Provider, *rc.ReturnCode) {
+
+	if "" == strings.TrimSpace(apiKey) {
+		return nil, rc.InvalidArgs.Specf("New(%q): no API key given", name)
+	}
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "tmdb":
+		return NewTMDB(apiKey, cache), nil
+	case "tvdb":
+		return NewTVDB(apiKey, cache), nil
+	}
+	return nil, rc.InvalidArgs.Specf("New(%q): unknown provider (expected \"tmdb\" or \"tvdb\")", name)
+}
@@ -0,0 +1,201 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: tmdb.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the provider of metadata from The Movie Database (TMDB).
+//
+// =============================================================================
+
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"ardnew.com/pimmp/pkg/migrate"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the TMDB provider.
+const (
+	tmdbName       = "TMDB"
+	tmdbAPI        = "https://api.themoviedb.org/3"
+	tmdbImage      = "https://image.tmdb.org/t/p/original"
+	tmdbDateFormat = "2006-01-02"
+	tmdbInterval   = 250 * time.Millisecond // TMDB permits ~40 requests per 10s
+)
+
+// type tmdbGenre is a single genre of a TMDB movie or show.
+type tmdbGenre struct {
+	Name string `json:"name"`
+}
+
+// type tmdbSearch is the response of a TMDB movie or show search.
+type tmdbSearch struct {
+	Results []struct {
+		ID int `json:"id"`
+	} `json:"results"`
+}
+
+// type tmdbDetail is the response describing a TMDB movie, show, or episode.
+// movies define Title and ReleaseDate, while shows and episodes define Name and
+// FirstAirDate or AirDate.
+type tmdbDetail struct {
+	Title        string      `json:"title"`
+	Name         string      `json:"name"`
+	Overview     string      `json:"overview"`
+	ReleaseDate  string      `json:"release_date"`
+	FirstAirDate string      `json:"first_air_date"`
+	AirDate      string      `json:"air_date"`
+	Genres       []tmdbGenre `json:"genres"`
+	PosterPath   string      `json:"poster_path"`
+	BackdropPath string      `json:"backdrop_path"`
+	StillPath    string      `json:"still_path"`
+}
+
+// type TMDB is the Provider of metadata from The Movie Database, describing
+// both movies and TV episodes. an API key (v3) is required, see
+// https://www.themoviedb.org/settings/api.
+type TMDB struct {
+	apiKey string
+	client *client
+}
+
+// function NewTMDB() creates a TMDB provider authenticating with the given API
+// key and caching its responses in the given Cache (nil if unused).
+func NewTMDB(apiKey string, cache *Cache) *TMDB {
+	return &TMDB{apiKey: apiKey, client: newClient(tmdbName, tmdbInterval, cache)}
+}
+
+// function Name() returns the name of the provider.
+func (p *TMDB) Name() string { return tmdbName }
+
+// function get() requests the given API path with the given parameters,
+// unmarshalling the response into v.
+func (p *TMDB) get(path string, param url.Values, v interface{}) (bool, *rc.ReturnCode) {
+
+	key := path + "?" + param.Encode() // excludes the API key
+	return p.client.get(key, func() (*http.Request, *rc.ReturnCode) {
+		query := url.Values{"api_key": {p.apiKey}}
+		for k, v := range param {
+			query[k] = v
+		}
+		req, err := http.NewRequest(http.MethodGet, tmdbAPI+path+"?"+query.Encode(), nil)
+		if nil != err {
+			return nil, rc.FetchError.Specf("get(%s): %s", key, err)
+		}
+		return req, nil
+	}, v)
+}
+
+// function search() returns the ID of the first result of searching the given
+// kind ("movie" or "tv") for the given title, or -1 if none was found.
+func (p *TMDB) search(kind, title string, year int) (int, *rc.ReturnCode) {
+
+	param := url.Values{"query": {title}}
+	if year > 0 {
+		if "movie" == kind {
+			param.Set("year", strconv.Itoa(year))
+		} else {
+			param.Set("first_air_date_year", strconv.Itoa(year))
+		}
+	}
+	var found tmdbSearch
+	if ok, ret := p.get("/search/"+kind, param, &found); !ok || 0 == len(found.Results) {
+		return -1, ret
+	}
+	return found.Results[0].ID, nil
+}
+
+// function Lookup() returns the metadata of the movie or episode identified by
+// the given query, or nil if it wasn't found.
+func (p *TMDB) Lookup(q Query) (*migrate.Item, *rc.ReturnCode) {
+
+	if q.IsEpisode() {
+		return p.episode(q)
+	}
+	id, ret := p.search("movie", q.Title, q.Year)
+	if id < 0 {
+		return nil, ret
+	}
+	var movie tmdbDetail
+	if ok, ret := p.get(fmt.Sprintf("/movie/%d", id), url.Values{}, &movie); !ok {
+		return nil, ret
+	}
+	item := &migrate.Item{
+		Title:       movie.Title,
+		Description: movie.Overview,
+		ReleaseDate: tmdbDate(movie.ReleaseDate),
+		Genres:      tmdbGenres(movie.Genres),
+		Artwork:     map[string]string{},
+	}
+	tmdbArtwork(item, "poster", movie.PosterPath)
+	tmdbArtwork(item, "fanart", movie.BackdropPath)
+	return item, nil
+}
+
+// function episode() returns the metadata of the TV episode identified by the
+// given query, or nil if it wasn't found. the episode is described by its own
+// title, synopsis, and air date, but the genres and artwork of its show.
+func (p *TMDB) episode(q Query) (*migrate.Item, *rc.ReturnCode) {
+
+	id, ret := p.search("tv", q.Show, q.Year)
+	if id < 0 {
+		return nil, ret
+	}
+	var show, ep tmdbDetail
+	if ok, ret := p.get(fmt.Sprintf("/tv/%d", id), url.Values{}, &show); !ok {
+		return nil, ret
+	}
+	path := fmt.Sprintf("/tv/%d/season/%d/episode/%d", id, q.Season, q.Episode)
+	if ok, ret := p.get(path, url.Values{}, &ep); !ok || "" == ep.Name {
+		return nil, ret
+	}
+	item := &migrate.Item{
+		Title:       ep.Name,
+		Description: ep.Overview,
+		ReleaseDate: tmdbDate(ep.AirDate),
+		Genres:      tmdbGenres(show.Genres),
+		Artwork:     map[string]string{},
+	}
+	tmdbArtwork(item, "poster", show.PosterPath)
+	tmdbArtwork(item, "fanart", show.BackdropPath)
+	tmdbArtwork(item, "thumb", ep.StillPath)
+	return item, nil
+}
+
+// function tmdbDate() parses the given TMDB date, returning the zero time if
+// it is invalid.
+func tmdbDate(s string) time.Time {
+	t, err := time.Parse(tmdbDateFormat, s)
+	if nil != err {
+		return time.Time{}
+	}
+	return t
+}
+
+// function tmdbGenres() returns the names of the given TMDB genres.
+func tmdbGenres(list []tmdbGenre) []string {
+	genres := []string{}
+	for _, g := range list {
+		if "" != g.Name {
+			genres = append(genres, g.Name)
+		}
+	}
+	return genres
+}
+
+// function tmdbArtwork() adds the URL of the TMDB image with the given path to
+// the artwork of the given kind, unless the path is empty.
+func tmdbArtwork(item *migrate.Item, kind, path string) {
+	if "" != path {
+		item.Artwork[kind] = tmdbImage + path
+	}
+}
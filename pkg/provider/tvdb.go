@@ -0,0 +1,206 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: tvdb.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the provider of metadata from TheTVDB (TVDB).
+//
+// =============================================================================
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"ardnew.com/pimmp/pkg/migrate"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the TVDB provider.
+const (
+	tvdbName       = "TVDB"
+	tvdbAPI        = "https://api4.thetvdb.com/v4"
+	tvdbDateFormat = "2006-01-02"
+	tvdbInterval   = 250 * time.Millisecond
+)
+
+// type tvdbSearch is the response of a TVDB search.
+type tvdbSearch struct {
+	Data []struct {
+		ID       string   `json:"tvdb_id"`
+		Name     string   `json:"name"`
+		Overview string   `json:"overview"`
+		Year     string   `json:"year"`
+		Genres   []string `json:"genres"`
+		ImageURL string   `json:"image_url"`
+	} `json:"data"`
+}
+
+// type tvdbEpisodes is the response listing the episodes of a TVDB series.
+type tvdbEpisodes struct {
+	Data struct {
+		Series struct {
+			Image string `json:"image"`
+		} `json:"series"`
+		Episodes []struct {
+			Name     string `json:"name"`
+			Overview string `json:"overview"`
+			Aired    string `json:"aired"`
+			Image    string `json:"image"`
+		} `json:"episodes"`
+	} `json:"data"`
+}
+
+// type TVDB is the Provider of metadata from TheTVDB, describing both movies
+// and TV episodes, though best suited to the latter. an API key (v4) is
+// required, see https://thetvdb.com/api-information.
+type TVDB struct {
+	apiKey string
+	client *client
+
+	mu    sync.Mutex // guards token
+	token string     // bearer token returned by login (valid for a month)
+}
+
+// function NewTVDB() creates a TVDB provider authenticating with the given API
+// key and caching its responses in the given Cache (nil if unused).
+func NewTVDB(apiKey string, cache *Cache) *TVDB {
+	return &TVDB{apiKey: apiKey, client: newClient(tvdbName, tvdbInterval, cache)}
+}
+
+// function Name() returns the name of the provider.
+func (p *TVDB) Name() string { return tvdbName }
+
+// function login() returns the bearer token authenticating requests, logging
+// in with the API key the first time it is needed.
+func (p *TVDB) login() (string, *rc.ReturnCode) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if "" != p.token {
+		return p.token, nil
+	}
+
+	body, _ := json.Marshal(map[string]string{"apikey": p.apiKey})
+	p.client.limit.wait()
+	rsp, err := p.client.http.Post(tvdbAPI+"/login", "application/json", bytes.NewReader(body))
+	if nil != err {
+		return "", rc.FetchError.Specf("login(): %s", err)
+	}
+	defer rsp.Body.Close()
+	if http.StatusOK != rsp.StatusCode {
+		return "", rc.FetchError.Specf("login(): %s (check the API key)", rsp.Status)
+	}
+	var auth struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rsp.Body).Decode(&auth); nil != err || "" == auth.Data.Token {
+		return "", rc.FetchError.Specf("login(): invalid response: %v", err)
+	}
+	p.token = auth.Data.Token
+	return p.token, nil
+}
+
+// function get() requests the given API path with the given parameters,
+// unmarshalling the response into v.
+func (p *TVDB) get(path string, param url.Values, v interface{}) (bool, *rc.ReturnCode) {
+
+	key := path + "?" + param.Encode()
+	return p.client.get(key, func() (*http.Request, *rc.ReturnCode) {
+		token, ret := p.login()
+		if nil != ret {
+			return nil, ret
+		}
+		req, err := http.NewRequest(http.MethodGet, tvdbAPI+key, nil)
+		if nil != err {
+			return nil, rc.FetchError.Specf("get(%s): %s", key, err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req, nil
+	}, v)
+}
+
+// function Lookup() returns the metadata of the movie or episode identified by
+// the given query, or nil if it wasn't found.
+func (p *TVDB) Lookup(q Query) (*migrate.Item, *rc.ReturnCode) {
+
+	if q.IsEpisode() {
+		return p.episode(q)
+	}
+	param := url.Values{"query": {q.Title}, "type": {"movie"}}
+	if q.Year > 0 {
+		param.Set("year", strconv.Itoa(q.Year))
+	}
+	var found tvdbSearch
+	if ok, ret := p.get("/search", param, &found); !ok || 0 == len(found.Data) {
+		return nil, ret
+	}
+	movie := found.Data[0]
+	item := &migrate.Item{
+		Title:       movie.Name,
+		Description: movie.Overview,
+		Genres:      append([]string{}, movie.Genres...),
+		Artwork:     map[string]string{},
+	}
+	// movie search results only give the year of release.
+	if year, err := strconv.Atoi(movie.Year); nil == err && year > 0 {
+		item.ReleaseDate = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if "" != movie.ImageURL {
+		item.Artwork["poster"] = movie.ImageURL
+	}
+	return item, nil
+}
+
+// function episode() returns the metadata of the TV episode identified by the
+// given query, or nil if it wasn't found.
+func (p *TVDB) episode(q Query) (*migrate.Item, *rc.ReturnCode) {
+
+	var found tvdbSearch
+	param := url.Values{"query": {q.Show}, "type": {"series"}}
+	if ok, ret := p.get("/search", param, &found); !ok || 0 == len(found.Data) {
+		return nil, ret
+	}
+	show := found.Data[0]
+
+	var list tvdbEpisodes
+	path := fmt.Sprintf("/series/%s/episodes/default", url.PathEscape(show.ID))
+	param = url.Values{
+		"season":        {strconv.Itoa(q.Season)},
+		"episodeNumber": {strconv.Itoa(q.Episode)},
+	}
+	if ok, ret := p.get(path, param, &list); !ok || 0 == len(list.Data.Episodes) {
+		return nil, ret
+	}
+	ep := list.Data.Episodes[0]
+	item := &migrate.Item{
+		Title:       ep.Name,
+		Description: ep.Overview,
+		Genres:      append([]string{}, show.Genres...),
+		Artwork:     map[string]string{},
+	}
+	if t, err := time.Parse(tvdbDateFormat, ep.Aired); nil == err {
+		item.ReleaseDate = t
+	}
+	if "" != list.Data.Series.Image {
+		item.Artwork["poster"] = list.Data.Series.Image
+	} else if "" != show.ImageURL {
+		item.Artwork["poster"] = show.ImageURL
+	}
+	if "" != ep.Image {
+		item.Artwork["thumb"] = ep.Image
+	}
+	return item, nil
+}
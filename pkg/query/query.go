@@ -0,0 +1,591 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: query.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    parses and evaluates the rules selecting media, e.g. those of a smart
+//    playlist: "kind=audio AND tag=jazz AND rating>=7".
+//
+// =============================================================================
+
+// package query parses rules selecting media by their fields. a rule is made
+// of conditions of the form <field><operator><value>, combined with AND, OR,
+// NOT, and parentheses (AND binds tighter than OR). values containing spaces
+// or operators are quoted, e.g. title~"of the". the fields and the operators
+// each accepts are:
+//
+//	kind                      =, !=         audio, video, image, or document
+//	tag, genre                =, !=         has (or hasn't) the tag/genre
+//	name, title, path, ext    =, !=, ~, !~  equals or contains the text
+//	rating, playcount, size   =, !=, <, <=, >, >=
+//	added, played, released   =, !=, <, <=, >, >=  dates as YYYY-MM-DD
+//	watched                   =, !=         true or false
+//
+// text is compared ignoring case, and sizes may have a K, M, or G suffix. a
+// date may be just a year (YYYY) or month (YYYY-MM), comparing the whole
+// period, e.g. released>2015 is anything released after 2015. the operator ":"
+// is the same as "=", e.g. tag:kids, and keywords and fields ignore case, with
+// the names of the fields of Media also accepted for dates, e.g.
+//
+//	kind=video and releaseDate>2015 and not tag:kids
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// the formats of the dates compared by a rule, by the period each denotes.
+var dateLayout = []struct {
+	layout        string
+	years, months int
+	days          int
+}{
+	{"2006-01-02", 0, 0, 1},
+	{"2006-01", 0, 1, 0},
+	{"2006", 1, 0, 0},
+}
+
+// variable fieldAlias maps the other names accepted for fields to theirs.
+var fieldAlias = map[string]string{
+	"releasedate": "released",
+	"timeadded":   "added",
+	"lastplayed":  "played",
+	"tags":        "tag",
+	"genres":      "genre",
+}
+
+// variable indexedField maps the fields compared by the indexes of a library's
+// database (see Index()) to the names of the indexed fields of Media.
+var indexedField = map[string]string{
+	"tag":   "Tags",
+	"title": "Title",
+}
+
+// type Query is a parsed rule, which selects the media matching it.
+type Query struct {
+	rule string // rule as given to Parse()
+	root node   // top of the parsed expression tree
+}
+
+// type node is an element of the parsed expression tree of a Query.
+type node interface {
+	match(m *media.Media) bool
+}
+
+// the nodes combining other nodes.
+type (
+	andNode []node // matches if every node matches
+	orNode  []node // matches if any node matches
+	notNode struct{ node }
+)
+
+func (n andNode) match(m *media.Media) bool {
+	for _, c := range n {
+		if !c.match(m) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n orNode) match(m *media.Media) bool {
+	for _, c := range n {
+		if c.match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n notNode) match(m *media.Media) bool {
+	return !n.node.match(m)
+}
+
+// type condition is a single comparison of a media field with a value.
+type condition struct {
+	field string
+	op    string
+	value string
+	test  func(m *media.Media) bool
+}
+
+func (c *condition) match(m *media.Media) bool {
+	return c.test(m)
+}
+
+// function Parse() parses the given rule, returning an error describing the
+// first problem found if it is invalid.
+func Parse(rule string) (*Query, *rc.ReturnCode) {
+
+	tok, err := tokenize(rule)
+	if nil == err {
+		p := &parser{tok: tok}
+		var root node
+		if root, err = p.parseOr(); nil == err {
+			if p.pos < len(p.tok) {
+				err = fmt.Errorf("unexpected %q", p.tok[p.pos].text)
+			} else {
+				return &Query{rule: strings.TrimSpace(rule), root: root}, nil
+			}
+		}
+	}
+	return nil, rc.InvalidArgs.Specf("invalid rule: %q: %s", rule, err)
+}
+
+// function Match() returns true if the given media is selected by the Query.
+func (q *Query) Match(m *media.Media) bool {
+	return nil != m && q.root.match(m)
+}
+
+// function String() returns the rule of the Query, as given to Parse().
+func (q *Query) String() string {
+	return q.rule
+}
+
+// function Index() returns the name of an indexed field of Media (see
+// media.EntityIndexes), e.g. "Tags", and the values of it of which every media
+// matching the Query has one, so that the candidates are found using a
+// database's index rather than by reading every record; Match() must still be
+// applied to them. returns false if there are none, e.g. if the Query is a
+// disjunction. the values are those that differ only by case, as commonly
+// written, since the index, unlike the Query, compares text exactly.
+func (q *Query) Index() (string, []string, bool) {
+	list := andNode{q.root}
+	if and, ok := q.root.(andNode); ok {
+		list = and
+	}
+	for _, n := range list {
+		c, ok := n.(*condition)
+		if !ok || "=" != c.op {
+			continue
+		}
+		if field, ok := indexedField[c.field]; ok {
+			value := []string{}
+			seen := map[string]bool{}
+			low := strings.ToLower(c.value)
+			for _, v := range []string{c.value, low, strings.ToUpper(low), strings.Title(low)} {
+				if !seen[v] {
+					seen[v] = true
+					value = append(value, v)
+				}
+			}
+			return field, value, true
+		}
+	}
+	return "", nil, false
+}
+
+// type token is a single word, quoted text, operator, or parenthesis of a rule.
+type token struct {
+	text   string
+	quoted bool // text was quoted, so it is never a keyword or operator
+}
+
+// the operators recognized by the tokenizer, longest first.
+var operators = []string{"!=", "!~", "<=", ">=", "=", "~", "<", ">", ":"}
+
+// function tokenize() splits the given rule into its tokens.
+func tokenize(rule string) ([]token, error) {
+
+	tok := []token{}
+	r := []rune(rule)
+	for i := 0; i < len(r); {
+		switch c := r[i]; {
+		case unicode.IsSpace(c):
+			i++
+		case '(' == c || ')' == c:
+			tok = append(tok, token{text: string(c)})
+			i++
+		case '"' == c:
+			j := i + 1
+			for j < len(r) && '"' != r[j] {
+				j++
+			}
+			if j == len(r) {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			tok = append(tok, token{text: string(r[i+1 : j]), quoted: true})
+			i = j + 1
+		default:
+			if op := operatorAt(r[i:]); "" != op {
+				tok = append(tok, token{text: op})
+				i += len(op)
+				continue
+			}
+			j := i
+			for j < len(r) && !unicode.IsSpace(r[j]) && !strings.ContainsRune("()\"", r[j]) &&
+				"" == operatorAt(r[j:]) {
+				j++
+			}
+			tok = append(tok, token{text: string(r[i:j])})
+			i = j
+		}
+	}
+	return tok, nil
+}
+
+// function operatorAt() returns the operator at the start of the given runes,
+// or an empty string if there is none.
+func operatorAt(r []rune) string {
+	for _, op := range operators {
+		if strings.HasPrefix(string(r[:minInt(len(r), 2)]), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// function minInt() returns the lesser of the given integers.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// function isOperator() returns true if the given token is an operator.
+func isOperator(t token) bool {
+	for _, op := range operators {
+		if !t.quoted && op == t.text {
+			return true
+		}
+	}
+	return false
+}
+
+// function isKeyword() returns true if the given token is the given keyword.
+func isKeyword(t token, keyword string) bool {
+	return !t.quoted && strings.EqualFold(keyword, t.text)
+}
+
+// type parser is the state of a recursive descent parse of a rule's tokens.
+type parser struct {
+	tok []token
+	pos int
+}
+
+// function peek() returns the next token, and false if there are none left.
+func (p *parser) peek() (token, bool) {
+	if p.pos < len(p.tok) {
+		return p.tok[p.pos], true
+	}
+	return token{}, false
+}
+
+// function parseOr() parses: and { OR and }
+func (p *parser) parseOr() (node, error) {
+	list := orNode{}
+	for {
+		n, err := p.parseAnd()
+		if nil != err {
+			return nil, err
+		}
+		list = append(list, n)
+		if t, ok := p.peek(); !ok || !isKeyword(t, "or") {
+			break
+		}
+		p.pos++
+	}
+	if 1 == len(list) {
+		return list[0], nil
+	}
+	return list, nil
+}
+
+// function parseAnd() parses: unary { AND unary }
+func (p *parser) parseAnd() (node, error) {
+	list := andNode{}
+	for {
+		n, err := p.parseUnary()
+		if nil != err {
+			return nil, err
+		}
+		list = append(list, n)
+		if t, ok := p.peek(); !ok || !isKeyword(t, "and") {
+			break
+		}
+		p.pos++
+	}
+	if 1 == len(list) {
+		return list[0], nil
+	}
+	return list, nil
+}
+
+// function parseUnary() parses: NOT unary | ( or ) | condition
+func (p *parser) parseUnary() (node, error) {
+	t, ok := p.peek()
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("unexpected end of rule")
+	case isKeyword(t, "not"):
+		p.pos++
+		n, err := p.parseUnary()
+		if nil != err {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case !t.quoted && "(" == t.text:
+		p.pos++
+		n, err := p.parseOr()
+		if nil != err {
+			return nil, err
+		}
+		if t, ok := p.peek(); !ok || t.quoted || ")" != t.text {
+			return nil, fmt.Errorf("missing \")\"")
+		}
+		p.pos++
+		return n, nil
+	}
+	return p.parseCondition()
+}
+
+// function parseCondition() parses: field operator value
+func (p *parser) parseCondition() (node, error) {
+	if p.pos+3 > len(p.tok) {
+		return nil, fmt.Errorf("incomplete condition")
+	}
+	field, op, value := p.tok[p.pos], p.tok[p.pos+1], p.tok[p.pos+2]
+	if field.quoted || isOperator(field) {
+		return nil, fmt.Errorf("expected field name, found %q", field.text)
+	}
+	if !isOperator(op) {
+		return nil, fmt.Errorf("expected operator after %q, found %q", field.text, op.text)
+	}
+	if !value.quoted && (isOperator(value) || "(" == value.text || ")" == value.text) {
+		return nil, fmt.Errorf("expected value after %s%s, found %q", field.text, op.text, value.text)
+	}
+	p.pos += 3
+	name, o := strings.ToLower(field.text), op.text
+	if alias, ok := fieldAlias[name]; ok {
+		name = alias
+	}
+	if ":" == o {
+		o = "="
+	}
+	return newCondition(name, o, value.text)
+}
+
+// function newCondition() creates the condition comparing the given field of
+// media with the given value using the given operator.
+func newCondition(field, op, value string) (node, error) {
+
+	c := &condition{field: field, op: op, value: value}
+	invalidOp := fmt.Errorf("operator %q not valid for field %q", op, field)
+
+	switch field {
+	case "kind":
+		var kind media.MediaKind
+		switch strings.ToLower(value) {
+		case "audio":
+			kind = media.KindAudio
+		case "video":
+			kind = media.KindVideo
+		case "image":
+			kind = media.KindImage
+		case "document":
+			kind = media.KindDocument
+		default:
+			return nil, fmt.Errorf("invalid kind: %q (must be audio, video, image, or document)", value)
+		}
+		eq, err := equality(op, invalidOp)
+		if nil != err {
+			return nil, err
+		}
+		c.test = func(m *media.Media) bool { return (kind == m.Kind) == eq }
+
+	case "tag", "genre":
+		eq, err := equality(op, invalidOp)
+		if nil != err {
+			return nil, err
+		}
+		c.test = func(m *media.Media) bool {
+			list := m.Tags
+			if "genre" == field {
+				list = m.Genres
+			}
+			for _, s := range list {
+				if strings.EqualFold(value, s) {
+					return eq
+				}
+			}
+			return !eq
+		}
+
+	case "name", "title", "path", "ext":
+		text := map[string]func(*media.Media) string{
+			"name":  func(m *media.Media) string { return m.Name },
+			"title": func(m *media.Media) string { return m.Title },
+			"path":  func(m *media.Media) string { return m.AbsPath },
+			"ext": func(m *media.Media) string {
+				return strings.TrimPrefix(m.Ext, ".")
+			},
+		}[field]
+		if "ext" == field {
+			value = strings.TrimPrefix(value, ".")
+		}
+		low := strings.ToLower(value)
+		switch op {
+		case "=":
+			c.test = func(m *media.Media) bool { return strings.EqualFold(value, text(m)) }
+		case "!=":
+			c.test = func(m *media.Media) bool { return !strings.EqualFold(value, text(m)) }
+		case "~":
+			c.test = func(m *media.Media) bool { return strings.Contains(strings.ToLower(text(m)), low) }
+		case "!~":
+			c.test = func(m *media.Media) bool { return !strings.Contains(strings.ToLower(text(m)), low) }
+		default:
+			return nil, invalidOp
+		}
+
+	case "rating", "playcount", "size":
+		n, err := parseNumber(field, value)
+		if nil != err {
+			return nil, err
+		}
+		number := map[string]func(*media.Media) int64{
+			"rating":    func(m *media.Media) int64 { return m.Rating },
+			"playcount": func(m *media.Media) int64 { return m.PlayCount },
+			"size":      func(m *media.Media) int64 { return m.Size },
+		}[field]
+		cmp, err := ordering(op, invalidOp)
+		if nil != err {
+			return nil, err
+		}
+		c.test = func(m *media.Media) bool {
+			v := number(m)
+			switch {
+			case v < n:
+				return cmp(-1)
+			case v > n:
+				return cmp(+1)
+			}
+			return cmp(0)
+		}
+
+	case "added", "played", "released":
+		day, next, err := parseDate(value)
+		if nil != err {
+			return nil, err
+		}
+		date := map[string]func(*media.Media) time.Time{
+			"added":    func(m *media.Media) time.Time { return m.TimeAdded },
+			"played":   func(m *media.Media) time.Time { return m.LastPlayed },
+			"released": func(m *media.Media) time.Time { return m.ReleaseDate },
+		}[field]
+		cmp, err := ordering(op, invalidOp)
+		if nil != err {
+			return nil, err
+		}
+		// dates are compared by the period they denote, so that e.g.
+		// added=2024-06-01 matches anything added during that day.
+		c.test = func(m *media.Media) bool {
+			t := date(m)
+			switch {
+			case t.IsZero():
+				return false // never played, unknown release date, etc.
+			case t.Before(day):
+				return cmp(-1)
+			case !t.Before(next):
+				return cmp(+1)
+			}
+			return cmp(0)
+		}
+
+	case "watched":
+		want, err := strconv.ParseBool(value)
+		if nil != err {
+			return nil, fmt.Errorf("invalid boolean: %q (must be true or false)", value)
+		}
+		eq, err := equality(op, invalidOp)
+		if nil != err {
+			return nil, err
+		}
+		c.test = func(m *media.Media) bool { return (want == m.Watched) == eq }
+
+	default:
+		return nil, fmt.Errorf("unknown field: %q", field)
+	}
+	return c, nil
+}
+
+// function equality() returns true if the given operator is "=", or false if
+// it is "!=". any other operator returns the given error.
+func equality(op string, invalid error) (bool, error) {
+	switch op {
+	case "=":
+		return true, nil
+	case "!=":
+		return false, nil
+	}
+	return false, invalid
+}
+
+// function ordering() returns a function which, given the sign of the
+// comparison of a field with a value, returns true if the given operator is
+// satisfied. any operator that doesn't order values returns the given error.
+func ordering(op string, invalid error) (func(int) bool, error) {
+	switch op {
+	case "=":
+		return func(s int) bool { return 0 == s }, nil
+	case "!=":
+		return func(s int) bool { return 0 != s }, nil
+	case "<":
+		return func(s int) bool { return s < 0 }, nil
+	case "<=":
+		return func(s int) bool { return s <= 0 }, nil
+	case ">":
+		return func(s int) bool { return s > 0 }, nil
+	case ">=":
+		return func(s int) bool { return s >= 0 }, nil
+	}
+	return nil, invalid
+}
+
+// function parseDate() parses the given date, returning the start of the
+// period it denotes (a day, month, or year) and the start of the next.
+func parseDate(value string) (time.Time, time.Time, error) {
+	for _, d := range dateLayout {
+		if len(d.layout) != len(value) {
+			continue
+		}
+		if t, err := time.ParseInLocation(d.layout, value, time.Local); nil == err {
+			return t, t.AddDate(d.years, d.months, d.days), nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date: %q (must be YYYY-MM-DD, YYYY-MM, or YYYY)", value)
+}
+
+// function parseNumber() parses the given value of a numeric field. sizes may
+// have a K, M, or G suffix (powers of 1024).
+func parseNumber(field, value string) (int64, error) {
+	mult := int64(1)
+	if "size" == field && len(value) > 1 {
+		switch unicode.ToUpper(rune(value[len(value)-1])) {
+		case 'K':
+			mult = 1 << 10
+		case 'M':
+			mult = 1 << 20
+		case 'G':
+			mult = 1 << 30
+		}
+		if mult > 1 {
+			value = value[:len(value)-1]
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if nil != err {
+		return 0, fmt.Errorf("invalid number for field %q: %q", field, value)
+	}
+	return n * mult, nil
+}
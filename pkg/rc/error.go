@@ -0,0 +1,181 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 02 Oct 2018
+//  FILE: error.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines types and functions for describing return values and error codes
+//    to the user
+//
+// =============================================================================
+
+// package rc defines the return codes shared by all of pimmp's packages. every
+// subroutine that can fail reports the reason using one of these, and the
+// program's exit status is derived from them. a ReturnCode is an error, which
+// matches (see errors.Is()) any ReturnCode with the same code, and may wrap the
+// error that caused it (see Wrapf()).
+package rc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// type Kind identifies the different kinds of return codes.
+type Kind int
+
+// constants that categorize return codes into high-level status groups which
+// affect the prefix/highlighting of messages in the user's output log.
+const (
+	KindInfo Kind = iota
+	KindWarn
+	KindError
+)
+
+// type ReturnCode contains information describing the reason for program exit,
+// including potential runtime errors with detailed diagnostic info.
+type ReturnCode struct {
+	kind Kind   // type of return code; affects how the message is displayed
+	code int    // value between 0 and 255 (inclusive) for portability
+	desc string // built-in description of this general purpose return code
+	info string // additional detail elaborating the return event
+
+	cause error // error that caused the return event, if any (see Wrapf())
+}
+
+// private constants
+const (
+	errorOffset   = 100
+	maxReturnCode = 255
+)
+
+var (
+	// non-error return codes
+	OK    = New(KindInfo, 0, "ok", "")    // no errors, normal return
+	Usage = New(KindInfo, 1, "usage", "") // no errors, displays usage help
+
+	// error return codes
+	InvalidArgs      = New(KindError, errorOffset+0, "invalid arguments", "")          // invalid command line args
+	InvalidLibrary   = New(KindWarn, errorOffset+1, "invalid library", "")             // invalid library
+	LibraryBusy      = New(KindWarn, errorOffset+2, "library busy", "")                // library busy with other tasks
+	InvalidPath      = New(KindWarn, errorOffset+3, "invalid path", "")                // invalid path
+	InvalidStat      = New(KindWarn, errorOffset+4, "error reading file stat", "")     // file stat error
+	DirDepth         = New(KindWarn, errorOffset+5, "search depth limit exceeded", "") // directory traversal depth limit exceeded
+	DirOpen          = New(KindWarn, errorOffset+6, "cannot open directory", "")       // cannot open directory for reading
+	InvalidFile      = New(KindWarn, errorOffset+7, "invalid file", "")                // some invalid type of file (symlink, FIFO, etc.)
+	InvalidConfig    = New(KindError, errorOffset+8, "invalid configuration", "")      // invalid configuration settings
+	InvalidDatabase  = New(KindWarn, errorOffset+9, "invalid database", "")            // failed to create a media database
+	DatabaseError    = New(KindWarn, errorOffset+10, "database operation failed", "")  // failed to perform an operation on the database
+	DuplicateLibrary = New(KindWarn, errorOffset+11, "duplicate library", "")          // duplicate; library path already being handled
+	InvalidJSONData  = New(KindWarn, errorOffset+12, "invalid JSON data", "")          // cannot handle some JSON-related data object
+	QueryError       = New(KindWarn, errorOffset+13, "failed to query database", "")   // couldn't perform query on database collection
+	TUIError         = New(KindError, errorOffset+14, "error drawing screen", "")      // some sort of error when drawing screen buffer
+	CorruptRecord    = New(KindWarn, errorOffset+15, "corrupt database record", "")    // stored record is malformed or inconsistent
+	PlaybackError    = New(KindWarn, errorOffset+16, "playback failed", "")            // external player could not be started or failed
+	PluginError      = New(KindWarn, errorOffset+17, "plugin failed", "")              // external plugin could not be started or misbehaved
+	ExportError      = New(KindWarn, errorOffset+18, "export failed", "")              // could not write exported data
+	ImportError      = New(KindWarn, errorOffset+19, "import failed", "")              // could not read imported data
+	TrashError       = New(KindWarn, errorOffset+20, "trash operation failed", "")     // could not move a file to or from the trash
+	VerifyError      = New(KindWarn, errorOffset+21, "verification failed", "")        // file content is damaged or cannot be decoded
+	Canceled         = New(KindWarn, errorOffset+22, "operation canceled", "")         // interrupted before it could finish
+	WatchError       = New(KindWarn, errorOffset+23, "cannot watch file system", "")   // could not watch a library for changes
+	MetadataError    = New(KindWarn, errorOffset+24, "cannot read metadata", "")       // embedded tags or stream headers unreadable
+	ProbeError       = New(KindWarn, errorOffset+25, "cannot probe media", "")         // ffprobe failed or reported no streams
+	FetchError       = New(KindWarn, errorOffset+26, "cannot fetch metadata", "")      // online metadata provider unreachable or failed
+	ServerError      = New(KindWarn, errorOffset+27, "web server failed", "")          // could not serve the web interface
+	BusError         = New(KindWarn, errorOffset+28, "D-Bus request failed", "")       // could not export the MPRIS interface on the session bus
+	SyncError        = New(KindWarn, errorOffset+29, "sync failed", "")                // could not synchronize with an online account (Trakt)
+	Locked           = New(KindWarn, errorOffset+30, "in use by another process", "")  // lock file held by another running process
+	ThumbnailError   = New(KindWarn, errorOffset+31, "cannot generate thumbnail", "")  // ffmpeg failed or wrote no picture
+	Unknown          = New(KindError, maxReturnCode, "unknown error", "")              // unanticipated error encountered
+)
+
+// function New() constructs a new ReturnCode object with a specified return
+// code, description, and info.
+func New(kind Kind, code int, desc string, info string) *ReturnCode {
+	return &ReturnCode{kind: kind, code: code, desc: desc, info: info}
+}
+
+// function Spec() returns a copy of an existing ReturnCode object with the
+// specified info string. the return codes defined by this package are never
+// changed themselves, so that they may be shared freely; the copy has the same
+// kind, return code, and description, and so matches its original with
+// errors.Is().
+func (c *ReturnCode) Spec(info string) *ReturnCode {
+	return &ReturnCode{kind: c.kind, code: c.code, desc: c.desc, info: info}
+}
+
+// function Specf() is a wrapper for function Spec() that constructs the
+// string using the specified printf-style format strings + arguments.
+func (c *ReturnCode) Specf(format string, v ...interface{}) *ReturnCode {
+	s := fmt.Sprintf(format, v...)
+	return c.Spec(s)
+}
+
+// function Wrapf() is a wrapper for function Specf() that also records the
+// given error as the cause of the ReturnCode, which is returned by Unwrap().
+func (c *ReturnCode) Wrapf(err error, format string, v ...interface{}) *ReturnCode {
+	r := c.Specf(format, v...)
+	r.cause = err
+	return r
+}
+
+// function Unwrap() returns the error that caused the ReturnCode, or nil if
+// none was recorded (see Wrapf()).
+func (c *ReturnCode) Unwrap() error {
+	if nil == c {
+		return nil
+	}
+	return c.cause
+}
+
+// function Is() returns true if the given error is a ReturnCode with the same
+// numeric return value, so that errors.Is() matches a ReturnCode regardless of
+// its info.
+func (c *ReturnCode) Is(target error) bool {
+	t, ok := target.(*ReturnCode)
+	return ok && nil != t && nil != c && t.code == c.code
+}
+
+// function KSpecf() is a wrapper for function Specf() that changes the kind of
+// the ReturnCode returned from the default.
+func (c *ReturnCode) KSpecf(kind Kind, format string, v ...interface{}) *ReturnCode {
+	r := c.Specf(format, v...)
+	r.kind = kind
+	return r
+}
+
+// function Code() returns the numeric return value of a ReturnCode, suitable
+// for use as the program's exit status.
+func (c *ReturnCode) Code() int {
+	return c.code
+}
+
+// function Kind() returns the status group of a ReturnCode.
+func (c *ReturnCode) Kind() Kind {
+	return c.kind
+}
+
+// function Error() constructs an error message using the current fields of a
+// ReturnCode object. the Code and Desc fields are required, but Info is not. if
+// info is an empty string or contains only whitespace, then it will not be
+// included in the returned string.
+func (c *ReturnCode) Error() string {
+	var pre string
+	switch c.kind {
+	case KindInfo:
+		pre = ""
+	case KindWarn:
+		pre = fmt.Sprintf("[W%d] ", c.code)
+	case KindError:
+		pre = fmt.Sprintf("[E%d] ", c.code)
+	}
+	s := fmt.Sprintf("%s%s", pre, c.desc)
+	i := strings.TrimSpace(c.info)
+	if len(i) > 0 {
+		s = fmt.Sprintf("%s: %s", s, i)
+	}
+	return s
+}
@@ -0,0 +1,261 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: registry.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the registry of libraries opened by default, each with its own
+//    name and settings.
+//
+// =============================================================================
+
+// package registry defines the registry of libraries: the root directory of
+// each library the user has added, under a unique name, along with the
+// settings its scans use in place of the global options. the libraries
+// registered are opened whenever no library is given on the command line, so
+// they are saved in a single file alongside the configuration.
+package registry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/library"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/platform"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant DefaultFileName is the name of the file, in the configuration
+// directory, in which the registry is saved.
+const DefaultFileName = "libraries.json"
+
+// type Library is a single registered library.
+type Library struct {
+	Name     string   // unique name of the library
+	Path     string   // absolute path of the library's root directory
+	MaxDepth uint     `json:",omitempty"` // directories below the root scanned (0 = -depth)
+	Exclude  []string `json:",omitempty"` // patterns of files never scanned, in addition to -exclude
+	Kinds    []string `json:",omitempty"` // kinds of media added by scans (empty = all)
+	Type     string   `json:",omitempty"` // type of library recorded in its database (empty = unchanged)
+	Probe    *bool    `json:",omitempty"` // probe video files with ffprobe (nil = -probe)
+}
+
+// var settingName lists the settings of a library accepted by Parse().
+var settingName = map[string]bool{
+	"depth":   true,
+	"exclude": true,
+	"kinds":   true,
+	"probe":   true,
+	"type":    true,
+}
+
+// function New() creates a new Library with the given name, rooted at the
+// given path, whose scans add only the given kinds of media (all if empty) of
+// those of the given type of library (see library.Type). the name defaults to
+// the base name of the path.
+func New(name, path string, maxDepth uint, exclude, kinds []string, libType string, probe *bool) (*Library, *rc.ReturnCode) {
+
+	abs, err := filepath.Abs(path)
+	if nil != err {
+		return nil, rc.InvalidPath.Specf("registry.New(%q): %s", path, err)
+	}
+	if info, err := os.Stat(abs); nil != err || !info.IsDir() {
+		return nil, rc.InvalidLibrary.Specf("registry.New(%q): not a directory", abs)
+	}
+	if name = strings.TrimSpace(name); "" == name {
+		name = filepath.Base(abs)
+	}
+	kind := []string{}
+	for _, k := range kinds {
+		if k = strings.TrimSpace(k); "" == k {
+			continue
+		}
+		if _, ok := media.ParseMediaKind(k); !ok {
+			return nil, rc.InvalidArgs.Specf("registry.New(%q): invalid kind of media: %q", name, k)
+		}
+		kind = append(kind, strings.ToLower(k))
+	}
+	if libType = strings.TrimSpace(libType); "" != libType {
+		if _, ok := library.ParseType(libType); !ok {
+			return nil, rc.InvalidArgs.Specf("registry.New(%q): invalid library type: %q (expected one of: %s)",
+				name, libType, strings.Join(library.TypeNames(), ", "))
+		}
+		libType = strings.ToLower(libType)
+	}
+	return &Library{Name: name, Path: abs, MaxDepth: maxDepth, Exclude: exclude, Kinds: kind,
+		Type: libType, Probe: probe}, nil
+}
+
+// function Parse() parses the given specification of a library, i.e. its path,
+// optionally preceded by its name and a colon, and followed by its settings in
+// the form of a URL query, e.g. "Music:~/Music?kinds=audio&depth=3". the
+// settings are depth, exclude and kinds (comma-separated), type, and probe (true
+// or false), each like the field of the same name.
+func Parse(spec string) (*Library, *rc.ReturnCode) {
+
+	name, path, query := "", strings.TrimSpace(spec), ""
+	if i := strings.LastIndex(path, "?"); i >= 0 {
+		path, query = path[:i], path[i+1:]
+	}
+	// a single letter followed by a colon is a drive on Windows, not a name,
+	// and a name is never a path itself.
+	if i := strings.Index(path, ":"); i > 1 && !strings.ContainsAny(path[:i], `/\`) {
+		name, path = path[:i], path[i+1:]
+	}
+	if "~" == path || strings.HasPrefix(path, "~/") {
+		path = filepath.Join(platform.HomeDir(), path[1:])
+	}
+
+	value, err := url.ParseQuery(query)
+	if nil != err {
+		return nil, rc.InvalidArgs.Specf("registry.Parse(%q): %s", spec, err)
+	}
+	for k := range value {
+		if !settingName[k] {
+			return nil, rc.InvalidArgs.Specf("registry.Parse(%q): unknown setting: %q", spec, k)
+		}
+	}
+	var depth uint64
+	if d := value.Get("depth"); "" != d {
+		if depth, err = strconv.ParseUint(d, 10, 0); nil != err {
+			return nil, rc.InvalidArgs.Specf("registry.Parse(%q): depth: %s", spec, err)
+		}
+	}
+	var probe *bool
+	if p := value.Get("probe"); "" != p {
+		b, err := strconv.ParseBool(p)
+		if nil != err {
+			return nil, rc.InvalidArgs.Specf("registry.Parse(%q): probe: %s", spec, err)
+		}
+		probe = &b
+	}
+	return New(name, path, uint(depth), splitList(value.Get("exclude")),
+		splitList(value.Get("kinds")), value.Get("type"), probe)
+}
+
+// function splitList() returns the non-empty elements of the given
+// comma-separated list.
+func splitList(list string) []string {
+	elem := []string{}
+	for _, e := range strings.Split(list, ",") {
+		if e = strings.TrimSpace(e); "" != e {
+			elem = append(elem, e)
+		}
+	}
+	return elem
+}
+
+// function MediaKinds() returns the kinds of media added by the library's scans,
+// or nil if all are.
+func (l *Library) MediaKinds() []media.MediaKind {
+	if 0 == len(l.Kinds) {
+		return nil
+	}
+	kinds := []media.MediaKind{}
+	for _, k := range l.Kinds {
+		if kind, ok := media.ParseMediaKind(k); ok {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// function String() returns a description of the Library for display.
+func (l *Library) String() string {
+	desc := l.Name + ": " + l.Path
+	setting := []string{}
+	if l.MaxDepth > 0 {
+		setting = append(setting, "depth: "+strconv.FormatUint(uint64(l.MaxDepth), 10))
+	}
+	if len(l.Exclude) > 0 {
+		setting = append(setting, "exclude: "+strings.Join(l.Exclude, ", "))
+	}
+	if len(l.Kinds) > 0 {
+		setting = append(setting, "kinds: "+strings.Join(l.Kinds, ", "))
+	}
+	if "" != l.Type {
+		setting = append(setting, "type: "+l.Type)
+	}
+	if nil != l.Probe {
+		setting = append(setting, "probe: "+strconv.FormatBool(*l.Probe))
+	}
+	if len(setting) > 0 {
+		desc += " (" + strings.Join(setting, "; ") + ")"
+	}
+	return desc
+}
+
+// function Load() reads the libraries registered in the file at the given
+// path, sorted by name. a file that doesn't exist registers no libraries.
+func Load(path string) ([]*Library, *rc.ReturnCode) {
+
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		if os.IsNotExist(err) {
+			return []*Library{}, nil
+		}
+		return nil, rc.InvalidConfig.Specf("Load(%q): %s", path, err)
+	}
+	list := []*Library{}
+	if err := json.Unmarshal(data, &list); nil != err {
+		return nil, rc.InvalidJSONData.Specf("Load(%q): json.Unmarshal(): %s", path, err)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Name < list[b].Name })
+	return list, nil
+}
+
+// function Save() writes the given libraries to the file at the given path,
+// replacing its content.
+func Save(path string, list []*Library) *rc.ReturnCode {
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if nil != err {
+		return rc.InvalidJSONData.Specf("Save(%q): json.MarshalIndent(): %s", path, err)
+	}
+	// write a temporary file first, so that an interrupted write never leaves
+	// behind a truncated file.
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); nil != err {
+		return rc.InvalidConfig.Specf("Save(%q): %s", path, err)
+	}
+	if err := os.Rename(tmp, path); nil != err {
+		os.Remove(tmp)
+		return rc.InvalidConfig.Specf("Save(%q): %s", path, err)
+	}
+	return nil
+}
+
+// function Find() returns the library in the given list with the given name
+// (ignoring case), or nil if there is none.
+func Find(list []*Library, name string) *Library {
+	for _, l := range list {
+		if strings.EqualFold(l.Name, strings.TrimSpace(name)) {
+			return l
+		}
+	}
+	return nil
+}
+
+// function FindPath() returns the library in the given list rooted at the given
+// path, or nil if there is none.
+func FindPath(list []*Library, path string) *Library {
+	abs, err := filepath.Abs(path)
+	if nil != err {
+		return nil
+	}
+	for _, l := range list {
+		if l.Path == abs {
+			return l
+		}
+	}
+	return nil
+}
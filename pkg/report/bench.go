@@ -0,0 +1,74 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: bench.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the report comparing the scan passes of a benchmark, each run with
+//    different numbers of workers and sizes of database buffers.
+//
+// =============================================================================
+
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// type BenchRun describes a single scan pass of a benchmark and the settings
+// with which it was run.
+type BenchRun struct {
+	Probers        int           // number of ffprobe processes run at once (0 = any)
+	DiskBufferSize int           // size (bytes) of the database's disk buffers
+	HashBufferSize int           // size (bytes) of the database's hash table growth
+	Files          uint          // number of regular files examined
+	Media          uint          // number of media found
+	Elapsed        time.Duration // time taken by the scan
+	Bytes          uint64        // bytes allocated on the heap during the scan
+	Allocs         uint64        // heap objects allocated during the scan
+}
+
+// function FilesPerSec() returns the rate at which the scan examined files.
+func (b BenchRun) FilesPerSec() float64 {
+	if b.Elapsed <= 0 {
+		return 0
+	}
+	return float64(b.Files) / b.Elapsed.Seconds()
+}
+
+// function Bench() composes a report of the given scan passes of a benchmark,
+// fastest first.
+func Bench(runs []BenchRun) *Report {
+
+	sorted := make([]BenchRun, len(runs))
+	copy(sorted, runs)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return sorted[a].FilesPerSec() > sorted[b].FilesPerSec()
+	})
+
+	r := newReport("Scan benchmark", "Probers", "Disk buffer", "Hash buffer",
+		"Files", "Media", "Elapsed", "Files/s", "Allocated", "Allocs")
+	for _, b := range sorted {
+		probers := strconv.Itoa(b.Probers)
+		if 0 == b.Probers {
+			probers = "any"
+		}
+		r.Rows = append(r.Rows, []string{
+			probers,
+			HumanSize(int64(b.DiskBufferSize)),
+			HumanSize(int64(b.HashBufferSize)),
+			strconv.FormatUint(uint64(b.Files), 10),
+			strconv.FormatUint(uint64(b.Media), 10),
+			b.Elapsed.Round(time.Millisecond).String(),
+			fmt.Sprintf("%.1f", b.FilesPerSec()),
+			HumanSize(int64(b.Bytes)),
+			strconv.FormatUint(b.Allocs, 10),
+		})
+	}
+	return r
+}
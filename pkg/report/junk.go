@@ -0,0 +1,100 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: junk.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the reports of the files found by the most recent scan of each
+//    library that are neither media nor support files, listed one by one or
+//    summarized by extension.
+//
+// =============================================================================
+
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"ardnew.com/pimmp/pkg/storage"
+)
+
+// function Junk() composes a report of the files recorded by the most recent
+// scan of each library that are neither media nor support files, keyed by the
+// library's path. the libraries are listed by path, each with its files in
+// order of discovery.
+func Junk(list map[string]*storage.JunkReport) *Report {
+
+	name := make([]string, 0, len(list))
+	for n := range list {
+		name = append(name, n)
+	}
+	sort.Strings(name)
+
+	r := newReport("Junk files", "Library", "Scanned", "Path", "Extension", "Bytes", "Size")
+	for _, n := range name {
+		scanned := ""
+		if !list[n].Time.IsZero() {
+			scanned = list[n].Time.Local().Format(timeFormat)
+		}
+		for _, e := range list[n].Entries {
+			r.Rows = append(r.Rows, []string{
+				n, scanned, e.Path, e.Ext, strconv.FormatInt(e.Size, 10), HumanSize(e.Size)})
+		}
+		if list[n].Omitted > 0 {
+			r.Rows = append(r.Rows, []string{
+				n, scanned, fmt.Sprintf("(%d more files not recorded)", list[n].Omitted), "", "", ""})
+		}
+	}
+	return r
+}
+
+// function JunkByExt() composes a report of the space consumed by the files
+// recorded by the most recent scan of each library that are neither media nor
+// support files, grouped by extension, largest first.
+func JunkByExt(list map[string]*storage.JunkReport) *Report {
+
+	type group struct {
+		ext   string
+		files int
+		bytes int64
+	}
+
+	index := map[string]*group{}
+	for _, rep := range list {
+		for _, e := range rep.Entries {
+			g, ok := index[e.Ext]
+			if !ok {
+				g = &group{ext: e.Ext}
+				index[e.Ext] = g
+			}
+			g.files++
+			g.bytes += e.Size
+		}
+	}
+
+	sorted := make([]*group, 0, len(index))
+	for _, g := range index {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		if sorted[a].bytes != sorted[b].bytes {
+			return sorted[a].bytes > sorted[b].bytes
+		}
+		return sorted[a].ext < sorted[b].ext
+	})
+
+	r := newReport("Junk files by extension", "Extension", "Files", "Bytes", "Size")
+	for _, g := range sorted {
+		ext := g.ext
+		if "" == ext {
+			ext = "(none)"
+		}
+		r.Rows = append(r.Rows, []string{
+			ext, strconv.Itoa(g.files), strconv.FormatInt(g.bytes, 10), HumanSize(g.bytes)})
+	}
+	return r
+}
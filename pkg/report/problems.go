@@ -0,0 +1,62 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: problems.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the report of the problems encountered by the most recent load and
+//    scan of each library.
+//
+// =============================================================================
+
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"ardnew.com/pimmp/pkg/storage"
+)
+
+// function Problems() composes a report of the problems recorded by the most
+// recent load and scan of each library, keyed by the library's path. the
+// libraries are listed by path, each with the problems of its load followed by
+// those of its scan, in order of occurrence.
+func Problems(list map[string]*storage.ScanReport) *Report {
+
+	name := make([]string, 0, len(list))
+	for n := range list {
+		name = append(name, n)
+	}
+	sort.Strings(name)
+
+	r := newReport("Scan problems", "Library", "Stage", "Finished", "Path", "Code", "Message")
+	for _, n := range name {
+		for _, stage := range []struct {
+			name    string
+			section storage.ReportSection
+		}{
+			{"load", list[n].Load},
+			{"scan", list[n].Scan},
+		} {
+			s := stage.section
+			finished := ""
+			if !s.Time.IsZero() {
+				finished = s.Time.Local().Format(timeFormat)
+			}
+			for _, e := range s.Entries {
+				r.Rows = append(r.Rows, []string{
+					n, stage.name, finished, e.Path, strconv.Itoa(e.Code), e.Message})
+			}
+			if s.Omitted > 0 {
+				r.Rows = append(r.Rows, []string{
+					n, stage.name, finished, "", "",
+					fmt.Sprintf("(%d more problems not recorded)", s.Omitted)})
+			}
+		}
+	}
+	return r
+}
@@ -0,0 +1,249 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: report.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the tabular reports of library contents and the functions that
+//    compose them from lists of media.
+//
+// =============================================================================
+
+// package report composes shareable reports of library contents, suitable for
+// emailing or hosting as CSV or a simple HTML page.
+package report
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Format is an enum identifying the file formats in which a Report can be
+// written.
+type Format int
+
+const (
+	FormatUnknown Format = iota - 1 // = -1
+	FormatCSV                       // =  0
+	FormatHTML                      // =  1
+	FormatText                      // =  2
+	FormatCOUNT                     // =  3
+)
+
+var (
+	// variable FormatName maps the Format enum values to the names by which
+	// users select them.
+	FormatName = [FormatCOUNT]string{
+		"csv",  // 0 = FormatCSV
+		"html", // 1 = FormatHTML
+		"text", // 2 = FormatText
+	}
+)
+
+// local unexported constants for composing reports.
+const (
+	timeFormat = "2006-01-02 15:04"
+)
+
+// the columns describing each media in a report.
+var mediaColumn = []string{"Kind", "Title", "Size", "Added", "Modified", "Path"}
+
+// type Report is a titled table of rows describing media.
+type Report struct {
+	Title     string     // headline of the report
+	Generated time.Time  // time at which the report was composed
+	Columns   []string   // name of each column
+	Rows      [][]string // cells of each row, one per column
+}
+
+// function ParseFormat() returns the Format with the given name, or an error
+// if there is no such Format.
+func ParseFormat(name string) (Format, *rc.ReturnCode) {
+	for f, n := range FormatName {
+		if strings.EqualFold(n, name) {
+			return Format(f), nil
+		}
+	}
+	return FormatUnknown, rc.InvalidArgs.Specf(
+		"ParseFormat(): unrecognized report format: %q (expected one of: %s)",
+		name, strings.Join(FormatName[:], ", "))
+}
+
+// function newReport() creates a new, empty Report with the given title and
+// columns.
+func newReport(title string, column ...string) *Report {
+	return &Report{
+		Title:     title,
+		Generated: time.Now(),
+		Columns:   column,
+		Rows:      [][]string{},
+	}
+}
+
+// function Contents() composes a report of all the given media.
+func Contents(list []*media.Media) *Report {
+
+	r := newReport("Library contents", mediaColumn...)
+	for _, m := range list {
+		r.Rows = append(r.Rows, mediaRow(m))
+	}
+	return r
+}
+
+// function Failed() composes a report of the given media whose most recent
+// integrity verification failed, most recently verified first.
+func Failed(list []*media.Media) *Report {
+
+	failed := []*media.Media{}
+	for _, m := range list {
+		if "" != m.VerifyError {
+			failed = append(failed, m)
+		}
+	}
+	sort.SliceStable(failed, func(a, b int) bool {
+		return failed[a].Verified.After(failed[b].Verified)
+	})
+
+	r := newReport("Failed verification", append(mediaColumn, "Verified", "Reason")...)
+	for _, m := range failed {
+		r.Rows = append(r.Rows, append(mediaRow(m),
+			m.Verified.Local().Format(timeFormat), m.VerifyError))
+	}
+	return r
+}
+
+// function Recent() composes a report of the given media added to a library
+// since the given time, newest first.
+func Recent(list []*media.Media, since time.Time) *Report {
+
+	recent := []*media.Media{}
+	for _, m := range list {
+		if m.TimeAdded.After(since) {
+			recent = append(recent, m)
+		}
+	}
+	sort.SliceStable(recent, func(a, b int) bool {
+		return recent[a].TimeAdded.After(recent[b].TimeAdded)
+	})
+
+	r := newReport("Recently added since "+since.Format(timeFormat), mediaColumn...)
+	for _, m := range recent {
+		r.Rows = append(r.Rows, mediaRow(m))
+	}
+	return r
+}
+
+// function Duplicates() composes a report of the given media that are likely
+// duplicates of each other, i.e. files of the same kind, extension, and size
+// in bytes. each group of duplicates is numbered in the first column.
+func Duplicates(list []*media.Media) *Report {
+
+	type key struct {
+		kind media.MediaKind
+		ext  string
+		size int64
+	}
+
+	group := map[key][]*media.Media{}
+	order := []key{}
+	for _, m := range list {
+		// empty files are all the same size, but hardly duplicates.
+		if m.Size <= 0 {
+			continue
+		}
+		k := key{m.Kind, strings.ToLower(m.Ext), m.Size}
+		if _, ok := group[k]; !ok {
+			order = append(order, k)
+		}
+		group[k] = append(group[k], m)
+	}
+
+	r := newReport("Duplicates", append([]string{"Group"}, mediaColumn...)...)
+	num := 0
+	for _, k := range order {
+		if len(group[k]) < 2 {
+			continue
+		}
+		num++
+		for _, m := range group[k] {
+			r.Rows = append(r.Rows, append([]string{strconv.Itoa(num)}, mediaRow(m)...))
+		}
+	}
+	return r
+}
+
+// function Identical() composes a report of the given media whose files have
+// identical content, i.e. the same content hash (see package contenthash) and
+// size. media without a hash are omitted. each group of identical media is
+// numbered in the first column, largest group (by the space used by all of its
+// files but one) first.
+func Identical(list []*media.Media) *Report {
+
+	type key struct {
+		hash string
+		size int64
+	}
+
+	group := map[key][]*media.Media{}
+	order := []key{}
+	for _, m := range list {
+		if "" == m.Hash {
+			continue
+		}
+		k := key{m.Hash, m.Size}
+		if _, ok := group[k]; !ok {
+			order = append(order, k)
+		}
+		group[k] = append(group[k], m)
+	}
+	wasted := func(k key) int64 { return k.size * int64(len(group[k])-1) }
+	sort.SliceStable(order, func(a, b int) bool { return wasted(order[a]) > wasted(order[b]) })
+
+	r := newReport("Identical media", append([]string{"Group"}, mediaColumn...)...)
+	num := 0
+	for _, k := range order {
+		if len(group[k]) < 2 {
+			continue
+		}
+		num++
+		sort.Slice(group[k], func(a, b int) bool { return group[k][a].AbsPath < group[k][b].AbsPath })
+		for _, m := range group[k] {
+			r.Rows = append(r.Rows, append([]string{strconv.Itoa(num)}, mediaRow(m)...))
+		}
+	}
+	return r
+}
+
+// function mediaRow() returns the cells describing the given media in the
+// order of mediaColumn.
+func mediaRow(m *media.Media) []string {
+
+	kind := "unknown"
+	if m.Kind > media.KindUnknown && m.Kind < media.KindCOUNT {
+		kind = strings.ToLower(media.MediaColName[m.Kind])
+	}
+	title := m.Title
+	if "" == title || "--" == title || m.AbsName == title {
+		title = m.AbsBase
+	}
+	added := ""
+	if !m.TimeAdded.IsZero() {
+		added = m.TimeAdded.Local().Format(timeFormat)
+	}
+
+	return []string{
+		kind,
+		title,
+		strconv.FormatInt(m.Size, 10),
+		added,
+		m.TimeModified.Local().Format(timeFormat),
+		m.AbsPath,
+	}
+}
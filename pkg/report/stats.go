@@ -0,0 +1,199 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: stats.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the statistics summarizing the libraries over time: their growth,
+//    how often media is played, and the most common genres.
+//
+// =============================================================================
+
+package report
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/media"
+)
+
+// constant week is the period of each sample in a Series.
+const week = 7 * 24 * time.Hour
+
+// sparkTick are the characters drawing a sparkline, from lowest to highest.
+var sparkTick = []rune("▁▂▃▄▅▆▇█")
+
+// type Series is a count of events per consecutive week, oldest first.
+type Series struct {
+	Start time.Time // beginning of the first week
+	Count []int     // number of events in each week
+	Total int       // number of events before the first week
+}
+
+// function newSeries() returns an empty Series of the given number of weeks,
+// the last of which contains the given time.
+func newSeries(now time.Time, weeks int) *Series {
+	if weeks < 1 {
+		weeks = 1
+	}
+	return &Series{
+		Start: now.Add(-time.Duration(weeks) * week).Add(time.Nanosecond),
+		Count: make([]int, weeks),
+	}
+}
+
+// function add() counts an event at the given time. events before the Series
+// are counted in its Total, and events after it are ignored.
+func (s *Series) add(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if t.Before(s.Start) {
+		s.Total++
+		return
+	}
+	if i := int(t.Sub(s.Start) / week); i < len(s.Count) {
+		s.Count[i]++
+	}
+}
+
+// function Cumulative() returns the total number of events at the end of each
+// week of the Series, including those before it.
+func (s *Series) Cumulative() []int {
+	sum := make([]int, len(s.Count))
+	n := s.Total
+	for i, c := range s.Count {
+		n += c
+		sum[i] = n
+	}
+	return sum
+}
+
+// function WeeklyAdded() returns the number of the given media added to the
+// libraries in each of the given number of weeks up to the given time.
+func WeeklyAdded(list []*media.Media, now time.Time, weeks int) *Series {
+	s := newSeries(now, weeks)
+	for _, m := range list {
+		s.add(m.TimeAdded)
+	}
+	return s
+}
+
+// function WeeklyPlays() returns the number of times the given media were
+// played in each of the given number of weeks up to the given time. besides
+// the most recent play, earlier plays are only known from the media's edit
+// history, so plays older than the history are not counted.
+func WeeklyPlays(list []*media.Media, now time.Time, weeks int) *Series {
+	s := newSeries(now, weeks)
+	for _, m := range list {
+		s.add(m.LastPlayed)
+		for _, e := range m.History {
+			if "LastPlayed" != e.Field {
+				continue
+			}
+			// each change of LastPlayed replaced the time of a previous play.
+			if old, ok := e.Old.(string); ok {
+				if t, err := time.Parse(time.RFC3339Nano, old); nil == err {
+					s.add(t)
+				}
+			}
+		}
+	}
+	s.Total = 0 // plays before the series are not meaningful
+	return s
+}
+
+// function Sparkline() draws the given values as a line of bars, one for each
+// value, scaled between the lowest and highest value.
+func Sparkline(value []int) string {
+
+	if 0 == len(value) {
+		return ""
+	}
+	lo, hi := value[0], value[0]
+	for _, v := range value {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	var sb strings.Builder
+	for _, v := range value {
+		i := 0
+		if hi > lo {
+			i = (v - lo) * (len(sparkTick) - 1) / (hi - lo)
+		}
+		sb.WriteRune(sparkTick[i])
+	}
+	return sb.String()
+}
+
+// function Weekly() composes a report of the given Series, one row per week,
+// most recent first, listing the events in each week and the running total.
+func Weekly(title, column string, s *Series) *Report {
+
+	r := newReport(title, "Week of", column, "Total")
+	sum := s.Cumulative()
+	for i := len(s.Count) - 1; i >= 0; i-- {
+		r.Rows = append(r.Rows, []string{
+			s.Start.Add(time.Duration(i) * week).Format("2006-01-02"),
+			strconv.Itoa(s.Count[i]),
+			strconv.Itoa(sum[i]),
+		})
+	}
+	return r
+}
+
+// function Genres() composes a report of the genres of the given media, most
+// common first. if limit is positive, only that many genres are reported.
+// genres are compared ignoring case.
+func Genres(list []*media.Media, limit int) *Report {
+
+	type genre struct {
+		name  string
+		count int
+	}
+
+	index := map[string]*genre{}
+	for _, m := range list {
+		seen := map[string]bool{}
+		for _, g := range m.Genres {
+			key := strings.ToLower(strings.TrimSpace(g))
+			if "" == key || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, ok := index[key]; !ok {
+				index[key] = &genre{name: strings.TrimSpace(g)}
+			}
+			index[key].count++
+		}
+	}
+
+	sorted := make([]*genre, 0, len(index))
+	for _, g := range index {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		if sorted[a].count != sorted[b].count {
+			return sorted[a].count > sorted[b].count
+		}
+		return sorted[a].name < sorted[b].name
+	})
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+
+	r := newReport("Top genres", "Genre", "Media")
+	for _, g := range sorted {
+		r.Rows = append(r.Rows, []string{g.name, strconv.Itoa(g.count)})
+	}
+	return r
+}
@@ -0,0 +1,179 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: usage.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the disk usage reports, summarizing the space consumed by media
+//    grouped by kind, extension, directory, and quality tier.
+//
+// =============================================================================
+
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/media"
+)
+
+// type UsageBy is an enum identifying the attribute by which media is grouped
+// in a disk usage report.
+type UsageBy int
+
+const (
+	UsageByUnknown UsageBy = iota - 1 // = -1
+	UsageByKind                       // =  0
+	UsageByExt                        // =  1
+	UsageByDir                        // =  2
+	UsageByQuality                    // =  3
+	UsageByCOUNT                      // =  4
+)
+
+var (
+	// variable UsageByName maps the UsageBy enum values to the name of the
+	// attribute by which media is grouped.
+	UsageByName = [UsageByCOUNT]string{
+		"kind",      // 0 = UsageByKind
+		"extension", // 1 = UsageByExt
+		"directory", // 2 = UsageByDir
+		"quality",   // 3 = UsageByQuality
+	}
+)
+
+// the quality tiers recognized in the file names of videos, best first. there
+// is no better source of this info until the media itself is probed.
+var videoQuality = []struct {
+	tier    string
+	pattern *regexp.Regexp
+}{
+	{"2160p", regexp.MustCompile(`(?i)\b(2160p|4k|uhd)\b`)},
+	{"1080p", regexp.MustCompile(`(?i)\b1080[pi]\b`)},
+	{"720p", regexp.MustCompile(`(?i)\b720p\b`)},
+	{"SD", regexp.MustCompile(`(?i)\b(576[pi]|480[pi]|dvd(rip)?|sd)\b`)},
+}
+
+// the audio encodings (as named in media.ExtTable) that are lossless.
+var losslessAudio = map[string]bool{
+	"Apple AIFF":                true,
+	"Free Lossless Audio Codec": true,
+	"Microsoft WAV":             true,
+	"Monkey's Audio":            true,
+	"True Audio Lossless":       true,
+	"WavPack":                   true,
+}
+
+// function QualityTier() returns the quality tier of the given media: for
+// videos, the resolution named in the file name; for audio, whether or not the
+// encoding is lossless.
+func QualityTier(m *media.Media) string {
+	switch m.Kind {
+	case media.KindVideo:
+		for _, q := range videoQuality {
+			if q.pattern.MatchString(m.AbsName) {
+				return q.tier
+			}
+		}
+	case media.KindAudio:
+		if losslessAudio[m.ExtName] {
+			return "lossless"
+		}
+		return "lossy"
+	}
+	return "unknown"
+}
+
+// function HumanSize() formats the given number of bytes using binary units,
+// e.g. "1.5 GiB".
+func HumanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for q := n / unit; q >= unit; q /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// function Usage() composes a report of the space consumed by the given media,
+// grouped by the given attribute, largest first. if limit is positive, only
+// that many of the largest groups are reported.
+func Usage(list []*media.Media, by UsageBy, limit int) *Report {
+
+	type group struct {
+		name  string
+		files int
+		bytes int64
+	}
+
+	var total int64
+	index := map[string]*group{}
+	for _, m := range list {
+		var name string
+		switch by {
+		case UsageByKind:
+			name = "unknown"
+			if m.Kind > media.KindUnknown && m.Kind < media.KindCOUNT {
+				name = strings.ToLower(media.MediaColName[m.Kind])
+			}
+		case UsageByExt:
+			name = strings.ToLower(m.Ext)
+		case UsageByDir:
+			name = m.AbsDir
+		case UsageByQuality:
+			name = QualityTier(m)
+		}
+		g, ok := index[name]
+		if !ok {
+			g = &group{name: name}
+			index[name] = g
+		}
+		g.files++
+		g.bytes += m.Size
+		total += m.Size
+	}
+
+	sorted := make([]*group, 0, len(index))
+	for _, g := range index {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		if sorted[a].bytes != sorted[b].bytes {
+			return sorted[a].bytes > sorted[b].bytes
+		}
+		return sorted[a].name < sorted[b].name
+	})
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+
+	name := "unknown"
+	if by > UsageByUnknown && by < UsageByCOUNT {
+		name = UsageByName[by]
+	}
+	r := newReport("Disk usage by "+name,
+		strings.Title(name), "Files", "Bytes", "Size", "Share")
+	for _, g := range sorted {
+		share := 0.0
+		if total > 0 {
+			share = 100 * float64(g.bytes) / float64(total)
+		}
+		r.Rows = append(r.Rows, []string{
+			g.name,
+			strconv.Itoa(g.files),
+			strconv.FormatInt(g.bytes, 10),
+			HumanSize(g.bytes),
+			fmt.Sprintf("%.1f%%", share),
+		})
+	}
+	return r
+}
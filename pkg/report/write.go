@@ -0,0 +1,103 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: write.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the functions writing reports as CSV and as a simple, standalone
+//    HTML page.
+//
+// =============================================================================
+
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// the standalone HTML page of a report. all styles are inline so that the page
+// can be emailed or hosted as a single file.
+var htmlPage = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; }
+th { background: #eee; }
+tr:nth-child(even) td { background: #f8f8f8; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{len .Rows}} item(s), generated {{.Generated.Format "2006-01-02 15:04"}} by pimmp</p>
+<table>
+<tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// function Write() writes the report to w in the given format.
+func (r *Report) Write(w io.Writer, format Format) *rc.ReturnCode {
+	switch format {
+	case FormatCSV:
+		return r.WriteCSV(w)
+	case FormatHTML:
+		return r.WriteHTML(w)
+	case FormatText:
+		return r.WriteText(w)
+	}
+	return rc.InvalidArgs.Specf("Write(): unrecognized report format: %d", int(format))
+}
+
+// function WriteCSV() writes the report to w as CSV, with a header row naming
+// the columns.
+func (r *Report) WriteCSV(w io.Writer) *rc.ReturnCode {
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(r.Columns); nil != err {
+		return rc.ExportError.Specf("WriteCSV(%q): %s", r.Title, err)
+	}
+	if err := cw.WriteAll(r.Rows); nil != err {
+		return rc.ExportError.Specf("WriteCSV(%q): %s", r.Title, err)
+	}
+	return nil
+}
+
+// function WriteHTML() writes the report to w as a standalone HTML page.
+func (r *Report) WriteHTML(w io.Writer) *rc.ReturnCode {
+	if err := htmlPage.Execute(w, r); nil != err {
+		return rc.ExportError.Specf("WriteHTML(%q): %s", r.Title, err)
+	}
+	return nil
+}
+
+// function WriteText() writes the report to w as plain text, with the title
+// followed by the rows in aligned columns. this is the format intended for
+// reading directly in a terminal.
+func (r *Report) WriteText(w io.Writer) *rc.ReturnCode {
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\n\n", r.Title)
+	fmt.Fprintln(tw, strings.Join(r.Columns, "\t"))
+	for _, row := range r.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); nil != err {
+		return rc.ExportError.Specf("WriteText(%q): %s", r.Title, err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: backup.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    copies a library's database directory, so that it can be restored after
+//    the original is damaged or lost.
+//
+// =============================================================================
+
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// function Backup() copies the entire database directory into a new directory
+// of the same name in the given directory, returning the path of the copy. the
+// copy can be restored by replacing the database directory with it. the
+// database must not be modified while it is copied.
+func (d *Database) Backup(dir string) (string, *rc.ReturnCode) {
+
+	dest := filepath.Join(dir, d.name)
+	if _, err := os.Stat(dest); nil == err {
+		return "", rc.InvalidPath.Specf("Backup(%q): backup exists: %q", dir, dest)
+	}
+
+	err := filepath.Walk(d.absPath, func(path string, info os.FileInfo, err error) error {
+		if nil != err {
+			return err
+		}
+		rel, err := filepath.Rel(d.absPath, path)
+		if nil != err {
+			return err
+		}
+		// the lock file isn't copied, since nothing has the copy open.
+		if lockFileName == rel {
+			return nil
+		}
+		target := filepath.Join(dest, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, os.ModePerm)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFile(path, target, info.Mode().Perm())
+	})
+	if nil != err {
+		os.RemoveAll(dest)
+		return "", rc.DatabaseError.Specf("Backup(%q): %s", dir, err)
+	}
+	return dest, nil
+}
+
+// function copyFile() copies the content of the file at path from to a new
+// file at path to with the given permissions.
+func copyFile(from, to string, perm os.FileMode) error {
+
+	src, err := os.Open(from)
+	if nil != err {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if nil != err {
+		return err
+	}
+	if _, err := io.Copy(dst, src); nil != err {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,168 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: batch.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    buffers the records inserted into a collection so that they are written
+//    to the database many at a time, e.g. while scanning a large library.
+//
+// =============================================================================
+
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for batched inserts.
+const (
+	batchInterval = 2 * time.Second // time after which buffered records are due (see FlushDue())
+)
+
+// type Batch buffers the records inserted into one collection until their
+// total size reaches the configured DiskBufferSize, or until it is flushed,
+// and then inserts them all at once (see engine.Collection.InsertMany()). a
+// buffered record isn't found by any query until its batch is flushed, so each
+// record is given a function to call with its ID once it has been inserted.
+//
+// a Batch is never flushed behind its user's back: the functions given with
+// its records are only called from Insert(), Flush(), or FlushDue(), on the
+// goroutine calling them, so they may safely update the state of the scan
+// buffering the records.
+type Batch struct {
+	mutex sync.Mutex
+	db    *Database
+	class media.EntityClass        // class of the collection
+	kind  int                      // kind of the collection
+	limit int                      // size (in bytes) at which records are flushed
+	docs  []map[string]interface{} // records not yet inserted
+	done  []func(id int)           // function called with the ID of each record
+	size  int                      // total size (in bytes) of the buffered records
+	since time.Time                // time the oldest buffered record was buffered
+}
+
+// function newBatch() creates a Batch buffering records of the collection of
+// the given class and kind until their total size reaches the given limit.
+func newBatch(d *Database, class media.EntityClass, kind int, limit int) *Batch {
+	return &Batch{db: d, class: class, kind: kind, limit: limit}
+}
+
+// function Insert() buffers the given record to be inserted into the
+// collection with the next batch, after which the given function, if not nil,
+// is called with its ID. returns the error of the batch if this record caused
+// it to be flushed.
+func (b *Batch) Insert(doc map[string]interface{}, done func(id int)) *rc.ReturnCode {
+
+	// the size is only an estimate; the engine encodes the record once more.
+	data, err := json.Marshal(doc)
+	if nil != err {
+		return rc.DatabaseError.Specf(
+			"Insert(%q): json.Marshal(): %s", b.db.ColName[b.class][b.kind], err)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// never let a single batch outgrow the pre-allocated buffer, unless the
+	// record is larger than it on its own.
+	if len(b.docs) > 0 && b.size+len(data) > b.limit {
+		if ret := b.flush(); nil != ret {
+			return ret
+		}
+	}
+	if 0 == len(b.docs) {
+		b.since = time.Now()
+	}
+	b.docs = append(b.docs, doc)
+	b.done = append(b.done, done)
+	b.size += len(data)
+	if b.size >= b.limit {
+		return b.flush()
+	}
+	return nil
+}
+
+// function Flush() inserts every buffered record into the collection.
+func (b *Batch) Flush() *rc.ReturnCode {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.flush()
+}
+
+// function FlushDue() inserts every buffered record into the collection if the
+// oldest of them has waited at least batchInterval.
+func (b *Batch) FlushDue() *rc.ReturnCode {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if 0 == len(b.docs) || time.Since(b.since) < batchInterval {
+		return nil
+	}
+	return b.flush()
+}
+
+// function flush() inserts every buffered record into the collection and
+// calls their functions with the IDs assigned. the records that couldn't be
+// inserted are discarded. the mutex must be held by the caller.
+func (b *Batch) flush() *rc.ReturnCode {
+
+	if 0 == len(b.docs) {
+		return nil
+	}
+	docs, done := b.docs, b.done
+	b.docs, b.done, b.size = nil, nil, 0
+
+	// the collection is looked up each time, Scrub() replaces it.
+	ids, err := b.db.Col[b.class][b.kind].InsertMany(docs)
+	for i, id := range ids {
+		if id >= 0 && nil != done[i] {
+			done[i](id)
+		}
+	}
+	if nil != err {
+		return rc.DatabaseError.Specf(
+			"flush(%q): failed to insert %d records: %s",
+			b.db.ColName[b.class][b.kind], len(docs), err)
+	}
+	return nil
+}
+
+// function Flush() inserts the buffered records of every collection into the
+// database (see type Batch), returning the first error encountered.
+func (d *Database) Flush() *rc.ReturnCode {
+
+	var first *rc.ReturnCode
+	for class := range d.Batch {
+		for _, b := range d.Batch[class] {
+			if ret := b.Flush(); nil != ret && nil == first {
+				first = ret
+			}
+		}
+	}
+	return first
+}
+
+// function FlushDue() inserts the buffered records of every collection whose
+// oldest record has waited at least batchInterval (see FlushDue() of Batch),
+// returning the first error encountered.
+func (d *Database) FlushDue() *rc.ReturnCode {
+
+	var first *rc.ReturnCode
+	for class := range d.Batch {
+		for _, b := range d.Batch[class] {
+			if ret := b.FlushDue(); nil != ret && nil == first {
+				first = ret
+			}
+		}
+	}
+	return first
+}
@@ -0,0 +1,904 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2018
+//  FILE: database.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines types and functions for interacting with an on-disk persistent
+//    storage container. this is primarily used to store the media content in
+//    an indexed file structure of some sort with fast read-write access.
+//
+// =============================================================================
+
+// package storage wraps the database engine in which each library persists
+// its entity records.
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"ardnew.com/goutil"
+	//"github.com/davecgh/go-spew/spew"
+
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/pidfile"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// var logs are the loggers of the messages about the library databases,
+// filtered as component "db" (see console.Register()).
+var logs = console.Register("db")
+
+// local unexported constants for the database engine.
+const (
+	dataConfigFileName  = "data-config.json"
+	lockFileName        = "pimmp.pid" // held by the process which has the database open
+	dataConfigFilePerms = 0644
+	quarantineColName   = "Quarantine"
+	orphanColName       = "Orphaned"
+	journalColName      = "Journal"
+	indexPathSep        = "," // separator of the segments of an index path (see initialize())
+
+	kibiBytes = 1024
+	mebiBytes = 1048576
+)
+
+// names of the command-line options which configure the database engine. these
+// are reported back to the user whenever they conflict with the configuration
+// of an existing database.
+const (
+	DiskBufferSizeOption = "diskbuffersize"
+	HashBufferSizeOption = "hashbuffersize"
+	EngineOption         = "dbengine"
+)
+
+var (
+	// see type JSONDataConfig for a description of these items
+	defaultMaxRecordSize  = 64 * kibiBytes
+	DefaultDiskBufferSize = 4 * defaultMaxRecordSize / runtime.NumCPU()
+	defaultHashBucketSize = 16
+	DefaultHashBufferSize = DefaultDiskBufferSize / 4
+	defaultHashedBitsSize = 13
+	defaultNumHashBuckets = 8192
+)
+
+// type Config contains the user-configurable parameters of the database engine.
+// the Provided slice lists the option name of each field that was explicitly
+// set by the user, as opposed to those left with their default value.
+type Config struct {
+	Engine         string   // name of the engine of new databases (see package engine)
+	DiskBufferSize int      // size (in bytes) of each collection's pre-allocated files (tiedot only), and of each batch of inserts
+	HashBufferSize int      // size (in bytes) to grow hash table files (tiedot only)
+	Provided       []string // names of the options the user provided
+	ReadOnly       bool     // never write to the database, keeping changes in memory (see engine.Overlay)
+}
+
+// function NewConfig() creates a database Config with all default values.
+func NewConfig() *Config {
+	return &Config{
+		Engine:         engine.Default,
+		DiskBufferSize: DefaultDiskBufferSize,
+		HashBufferSize: DefaultHashBufferSize,
+		Provided:       []string{},
+	}
+}
+
+// function isProvided() returns true if and only if at least one of the
+// database configuration options was provided by the user.
+func (c *Config) isProvided() bool {
+	return len(c.Provided) > 0
+}
+
+// type JSONDataConfig defines all of tiedot's configurable parameters for
+// initial index and cache sizes
+type JSONDataConfig struct {
+	config         *Config // not stored in the json data
+	MaxRecordSize  int     `json:"DocMaxRoom"`     // maximum size of a single document that will ever be accepted into the database.
+	DiskBufferSize int     `json:"ColFileGrowth"`  // size (in bytes) of each collection's pre-allocated files.
+	HashBucketSize int     `json:"PerBucket"`      // number of entries pre-allocated to each hash table bucket.
+	HashBufferSize int     `json:"HTFileGrowth"`   // size (in bytes) to grow hash table file to fit in more entries.
+	HashedBitsSize uint    `json:"HashBits"`       // number of bits to consider for hashing indexed key, also determines the initial number of buckets in a hash table file.
+	NumHashBuckets int     `json:"InitialBuckets"` // number of buckets initially allocated in a hash table file.
+}
+
+// creates a string representation of the Database for easy identification in
+// logs.
+func (c *JSONDataConfig) String() string {
+	r := strings.NewReplacer("\r", "", "\n", "")
+	return r.Replace(fmt.Sprintf("%#v", c))
+}
+
+// function newJSONDataConfig() creates the struct that configures tiedot's
+// index/cache sizing options. this struct is intended to be marshalled into
+// a json string and stored in a file read by the tiedot runtime.
+func newJSONDataConfig(cfg *Config) (*JSONDataConfig, *rc.ReturnCode) {
+
+	if nil == cfg {
+		return nil, rc.InvalidJSONData.Spec(
+			"newJSONDataConfig(): cannot encode JSON object: &Config{} is nil")
+	}
+
+	bits := uint(math.Log2(float64(cfg.HashBufferSize) / 512.0))
+	buckets := 1 << bits
+	recordSizeMax := defaultMaxRecordSize
+	bucketSize := defaultHashBucketSize
+
+	return &JSONDataConfig{
+		config:         cfg,
+		MaxRecordSize:  int(recordSizeMax),
+		DiskBufferSize: cfg.DiskBufferSize,
+		HashBucketSize: int(bucketSize),
+		HashBufferSize: cfg.HashBufferSize,
+		HashedBitsSize: uint(bits),
+		NumHashBuckets: int(buckets),
+	}, nil
+}
+
+// function marshal() marshals a configuration struct into a json string capable
+// of being read from a file by the tiedot runtime.
+func (c *JSONDataConfig) marshal(indent bool) ([]byte, *rc.ReturnCode) {
+
+	var data []byte
+	var err error
+
+	if indent {
+		data, err = json.MarshalIndent(c, "", "  ")
+	} else {
+		data, err = json.Marshal(c)
+	}
+	if nil != err {
+		return nil, rc.InvalidJSONData.Specf(
+			"marshal(%s): cannot marshal struct into JSON object: %s", c, err)
+	}
+	return data, nil
+}
+
+// function unmarshal() unmarshals the tiedot-defined json configuration string
+// into a JSONDataConfig{} struct.
+func (c *JSONDataConfig) unmarshal(data []byte) *rc.ReturnCode {
+
+	if err := json.Unmarshal(data, c); nil != err {
+		return rc.InvalidJSONData.Specf(
+			"unmarshal(%q): cannot unmarshal JSON object into struct: %s",
+			string(data), err)
+	}
+	return nil
+}
+
+// function equals() performs a field-by-field logical comparison of two
+// JSONDataConfig{} structs returning true if and only if the fields are equal.
+// a slice of strings containing the corresponding command-line option names of
+// all unequal fields is returned. an empty slice is returned if all fields are
+// equal or the argument references point to the same object.
+func (c *JSONDataConfig) equals(jdc *JSONDataConfig) (bool, []string) {
+
+	uneq := []string{}
+
+	if c != jdc {
+		// these fields are the only options the user can specify on the command
+		// line. all other fields are calculated based on these.
+		if c.DiskBufferSize != jdc.DiskBufferSize {
+			uneq = append(uneq, DiskBufferSizeOption)
+		}
+		if c.HashBufferSize != jdc.HashBufferSize {
+			uneq = append(uneq, HashBufferSizeOption)
+		}
+	}
+	return 0 == len(uneq), uneq
+}
+
+// type DiscoveryMethod represents the method by which media is located.
+type DiscoveryMethod int
+
+// constants which categorize the method by which media items
+// are discovered. items discovered by "load" are previously-known items being
+// loaded by the database, and items discovered by "scan" were encountered (for
+// the first time) by file system traversal. items discovered by "update" were
+// previously-known items encountered by file system traversal whose files had
+// changed since.
+const (
+	MethodUnknown DiscoveryMethod = iota - 1 // = -1
+	MethodLoad                               // = 0 loaded from database
+	MethodScan                               // = 1 found by file system traversal
+	MethodUpdate                             // = 2 changed on file system since last found
+	MethodCOUNT                              // = 3
+)
+
+// type Database represents an abstraction from the internal persistent storage
+// mechanism used for maintaining an index of all known libraries and their
+// respective media content.
+type Database struct {
+	absPath string // absolute path to database directory
+	libPath string // absolute path to library
+	name    string // libPath checksum (name of database directory)
+	dataDir string // directory containing all known library databases
+
+	store            engine.Store                           // interactive database object
+	Col              [media.ClassCOUNT][]engine.Collection  // db collections referenced by MediaKind
+	QuarantineCol    engine.Collection                      // collection of unparseable records removed from the others
+	OrphanCol        engine.Collection                      // collection of records whose files no longer exist
+	JournalCol       engine.Collection                      // collection of the changes made to files, which may be reverted
+	ColName          [media.ClassCOUNT][]string             // name of each collection
+	Index            [media.ClassCOUNT][]*media.EntityIndex // indices on each collection
+	NumRecordsLoad   [media.ClassCOUNT][]uint               // number of records in each media collection discovered by Load()
+	NumRecordsScan   [media.ClassCOUNT][]uint               // number of records in each media collection discovered by Scan()
+	NumRecordsUpdate [media.ClassCOUNT][]uint               // number of records in each media collection updated by Scan()
+	Batch            [media.ClassCOUNT][]*Batch             // buffered inserts into each collection (see type Batch)
+	diskBufferSize   int                                    // size (in bytes) of each batch of inserts
+	timeCreated      time.Time                              // only set if the db was newly created, else IsZero() will return true
+	lock             *pidfile.File                          // prevents other processes from opening the database
+	overlay          *engine.Overlay                        // intercepts every write to store, if read-only (nil otherwise)
+}
+
+// type RecordID offers a tuple object storing any given type with an integer ID
+// which tiedot uses as its primary (hash) key for locating records in any given
+// collection.
+type RecordID struct {
+	ID  int
+	Rec interface{}
+}
+
+// type QuarantineRecord is the struct stored in the quarantine collection for
+// each record that could not be parsed while loading. the original data is
+// retained verbatim so that it can be inspected or repaired at a later time.
+type QuarantineRecord struct {
+	Class      media.EntityClass // class of the collection from which it was removed
+	Kind       int               // kind of the collection from which it was removed
+	Collection string            // name of the collection from which it was removed
+	ID         int               // original doc ID in the source collection
+	Reason     string            // description of the error encountered
+	Data       string            // original, unmodified record data
+	Time       time.Time         // date the record was quarantined
+}
+
+// type OrphanRecord is the struct stored in the orphaned collection for each
+// record whose file no longer existed while loading. the original data is
+// retained verbatim so that the record can be restored if the file reappears.
+type OrphanRecord struct {
+	Class      media.EntityClass // class of the collection from which it was removed
+	Kind       int               // kind of the collection from which it was removed
+	Collection string            // name of the collection from which it was removed
+	ID         int               // original doc ID in the source collection
+	AbsPath    string            // absolute path of the missing file
+	Data       string            // original, unmodified record data
+	Time       time.Time         // date the record was orphaned
+}
+
+// the changes to files recorded in the journal collection (see type
+// JournalRecord).
+const (
+	JournalMove   = "move"   // a file moved or renamed
+	JournalDelete = "delete" // a file moved to the trash, and its record deleted
+)
+
+// type JournalRecord is the struct stored in the journal collection for each
+// change made to a file by a command that may be reverted, e.g. organizing or
+// deleting media. the changes made by a single command share the same batch,
+// which is reverted as a whole.
+type JournalRecord struct {
+	Batch     time.Time         // time at which the batch of changes began
+	Op        string            // change made to the file (JournalMove, JournalDelete)
+	Class     media.EntityClass // class of the collection of the file's record
+	Kind      int               // kind of the collection of the file's record
+	AbsPath   string            // absolute path of the file before the change
+	NewPath   string            // absolute path of the file after the change (JournalMove)
+	Trash     string            // trash directory holding the file (JournalDelete)
+	TrashName string            // name of the file in the trash (JournalDelete)
+	Data      string            // original, unmodified record data (JournalDelete, empty if not media)
+	Time      time.Time         // date the change was made
+}
+
+// function NewDatabase() creates a new high-level database object through
+// which all of the persistent storage operations should be performed.
+func NewDatabase(cfg *Config, abs string, dat string) (*Database, *rc.ReturnCode) {
+
+	// zeroized Time object is January 1, year 1, 00:00:00.000000000 UTC
+	// calling time.IsZero() with this value will return true, alternatively,
+	// calling our (*Database).IsFirstAppearance() will also return true.
+	timeCreated := time.Time{}
+
+	// compute an identifying checksum from the absolute path to the library,
+	// and use that to build a path to the database directory.
+	sum := strings.ToLower(goutil.MD5(abs))
+	path := filepath.Join(dat, sum)
+
+	// verify or create the database directory if it doesn't exist. a
+	// read-only database can't be created, there is nothing to read.
+	if exists, _ := goutil.PathExists(path); !exists {
+		if cfg.ReadOnly {
+			return nil, rc.InvalidDatabase.Specf(
+				"NewDatabase(%q, %q): cannot open read-only: library has no database yet", abs, dat)
+		}
+		if err := os.MkdirAll(path, os.ModePerm); nil != err {
+			return nil, rc.InvalidDatabase.Specf(
+				"NewDatabase(%q, %q): os.MkdirAll(%q): %s", abs, dat, path, err)
+		}
+		logs.Info.Verbosef("creating library database: %q (%s)", abs, sum)
+	}
+
+	// select the database engine: that of the existing database, if any, or
+	// else the one configured. the engine of a database never changes.
+	if "" != cfg.Engine && !engine.Valid(cfg.Engine) {
+		return nil, rc.InvalidConfig.Specf(
+			"NewDatabase(%q, %q): unknown database engine: %q (expected one of: %s)",
+			abs, dat, cfg.Engine, strings.Join(engine.Names(), ", "))
+	}
+	name := engine.Detect(path)
+	if "" == name {
+		// the directory exists, but holds no data store of any engine (e.g. its
+		// creation was interrupted), which is just as absent.
+		if cfg.ReadOnly {
+			return nil, rc.InvalidDatabase.Specf(
+				"NewDatabase(%q, %q): cannot open read-only: library has no database yet", abs, dat)
+		}
+		name = strings.ToLower(cfg.Engine)
+		if "" == name {
+			name = engine.Default
+		}
+		timeCreated = time.Now()
+	} else if "" != cfg.Engine && !strings.EqualFold(cfg.Engine, name) {
+		logs.Warn.Verbosef(
+			"database already uses engine %q, ignoring option -%s=%s "+
+				"(see \"db export\" and \"db import\" to convert it): %q",
+			name, EngineOption, cfg.Engine, abs)
+	}
+
+	// only tiedot reads a configuration file.
+	if engine.Tiedot == name {
+		if ret := configureTiedot(cfg, abs, dat, path, sum); nil != ret {
+			return nil, ret
+		}
+	}
+
+	// no other process may open the data store while we have it open, since
+	// neither engine can share it. the lock is only acquired once the engine
+	// was detected, which an unexpected file would confuse.
+	lock, ret := pidfile.Acquire(filepath.Join(path, lockFileName))
+	if nil != ret {
+		return nil, ret
+	}
+
+	// open the actual persistent data store if it exists; otherwise, create it.
+	store, err := engine.Open(name, path)
+	if nil != err {
+		lock.Release()
+		return nil, rc.DatabaseError.Specf(
+			"NewDatabase(%q, %q): engine.Open(%q, %q): %s", abs, dat, name, path, err)
+	}
+	var overlay *engine.Overlay
+	if cfg.ReadOnly {
+		overlay = engine.NewOverlay(store)
+		store = overlay
+		logs.Info.Verbosef("opened database read-only, changes are discarded: %q", abs)
+	}
+
+	// initialize the new struct object.
+	base := &Database{
+		absPath:          path,
+		libPath:          abs,
+		name:             sum,
+		dataDir:          dat,
+		store:            store,
+		Col:              [media.ClassCOUNT][]engine.Collection{},
+		QuarantineCol:    nil,
+		OrphanCol:        nil,
+		JournalCol:       nil,
+		ColName:          [media.ClassCOUNT][]string{},
+		Index:            [media.ClassCOUNT][]*media.EntityIndex{},
+		NumRecordsLoad:   [media.ClassCOUNT][]uint{},
+		NumRecordsScan:   [media.ClassCOUNT][]uint{},
+		NumRecordsUpdate: [media.ClassCOUNT][]uint{},
+		Batch:            [media.ClassCOUNT][]*Batch{},
+		diskBufferSize:   cfg.DiskBufferSize,
+		timeCreated:      timeCreated,
+		lock:             lock,
+		overlay:          overlay,
+	}
+
+	// initialize the backing data store by creating the required collections;
+	// returns to the caller any error it may have encountered.
+	if ok, ret := base.initialize(); !ok {
+		store.Close()
+		lock.Release()
+		return nil, ret
+	}
+
+	// no errors caused an early return, so return the new struct object and a
+	// nil ReturnCode to indicate success.
+	return base, nil
+}
+
+// function configureTiedot() verifies the configuration file of the tiedot
+// database in the directory at the given path agrees with the given Config, or
+// writes the file if it doesn't exist yet, i.e. the database is new.
+func configureTiedot(cfg *Config, abs, dat, path, sum string) *rc.ReturnCode {
+
+	// configure the database based on the given Config struct -- this may be
+	// user-provided values, default values, or a combination of the two; it
+	// depends on whether or not the user overwrote the default values using
+	// their command-line flags.
+	jdc, ret := newJSONDataConfig(cfg)
+	if nil != ret {
+		return ret
+	}
+
+	userDefinedConfig, userOptions := cfg.isProvided(), cfg.Provided
+
+	// check if a config file already exists; i.e. if a config file already
+	// exists, then we assume this library's database has already been
+	// configured (and the library possibly even scanned) sometime in the past.
+	configPath := filepath.Join(path, dataConfigFileName)
+	if exists, _ := goutil.PathExists(configPath); exists {
+
+		// this is a known library, so its database configuration has already
+		// been defined. verify the user isn't trying to change the database
+		// configuration, because our db driver doesn't support reconfiguration
+		// of a populated database.
+		if userDefinedConfig {
+
+			// the user has provided database configuration parameters via
+			// command-line, so we need to compare them against the
+			// configuration file on disk. read the file from disk.
+			jdcPrev := &JSONDataConfig{}
+			dataPrev, err := ioutil.ReadFile(configPath)
+			if nil != err {
+				return rc.DatabaseError.Specf(
+					"NewDatabase(%q, %q): ioutil.ReadFile(%q): %s",
+					abs, dat, configPath, err)
+			}
+
+			// now unmarshal the file's json string into a configuration struct.
+			if ret := jdcPrev.unmarshal(dataPrev); nil != ret {
+				return ret
+			}
+
+			// construct a string of all of the user's actual command-line
+			// arguments they provided to configure the database.
+			args := make([]string, len(userOptions))
+			for i, str := range userOptions {
+				args[i] = "-" + str
+			}
+			csv := strings.Join(args, ", ")
+
+			// verify the arguments are the same as the database configuration.
+			// if they are not the same, bail out with an insanely long and
+			// verbose reason and instructions to remedy the situation.
+			// note that this is a limitation of the current database driver
+			// "tiedot". if another database is used, be sure to revisit this.
+			if equals, _ := jdc.equals(jdcPrev); !equals {
+				logs.Error.Logf(
+					"you must delete the current database (%q) and rescan the "+
+						"library to use a different database configuration. "+
+						"otherwise, please remove one or more of the "+
+						"following command-line options: %s", path, csv)
+				return rc.DatabaseError.Specf(
+					"cannot reconfigure the storage/performance parameters " +
+						"of an existing library database. one or more " +
+						"command-line options provided are not compatible " +
+						"with the current database configuration.")
+			}
+
+			// if we didn't die in the previous conditional, then the options
+			// the user provided are the same as the current configuration.
+			logs.Warn.Verbosef(
+				"database already configured, ignoring redundant "+
+					"command-line options: %s", csv)
+		}
+
+	} else {
+
+		// this is an unknown library. we are creating the database for the
+		// first time and so need a database configuration file in json format
+		// written to the database directory.
+
+		// marshal the configuration struct into a json string for writing into
+		// the config file which is read by and used by the tiedot runtime.
+		data, ret := jdc.marshal(true)
+		if nil != ret {
+			return ret
+		}
+
+		// flush the formatted json string to the config file on disk. this is
+		// the permanent configuration used by the database runtime from now on
+		// and cannot be changed.
+		if err := ioutil.WriteFile(configPath, data, dataConfigFilePerms); nil != err {
+			return rc.DatabaseError.Specf(
+				"NewDatabase(%q, %q): ioutil.WriteFile(%q, %s, %d): %s",
+				abs, dat, configPath, data, dataConfigFilePerms, err)
+		}
+
+		// notify the user if the database configuration written to file came
+		// from the user's command-line options or the hard-coded defaults.
+		if userDefinedConfig {
+			logs.Info.Tracef(
+				"created database configuration file with user-defined options: %q (%s)",
+				dataConfigFileName, sum)
+		} else {
+			logs.Info.Tracef(
+				"created database configuration file with default options: %q (%s)",
+				dataConfigFileName, sum)
+		}
+	}
+
+	return nil
+}
+
+// function String() creates a string representation of the Database for easy
+// identification in logs.
+func (d *Database) String() string {
+	return fmt.Sprintf("{%q,%s}", d.dataDir, d.name)
+}
+
+// function Name() returns the name of the database directory, a checksum of
+// the library's path, which identifies the library among all others.
+func (d *Database) Name() string { return d.name }
+
+// function TotalRecordsString() constructs a human-readable string describing
+// the total number of entity records (as indicated by the Database object's
+// counter fields) of a given class c and kind k. if class and/or kind is a
+// negative value, then include all classes and/or kinds, respectively.
+// also returned is the total sum, indiscriminated by class or kind.
+func (d *Database) TotalRecordsString(m DiscoveryMethod, c int, k int) (uint, string) {
+
+	var numRecords *[media.ClassCOUNT][]uint
+	switch m {
+	case MethodLoad:
+		numRecords = &d.NumRecordsLoad
+	case MethodScan:
+		numRecords = &d.NumRecordsScan
+	case MethodUpdate:
+		numRecords = &d.NumRecordsUpdate
+	default:
+		return 0, ""
+	}
+
+	total := uint(0)
+	desc := ""
+	for class, count := range *numRecords {
+		if !(int(c) == class || c < 0) {
+			continue
+		}
+		for kind, name := range d.ColName[class] {
+			if !(int(k) == kind || k < 0) {
+				continue
+			}
+			if count[kind] > 0 {
+				total += count[kind]
+				if len(desc) > 0 {
+					desc = fmt.Sprintf("%s, ", desc)
+				}
+				desc = fmt.Sprintf("%s%d %s", desc, count[kind], strings.ToLower(name))
+			}
+		}
+	}
+	return total, desc
+}
+
+// variable recordClassName names each class of entity in the counts returned
+// by RecordCounts().
+var recordClassName = [media.ClassCOUNT]string{
+	"media",    // 0 = ClassMedia
+	"support",  // 1 = ClassSupport
+	"playlist", // 2 = ClassPlaylist
+	"series",   // 3 = ClassSeries
+}
+
+// function RecordCounts() returns the number of entity records (as indicated
+// by the Database object's counter fields) of each kind discovered by the
+// given method, keyed by the name of its class and then by the name of its
+// collection, in lower case, e.g. counts["media"]["video"]. the kinds of which
+// no records were discovered are omitted.
+func (d *Database) RecordCounts(m DiscoveryMethod) map[string]map[string]uint {
+
+	var numRecords *[media.ClassCOUNT][]uint
+	switch m {
+	case MethodLoad:
+		numRecords = &d.NumRecordsLoad
+	case MethodScan:
+		numRecords = &d.NumRecordsScan
+	case MethodUpdate:
+		numRecords = &d.NumRecordsUpdate
+	default:
+		return nil
+	}
+
+	counts := map[string]map[string]uint{}
+	for class, count := range *numRecords {
+		for kind, name := range d.ColName[class] {
+			if count[kind] > 0 {
+				if nil == counts[recordClassName[class]] {
+					counts[recordClassName[class]] = map[string]uint{}
+				}
+				counts[recordClassName[class]][strings.ToLower(name)] = count[kind]
+			}
+		}
+	}
+	return counts
+}
+
+// function ReadOnly() returns true if the database is never written to, its
+// changes kept in memory and discarded once closed.
+func (d *Database) ReadOnly() bool {
+	return nil != d.overlay
+}
+
+// function Changes() returns the changes made to each collection of a
+// read-only database (see ReadOnly()) that has any, keyed by its name, which
+// would have been written otherwise. returns nil if the database isn't
+// read-only.
+func (d *Database) Changes() map[string]engine.Changes {
+	if nil == d.overlay {
+		return nil
+	}
+	return d.overlay.Changes()
+}
+
+// function Close() closes the backing data store. returns true on success, and
+// returns false with a diagnostic ReturnCode on failure.
+func (d *Database) Close() (bool, *rc.ReturnCode) {
+
+	if ret := d.Flush(); nil != ret {
+		logs.Warn.Log(ret)
+	}
+	err := d.store.Close()
+	if ret := d.lock.Release(); nil != ret {
+		logs.Warn.Log(ret)
+	}
+	if nil != err {
+		return false, rc.DatabaseError.Specf("Close(%s): %s", d, err)
+	}
+	return true, nil
+}
+
+// function Sync() flushes every change made to the backing data store to disk,
+// e.g. the partial results of an interrupted scan, including the inserts still
+// buffered (see type Batch).
+func (d *Database) Sync() *rc.ReturnCode {
+
+	if ret := d.Flush(); nil != ret {
+		return ret
+	}
+	if err := d.store.Sync(); nil != err {
+		return rc.DatabaseError.Specf("Sync(%s): %s", d, err)
+	}
+	return nil
+}
+
+// function IsFirstAppearance() inspects this Database's timeCreated field to
+// determine if the data store was just created for the first time during this
+// invocation of the program. the timeCreated (time.Time) field remains its
+// initial zero-value if the Database already existed on disk and we are loading
+// from it before we begin to (potentially) populate it.
+func (d *Database) IsFirstAppearance() bool {
+	return !d.timeCreated.IsZero()
+}
+
+// function initialize() creates the required collections in the backing data
+// store. returns true on success, and returns false with a diagnostic
+// ReturnCode on failure.
+func (d *Database) initialize() (bool, *rc.ReturnCode) {
+
+	for class := media.EntityClass(0); class < media.ClassCOUNT; class++ {
+
+		// create each of the collection slices, copying items as needed.
+		numCol := len(media.EntityColName[class])
+		d.Col[class] = make([]engine.Collection, numCol)
+		d.ColName[class] = make([]string, numCol)
+		d.NumRecordsLoad[class] = make([]uint, numCol)
+		d.NumRecordsScan[class] = make([]uint, numCol)
+		d.NumRecordsUpdate[class] = make([]uint, numCol)
+		d.Batch[class] = make([]*Batch, numCol)
+		copy(d.ColName[class], media.EntityColName[class])
+
+		// create each of the index slices, copying items as needed.
+		numIndex := len(media.EntityIndexes[class])
+		d.Index[class] = make([]*media.EntityIndex, numIndex)
+		copy(d.Index[class], media.EntityIndexes[class])
+
+		// iterate over all required collection names
+		for kind, name := range d.ColName[class] {
+			// verify it is available
+			existed := d.store.ColExists(name)
+			if !existed {
+				// otherwise, collection doesn't exist -- create it
+				if err := d.store.Create(name); nil != err {
+					return false, rc.DatabaseError.Specf(
+						"initialize(): %s: Create(%q): %s", d, name, err)
+				}
+				logs.Info.Tracef("created database collection: %q (%s)", name, d.name)
+			}
+
+			// keep a reference to the collection handler
+			d.Col[class][kind] = d.store.Use(name)
+			d.Batch[class][kind] = newBatch(d, class, kind, d.diskBufferSize)
+
+			// install all class indices missing from the collection, i.e. all of
+			// them if newly created, or those added since it was created.
+			have := map[string]bool{}
+			for _, idx := range d.Col[class][kind].AllIndexes() {
+				have[strings.Join(idx, indexPathSep)] = true
+			}
+			for _, idx := range d.Index[class] {
+				if have[strings.Join(*idx, indexPathSep)] {
+					continue
+				}
+				if err := d.Col[class][kind].Index(*idx); nil != err {
+					return false, rc.DatabaseError.Specf(
+						"initialize(): %s: Index(%q): %s", d, name, err)
+				}
+				if existed {
+					logs.Info.Tracef("indexed database collection: %q by %v (%s)", name, *idx, d.name)
+				}
+			}
+		}
+	}
+
+	// the quarantine collection is not associated with any entity class, and
+	// its records are never indexed. it only needs to exist.
+	if !d.store.ColExists(quarantineColName) {
+		if err := d.store.Create(quarantineColName); nil != err {
+			return false, rc.DatabaseError.Specf(
+				"initialize(): %s: Create(%q): %s", d, quarantineColName, err)
+		}
+		logs.Info.Tracef("created database collection: %q (%s)", quarantineColName, d.name)
+	}
+	d.QuarantineCol = d.store.Use(quarantineColName)
+
+	// likewise for the orphaned collection.
+	if !d.store.ColExists(orphanColName) {
+		if err := d.store.Create(orphanColName); nil != err {
+			return false, rc.DatabaseError.Specf(
+				"initialize(): %s: Create(%q): %s", d, orphanColName, err)
+		}
+		logs.Info.Tracef("created database collection: %q (%s)", orphanColName, d.name)
+	}
+	d.OrphanCol = d.store.Use(orphanColName)
+
+	// likewise for the journal collection.
+	if !d.store.ColExists(journalColName) {
+		if err := d.store.Create(journalColName); nil != err {
+			return false, rc.DatabaseError.Specf(
+				"initialize(): %s: Create(%q): %s", d, journalColName, err)
+		}
+		logs.Info.Tracef("created database collection: %q (%s)", journalColName, d.name)
+	}
+	d.JournalCol = d.store.Use(journalColName)
+
+	return true, nil
+}
+
+// function Quarantine() moves a record that could not be parsed out of
+// its collection and into the quarantine collection, recording the reason it
+// was removed. the record is deleted from its source collection only if it was
+// successfully inserted into the quarantine collection.
+func (d *Database) Quarantine(class media.EntityClass, kind int, id int, data []byte, reason string) *rc.ReturnCode {
+
+	rec := map[string]interface{}{}
+	qr := &QuarantineRecord{
+		Class:      class,
+		Kind:       kind,
+		Collection: d.ColName[class][kind],
+		ID:         id,
+		Reason:     reason,
+		Data:       string(data),
+		Time:       time.Now(),
+	}
+
+	enc, err := json.Marshal(qr)
+	if nil == err {
+		err = json.Unmarshal(enc, &rec)
+	}
+	if nil != err {
+		return rc.InvalidJSONData.Specf(
+			"Quarantine(%s, %d): cannot convert quarantine record: %s", d, id, err)
+	}
+
+	if _, err := d.QuarantineCol.Insert(rec); nil != err {
+		return rc.DatabaseError.Specf(
+			"Quarantine(%s, %d): failed to insert record: %s", d, id, err)
+	}
+	if err := d.Col[class][kind].Delete(id); nil != err {
+		return rc.DatabaseError.Specf(
+			"Quarantine(%s, %d): failed to delete record: %s", d, id, err)
+	}
+	return nil
+}
+
+// function Orphan() moves the record of a file that no longer exists out of its
+// collection and into the orphaned collection. the original record is deleted
+// only if it was successfully inserted into the orphaned collection.
+func (d *Database) Orphan(class media.EntityClass, kind int, id int, absPath string, data []byte) *rc.ReturnCode {
+
+	rec := map[string]interface{}{}
+	or := &OrphanRecord{
+		Class:      class,
+		Kind:       kind,
+		Collection: d.ColName[class][kind],
+		ID:         id,
+		AbsPath:    absPath,
+		Data:       string(data),
+		Time:       time.Now(),
+	}
+
+	enc, err := json.Marshal(or)
+	if nil == err {
+		err = json.Unmarshal(enc, &rec)
+	}
+	if nil != err {
+		return rc.InvalidJSONData.Specf(
+			"Orphan(%s, %d): cannot convert orphan record: %s", d, id, err)
+	}
+
+	if _, err := d.OrphanCol.Insert(rec); nil != err {
+		return rc.DatabaseError.Specf(
+			"Orphan(%s, %d): failed to insert record: %s", d, id, err)
+	}
+	if err := d.Col[class][kind].Delete(id); nil != err {
+		return rc.DatabaseError.Specf(
+			"Orphan(%s, %d): failed to delete record: %s", d, id, err)
+	}
+	return nil
+}
+
+// function Journal() records the given change to a file in the journal
+// collection, from which it may be reverted.
+func (d *Database) Journal(jr *JournalRecord) *rc.ReturnCode {
+
+	rec := map[string]interface{}{}
+	enc, err := json.Marshal(jr)
+	if nil == err {
+		err = json.Unmarshal(enc, &rec)
+	}
+	if nil != err {
+		return rc.InvalidJSONData.Specf(
+			"Journal(%s, %q): cannot convert journal record: %s", d, jr.AbsPath, err)
+	}
+	if _, err := d.JournalCol.Insert(rec); nil != err {
+		return rc.DatabaseError.Specf(
+			"Journal(%s, %q): failed to insert record: %s", d, jr.AbsPath, err)
+	}
+	return nil
+}
+
+// function Scrub() fixes corrupt records and defragments disk space used by the
+// database -- performed on all collections in the database.
+func (d *Database) Scrub() {
+
+	if ret := d.Flush(); nil != ret {
+		logs.Warn.Log(ret)
+	}
+	for class, col := range d.Col {
+		for kind, name := range d.ColName[class] {
+			if d.store.ColExists(name) {
+				d.store.Scrub(name)
+			}
+			// after Scrub(), tiedot has potentially reallocated space elsewhere and
+			// the reference is probably no longer valid.
+			col[kind] = d.store.Use(name)
+		}
+	}
+	if d.store.ColExists(quarantineColName) {
+		d.store.Scrub(quarantineColName)
+	}
+	d.QuarantineCol = d.store.Use(quarantineColName)
+	if d.store.ColExists(orphanColName) {
+		d.store.Scrub(orphanColName)
+	}
+	d.OrphanCol = d.store.Use(orphanColName)
+	if d.store.ColExists(journalColName) {
+		d.store.Scrub(journalColName)
+	}
+	d.JournalCol = d.store.Use(journalColName)
+}
@@ -0,0 +1,210 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: export.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    exports every record of a library's database to a portable JSON document,
+//    and imports such a document into another database.
+//
+// =============================================================================
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/engine"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for exported databases.
+const (
+	exportFormat  = "pimmp-db" // identifies documents written by Export()
+	exportVersion = 1          // version of the document's structure
+)
+
+// type Export is the portable JSON document to which a database is exported.
+// unlike a backup (see Backup()), it is independent of the database engine's
+// files, so it can be read by other versions of pimmp, other programs, or
+// people.
+type Export struct {
+	Format      string                       // always "pimmp-db"
+	Version     int                          // version of the document's structure
+	Library     string                       // absolute path to the library exported
+	Time        time.Time                    // date the database was exported
+	Collections map[string][]json.RawMessage // unmodified records of each collection, by name
+}
+
+// function collections() returns every collection of the database, by name,
+// including the quarantine, orphaned, and journal collections.
+func (d *Database) collections() map[string]engine.Collection {
+	cols := map[string]engine.Collection{
+		quarantineColName: d.QuarantineCol,
+		orphanColName:     d.OrphanCol,
+		journalColName:    d.JournalCol,
+	}
+	for class, names := range d.ColName {
+		for kind, name := range names {
+			cols[name] = d.Col[class][kind]
+		}
+	}
+	return cols
+}
+
+// function Export() writes every record of every collection of the database
+// to the given writer as a single, indented JSON document (see type Export).
+// the database must not be modified while it is exported.
+func (d *Database) Export(w io.Writer) *rc.ReturnCode {
+
+	doc := &Export{
+		Format:      exportFormat,
+		Version:     exportVersion,
+		Library:     d.libPath,
+		Time:        time.Now(),
+		Collections: map[string][]json.RawMessage{},
+	}
+	for name, col := range d.collections() {
+		recs := []json.RawMessage{}
+		col.ForEachDoc(func(id int, data []byte) (willMoveOn bool) {
+			recs = append(recs, append(json.RawMessage{}, data...))
+			return true // move on to next record
+		})
+		doc.Collections[name] = recs
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); nil != err {
+		return rc.ExportError.Specf("Export(%s): %s", d, err)
+	}
+	return nil
+}
+
+// function Import() inserts the records of the JSON document read from the
+// given reader, as written by Export(), into the collections of the same
+// names, returning the number of records inserted. records of collections the
+// database doesn't have are skipped. the database must be empty, unless
+// replace is true, in which case all of its records are deleted first.
+//
+// if the document was exported from a library at another path, e.g. on
+// another machine, the paths of the files within it are changed to the same
+// files within this database's library.
+func (d *Database) Import(r io.Reader, replace bool) (uint, *rc.ReturnCode) {
+
+	doc := &Export{}
+	if err := json.NewDecoder(r).Decode(doc); nil != err {
+		return 0, rc.ImportError.Specf("Import(%s): %s", d, err)
+	}
+	if exportFormat != doc.Format || doc.Version < 1 || doc.Version > exportVersion {
+		return 0, rc.ImportError.Specf(
+			"Import(%s): unsupported document: %q (version %d)", d, doc.Format, doc.Version)
+	}
+
+	cols := d.collections()
+	for name, col := range cols {
+		ids := []int{}
+		col.ForEachDoc(func(id int, _ []byte) (willMoveOn bool) {
+			ids = append(ids, id)
+			return true // move on to next record
+		})
+		if len(ids) > 0 && !replace {
+			return 0, rc.ImportError.Specf(
+				"Import(%s): database not empty: collection %q has %d records", d, name, len(ids))
+		}
+		// records are deleted only once the engine has finished walking them.
+		for _, id := range ids {
+			if err := col.Delete(id); nil != err {
+				return 0, rc.DatabaseError.Specf(
+					"Import(%s): failed to delete record (ID={%q,%X}): %s", d, name, id, err)
+			}
+		}
+	}
+
+	var count uint = 0
+	for name, recs := range doc.Collections {
+		col, ok := cols[name]
+		if !ok {
+			logs.Warn.Verbosef("skipping unknown collection %q (%d records)", name, len(recs))
+			continue
+		}
+		for _, data := range recs {
+			rec, err := decodeRecord(data)
+			if nil != err {
+				return count, rc.ImportError.Specf("Import(%s): %q: %s", d, name, err)
+			}
+			if "" != doc.Library && d.libPath != doc.Library {
+				rebaseRecord(rec, doc.Library, d.libPath)
+			}
+			if _, err := col.Insert(rec); nil != err {
+				return count, rc.DatabaseError.Specf(
+					"Import(%s): failed to insert record into %q: %s", d, name, err)
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// function decodeRecord() decodes the given record data. numbers are decoded
+// verbatim (as json.Number), so that integers too large for a float64, e.g.
+// inode numbers and durations, are stored again unchanged.
+func decodeRecord(data []byte) (map[string]interface{}, error) {
+	rec := map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&rec); nil != err {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// function rebaseRecord() changes every path within the library at path from
+// found in the given record to the same path within the library at path to.
+// the quarantine and orphaned collections keep the original records as JSON
+// text in their field "Data", which is changed likewise.
+func rebaseRecord(rec map[string]interface{}, from, to string) {
+	for key, val := range rec {
+		if s, ok := val.(string); ok && "Data" == key && strings.HasPrefix(s, "{") {
+			if data, err := decodeRecord([]byte(s)); nil == err {
+				rebaseRecord(data, from, to)
+				if enc, err := json.Marshal(data); nil == err {
+					rec[key] = string(enc)
+					continue
+				}
+			}
+		}
+		rec[key] = rebaseValue(val, from, to)
+	}
+}
+
+// function rebaseValue() returns the given decoded JSON value with every path
+// within the library at path from changed to the same path within the library
+// at path to, searching objects and arrays recursively.
+func rebaseValue(val interface{}, from, to string) interface{} {
+	switch v := val.(type) {
+	case string:
+		if v == from {
+			return to
+		}
+		if strings.HasPrefix(v, from) {
+			if sep := v[len(from)]; '/' == sep || filepath.Separator == sep {
+				return to + v[len(from):]
+			}
+		}
+	case map[string]interface{}:
+		rebaseRecord(v, from, to)
+	case []interface{}:
+		for i := range v {
+			v[i] = rebaseValue(v[i], from, to)
+		}
+	}
+	return val
+}
@@ -0,0 +1,71 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: info.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    records the properties of each library that persist with its database,
+//    regardless of how the library is opened.
+//
+// =============================================================================
+
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the library info.
+const (
+	infoFileName = "library.json"
+)
+
+// type Info contains the properties of a library recorded in its database.
+type Info struct {
+	Type string `json:",omitempty"` // type of library, i.e. the kinds of media it contains (empty = any)
+}
+
+// function Info() returns the properties of the library recorded in the
+// database, which are all empty if none were ever recorded.
+func (d *Database) Info() (*Info, *rc.ReturnCode) {
+
+	path := filepath.Join(d.absPath, infoFileName)
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		if os.IsNotExist(err) {
+			return &Info{}, nil
+		}
+		return nil, rc.DatabaseError.Specf("Info(): ioutil.ReadFile(%q): %s", path, err)
+	}
+	info := &Info{}
+	if err := json.Unmarshal(data, info); nil != err {
+		return nil, rc.InvalidJSONData.Specf("Info(): json.Unmarshal(%q): %s", path, err)
+	}
+	return info, nil
+}
+
+// function SetInfo() records the given properties of the library in the
+// database, replacing those recorded before. a read-only database (see
+// ReadOnly()) is left unchanged.
+func (d *Database) SetInfo(info *Info) *rc.ReturnCode {
+
+	if d.ReadOnly() {
+		return nil
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if nil != err {
+		return rc.InvalidJSONData.Specf("SetInfo(): json.MarshalIndent(): %s", err)
+	}
+	path := filepath.Join(d.absPath, infoFileName)
+	if err := ioutil.WriteFile(path, data, dataConfigFilePerms); nil != err {
+		return rc.DatabaseError.Specf("SetInfo(): ioutil.WriteFile(%q): %s", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: junk.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    records the files found by the most recent scan of each library that are
+//    neither media nor support files, so that the clutter left beside media
+//    (e.g. release notes, thumbnails, and partial downloads) can be reviewed
+//    and cleaned up.
+//
+// =============================================================================
+
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the junk report.
+const (
+	junkFileName   = "junk.json"
+	maxJunkEntries = 10000 // number of files retained of each scan
+)
+
+// type JunkEntry describes a single file found by a scan that is neither media
+// nor a support file.
+type JunkEntry struct {
+	Path string // file, relative to the library
+	Ext  string // file name extension, in lower case (empty if none)
+	Size int64  // size of the file (in bytes)
+}
+
+// type JunkReport lists the files found by the most recent scan of a library
+// that are neither media nor support files.
+type JunkReport struct {
+	Time    time.Time   // time at which the scan finished
+	Entries []JunkEntry // files in order of discovery
+	Omitted int         // number of files beyond maxJunkEntries, not listed
+}
+
+// function JunkReport() returns the files recorded in the database, which is an
+// empty report if the library was never scanned since.
+func (d *Database) JunkReport() (*JunkReport, *rc.ReturnCode) {
+
+	path := filepath.Join(d.absPath, junkFileName)
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		if os.IsNotExist(err) {
+			return &JunkReport{}, nil
+		}
+		return nil, rc.DatabaseError.Specf("JunkReport(): ioutil.ReadFile(%q): %s", path, err)
+	}
+	report := &JunkReport{}
+	if err := json.Unmarshal(data, report); nil != err {
+		return nil, rc.InvalidJSONData.Specf("JunkReport(): json.Unmarshal(%q): %s", path, err)
+	}
+	return report, nil
+}
+
+// function SetJunkReport() replaces the files recorded in the database with the
+// given report. only the first maxJunkEntries files are retained. a read-only
+// database (see ReadOnly()) is left unchanged.
+func (d *Database) SetJunkReport(r *JunkReport) *rc.ReturnCode {
+
+	if d.ReadOnly() {
+		return nil
+	}
+	if len(r.Entries) > maxJunkEntries {
+		r.Omitted += len(r.Entries) - maxJunkEntries
+		r.Entries = r.Entries[:maxJunkEntries]
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if nil != err {
+		return rc.InvalidJSONData.Specf("SetJunkReport(): json.MarshalIndent(): %s", err)
+	}
+	path := filepath.Join(d.absPath, junkFileName)
+	if err := ioutil.WriteFile(path, data, dataConfigFilePerms); nil != err {
+		return rc.DatabaseError.Specf("SetJunkReport(): ioutil.WriteFile(%q): %s", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: query.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    finds records by the values of their indexed fields, identified by name
+//    rather than by the position of their index.
+//
+// =============================================================================
+
+package storage
+
+import (
+	"fmt"
+
+	"ardnew.com/pimmp/pkg/media"
+)
+
+// function IndexOf() returns the index of the collections of the given class
+// on the struct field with the given name, or nil if the field isn't indexed,
+// i.e. it isn't tagged with `db:"index"` (see media.EntityIndexes).
+func (d *Database) IndexOf(class media.EntityClass, field string) *media.EntityIndex {
+
+	if class <= media.ClassUnknown || class >= media.ClassCOUNT {
+		return nil
+	}
+	for _, idx := range d.Index[class] {
+		if 1 == len(*idx) && field == (*idx)[0] {
+			return idx
+		}
+	}
+	return nil
+}
+
+// function Find() returns the IDs of the records in the collection of the
+// given class and kind having any of the given values in the indexed struct
+// field with the given name.
+func (d *Database) Find(class media.EntityClass, kind int, field string, values ...string) (map[int]struct{}, error) {
+
+	idx := d.IndexOf(class, field)
+	if nil == idx {
+		return nil, fmt.Errorf("Find(%s): field not indexed: %q (class %d)", d, field, int(class))
+	}
+	if kind < 0 || kind >= len(d.Col[class]) {
+		return nil, fmt.Errorf("Find(%s): unrecognized kind: %d (class %d)", d, kind, int(class))
+	}
+	return d.Col[class][kind].Find(*idx, values...)
+}
+
+// function QueryBy() returns the IDs of the records in every collection of the
+// given class having any of the given values in the indexed struct field with
+// the given name, indexed by the kind of their collection.
+func (d *Database) QueryBy(class media.EntityClass, field string, values ...string) ([]map[int]struct{}, error) {
+
+	if class <= media.ClassUnknown || class >= media.ClassCOUNT {
+		return nil, fmt.Errorf("QueryBy(%s): unrecognized class: %d", d, int(class))
+	}
+	result := make([]map[int]struct{}, len(d.Col[class]))
+	for kind := range d.Col[class] {
+		ids, err := d.Find(class, kind, field, values...)
+		if nil != err {
+			return nil, err
+		}
+		result[kind] = ids
+	}
+	return result, nil
+}
+
+// function FindOne() returns the ID of a record in the collection of the given
+// class and kind having the given value in the indexed struct field with the
+// given name, and whether or not any such record exists. if several do, the
+// one returned is unspecified.
+func (d *Database) FindOne(class media.EntityClass, kind int, field string, value string) (int, bool, error) {
+
+	result, err := d.Find(class, kind, field, value)
+	if nil != err {
+		return -1, false, err
+	}
+	for id := range result {
+		return id, true, nil
+	}
+	return -1, false, nil
+}
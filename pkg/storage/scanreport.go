@@ -0,0 +1,117 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: scanreport.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    records the problems encountered by the most recent load and scan of each
+//    library, so that they can be reviewed after the fact rather than picked
+//    out of the log.
+//
+// =============================================================================
+
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the scan report.
+const (
+	scanReportFileName = "report.json"
+	maxReportEntries   = 5000 // number of problems retained of each load or scan
+)
+
+// type ReportEntry describes a single problem, i.e. a non-fatal ReturnCode,
+// encountered while loading or scanning a library.
+type ReportEntry struct {
+	Path    string // file concerned, relative to the library (empty if unknown)
+	Code    int    // return code of the problem
+	Message string // description of the problem
+}
+
+// type ReportSection lists the problems encountered by a single load or scan.
+type ReportSection struct {
+	Time    time.Time     // time at which the load or scan finished
+	Entries []ReportEntry // problems in order of occurrence
+	Omitted int           // number of problems beyond maxReportEntries, not listed
+}
+
+// type ScanReport lists the problems encountered by the most recent load and
+// scan of a library.
+type ScanReport struct {
+	Load ReportSection
+	Scan ReportSection
+}
+
+// function Len() returns the number of problems in the report, including those
+// omitted.
+func (r *ScanReport) Len() int {
+	return len(r.Load.Entries) + r.Load.Omitted + len(r.Scan.Entries) + r.Scan.Omitted
+}
+
+// function ScanReport() returns the problems recorded in the database, which
+// is an empty report if the library was never loaded or scanned since.
+func (d *Database) ScanReport() (*ScanReport, *rc.ReturnCode) {
+
+	path := filepath.Join(d.absPath, scanReportFileName)
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		if os.IsNotExist(err) {
+			return &ScanReport{}, nil
+		}
+		return nil, rc.DatabaseError.Specf("ScanReport(): ioutil.ReadFile(%q): %s", path, err)
+	}
+	report := &ScanReport{}
+	if err := json.Unmarshal(data, report); nil != err {
+		return nil, rc.InvalidJSONData.Specf("ScanReport(): json.Unmarshal(%q): %s", path, err)
+	}
+	return report, nil
+}
+
+// function SetReportSection() replaces the problems of the load (MethodLoad) or
+// scan (MethodScan) recorded in the database with the given section, leaving
+// the other as it was. only the first maxReportEntries problems are retained.
+// a read-only database (see ReadOnly()) is left unchanged.
+func (d *Database) SetReportSection(m DiscoveryMethod, s ReportSection) *rc.ReturnCode {
+
+	if d.ReadOnly() {
+		return nil
+	}
+	report, ret := d.ScanReport()
+	if nil != ret {
+		// a damaged report is superseded by the next anyway, start a new one.
+		logs.Warn.Log(ret)
+		report = &ScanReport{}
+	}
+	if len(s.Entries) > maxReportEntries {
+		s.Omitted += len(s.Entries) - maxReportEntries
+		s.Entries = s.Entries[:maxReportEntries]
+	}
+	switch m {
+	case MethodLoad:
+		report.Load = s
+	case MethodScan:
+		report.Scan = s
+	default:
+		return rc.InvalidArgs.Specf("SetReportSection(): invalid discovery method: %d", int(m))
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if nil != err {
+		return rc.InvalidJSONData.Specf("SetReportSection(): json.MarshalIndent(): %s", err)
+	}
+	path := filepath.Join(d.absPath, scanReportFileName)
+	if err := ioutil.WriteFile(path, data, dataConfigFilePerms); nil != err {
+		return rc.DatabaseError.Specf("SetReportSection(): ioutil.WriteFile(%q): %s", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: session.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    records the scan sessions of each library, so that the media discovered
+//    by recent scans can be distinguished from the rest.
+//
+// =============================================================================
+
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the scan session log.
+const (
+	sessionFileName = "sessions.json"
+	maxSessions     = 32 // number of most recent sessions retained
+)
+
+// type Session records a single completed scan of a library.
+type Session struct {
+	Start time.Time // time at which the scan began
+	Stop  time.Time // time at which the scan finished
+	Found uint      // number of new records created by the scan
+
+	Files   uint // number of regular files examined by the scan
+	Records uint // number of records in the database once the scan finished
+}
+
+// function Sessions() returns the scan sessions recorded in the database, the
+// most recent first.
+func (d *Database) Sessions() ([]Session, *rc.ReturnCode) {
+
+	path := filepath.Join(d.absPath, sessionFileName)
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		if os.IsNotExist(err) {
+			return []Session{}, nil
+		}
+		return nil, rc.DatabaseError.Specf("Sessions(): ioutil.ReadFile(%q): %s", path, err)
+	}
+	list := []Session{}
+	if err := json.Unmarshal(data, &list); nil != err {
+		return nil, rc.InvalidJSONData.Specf("Sessions(): json.Unmarshal(%q): %s", path, err)
+	}
+	return list, nil
+}
+
+// function AddSession() records the given scan session in the database. only
+// the most recent sessions are retained. a read-only database (see ReadOnly())
+// is left unchanged.
+func (d *Database) AddSession(s Session) *rc.ReturnCode {
+
+	if d.ReadOnly() {
+		return nil
+	}
+	list, ret := d.Sessions()
+	if nil != ret {
+		// a damaged log is only of historical interest, start a new one.
+		logs.Warn.Log(ret)
+		list = []Session{}
+	}
+	list = append([]Session{s}, list...)
+	if len(list) > maxSessions {
+		list = list[:maxSessions]
+	}
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if nil != err {
+		return rc.InvalidJSONData.Specf("AddSession(): json.MarshalIndent(): %s", err)
+	}
+	path := filepath.Join(d.absPath, sessionFileName)
+	if err := ioutil.WriteFile(path, data, dataConfigFilePerms); nil != err {
+		return rc.DatabaseError.Specf("AddSession(): ioutil.WriteFile(%q): %s", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: snapshot.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    saves named snapshots of a library's records, and compares two of them to
+//    find the records added, removed, and changed in between.
+//
+// =============================================================================
+
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the snapshot files.
+const (
+	snapshotDirName = "snapshots" // subdirectory of the database directory
+	snapshotFileExt = ".json"
+)
+
+// snapshotIgnore lists the record fields not retained in snapshots, since they
+// are either derived from the record's path or of no interest to anyone.
+var snapshotIgnore = []string{
+	"AbsPath", "AbsDir", "AbsName", "AbsBase", "RelPath", "SysInfo",
+}
+
+// type Snapshot is the state of every record of a library at a point in time.
+type Snapshot struct {
+	Name    string                        // unique name of the snapshot
+	Taken   time.Time                     // time at which the snapshot was taken
+	Records map[string]media.EntityRecord // records keyed by library-relative path
+}
+
+// type SnapshotDiff describes the differences between two snapshots of the
+// same library, each list sorted by path.
+type SnapshotDiff struct {
+	Added   []string                // paths of records only in the newer snapshot
+	Removed []string                // paths of records only in the older snapshot
+	Changed map[string][]media.Edit // fields changed of the records in both
+}
+
+// function NewSnapshot() creates an empty Snapshot with the given name, taken
+// now.
+func NewSnapshot(name string) *Snapshot {
+	return &Snapshot{
+		Name:    name,
+		Taken:   time.Now(),
+		Records: map[string]media.EntityRecord{},
+	}
+}
+
+// function Add() adds the given record, of the file at the given path relative
+// to the library, to the Snapshot.
+func (s *Snapshot) Add(relPath string, rec media.EntityRecord) {
+	keep := media.EntityRecord{}
+	for k, v := range rec {
+		keep[k] = v
+	}
+	for _, k := range snapshotIgnore {
+		delete(keep, k)
+	}
+	s.Records[filepath.ToSlash(relPath)] = keep
+}
+
+// function Diff() compares the Snapshot, taken earlier, with the given newer
+// Snapshot.
+func (s *Snapshot) Diff(newer *Snapshot) *SnapshotDiff {
+
+	diff := &SnapshotDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: map[string][]media.Edit{},
+	}
+	for path, was := range s.Records {
+		now, ok := newer.Records[path]
+		if !ok {
+			diff.Removed = append(diff.Removed, path)
+			continue
+		}
+		if edit := media.DiffRecords(&was, &now, newer.Taken); len(edit) > 0 {
+			diff.Changed[path] = edit
+		}
+	}
+	for path := range newer.Records {
+		if _, ok := s.Records[path]; !ok {
+			diff.Added = append(diff.Added, path)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+// function ChangedPaths() returns the paths of the changed records, sorted.
+func (d *SnapshotDiff) ChangedPaths() []string {
+	path := make([]string, 0, len(d.Changed))
+	for p := range d.Changed {
+		path = append(path, p)
+	}
+	sort.Strings(path)
+	return path
+}
+
+// function Empty() returns true if the two snapshots compared are identical.
+func (d *SnapshotDiff) Empty() bool {
+	return 0 == len(d.Added)+len(d.Removed)+len(d.Changed)
+}
+
+// function snapshotPath() returns the path of the file in which the snapshot
+// with the given name is saved, verifying the name is usable as a file name.
+func (d *Database) snapshotPath(name string) (string, *rc.ReturnCode) {
+	if "" == strings.TrimSpace(name) || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", rc.InvalidArgs.Specf("invalid snapshot name: %q", name)
+	}
+	return filepath.Join(d.absPath, snapshotDirName, name+snapshotFileExt), nil
+}
+
+// function SaveSnapshot() saves the given Snapshot in the database. an existing
+// snapshot of the same name is never replaced, nor is a snapshot saved in a
+// read-only database (see ReadOnly()).
+func (d *Database) SaveSnapshot(s *Snapshot) *rc.ReturnCode {
+
+	if d.ReadOnly() {
+		return rc.DatabaseError.Specf("SaveSnapshot(%q): database is read-only", s.Name)
+	}
+	path, ret := d.snapshotPath(s.Name)
+	if nil != ret {
+		return ret
+	}
+	if _, err := os.Stat(path); nil == err {
+		return rc.InvalidArgs.Specf("snapshot already exists: %q", s.Name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); nil != err {
+		return rc.DatabaseError.Specf("SaveSnapshot(): os.MkdirAll(%q): %s", filepath.Dir(path), err)
+	}
+	data, err := json.Marshal(s)
+	if nil != err {
+		return rc.InvalidJSONData.Specf("SaveSnapshot(): json.Marshal(): %s", err)
+	}
+	if err := ioutil.WriteFile(path, data, dataConfigFilePerms); nil != err {
+		return rc.DatabaseError.Specf("SaveSnapshot(): ioutil.WriteFile(%q): %s", path, err)
+	}
+	return nil
+}
+
+// function Snapshot() reads the snapshot with the given name from the
+// database.
+func (d *Database) Snapshot(name string) (*Snapshot, *rc.ReturnCode) {
+
+	path, ret := d.snapshotPath(name)
+	if nil != ret {
+		return nil, ret
+	}
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		if os.IsNotExist(err) {
+			return nil, rc.InvalidArgs.Specf("no such snapshot: %q", name)
+		}
+		return nil, rc.DatabaseError.Specf("Snapshot(): ioutil.ReadFile(%q): %s", path, err)
+	}
+	s := &Snapshot{}
+	if err := json.Unmarshal(data, s); nil != err {
+		return nil, rc.InvalidJSONData.Specf("Snapshot(): json.Unmarshal(%q): %s", path, err)
+	}
+	if nil == s.Records {
+		s.Records = map[string]media.EntityRecord{}
+	}
+	return s, nil
+}
+
+// function Snapshots() returns the snapshots saved in the database, oldest
+// first.
+func (d *Database) Snapshots() ([]*Snapshot, *rc.ReturnCode) {
+
+	dir := filepath.Join(d.absPath, snapshotDirName)
+	info, err := ioutil.ReadDir(dir)
+	if nil != err {
+		if os.IsNotExist(err) {
+			return []*Snapshot{}, nil
+		}
+		return nil, rc.DatabaseError.Specf("Snapshots(): ioutil.ReadDir(%q): %s", dir, err)
+	}
+	list := []*Snapshot{}
+	for _, fi := range info {
+		if fi.IsDir() || snapshotFileExt != filepath.Ext(fi.Name()) {
+			continue
+		}
+		s, ret := d.Snapshot(strings.TrimSuffix(fi.Name(), snapshotFileExt))
+		if nil != ret {
+			return nil, ret
+		}
+		list = append(list, s)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Taken.Before(list[b].Taken) })
+	return list, nil
+}
+
+// function RemoveSnapshot() deletes the snapshot with the given name from the
+// database, unless it is read-only (see ReadOnly()).
+func (d *Database) RemoveSnapshot(name string) *rc.ReturnCode {
+
+	if d.ReadOnly() {
+		return rc.DatabaseError.Specf("RemoveSnapshot(%q): database is read-only", name)
+	}
+	path, ret := d.snapshotPath(name)
+	if nil != ret {
+		return ret
+	}
+	if err := os.Remove(path); nil != err {
+		if os.IsNotExist(err) {
+			return rc.InvalidArgs.Specf("no such snapshot: %q", name)
+		}
+		return rc.DatabaseError.Specf("RemoveSnapshot(): os.Remove(%q): %s", path, err)
+	}
+	return nil
+}
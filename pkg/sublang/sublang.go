@@ -0,0 +1,279 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: sublang.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    identifies the language of subtitle files, from the language code
+//    conventionally appended to their file names (e.g. "Movie.en.srt") or else
+//    by sniffing the words (or script) of their content.
+//
+// =============================================================================
+
+// package sublang identifies the language of subtitle files. languages are
+// given by their ISO 639-1 codes (e.g. "en"), though the ISO 639-2 codes (e.g.
+// "eng" or "ger") and English names (e.g. "english") of the languages known are
+// recognized as well.
+package sublang
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// local unexported constants for sniffing content.
+const (
+	sniffLen      = 64 * 1024 // max number of bytes of a file sniffed
+	sniffMinWords = 20        // min number of words sniffed to identify a language
+	sniffMinHits  = 5         // min number of stopwords of the language identified
+	sniffMinRatio = 1.5       // min ratio of the best language's hits to the runner-up's
+	scriptMinPct  = 30        // min percent of letters in a non-Latin script to identify it
+)
+
+// type language describes a language recognized by name or content.
+type language struct {
+	code  string   // ISO 639-1 code
+	alias []string // ISO 639-2 codes and names, all lowercase
+	stop  []string // frequent short words, identifying the language in text
+}
+
+// variable languages lists every language recognized. only the Latin-script
+// languages are identified by their stopwords; the others are identified by
+// their script (see scripts).
+var languages = []language{
+	{"en", []string{"eng", "english"},
+		[]string{"the", "and", "you", "that", "is", "to", "it", "of", "what", "this", "we", "have", "he", "was", "don't"}},
+	{"es", []string{"spa", "spanish", "espanol", "español", "castellano"},
+		[]string{"que", "de", "no", "la", "el", "es", "y", "en", "lo", "un", "por", "qué", "los", "con", "está"}},
+	{"fr", []string{"fre", "fra", "french", "francais", "français"},
+		[]string{"je", "de", "est", "pas", "le", "vous", "la", "tu", "que", "un", "il", "et", "les", "ce", "c'est"}},
+	{"de", []string{"ger", "deu", "german", "deutsch"},
+		[]string{"ich", "sie", "das", "ist", "du", "nicht", "die", "und", "es", "der", "wir", "was", "zu", "ein", "mit"}},
+	{"it", []string{"ita", "italian", "italiano"},
+		[]string{"che", "non", "di", "il", "è", "la", "un", "sono", "per", "mi", "ho", "hai", "ma", "cosa", "questo"}},
+	{"pt", []string{"por", "portuguese", "portugues", "português", "pob"},
+		[]string{"que", "não", "de", "o", "um", "para", "é", "eu", "se", "me", "uma", "você", "está", "com", "do"}},
+	{"nl", []string{"dut", "nld", "dutch", "nederlands"},
+		[]string{"de", "het", "een", "ik", "je", "niet", "is", "dat", "van", "en", "wat", "we", "zijn", "hij", "maar"}},
+	{"sv", []string{"swe", "swedish", "svenska"},
+		[]string{"jag", "det", "är", "du", "inte", "att", "en", "och", "har", "vi", "på", "för", "med", "han", "vad"}},
+	{"da", []string{"dan", "danish", "dansk"},
+		[]string{"jeg", "det", "er", "du", "ikke", "at", "en", "og", "har", "vi", "på", "for", "med", "han", "hvad"}},
+	{"no", []string{"nor", "nob", "nno", "norwegian", "norsk"},
+		[]string{"jeg", "det", "er", "du", "ikke", "å", "en", "og", "har", "vi", "på", "for", "med", "han", "hva"}},
+	{"fi", []string{"fin", "finnish", "suomi"},
+		[]string{"en", "se", "on", "ja", "ei", "että", "mitä", "hän", "me", "olen", "sinä", "minä", "tämä", "kun", "niin"}},
+	{"pl", []string{"pol", "polish", "polski"},
+		[]string{"nie", "to", "się", "jest", "że", "na", "co", "mnie", "jak", "ale", "tak", "ja", "w", "mi", "czy"}},
+	{"cs", []string{"cze", "ces", "czech", "cesky", "čeština"},
+		[]string{"je", "to", "že", "se", "na", "ne", "jsem", "co", "tak", "jak", "ale", "by", "mi", "už", "tady"}},
+	{"hu", []string{"hun", "hungarian", "magyar"},
+		[]string{"a", "az", "nem", "hogy", "és", "van", "egy", "ez", "meg", "mi", "de", "csak", "már", "is", "ki"}},
+	{"ro", []string{"rum", "ron", "romanian", "română"},
+		[]string{"nu", "să", "și", "în", "de", "ce", "pe", "mă", "este", "un", "am", "asta", "cu", "la", "o"}},
+	{"tr", []string{"tur", "turkish", "türkçe"},
+		[]string{"bir", "bu", "ne", "ve", "için", "sen", "ben", "mi", "değil", "çok", "var", "da", "de", "o", "ama"}},
+	{"ru", []string{"rus", "russian"}, nil},
+	{"el", []string{"gre", "ell", "greek"}, nil},
+	{"ar", []string{"ara", "arabic"}, nil},
+	{"he", []string{"heb", "hebrew"}, nil},
+	{"ja", []string{"jpn", "japanese"}, nil},
+	{"ko", []string{"kor", "korean"}, nil},
+	{"zh", []string{"chi", "zho", "chinese", "chs", "cht"}, nil},
+}
+
+// variable scripts identifies the languages whose content is written in a
+// script of their own, in order of precedence (kana before Han, since Japanese
+// is written in both).
+var scripts = []struct {
+	code  string
+	table *unicode.RangeTable
+}{
+	{"ja", unicode.Hiragana},
+	{"ja", unicode.Katakana},
+	{"ko", unicode.Hangul},
+	{"zh", unicode.Han},
+	{"ru", unicode.Cyrillic},
+	{"el", unicode.Greek},
+	{"ar", unicode.Arabic},
+	{"he", unicode.Hebrew},
+}
+
+// variable flags lists the annotations commonly appended to subtitle file
+// names following the language, e.g. "Movie.en.forced.srt".
+var flags = map[string]bool{
+	"forced": true, "sdh": true, "cc": true, "default": true, "full": true,
+}
+
+// variable textExt lists the file name extensions of the subtitle formats that
+// are plain text, and so can be sniffed.
+var textExt = map[string]bool{
+	".srt": true, ".ass": true, ".ssa": true, ".vtt": true, ".sub": true,
+	".smi": true, ".sami": true, ".txt": true,
+}
+
+// function Normalize() returns the ISO 639-1 code of the given language code
+// or name (ignoring case and any region, e.g. "pt-BR"), or "" if the language
+// isn't recognized.
+func Normalize(lang string) string {
+
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	if "" == lang {
+		return ""
+	}
+	for _, l := range languages {
+		if lang == l.code {
+			return l.code
+		}
+		for _, a := range l.alias {
+			if lang == a {
+				return l.code
+			}
+		}
+	}
+	return ""
+}
+
+// function FromName() returns the language named by the suffix of the given
+// file name (without its extension), e.g. "en" for "Movie.en", "Movie.eng.sdh",
+// or "Movie.pt-BR", or "" if it has none. also returns the name without the
+// suffix, i.e. the name of the video it accompanies. a code in title case
+// (e.g. "Movie.It") is taken to be a word of the title instead.
+func FromName(base string) (string, string) {
+
+	rest := base
+	for {
+		i := strings.LastIndexAny(rest, "._")
+		if i <= 0 {
+			return "", base
+		}
+		suffix := rest[i+1:]
+		if flags[strings.ToLower(suffix)] {
+			rest = rest[:i]
+			continue
+		}
+		if isTitle(suffix) && len(suffix) <= 3 {
+			return "", base
+		}
+		if code := Normalize(suffix); "" != code {
+			return code, rest[:i]
+		}
+		return "", base
+	}
+}
+
+// function isTitle() returns true if the given word is in title case, i.e. an
+// upper case letter followed by lower case.
+func isTitle(word string) bool {
+	r, n := utf8.DecodeRuneInString(word)
+	return unicode.IsUpper(r) && strings.ToUpper(word[n:]) != word[n:]
+}
+
+// function Detect() returns the language of the subtitle file at the given
+// path, from its file name if named, or else by sniffing its content. returns
+// "" if the language can't be identified.
+func Detect(path string) string {
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if code, _ := FromName(base); "" != code {
+		return code
+	}
+	if !textExt[strings.ToLower(filepath.Ext(path))] {
+		return ""
+	}
+	f, err := os.Open(path)
+	if nil != err {
+		return ""
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(f, sniffLen))
+	if nil != err {
+		return ""
+	}
+	return Sniff(data)
+}
+
+// function Sniff() returns the language of the given subtitle text, identified
+// by its script or else by the frequency of the stopwords of each language, or
+// "" if it can't be identified. the text must be UTF-8 (or ASCII); timestamps
+// and markup are ignored, since they contain no letters worth counting.
+func Sniff(data []byte) string {
+
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "" // binary, or UTF-16
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	for !utf8.Valid(data) && len(data) > 0 {
+		// the limit may have cut the last character short.
+		data = data[:len(data)-1]
+	}
+
+	// count the letters of each script.
+	letters := 0
+	count := make([]int, len(scripts))
+	for _, r := range string(data) {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for i, s := range scripts {
+			if unicode.Is(s.table, r) {
+				count[i]++
+				break
+			}
+		}
+	}
+	if 0 == letters {
+		return ""
+	}
+	for i, s := range scripts {
+		if count[i]*100 >= letters*scriptMinPct {
+			return s.code
+		}
+	}
+
+	// and otherwise, the stopwords of each Latin-script language.
+	words := strings.FieldsFunc(strings.ToLower(string(data)), func(r rune) bool {
+		return !unicode.IsLetter(r) && '\'' != r
+	})
+	if len(words) < sniffMinWords {
+		return ""
+	}
+	best, bestHits, nextHits := "", 0, 0
+	for _, l := range languages {
+		if 0 == len(l.stop) {
+			continue
+		}
+		stop := map[string]bool{}
+		for _, w := range l.stop {
+			stop[w] = true
+		}
+		hits := 0
+		for _, w := range words {
+			if stop[w] {
+				hits++
+			}
+		}
+		switch {
+		case hits > bestHits:
+			best, bestHits, nextHits = l.code, hits, bestHits
+		case hits > nextHits:
+			nextHits = hits
+		}
+	}
+	if bestHits < sniffMinHits || float64(bestHits) < sniffMinRatio*float64(nextHits) {
+		return ""
+	}
+	return best
+}
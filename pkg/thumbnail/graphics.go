@@ -0,0 +1,223 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: graphics.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    displays thumbnails in the terminal, using the graphics protocol of kitty
+//    or the sixel protocol, whichever the terminal supports.
+//
+// =============================================================================
+
+package thumbnail
+
+import (
+	"bufio"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// the terminal graphics protocols by which thumbnails are displayed.
+const (
+	ProtocolAuto  = "auto"  // detected from the environment (see DetectProtocol())
+	ProtocolKitty = "kitty" // the graphics protocol of kitty (also WezTerm, Ghostty)
+	ProtocolSixel = "sixel" // DEC sixels (e.g. foot, mlterm, xterm -ti vt340)
+	ProtocolNone  = "none"  // thumbnails aren't displayed
+)
+
+// variable Protocols lists every terminal graphics protocol.
+var Protocols = []string{ProtocolAuto, ProtocolKitty, ProtocolSixel, ProtocolNone}
+
+// local unexported constants for displaying thumbnails.
+const (
+	kittyChunkSize = 4096 // maximum length of the base64 data of an escape code
+	sixelLevels    = 6    // levels of each of red, green, and blue in the palette
+)
+
+// function ValidProtocol() returns true if the given name is a protocol.
+func ValidProtocol(name string) bool {
+	for _, p := range Protocols {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// function DetectProtocol() returns the given protocol, or, if ProtocolAuto,
+// the protocol supported by the terminal, guessed from the environment
+// variables it sets, or ProtocolNone if it supports neither.
+func DetectProtocol(name string) string {
+
+	if ProtocolAuto != name {
+		return name
+	}
+	term := strings.ToLower(os.Getenv("TERM"))
+	prog := strings.ToLower(os.Getenv("TERM_PROGRAM"))
+	switch {
+	case "" != os.Getenv("KITTY_WINDOW_ID"), strings.Contains(term, "kitty"),
+		"wezterm" == prog, "ghostty" == prog:
+		return ProtocolKitty
+	case strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "mlterm"),
+		strings.HasPrefix(term, "contour"):
+		return ProtocolSixel
+	}
+	return ProtocolNone
+}
+
+// function Display() writes the thumbnail at the given path to the given
+// terminal using the given protocol (see DetectProtocol()), at the position of
+// the cursor, followed by a new line.
+func Display(w io.Writer, path, protocol string) *rc.ReturnCode {
+
+	switch protocol {
+	case ProtocolKitty:
+		data, err := ioutil.ReadFile(path)
+		if nil != err {
+			return rc.ThumbnailError.Specf("Display(%q): %s", path, err)
+		}
+		return writeKitty(w, data)
+	case ProtocolSixel:
+		f, err := os.Open(path)
+		if nil != err {
+			return rc.ThumbnailError.Specf("Display(%q): %s", path, err)
+		}
+		defer f.Close()
+		img, err := png.Decode(f)
+		if nil != err {
+			return rc.ThumbnailError.Specf("Display(%q): png.Decode(): %s", path, err)
+		}
+		return writeSixel(w, img)
+	}
+	return rc.ThumbnailError.Specf("Display(%q): unsupported terminal graphics protocol: %q", path, protocol)
+}
+
+// function writeKitty() writes the given PNG data using the graphics protocol
+// of kitty, which transmits and displays it in chunks of base64 data.
+func writeKitty(w io.Writer, data []byte) *rc.ReturnCode {
+
+	enc := base64.StdEncoding.EncodeToString(data)
+	bw := bufio.NewWriter(w)
+	for first := true; first || len(enc) > 0; first = false {
+		chunk := enc
+		if len(chunk) > kittyChunkSize {
+			chunk = chunk[:kittyChunkSize]
+		}
+		enc = enc[len(chunk):]
+		more := "0"
+		if len(enc) > 0 {
+			more = "1"
+		}
+		bw.WriteString("\x1b_G")
+		if first {
+			bw.WriteString("f=100,a=T,")
+		}
+		bw.WriteString("m=" + more + ";" + chunk + "\x1b\\")
+	}
+	bw.WriteString("\n")
+	if err := bw.Flush(); nil != err {
+		return rc.ThumbnailError.Specf("writeKitty(): %s", err)
+	}
+	return nil
+}
+
+// function writeSixel() writes the given image as sixels, in a palette of
+// sixelLevels levels of each of red, green, and blue. transparent pixels are
+// left unpainted.
+func writeSixel(w io.Writer, img image.Image) *rc.ReturnCode {
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	numColors := sixelLevels * sixelLevels * sixelLevels
+
+	// map each pixel to the index of its color in the palette, or -1 if it is
+	// transparent.
+	index := make([]int, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			if a < 0x8000 {
+				index[y*width+x] = -1
+				continue
+			}
+			level := func(c uint32) int { return int(c) * (sixelLevels - 1) / 0xffff }
+			index[y*width+x] = (level(r)*sixelLevels+level(g))*sixelLevels + level(b)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("\x1bP0;1q\"1;1;" + strconv.Itoa(width) + ";" + strconv.Itoa(height))
+	for i := 0; i < numColors; i++ {
+		// sixel colors are given in percent.
+		pct := func(l int) string { return strconv.Itoa(l * 100 / (sixelLevels - 1)) }
+		r, g, b := i/(sixelLevels*sixelLevels), i/sixelLevels%sixelLevels, i%sixelLevels
+		bw.WriteString("#" + strconv.Itoa(i) + ";2;" + pct(r) + ";" + pct(g) + ";" + pct(b))
+	}
+
+	// each band of 6 rows is painted once for every color it uses, each pass
+	// returning to the start of the band.
+	row := make([]byte, width)
+	for top := 0; top < height; top += 6 {
+		used := map[int]bool{}
+		for y := top; y < top+6 && y < height; y++ {
+			for x := 0; x < width; x++ {
+				if c := index[y*width+x]; c >= 0 {
+					used[c] = true
+				}
+			}
+		}
+		for c := 0; c < numColors; c++ {
+			if !used[c] {
+				continue
+			}
+			for x := 0; x < width; x++ {
+				var bits byte
+				for k := 0; k < 6 && top+k < height; k++ {
+					if c == index[(top+k)*width+x] {
+						bits |= 1 << uint(k)
+					}
+				}
+				row[x] = '?' + bits
+			}
+			bw.WriteString("#" + strconv.Itoa(c))
+			writeSixelRow(bw, row)
+			bw.WriteString("$")
+		}
+		bw.WriteString("-")
+	}
+	bw.WriteString("\x1b\\\n")
+	if err := bw.Flush(); nil != err {
+		return rc.ThumbnailError.Specf("writeSixel(): %s", err)
+	}
+	return nil
+}
+
+// function writeSixelRow() writes the given sixels, compressing runs of the
+// same sixel.
+func writeSixelRow(w *bufio.Writer, row []byte) {
+	for i := 0; i < len(row); {
+		n := 1
+		for i+n < len(row) && row[i+n] == row[i] {
+			n++
+		}
+		if n > 3 {
+			w.WriteString("!" + strconv.Itoa(n))
+			w.WriteByte(row[i])
+		} else {
+			for k := 0; k < n; k++ {
+				w.WriteByte(row[i])
+			}
+		}
+		i += n
+	}
+}
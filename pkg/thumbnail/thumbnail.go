@@ -0,0 +1,157 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: thumbnail.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    generates the thumbnails of media files using ffmpeg: a frame extracted
+//    from a video, or a picture of the waveform of an audio file, cached as
+//    PNG files.
+//
+// =============================================================================
+
+// package thumbnail generates small PNG pictures depicting media files using
+// ffmpeg: a frame from some way into a video, or the waveform of an audio file.
+// generating one starts a process reading much of the file, so they are cached
+// (see package filecache), keyed by the record of the media (see Thumbnail()
+// of Library), and only generated again once the file has changed or they were
+// evicted. thumbnails are only available if ffmpeg is installed.
+package thumbnail
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"ardnew.com/pimmp/pkg/filecache"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant CacheDirName is the name of the directory of the thumbnails, in
+// the file cache.
+const CacheDirName = "thumbnails"
+
+// local unexported constants for generating thumbnails.
+const (
+	frameWidth     = 320       // width (pixels) of the frames of videos
+	waveformSize   = "640x120" // size (pixels) of the waveforms of audio
+	waveformColor  = "steelblue"
+	frameOffset    = 0.1 // fraction of a video's length at which its frame is taken
+	maxFrameOffset = 5 * time.Minute
+	maxThumbError  = 200 // maximum length of ffmpeg's output kept as the reason
+)
+
+// the program invoked to generate a thumbnail, and its arguments preceding
+// those describing the thumbnail.
+var (
+	generator    = "ffmpeg"
+	generatorPre = []string{"-v", "error", "-nostdin", "-y"}
+)
+
+// function Available() returns true if the program generating thumbnails is
+// installed.
+func Available() bool {
+	_, err := exec.LookPath(generator)
+	return nil == err
+}
+
+// type Cache is the set of generated thumbnails kept in a file cache. a Cache
+// is safe for use by multiple goroutines, though only one thumbnail is
+// generated at a time.
+type Cache struct {
+	files *filecache.Cache
+	mutex sync.Mutex
+}
+
+// function NewCache() creates a new Cache of the thumbnails kept in the given
+// file cache, under directory CacheDirName.
+func NewCache(files *filecache.Cache) *Cache {
+	return &Cache{files: files}
+}
+
+// function Supports() returns true if a thumbnail can be generated for the
+// given media: a video or audio file, but not the track of a cue sheet or a
+// disc, which have no file of their own.
+func Supports(m *media.Media) bool {
+	if nil == m || m.IsTrack() || m.IsDisc() {
+		return false
+	}
+	return media.KindVideo == m.Kind || media.KindAudio == m.Kind
+}
+
+// function Get() returns the path of the thumbnail of the given media with the
+// given key, identifying its record, generating it first if it isn't cached or
+// is older than the media's file.
+func (c *Cache) Get(key string, m *media.Media) (string, *rc.ReturnCode) {
+
+	if !Supports(m) {
+		return "", rc.ThumbnailError.Specf("Get(%q): unsupported kind of media: %q", key, m.AbsPath)
+	}
+	info, err := os.Stat(m.AbsPath)
+	if nil != err {
+		return "", rc.ThumbnailError.Specf("Get(%q): os.Stat(): %s", key, err)
+	}
+	// ffmpeg picks the format of its output by the name's extension.
+	key = path.Join(CacheDirName, key) + ".png"
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// the time the thumbnail was last used is never before it was generated,
+	// so a thumbnail used since the file changed was generated again then.
+	if thumb, err := os.Stat(c.files.Path(key)); nil == err && !thumb.ModTime().Before(info.ModTime()) {
+		c.files.Touch(key)
+		return c.files.Path(key), nil
+	}
+	return c.files.Put(key, func(tmp string) *rc.ReturnCode { return generate(m, tmp) })
+}
+
+// function generate() runs ffmpeg to write the thumbnail of the given media to
+// the file at the given path.
+func generate(m *media.Media, out string) *rc.ReturnCode {
+
+	args := append([]string{}, generatorPre...)
+	switch m.Kind {
+	case media.KindVideo:
+		// the first frames are often black, or titles, so the frame is taken
+		// some way into the video, if its length is known.
+		offset := time.Duration(float64(m.Duration) * frameOffset)
+		if offset > maxFrameOffset {
+			offset = maxFrameOffset
+		}
+		args = append(args,
+			"-ss", fmt.Sprintf("%.3f", offset.Seconds()), "-i", m.AbsPath,
+			"-frames:v", "1", "-vf", fmt.Sprintf("scale=%d:-2", frameWidth), out)
+	case media.KindAudio:
+		args = append(args,
+			"-i", m.AbsPath, "-filter_complex",
+			fmt.Sprintf("showwavespic=s=%s:colors=%s", waveformSize, waveformColor),
+			"-frames:v", "1", out)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(generator, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); nil != err {
+		msg := strings.TrimSpace(stderr.String())
+		if "" == msg {
+			msg = err.Error()
+		}
+		if len(msg) > maxThumbError {
+			msg = msg[:maxThumbError] + "..."
+		}
+		return rc.ThumbnailError.Specf("generate(%q): %s", m.AbsPath, strings.Replace(msg, "\n", "; ", -1))
+	}
+	if info, err := os.Stat(out); nil != err || 0 == info.Size() {
+		return rc.ThumbnailError.Specf("generate(%q): no picture written", m.AbsPath)
+	}
+	return nil
+}
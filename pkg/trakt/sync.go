@@ -0,0 +1,454 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: sync.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    reconciles the watch state and ratings of the videos in the libraries
+//    with those of a Trakt account, the most recent change of either winning.
+//
+// =============================================================================
+
+package trakt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/provider"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// type Video is the local state of a movie or TV episode synced with Trakt,
+// which identifies it by its title and year, or by its show, season, and
+// episode number (only the first episode of a multi-episode file is synced).
+type Video struct {
+	Query   provider.Query // identifies the movie or episode
+	Watched bool           // played to completion at least once
+	Plays   int64          // number of times played to completion
+	Played  time.Time      // when last watched, or else marked unwatched, zero if unknown
+	Rating  int64          // rating, from 1 to media.MaxRating (0 = unrated)
+	Rated   time.Time      // when last rated, or else unrated, zero if unknown
+}
+
+// type Update is a change to the local state of a Video, pulled from Trakt.
+type Update struct {
+	Index      int       // index of the Video in the list synced
+	SetWatched bool      // the watch state changed
+	Watched    bool      // watched on Trakt, so its plays are merged
+	WatchedAt  time.Time // when last watched on Trakt, zero if unwatched
+	Plays      int64     // number of times played according to Trakt
+	SetRating  bool      // the rating changed
+	Rating     int64     // rating on Trakt (0 = unrated), valid if SetRating
+}
+
+// type remote is the state of a movie or TV episode on Trakt.
+type remote struct {
+	movie     *object   // the movie, nil if an episode
+	show      *object   // show of the episode, nil if a movie
+	season    int       // season number of the episode
+	episode   int       // episode number of the episode within its season
+	plays     int64     // number of times watched, 0 if unwatched
+	watchedAt time.Time // when last watched
+	rating    int64     // rating, 0 if unrated
+	ratedAt   time.Time // when rated
+}
+
+// type object is a movie or show, as the API identifies them. objects
+// returned by the API are sent back with their IDs, the others by their title
+// and year, which Trakt matches itself.
+type object struct {
+	Title string                 `json:"title"`
+	Year  int                    `json:"year,omitempty"`
+	IDs   map[string]interface{} `json:"ids,omitempty"`
+}
+
+// types of the responses of the sync endpoints.
+type (
+	watchedMovie struct {
+		Plays         int64     `json:"plays"`
+		LastWatchedAt time.Time `json:"last_watched_at"`
+		Movie         object    `json:"movie"`
+	}
+	watchedShow struct {
+		Show    object `json:"show"`
+		Seasons []struct {
+			Number   int `json:"number"`
+			Episodes []struct {
+				Number        int       `json:"number"`
+				Plays         int64     `json:"plays"`
+				LastWatchedAt time.Time `json:"last_watched_at"`
+			} `json:"episodes"`
+		} `json:"seasons"`
+	}
+	rated struct {
+		RatedAt time.Time `json:"rated_at"`
+		Rating  int64     `json:"rating"`
+		Movie   *object   `json:"movie"`
+		Show    *object   `json:"show"`
+		Episode *struct {
+			Season int `json:"season"`
+			Number int `json:"number"`
+		} `json:"episode"`
+	}
+)
+
+// types of the requests of the sync endpoints.
+type (
+	batch struct {
+		Movies []movieItem `json:"movies,omitempty"`
+		Shows  []showItem  `json:"shows,omitempty"`
+
+		show map[string]int // index of each show by its key
+		sent map[string]bool
+	}
+	movieItem struct {
+		object
+		itemState
+	}
+	showItem struct {
+		object
+		Seasons []seasonItem `json:"seasons"`
+	}
+	seasonItem struct {
+		Number   int           `json:"number"`
+		Episodes []episodeItem `json:"episodes"`
+	}
+	episodeItem struct {
+		Number int `json:"number"`
+		itemState
+	}
+	itemState struct {
+		WatchedAt string `json:"watched_at,omitempty"`
+		Rating    int64  `json:"rating,omitempty"`
+		RatedAt   string `json:"rated_at,omitempty"`
+	}
+)
+
+// function NewVideo() returns the local state of the given video, or false if
+// it identifies no movie or episode (see provider.NewQuery()). the times of
+// its changes are those recorded in its history.
+func NewVideo(v *media.VideoMedia) (Video, bool) {
+
+	q, ok := provider.NewQuery(v)
+	if !ok || "" == q.Title && "" == q.Show {
+		return Video{}, false
+	}
+	vid := Video{Query: q, Watched: v.Watched, Plays: v.PlayCount, Rating: v.Rating}
+	vid.Played, _ = v.EditedAt("Watched")
+	if v.Watched && v.LastPlayed.After(vid.Played) {
+		vid.Played = v.LastPlayed
+	}
+	vid.Rated, _ = v.EditedAt("Rating")
+	return vid, true
+}
+
+// function Apply() applies the Update to the given media, returning true if it
+// was changed.
+func (u *Update) Apply(m *media.Media) bool {
+
+	changed := false
+	if u.SetWatched && u.Watched != m.Watched {
+		m.Watched, changed = u.Watched, true
+		if u.Watched {
+			m.ResumePosition = 0
+		}
+	}
+	if u.Watched {
+		if u.Plays > m.PlayCount {
+			m.PlayCount, changed = u.Plays, true
+		}
+		if u.WatchedAt.After(m.LastPlayed) {
+			m.LastPlayed, changed = u.WatchedAt, true
+		}
+	}
+	if u.SetRating && m.SetRating(u.Rating) {
+		changed = true
+	}
+	return changed
+}
+
+// function Sync() reconciles the given videos with the Trakt account. the
+// state of a video on either side prevails if it changed more recently than
+// the other. a video watched (or rated) locally only is assumed removed from
+// Trakt if it was on Trakt when last synced and hasn't changed locally since,
+// and is otherwise added to Trakt. the changes to the account are made (unless
+// dryRun is true), and those to the videos returned, along with the number of
+// changes to the account. once the updates are applied, Synced() must be
+// called.
+func (c *Client) Sync(videos []Video, dryRun bool) ([]Update, int, *rc.ReturnCode) {
+
+	state, ret := c.remoteState()
+	if nil != ret {
+		return nil, 0, ret
+	}
+
+	last := c.LastSync()
+	known := func(list []string) map[string]bool {
+		set := map[string]bool{}
+		for _, k := range list {
+			set[k] = true
+		}
+		return set
+	}
+	knownWatched, knownRated := known(c.account.Watched), known(c.account.Rated)
+	c.watched, c.rated = []string{}, []string{}
+	stamp := func(t time.Time) string {
+		if t.IsZero() {
+			t = time.Now()
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	var (
+		addHistory, delHistory = newBatch(), newBatch()
+		addRatings, delRatings = newBatch(), newBatch()
+		updates                = []Update{}
+	)
+	for i, v := range videos {
+		k := keyOf(v.Query)
+		r := state.lookup(v.Query)
+		u := Update{Index: i}
+
+		watched := nil != r && r.plays > 0
+		switch {
+		case v.Watched && watched:
+			if r.plays > v.Plays || r.watchedAt.After(v.Played) {
+				u.Watched, u.Plays, u.WatchedAt = true, r.plays, r.watchedAt
+			}
+		case v.Watched:
+			if !knownWatched[k] || v.Played.After(last) {
+				addHistory.add(r, v.Query, itemState{WatchedAt: stamp(v.Played)})
+			} else {
+				u.SetWatched, u.Watched = true, false
+			}
+		case watched:
+			if v.Played.After(r.watchedAt) {
+				delHistory.add(r, v.Query, itemState{})
+				watched = false
+			} else {
+				u.SetWatched, u.Watched, u.Plays, u.WatchedAt = true, true, r.plays, r.watchedAt
+			}
+		}
+
+		if watched {
+			c.watched = append(c.watched, k)
+		}
+
+		rating := int64(0)
+		if nil != r {
+			rating = r.rating
+		}
+		switch {
+		case v.Rating == rating:
+		case v.Rating > 0 && rating > 0:
+			if v.Rated.After(r.ratedAt) {
+				addRatings.add(r, v.Query, itemState{Rating: v.Rating, RatedAt: stamp(v.Rated)})
+			} else {
+				u.SetRating, u.Rating = true, rating
+			}
+		case v.Rating > 0:
+			if !knownRated[k] || v.Rated.After(last) {
+				addRatings.add(r, v.Query, itemState{Rating: v.Rating, RatedAt: stamp(v.Rated)})
+			} else {
+				u.SetRating, u.Rating = true, 0
+			}
+		default:
+			if v.Rated.After(r.ratedAt) {
+				delRatings.add(r, v.Query, itemState{})
+				rating = 0
+			} else {
+				u.SetRating, u.Rating = true, rating
+			}
+		}
+
+		if rating > 0 {
+			c.rated = append(c.rated, k)
+		}
+
+		if u.SetWatched || u.SetRating || u.Watched {
+			updates = append(updates, u)
+		}
+	}
+
+	pushed := 0
+	for _, req := range []struct {
+		path string
+		body *batch
+	}{
+		{"/sync/history", addHistory},
+		{"/sync/history/remove", delHistory},
+		{"/sync/ratings", addRatings},
+		{"/sync/ratings/remove", delRatings},
+	} {
+		if 0 == len(req.body.sent) {
+			continue
+		}
+		pushed += len(req.body.sent)
+		if dryRun {
+			continue
+		}
+		if ret := c.post(req.path, req.body, nil); nil != ret {
+			return nil, 0, ret
+		}
+	}
+	return updates, pushed, nil
+}
+
+// type remoteState is the state of every movie and episode on Trakt either
+// watched or rated, by key (see keyOf()).
+type remoteState map[string]*remote
+
+// function remoteState() requests the watched and rated movies and episodes of
+// the account.
+func (c *Client) remoteState() (remoteState, *rc.ReturnCode) {
+
+	state := remoteState{}
+	movie := func(o object) *remote {
+		k := keyOf(provider.Query{Title: o.Title, Year: o.Year})
+		if _, ok := state[k]; !ok {
+			m := o
+			state[k] = &remote{movie: &m}
+			// videos whose year is unknown are matched by title alone.
+			state[keyOf(provider.Query{Title: o.Title})] = state[k]
+		}
+		return state[k]
+	}
+	episode := func(o object, season, number int) *remote {
+		k := keyOf(provider.Query{Show: o.Title, Season: season, Episode: number})
+		if _, ok := state[k]; !ok {
+			s := o
+			state[k] = &remote{show: &s, season: season, episode: number}
+		}
+		return state[k]
+	}
+
+	var movies []watchedMovie
+	if ret := c.get("/sync/watched/movies", &movies); nil != ret {
+		return nil, ret
+	}
+	for _, w := range movies {
+		r := movie(w.Movie)
+		r.plays, r.watchedAt = w.Plays, w.LastWatchedAt
+	}
+
+	var shows []watchedShow
+	if ret := c.get("/sync/watched/shows", &shows); nil != ret {
+		return nil, ret
+	}
+	for _, w := range shows {
+		for _, s := range w.Seasons {
+			for _, e := range s.Episodes {
+				r := episode(w.Show, s.Number, e.Number)
+				r.plays, r.watchedAt = e.Plays, e.LastWatchedAt
+			}
+		}
+	}
+
+	for _, path := range []string{"/sync/ratings/movies", "/sync/ratings/episodes"} {
+		var ratings []rated
+		if ret := c.get(path, &ratings); nil != ret {
+			return nil, ret
+		}
+		for _, x := range ratings {
+			var r *remote
+			switch {
+			case nil != x.Movie:
+				r = movie(*x.Movie)
+			case nil != x.Show && nil != x.Episode:
+				r = episode(*x.Show, x.Episode.Season, x.Episode.Number)
+			default:
+				continue
+			}
+			r.rating, r.ratedAt = x.Rating, x.RatedAt
+		}
+	}
+	return state, nil
+}
+
+// function lookup() returns the state on Trakt of the movie or episode
+// identified by the given query, or nil if neither watched nor rated.
+func (s remoteState) lookup(q provider.Query) *remote {
+	if r, ok := s[keyOf(q)]; ok {
+		return r
+	}
+	return nil
+}
+
+// function keyOf() returns the key identifying the movie or episode of the
+// given query, ignoring case, punctuation, and spacing of its title.
+func keyOf(q provider.Query) string {
+	if q.IsEpisode() {
+		return fmt.Sprintf("episode|%s|%d|%d", fold(q.Show), q.Season, q.Episode)
+	}
+	if q.Year > 0 {
+		return fmt.Sprintf("movie|%s|%d", fold(q.Title), q.Year)
+	}
+	return fmt.Sprintf("movie|%s", fold(q.Title))
+}
+
+// function fold() returns the given title in lower case, with only its letters
+// and digits.
+func fold(title string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, title)
+}
+
+// function newBatch() creates an empty request of a sync endpoint.
+func newBatch() *batch {
+	return &batch{show: map[string]int{}, sent: map[string]bool{}}
+}
+
+// function add() adds the movie or episode with the given state on Trakt (nil
+// if unknown there), identified by the given query, to the batch with the
+// given state. each is added only once.
+func (b *batch) add(r *remote, q provider.Query, state itemState) {
+
+	k := keyOf(q)
+	if b.sent[k] {
+		return
+	}
+	b.sent[k] = true
+
+	if !q.IsEpisode() {
+		o := object{Title: q.Title, Year: q.Year}
+		if nil != r && nil != r.movie {
+			o = *r.movie
+		}
+		b.Movies = append(b.Movies, movieItem{object: o, itemState: state})
+		return
+	}
+
+	o := object{Title: q.Show, Year: q.Year}
+	if nil != r && nil != r.show {
+		o = *r.show
+	}
+	sk := fold(q.Show)
+	i, ok := b.show[sk]
+	if !ok {
+		i = len(b.Shows)
+		b.show[sk] = i
+		b.Shows = append(b.Shows, showItem{object: o})
+	}
+	show := &b.Shows[i]
+	for j := range show.Seasons {
+		if q.Season == show.Seasons[j].Number {
+			show.Seasons[j].Episodes = append(show.Seasons[j].Episodes,
+				episodeItem{Number: q.Episode, itemState: state})
+			return
+		}
+	}
+	show.Seasons = append(show.Seasons, seasonItem{
+		Number:   q.Season,
+		Episodes: []episodeItem{{Number: q.Episode, itemState: state}},
+	})
+}
@@ -0,0 +1,301 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: trakt.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the client of the Trakt API, which authorizes pimmp to access a
+//    Trakt account by the OAuth device flow and keeps its tokens on disk.
+//
+// =============================================================================
+
+// package trakt synchronizes the watch state and ratings of videos with a
+// Trakt (https://trakt.tv) account. pimmp must first be registered as an API
+// application of the account, whose client ID and secret are given to New(),
+// and then authorized once by Login(), after which its tokens are kept in a
+// file (see AccountFileName) and refreshed as needed.
+package trakt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// local unexported constants for the Trakt API client.
+const (
+	apiURL         = "https://api.trakt.tv" // base URL of every request
+	apiVersion     = "2"                    // version of the API requested
+	requestTimeout = 30 * time.Second       // maximum time waited for each response
+	maxResponseLen = 16 << 20               // longest response body read
+	refreshMargin  = 24 * time.Hour         // how long before it expires a token is refreshed
+	userAgent      = "pimmp/1.0 (https://github.com/ardnew/pimmp)"
+)
+
+// constant AccountFileName is the name of the file, in the library data
+// directory, keeping the tokens of the authorized Trakt account.
+const AccountFileName = "trakt.json"
+
+// type account is the authorization of a Trakt account, and the state of its
+// synchronization, kept in the account file.
+type account struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expires      time.Time `json:"expires"`   // when the access token expires
+	LastSync     time.Time `json:"last_sync"` // when the last sync finished, zero if never
+
+	// keys of the local videos watched, and rated, on Trakt when last synced,
+	// so that those since removed from Trakt are told apart from those that
+	// never were on it (see Sync()).
+	Watched []string `json:"watched,omitempty"`
+	Rated   []string `json:"rated,omitempty"`
+}
+
+// type token is the response of the OAuth token endpoints.
+type token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // seconds
+	CreatedAt    int64  `json:"created_at"` // Unix time
+}
+
+// type deviceCode is the response of the OAuth device code endpoint.
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURL string `json:"verification_url"`
+	ExpiresIn       int64  `json:"expires_in"` // seconds
+	Interval        int64  `json:"interval"`   // seconds
+}
+
+// type Client performs the requests of a single Trakt account.
+type Client struct {
+	id      string       // client ID of the API application
+	secret  string       // client secret of the API application
+	path    string       // path of the account file
+	http    *http.Client // the underlying HTTP client
+	account account      // tokens of the authorized account
+
+	watched, rated []string // keys recorded by Synced(), found by Sync()
+}
+
+// function New() creates a Client of the API application with the given client
+// ID and secret, reading the tokens of the account authorized earlier (if any)
+// from the account file at the given path.
+func New(clientID, clientSecret, path string) (*Client, *rc.ReturnCode) {
+
+	if "" == clientID || "" == clientSecret {
+		return nil, rc.InvalidArgs.Spec("New(): the client ID and secret of a Trakt API application are required")
+	}
+	c := &Client{
+		id:     clientID,
+		secret: clientSecret,
+		path:   path,
+		http:   &http.Client{Timeout: requestTimeout},
+	}
+	data, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return c, nil
+	case nil != err:
+		return nil, rc.SyncError.Specf("New(%q): ioutil.ReadFile(): %s", path, err)
+	}
+	if err := json.Unmarshal(data, &c.account); nil != err {
+		return nil, rc.SyncError.Specf("New(%q): json.Unmarshal(): %s", path, err)
+	}
+	return c, nil
+}
+
+// function LoggedIn() returns true if an account has been authorized.
+func (c *Client) LoggedIn() bool {
+	return "" != c.account.RefreshToken
+}
+
+// function LastSync() returns when the last sync finished, or the zero time if
+// the account was never synced.
+func (c *Client) LastSync() time.Time {
+	return c.account.LastSync
+}
+
+// function Synced() records the given time as that at which the last sync
+// finished, i.e. once its updates have been applied to the libraries.
+func (c *Client) Synced(at time.Time) *rc.ReturnCode {
+	c.account.LastSync = at
+	c.account.Watched, c.account.Rated = c.watched, c.rated
+	return c.save()
+}
+
+// function Login() authorizes access to a Trakt account by the OAuth device
+// flow: the given function is called with the URL the user must visit, and the
+// code they must enter there, and then Login() waits until they have done so
+// (or the code expires, or the context is done). any account authorized
+// earlier is replaced.
+func (c *Client) Login(ctx context.Context, prompt func(url, code string)) *rc.ReturnCode {
+
+	var code deviceCode
+	if _, ret := c.call(http.MethodPost, "/oauth/device/code",
+		map[string]string{"client_id": c.id}, &code, false); nil != ret {
+		return ret
+	}
+	prompt(code.VerificationURL, code.UserCode)
+
+	interval := time.Duration(code.Interval) * time.Second
+	expires := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for time.Now().Before(expires) {
+		select {
+		case <-ctx.Done():
+			return rc.Canceled.Spec("Login(): interrupted")
+		case <-time.After(interval):
+		}
+		var tok token
+		status, ret := c.call(http.MethodPost, "/oauth/device/token", map[string]string{
+			"code":          code.DeviceCode,
+			"client_id":     c.id,
+			"client_secret": c.secret,
+		}, &tok, false)
+		switch {
+		case nil == ret:
+			c.account = account{}
+			c.setToken(tok)
+			return c.save()
+		case http.StatusBadRequest == status:
+			// the user hasn't entered the code yet.
+		case http.StatusTooManyRequests == status:
+			interval += time.Second
+		default:
+			return ret
+		}
+	}
+	return rc.SyncError.Spec("Login(): the code expired before it was entered")
+}
+
+// function setToken() keeps the tokens of the given response.
+func (c *Client) setToken(tok token) {
+	created := time.Unix(tok.CreatedAt, 0)
+	if tok.CreatedAt <= 0 {
+		created = time.Now()
+	}
+	c.account.AccessToken = tok.AccessToken
+	c.account.RefreshToken = tok.RefreshToken
+	c.account.Expires = created.Add(time.Duration(tok.ExpiresIn) * time.Second)
+}
+
+// function authorize() refreshes the access token if it expires soon.
+func (c *Client) authorize() *rc.ReturnCode {
+
+	if !c.LoggedIn() {
+		return rc.SyncError.Spec("authorize(): no Trakt account authorized (see \"trakt login\")")
+	}
+	if time.Until(c.account.Expires) > refreshMargin {
+		return nil
+	}
+	var tok token
+	if _, ret := c.call(http.MethodPost, "/oauth/token", map[string]string{
+		"refresh_token": c.account.RefreshToken,
+		"client_id":     c.id,
+		"client_secret": c.secret,
+		"redirect_uri":  "urn:ietf:wg:oauth:2.0:oob",
+		"grant_type":    "refresh_token",
+	}, &tok, false); nil != ret {
+		return ret
+	}
+	c.setToken(tok)
+	return c.save()
+}
+
+// function save() writes the account file, readable only by its owner since
+// it contains the account's tokens.
+func (c *Client) save() *rc.ReturnCode {
+
+	data, err := json.MarshalIndent(c.account, "", "  ")
+	if nil != err {
+		return rc.SyncError.Specf("save(%q): json.MarshalIndent(): %s", c.path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); nil != err {
+		return rc.SyncError.Specf("save(%q): os.MkdirAll(): %s", c.path, err)
+	}
+	if err := ioutil.WriteFile(c.path, data, 0600); nil != err {
+		return rc.SyncError.Specf("save(%q): ioutil.WriteFile(): %s", c.path, err)
+	}
+	return nil
+}
+
+// function get() performs an authorized GET request of the given API path,
+// unmarshalling its JSON response into v.
+func (c *Client) get(path string, v interface{}) *rc.ReturnCode {
+	if ret := c.authorize(); nil != ret {
+		return ret
+	}
+	_, ret := c.call(http.MethodGet, path, nil, v, true)
+	return ret
+}
+
+// function post() performs an authorized POST request of the given API path
+// with the given JSON body, unmarshalling its JSON response into v (unless
+// nil).
+func (c *Client) post(path string, body, v interface{}) *rc.ReturnCode {
+	if ret := c.authorize(); nil != ret {
+		return ret
+	}
+	_, ret := c.call(http.MethodPost, path, body, v, true)
+	return ret
+}
+
+// function call() performs a request of the given API path with the given
+// JSON body (unless nil), authorized by the access token if auth is true, and
+// unmarshals its JSON response into v (unless nil). returns the status code of
+// the response, if any, which is an error unless 2xx.
+func (c *Client) call(method, path string, body, v interface{}, auth bool) (int, *rc.ReturnCode) {
+
+	var content io.Reader
+	if nil != body {
+		data, err := json.Marshal(body)
+		if nil != err {
+			return 0, rc.SyncError.Specf("%s %s: json.Marshal(): %s", method, path, err)
+		}
+		content = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, apiURL+path, content)
+	if nil != err {
+		return 0, rc.SyncError.Specf("%s %s: %s", method, path, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("trakt-api-version", apiVersion)
+	req.Header.Set("trakt-api-key", c.id)
+	if auth {
+		req.Header.Set("Authorization", "Bearer "+c.account.AccessToken)
+	}
+
+	rsp, err := c.http.Do(req)
+	if nil != err {
+		return 0, rc.SyncError.Specf("%s %s: %s", method, path, err)
+	}
+	defer rsp.Body.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(rsp.Body, maxResponseLen+1))
+	if nil != err {
+		return rsp.StatusCode, rc.SyncError.Specf("%s %s: %s", method, path, err)
+	}
+	if len(data) > maxResponseLen {
+		return rsp.StatusCode, rc.SyncError.Specf("%s %s: response too long", method, path)
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return rsp.StatusCode, rc.SyncError.Specf("%s %s: %s", method, path, rsp.Status)
+	}
+	if nil != v && len(data) > 0 {
+		if err := json.Unmarshal(data, v); nil != err {
+			return rsp.StatusCode, rc.SyncError.Specf("%s %s: json.Unmarshal(): %s", method, path, err)
+		}
+	}
+	return rsp.StatusCode, nil
+}
@@ -0,0 +1,96 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: move.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    moves files into and out of a trash directory, which may reside on a
+//    different file system than the files (e.g. a -trashdir).
+//
+// =============================================================================
+
+package trash
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"ardnew.com/pimmp/pkg/platform"
+)
+
+// function move() moves the file (or directory, e.g. of a video disc) at path
+// src to path dst, whose parent directory must exist. a file can only be
+// renamed within one file system, so one moved to another is copied instead,
+// synced to disk, and only then removed.
+func move(src, dst string) error {
+
+	srcDev, srcOK := platform.DeviceID(src)
+	dstDev, dstOK := platform.DeviceID(filepath.Dir(dst))
+	if !srcOK || !dstOK || srcDev == dstDev {
+		return os.Rename(src, dst)
+	}
+	if err := copyTree(src, dst); nil != err {
+		// never leave a partial copy behind; the original is still intact.
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// function copyTree() copies the file or directory at path src to path dst,
+// which must not exist, preserving the permissions and modification times of
+// the files, and the targets of symbolic links.
+func copyTree(src, dst string) error {
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if nil != err {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if nil != err {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			return os.Mkdir(target, mode.Perm()|0700)
+		case 0 != mode&os.ModeSymlink:
+			link, err := os.Readlink(path)
+			if nil != err {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			if err := copyFile(path, target, mode.Perm()); nil != err {
+				return err
+			}
+			return os.Chtimes(target, info.ModTime(), info.ModTime())
+		}
+	})
+}
+
+// function copyFile() copies the regular file at path src to a new file at
+// path dst with the given permissions, and syncs it to disk.
+func copyFile(src, dst string, perm os.FileMode) error {
+
+	in, err := os.Open(src)
+	if nil != err {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if nil != err {
+		return err
+	}
+	if _, err = io.Copy(out, in); nil == err {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); nil == err {
+		err = closeErr
+	}
+	return err
+}
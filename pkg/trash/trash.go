@@ -0,0 +1,267 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: trash.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    defines the trash directories to which files are moved instead of being
+//    deleted, and from which they can be restored.
+//
+// =============================================================================
+
+// package trash moves files to a trash directory instead of deleting them, so
+// that they can be restored later. every trash directory -- whether the OS
+// trash or pimmp's own -- uses the layout of the freedesktop.org trash spec:
+// the trashed files in subdirectory "files", and for each a file in "info"
+// recording its original path and the time it was deleted.
+package trash
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant DefaultDirName is the name of the trash directory created in the
+// root of a library when no other trash is available.
+const DefaultDirName = ".pimmp-trash"
+
+// local unexported constants for the trash directory layout.
+const (
+	filesDirName = "files"
+	infoDirName  = "info"
+	infoExt      = ".trashinfo"
+	infoHeader   = "[Trash Info]"
+	infoPath     = "Path="
+	infoDate     = "DeletionDate="
+	dateFormat   = "2006-01-02T15:04:05"
+)
+
+// type Trash is a single trash directory.
+type Trash struct {
+	dir string // absolute path to the trash directory
+}
+
+// type Item is a single file in a Trash.
+type Item struct {
+	Name    string    // name of the file in the trash
+	Path    string    // absolute path from which the file was deleted
+	Deleted time.Time // time at which the file was deleted
+	trash   *Trash    // trash containing the file
+}
+
+// function New() creates a Trash using the given directory, which is created
+// as needed when files are first moved to it.
+func New(dir string) *Trash {
+	return &Trash{dir: dir}
+}
+
+// function For() returns the Trash to which the file at the given absolute
+// path should be moved: the directory given with override if non-empty, then
+// the OS trash if supported, and finally DefaultDirName in the given library
+// root directory.
+func For(absPath, override, libRoot string) *Trash {
+	if "" != override {
+		return New(override)
+	}
+	if t := osTrash(absPath); nil != t {
+		return t
+	}
+	return New(filepath.Join(libRoot, DefaultDirName))
+}
+
+// function All() returns every Trash to which the files of the given library
+// root directories may have been moved (see For()). trash directories that
+// don't exist are omitted.
+func All(override string, libRoot ...string) []*Trash {
+
+	seen := map[string]bool{}
+	all := []*Trash{}
+	add := func(t *Trash) {
+		if nil == t || seen[t.dir] {
+			return
+		}
+		seen[t.dir] = true
+		if info, err := os.Stat(t.dir); nil == err && info.IsDir() {
+			all = append(all, t)
+		}
+	}
+
+	if "" != override {
+		add(New(override))
+	}
+	for _, root := range libRoot {
+		add(osTrash(root))
+		add(New(filepath.Join(root, DefaultDirName)))
+	}
+	return all
+}
+
+// function IsTrashDir() returns true if a directory with the given name is a
+// trash directory, whose contents should never be scanned as part of a library.
+func IsTrashDir(name string) bool {
+	return DefaultDirName == name || ".Trash" == name || strings.HasPrefix(name, ".Trash-")
+}
+
+// function String() returns the path to the Trash's directory.
+func (t *Trash) String() string {
+	return t.dir
+}
+
+// function Put() moves the file at the given absolute path to the Trash,
+// copying it if the Trash is on another file system (see move()).
+func (t *Trash) Put(absPath string) (*Item, *rc.ReturnCode) {
+
+	filesDir := filepath.Join(t.dir, filesDirName)
+	infoDir := filepath.Join(t.dir, infoDirName)
+	for _, d := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(d, 0700); nil != err {
+			return nil, rc.TrashError.Specf("Put(%q): os.MkdirAll(%q): %s", absPath, d, err)
+		}
+	}
+
+	// the info file is created first, exclusively, to reserve the name in the
+	// trash. if the name is taken, a numeric suffix is added until it isn't.
+	base := filepath.Base(absPath)
+	item := &Item{Path: absPath, Deleted: time.Now(), trash: t}
+	var info *os.File
+	for n := 1; nil == info; n++ {
+		item.Name = base
+		if n > 1 {
+			item.Name = fmt.Sprintf("%s.%d", base, n)
+		}
+		f, err := os.OpenFile(item.infoPath(), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if nil == err {
+			info = f
+		} else if !os.IsExist(err) {
+			return nil, rc.TrashError.Specf("Put(%q): os.OpenFile(%q): %s", absPath, item.infoPath(), err)
+		}
+	}
+
+	_, err := fmt.Fprintf(info, "%s\n%s%s\n%s%s\n", infoHeader,
+		infoPath, (&url.URL{Path: absPath}).EscapedPath(),
+		infoDate, item.Deleted.Format(dateFormat))
+	if closeErr := info.Close(); nil == err {
+		err = closeErr
+	}
+	if nil == err {
+		err = move(absPath, item.filePath())
+	}
+	if nil != err {
+		os.Remove(item.infoPath())
+		return nil, rc.TrashError.Specf("Put(%q): %s", absPath, err)
+	}
+	return item, nil
+}
+
+// function List() returns every Item in the Trash.
+func (t *Trash) List() ([]*Item, *rc.ReturnCode) {
+
+	infoDir := filepath.Join(t.dir, infoDirName)
+	entry, err := filepath.Glob(filepath.Join(infoDir, "*"+infoExt))
+	if nil != err {
+		return nil, rc.TrashError.Specf("List(%q): %s", t.dir, err)
+	}
+
+	items := []*Item{}
+	for _, e := range entry {
+		item, ret := t.readInfo(e)
+		if nil != ret {
+			return nil, ret
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// function Item() returns the Item in the Trash with the given name.
+func (t *Trash) Item(name string) (*Item, *rc.ReturnCode) {
+	return t.readInfo(filepath.Join(t.dir, infoDirName, name+infoExt))
+}
+
+// function readInfo() parses the info file at the given path.
+func (t *Trash) readInfo(infoFile string) (*Item, *rc.ReturnCode) {
+
+	f, err := os.Open(infoFile)
+	if nil != err {
+		return nil, rc.TrashError.Specf("readInfo(%q): %s", infoFile, err)
+	}
+	defer f.Close()
+
+	item := &Item{Name: strings.TrimSuffix(filepath.Base(infoFile), infoExt), trash: t}
+	scan := bufio.NewScanner(f)
+	for scan.Scan() {
+		line := scan.Text()
+		switch {
+		case strings.HasPrefix(line, infoPath):
+			p, err := url.PathUnescape(strings.TrimPrefix(line, infoPath))
+			if nil != err {
+				return nil, rc.TrashError.Specf("readInfo(%q): invalid path: %s", infoFile, err)
+			}
+			// relative paths are relative to the directory containing the
+			// trash, i.e. the top of the device on which it resides.
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(filepath.Dir(t.dir), p)
+			}
+			item.Path = p
+		case strings.HasPrefix(line, infoDate):
+			item.Deleted, _ = time.ParseInLocation(dateFormat,
+				strings.TrimPrefix(line, infoDate), time.Local)
+		}
+	}
+	if err := scan.Err(); nil != err {
+		return nil, rc.TrashError.Specf("readInfo(%q): %s", infoFile, err)
+	}
+	if "" == item.Path {
+		return nil, rc.TrashError.Specf("readInfo(%q): no original path", infoFile)
+	}
+	return item, nil
+}
+
+// function String() returns a description of the Item for display.
+func (i *Item) String() string {
+	return fmt.Sprintf("%q (deleted %s, in %s)",
+		i.Path, i.Deleted.Format("2006-01-02 15:04"), i.trash)
+}
+
+// function Trash() returns the Trash containing the Item.
+func (i *Item) Trash() *Trash {
+	return i.trash
+}
+
+// function Restore() moves the Item back to the path from which it was
+// deleted. an existing file at that path is never overwritten.
+func (i *Item) Restore() *rc.ReturnCode {
+
+	if _, err := os.Lstat(i.Path); nil == err {
+		return rc.TrashError.Specf("Restore(%q): file already exists", i.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(i.Path), 0755); nil != err {
+		return rc.TrashError.Specf("Restore(%q): os.MkdirAll(): %s", i.Path, err)
+	}
+	if err := move(i.filePath(), i.Path); nil != err {
+		return rc.TrashError.Specf("Restore(%q): %s", i.Path, err)
+	}
+	if err := os.Remove(i.infoPath()); nil != err {
+		return rc.TrashError.Specf("Restore(%q): os.Remove(%q): %s", i.Path, i.infoPath(), err)
+	}
+	return nil
+}
+
+// function filePath() returns the path to the Item's file in the trash.
+func (i *Item) filePath() string {
+	return filepath.Join(i.trash.dir, filesDirName, i.Name)
+}
+
+// function infoPath() returns the path to the Item's info file in the trash.
+func (i *Item) infoPath() string {
+	return filepath.Join(i.trash.dir, infoDirName, i.Name+infoExt)
+}
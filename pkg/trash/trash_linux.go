@@ -0,0 +1,59 @@
+// +build linux
+
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: trash_linux.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    locates the OS trash on systems following the freedesktop.org trash spec
+//    (i.e. the trash used by GNOME, KDE, XFCE, etc.).
+//
+// =============================================================================
+
+package trash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"ardnew.com/pimmp/pkg/platform"
+)
+
+// function osTrash() returns the OS trash to which the file at the given
+// absolute path should be moved: the user's home trash if the file is on the
+// same device, otherwise the per-user trash at the top of the file's device.
+// returns nil if the trash can't be determined.
+func osTrash(absPath string) *Trash {
+
+	dev, ok := platform.DeviceID(absPath)
+	if !ok {
+		return nil
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if "" == dataHome {
+		dataHome = filepath.Join(platform.HomeDir(), ".local", "share")
+	}
+	if home, ok := platform.DeviceID(platform.HomeDir()); ok && home == dev {
+		return New(filepath.Join(dataHome, "Trash"))
+	}
+
+	// walk up the directory tree until we cross onto another device; the last
+	// directory on the file's device is its mount point.
+	top := absPath
+	for {
+		parent := filepath.Dir(top)
+		if parent == top {
+			break
+		}
+		if d, ok := platform.DeviceID(parent); !ok || d != dev {
+			break
+		}
+		top = parent
+	}
+	return New(filepath.Join(top, fmt.Sprintf(".Trash-%d", os.Getuid())))
+}
@@ -0,0 +1,21 @@
+// +build !linux
+
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: trash_other.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    the OS trash (macOS Trash, Windows Recycle Bin) is not yet supported on
+//    other systems, so files are always moved to a trash directory of our own.
+//
+// =============================================================================
+
+package trash
+
+// function osTrash() always returns nil, since the OS trash is unsupported.
+func osTrash(absPath string) *Trash {
+	return nil
+}
@@ -0,0 +1,188 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: verify.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    verifies the integrity of media files: that their content hasn't changed
+//    since it was last verified, and that it can still be decoded.
+//
+// =============================================================================
+
+// package verify detects media files damaged by failing disks or bit rot. each
+// file's checksum is recorded the first time it is verified; thereafter, a
+// file whose content no longer matches its checksum -- even though its size
+// and modification time are unchanged, i.e. it wasn't deliberately modified --
+// has been damaged. if ffmpeg is installed, the files are also decoded in full
+// to find damage present from the start, e.g. an incomplete download.
+package verify
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant maxDecodeError is the maximum length of the decoder's output kept
+// as the reason a file failed verification.
+const maxDecodeError = 200
+
+// the decoder invoked to check that a file can be decoded, and its arguments
+// preceding and following the file path. ffmpeg decodes the file to nowhere,
+// reporting only errors.
+var (
+	decoder     = "ffmpeg"
+	decoderPre  = []string{"-nostdin", "-v", "error", "-i"}
+	decoderPost = []string{"-f", "null", "-"}
+)
+
+// function Checksum() returns the SHA-256 digest (hex) of the content of the
+// file at the given path.
+func Checksum(path string) (string, *rc.ReturnCode) {
+
+	f, err := os.Open(path)
+	if nil != err {
+		return "", rc.InvalidFile.Specf("Checksum(%q): os.Open(): %s", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); nil != err {
+		return "", rc.InvalidFile.Specf("Checksum(%q): %s", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// function CanDecode() returns true if the decoder used by Decode() is
+// installed.
+func CanDecode() bool {
+	_, err := exec.LookPath(decoder)
+	return nil == err
+}
+
+// function Decode() decodes the entire file at the given path, returning the
+// errors reported by the decoder, if any.
+func Decode(path string) *rc.ReturnCode {
+
+	var stderr bytes.Buffer
+	args := append(append(append([]string{}, decoderPre...), path), decoderPost...)
+	cmd := exec.Command(decoder, args...)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	// the decoder doesn't necessarily fail on recoverable errors, but it does
+	// always report them.
+	if msg := strings.TrimSpace(stderr.String()); "" != msg || nil != err {
+		if "" == msg {
+			msg = err.Error()
+		}
+		if len(msg) > maxDecodeError {
+			msg = msg[:maxDecodeError] + "..."
+		}
+		return rc.VerifyError.Specf("Decode(%q): %s", path, strings.Replace(msg, "\n", "; ", -1))
+	}
+	return nil
+}
+
+// function Verify() verifies the file of the given media (see File()),
+// recording the outcome in the media's Checksum, Verified, and VerifyError
+// fields. the file is also decoded if decode is true. a file deliberately
+// modified since it was last verified (its size or modification time differ)
+// is not a failure; its new checksum is recorded instead. returns the reason
+// verification failed, if it did.
+func Verify(m *media.Media, decode bool) *rc.ReturnCode {
+
+	m.Verified = time.Now()
+	fail := func(ret *rc.ReturnCode) *rc.ReturnCode {
+		m.VerifyError = ret.Error()
+		return ret
+	}
+
+	info, err := os.Stat(m.File())
+	if nil != err {
+		return fail(rc.VerifyError.Specf("Verify(%q): os.Stat(): %s", m.File(), err))
+	}
+	if m.IsDisc() {
+		// the folder of a video disc has no content of its own to check.
+		m.VerifyError = ""
+		return nil
+	}
+	sum, ret := Checksum(m.File())
+	if nil != ret {
+		return fail(rc.VerifyError.Specf("Verify(%q): %s", m.File(), ret))
+	}
+	modified := info.Size() != m.Size || !info.ModTime().Equal(m.TimeModified)
+	if "" != m.Checksum && sum != m.Checksum && !modified {
+		return fail(rc.VerifyError.Specf(
+			"Verify(%q): content changed without being modified (checksum %.12s, expected %.12s)",
+			m.File(), sum, m.Checksum))
+	}
+	m.Checksum, m.Size, m.TimeModified = sum, info.Size(), info.ModTime()
+
+	if decode {
+		if ret := Decode(m.File()); nil != ret {
+			return fail(ret)
+		}
+	}
+	m.VerifyError = ""
+	return nil
+}
+
+// function Due() returns (at most) the given number of media from the given
+// list that were verified least recently, never-verified media first.
+func Due(list []*media.Media, n int) []*media.Media {
+
+	due := append([]*media.Media{}, list...)
+	sort.SliceStable(due, func(a, b int) bool {
+		return due[a].Verified.Before(due[b].Verified)
+	})
+	if n < 0 {
+		n = 0
+	}
+	if n < len(due) {
+		due = due[:n]
+	}
+	return due
+}
+
+// function BatchSize() returns the number of media, out of the given total,
+// that must be verified every interval in order to verify the given percent
+// of them per day. at least one is verified per interval unless the percent
+// is zero.
+func BatchSize(total int, percent float64, interval time.Duration) int {
+	if total <= 0 || percent <= 0 {
+		return 0
+	}
+	perDay := float64(total) * math.Min(percent, 100) / 100
+	n := int(math.Ceil(perDay * float64(interval) / float64(24*time.Hour)))
+	if n > total {
+		return total
+	}
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// function Failed() returns the media from the given list whose most recent
+// verification failed.
+func Failed(list []*media.Media) []*media.Media {
+	failed := []*media.Media{}
+	for _, m := range list {
+		if "" != m.VerifyError {
+			failed = append(failed, m)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,276 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: versions.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    groups the videos of the same movie or episode found in several files,
+//    ranks them by quality, and records the decisions made about which to
+//    keep so that they aren't asked again.
+//
+// =============================================================================
+
+// package versions finds the videos that are versions of the same title, e.g.
+// a movie found both in 1080p and in 720p, or an episode found twice at
+// different bitrates, unlike package dedupe, which finds byte-identical
+// copies. the versions of each title are ranked by their resolution and
+// bitrate (read by ffprobe, see SetProbe() of Library), and the decision of
+// which to keep, and what to do with the others, is recorded in a single file
+// alongside the configuration, shared by all libraries.
+package versions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// constant DefaultFileName is the name of the file, in the configuration
+// directory, in which the decisions are saved.
+const DefaultFileName = "versions.json"
+
+// constant HiddenTag is the tag given to the versions hidden by a decision
+// (see ActionHide), which are then omitted wherever media are listed.
+const HiddenTag = "hidden-version"
+
+// the actions taken on the versions of a title other than the one kept.
+const (
+	ActionKeep   = "keep"   // keep every version
+	ActionDelete = "delete" // move the others to the trash
+	ActionHide   = "hide"   // tag the others with HiddenTag
+)
+
+// variable Actions lists every action, in the order offered.
+var Actions = []string{ActionDelete, ActionHide, ActionKeep}
+
+// function ValidAction() returns true if the given name is an action.
+func ValidAction(name string) bool {
+	for _, a := range Actions {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// type Group is a set of videos that are versions of the same title.
+type Group struct {
+	Key      string              // identifies the title (see Key())
+	Title    string              // describes the title, e.g. "Show S01E02"
+	Versions []*media.VideoMedia // the versions, best first (see Better())
+}
+
+// function Paths() returns the path of each version of the Group, sorted.
+func (g *Group) Paths() []string {
+	path := make([]string, len(g.Versions))
+	for i, v := range g.Versions {
+		path[i] = v.AbsPath
+	}
+	sort.Strings(path)
+	return path
+}
+
+// function normalize() reduces the given title to its lower-case letters and
+// digits, separated by single spaces, so that titles differing only in case
+// and punctuation are the same.
+func normalize(title string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// function Key() returns the key identifying the title of which the given
+// video is a version: the series, season, and episode of an episode, or the
+// title and year of a movie. returns the empty string if the title is unknown.
+func Key(v *media.VideoMedia) string {
+	if "" != v.Series {
+		if s := normalize(v.Series); "" != s {
+			return fmt.Sprintf("episode:%s:s%de%d", s, v.Season, v.Episode)
+		}
+	}
+	if t := normalize(v.Title); "" != t {
+		return fmt.Sprintf("movie:%s:%d", t, v.Year)
+	}
+	return ""
+}
+
+// function describe() returns the title of which the given video is a version,
+// as shown to the user.
+func describe(v *media.VideoMedia) string {
+	if "" != v.Series {
+		return fmt.Sprintf("%s S%02dE%02d", v.Series, v.Season, v.Episode)
+	}
+	if v.Year > 0 {
+		return fmt.Sprintf("%s (%d)", v.Title, v.Year)
+	}
+	return v.Title
+}
+
+// function Bitrate() returns the average bitrate (bits per second) of the
+// given video, computed from its size and duration, or 0 if its duration is
+// unknown.
+func Bitrate(v *media.VideoMedia) int64 {
+	if v.Duration <= 0 {
+		return 0
+	}
+	return int64(float64(v.Size*8) / v.Duration.Seconds())
+}
+
+// function Better() returns true if the version a is of better quality than b:
+// of higher resolution (in pixels), or else of higher bitrate, or else larger.
+func Better(a, b *media.VideoMedia) bool {
+	if pa, pb := a.Width*a.Height, b.Width*b.Height; pa != pb {
+		return pa > pb
+	}
+	if ra, rb := Bitrate(a), Bitrate(b); ra != rb {
+		return ra > rb
+	}
+	if a.Size != b.Size {
+		return a.Size > b.Size
+	}
+	return a.AbsPath < b.AbsPath
+}
+
+// function Quality() describes the quality of the given version, e.g.
+// "1920x1080 h264, 8.2 Mbit/s".
+func Quality(v *media.VideoMedia) string {
+	q := []string{}
+	if v.Width > 0 && v.Height > 0 {
+		q = append(q, strings.TrimSpace(fmt.Sprintf("%dx%d %s", v.Width, v.Height, v.VideoCodec)))
+	}
+	if r := Bitrate(v); r > 0 {
+		q = append(q, fmt.Sprintf("%.1f Mbit/s", float64(r)/1e6))
+	}
+	if 0 == len(q) {
+		return "unknown quality (not probed)"
+	}
+	return strings.Join(q, ", ")
+}
+
+// function Find() returns each Group of two or more of the given videos that
+// are versions of the same title, sorted by title. the parts of a video split
+// into several files are represented by the first part only, and the videos
+// whose title is unknown are never grouped.
+func Find(list []*media.VideoMedia) []*Group {
+
+	index := map[string]*Group{}
+	for _, v := range list {
+		if "" != v.PartOf {
+			continue
+		}
+		key := Key(v)
+		if "" == key {
+			continue
+		}
+		g, ok := index[key]
+		if !ok {
+			g = &Group{Key: key, Title: describe(v)}
+			index[key] = g
+		}
+		g.Versions = append(g.Versions, v)
+	}
+
+	group := []*Group{}
+	for _, g := range index {
+		if len(g.Versions) < 2 {
+			continue
+		}
+		sort.Slice(g.Versions, func(a, b int) bool { return Better(g.Versions[a], g.Versions[b]) })
+		group = append(group, g)
+	}
+	sort.Slice(group, func(a, b int) bool {
+		if group[a].Title != group[b].Title {
+			return group[a].Title < group[b].Title
+		}
+		return group[a].Key < group[b].Key
+	})
+	return group
+}
+
+// type Decision records which version of a title was kept, and what was done
+// with the others.
+type Decision struct {
+	Key    string    // identifies the title (see Key())
+	Keep   string    // path of the version kept (empty if ActionKeep)
+	Action string    // action taken on the others (see Actions)
+	Paths  []string  // paths of the versions handled when decided, sorted
+	Time   time.Time // date the decision was made
+}
+
+// function Decides() returns true if the Decision was made about the given
+// Group, with the same versions, so it needn't be asked again. a version
+// found since, or one that couldn't be handled, must be decided anew.
+func (d *Decision) Decides(g *Group) bool {
+
+	if nil == d || d.Key != g.Key {
+		return false
+	}
+	decided := map[string]bool{}
+	for _, p := range d.Paths {
+		decided[p] = true
+	}
+	for _, p := range g.Paths() {
+		if !decided[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// function Load() reads the decisions saved in the file at the given path,
+// keyed by the title decided. a file that doesn't exist contains none.
+func Load(path string) (map[string]*Decision, *rc.ReturnCode) {
+
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		if os.IsNotExist(err) {
+			return map[string]*Decision{}, nil
+		}
+		return nil, rc.InvalidConfig.Specf("Load(%q): %s", path, err)
+	}
+	list := []*Decision{}
+	if err := json.Unmarshal(data, &list); nil != err {
+		return nil, rc.InvalidJSONData.Specf("Load(%q): json.Unmarshal(): %s", path, err)
+	}
+	decided := map[string]*Decision{}
+	for _, d := range list {
+		decided[d.Key] = d
+	}
+	return decided, nil
+}
+
+// function Save() writes the given decisions to the file at the given path,
+// replacing its content.
+func Save(path string, decided map[string]*Decision) *rc.ReturnCode {
+
+	list := make([]*Decision, 0, len(decided))
+	for _, d := range decided {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Key < list[b].Key })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if nil != err {
+		return rc.InvalidJSONData.Specf("Save(%q): json.MarshalIndent(): %s", path, err)
+	}
+	// write a temporary file first, so that an interrupted write never leaves
+	// behind a truncated file.
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); nil != err {
+		return rc.InvalidConfig.Specf("Save(%q): %s", path, err)
+	}
+	if err := os.Rename(tmp, path); nil != err {
+		os.Remove(tmp)
+		return rc.InvalidConfig.Specf("Save(%q): %s", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// pimmp web interface: lists the media served by the JSON API (see package
+// web), and streams or plays the one selected.
+"use strict";
+
+const $ = (id) => document.getElementById(id);
+const icons = { video: "\u{1F39E}", audio: "♫", image: "\u{1F5BC}", document: "\u{1F4D6}" };
+
+let timer = null;
+
+// fetches the given API path, returning its JSON content.
+async function api(path, options) {
+  const resp = await fetch(path, options);
+  if (!resp.ok) {
+    throw new Error(`${path}: ${resp.status} ${await resp.text()}`);
+  }
+  return resp.status === 204 || resp.status === 202 ? null : resp.json();
+}
+
+// formats the given size in bytes, e.g. "1.4 GiB".
+function humanSize(n) {
+  const units = ["B", "KiB", "MiB", "GiB", "TiB"];
+  let i = 0;
+  while (n >= 1024 && i < units.length - 1) {
+    n /= 1024;
+    i++;
+  }
+  return `${n.toFixed(i ? 1 : 0)} ${units[i]}`;
+}
+
+// formats the given length in seconds, e.g. "1:42:05".
+function humanDuration(s) {
+  s = Math.round(s);
+  const h = Math.floor(s / 3600), m = Math.floor(s / 60) % 60, sec = s % 60;
+  const pad = (v) => String(v).padStart(2, "0");
+  return h ? `${h}:${pad(m)}:${pad(sec)}` : `${m}:${pad(sec)}`;
+}
+
+// fills the library selector.
+async function loadLibraries() {
+  const sel = $("library");
+  const current = sel.value;
+  sel.length = 1;
+  for (const lib of await api("api/libraries")) {
+    sel.add(new Option(`${lib.Name} (${lib.Media})`, lib.Name));
+  }
+  sel.value = current;
+}
+
+// lists the media matching the current search.
+async function search() {
+  const params = new URLSearchParams({
+    q: $("search").value,
+    kind: $("kind").value,
+    library: $("library").value,
+  });
+  const list = await api(`api/media?${params}`);
+  const grid = $("grid");
+  grid.replaceChildren();
+  for (const item of list) {
+    grid.append(card(item));
+  }
+  $("count").textContent = `${list.length} media`;
+}
+
+// returns the element showing the given media in the grid.
+function card(item) {
+  const el = document.createElement("div");
+  el.className = "card" + (item.Watched ? " watched" : "");
+  el.title = item.Path;
+
+  const art = document.createElement("div");
+  art.className = "art";
+  if (item.Poster) {
+    art.style.backgroundImage = `url("api/poster/${item.ID}")`;
+  } else if (item.Thumb) {
+    art.style.backgroundImage = `url("api/thumbnail/${item.ID}")`;
+    art.classList.add(item.Kind === "audio" ? "waveform" : "frame");
+  } else {
+    art.textContent = icons[item.Kind] || "?";
+  }
+  const name = document.createElement("div");
+  name.className = "name";
+  name.textContent = item.Title;
+  const meta = document.createElement("div");
+  meta.className = "meta";
+  meta.textContent = [item.Kind, item.Duration ? humanDuration(item.Duration) : "", humanSize(item.Size)]
+    .filter(Boolean).join(" · ");
+
+  el.append(art, name, meta);
+  el.addEventListener("click", () => show(item));
+  return el;
+}
+
+// opens the detail view of the given media.
+function show(item) {
+  $("detail-title").textContent = item.Title;
+  $("detail-info").textContent = [item.Library, item.Path, humanSize(item.Size),
+    item.Rating ? `rated ${item.Rating}` : "", item.Tags.join(", "), `added ${item.Added}`]
+    .filter(Boolean).join(" · ");
+  $("detail-player").replaceChildren();
+  if (item.Thumb) {
+    // a frame of the video, or the waveform of the audio, until streamed.
+    const thumb = document.createElement("img");
+    thumb.src = `api/thumbnail/${item.ID}`;
+    thumb.alt = "";
+    $("detail-player").append(thumb);
+  }
+  // the file's own URL names the file, e.g. for players like VLC.
+  $("detail-download").href = item.File || `api/stream/${item.ID}`;
+  $("detail-download").download = item.Path.split(/[\\/]/).pop();
+  $("detail-stream").disabled = item.Kind === "document" || !item.File;
+  $("detail-stream").onclick = () => stream(item);
+  $("detail-play").onclick = () => play(item);
+  $("detail").showModal();
+}
+
+// streams the given media into the detail view.
+function stream(item) {
+  const tag = { video: "video", audio: "audio", image: "img" }[item.Kind];
+  if (!tag) {
+    return;
+  }
+  const el = document.createElement(tag);
+  el.src = item.File;
+  if (tag !== "img") {
+    el.controls = true;
+    el.autoplay = true;
+  }
+  $("detail-player").replaceChildren(el);
+}
+
+// plays the given media on the host.
+async function play(item) {
+  try {
+    await api(`api/play/${item.ID}`, { method: "POST" });
+  } catch (err) {
+    alert(err.message);
+  }
+}
+
+// reads the libraries again, then repeats the search.
+async function reload() {
+  await api("api/reload", { method: "POST" });
+  await loadLibraries();
+  await search();
+}
+
+$("search").addEventListener("input", () => {
+  clearTimeout(timer);
+  timer = setTimeout(search, 250);
+});
+$("kind").addEventListener("change", search);
+$("library").addEventListener("change", search);
+$("reload").addEventListener("click", reload);
+$("detail").addEventListener("close", () => $("detail-player").replaceChildren());
+
+loadLibraries().then(search).catch((err) => {
+  $("grid").textContent = err.message;
+});
@@ -0,0 +1,151 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: auth.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    authenticates the requests to the web interface by HTTP basic auth or
+//    bearer tokens, and permits each only what the role of its credentials
+//    allows.
+//
+// =============================================================================
+
+package web
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// the roles of credentials, each permitting some of the requests.
+const (
+	RoleRead  = "read"  // browse, stream, and download the media
+	RoleAdmin = "admin" // also play media on the host and reload the libraries
+)
+
+// constant authRealm names the protection space of the credentials, shown by
+// browsers when prompting for them.
+const authRealm = "pimmp"
+
+// variable adminPaths lists the prefixes of the paths of the requests only
+// permitted with the role RoleAdmin.
+var adminPaths = []string{"/api/play/", "/api/reload"}
+
+// type Credentials are the users (by name and password) and bearer tokens
+// permitted to make requests, each with a role.
+type Credentials struct {
+	users  map[string]*user
+	tokens map[[sha256.Size]byte]string // role of each token, keyed by its hash
+}
+
+// type user is the password, hashed, and role of a user.
+type user struct {
+	hash [sha256.Size]byte
+	role string
+}
+
+// function ParseCredentials() parses the given users, each "name:password"
+// optionally followed by ":read" or ":admin" (the default is read), and bearer
+// tokens, each "token" optionally followed by a role the same way. a name never
+// contains a colon, but a password may, so only a known role following another
+// colon after the password is taken as its role: "bob:admin" is user bob with
+// password admin. returns nil if there are neither, in which case requests
+// aren't authenticated.
+func ParseCredentials(users, tokens []string) (*Credentials, *rc.ReturnCode) {
+
+	c := &Credentials{users: map[string]*user{}, tokens: map[[sha256.Size]byte]string{}}
+	for _, u := range users {
+		field := strings.SplitN(u, ":", 2)
+		if len(field) < 2 || "" == field[0] || "" == field[1] {
+			return nil, rc.InvalidArgs.Specf("ParseCredentials(): invalid user (expected name:password[:role]): %q", u)
+		}
+		name, password, role := field[0], field[1], RoleRead
+		if strings.Contains(password, ":") {
+			password, role = splitRole(password)
+		}
+		if "" == password {
+			return nil, rc.InvalidArgs.Specf("ParseCredentials(): invalid user (expected name:password[:role]): %q", u)
+		}
+		if _, ok := c.users[name]; ok {
+			return nil, rc.InvalidArgs.Specf("ParseCredentials(): user given twice: %q", name)
+		}
+		c.users[name] = &user{hash: sha256.Sum256([]byte(password)), role: role}
+	}
+	for _, t := range tokens {
+		token, role := splitRole(t)
+		if "" == token {
+			return nil, rc.InvalidArgs.Specf("ParseCredentials(): invalid token (expected token[:role]): %q", t)
+		}
+		c.tokens[sha256.Sum256([]byte(token))] = role
+	}
+	if 0 == len(c.users) && 0 == len(c.tokens) {
+		return nil, nil
+	}
+	return c, nil
+}
+
+// function splitRole() splits the given secret at its last colon if a known
+// role follows it, returning the secret before it and the role, or else the
+// whole secret and RoleRead.
+func splitRole(s string) (string, string) {
+	if sep := strings.LastIndex(s, ":"); sep >= 0 {
+		switch role := s[sep+1:]; role {
+		case RoleRead, RoleAdmin:
+			return s[:sep], role
+		}
+	}
+	return s, RoleRead
+}
+
+// function role() returns the role of the credentials of the given request,
+// or false if it has none, or they are wrong. passwords and tokens are
+// compared by their hashes, in constant time.
+func (c *Credentials) role(r *http.Request) (string, bool) {
+
+	if name, password, ok := r.BasicAuth(); ok {
+		u, found := c.users[name]
+		hash := sha256.Sum256([]byte(password))
+		if !found || 1 != subtle.ConstantTimeCompare(hash[:], u.hash[:]) {
+			return "", false
+		}
+		return u.role, true
+	}
+	auth := r.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold("bearer ", auth[:7]) {
+		// the map lookup isn't constant time, but it is of the hash, which
+		// reveals nothing of the token.
+		role, ok := c.tokens[sha256.Sum256([]byte(strings.TrimSpace(auth[7:])))]
+		return role, ok
+	}
+	return "", false
+}
+
+// function authenticate() returns a handler serving the requests made with
+// the given Credentials through the given handler, refusing the others: those
+// without valid credentials are unauthorized (prompting a browser for them),
+// and those not permitted by the role of theirs are forbidden.
+func authenticate(c *Credentials, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role, ok := c.role(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+authRealm+`", charset="UTF-8"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if RoleAdmin != role {
+			for _, p := range adminPaths {
+				if strings.HasPrefix(r.URL.Path, p) {
+					http.Error(w, "forbidden: requires role "+RoleAdmin, http.StatusForbidden)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
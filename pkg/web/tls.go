@@ -0,0 +1,110 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: tls.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    generates the self-signed certificate with which the web interface is
+//    served over TLS when no other certificate is given.
+//
+// =============================================================================
+
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ardnew.com/pimmp/pkg/rc"
+)
+
+// exported constants for the self-signed certificate.
+const (
+	CertFileName = "web-cert.pem" // name of the certificate file, in the configuration directory
+	KeyFileName  = "web-key.pem"  // name of the private key file, in the configuration directory
+)
+
+// local unexported constants for the self-signed certificate.
+const (
+	certValidity = 365 * 24 * time.Hour // how long a certificate generated is valid
+	certRenewal  = 7 * 24 * time.Hour   // how long before it expires it is replaced
+)
+
+// function SelfSignedCert() ensures that the files at the given paths contain
+// a certificate and its private key, valid for at least another week, reusing
+// those already there if so, or else generating a new self-signed certificate
+// valid for a year for the given host names and IP addresses, along with
+// localhost. browsers warn about a self-signed certificate until it is
+// trusted, which is only once if it is reused.
+func SelfSignedCert(certPath, keyPath string, hosts []string) *rc.ReturnCode {
+
+	if pair, err := tls.LoadX509KeyPair(certPath, keyPath); nil == err && len(pair.Certificate) > 0 {
+		if cert, err := x509.ParseCertificate(pair.Certificate[0]); nil == err &&
+			time.Now().Add(certRenewal).Before(cert.NotAfter) {
+			return nil
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if nil != err {
+		return rc.ServerError.Specf("SelfSignedCert(): ecdsa.GenerateKey(): %s", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if nil != err {
+		return rc.ServerError.Specf("SelfSignedCert(): rand.Int(): %s", err)
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"pimmp"}, CommonName: "pimmp"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(certValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range append([]string{"localhost", "127.0.0.1", "::1"}, hosts...) {
+		if ip := net.ParseIP(h); nil != ip {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else if "" != h {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if nil != err {
+		return rc.ServerError.Specf("SelfSignedCert(): x509.CreateCertificate(): %s", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if nil != err {
+		return rc.ServerError.Specf("SelfSignedCert(): x509.MarshalECPrivateKey(): %s", err)
+	}
+
+	write := func(path, kind string, data []byte, perm os.FileMode) *rc.ReturnCode {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); nil != err {
+			return rc.ServerError.Specf("SelfSignedCert(%q): os.MkdirAll(): %s", path, err)
+		}
+		block := pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: data})
+		if err := ioutil.WriteFile(path, block, perm); nil != err {
+			return rc.ServerError.Specf("SelfSignedCert(%q): %s", path, err)
+		}
+		return nil
+	}
+	// the key is written first, and only readable by its owner.
+	if ret := write(keyPath, "EC PRIVATE KEY", keyDER, 0600); nil != ret {
+		return ret
+	}
+	return write(certPath, "CERTIFICATE", der, 0644)
+}
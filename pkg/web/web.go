@@ -0,0 +1,646 @@
+// =============================================================================
+//  PROJ: pimmp
+//  AUTH: ardnew
+//  DATE: 16 Oct 2026
+//  FILE: web.go
+// -----------------------------------------------------------------------------
+//
+//  DESCRIPTION
+//    serves a minimal single-page web interface over HTTP for browsing and
+//    playing the media of the libraries, e.g. on a headless machine.
+//
+// =============================================================================
+
+// package web serves a browser-based interface to the libraries: a single page
+// (embedded in the executable, see directory assets) listing their media, with
+// searching and posters, from which media can be streamed to the browser or
+// played on the host by its configured player. the page talks to the server by
+// a small JSON API:
+//
+//	GET  /api/libraries     the libraries served, with their number of media
+//	GET  /api/media         the media, filtered by the parameters q (text),
+//	                        rule (a query, see package query), kind, and
+//	                        library
+//	GET  /api/poster/<id>   the artwork of the media with the given ID (online
+//	                        artwork is downloaded and cached, if given a
+//	                        cache, see SetArtworkCache())
+//	GET  /api/thumbnail/<id>
+//	                        a frame of the video, or the waveform of the audio,
+//	                        with the given ID (see package thumbnail)
+//	GET  /api/stream/<id>   the file of the media, supporting range requests
+//	GET  /api/file/<library>/<kind>/<record>[/<name>]
+//	                        the file of the media with the given record ID in
+//	                        the collection of the given kind (see Item), for
+//	                        players like VLC; the trailing file name is ignored
+//	POST /api/play/<id>     plays the media on the host
+//	POST /api/reload        reads the libraries' databases again
+//
+// unless given credentials (see SetCredentials()), nothing is authenticated,
+// so the server should then only listen on addresses reachable by trusted
+// clients. with credentials, each request needs a user's name and password
+// (HTTP basic auth, which browsers prompt for) or a bearer token, whose role
+// permits it: any role may browse and stream the media, but only RoleAdmin may
+// play them on the host or reload the libraries. credentials are sent in the
+// clear unless served over TLS (see SetTLS()).
+package web
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ardnew.com/pimmp/pkg/console"
+	"ardnew.com/pimmp/pkg/filecache"
+	"ardnew.com/pimmp/pkg/library"
+	"ardnew.com/pimmp/pkg/media"
+	"ardnew.com/pimmp/pkg/query"
+	"ardnew.com/pimmp/pkg/rc"
+	"ardnew.com/pimmp/pkg/thumbnail"
+)
+
+// var logs are the loggers of the messages about the web interface, filtered as
+// component "web" (see console.Register()).
+var logs = console.Register("web")
+
+// local unexported constants for the web server.
+const (
+	shutdownTimeout = 5 * time.Second  // time allowed for requests to finish
+	artworkTimeout  = 20 * time.Second // time allowed to download artwork
+	maxArtworkLen   = 16 << 20         // largest artwork downloaded
+	artworkDirName  = "artwork"        // directory of the artwork, in the file cache
+)
+
+// constant DefaultAddr is the address on which the server listens by default,
+// which is only reachable from the host itself.
+const DefaultAddr = "localhost:8642"
+
+//go:embed assets
+var assets embed.FS
+
+// type PlayFunc plays the given media of the given library on the host,
+// returning once playback has finished.
+type PlayFunc func(*library.Library, *media.Media) *rc.ReturnCode
+
+// type Item is the description of a media sent to the page.
+type Item struct {
+	ID       string   // ID of the media (see ID() of Entity)
+	RecordID int      // ID of the media's record in its library's database
+	Library  string   // name of the library containing the media
+	Kind     string   // kind of media: "audio", "video", "image", or "document"
+	Title    string   // title of the media, or its name if untitled
+	Path     string   // path of the media file, relative to its library
+	Ext      string   // file name extension, e.g. ".mkv"
+	Size     int64    // length in bytes
+	Added    string   // date the media was added to its library (YYYY-MM-DD)
+	Duration float64  // length in seconds, 0 if unknown
+	Rating   int64    // user-assigned rating (0 = unrated)
+	Tags     []string // user-assigned tags
+	Poster   bool     // true if the media has artwork (see /api/poster)
+	Thumb    bool     // true if the media can have a thumbnail (see /api/thumbnail)
+	Watched  bool     // true if the media was played to completion
+	File     string   // path of the media's file under /api/file, empty for tracks of cue sheets
+}
+
+// type entry is a media served, along with the library containing it.
+type entry struct {
+	lib  *library.Library
+	med  *media.Media
+	item *Item
+}
+
+// type Server serves the web interface to the media of some libraries. the
+// media are read from the libraries' databases once when the server starts,
+// and again on each request to /api/reload.
+type Server struct {
+	libs   []*library.Library
+	accept func(*media.Media) bool // selects the media served
+	play   PlayFunc                // plays media on the host, nil to disallow
+	thumbs *thumbnail.Cache        // thumbnails of the media, nil to disallow
+	art    *filecache.Cache        // artwork downloaded, nil to redirect to it
+	fetch  sync.Mutex              // serializes the downloads of artwork
+	creds  *Credentials            // permitted to make requests, nil to permit all
+	cert   string                  // path of the TLS certificate, empty to serve HTTP
+	key    string                  // path of the TLS certificate's private key
+	ctx    context.Context         // lifetime of the server, set by ListenAndServe()
+	mutex  sync.RWMutex
+	list   []*entry          // media served, sorted by library and path
+	byID   map[string]*entry // media served, by ID
+}
+
+// function New() creates a new Server for the media of the given libraries
+// accepted by the given filter (nil accepts all). media are played on the host
+// by the given function, or never if nil.
+func New(libs []*library.Library, accept func(*media.Media) bool, play PlayFunc) *Server {
+	if nil == accept {
+		accept = func(*media.Media) bool { return true }
+	}
+	return &Server{libs: libs, accept: accept, play: play}
+}
+
+// function SetThumbnails() serves the thumbnails of the media from the given
+// Cache, generating those missing, or none if nil.
+func (s *Server) SetThumbnails(c *thumbnail.Cache) {
+	s.thumbs = c
+}
+
+// function SetArtworkCache() downloads the online artwork of the media into
+// the given cache, serving it from there, or redirects to it if nil.
+func (s *Server) SetArtworkCache(c *filecache.Cache) {
+	s.art = c
+}
+
+// function SetCredentials() requires the given Credentials of every request,
+// or of none if nil.
+func (s *Server) SetCredentials(c *Credentials) {
+	s.creds = c
+}
+
+// function SetTLS() serves HTTPS using the certificate and private key in the
+// PEM files at the given paths (see SelfSignedCert()), or HTTP if empty.
+func (s *Server) SetTLS(cert, key string) {
+	s.cert, s.key = cert, key
+}
+
+// function Reload() reads the media of the libraries from their databases.
+// the media already served are kept if the given Context is done before they
+// were all read, or any library couldn't be read, rather than serving only
+// some of them.
+func (s *Server) Reload(ctx context.Context) *rc.ReturnCode {
+
+	list := []*entry{}
+	bus := library.NewBus()
+	bus.Subscribe(func(e library.Event) {
+		d := e.Discovery
+		if m := d.Media(); nil != m && s.accept(m) {
+			item := newItem(d.Library, m, d.RecordID)
+			item.Thumb = nil != s.thumbs && thumbnail.Supports(m)
+			list = append(list, &entry{lib: d.Library, med: m, item: item})
+		}
+	}, library.MediaDiscovered)
+	for _, l := range s.libs {
+		if nil != ctx.Err() {
+			return rc.Canceled.Specf("Reload(): %s", ctx.Err())
+		}
+		_, ret := l.Load(ctx, bus)
+		if nil != ret {
+			return ret
+		}
+	}
+	if nil != ctx.Err() {
+		return rc.Canceled.Specf("Reload(): %s", ctx.Err())
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].item.Library != list[b].item.Library {
+			return list[a].item.Library < list[b].item.Library
+		}
+		return list[a].item.Path < list[b].item.Path
+	})
+	byID := map[string]*entry{}
+	for _, e := range list {
+		byID[e.item.ID] = e
+	}
+
+	s.mutex.Lock()
+	s.list, s.byID = list, byID
+	s.mutex.Unlock()
+	logs.Info.Verbosef("serving %d media", len(list))
+	return nil
+}
+
+// function newItem() returns the description of the given media of the given
+// library, with the given record ID, sent to the page.
+func newItem(l *library.Library, m *media.Media, id int) *Item {
+
+	title := m.Title
+	if "" == title {
+		title = m.Name
+	}
+	if "" == title {
+		title = m.AbsBase
+	}
+	kind := kindName(m.Kind)
+	file := ""
+	if !m.IsTrack() && !m.IsDisc() {
+		file = "/api/file/" + url.PathEscape(l.Name()) + "/" + kind + "/" +
+			strconv.Itoa(id) + "/" + url.PathEscape(m.AbsName)
+	}
+	tags := m.Tags
+	if nil == tags {
+		tags = []string{}
+	}
+	return &Item{
+		ID:       m.ID(),
+		RecordID: id,
+		Library:  l.Name(),
+		Kind:     kind,
+		Title:    title,
+		Path:     m.RelPath,
+		Ext:      m.Ext,
+		Size:     m.Size,
+		Added:    m.TimeAdded.Local().Format("2006-01-02"),
+		Duration: m.Duration.Seconds(),
+		Rating:   m.Rating,
+		Tags:     tags,
+		Poster:   "" != poster(m),
+		Watched:  m.Watched,
+		File:     file,
+	}
+}
+
+// function kindName() returns the name of the given kind of media in the API,
+// e.g. "video".
+func kindName(kind media.MediaKind) string {
+	if kind < 0 || kind >= media.KindCOUNT {
+		return "unknown"
+	}
+	return strings.ToLower(media.MediaColName[kind])
+}
+
+// function poster() returns the path or URL of the artwork depicting the given
+// media, preferring its poster, or "" if it has none.
+func poster(m *media.Media) string {
+	if p, ok := m.Artwork["poster"]; ok && "" != p {
+		return p
+	}
+	if "" != m.ArtworkFile {
+		return m.ArtworkFile
+	}
+	for _, p := range m.Artwork {
+		if "" != p {
+			return p
+		}
+	}
+	return ""
+}
+
+// function Handler() returns the HTTP handler serving the page and its API.
+func (s *Server) Handler() http.Handler {
+
+	static, err := fs.Sub(assets, "assets")
+	if nil != err {
+		panic(err) // the assets are embedded, so this is never reached
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(static)))
+	mux.HandleFunc("/api/libraries", s.serveLibraries)
+	mux.HandleFunc("/api/media", s.serveMedia)
+	mux.HandleFunc("/api/poster/", s.servePoster)
+	mux.HandleFunc("/api/thumbnail/", s.serveThumbnail)
+	mux.HandleFunc("/api/stream/", s.serveStream)
+	mux.HandleFunc("/api/file/", s.serveFile)
+	mux.HandleFunc("/api/play/", s.servePlay)
+	mux.HandleFunc("/api/reload", s.serveReload)
+	if nil != s.creds {
+		return authenticate(s.creds, mux)
+	}
+	return mux
+}
+
+// function ListenAndServe() reads the media of the libraries and serves the
+// web interface on the given address until the given Context is done.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) *rc.ReturnCode {
+
+	ln, err := net.Listen("tcp", addr)
+	if nil != err {
+		return rc.ServerError.Specf("ListenAndServe(%q): %s", addr, err)
+	}
+	s.ctx = ctx
+	if ret := s.Reload(ctx); nil != ret {
+		logs.Warn.Log(ret)
+	}
+
+	srv := &http.Server{Handler: s.Handler()}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shut, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		srv.Shutdown(shut)
+	}()
+
+	if "" != s.cert {
+		logs.Info.Logf("serving web interface: https://%s/", ln.Addr())
+		err = srv.ServeTLS(ln, s.cert, s.key)
+	} else {
+		logs.Info.Logf("serving web interface: http://%s/", ln.Addr())
+		err = srv.Serve(ln)
+	}
+	if nil != err && http.ErrServerClosed != err {
+		return rc.ServerError.Specf("ListenAndServe(%q): %s", addr, err)
+	}
+	<-done
+	return nil
+}
+
+// function find() returns the media served with the ID at the end of the
+// request's path, after the given prefix, or nil if there is none.
+func (s *Server) find(r *http.Request, prefix string) *entry {
+	id := strings.ToLower(strings.TrimPrefix(r.URL.Path, prefix))
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.byID[id]
+}
+
+// function writeJSON() writes the given value to the response as JSON.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); nil != err {
+		logs.Warn.Verbosef("cannot write response: %s", err)
+	}
+}
+
+// function serveLibraries() lists the libraries served, with the number of
+// media served from each.
+func (s *Server) serveLibraries(w http.ResponseWriter, r *http.Request) {
+
+	count := map[string]int{}
+	s.mutex.RLock()
+	for _, e := range s.list {
+		count[e.item.Library]++
+	}
+	s.mutex.RUnlock()
+
+	type lib struct {
+		Name  string // name of the library
+		Media int    // number of media served from it
+	}
+	list := []lib{}
+	for _, l := range s.libs {
+		list = append(list, lib{Name: l.Name(), Media: count[l.Name()]})
+	}
+	writeJSON(w, list)
+}
+
+// function serveMedia() lists the media served matching the text (parameter
+// q), query (parameter rule, see package query), kind, and library given, if
+// any. an invalid query is a bad request.
+func (s *Server) serveMedia(w http.ResponseWriter, r *http.Request) {
+
+	param := r.URL.Query()
+	text, kind, lib := param.Get("q"), param.Get("kind"), param.Get("library")
+	var q *query.Query
+	if rule := param.Get("rule"); "" != strings.TrimSpace(rule) {
+		var ret *rc.ReturnCode
+		if q, ret = query.Parse(rule); nil != ret {
+			http.Error(w, ret.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	list := []*Item{}
+	s.mutex.RLock()
+	for _, e := range s.list {
+		if ("" == kind || kind == e.item.Kind) && ("" == lib || lib == e.item.Library) &&
+			e.med.Matches(text) && (nil == q || q.Match(e.med)) {
+			list = append(list, e.item)
+		}
+	}
+	s.mutex.RUnlock()
+	writeJSON(w, list)
+}
+
+// function servePoster() serves the artwork of the media with the given ID,
+// redirecting to it if it is online.
+func (s *Server) servePoster(w http.ResponseWriter, r *http.Request) {
+
+	e := s.find(r, "/api/poster/")
+	if nil == e {
+		http.NotFound(w, r)
+		return
+	}
+	p := poster(e.med)
+	switch {
+	case "" == p:
+		http.NotFound(w, r)
+	case strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://"):
+		if nil != s.art {
+			path, ret := s.fetchArtwork(p)
+			if nil == ret {
+				http.ServeFile(w, r, path)
+				return
+			}
+			logs.Warn.Verbose(ret)
+		}
+		http.Redirect(w, r, p, http.StatusFound)
+	default:
+		// relative artwork paths are relative to the media's directory.
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(e.med.AbsDir, p)
+		}
+		http.ServeFile(w, r, p)
+	}
+}
+
+// function fetchArtwork() returns the path of the artwork at the given URL in
+// the artwork cache, downloading it first if it isn't cached.
+func (s *Server) fetchArtwork(link string) (string, *rc.ReturnCode) {
+
+	sum := sha256.Sum256([]byte(link))
+	ext := strings.ToLower(filepath.Ext(strings.SplitN(link, "?", 2)[0]))
+	if len(ext) > 5 {
+		ext = ""
+	}
+	key := artworkDirName + "/" + hex.EncodeToString(sum[:]) + ext
+	// the pages request the artwork of many media at once, which would
+	// otherwise download the same artwork into the same file at once.
+	s.fetch.Lock()
+	defer s.fetch.Unlock()
+	if path, ok := s.art.Get(key); ok {
+		return path, nil
+	}
+	return s.art.Put(key, func(tmp string) *rc.ReturnCode {
+		client := &http.Client{Timeout: artworkTimeout}
+		resp, err := client.Get(link)
+		if nil != err {
+			return rc.FetchError.Specf("fetchArtwork(%q): %s", link, err)
+		}
+		defer resp.Body.Close()
+		if http.StatusOK != resp.StatusCode {
+			return rc.FetchError.Specf("fetchArtwork(%q): %s", link, resp.Status)
+		}
+		f, err := os.Create(tmp)
+		if nil != err {
+			return rc.FetchError.Specf("fetchArtwork(%q): %s", link, err)
+		}
+		_, err = io.Copy(f, io.LimitReader(resp.Body, maxArtworkLen))
+		if cerr := f.Close(); nil == err {
+			err = cerr
+		}
+		if nil != err {
+			return rc.FetchError.Specf("fetchArtwork(%q): %s", link, err)
+		}
+		return nil
+	})
+}
+
+// function serveThumbnail() serves the thumbnail of the media with the given
+// ID, generating it first if it isn't cached.
+func (s *Server) serveThumbnail(w http.ResponseWriter, r *http.Request) {
+
+	e := s.find(r, "/api/thumbnail/")
+	if nil == e || nil == s.thumbs || !e.item.Thumb {
+		http.NotFound(w, r)
+		return
+	}
+	path, ret := e.lib.ThumbnailByID(s.thumbs, e.med.Kind, e.item.RecordID, e.med)
+	if nil != ret {
+		logs.Warn.Verbose(ret)
+		http.Error(w, ret.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	http.ServeFile(w, r, path)
+}
+
+// function serveStream() serves the file of the media with the given ID.
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+
+	e := s.find(r, "/api/stream/")
+	if nil == e {
+		http.NotFound(w, r)
+		return
+	}
+	sendFile(w, r, e.med)
+}
+
+// function serveFile() serves the file of the media with the record ID, in the
+// collection of the kind, of the library named by the request's path (see the
+// package description). the record is read from the database, so the media
+// need not have been read by Reload(), though it must be accepted all the same.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+
+	// the name of the library may itself contain escaped slashes.
+	seg := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/api/file/"), "/")
+	if len(seg) < 3 {
+		http.NotFound(w, r)
+		return
+	}
+	name, err := url.PathUnescape(seg[0])
+	if nil != err {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.Atoi(seg[2])
+	if nil != err || id < 0 {
+		http.Error(w, "invalid record ID: "+seg[2], http.StatusBadRequest)
+		return
+	}
+	kind := media.KindUnknown
+	for k := media.MediaKind(0); k < media.KindCOUNT; k++ {
+		if kindName(k) == seg[1] {
+			kind = k
+		}
+	}
+	var lib *library.Library
+	for _, l := range s.libs {
+		if name == l.Name() {
+			lib = l
+		}
+	}
+	if nil == lib || media.KindUnknown == kind {
+		http.NotFound(w, r)
+		return
+	}
+	_, med, ret := lib.MediaByID(kind, id)
+	if nil != ret || !s.accept(med) {
+		http.NotFound(w, r)
+		return
+	}
+	sendFile(w, r, med)
+}
+
+// function sendFile() writes the file of the given media to the response, with
+// the MIME type of its kind of file. range requests are supported, so that the
+// client can seek within it.
+func sendFile(w http.ResponseWriter, r *http.Request, m *media.Media) {
+
+	if m.IsTrack() || m.IsDisc() {
+		// the track of a cue sheet has no file of its own, nor does a disc.
+		http.NotFound(w, r)
+		return
+	}
+	f, err := os.Open(m.AbsPath)
+	if nil != err {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if nil != err {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// ServeContent() only guesses the type from the name if it isn't set, and
+	// the guess misses many media types, e.g. Matroska.
+	w.Header().Set("Content-Type", m.MIMEType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline",
+		map[string]string{"filename": m.AbsName}))
+	http.ServeContent(w, r, m.AbsName, info.ModTime(), f)
+}
+
+// function servePlay() plays the media with the given ID on the host. the
+// response is sent once playback has started, without waiting for it to end.
+func (s *Server) servePlay(w http.ResponseWriter, r *http.Request) {
+
+	if http.MethodPost != r.Method {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if nil == s.play {
+		http.Error(w, "playback on the host is disabled", http.StatusForbidden)
+		return
+	}
+	e := s.find(r, "/api/play/")
+	if nil == e {
+		http.NotFound(w, r)
+		return
+	}
+	logs.Info.Logf("playing: %q", e.med.AbsPath)
+	// the player updates the media it is given, which the other requests may
+	// be reading meanwhile.
+	m := *e.med
+	go func() {
+		if ret := s.play(e.lib, &m); nil != ret {
+			logs.Warn.Log(ret)
+		}
+	}()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// function serveReload() reads the media of the libraries from their databases
+// again, e.g. after they were scanned. they are read for the lifetime of the
+// server, not of the request, so that a client hanging up doesn't cut short the
+// media served to every other.
+func (s *Server) serveReload(w http.ResponseWriter, r *http.Request) {
+
+	if http.MethodPost != r.Method {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := s.ctx
+	if nil == ctx {
+		ctx = context.Background()
+	}
+	if ret := s.Reload(ctx); nil != ret {
+		logs.Warn.Log(ret)
+		http.Error(w, ret.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,115 +0,0 @@
-// =============================================================================
-//  PROJ: pimmp
-//  AUTH: ardnew
-//  DATE: 29 Nov 2018
-//  FILE: tui.go
-// -----------------------------------------------------------------------------
-//
-//  DESCRIPTION
-//    defines overall layout and management of UI widgets, keypress event
-//    handlers, and other high-level user interactions.
-//
-// =============================================================================
-
-package main
-
-import (
-	//"bytes"
-	//"fmt"
-	//"strconv"
-	//"strings"
-	//"sync"
-	//"sync/atomic"
-	"time"
-	//"unicode"
-
-	"github.com/gdamore/tcell"
-	"github.com/rivo/tview"
-)
-
-// the various refresh rates for the UI intended to lighten the CPU load when
-// idle or not actively in use, while remaining highly responsive when active.
-var (
-	idleUpdateFreq time.Duration = 30 * time.Second
-	busyUpdateFreq time.Duration = 100 * time.Millisecond
-)
-
-var (
-	// the term "interactive" is used to mean an item has a dedicated, keyboard-
-	// driven key combo, so that it behaves much like a button.
-	colorScheme = struct {
-		backgroundPrimary   tcell.Color // main background color
-		backgroundSecondary tcell.Color // background color of modal windows
-		backgroundTertiary  tcell.Color // background of dropdown menus, etc.
-		inactiveText        tcell.Color // non-interactive info, secondary or unfocused
-		activeText          tcell.Color // non-interactive info, primary or focused
-		inactiveMenuText    tcell.Color // unselected interactive text
-		activeMenuText      tcell.Color // selected interactive text
-		activeBorder        tcell.Color // border of active/modal views
-		highlightPrimary    tcell.Color // active selections and prominent indicators
-		highlightSecondary  tcell.Color // dynamic persistent status info
-		highlightTertiary   tcell.Color // dynamic temporary status info
-	}{
-		backgroundPrimary:   tcell.ColorBlack,
-		backgroundSecondary: tcell.ColorDarkSlateGray,
-		backgroundTertiary:  tcell.ColorSkyblue,
-		inactiveText:        tcell.ColorDarkSlateGray,
-		activeText:          tcell.ColorWhiteSmoke,
-		inactiveMenuText:    tcell.ColorSkyblue,
-		activeMenuText:      tcell.ColorDodgerBlue,
-		activeBorder:        tcell.ColorSkyblue,
-		highlightPrimary:    tcell.ColorDarkOrange,
-		highlightSecondary:  tcell.ColorDodgerBlue,
-		highlightTertiary:   tcell.ColorGreenYellow,
-	}
-)
-
-// function init() offers an early opportunity to override some of the constants
-// defined in external libs like tview.
-func init() {
-	// color overrides for the primitives initialized by tview.
-	tview.Styles.ContrastBackgroundColor = colorScheme.backgroundSecondary
-	tview.Styles.MoreContrastBackgroundColor = colorScheme.backgroundTertiary
-	tview.Styles.BorderColor = colorScheme.activeText
-	tview.Styles.PrimaryTextColor = colorScheme.activeText
-}
-
-// type TUI holds the high level components of the terminal user interface
-// as well as the main tview runtime API object tview.Application.
-type TUI struct {
-	app    *tview.Application
-	option *Options
-	lib    []*Library
-	busy   *BusyState
-}
-
-// function newLayout() creates the initial layout of the user interface and
-// populates it with the primary widgets. each Library passed in as argument
-// has its Layout field initialized with this instance.
-func newTUI(opt *Options, busy *BusyState, lib ...*Library) *TUI {
-
-	// declare the instance early so that it can be passed in to other objects
-	// that need a reference before returning ourself. note that it is declared
-	// as a pointer so that we don't risk creating a new instance between here
-	// and the return -- always dereference for initialization of values and be
-	// careful to retain the reference itself.
-	var tui *TUI = &TUI{}
-
-	app := tview.NewApplication()
-
-	*tui = TUI{
-		app:    app,
-		option: opt,
-		lib:    lib,
-		busy:   busy,
-	}
-
-	return tui
-}
-
-// function show() is the main draw cycle. it uses a dynamic refresh rate for a
-// lighter CPU load when idle and better responsiveness when busy.
-func (t *TUI) show() *ReturnCode {
-
-	return nil
-}